@@ -0,0 +1,119 @@
+package fido
+
+import (
+	"math"
+	"time"
+)
+
+// AccessOp is the kind of operation in an AccessEvent.
+type AccessOp uint8
+
+// Access log operations.
+const (
+	AccessGet   AccessOp = iota // Get; Tier reports the outcome
+	AccessSet                   // Set, SetTTL, SetAsync, or SetAsyncTTL
+	AccessEvict                 // entry evicted from memory by capacity pressure
+)
+
+var accessOpNames = [...]string{"get", "set", "evict"}
+
+func (op AccessOp) String() string {
+	if int(op) < len(accessOpNames) {
+		return accessOpNames[op]
+	}
+	return "unknown"
+}
+
+// AccessEvent describes one sampled cache operation. Keys are reported only by
+// hash, so logs can leave the process without exposing key contents.
+type AccessEvent struct {
+	Time    time.Time
+	KeyHash uint64
+	Latency time.Duration // zero for evictions
+	Size    int           // value bytes, measured as for LargeObjects; 0 if unmeasurable or a miss
+	Op      AccessOp
+	// Tier is, for gets, the tier that served the value (TierMiss on a miss);
+	// for sets, where the write landed before returning: TierMemory for Cache,
+	// TierStore for TieredCache.SetTTL, TierPending for SetAsync.
+	Tier Tier
+}
+
+// AccessLog emits a sampled stream of get, set, and evict events to sink, for
+// offline policy simulation and capacity planning from production traffic.
+// Sampling is by key hash, so a sampled key has all of its operations logged
+// and a sampled trace replays like a smaller cache; rate is the fraction of
+// keys sampled, in (0, 1].
+//
+// sink is called synchronously, and for evictions while the cache lock is held:
+// it must be fast and must not call back into the cache. Typically it appends
+// to a buffered channel and drops events when full.
+func AccessLog(rate float64, sink func(AccessEvent)) Option {
+	return func(c *config) {
+		c.accessRate = rate
+		c.accessSink = sink
+	}
+}
+
+// accessLog samples and emits access events. A nil *accessLog logs nothing.
+type accessLog[K comparable, V any] struct {
+	sink      func(AccessEvent)
+	hash      func(K) uint64
+	sizer     func(V) int
+	threshold uint64 // keys whose hash is at most threshold are sampled
+}
+
+func newAccessLog[K comparable, V any](cfg *config, hash func(K) uint64) *accessLog[K, V] {
+	if cfg.accessSink == nil || cfg.accessRate <= 0 {
+		return nil
+	}
+	threshold := uint64(math.MaxUint64)
+	if cfg.accessRate < 1 {
+		threshold = uint64(cfg.accessRate * math.MaxUint64)
+	}
+	sizer, ok := cfg.sizer.(func(V) int)
+	if !ok {
+		sizer = defaultSizer[V]()
+	}
+	return &accessLog[K, V]{sink: cfg.accessSink, hash: hash, sizer: sizer, threshold: threshold}
+}
+
+// sample returns key's hash and whether its operations are logged.
+func (l *accessLog[K, V]) sample(key K) (uint64, bool) {
+	if l == nil {
+		return 0, false
+	}
+	h := l.hash(key)
+	return h, h <= l.threshold
+}
+
+func (l *accessLog[K, V]) size(value V) int {
+	if l.sizer == nil {
+		return 0
+	}
+	return l.sizer(value)
+}
+
+// emit sends an event for an operation on a sampled key that began at start.
+func (l *accessLog[K, V]) emit(op AccessOp, hash uint64, value V, tier Tier, start time.Time) {
+	now := time.Now()
+	ev := AccessEvent{Time: now, KeyHash: hash, Latency: now.Sub(start), Op: op, Tier: tier}
+	if op != AccessGet || tier != TierMiss {
+		ev.Size = l.size(value)
+	}
+	l.sink(ev)
+}
+
+// evicted logs a memory eviction. Called under the s3fifo lock.
+func (l *accessLog[K, V]) evicted(_ K, hash uint64, value V, _ uint32) {
+	if hash > l.threshold {
+		return
+	}
+	l.sink(AccessEvent{Time: time.Now(), KeyHash: hash, Size: l.size(value), Op: AccessEvict})
+}
+
+// attach logs s's capacity evictions.
+func (l *accessLog[K, V]) attach(s *s3fifo[K, V]) {
+	if l != nil {
+		s.onDrop = l.evicted
+	}
+}
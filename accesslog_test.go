@@ -0,0 +1,136 @@
+package fido
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// eventLog collects access events for tests.
+type eventLog struct {
+	mu     sync.Mutex
+	events []AccessEvent
+}
+
+func (l *eventLog) sink(ev AccessEvent) {
+	l.mu.Lock()
+	l.events = append(l.events, ev)
+	l.mu.Unlock()
+}
+
+func (l *eventLog) ops() []AccessEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]AccessEvent(nil), l.events...)
+}
+
+func TestCache_AccessLog(t *testing.T) {
+	var log eventLog
+	cache := New[string, string](Size(100), AccessLog(1, log.sink))
+
+	cache.Set("k", "hello")
+	cache.Get("k")
+	cache.Get("missing")
+
+	evs := log.ops()
+	if len(evs) != 3 {
+		t.Fatalf("events = %+v; want 3", evs)
+	}
+	h := cache.memory.hasher("k")
+	if evs[0].Op != AccessSet || evs[0].KeyHash != h || evs[0].Size != 5 || evs[0].Tier != TierMemory {
+		t.Errorf("set event = %+v", evs[0])
+	}
+	if evs[1].Op != AccessGet || evs[1].KeyHash != h || evs[1].Size != 5 || evs[1].Tier != TierMemory {
+		t.Errorf("get event = %+v", evs[1])
+	}
+	if evs[2].Op != AccessGet || evs[2].Tier != TierMiss || evs[2].Size != 0 {
+		t.Errorf("miss event = %+v", evs[2])
+	}
+	if evs[1].Time.IsZero() || evs[1].Latency < 0 {
+		t.Errorf("get event time/latency = %v, %v", evs[1].Time, evs[1].Latency)
+	}
+}
+
+func TestCache_AccessLog_Evictions(t *testing.T) {
+	var log eventLog
+	cache := New[int, int](Size(100), AccessLog(1, log.sink))
+	for i := range 1000 {
+		cache.Set(i, i)
+	}
+	evicts := 0
+	for _, ev := range log.ops() {
+		if ev.Op == AccessEvict {
+			evicts++
+		}
+	}
+	if evicts < 800 {
+		t.Errorf("evict events = %d; want most of the 900 overflow entries", evicts)
+	}
+}
+
+func TestAccessLog_SamplesByKey(t *testing.T) {
+	var log eventLog
+	cache := New[int, int](Size(10000), AccessLog(0.25, log.sink))
+	for i := range 4000 {
+		cache.Set(i, i)
+		cache.Get(i)
+	}
+
+	perKey := map[uint64]int{}
+	for _, ev := range log.ops() {
+		perKey[ev.KeyHash]++
+	}
+	if n := len(perKey); n < 700 || n > 1300 {
+		t.Errorf("sampled keys = %d of 4000; want about 1000", n)
+	}
+	for h, n := range perKey {
+		if n != 2 {
+			t.Fatalf("key %x logged %d events; want both its set and get", h, n)
+		}
+	}
+}
+
+func TestTieredCache_AccessLog(t *testing.T) {
+	ctx := context.Background()
+	var log eventLog
+	store := newMockStore[string, int]()
+	_ = store.Set(ctx, "persisted", 1, time.Time{}) //nolint:errcheck // Test fixture
+
+	cache, err := NewTiered[string, int](store, AccessLog(1, log.sink), Sizer(func(int) int { return 8 }))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	cache.Get(ctx, "persisted") //nolint:errcheck // logging only
+	cache.Get(ctx, "persisted") //nolint:errcheck // logging only
+	if err := cache.Set(ctx, "k", 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.Fetch(ctx, "missing", func(context.Context) (int, error) { return 3, nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []Tier
+	for _, ev := range log.ops() {
+		got = append(got, ev.Tier)
+	}
+	want := []Tier{TierStore, TierMemory, TierStore, TierMiss}
+	if len(got) != len(want) {
+		t.Fatalf("tiers = %v; want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("tiers = %v; want %v", got, want)
+			break
+		}
+	}
+	if evs := log.ops(); evs[2].Op != AccessSet || evs[2].Size != 8 {
+		t.Errorf("set event = %+v; want size 8 from Sizer", evs[2])
+	}
+
+	if _, err := NewTiered[string, int](store, AccessLog(2, log.sink)); err == nil {
+		t.Error("AccessLog rate above 1 should be rejected")
+	}
+}
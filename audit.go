@@ -0,0 +1,128 @@
+package fido
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// auditLogSize is how many administrative operations AuditLog keeps.
+// They are rare, so the log is always on.
+const auditLogSize = 64
+
+// AdminOp is one administrative operation, such as a Flush, recorded for
+// AuditLog.
+type AdminOp struct {
+	Time      time.Time
+	Op        string        // method name: Flush, FlushMemory, FlushPersist, BumpEpoch, DeleteFunc, FlushOwner, CleanupOwner, or Cleanup
+	Removed   int           // entries removed; -1 if not counted, as by BumpEpoch
+	Persisted int           // of Removed, entries removed from persistence by Flush
+	Duration  time.Duration // how long the operation took
+	Caller    string        // file:line that called the cache; empty for AutoCleanup
+	Owner     string        // owner from the caller's context (WithOwner); empty if none
+	Target    string        // owner acted on by FlushOwner and CleanupOwner
+	Err       error         // nil if the operation succeeded
+}
+
+func (o AdminOp) String() string {
+	s := fmt.Sprintf("%s %s removed=%d duration=%v", o.Time.Format(time.RFC3339Nano), o.Op, o.Removed, o.Duration)
+	if o.Op == "Flush" {
+		s += fmt.Sprintf(" memory=%d persisted=%d", o.Removed-o.Persisted, o.Persisted)
+	}
+	if o.Caller != "" {
+		s += " caller=" + o.Caller
+	}
+	if o.Owner != "" {
+		s += " owner=" + o.Owner
+	}
+	if o.Target != "" {
+		s += " target=" + o.Target
+	}
+	if o.Err != nil {
+		s += " error=" + o.Err.Error()
+	}
+	return s
+}
+
+// auditLog holds the last administrative operations, overwriting the oldest.
+// The zero value is empty and ready to use; the ring is allocated on first use.
+type auditLog struct {
+	mu  sync.Mutex
+	ops []AdminOp
+	seq uint64 // operations recorded
+}
+
+// record logs op, started at op.Time by the cache method that calls record,
+// filling in its duration, the method's caller, and the owner from ctx.
+func (a *auditLog) record(ctx context.Context, op AdminOp) {
+	op.Duration = time.Since(op.Time)
+	if _, file, line, ok := runtime.Caller(2); ok {
+		op.Caller = fmt.Sprintf("%s:%d", filepath.Base(file), line)
+	}
+	op.Owner = ownerOf(ctx)
+	a.add(op)
+}
+
+// add logs op and keeps it for snapshot.
+func (a *auditLog) add(op AdminOp) {
+	attrs := []any{"op", op.Op, "removed", op.Removed, "duration", op.Duration}
+	if op.Op == "Flush" {
+		attrs = append(attrs, "memory", op.Removed-op.Persisted, "persisted", op.Persisted)
+	}
+	if op.Caller != "" {
+		attrs = append(attrs, "caller", op.Caller)
+	}
+	if op.Owner != "" {
+		attrs = append(attrs, "owner", op.Owner)
+	}
+	if op.Target != "" {
+		attrs = append(attrs, "target", op.Target)
+	}
+	if op.Err != nil {
+		slog.Warn("cache admin operation failed", append(attrs, "error", op.Err)...)
+	} else {
+		slog.Info("cache admin operation", attrs...)
+	}
+
+	a.mu.Lock()
+	if a.ops == nil {
+		a.ops = make([]AdminOp, auditLogSize)
+	}
+	a.ops[a.seq%auditLogSize] = op
+	a.seq++
+	a.mu.Unlock()
+}
+
+// snapshot returns the recorded operations, oldest first.
+func (a *auditLog) snapshot() []AdminOp {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	n := min(a.seq, uint64(len(a.ops)))
+	out := make([]AdminOp, 0, n)
+	for i := a.seq - n; i < a.seq; i++ {
+		out = append(out, a.ops[i%auditLogSize])
+	}
+	return out
+}
+
+// AuditLog returns the cache's last 64 administrative operations (Flush,
+// BumpEpoch, and DeleteFunc), oldest first, with when each ran, who called
+// it, and how many entries it removed, for finding out after an incident what
+// wiped the cache. Each is also logged with slog as it happens, and prints as
+// one line.
+func (c *Cache[K, V]) AuditLog() []AdminOp {
+	return c.audit.snapshot()
+}
+
+// AuditLog returns the cache's last 64 administrative operations, as
+// Cache.AuditLog: Flush, FlushMemory, FlushPersist, BumpEpoch, DeleteFunc,
+// FlushOwner, CleanupOwner, and AutoCleanup rounds that removed entries or
+// failed. Operations on Store directly, or by other processes sharing it, are
+// not recorded.
+func (c *TieredCache[K, V]) AuditLog() []AdminOp {
+	return c.audit.snapshot()
+}
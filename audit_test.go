@@ -0,0 +1,121 @@
+package fido
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCache_AuditLog(t *testing.T) {
+	cache := New[string, int]()
+	if ops := cache.AuditLog(); len(ops) != 0 {
+		t.Fatalf("AuditLog = %v; want empty", ops)
+	}
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.DeleteFunc(func(k string, _ int) bool { return k == "a" })
+	cache.Flush()
+	cache.BumpEpoch()
+
+	ops := cache.AuditLog()
+	want := []struct {
+		op      string
+		removed int
+	}{{"DeleteFunc", 1}, {"Flush", 1}, {"BumpEpoch", -1}}
+	if len(ops) != len(want) {
+		t.Fatalf("AuditLog has %d ops; want %d: %v", len(ops), len(want), ops)
+	}
+	for i, w := range want {
+		if ops[i].Op != w.op || ops[i].Removed != w.removed {
+			t.Errorf("op %d = %s removed=%d; want %s removed=%d", i, ops[i].Op, ops[i].Removed, w.op, w.removed)
+		}
+		if !strings.HasPrefix(ops[i].Caller, "audit_test.go:") {
+			t.Errorf("op %d caller = %q; want this test file", i, ops[i].Caller)
+		}
+		if ops[i].Time.IsZero() {
+			t.Errorf("op %d has no time", i)
+		}
+	}
+}
+
+func TestCache_AuditLog_Wraps(t *testing.T) {
+	cache := New[string, int]()
+	for range auditLogSize + 6 {
+		cache.Flush()
+	}
+	cache.Set("k", 1)
+	cache.Flush()
+	ops := cache.AuditLog()
+	if len(ops) != auditLogSize {
+		t.Fatalf("AuditLog has %d ops; want %d", len(ops), auditLogSize)
+	}
+	if last := ops[len(ops)-1]; last.Removed != 1 {
+		t.Errorf("last op removed %d; want the newest Flush's 1", last.Removed)
+	}
+}
+
+func TestTieredCache_AuditLog(t *testing.T) {
+	store := newOwnedStore()
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	ctx := context.Background()
+	admin := WithOwner(ctx, "sre")
+	if err := cache.Set(WithOwner(ctx, "team-a"), "a", 1); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, err := cache.FlushOwner(admin, "team-a"); err != nil {
+		t.Fatalf("FlushOwner: %v", err)
+	}
+	if err := cache.Set(ctx, "b", 2); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, err := cache.Flush(admin); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if err := cache.BumpEpoch(ctx); err != nil {
+		t.Fatalf("BumpEpoch: %v", err)
+	}
+
+	ops := cache.AuditLog()
+	if len(ops) != 3 {
+		t.Fatalf("AuditLog = %v; want FlushOwner, Flush, BumpEpoch", ops)
+	}
+	if o := ops[0]; o.Op != "FlushOwner" || o.Removed != 1 || o.Owner != "sre" || o.Target != "team-a" {
+		t.Errorf("ops[0] = %v; want FlushOwner of team-a by sre removing 1", o)
+	}
+	// Flush counts each tier's copy, and is recorded once rather than per tier.
+	if o := ops[1]; o.Op != "Flush" || o.Removed != 2 || o.Persisted != 1 || o.Owner != "sre" {
+		t.Errorf("ops[1] = %v; want Flush by sre removing 2, 1 of them persisted", o)
+	}
+	if s := ops[1].String(); !strings.Contains(s, "Flush removed=2 ") || !strings.Contains(s, " memory=1 persisted=1") {
+		t.Errorf("String() = %q; want the per-tier counts", s)
+	}
+	if o := ops[2]; o.Op != "BumpEpoch" || o.Owner != "" || !strings.HasPrefix(o.Caller, "audit_test.go:") {
+		t.Errorf("ops[2] = %v; want BumpEpoch called from this file", o)
+	}
+	if s := ops[0].String(); !strings.Contains(s, "FlushOwner removed=1") || !strings.Contains(s, "target=team-a") {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func TestTieredCache_AuditLog_Cleanup(t *testing.T) {
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store, AutoCleanup(20*time.Millisecond, 0))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	if err := store.Set(context.Background(), "old", 1, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("store.Set: %v", err)
+	}
+	waitFor(t, func() bool { return len(cache.AuditLog()) > 0 })
+	if o := cache.AuditLog()[0]; o.Op != "Cleanup" || o.Removed != 1 || o.Caller != "" {
+		t.Errorf("AuditLog()[0] = %v; want a background Cleanup removing 1", o)
+	}
+}
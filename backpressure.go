@@ -0,0 +1,163 @@
+package fido
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrBackpressure is returned by SetAsync and DeleteAsync when AsyncQueue is
+// full and its policy refuses the write, and recorded with DeadLetter for
+// writes dropped by BackpressureDropOldest.
+var ErrBackpressure = errors.New("async write queue full")
+
+// Backpressure selects what SetAsync and DeleteAsync do when AsyncQueue is full.
+type Backpressure uint8
+
+// Backpressure policies.
+const (
+	BackpressureReject     Backpressure = iota // return ErrBackpressure at once
+	BackpressureBlock                          // wait for a slot, up to the timeout and ctx
+	BackpressureDropOldest                     // cancel the oldest write and take its slot
+)
+
+var backpressureNames = [...]string{"reject", "block", "drop-oldest"}
+
+func (b Backpressure) String() string {
+	if int(b) < len(backpressureNames) {
+		return backpressureNames[b]
+	}
+	return fmt.Sprintf("Backpressure(%d)", b)
+}
+
+// asyncTicket is an async write admitted to an asyncQueue, holding one of its
+// slots until release, or until it is dropped before its store call starts.
+type asyncTicket struct {
+	ctx     context.Context //nolint:containedctx // the write's context, cancelled if it is dropped
+	cancel  context.CancelFunc
+	elem    *list.Element // position in asyncQueue.order; nil unless droppable
+	dropped atomic.Bool
+	started atomic.Bool // set by the write as it is about to call the store
+	held    atomic.Bool // still holds its slot
+}
+
+// asyncQueue bounds the SetAsync and DeleteAsync writes admitted and not yet
+// finished, for AsyncQueue, and counts them for Stats. Without AsyncQueue it
+// only counts.
+//
+//nolint:govet // fieldalignment: semantic grouping preferred
+type asyncQueue struct {
+	slots    semaphore // nil when unbounded
+	policy   Backpressure
+	timeout  time.Duration
+	mu       sync.Mutex
+	order    *list.List // of *asyncTicket not yet dropped, oldest first; BackpressureDropOldest only
+	depth    atomic.Int64
+	rejected atomic.Uint64
+	dropped  atomic.Uint64
+}
+
+func newAsyncQueue(cfg *config) *asyncQueue {
+	q := &asyncQueue{slots: newSemaphore(cfg.asyncQueueSize), policy: cfg.backpressure, timeout: cfg.asyncQueueTimeout}
+	if q.slots != nil && q.policy == BackpressureDropOldest {
+		q.order = list.New()
+	}
+	return q
+}
+
+// admit takes a slot for a write requested under ctx, applying the policy if
+// there is none, and returns a ticket whose context the write runs under.
+func (q *asyncQueue) admit(ctx context.Context) (*asyncTicket, error) {
+	if err := q.take(ctx); err != nil {
+		q.rejected.Add(1)
+		return nil, err
+	}
+	q.depth.Add(1)
+	t := &asyncTicket{}
+	t.held.Store(true)
+	t.ctx, t.cancel = context.WithCancel(BackgroundLane(context.WithoutCancel(ctx)))
+	if q.order != nil {
+		q.mu.Lock()
+		t.elem = q.order.PushBack(t)
+		q.mu.Unlock()
+	}
+	return t, nil
+}
+
+// take acquires a slot, or returns an error wrapping ErrBackpressure as the
+// policy directs.
+func (q *asyncQueue) take(ctx context.Context) error {
+	if q.slots == nil {
+		return nil
+	}
+	select {
+	case q.slots <- struct{}{}:
+		return nil
+	default:
+	}
+	if q.policy == BackpressureReject {
+		return ErrBackpressure
+	}
+	if q.policy == BackpressureDropOldest {
+		q.dropOldest()
+	}
+	wait := ctx
+	if q.timeout > 0 {
+		var cancel context.CancelFunc
+		wait, cancel = context.WithTimeout(ctx, q.timeout)
+		defer cancel()
+	}
+	if err := q.slots.acquire(wait); err != nil {
+		return fmt.Errorf("%w: %w", ErrBackpressure, err)
+	}
+	return nil
+}
+
+// dropOldest cancels the oldest write not already dropped. Its slot frees at
+// once if the write has not started its store call, such as one still waiting
+// behind an earlier write to its key, and otherwise when that call returns.
+func (q *asyncQueue) dropOldest() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	e := q.order.Front()
+	if e == nil {
+		return // every write is already dropped and finishing
+	}
+	t := e.Value.(*asyncTicket) //nolint:errcheck,forcetypeassert // only *asyncTicket is stored
+	q.order.Remove(e)
+	t.elem = nil
+	t.dropped.Store(true)
+	t.cancel()
+	q.dropped.Add(1)
+	// Checked after cancel: a write that starts from here on sees its context
+	// done and skips the store call.
+	if !t.started.Load() {
+		q.free(t)
+	}
+}
+
+// release frees t's slot once its write has finished.
+func (q *asyncQueue) release(t *asyncTicket) {
+	if q.order != nil {
+		q.mu.Lock()
+		if t.elem != nil {
+			q.order.Remove(t.elem)
+			t.elem = nil
+		}
+		q.mu.Unlock()
+	}
+	t.cancel()
+	q.free(t)
+}
+
+// free returns t's slot unless it has already been returned.
+func (q *asyncQueue) free(t *asyncTicket) {
+	if t.held.Swap(false) {
+		q.depth.Add(-1)
+		q.slots.release()
+	}
+}
@@ -0,0 +1,197 @@
+package fido
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stallStore holds each Set until gate closes or its context ends.
+type stallStore struct {
+	*mockStore[string, int]
+	gate    chan struct{}
+	writing atomic.Int32
+	deaf    bool // ignore the context, like a call already on the wire
+}
+
+func (s *stallStore) Set(ctx context.Context, key string, value int, expiry time.Time) error {
+	s.writing.Add(1)
+	defer s.writing.Add(-1)
+	done := ctx.Done()
+	if s.deaf {
+		done = nil
+	}
+	select {
+	case <-s.gate:
+	case <-done:
+		return ctx.Err()
+	}
+	return s.mockStore.Set(ctx, key, value, expiry)
+}
+
+func newStallStore() *stallStore {
+	return &stallStore{mockStore: newMockStore[string, int](), gate: make(chan struct{})}
+}
+
+func TestTieredCache_AsyncQueue_Reject(t *testing.T) {
+	ctx := context.Background()
+	store := newStallStore()
+	cache, err := NewTiered[string, int](store, AsyncQueue(2, BackpressureReject, 0))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+	if cfg := cache.Config(); cfg.AsyncQueueSize != 2 || cfg.Backpressure != BackpressureReject {
+		t.Errorf("Config() = %d, %v; want 2, reject", cfg.AsyncQueueSize, cfg.Backpressure)
+	}
+
+	for _, k := range []string{"a", "b"} {
+		if err := cache.SetAsync(ctx, k, 1); err != nil {
+			t.Fatalf("SetAsync(%s): %v", k, err)
+		}
+	}
+	waitFor(t, func() bool { return store.writing.Load() == 2 })
+
+	if err := cache.SetAsync(ctx, "c", 3); !errors.Is(err, ErrBackpressure) {
+		t.Errorf("SetAsync with the queue full = %v; want ErrBackpressure", err)
+	}
+	if _, found, _ := cache.Get(ctx, "c"); found { //nolint:errcheck // only checking presence
+		t.Error("a refused SetAsync stored its value")
+	}
+	if err := cache.DeleteAsync(ctx, "a"); !errors.Is(err, ErrBackpressure) {
+		t.Errorf("DeleteAsync with the queue full = %v; want ErrBackpressure", err)
+	}
+	if _, found, _ := cache.Get(ctx, "a"); !found { //nolint:errcheck // only checking presence
+		t.Error("a refused DeleteAsync removed the key")
+	}
+	if s := cache.Stats(); s.AsyncQueued != 2 || s.AsyncRejected != 2 || s.AsyncDropped != 0 {
+		t.Errorf("Stats() queued=%d rejected=%d dropped=%d; want 2, 2, 0", s.AsyncQueued, s.AsyncRejected, s.AsyncDropped)
+	}
+
+	close(store.gate)
+	waitFor(t, func() bool { return cache.Stats().AsyncQueued == 0 })
+	if err := cache.SetAsync(ctx, "c", 3); err != nil {
+		t.Errorf("SetAsync once the queue drained: %v", err)
+	}
+}
+
+func TestTieredCache_AsyncQueue_Block(t *testing.T) {
+	ctx := context.Background()
+	store := newStallStore()
+	cache, err := NewTiered[string, int](store, AsyncQueue(1, BackpressureBlock, time.Minute))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	if err := cache.SetAsync(ctx, "a", 1); err != nil {
+		t.Fatalf("SetAsync: %v", err)
+	}
+	waitFor(t, func() bool { return store.writing.Load() == 1 })
+
+	// The caller's context bounds the wait as well as the queue's timeout.
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	err = cache.SetAsync(short, "b", 2)
+	if !errors.Is(err, ErrBackpressure) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("SetAsync past its deadline = %v; want ErrBackpressure and DeadlineExceeded", err)
+	}
+
+	result := make(chan error, 1)
+	go func() { result <- cache.SetAsync(ctx, "c", 3) }()
+	select {
+	case err := <-result:
+		t.Fatalf("SetAsync returned %v while the queue was full; want it to wait", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(store.gate)
+	if err := <-result; err != nil {
+		t.Errorf("SetAsync once a slot freed: %v", err)
+	}
+	waitFor(t, func() bool { return cache.Stats().AsyncQueued == 0 })
+	if _, _, found, _ := store.Get(ctx, "c"); !found { //nolint:errcheck // only checking presence
+		t.Error("the blocked write never reached the store")
+	}
+}
+
+func TestTieredCache_AsyncQueue_DropOldest(t *testing.T) {
+	ctx := context.Background()
+	store := newStallStore()
+	cache, err := NewTiered[string, int](store, AsyncQueue(2, BackpressureDropOldest, 0), DeadLetter(10))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	for i, k := range []string{"a", "b"} {
+		if err := cache.SetAsync(ctx, k, i); err != nil {
+			t.Fatalf("SetAsync(%s): %v", k, err)
+		}
+	}
+	waitFor(t, func() bool { return store.writing.Load() == 2 })
+
+	if err := cache.SetAsync(ctx, "c", 2); err != nil {
+		t.Fatalf("SetAsync with the queue full: %v", err)
+	}
+	if s := cache.Stats(); s.AsyncDropped != 1 || s.AsyncQueued != 2 {
+		t.Errorf("Stats() dropped=%d queued=%d; want 1, 2", s.AsyncDropped, s.AsyncQueued)
+	}
+	if _, found, _ := cache.Get(ctx, "a"); !found { //nolint:errcheck // only checking presence
+		t.Error("the dropped write's value left memory")
+	}
+
+	close(store.gate)
+	waitFor(t, func() bool { return cache.Stats().AsyncQueued == 0 })
+	failed := cache.FailedWrites()
+	if len(failed) != 1 || failed[0].Key != "a" || !errors.Is(failed[0].Err, ErrBackpressure) {
+		t.Fatalf("FailedWrites() = %v; want a, dropped with ErrBackpressure", failed)
+	}
+	for k, want := range map[string]bool{"a": false, "b": true, "c": true} {
+		if _, _, found, _ := store.Get(ctx, k); found != want { //nolint:errcheck // only checking presence
+			t.Errorf("store has %s = %v; want %v", k, found, want)
+		}
+	}
+}
+
+func TestTieredCache_AsyncQueue_DropQueuedSameKey(t *testing.T) {
+	ctx := context.Background()
+	store := newStallStore()
+	store.deaf = true
+	cache, err := NewTiered[string, int](store, AsyncQueue(2, BackpressureDropOldest, 50*time.Millisecond), DeadLetter(10))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	// The second write to a waits behind the first, which is in the store.
+	for v := range 2 {
+		if err := cache.SetAsync(ctx, "a", v); err != nil {
+			t.Fatalf("SetAsync(a, %d): %v", v, err)
+		}
+	}
+	waitFor(t, func() bool { return store.writing.Load() == 1 })
+
+	// Dropping the write in the store frees nothing until the call returns.
+	if err := cache.SetAsync(ctx, "b", 1); !errors.Is(err, ErrBackpressure) {
+		t.Fatalf("SetAsync(b) = %v; want ErrBackpressure while the dropped write is in the store", err)
+	}
+	// Dropping the queued write frees its slot at once.
+	if err := cache.SetAsync(ctx, "c", 1); err != nil {
+		t.Fatalf("SetAsync(c) after dropping a queued write: %v", err)
+	}
+	if s := cache.Stats(); s.AsyncDropped != 2 || s.AsyncQueued != 2 {
+		t.Errorf("Stats() dropped=%d queued=%d; want 2, 2", s.AsyncDropped, s.AsyncQueued)
+	}
+
+	close(store.gate)
+	waitFor(t, func() bool { return cache.Stats().AsyncQueued == 0 })
+	waitFor(t, func() bool { return len(cache.FailedWrites()) == 1 })
+	if failed := cache.FailedWrites(); failed[0].Key != "a" || failed[0].Value != 1 || !errors.Is(failed[0].Err, ErrBackpressure) {
+		t.Errorf("FailedWrites() = %v; want a=1, dropped with ErrBackpressure", failed)
+	}
+	if _, _, found, _ := store.Get(ctx, "c"); !found { //nolint:errcheck // only checking presence
+		t.Error("the write admitted in the freed slot never reached the store")
+	}
+}
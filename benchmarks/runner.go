@@ -6,6 +6,12 @@
 //
 //	go run benchmarks/runner.go                  # solo fido, validate hitrate
 //	go run benchmarks/runner.go -competitive    # gold medalists, track rankings
+//	go run benchmarks/runner.go -report out/bench  # also write fido_report.md and .json
+//	go run benchmarks/runner.go -sweep          # grid of tuning knobs, Pareto frontier
+//	go run benchmarks/runner.go -sweep -grid 'maxFreq=3,5,7;ghostFPRate=0.0001,0.00001'
+//
+// Deltas are computed against benchmarks/gocachemark_results.json, the
+// committed results, or the file given by -baseline.
 //
 // Environment variables:
 //
@@ -16,14 +22,23 @@ package main
 
 import (
 	"bufio"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"slices"
 	"strings"
+	"time"
+
+	"github.com/codeGROOVE-dev/fido"
 )
 
 // hitrateGoals are the minimum acceptable averages across all cache sizes.
@@ -78,6 +93,10 @@ const (
 
 func main() {
 	competitive := flag.Bool("competitive", false, "Run competitive benchmark with gold medalists")
+	baseline := flag.String("baseline", "", "Results file to compare against (default benchmarks/gocachemark_results.json)")
+	reportDir := flag.String("report", "", "Directory to write fido_report.md and fido_report.json to")
+	sweep := flag.Bool("sweep", false, "Run hit rate and memory benchmarks across a grid of tuning knobs")
+	grid := flag.String("grid", "", "Sweep grid as knob=v1,v2;knob=v1 (default: every knob's built-in values)")
 	flag.Parse()
 
 	// Find fido root (where we're running from).
@@ -100,19 +119,37 @@ func main() {
 		fatal("updating go.mod replace: %v", err)
 	}
 
+	if *sweep {
+		knobs, err := parseGrid(*grid)
+		if err != nil {
+			fatal("parsing grid: %v", err)
+		}
+		if err := runSweep(fidoDir, gocachemarkDir, knobs, os.Getenv("TESTS"), os.Getenv("SIZES"), *reportDir); err != nil {
+			fatal("sweep: %v", err)
+		}
+		return
+	}
+
 	// Prepare output directory for results.
 	benchmarksDir := filepath.Join(fidoDir, "benchmarks")
 
-	// Load reference results for comparison.
-	ref, _ := loadResults(filepath.Join(benchmarksDir, "gocachemark_results.json"))
+	// Load reference results for comparison: the committed results unless
+	// -baseline names another file.
+	baselinePath := *baseline
+	if baselinePath == "" {
+		baselinePath = filepath.Join(benchmarksDir, "gocachemark_results.json")
+	}
+	ref, err := loadResults(baselinePath)
+	if err != nil && *baseline != "" {
+		fatal("loading baseline: %v", err)
+	}
 
 	// Build gocachemark arguments.
-	args := []string{"run", "."}
+	caches := "fido"
 	if *competitive {
-		args = append(args, "-caches", goldMedalists)
-	} else {
-		args = append(args, "-caches", "fido")
+		caches = goldMedalists
 	}
+	args := []string{"run", ".", "-caches", caches}
 
 	// Always use temp directory for output first.
 	outdir, err := os.MkdirTemp("", "gocachemark-")
@@ -151,23 +188,54 @@ func main() {
 	}
 
 	// Show deltas against reference.
+	metrics := compareMetrics(ref, results)
 	fmt.Println()
 	if ref != nil {
-		showDeltas(ref, results)
+		showDeltas(metrics)
 	}
 
-	// Validate results.
-	if err := validateHitrate(results); err != nil {
-		fatal("%v", err)
+	// Validate results, writing the report before failing so a regression
+	// still leaves an artifact to attach.
+	failures := []error{validateHitrate(results), validateSuiteGoals(results)}
+	if *competitive {
+		failures = append(failures, validateCompetitive(results, ref, testsFilter, suitesFilter))
 	}
-	if err := validateSuiteGoals(results); err != nil {
+	if *reportDir != "" {
+		r := Report{
+			Timestamp: time.Now().Format(time.RFC3339),
+			Fingerprint: fingerprint(fidoDir, gocachemarkDir, results, runFilters{
+				Caches: caches, Tests: testsFilter, Suites: suitesFilter, Sizes: sizesFilter,
+			}),
+			Metrics: metrics,
+			Passed:  errors.Join(failures...) == nil,
+		}
+		if ref != nil {
+			r.Baseline = &BaselineInfo{
+				Path:        baselinePath,
+				Timestamp:   ref.Timestamp,
+				MachineInfo: ref.MachineInfo,
+				SameMachine: ref.MachineInfo.sameAs(results.MachineInfo),
+			}
+		}
+		if *competitive {
+			r.Rankings = results.Rankings
+		}
+		for _, err := range failures {
+			if err != nil {
+				r.Failures = append(r.Failures, err.Error())
+			}
+		}
+		if err := writeReport(*reportDir, &r); err != nil {
+			fatal("writing report: %v", err)
+		}
+		fmt.Printf("\nReport written to %s/\n", *reportDir)
+	}
+	if err := errors.Join(failures...); err != nil {
 		fatal("%v", err)
 	}
+
+	// Only save results if all tests were run (no filters).
 	if *competitive {
-		if err := validateCompetitive(results, ref, testsFilter, suitesFilter); err != nil {
-			fatal("%v", err)
-		}
-		// Only save results if all tests were run (no filters).
 		if fullRun {
 			if err := copyResults(outdir, benchmarksDir); err != nil {
 				fatal("saving results: %v", err)
@@ -283,12 +351,27 @@ func runGocachemark(dir string, args []string, outdir string) (*Results, error)
 
 // Results represents gocachemark JSON output.
 type Results struct {
-	HitRate    map[string]json.RawMessage `json:"hitRate"`
-	Latency    map[string]json.RawMessage `json:"latency"`
-	Throughput map[string]json.RawMessage `json:"throughput"`
-	Memory     *MemoryResults             `json:"memory"`
-	Rankings   []RankEntry                `json:"rankings"`
-	MedalTable MedalTable                 `json:"medalTable"`
+	Timestamp   string                     `json:"timestamp"`
+	MachineInfo MachineInfo                `json:"machineInfo"`
+	HitRate     map[string]json.RawMessage `json:"hitRate"`
+	Latency     map[string]json.RawMessage `json:"latency"`
+	Throughput  map[string]json.RawMessage `json:"throughput"`
+	Memory      *MemoryResults             `json:"memory"`
+	Rankings    []RankEntry                `json:"rankings"`
+	MedalTable  MedalTable                 `json:"medalTable"`
+}
+
+// MachineInfo describes where gocachemark ran.
+type MachineInfo struct {
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	GoVersion string `json:"goVersion"`
+	NumCPU    int    `json:"numCpu"`
+}
+
+// sameAs reports whether latency and throughput measured on m and o are comparable.
+func (m MachineInfo) sameAs(o MachineInfo) bool {
+	return m.OS == o.OS && m.Arch == o.Arch && m.NumCPU == o.NumCPU
 }
 
 type MemoryResults struct {
@@ -421,21 +504,7 @@ func validateSuiteGoals(res *Results) error {
 	var fails []string
 
 	// Hit rate average.
-	var hitRates []float64
-	for name := range res.HitRate {
-		if name == "sizes" {
-			continue
-		}
-		caches, err := res.hitRateResults(name)
-		if err != nil {
-			continue
-		}
-		if rate := findHitRate(caches, "fido"); rate > 0 {
-			hitRates = append(hitRates, rate)
-		}
-	}
-	if len(hitRates) > 0 {
-		avg := sum(hitRates) / float64(len(hitRates))
+	if avg, ok := avgHitRate(res, "fido"); ok {
 		if avg >= suiteGoals.minHitRate {
 			fmt.Printf("✓ hitrate avg: %.2f%% (goal: ≥%.2f%%)\n", avg, suiteGoals.minHitRate)
 		} else {
@@ -465,6 +534,27 @@ func validateSuiteGoals(res *Results) error {
 	return nil
 }
 
+// avgHitRate returns cache's hit rate averaged across the hit rate tests.
+func avgHitRate(res *Results, cache string) (float64, bool) {
+	var hitRates []float64
+	for name := range res.HitRate {
+		if name == "sizes" {
+			continue
+		}
+		caches, err := res.hitRateResults(name)
+		if err != nil {
+			continue
+		}
+		if rate := findHitRate(caches, cache); rate > 0 {
+			hitRates = append(hitRates, rate)
+		}
+	}
+	if len(hitRates) == 0 {
+		return 0, false
+	}
+	return sum(hitRates) / float64(len(hitRates)), true
+}
+
 func sum(vals []float64) float64 {
 	var total float64
 	for _, v := range vals {
@@ -473,35 +563,64 @@ func sum(vals []float64) float64 {
 	return total
 }
 
-func showDeltas(ref, curr *Results) {
-	fmt.Println("=== Deltas vs Reference ===")
-	var any bool
+// Metric is one of fido's results, with its change from the baseline.
+type Metric struct {
+	Suite          string  `json:"suite"` // hitrate, latency, throughput, or memory
+	Name           string  `json:"name"`
+	Unit           string  `json:"unit"` // %, ns/op, ops/s, or bytes/item
+	Value          float64 `json:"value"`
+	Baseline       float64 `json:"baseline,omitempty"` // zero when the baseline lacks it
+	HigherIsBetter bool    `json:"higherIsBetter"`
+}
 
-	// Hit rate deltas (higher is better).
+// Delta returns the change from the baseline, absolute and as a percentage.
+func (m Metric) Delta() (delta, pct float64) {
+	if m.Baseline == 0 {
+		return 0, 0
+	}
+	delta = m.Value - m.Baseline
+	return delta, delta / m.Baseline * 100
+}
+
+// format renders v in m's unit.
+func (m Metric) format(v float64) string {
+	switch m.Unit {
+	case "%":
+		return fmt.Sprintf("%.2f%%", v)
+	case "ns/op":
+		return fmt.Sprintf("%.1fns", v)
+	case "ops/s":
+		return fmt.Sprintf("%.2fM", v/1e6)
+	default:
+		return fmt.Sprintf("%.0f", v)
+	}
+}
+
+// compareMetrics extracts fido's results from curr, paired with the same
+// results from ref (which may be nil), sorted by suite and name.
+func compareMetrics(ref, curr *Results) []Metric {
+	if ref == nil {
+		ref = &Results{}
+	}
+	var out []Metric
+
+	// Hit rate (higher is better).
 	for name := range curr.HitRate {
 		if name == "sizes" {
 			continue
 		}
-		refCaches, err := ref.hitRateResults(name)
-		if err != nil {
-			continue
-		}
 		currCaches, err := curr.hitRateResults(name)
 		if err != nil {
 			continue
 		}
-		refVal := findHitRate(refCaches, "fido")
-		currVal := findHitRate(currCaches, "fido")
-		if refVal == 0 {
-			continue
+		refCaches, _ := ref.hitRateResults(name)
+		if v := findHitRate(currCaches, "fido"); v > 0 {
+			out = append(out, Metric{Suite: "hitrate", Name: name, Unit: "%", Value: v,
+				Baseline: findHitRate(refCaches, "fido"), HigherIsBetter: true})
 		}
-		delta := currVal - refVal
-		pct := delta / refVal * 100
-		any = true
-		fmt.Printf("  hitrate/%s: %.2f%% → %.2f%% (%+.2f, %+.1f%%)\n", name, refVal, currVal, delta, pct)
 	}
 
-	// Latency deltas (lower is better).
+	// Latency (lower is better).
 	for name := range curr.Latency {
 		var refResults, currResults []LatencyResult
 		if raw, ok := ref.Latency[name]; ok {
@@ -510,19 +629,13 @@ func showDeltas(ref, curr *Results) {
 		if raw, ok := curr.Latency[name]; ok {
 			json.Unmarshal(raw, &currResults)
 		}
-		refVal := findLatency(refResults, "fido")
-		currVal := findLatency(currResults, "fido")
-		if refVal == 0 {
-			continue
+		if v := findLatency(currResults, "fido"); v > 0 {
+			out = append(out, Metric{Suite: "latency", Name: name, Unit: "ns/op", Value: v,
+				Baseline: findLatency(refResults, "fido")})
 		}
-		delta := currVal - refVal
-		pct := delta / refVal * 100
-		any = true
-		// Negative delta is good for latency
-		fmt.Printf("  latency/%s: %.1fns → %.1fns (%+.1f, %+.1f%%)\n", name, refVal, currVal, delta, pct)
 	}
 
-	// Throughput deltas (higher is better).
+	// Throughput (higher is better).
 	for name := range curr.Throughput {
 		if name == "threads" {
 			continue
@@ -534,29 +647,40 @@ func showDeltas(ref, curr *Results) {
 		if raw, ok := curr.Throughput[name]; ok {
 			json.Unmarshal(raw, &currResults)
 		}
-		refVal := findThroughput(refResults, "fido")
-		currVal := findThroughput(currResults, "fido")
-		if refVal == 0 {
-			continue
+		if v := findThroughput(currResults, "fido"); v > 0 {
+			out = append(out, Metric{Suite: "throughput", Name: name, Unit: "ops/s", Value: v,
+				Baseline: findThroughput(refResults, "fido"), HigherIsBetter: true})
 		}
-		delta := currVal - refVal
-		pct := delta / refVal * 100
-		any = true
-		fmt.Printf("  throughput/%s: %.2fM → %.2fM (%+.2fM, %+.1f%%)\n", name, refVal/1e6, currVal/1e6, delta/1e6, pct)
 	}
 
-	// Memory delta (lower is better).
-	if ref.Memory != nil && curr.Memory != nil {
-		refVal := findMemory(ref.Memory.Results, "fido")
-		currVal := findMemory(curr.Memory.Results, "fido")
-		if refVal > 0 && currVal > 0 {
-			delta := currVal - refVal
-			pct := float64(delta) / float64(refVal) * 100
-			any = true
-			fmt.Printf("  memory/bytesPerItem: %d → %d (%+d, %+.1f%%)\n", refVal, currVal, delta, pct)
+	// Memory (lower is better).
+	if curr.Memory != nil {
+		if v := findMemory(curr.Memory.Results, "fido"); v > 0 {
+			m := Metric{Suite: "memory", Name: "bytesPerItem", Unit: "bytes/item", Value: float64(v)}
+			if ref.Memory != nil {
+				m.Baseline = float64(findMemory(ref.Memory.Results, "fido"))
+			}
+			out = append(out, m)
 		}
 	}
 
+	slices.SortFunc(out, func(a, b Metric) int {
+		return cmp.Or(cmp.Compare(a.Suite, b.Suite), cmp.Compare(a.Name, b.Name))
+	})
+	return out
+}
+
+func showDeltas(metrics []Metric) {
+	fmt.Println("=== Deltas vs Reference ===")
+	var any bool
+	for _, m := range metrics {
+		if m.Baseline == 0 {
+			continue
+		}
+		delta, pct := m.Delta()
+		any = true
+		fmt.Printf("  %s/%s: %s → %s (%+.2f, %+.1f%%)\n", m.Suite, m.Name, m.format(m.Baseline), m.format(m.Value), delta, pct)
+	}
 	if !any {
 		fmt.Println("  (no reference data)")
 	}
@@ -798,6 +922,424 @@ func buildPlacementMap(r *Results) map[string]map[string]placement {
 	return out
 }
 
+// Report is the machine-readable artifact written by -report.
+type Report struct {
+	Timestamp   string        `json:"timestamp"`
+	Fingerprint Fingerprint   `json:"fingerprint"`
+	Baseline    *BaselineInfo `json:"baseline,omitempty"`
+	Metrics     []Metric      `json:"metrics"`
+	Rankings    []RankEntry   `json:"rankings,omitempty"` // competitive runs only
+	Failures    []string      `json:"failures,omitempty"`
+	Passed      bool          `json:"passed"`
+}
+
+// BaselineInfo describes the results a report's deltas are computed against.
+type BaselineInfo struct {
+	Path        string      `json:"path"`
+	Timestamp   string      `json:"timestamp"`
+	MachineInfo MachineInfo `json:"machineInfo"`
+	SameMachine bool        `json:"sameMachine"` // whether latency and throughput deltas are meaningful
+}
+
+// runFilters are the gocachemark selections a run was made with.
+type runFilters struct {
+	Caches string `json:"caches"`
+	Tests  string `json:"tests,omitempty"`
+	Suites string `json:"suites,omitempty"`
+	Sizes  string `json:"sizes,omitempty"`
+}
+
+// Fingerprint identifies what a run measured and where. Hash covers
+// everything but the fido commit, so two reports with the same hash measured
+// the same workloads with the same tuning on the same kind of machine.
+type Fingerprint struct {
+	Hash              string                 `json:"hash"`
+	FidoCommit        string                 `json:"fidoCommit"`
+	FidoDirty         bool                   `json:"fidoDirty"` // uncommitted changes
+	GocachemarkCommit string                 `json:"gocachemarkCommit"`
+	MachineInfo       MachineInfo            `json:"machineInfo"`
+	Filters           runFilters             `json:"filters"`
+	Configs           map[string]fido.Config `json:"configs"` // fido's tuning at each benchmarked size
+}
+
+// fingerprint describes a run of gocachemark in gocachemarkDir against fidoDir.
+func fingerprint(fidoDir, gocachemarkDir string, res *Results, filters runFilters) Fingerprint {
+	fp := Fingerprint{MachineInfo: res.MachineInfo, Filters: filters, Configs: make(map[string]fido.Config)}
+	fp.FidoCommit, fp.FidoDirty = gitState(fidoDir)
+	fp.GocachemarkCommit, _ = gitState(gocachemarkDir)
+
+	var sizes []int
+	if raw, ok := res.HitRate["sizes"]; ok {
+		json.Unmarshal(raw, &sizes)
+	}
+	if res.Memory != nil && res.Memory.Capacity > 0 {
+		sizes = append(sizes, res.Memory.Capacity)
+	}
+	for _, n := range sizes {
+		fp.Configs[fmt.Sprint(n)] = fido.New[string, string](fido.Size(n)).Config()
+	}
+
+	hashed := fp
+	hashed.FidoCommit, hashed.FidoDirty = "", false
+	data, err := json.Marshal(hashed)
+	if err != nil {
+		fatal("fingerprinting: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	fp.Hash = hex.EncodeToString(sum[:8])
+	return fp
+}
+
+// gitState returns the HEAD commit of the checkout at dir and whether it has
+// uncommitted changes, or an empty commit if dir is not a git checkout.
+func gitState(dir string) (commit string, dirty bool) {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", false
+	}
+	status, err := exec.Command("git", "-C", dir, "status", "--porcelain").Output()
+	return strings.TrimSpace(string(out)), err == nil && len(strings.TrimSpace(string(status))) > 0
+}
+
+// writeReport writes r to dir as fido_report.json and a markdown summary,
+// fido_report.md, for attaching to pull requests.
+func writeReport(dir string, r *Report) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "fido_report.json"), append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "fido_report.md"), []byte(r.markdown()), 0644)
+}
+
+// markdown renders r as a summary: run details, a table of deltas, and the
+// outcome of validation.
+func (r *Report) markdown() string {
+	var b strings.Builder
+	fp := r.Fingerprint
+	mi := fp.MachineInfo
+
+	b.WriteString("# fido benchmark report\n\n")
+	commit := cmp.Or(fp.FidoCommit, "unknown")
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	fmt.Fprintf(&b, "- Commit: `%s`", commit)
+	if fp.FidoDirty {
+		b.WriteString(" with uncommitted changes")
+	}
+	fmt.Fprintf(&b, "\n- Machine: %s/%s, %d CPUs, %s\n", mi.OS, mi.Arch, mi.NumCPU, mi.GoVersion)
+	fmt.Fprintf(&b, "- Caches: %s\n", fp.Filters.Caches)
+	for _, f := range []struct{ name, val string }{{"TESTS", fp.Filters.Tests}, {"SUITES", fp.Filters.Suites}, {"SIZES", fp.Filters.Sizes}} {
+		if f.val != "" {
+			fmt.Fprintf(&b, "- %s: %s\n", f.name, f.val)
+		}
+	}
+	fmt.Fprintf(&b, "- Fingerprint: `%s`\n", fp.Hash)
+	if bl := r.Baseline; bl != nil {
+		fmt.Fprintf(&b, "- Baseline: `%s` from %s (%s/%s, %d CPUs)", bl.Path, bl.Timestamp, bl.MachineInfo.OS, bl.MachineInfo.Arch, bl.MachineInfo.NumCPU)
+		if !bl.SameMachine {
+			b.WriteString("; a different machine, so latency and throughput deltas are indicative only")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n| Benchmark | Baseline | Current | Change |\n|-----------|---------:|--------:|-------:|\n")
+	for _, m := range r.Metrics {
+		base, change := "–", "–"
+		if m.Baseline != 0 {
+			_, pct := m.Delta()
+			base = m.format(m.Baseline)
+			change = fmt.Sprintf("%+.1f%%", pct)
+			if better := pct > 0 == m.HigherIsBetter; pct != 0 && better {
+				change += " ✓"
+			}
+		}
+		fmt.Fprintf(&b, "| %s/%s | %s | %s | %s |\n", m.Suite, m.Name, base, m.format(m.Value), change)
+	}
+
+	if len(r.Rankings) > 0 {
+		b.WriteString("\n| Rank | Cache | Score | Gold | Silver | Bronze |\n|-----:|-------|------:|-----:|-------:|-------:|\n")
+		for _, e := range r.Rankings {
+			fmt.Fprintf(&b, "| %d | %s | %d | %d | %d | %d |\n", e.Rank, e.Name, e.Score, e.Gold, e.Silver, e.Bronze)
+		}
+	}
+
+	if r.Passed {
+		b.WriteString("\n**Result:** ✓ all goals met\n")
+	} else {
+		b.WriteString("\n**Result:** ✗ goals not met\n\n```\n")
+		b.WriteString(strings.Join(r.Failures, "\n"))
+		b.WriteString("\n```\n")
+	}
+	return b.String()
+}
+
+// knob is a tuning constant varied by -sweep. The sweep finds pattern in
+// s3fifo.go and replaces it with repl, in which %s is the value; the value
+// "default" leaves the source as shipped.
+type knob struct {
+	name    string
+	pattern *regexp.Regexp
+	repl    string
+	values  []string
+}
+
+// sweepKnobs are the knobs -sweep varies, with their default grids.
+// smallRatio replaces the size-interpolated small queue ratio with a fixed
+// per-mille one, and deathRowDiv is capacity per death row slot.
+var sweepKnobs = []knob{
+	{"smallRatio", regexp.MustCompile(`perMille\(size, smallRatio\(size\)\)`), "perMille(size, %s)", []string{"default", "100", "175"}},
+	{"maxFreq", regexp.MustCompile(`(?m)^\tmaxFreq = \d+`), "\tmaxFreq = %s", []string{"3", "5", "7"}},
+	{"deathRowDiv", regexp.MustCompile(`max\(minDeathRowSize, size/\d+\)`), "max(minDeathRowSize, size/%s)", []string{"512", "768", "1024"}},
+	{"ghostFPRate", regexp.MustCompile(`(?m)^\tghostFPRate = [0-9.e-]+`), "\tghostFPRate = %s", []string{"0.0001", "0.00001", "0.000001"}},
+}
+
+// parseGrid returns the knobs to sweep: each knob's default grid if spec is
+// empty, else the values spec lists as knob=v1,v2;knob=v1, with unlisted
+// knobs left as shipped.
+func parseGrid(spec string) ([]knob, error) {
+	if spec == "" {
+		return sweepKnobs, nil
+	}
+	knobs := slices.Clone(sweepKnobs)
+	for i := range knobs {
+		knobs[i].values = []string{"default"}
+	}
+	for _, part := range strings.Split(spec, ";") {
+		name, vals, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || vals == "" {
+			return nil, fmt.Errorf("%q is not knob=v1,v2", part)
+		}
+		i := slices.IndexFunc(knobs, func(k knob) bool { return k.name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown knob %q", name)
+		}
+		knobs[i].values = strings.Split(vals, ",")
+	}
+	return knobs, nil
+}
+
+// SweepPoint is one combination of knob values and fido's results with it.
+type SweepPoint struct {
+	Settings     map[string]string `json:"settings"`
+	HitRate      float64           `json:"hitRate"` // average across hit rate tests
+	BytesPerItem int               `json:"bytesPerItem"`
+	Pareto       bool              `json:"pareto"` // no other point has a higher hit rate for less memory
+	Error        string            `json:"error,omitempty"`
+}
+
+// label renders p's settings in knob order.
+func (p *SweepPoint) label(knobs []knob) string {
+	parts := make([]string, len(knobs))
+	for i, k := range knobs {
+		parts[i] = k.name + "=" + p.Settings[k.name]
+	}
+	return strings.Join(parts, " ")
+}
+
+// runSweep benchmarks fido's hit rate and memory at every combination of the
+// knobs' values, each against a patched copy of the package so the working
+// tree is never modified, then reports the Pareto frontier.
+func runSweep(fidoDir, gocachemarkDir string, knobs []knob, testsFilter, sizesFilter, reportDir string) error {
+	src, err := os.ReadFile(filepath.Join(fidoDir, "s3fifo.go"))
+	if err != nil {
+		return err
+	}
+	for _, k := range knobs {
+		if !k.pattern.Match(src) {
+			return fmt.Errorf("knob %s: %s not found in s3fifo.go", k.name, k.pattern)
+		}
+	}
+
+	// Point gocachemark back at the real tree when done.
+	defer func() {
+		cmd := exec.Command("go", "mod", "edit", "-replace", fidoModule+"="+fidoDir)
+		cmd.Dir = gocachemarkDir
+		cmd.Run()
+	}()
+
+	points := []*SweepPoint{{Settings: map[string]string{}}}
+	for _, k := range knobs {
+		var next []*SweepPoint
+		for _, p := range points {
+			for _, v := range k.values {
+				settings := maps.Clone(p.Settings)
+				settings[k.name] = v
+				next = append(next, &SweepPoint{Settings: settings})
+			}
+		}
+		points = next
+	}
+
+	for i, p := range points {
+		fmt.Printf("=== Sweep %d/%d: %s ===\n", i+1, len(points), p.label(knobs))
+		if err := sweepOne(p, knobs, src, fidoDir, gocachemarkDir, testsFilter, sizesFilter); err != nil {
+			p.Error = err.Error()
+			fmt.Printf("✗ %v\n", err)
+			continue
+		}
+		fmt.Printf("hitrate %.3f%%, memory %d bytes/item\n\n", p.HitRate, p.BytesPerItem)
+	}
+
+	markPareto(points)
+	slices.SortStableFunc(points, func(a, b *SweepPoint) int { return cmp.Compare(b.HitRate, a.HitRate) })
+
+	fmt.Println("=== Sweep Results (* = Pareto frontier) ===")
+	for _, p := range points {
+		mark := " "
+		if p.Pareto {
+			mark = "*"
+		}
+		if p.Error != "" {
+			fmt.Printf("%s %s: failed\n", mark, p.label(knobs))
+			continue
+		}
+		fmt.Printf("%s %s: %.3f%%, %d bytes/item\n", mark, p.label(knobs), p.HitRate, p.BytesPerItem)
+	}
+
+	if reportDir != "" {
+		if err := writeSweepReport(reportDir, knobs, points); err != nil {
+			return fmt.Errorf("writing report: %w", err)
+		}
+		fmt.Printf("\nReport written to %s/\n", reportDir)
+	}
+	return nil
+}
+
+// sweepOne benchmarks p's settings, applied to src, recording the results in p.
+func sweepOne(p *SweepPoint, knobs []knob, src []byte, fidoDir, gocachemarkDir, testsFilter, sizesFilter string) error {
+	dir, err := os.MkdirTemp("", "fido-sweep-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	if err := copyPackage(fidoDir, dir); err != nil {
+		return err
+	}
+	patched := src
+	for _, k := range knobs {
+		if v := p.Settings[k.name]; v != "default" {
+			patched = k.pattern.ReplaceAllLiteral(patched, []byte(fmt.Sprintf(k.repl, v)))
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "s3fifo.go"), patched, 0644); err != nil {
+		return err
+	}
+
+	cmd := exec.Command("go", "mod", "edit", "-replace", fidoModule+"="+dir)
+	cmd.Dir = gocachemarkDir
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("updating go.mod replace: %w", err)
+	}
+
+	outdir := filepath.Join(dir, "out")
+	args := []string{"run", ".", "-caches", "fido", "-suites", "hitrate,memory", "-outdir", outdir}
+	if testsFilter != "" {
+		args = append(args, "-tests", testsFilter)
+	}
+	if sizesFilter != "" {
+		args = append(args, "-sizes", sizesFilter)
+	}
+	res, err := runGocachemark(gocachemarkDir, args, outdir)
+	if err != nil {
+		return err
+	}
+	avg, ok := avgHitRate(res, "fido")
+	if !ok {
+		return errors.New("no hit rate results for fido")
+	}
+	p.HitRate = avg
+	if res.Memory != nil {
+		p.BytesPerItem = findMemory(res.Memory.Results, "fido")
+	}
+	return nil
+}
+
+// copyPackage copies the root package's non-test sources and module files
+// from src to dst.
+func copyPackage(src, dst string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		name := e.Name()
+		isSource := strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go")
+		if e.IsDir() || !isSource && name != "go.mod" && name != "go.sum" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(src, name))
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dst, name), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markPareto flags the points no other successful point dominates: none has
+// at least the hit rate for at most the memory, and is better in one of them.
+func markPareto(points []*SweepPoint) {
+	for _, p := range points {
+		if p.Error != "" {
+			continue
+		}
+		p.Pareto = !slices.ContainsFunc(points, func(q *SweepPoint) bool {
+			return q.Error == "" && q.HitRate >= p.HitRate && q.BytesPerItem <= p.BytesPerItem &&
+				(q.HitRate > p.HitRate || q.BytesPerItem < p.BytesPerItem)
+		})
+	}
+}
+
+// writeSweepReport writes the sweep's points, best hit rate first, to dir as
+// sweep_report.json and a markdown table, sweep_report.md.
+func writeSweepReport(dir string, knobs []knob, points []*SweepPoint) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(points, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sweep_report.json"), append(data, '\n'), 0644); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("# fido tuning sweep\n\nPoints on the Pareto frontier of hit rate against memory are marked ★.\n\n|   |")
+	for _, k := range knobs {
+		fmt.Fprintf(&b, " %s |", k.name)
+	}
+	b.WriteString(" Hit rate | Bytes/item |\n|---|")
+	b.WriteString(strings.Repeat("---|", len(knobs)))
+	b.WriteString("---:|---:|\n")
+	for _, p := range points {
+		mark := ""
+		if p.Pareto {
+			mark = "★"
+		}
+		fmt.Fprintf(&b, "| %s |", mark)
+		for _, k := range knobs {
+			fmt.Fprintf(&b, " %s |", p.Settings[k.name])
+		}
+		if p.Error != "" {
+			b.WriteString(" failed | – |\n")
+			continue
+		}
+		fmt.Fprintf(&b, " %.3f%% | %d |\n", p.HitRate, p.BytesPerItem)
+	}
+	return os.WriteFile(filepath.Join(dir, "sweep_report.md"), []byte(b.String()), 0644)
+}
+
 func copyResults(src, dst string) error {
 	files := []string{"gocachemark_results.json", "gocachemark_results.md"}
 	for _, name := range files {
@@ -3,6 +3,7 @@ package fido
 import (
 	"math"
 	"math/bits"
+	"sync/atomic"
 )
 
 // bloomFilter is a probabilistic set membership test.
@@ -64,6 +65,45 @@ func (b *bloomFilter) Reset() {
 	b.entries = 0
 }
 
+// atomicBloom is a bloomFilter that is safe for concurrent adds and lookups.
+type atomicBloom struct {
+	data []atomic.Uint64
+	mask uint64
+	k    int
+}
+
+func newAtomicBloom(capacity int, fpRate float64) *atomicBloom {
+	b := newBloomFilter(capacity, fpRate)
+	return &atomicBloom{data: make([]atomic.Uint64, len(b.data)), mask: b.mask, k: b.k}
+}
+
+func (b *atomicBloom) Add(h uint64) {
+	h1, h2 := h, h>>32
+	for i := range b.k {
+		//nolint:gosec // G115: i is bounded by b.k which is capped at 16
+		idx := (h1 + uint64(i)*h2) & b.mask
+		b.data[idx/64].Or(1 << (idx % 64))
+	}
+}
+
+func (b *atomicBloom) Reset() {
+	for i := range b.data {
+		b.data[i].Store(0)
+	}
+}
+
+func (b *atomicBloom) Contains(h uint64) bool {
+	h1, h2 := h, h>>32
+	for i := range b.k {
+		//nolint:gosec // G115: i is bounded by b.k which is capped at 16
+		idx := (h1 + uint64(i)*h2) & b.mask
+		if b.data[idx/64].Load()&(1<<(idx%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // hashInt64 mixes an int64 into a well-distributed uint64 (SplitMix64).
 func hashInt64(x int64) uint64 {
 	//nolint:gosec // G115: intentional bit reinterpretation for hashing
@@ -0,0 +1,128 @@
+package fido
+
+import (
+	"sync"
+	"time"
+)
+
+// Child is a cache layered over a parent Cache, such as a request-scoped cache
+// over a process-wide one. Reads check the child's own writes, then the parent.
+// Writes are request-local unless the child propagates them; local writes are
+// discarded with the child unless Commit copies them to the parent.
+//
+// A Child is safe for concurrent use but is meant to be short-lived: it holds
+// every local write until Commit or Discard and is not bounded by Size.
+//
+//nolint:govet // fieldalignment: semantic grouping preferred
+type Child[K comparable, V any] struct {
+	parent    *Cache[K, V]
+	mu        sync.Mutex
+	writes    map[K]childWrite[V] // local writes and deletes, newest per key
+	propagate bool
+}
+
+// childWrite is a child's write or delete, masking the parent's entry for key.
+type childWrite[V any] struct {
+	value   V
+	expiry  time.Time // zero means no expiry
+	deleted bool
+}
+
+// Child returns a new cache that reads through to c. If propagate is true,
+// writes and deletes also apply to c immediately; otherwise c sees them only
+// after Commit.
+func (c *Cache[K, V]) Child(propagate bool) *Child[K, V] {
+	return &Child[K, V]{parent: c, writes: make(map[K]childWrite[V]), propagate: propagate}
+}
+
+// Get returns the child's own value for key if it wrote or deleted one,
+// otherwise the parent's.
+func (c *Child[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	w, ok := c.writes[key]
+	c.mu.Unlock()
+	if !ok {
+		return c.parent.Get(key)
+	}
+	if w.deleted || (!w.expiry.IsZero() && time.Now().After(w.expiry)) {
+		var zero V
+		return zero, false
+	}
+	return c.parent.clone.copy(w.value, true), true
+}
+
+// Set stores a value using the parent's default TTL, or its TTLFromValue.
+func (c *Child[K, V]) Set(key K, value V) {
+	c.SetTTL(key, value, c.parent.valueTTL.or(value, c.parent.defaultTTL))
+}
+
+// SetTTL stores a value with an explicit TTL.
+// A zero or negative TTL means the entry never expires.
+func (c *Child[K, V]) SetTTL(key K, value V, ttl time.Duration) {
+	var expiry time.Time
+	if ttl > 0 {
+		expiry = time.Now().Add(ttl)
+	}
+	c.mu.Lock()
+	c.writes[key] = childWrite[V]{value: value, expiry: expiry}
+	c.mu.Unlock()
+	if c.propagate {
+		c.parent.SetTTL(key, value, ttl)
+	}
+}
+
+// Delete removes key from the child. Until Commit, the parent's value stays
+// hidden from the child but visible to everyone else, unless the child propagates.
+func (c *Child[K, V]) Delete(key K) {
+	c.mu.Lock()
+	c.writes[key] = childWrite[V]{deleted: true}
+	c.mu.Unlock()
+	if c.propagate {
+		c.parent.Delete(key)
+	}
+}
+
+// Commit applies the child's local writes and deletes to the parent and
+// returns how many it applied. Writes that have since expired are dropped.
+// A propagating child has nothing to apply, so Commit only clears it.
+func (c *Child[K, V]) Commit() int {
+	c.mu.Lock()
+	writes := c.writes
+	c.writes = make(map[K]childWrite[V])
+	c.mu.Unlock()
+	if c.propagate {
+		return 0
+	}
+
+	n := 0
+	now := time.Now()
+	for key, w := range writes {
+		switch {
+		case w.deleted:
+			c.parent.Delete(key)
+		case w.expiry.IsZero():
+			c.parent.SetTTL(key, w.value, 0)
+		case w.expiry.After(now):
+			c.parent.SetTTL(key, w.value, w.expiry.Sub(now))
+		default:
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+// Discard drops the child's local writes and deletes without applying them.
+// Afterwards the child reads straight through to the parent.
+func (c *Child[K, V]) Discard() {
+	c.mu.Lock()
+	clear(c.writes)
+	c.mu.Unlock()
+}
+
+// Len returns the number of keys the child has written or deleted.
+func (c *Child[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.writes)
+}
@@ -0,0 +1,142 @@
+package fido
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestChild_Local(t *testing.T) {
+	parent := New[string, int]()
+	parent.Set("shared", 1)
+	parent.Set("gone", 2)
+
+	child := parent.Child(false)
+	if v, ok := child.Get("shared"); !ok || v != 1 {
+		t.Errorf("child.Get(shared) = %d, %v; want 1, true from parent", v, ok)
+	}
+
+	child.Set("shared", 10)
+	child.Set("local", 3)
+	child.Delete("gone")
+	if v, ok := child.Get("shared"); !ok || v != 10 {
+		t.Errorf("child.Get(shared) = %d, %v; want 10, true", v, ok)
+	}
+	if _, ok := child.Get("gone"); ok {
+		t.Error("child should not see a key it deleted")
+	}
+	if v, _ := parent.Get("shared"); v != 1 {
+		t.Errorf("parent.Get(shared) = %d; want 1 before Commit", v)
+	}
+	if _, ok := parent.Get("local"); ok {
+		t.Error("parent should not see local writes before Commit")
+	}
+	if _, ok := parent.Get("gone"); !ok {
+		t.Error("parent should keep keys the child deleted before Commit")
+	}
+	if child.Len() != 3 {
+		t.Errorf("child.Len() = %d; want 3", child.Len())
+	}
+
+	if n := child.Commit(); n != 3 {
+		t.Errorf("Commit() = %d; want 3", n)
+	}
+	if v, _ := parent.Get("shared"); v != 10 {
+		t.Errorf("parent.Get(shared) = %d; want 10 after Commit", v)
+	}
+	if v, ok := parent.Get("local"); !ok || v != 3 {
+		t.Errorf("parent.Get(local) = %d, %v; want 3, true after Commit", v, ok)
+	}
+	if _, ok := parent.Get("gone"); ok {
+		t.Error("Commit should apply deletes")
+	}
+	if child.Len() != 0 {
+		t.Errorf("child.Len() = %d after Commit; want 0", child.Len())
+	}
+}
+
+func TestChild_Discard(t *testing.T) {
+	parent := New[string, int]()
+	parent.Set("a", 1)
+	child := parent.Child(false)
+	child.Set("a", 2)
+	child.Set("b", 3)
+
+	child.Discard()
+	if v, _ := child.Get("a"); v != 1 {
+		t.Errorf("child.Get(a) = %d after Discard; want parent's 1", v)
+	}
+	if _, ok := parent.Get("b"); ok {
+		t.Error("discarded writes reached the parent")
+	}
+	if n := child.Commit(); n != 0 {
+		t.Errorf("Commit() after Discard = %d; want 0", n)
+	}
+}
+
+func TestChild_Propagate(t *testing.T) {
+	parent := New[string, int]()
+	parent.Set("gone", 1)
+	child := parent.Child(true)
+
+	child.Set("a", 1)
+	child.Delete("gone")
+	if v, ok := parent.Get("a"); !ok || v != 1 {
+		t.Errorf("parent.Get(a) = %d, %v; want 1, true", v, ok)
+	}
+	if _, ok := parent.Get("gone"); ok {
+		t.Error("propagated delete should reach the parent")
+	}
+
+	// The child still sees its own write if the parent drops it.
+	parent.Delete("a")
+	if v, ok := child.Get("a"); !ok || v != 1 {
+		t.Errorf("child.Get(a) = %d, %v; want 1, true", v, ok)
+	}
+	if n := child.Commit(); n != 0 {
+		t.Errorf("Commit() = %d; want 0 for a propagating child", n)
+	}
+}
+
+func TestChild_TTL(t *testing.T) {
+	parent := New[string, int](TTL(time.Hour))
+	child := parent.Child(false)
+	child.SetTTL("short", 1, time.Millisecond)
+	child.Set("default", 2)
+	child.SetTTL("forever", 3, 0)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok := child.Get("short"); ok {
+		t.Error("child.Get(short) should miss after expiry")
+	}
+	if n := child.Commit(); n != 2 {
+		t.Errorf("Commit() = %d; want 2, skipping the expired write", n)
+	}
+	if _, ok := parent.Get("short"); ok {
+		t.Error("expired write reached the parent")
+	}
+	if ent, ok := parent.memory.getEntry("default"); !ok || ent.expirySec() == 0 {
+		t.Error("default write should carry the parent's TTL")
+	}
+	if ent, ok := parent.memory.getEntry("forever"); !ok || ent.expirySec() != 0 {
+		t.Error("write with zero TTL should not expire")
+	}
+}
+
+func TestChild_Concurrent(t *testing.T) {
+	parent := New[int, int]()
+	child := parent.Child(false)
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Go(func() {
+			for i := range 100 {
+				child.Set(g*100+i, i)
+				child.Get(g*100 + i)
+			}
+		})
+	}
+	wg.Wait()
+	if n := child.Commit(); n != 800 || parent.Len() != 800 {
+		t.Errorf("Commit() = %d, parent.Len() = %d; want 800, 800", n, parent.Len())
+	}
+}
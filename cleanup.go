@@ -0,0 +1,103 @@
+package fido
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// cleanupLease names the lease replicas compete for to run AutoCleanup.
+const cleanupLease = "cleanup"
+
+// cleaner periodically calls Store.Cleanup. With a Leaser store, each round
+// first takes the cleanup lease, so one replica does the work while the others
+// skip. The lease outlives two rounds, so the leader keeps it by renewing and a
+// replica takes over within two intervals if the leader goes away.
+//
+//nolint:govet // fieldalignment: semantic grouping preferred
+type cleaner[K comparable, V any] struct {
+	store    Store[K, V]
+	interval time.Duration
+	maxAge   time.Duration
+	owner    string
+	audit    *auditLog // records rounds that removed entries or failed
+	done     chan struct{}
+	wg       sync.WaitGroup
+	once     sync.Once
+}
+
+func newCleaner[K comparable, V any](store Store[K, V], cfg *config, audit *auditLog) *cleaner[K, V] {
+	if cfg.cleanupInterval <= 0 {
+		return nil
+	}
+	c := &cleaner[K, V]{
+		store:    store,
+		interval: cfg.cleanupInterval,
+		maxAge:   cfg.cleanupMaxAge,
+		owner:    ownerID(),
+		audit:    audit,
+		done:     make(chan struct{}),
+	}
+	c.wg.Go(c.run)
+	return c
+}
+
+// ownerID identifies this cache instance as a lease holder.
+func ownerID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	b := make([]byte, 8)
+	_, _ = rand.Read(b) //nolint:errcheck // crypto/rand.Read never fails
+	return host + "-" + hex.EncodeToString(b)
+}
+
+func (c *cleaner[K, V]) run() {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.round()
+		}
+	}
+}
+
+// round runs one cleanup pass if this replica holds the lease.
+// It reports whether Cleanup was called.
+func (c *cleaner[K, V]) round() bool {
+	ctx, cancel := context.WithTimeout(BackgroundLane(context.Background()), c.interval)
+	defer cancel()
+
+	if l, ok := baseStore(c.store).(Leaser); ok {
+		leader, err := l.AcquireLease(ctx, cleanupLease, c.owner, 2*c.interval)
+		if err != nil {
+			slog.Warn("cleanup lease failed", "error", err)
+			return false
+		}
+		if !leader {
+			return false
+		}
+	}
+
+	start := time.Now()
+	n, err := c.store.Cleanup(ctx, c.maxAge)
+	if err != nil || n > 0 {
+		c.audit.add(AdminOp{Time: start, Op: "Cleanup", Removed: n, Duration: time.Since(start), Err: err})
+	}
+	return true
+}
+
+func (c *cleaner[K, V]) stop() {
+	c.once.Do(func() {
+		close(c.done)
+		c.wg.Wait()
+	})
+}
@@ -0,0 +1,113 @@
+package fido
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// leaseTable is a lease registry shared by several leaseMockStores, standing in
+// for the shared backend replicas compete on.
+type leaseTable struct {
+	mu     sync.Mutex
+	owner  map[string]string
+	expiry map[string]time.Time
+}
+
+// leaseMockStore counts Cleanup calls and grants leases from a shared table.
+type leaseMockStore[K comparable, V any] struct {
+	*mockStore[K, V]
+	table    *leaseTable
+	cleanups atomic.Int32
+}
+
+func (m *leaseMockStore[K, V]) Cleanup(ctx context.Context, maxAge time.Duration) (int, error) {
+	m.cleanups.Add(1)
+	return m.mockStore.Cleanup(ctx, maxAge)
+}
+
+func (m *leaseMockStore[K, V]) AcquireLease(_ context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	m.table.mu.Lock()
+	defer m.table.mu.Unlock()
+	if cur, ok := m.table.owner[name]; ok && cur != owner && time.Now().Before(m.table.expiry[name]) {
+		return false, nil
+	}
+	m.table.owner[name] = owner
+	m.table.expiry[name] = time.Now().Add(ttl)
+	return true, nil
+}
+
+func TestTieredCache_AutoCleanup(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	_ = store.Set(ctx, "old", 1, time.Now().Add(-time.Hour)) //nolint:errcheck // Test fixture
+	_ = store.Set(ctx, "live", 2, time.Time{})               //nolint:errcheck // Test fixture
+
+	cache, err := NewTiered[string, int](store, AutoCleanup(10*time.Millisecond, 0))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	waitFor(t, func() bool {
+		_, _, found, _ := store.Get(ctx, "old") //nolint:errcheck // polling
+		store.mu.RLock()
+		_, present := store.data["old"]
+		store.mu.RUnlock()
+		return !found && !present
+	})
+	if _, _, found, _ := store.Get(ctx, "live"); !found { //nolint:errcheck // checked via found
+		t.Error("live entry should survive cleanup")
+	}
+}
+
+func TestTieredCache_AutoCleanup_LeaderElection(t *testing.T) {
+	table := &leaseTable{owner: map[string]string{}, expiry: map[string]time.Time{}}
+	stores := make([]*leaseMockStore[string, int], 3)
+	for i := range stores {
+		stores[i] = &leaseMockStore[string, int]{mockStore: newMockStore[string, int](), table: table}
+		cache, err := NewTiered[string, int](stores[i], AutoCleanup(10*time.Millisecond, time.Hour))
+		if err != nil {
+			t.Fatalf("NewTiered: %v", err)
+		}
+		defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	running := 0
+	for _, s := range stores {
+		if s.cleanups.Load() > 0 {
+			running++
+		}
+	}
+	if running != 1 {
+		t.Errorf("%d replicas ran Cleanup; want exactly 1 leader", running)
+	}
+}
+
+func TestTieredCache_AutoCleanup_Failover(t *testing.T) {
+	table := &leaseTable{owner: map[string]string{}, expiry: map[string]time.Time{}}
+	leader := &leaseMockStore[string, int]{mockStore: newMockStore[string, int](), table: table}
+	follower := &leaseMockStore[string, int]{mockStore: newMockStore[string, int](), table: table}
+
+	lc, err := NewTiered[string, int](leader, AutoCleanup(10*time.Millisecond, time.Hour))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	waitFor(t, func() bool { return leader.cleanups.Load() > 0 })
+
+	fc, err := NewTiered[string, int](follower, AutoCleanup(10*time.Millisecond, time.Hour))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = fc.Close() }() //nolint:errcheck // Test cleanup
+
+	// The leader goes away; its lease lapses and the follower takes over.
+	if err := lc.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	waitFor(t, func() bool { return follower.cleanups.Load() > 0 })
+}
@@ -0,0 +1,101 @@
+package fido
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// defaultClockResolution bounds how stale expiry checks may be. The clock
+	// caches whole seconds, so a second is also its finest useful tick.
+	defaultClockResolution = time.Second
+
+	// clockIdle is how long a clock keeps ticking without readers before it stops.
+	// The next read restarts it, so idle processes don't wake every second.
+	clockIdle = time.Second
+)
+
+// clocks holds one shared coarseClock per resolution. Caches have no Close,
+// so per-cache tickers would leak; shared clocks stop themselves when idle.
+var clocks sync.Map // time.Duration -> *coarseClock
+
+// coarseClock caches the current Unix second, refreshed by a ticker, so expiry
+// checks on the read path cost an atomic load instead of a time.Now call.
+type coarseClock struct {
+	sec     atomic.Uint32 // Unix seconds as of the last tick
+	running atomic.Bool
+	used    atomic.Bool // read since the last tick
+	res     time.Duration
+}
+
+// clockFor returns the shared clock for res, or nil if res is negative,
+// meaning expiry checks read the exact time.
+func clockFor(res time.Duration) *coarseClock {
+	if res < 0 {
+		return nil
+	}
+	if res == 0 {
+		res = defaultClockResolution
+	}
+	if c, ok := clocks.Load(res); ok {
+		return c.(*coarseClock) //nolint:errcheck,forcetypeassert // map only holds *coarseClock
+	}
+	c, _ := clocks.LoadOrStore(res, &coarseClock{res: res})
+	return c.(*coarseClock) //nolint:errcheck,forcetypeassert // map only holds *coarseClock
+}
+
+// now returns the current Unix second, at most res stale (rounded up to whole
+// seconds).
+func (c *coarseClock) now() uint32 {
+	if !c.running.Load() {
+		return c.start()
+	}
+	// Check before storing so steady-state reads don't contend on the cache line.
+	if !c.used.Load() {
+		c.used.Store(true)
+	}
+	return c.sec.Load()
+}
+
+// start reads the exact time and starts the ticker if it isn't running.
+func (c *coarseClock) start() uint32 {
+	now := unixSec()
+	if c.running.CompareAndSwap(false, true) {
+		c.sec.Store(now)
+		c.used.Store(true)
+		go c.run()
+	}
+	return now
+}
+
+// tick returns how often the clock refreshes: res rounded up to whole seconds,
+// since a faster tick would mostly store the second already cached.
+func (c *coarseClock) tick() time.Duration {
+	return max(time.Second, (c.res + time.Second - 1).Truncate(time.Second))
+}
+
+func (c *coarseClock) run() {
+	period := c.tick()
+	// Start on a second boundary so the cached second turns over with the real one.
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	t := time.NewTicker(period)
+	defer t.Stop()
+	idleTicks := max(int(clockIdle/period), 1)
+	idle := 0
+	for {
+		c.sec.Store(unixSec())
+		if c.used.Swap(false) {
+			idle = 0
+		} else if idle++; idle >= idleTicks {
+			c.running.Store(false)
+			return
+		}
+		<-t.C
+	}
+}
+
+func unixSec() uint32 {
+	//nolint:gosec // G115: Unix seconds fit in uint32 until year 2106
+	return uint32(time.Now().Unix())
+}
@@ -0,0 +1,85 @@
+package fido
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockFor(t *testing.T) {
+	if clockFor(-1) != nil {
+		t.Error("negative resolution should disable the coarse clock")
+	}
+	if c := clockFor(0); c == nil || c.res != defaultClockResolution {
+		t.Errorf("clockFor(0) = %+v; want default resolution", c)
+	}
+	if clockFor(time.Millisecond) != clockFor(time.Millisecond) {
+		t.Error("clocks should be shared per resolution")
+	}
+}
+
+func TestCoarseClock_Now(t *testing.T) {
+	c := &coarseClock{res: time.Millisecond}
+	for range 3 {
+		got, want := c.now(), unixSec()
+		if got > want || want-got > 1 {
+			t.Errorf("now() = %d; want about %d", got, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !c.running.Load() {
+		t.Error("clock should be running after reads")
+	}
+}
+
+func TestCoarseClock_Tick(t *testing.T) {
+	for res, want := range map[time.Duration]time.Duration{
+		time.Millisecond:        time.Second,
+		time.Second:             time.Second,
+		1500 * time.Millisecond: 2 * time.Second,
+	} {
+		if got := (&coarseClock{res: res}).tick(); got != want {
+			t.Errorf("tick() at resolution %v = %v; want %v", res, got, want)
+		}
+	}
+}
+
+func TestCoarseClock_StopsWhenIdle(t *testing.T) {
+	if testing.Short() {
+		t.Skip("waits for the idle timeout")
+	}
+	c := &coarseClock{res: 100 * time.Millisecond}
+	c.now()
+	// Up to a second to reach a tick boundary, then clockIdle without reads.
+	deadline := time.Now().Add(2*time.Second + clockIdle)
+	for c.running.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("clock still running after the idle timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The next read restarts it.
+	if got, want := c.now(), unixSec(); got != want {
+		t.Errorf("now() after idle = %d; want %d", got, want)
+	}
+	if !c.running.Load() {
+		t.Error("read should restart the clock")
+	}
+}
+
+func TestCache_ClockResolution(t *testing.T) {
+	if got := New[string, int]().Config().ClockResolution; got != defaultClockResolution {
+		t.Errorf("default ClockResolution = %v; want %v", got, defaultClockResolution)
+	}
+	if got := New[string, int](ClockResolution(-1)).Config().ClockResolution; got != 0 {
+		t.Errorf("ClockResolution(-1) = %v; want 0", got)
+	}
+
+	cache := New[string, int](ClockResolution(-1))
+	cache.SetTTL("k", 1, time.Hour)
+	ent, _ := cache.memory.getEntry("k")
+	ent.storeValue(1, unixSec()-1, cache.memory.epoch.Load(), 0)
+	if _, ok := cache.Get("k"); ok {
+		t.Error("expired entry should miss with exact clock")
+	}
+}
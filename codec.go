@@ -0,0 +1,127 @@
+package fido
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Codec converts a View's values to and from bytes.
+type Codec[V any] interface {
+	Encode(value V) ([]byte, error)
+	Decode(data []byte) (V, error)
+}
+
+// JSON returns a Codec using encoding/json. It is the default for NewView.
+func JSON[V any]() Codec[V] {
+	return jsonCodec[V]{}
+}
+
+type jsonCodec[V any] struct{}
+
+func (jsonCodec[V]) Encode(value V) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (jsonCodec[V]) Decode(data []byte) (V, error) {
+	var v V
+	err := json.Unmarshal(data, &v)
+	return v, err
+}
+
+// Gob returns a Codec using encoding/gob. Unlike JSON it round-trips
+// interface-typed fields, provided every concrete type stored in them is
+// registered with RegisterType in each process that encodes or decodes.
+func Gob[V any]() Codec[V] {
+	return gobCodec[V]{}
+}
+
+// gobValue wraps values so interface-typed V keeps its dynamic type.
+type gobValue[V any] struct {
+	V V
+}
+
+type gobCodec[V any] struct{}
+
+func (gobCodec[V]) Encode(value V) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(gobValue[V]{V: value}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec[V]) Decode(data []byte) (V, error) {
+	var w gobValue[V]
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&w)
+	return w.V, err
+}
+
+// RegisterType records the concrete type of v for the Gob codec, so values of
+// that type can be stored in interface-typed fields. Like gob.Register, call
+// it during initialization, in every process sharing the cached data.
+func RegisterType(v any) {
+	gob.Register(v)
+}
+
+// ErrUnregisteredType is wrapped by CodecError when a Gob-encoded value holds
+// an interface whose concrete type was not passed to RegisterType.
+var ErrUnregisteredType = errors.New("type not registered")
+
+// CodecError reports a value a View could not encode or decode.
+type CodecError struct {
+	Err  error  // underlying codec error
+	Op   string // "encode" or "decode"
+	Key  string // namespaced cache key
+	Type string // offending type, when the codec names one
+}
+
+func (e *CodecError) Error() string {
+	if errors.Is(e.Err, ErrUnregisteredType) {
+		return fmt.Sprintf("%s %s: type %s is not registered; call fido.RegisterType with a %s value",
+			e.Op, e.Key, e.Type, e.Type)
+	}
+	if e.Type != "" {
+		return fmt.Sprintf("%s %s: type %s: %v", e.Op, e.Key, e.Type, e.Err)
+	}
+	return fmt.Sprintf("%s %s: %v", e.Op, e.Key, e.Err)
+}
+
+func (e *CodecError) Unwrap() error {
+	return e.Err
+}
+
+// gob reports unregistered types only in its error text.
+var gobUnregistered = []string{
+	"gob: type not registered for interface: ", // encode: type name
+	"gob: name not registered for interface: ", // decode: quoted name
+}
+
+// newCodecError classifies err, naming the offending type where possible.
+func newCodecError(op, key string, err error) *CodecError {
+	e := &CodecError{Op: op, Key: key, Err: err}
+	msg := err.Error()
+	for _, prefix := range gobUnregistered {
+		if name, ok := strings.CutPrefix(msg, prefix); ok {
+			if unq, err := strconv.Unquote(name); err == nil {
+				name = unq
+			}
+			e.Type = name
+			e.Err = fmt.Errorf("%w: %w", ErrUnregisteredType, err)
+			return e
+		}
+	}
+	var ute *json.UnsupportedTypeError
+	var mte *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &ute):
+		e.Type = ute.Type.String()
+	case errors.As(err, &mte):
+		e.Type = mte.Type.String()
+	}
+	return e
+}
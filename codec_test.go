@@ -0,0 +1,82 @@
+package fido
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type shape interface{ Area() int }
+
+type square struct{ Side int }
+
+func (s square) Area() int { return s.Side * s.Side }
+
+type circleNotRegistered struct{ R int }
+
+func (c circleNotRegistered) Area() int { return 3 * c.R * c.R }
+
+type drawing struct {
+	Shape shape
+	Name  string
+}
+
+func TestGobCodec_Interfaces(t *testing.T) {
+	RegisterType(square{})
+	ctx := context.Background()
+	raw, err := NewRaw(newMockStore[string, []byte]())
+	if err != nil {
+		t.Fatalf("NewRaw: %v", err)
+	}
+	defer raw.Close() //nolint:errcheck // test cleanup
+
+	drawings := NewView[drawing](raw, "drawing", Gob[drawing]())
+	if err := drawings.Set(ctx, "a", drawing{Shape: square{Side: 3}, Name: "sq"}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	d, found, err := drawings.Get(ctx, "a")
+	if err != nil || !found {
+		t.Fatalf("Get = %v, %v", found, err)
+	}
+	if d.Name != "sq" || d.Shape == nil || d.Shape.Area() != 9 {
+		t.Errorf("Get = %+v; want square of side 3", d)
+	}
+
+	// Interface-typed V keeps its dynamic type.
+	shapes := NewView[shape](raw, "shape", Gob[shape]())
+	if err := shapes.Set(ctx, "s", square{Side: 2}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if s, _, err := shapes.Get(ctx, "s"); err != nil || s == nil || s.Area() != 4 {
+		t.Errorf("Get = %v, %v; want square of side 2", s, err)
+	}
+
+	err = drawings.Set(ctx, "b", drawing{Shape: circleNotRegistered{R: 1}})
+	var ce *CodecError
+	if !errors.As(err, &ce) || !errors.Is(err, ErrUnregisteredType) {
+		t.Fatalf("Set unregistered = %v; want CodecError wrapping ErrUnregisteredType", err)
+	}
+	if ce.Op != "encode" || ce.Key != "drawing:b" || ce.Type != "fido.circleNotRegistered" {
+		t.Errorf("CodecError = %+v", ce)
+	}
+	if !strings.Contains(err.Error(), "RegisterType") {
+		t.Errorf("error %q should point at RegisterType", err)
+	}
+}
+
+func TestNewCodecError(t *testing.T) {
+	ce := newCodecError("decode", "k", errors.New(`gob: name not registered for interface: "main.Foo"`))
+	if ce.Type != "main.Foo" || !errors.Is(ce, ErrUnregisteredType) {
+		t.Errorf("gob decode error = %+v; want unregistered main.Foo", ce)
+	}
+
+	_, err := JSON[int]().Decode([]byte(`"x"`))
+	ce = newCodecError("decode", "k", err)
+	if ce.Type != "int" || errors.Is(ce, ErrUnregisteredType) {
+		t.Errorf("json decode error = %+v; want type int", ce)
+	}
+	if !strings.HasPrefix(ce.Error(), "decode k: type int:") {
+		t.Errorf("Error() = %q", ce.Error())
+	}
+}
@@ -0,0 +1,173 @@
+package fido
+
+import (
+	"context"
+	"time"
+)
+
+// persistLimit bounds concurrent persistence operations, for
+// PersistConcurrency and BackgroundConcurrency. A nil *persistLimit imposes
+// no bound.
+type persistLimit struct {
+	foreground semaphore // PersistConcurrency; nil when unbounded
+	background semaphore // BackgroundConcurrency; nil to share foreground
+}
+
+// newPersistLimit returns the limits set by cfg, or nil when there are none.
+func newPersistLimit(cfg *config) *persistLimit {
+	if cfg.persistConcurrency <= 0 && cfg.backgroundConcurrency <= 0 {
+		return nil
+	}
+	return &persistLimit{
+		foreground: newSemaphore(cfg.persistConcurrency),
+		background: newSemaphore(cfg.backgroundConcurrency),
+	}
+}
+
+// lane returns the semaphore for an operation under ctx.
+func (l *persistLimit) lane(ctx context.Context) semaphore {
+	if l == nil {
+		return nil
+	}
+	if l.background != nil && isBackground(ctx) {
+		return l.background
+	}
+	return l.foreground
+}
+
+// semaphore bounds concurrent operations. A nil semaphore imposes no bound.
+type semaphore chan struct{}
+
+func newSemaphore(n int) semaphore {
+	if n <= 0 {
+		return nil
+	}
+	return make(semaphore, n)
+}
+
+// acquire waits for a free slot, or returns ctx's error if it ends first.
+func (s semaphore) acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a slot taken by acquire.
+func (s semaphore) release() {
+	if s != nil {
+		<-s
+	}
+}
+
+// backgroundKey marks a context as background persistence traffic.
+type backgroundKey struct{}
+
+// BackgroundLane returns a copy of ctx whose persistence operations count
+// against BackgroundConcurrency instead of PersistConcurrency, for bulk work
+// such as a warmup job or Redrive that shouldn't compete with interactive
+// reads. TieredCache runs its own async writes, victim spills, and AutoCleanup
+// in the background lane. Without BackgroundConcurrency it has no effect.
+func BackgroundLane(ctx context.Context) context.Context {
+	return context.WithValue(ctx, backgroundKey{}, struct{}{})
+}
+
+// isBackground reports whether ctx was marked by BackgroundLane.
+func isBackground(ctx context.Context) bool {
+	return ctx.Value(backgroundKey{}) != nil
+}
+
+// limitedStore runs a store's operations under a persistLimit, in the lane
+// their context selects. ValidateKey and Close don't reach the backend and
+// run unbounded.
+type limitedStore[K comparable, V any] struct {
+	Store[K, V]
+	limit *persistLimit
+}
+
+// limitStore wraps store with l, or returns it unchanged if l is nil.
+func limitStore[K comparable, V any](store Store[K, V], l *persistLimit) Store[K, V] {
+	if l == nil || store == nil {
+		return store
+	}
+	return &limitedStore[K, V]{Store: store, limit: l}
+}
+
+func (s *limitedStore[K, V]) Get(ctx context.Context, key K) (V, time.Time, bool, error) {
+	sem := s.limit.lane(ctx)
+	if err := sem.acquire(ctx); err != nil {
+		var zero V
+		return zero, time.Time{}, false, err
+	}
+	defer sem.release()
+	return s.Store.Get(ctx, key)
+}
+
+func (s *limitedStore[K, V]) Set(ctx context.Context, key K, value V, expiry time.Time) error {
+	sem := s.limit.lane(ctx)
+	if err := sem.acquire(ctx); err != nil {
+		return err
+	}
+	defer sem.release()
+	return s.Store.Set(ctx, key, value, expiry)
+}
+
+func (s *limitedStore[K, V]) Delete(ctx context.Context, key K) error {
+	sem := s.limit.lane(ctx)
+	if err := sem.acquire(ctx); err != nil {
+		return err
+	}
+	defer sem.release()
+	return s.Store.Delete(ctx, key)
+}
+
+// SetBatch takes one slot for the whole batch.
+func (s *limitedStore[K, V]) SetBatch(ctx context.Context, keys []K, values []V, expiries []time.Time) error {
+	sem := s.limit.lane(ctx)
+	if err := sem.acquire(ctx); err != nil {
+		return err
+	}
+	defer sem.release()
+	return setBatch(ctx, s.Store, keys, values, expiries)
+}
+
+func (s *limitedStore[K, V]) DeleteBatch(ctx context.Context, keys []K) error {
+	sem := s.limit.lane(ctx)
+	if err := sem.acquire(ctx); err != nil {
+		return err
+	}
+	defer sem.release()
+	return deleteBatch(ctx, s.Store, keys)
+}
+
+func (s *limitedStore[K, V]) Cleanup(ctx context.Context, maxAge time.Duration) (int, error) {
+	sem := s.limit.lane(ctx)
+	if err := sem.acquire(ctx); err != nil {
+		return 0, err
+	}
+	defer sem.release()
+	return s.Store.Cleanup(ctx, maxAge)
+}
+
+func (s *limitedStore[K, V]) Flush(ctx context.Context) (int, error) {
+	sem := s.limit.lane(ctx)
+	if err := sem.acquire(ctx); err != nil {
+		return 0, err
+	}
+	defer sem.release()
+	return s.Store.Flush(ctx)
+}
+
+func (s *limitedStore[K, V]) Len(ctx context.Context) (int, error) {
+	sem := s.limit.lane(ctx)
+	if err := sem.acquire(ctx); err != nil {
+		return 0, err
+	}
+	defer sem.release()
+	return s.Store.Len(ctx)
+}
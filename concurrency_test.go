@@ -0,0 +1,139 @@
+package fido
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// busyStore holds each Get and Set until gate closes, tracking how many run at once.
+type busyStore struct {
+	*mockStore[string, int]
+	gate    chan struct{}
+	running atomic.Int32
+	peak    atomic.Int32
+}
+
+func (s *busyStore) enter() {
+	n := s.running.Add(1)
+	for {
+		p := s.peak.Load()
+		if n <= p || s.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	<-s.gate
+	s.running.Add(-1)
+}
+
+func (s *busyStore) Get(ctx context.Context, key string) (int, time.Time, bool, error) {
+	s.enter()
+	return s.mockStore.Get(ctx, key)
+}
+
+func (s *busyStore) Set(ctx context.Context, key string, value int, expiry time.Time) error {
+	s.enter()
+	return s.mockStore.Set(ctx, key, value, expiry)
+}
+
+func TestTieredCache_PersistConcurrency(t *testing.T) {
+	ctx := context.Background()
+	store := &busyStore{mockStore: newMockStore[string, int](), gate: make(chan struct{})}
+	cache, err := NewTiered[string, int](store, PersistConcurrency(3))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+	if got := cache.Config().PersistConcurrency; got != 3 {
+		t.Errorf("Config().PersistConcurrency = %d; want 3", got)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		key := strconv.Itoa(i)
+		wg.Go(func() {
+			if _, _, err := cache.Get(ctx, "miss"+key); err != nil {
+				t.Errorf("Get(miss%s): %v", key, err)
+			}
+		})
+		if err := cache.SetAsync(ctx, key, i); err != nil {
+			t.Errorf("SetAsync(%s): %v", key, err)
+		}
+	}
+	waitFor(t, func() bool { return store.running.Load() == 3 })
+
+	// A caller whose context ends while waiting gives up without reaching the store.
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := cache.Get(short, "waiting"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Get while saturated = %v; want context.DeadlineExceeded", err)
+	}
+
+	close(store.gate)
+	wg.Wait()
+	for i := range 10 {
+		waitFor(t, func() bool { return !cache.Info(strconv.Itoa(i)).PendingPersist })
+	}
+	if p := store.peak.Load(); p != 3 {
+		t.Errorf("peak concurrent store operations = %d; want 3", p)
+	}
+}
+
+// slowWriteStore holds each Set until gate closes; Gets run immediately.
+type slowWriteStore struct {
+	*mockStore[string, int]
+	gate    chan struct{}
+	writing atomic.Int32
+}
+
+func (s *slowWriteStore) Set(ctx context.Context, key string, value int, expiry time.Time) error {
+	s.writing.Add(1)
+	defer s.writing.Add(-1)
+	<-s.gate
+	return s.mockStore.Set(ctx, key, value, expiry)
+}
+
+func TestTieredCache_BackgroundConcurrency(t *testing.T) {
+	ctx := context.Background()
+	store := &slowWriteStore{mockStore: newMockStore[string, int](), gate: make(chan struct{})}
+	cache, err := NewTiered[string, int](store, PersistConcurrency(1), BackgroundConcurrency(2))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+	if cfg := cache.Config(); cfg.PersistConcurrency != 1 || cfg.BackgroundConcurrency != 2 {
+		t.Errorf("Config() concurrency = %d, %d; want 1, 2", cfg.PersistConcurrency, cfg.BackgroundConcurrency)
+	}
+
+	for i := range 5 {
+		if err := cache.SetAsync(ctx, strconv.Itoa(i), i); err != nil {
+			t.Fatalf("SetAsync(%d): %v", i, err)
+		}
+	}
+	waitFor(t, func() bool { return store.writing.Load() == 2 })
+
+	// Async writes fill the background lane, leaving the foreground slot free.
+	short, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if _, _, err := cache.Get(short, "miss"); err != nil {
+		t.Errorf("foreground Get behind a write backlog: %v", err)
+	}
+	if n := store.writing.Load(); n != 2 {
+		t.Errorf("%d async writes running; want BackgroundConcurrency's 2", n)
+	}
+
+	bg, cancel := context.WithTimeout(BackgroundLane(ctx), 10*time.Millisecond)
+	defer cancel()
+	if _, _, err := cache.Get(bg, "miss"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("background Get with the lane full = %v; want context.DeadlineExceeded", err)
+	}
+
+	close(store.gate)
+	for i := range 5 {
+		waitFor(t, func() bool { return !cache.Info(strconv.Itoa(i)).PendingPersist })
+	}
+}
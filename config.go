@@ -0,0 +1,396 @@
+package fido
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"time"
+)
+
+// ErrInvalidConfig is returned by NewTiered when options are invalid or conflict.
+// New panics with it for a Sizer that does not match the cache's value type.
+var ErrInvalidConfig = errors.New("invalid cache configuration")
+
+// Config is a cache's effective configuration after defaults are applied,
+// as reported by Cache.Config and TieredCache.Config. Zero durations and
+// sizes mean the feature is disabled.
+type Config struct {
+	Store   string // persistence store type; empty for Cache
+	Victim  string // victim store type; empty when disabled
+	Version string // namespace for persisted entries; empty for the store's default
+
+	Size          int // maximum entries
+	SmallCapacity int // S3-FIFO small queue threshold
+	GhostCapacity int // ghost entries tracked before rotation
+	GhostFreqs    int // evicted keys whose frequency is remembered
+	DeathRowSize  int // evicted entries kept for resurrection
+	StatsStripes  int // per-P TrackStats stripes with CPULocalStats; 0 when disabled
+
+	TTL        time.Duration // default memory expiry
+	MemoryTTL  time.Duration // cap on memory lifetime
+	PersistTTL time.Duration // default persisted expiry (PersistTTL, else TTL)
+	VictimTTL  time.Duration
+	HotTTLMax  time.Duration // cap on TTLs extended by HotTTL; 0 when disabled
+
+	LargeThreshold        int // values above this many bytes use the large region
+	LargeMaxBytes         int
+	MaxValueBytes         int
+	MissFilterKeys        int // expected keys in the store for MissFilter
+	MaxKeyLength          int // strictest key length limit among the store tiers; 0 if none
+	PrefetchRate          int // Prefetch entries per second; 0 is unlimited
+	HotKeys               int // hottest keys saved by PersistHotKeys
+	DeadLetterSize        int // failed async writes kept by DeadLetter
+	AsyncQueueSize        int // async writes outstanding at once; 0 is unbounded
+	PersistConcurrency    int // persistence operations run at once; 0 is unlimited
+	BackgroundConcurrency int // background persistence operations run at once; 0 shares PersistConcurrency
+	EvictionTrace         int // eviction decisions kept for Evictions
+
+	AsyncWait         time.Duration
+	AsyncQueueTimeout time.Duration // how long BackpressureBlock and BackpressureDropOldest wait; 0 waits on ctx
+	CleanupInterval   time.Duration
+	CleanupMaxAge     time.Duration
+	HotKeysInterval   time.Duration // how often PersistHotKeys saves; 0 when disabled
+
+	Backpressure Backpressure // AsyncQueue policy; BackpressureReject when disabled
+
+	ClockResolution time.Duration // staleness bound on expiry checks; 0 means exact
+
+	GhostFPRate float64 // ghost bloom filter false positive rate
+	HotTTL      float64 // factor HotTTL extends hot entries' TTLs by; 0 when disabled
+	EarlyExpiry float64 // XFetch beta for EarlyExpiry; 0 when disabled
+
+	AccessLogRate float64 // fraction of keys sampled by AccessLog
+	Threshold     float64 // ThresholdCallback percentage; 0 when disabled
+
+	CopyOnRead         bool
+	TTLFromValue       bool
+	DecodeFallback     bool
+	Prefetch           bool
+	PurgeVersions      bool
+	HashLongKeys       bool
+	NoPersist          bool // NoPersistPattern or NoPersistFunc keeps some keys out of persistence
+	ExactGhosts        bool
+	Deterministic      bool
+	ReadYourWrites     bool
+	SnapshotOnShutdown bool
+	TrackStats         bool
+}
+
+// Config returns the cache's effective configuration.
+func (c *Cache[K, V]) Config() Config {
+	return c.settings
+}
+
+// Config returns the cache's effective configuration.
+func (c *TieredCache[K, V]) Config() Config {
+	return c.settings
+}
+
+// resolveConfig reports cfg as applied to memory. Options that only affect
+// TieredCache are added by resolveTiered.
+func resolveConfig[K comparable, V any](cfg *config, m *s3fifo[K, V]) Config {
+	r := Config{
+		Size:          m.capacity,
+		SmallCapacity: m.smallThresh,
+		GhostCapacity: m.ghostCap,
+		GhostFreqs:    len(m.ghostFreqRng.hashes),
+		GhostFPRate:   m.ghostFPRate,
+		DeathRowSize:  len(m.deathRow),
+		TTL:           cfg.defaultTTL,
+		TrackStats:    cfg.trackStats,
+		ExactGhosts:   m.ghostExact != nil,
+		Deterministic: cfg.deterministic,
+		CopyOnRead:    newCloner[V](cfg) != nil,
+		TTLFromValue:  newValueTTL[V](cfg) != nil,
+		EvictionTrace: max(cfg.evictionTrace, 0),
+		EarlyExpiry:   max(cfg.earlyBeta, 0),
+		StatsStripes:  statsStripes(cfg),
+	}
+	if _, ok := cfg.prefetch.(Prefetcher[K, V]); ok {
+		r.PrefetchRate = max(cfg.prefetchRate, 0)
+		r.Prefetch = true
+	}
+	if m.hot != nil {
+		r.HotTTL = m.hot.factor
+		r.HotTTLMax = time.Duration(m.hot.maxSec) * time.Second
+	}
+	if cfg.accessSink != nil && cfg.accessRate > 0 {
+		r.AccessLogRate = min(cfg.accessRate, 1)
+	}
+	if m.clock != nil {
+		r.ClockResolution = m.clock.res
+	}
+	if r.ExactGhosts {
+		r.GhostFPRate = 0
+	}
+	if m.large != nil {
+		r.LargeThreshold = m.large.threshold
+		r.LargeMaxBytes = m.large.maxBytes
+	}
+	return r
+}
+
+// resolveTiered adds TieredCache's options to a memory configuration.
+func resolveTiered(cfg *config, r Config, store, victim any) Config {
+	r.Store = fmt.Sprintf("%T", store)
+	if victim != nil {
+		r.Victim = fmt.Sprintf("%T", victim)
+		r.VictimTTL = cfg.victimTTL
+	}
+	r.MemoryTTL = cfg.memoryTTL
+	r.PersistTTL = cmp.Or(cfg.persistTTL, cfg.defaultTTL)
+	r.MaxValueBytes = cfg.maxValueBytes
+	r.MissFilterKeys = cfg.missFilterKeys
+	r.MaxKeyLength = keyLimit(store, victim)
+	r.HashLongKeys = cfg.hashLongKeys
+	r.NoPersist = len(cfg.noPersistPatterns) > 0 || cfg.noPersistFunc != nil
+	r.DeadLetterSize = cfg.deadLetterSize
+	if cfg.thresholdFn != nil {
+		r.Threshold = cfg.thresholdPct
+	}
+	r.Version = cfg.version
+	r.PurgeVersions = cfg.purgeVersions
+	r.DecodeFallback = cfg.decodeFallback != nil
+	r.AsyncWait = cfg.asyncWait
+	if cfg.asyncQueueSize > 0 {
+		r.AsyncQueueSize = cfg.asyncQueueSize
+		r.AsyncQueueTimeout = cfg.asyncQueueTimeout
+		r.Backpressure = cfg.backpressure
+	}
+	r.PersistConcurrency = max(cfg.persistConcurrency, 0)
+	r.BackgroundConcurrency = max(cfg.backgroundConcurrency, 0)
+	if cfg.cleanupInterval > 0 {
+		r.CleanupInterval = cfg.cleanupInterval
+		r.CleanupMaxAge = cfg.cleanupMaxAge
+	}
+	if cfg.hotKeys > 0 {
+		r.HotKeys = cfg.hotKeys
+		r.HotKeysInterval = cfg.hotKeysInterval
+	}
+	r.ReadYourWrites = cfg.readYourWrites
+	r.SnapshotOnShutdown = cfg.snapshotOnShutdown
+	return r
+}
+
+// validateConfig reports options that are out of range, conflict with each
+// other, or would otherwise be silently ignored by a TieredCache for store.
+func validateConfig[K comparable, V any](cfg *config, store Store[K, V]) error {
+	var errs []error
+	bad := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if cfg.size < 0 {
+		bad("Size(%d) is negative", cfg.size)
+	}
+	for _, d := range []struct {
+		name string
+		d    time.Duration
+	}{
+		{"TTL", cfg.defaultTTL},
+		{"MemoryTTL", cfg.memoryTTL},
+		{"PersistTTL", cfg.persistTTL},
+		{"AsyncWait", cfg.asyncWait},
+	} {
+		if d.d < 0 {
+			bad("%s(%v) is negative", d.name, d.d)
+		}
+	}
+
+	checkSizer[V](cfg, bad)
+	if cfg.clone != nil {
+		if _, ok := cfg.clone.(func(V) V); !ok {
+			bad("CopyOnRead takes %T; want func(%s) %[2]s", cfg.clone, reflect.TypeFor[V]())
+		}
+	}
+	if cfg.ttlFromValue != nil {
+		if _, ok := cfg.ttlFromValue.(func(V) time.Duration); !ok {
+			bad("TTLFromValue takes %T; want func(%s) time.Duration", cfg.ttlFromValue, reflect.TypeFor[V]())
+		}
+	}
+	switch {
+	case cfg.largeThreshold < 0 || cfg.largeMaxBytes < 0:
+		bad("LargeObjects(%d, %d) has a negative size", cfg.largeThreshold, cfg.largeMaxBytes)
+	case cfg.largeThreshold == 0 && cfg.largeMaxBytes > 0:
+		bad("LargeObjects has maxBytes %d but no threshold", cfg.largeMaxBytes)
+	case cfg.largeThreshold > 0 && cfg.sizer == nil && defaultSizer[V]() == nil:
+		bad("LargeObjects requires Sizer for %s values", reflect.TypeFor[V]())
+	}
+	if cfg.maxValueBytes < 0 {
+		bad("MaxValueBytes(%d) is negative", cfg.maxValueBytes)
+	}
+	if (cfg.hotFactor != 0 || cfg.hotMaxTTL != 0) && (cfg.hotFactor <= 1 || cfg.hotMaxTTL < time.Second) {
+		bad("HotTTL(%v, %v) needs a factor above 1 and a maxTTL of at least 1s", cfg.hotFactor, cfg.hotMaxTTL)
+	}
+	if cfg.cpuLocalStats && !cfg.trackStats {
+		bad("CPULocalStats requires TrackStats")
+	}
+	if cfg.earlyBeta < 0 {
+		bad("EarlyExpiry(%v) is negative", cfg.earlyBeta)
+	} else if cfg.earlyBeta > 0 && cfg.memoryTTL > 0 {
+		bad("EarlyExpiry conflicts with MemoryTTL, which expires memory copies before their values")
+	}
+	if cfg.ghostFreqs < 0 {
+		bad("GhostFrequencies(%d) is negative", cfg.ghostFreqs)
+	}
+	if cfg.ghostFPRate < 0 || cfg.ghostFPRate >= 1 {
+		bad("GhostFPRate(%v) is outside [0, 1)", cfg.ghostFPRate)
+	}
+
+	if cfg.victim != nil {
+		vs, ok := cfg.victim.(Store[K, V])
+		switch {
+		case !ok:
+			bad("Victim store is %T; want Store[%s, %s]", cfg.victim, reflect.TypeFor[K](), reflect.TypeFor[V]())
+		case sameStore(vs, store):
+			bad("Victim store is the persistence store")
+		}
+		if cfg.victimTTL <= 0 {
+			bad("Victim ttl %v must be positive", cfg.victimTTL)
+		}
+	}
+
+	if cfg.accessRate < 0 || cfg.accessRate > 1 || (cfg.accessRate > 0 && cfg.accessSink == nil) {
+		bad("AccessLog rate %v needs a sink and must be in (0, 1]", cfg.accessRate)
+	}
+
+	if cfg.missFilterKeys < 0 {
+		bad("MissFilter(%d) is negative", cfg.missFilterKeys)
+	} else if cfg.missFilterKeys > 0 {
+		if _, ok := any(store).(PrefixScanner[V]); !ok || reflect.TypeFor[K]().Kind() != reflect.String {
+			bad("MissFilter requires string keys and a PrefixScanner store; %T is not", store)
+		}
+	}
+
+	if cfg.hashLongKeys && (reflect.TypeFor[K]().Kind() != reflect.String || keyLimit(store, cfg.victim) == 0) {
+		bad("HashLongKeys requires string keys and a store tier that implements KeyLimiter")
+	}
+
+	for _, p := range cfg.noPersistPatterns {
+		if _, err := path.Match(p, ""); err != nil {
+			bad("NoPersistPattern(%q): %w", p, err)
+		}
+	}
+	if len(cfg.noPersistPatterns) > 0 && reflect.TypeFor[K]().Kind() != reflect.String {
+		bad("NoPersistPattern requires string keys; use NoPersistFunc")
+	}
+	if cfg.noPersistFunc != nil {
+		if _, ok := cfg.noPersistFunc.(func(K) bool); !ok {
+			bad("NoPersistFunc takes %T; want func(%s) bool", cfg.noPersistFunc, reflect.TypeFor[K]())
+		}
+	}
+
+	if cfg.version != "" || cfg.purgeVersions {
+		if _, ok := any(store).(Versioner); !ok {
+			bad("Version requires a store that implements Versioner; %T does not", store)
+		}
+	}
+
+	if cfg.decodeFallback != nil {
+		if _, ok := cfg.decodeFallback.(func([]byte) (V, error)); !ok {
+			bad("DecodeFallback takes %T; want func([]byte) (%s, error)", cfg.decodeFallback, reflect.TypeFor[V]())
+		}
+		if _, ok := any(store).(FallbackDecoder[V]); !ok {
+			bad("DecodeFallback requires a store that implements FallbackDecoder; %T does not", store)
+		}
+	}
+
+	if cfg.prefetch != nil {
+		if _, ok := cfg.prefetch.(Prefetcher[K, V]); !ok {
+			bad("Prefetch takes %T; want Prefetcher[%s, %s]", cfg.prefetch, reflect.TypeFor[K](), reflect.TypeFor[V]())
+		}
+	}
+	if cfg.persistConcurrency < 0 {
+		bad("PersistConcurrency(%d) is negative", cfg.persistConcurrency)
+	}
+	if cfg.backgroundConcurrency < 0 {
+		bad("BackgroundConcurrency(%d) is negative", cfg.backgroundConcurrency)
+	}
+	if cfg.deadLetterSize < 0 {
+		bad("DeadLetter(%d) is negative", cfg.deadLetterSize)
+	}
+	if cfg.asyncQueueSize < 0 {
+		bad("AsyncQueue(%d) is negative", cfg.asyncQueueSize)
+	}
+	if cfg.backpressure > BackpressureDropOldest {
+		bad("AsyncQueue policy %v is unknown", cfg.backpressure)
+	}
+	if cfg.asyncQueueTimeout < 0 {
+		bad("AsyncQueue timeout %v is negative", cfg.asyncQueueTimeout)
+	}
+	if cfg.thresholdFn == nil && cfg.thresholdPct != 0 {
+		bad("ThresholdCallback(%v) has a nil callback", cfg.thresholdPct)
+	}
+	if cfg.thresholdFn != nil && (cfg.thresholdPct <= 0 || cfg.thresholdPct > 100) {
+		bad("ThresholdCallback(%v) is not a percentage in (0, 100]", cfg.thresholdPct)
+	}
+	if cfg.prefetchRate < 0 {
+		bad("Prefetch rate %d is negative", cfg.prefetchRate)
+	}
+
+	if cfg.cleanupInterval < 0 || cfg.cleanupMaxAge < 0 {
+		bad("AutoCleanup(%v, %v) has a negative duration", cfg.cleanupInterval, cfg.cleanupMaxAge)
+	}
+
+	if cfg.hotKeys < 0 {
+		bad("PersistHotKeys(%d) is negative", cfg.hotKeys)
+	} else if cfg.hotKeys > 0 {
+		if cfg.hotKeysInterval <= 0 {
+			bad("PersistHotKeys interval %v must be positive", cfg.hotKeysInterval)
+		}
+		if _, ok := baseStore(store).(HotKeyRecorder[K]); !ok {
+			bad("PersistHotKeys requires a store that implements HotKeyRecorder[%s]", reflect.TypeFor[K]())
+		}
+	}
+
+	if cfg.evictionTrace < 0 {
+		bad("EvictionTrace(%d) is negative", cfg.evictionTrace)
+	}
+	if cfg.deterministic {
+		if cfg.clockResolution >= 0 {
+			bad("Deterministic requires exact time; ClockResolution(%v) overrides it", cfg.clockResolution)
+		}
+		if cfg.prefetch != nil {
+			bad("Deterministic conflicts with Prefetch, which loads in the background")
+		}
+		if cfg.cleanupInterval > 0 {
+			bad("Deterministic conflicts with AutoCleanup, which deletes in the background")
+		}
+		if cfg.hotKeys > 0 {
+			bad("Deterministic conflicts with PersistHotKeys, which loads in the background")
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrInvalidConfig, errors.Join(errs...))
+}
+
+// checkSizer reports a Sizer whose value type is not V. New and NewTiered
+// both reject one rather than measure values with the default sizer.
+func checkSizer[V any](cfg *config, bad func(format string, args ...any)) {
+	if cfg.sizer != nil {
+		if _, ok := cfg.sizer.(func(V) int); !ok {
+			bad("Sizer takes %T; want func(%s) int", cfg.sizer, reflect.TypeFor[V]())
+		}
+	}
+}
+
+// mustCheckSizer is checkSizer for New, which has no error to return and
+// panics with ErrInvalidConfig instead.
+func mustCheckSizer[V any](cfg *config) {
+	checkSizer[V](cfg, func(format string, args ...any) {
+		panic(fmt.Errorf("%w: %w", ErrInvalidConfig, fmt.Errorf(format, args...)))
+	})
+}
+
+// sameStore reports whether a and b are the same store value.
+func sameStore(a, b any) bool {
+	if a == nil || b == nil || !reflect.TypeOf(a).Comparable() || reflect.TypeOf(a) != reflect.TypeOf(b) {
+		return false
+	}
+	return a == b
+}
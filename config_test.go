@@ -0,0 +1,144 @@
+package fido
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCache_Config(t *testing.T) {
+	cfg := New[string, string](Size(1000), TTL(time.Minute), LargeObjects(100, 1000)).Config()
+	if cfg.Size != 1000 || cfg.TTL != time.Minute {
+		t.Errorf("Size, TTL = %d, %v; want 1000, 1m", cfg.Size, cfg.TTL)
+	}
+	if cfg.SmallCapacity <= 0 || cfg.SmallCapacity >= cfg.Size || cfg.GhostCapacity <= 0 || cfg.DeathRowSize <= 0 {
+		t.Errorf("derived capacities = %+v", cfg)
+	}
+	if cfg.LargeThreshold != 100 || cfg.LargeMaxBytes != 1000 {
+		t.Errorf("LargeObjects = %d, %d; want 100, 1000", cfg.LargeThreshold, cfg.LargeMaxBytes)
+	}
+	if cfg.Store != "" {
+		t.Errorf("Store = %q; want empty for memory cache", cfg.Store)
+	}
+
+	if got := New[string, int]().Config().Size; got != 16384 {
+		t.Errorf("default Size = %d; want 16384", got)
+	}
+	if cfg := New[string, int](GhostFrequencies(1000), GhostFPRate(0.001)).Config(); cfg.GhostFreqs != 1000 || cfg.GhostFPRate != 0.001 {
+		t.Errorf("GhostFreqs, GhostFPRate = %d, %v; want 1000, 0.001", cfg.GhostFreqs, cfg.GhostFPRate)
+	}
+	if cfg := New[string, int](ExactGhosts()).Config(); !cfg.ExactGhosts || cfg.GhostFPRate != 0 {
+		t.Errorf("ExactGhosts, GhostFPRate = %v, %v; want true, 0", cfg.ExactGhosts, cfg.GhostFPRate)
+	}
+	// LargeObjects needs a sizer for int values, so it is not in effect.
+	if got := New[string, int](LargeObjects(100, 1000)).Config().LargeThreshold; got != 0 {
+		t.Errorf("LargeThreshold without sizer = %d; want 0", got)
+	}
+}
+
+func TestTieredCache_Config(t *testing.T) {
+	victim := newMockStore[string, int]()
+	cache, err := NewTiered(newMockStore[string, int](),
+		TTL(time.Hour), MemoryTTL(time.Minute), Victim[string, int](victim, time.Second),
+		AsyncWait(10*time.Millisecond), ReadYourWrites(), AutoCleanup(time.Hour, 2*time.Hour))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // test cleanup
+
+	cfg := cache.Config()
+	if cfg.Store != "*fido.mockStore[string,int]" || cfg.Victim != cfg.Store {
+		t.Errorf("Store, Victim = %q, %q; want mockStore types", cfg.Store, cfg.Victim)
+	}
+	if cfg.TTL != time.Hour || cfg.PersistTTL != time.Hour || cfg.MemoryTTL != time.Minute || cfg.VictimTTL != time.Second {
+		t.Errorf("TTLs = %+v", cfg)
+	}
+	if cfg.AsyncWait != 10*time.Millisecond || !cfg.ReadYourWrites || cfg.SnapshotOnShutdown {
+		t.Errorf("async settings = %+v", cfg)
+	}
+	if cfg.CleanupInterval != time.Hour || cfg.CleanupMaxAge != 2*time.Hour {
+		t.Errorf("cleanup = %v, %v; want 1h, 2h", cfg.CleanupInterval, cfg.CleanupMaxAge)
+	}
+}
+
+func TestNewTiered_InvalidConfig(t *testing.T) {
+	store := newMockStore[string, int]()
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"negative size", []Option{Size(-1)}, "Size(-1)"},
+		{"negative ttl", []Option{TTL(-time.Second)}, "TTL(-1s)"},
+		{"negative async wait", []Option{AsyncWait(-time.Second)}, "AsyncWait"},
+		{"sizer type", []Option{Sizer(func(string) int { return 0 })}, "Sizer takes func(string) int"},
+		{"clone type", []Option{CopyOnRead(func(s string) string { return s })}, "want func(int) int"},
+		{"ttl from value type", []Option{TTLFromValue(func(string) time.Duration { return 0 })}, "want func(int) time.Duration"},
+		{"large without threshold", []Option{LargeObjects(0, 100)}, "no threshold"},
+		{"large without sizer", []Option{LargeObjects(10, 100)}, "requires Sizer for int"},
+		{"negative max value", []Option{MaxValueBytes(-1)}, "MaxValueBytes"},
+		{"negative ghost frequencies", []Option{GhostFrequencies(-1)}, "GhostFrequencies(-1)"},
+		{"ghost fp rate", []Option{GhostFPRate(1)}, "GhostFPRate(1)"},
+		{"hot ttl factor", []Option{HotTTL(0.5, time.Hour)}, "HotTTL(0.5, 1h0m0s)"},
+		{"victim types", []Option{Victim[string, string](newMockStore[string, string](), time.Second)}, "want Store[string, int]"},
+		{"victim is store", []Option{Victim[string, int](store, time.Second)}, "is the persistence store"},
+		{"victim ttl", []Option{Victim[string, int](newMockStore[string, int](), 0)}, "must be positive"},
+		{"cleanup", []Option{AutoCleanup(time.Hour, -time.Hour)}, "AutoCleanup"},
+		{"negative early expiry", []Option{EarlyExpiry(-1)}, "EarlyExpiry(-1) is negative"},
+		{"early expiry with memory ttl", []Option{EarlyExpiry(1), MemoryTTL(time.Minute)}, "EarlyExpiry conflicts with MemoryTTL"},
+		{"negative miss filter", []Option{MissFilter(-1)}, "MissFilter(-1)"},
+		{"miss filter without scanner", []Option{MissFilter(100)}, "requires string keys and a PrefixScanner"},
+		{"hash long keys without limit", []Option{HashLongKeys()}, "HashLongKeys requires string keys and a store tier that implements KeyLimiter"},
+		{"malformed no-persist pattern", []Option{NoPersistPattern("nonce:[")}, `NoPersistPattern("nonce:["): syntax error in pattern`},
+		{"no-persist func for other keys", []Option{NoPersistFunc(func(int) bool { return true })}, "NoPersistFunc takes func(int) bool; want func(string) bool"},
+		{"decode fallback type", []Option{DecodeFallback(func([]byte) (string, error) { return "", nil })}, "want func([]byte) (int, error)"},
+		{"prefetch types", []Option{Prefetch[string, string](stringSource{}, 0)}, "want Prefetcher[string, int]"},
+		{"negative dead letter", []Option{DeadLetter(-1)}, "DeadLetter(-1)"},
+		{"negative async queue", []Option{AsyncQueue(-1, BackpressureReject, 0)}, "AsyncQueue(-1)"},
+		{"async queue policy", []Option{AsyncQueue(10, Backpressure(7), 0)}, "AsyncQueue policy Backpressure(7) is unknown"},
+		{"async queue timeout", []Option{AsyncQueue(10, BackpressureBlock, -time.Second)}, "AsyncQueue timeout -1s"},
+		{"negative persist concurrency", []Option{PersistConcurrency(-1)}, "PersistConcurrency(-1)"},
+		{"negative background concurrency", []Option{BackgroundConcurrency(-1)}, "BackgroundConcurrency(-1)"},
+		{"threshold without callback", []Option{ThresholdCallback(80, nil)}, "nil callback"},
+		{"threshold over 100", []Option{ThresholdCallback(150, func(Usage) {})}, "ThresholdCallback(150)"},
+		{"prefetch rate", []Option{Prefetch[string, int](&batchSource{}, -1)}, "Prefetch rate -1"},
+		{"negative eviction trace", []Option{EvictionTrace(-1)}, "EvictionTrace(-1)"},
+		{"cpu-local stats", []Option{CPULocalStats()}, "CPULocalStats requires TrackStats"},
+		{"deterministic clock", []Option{Deterministic(nil), ClockResolution(0)}, "ClockResolution(0s) overrides"},
+		{"deterministic prefetch", []Option{Deterministic(nil), Prefetch[string, int](&batchSource{}, 0)}, "Deterministic conflicts with Prefetch"},
+		{"deterministic cleanup", []Option{Deterministic(nil), AutoCleanup(time.Hour, time.Hour)}, "Deterministic conflicts with AutoCleanup"},
+		{"decode fallback store", []Option{DecodeFallback(func([]byte) (int, error) { return 0, nil })}, "requires a store that implements FallbackDecoder"},
+		{"negative hot keys", []Option{PersistHotKeys(-1, time.Minute)}, "PersistHotKeys(-1)"},
+		{"hot keys interval", []Option{PersistHotKeys(10, 0)}, "PersistHotKeys interval 0s must be positive"},
+		{"hot keys store", []Option{PersistHotKeys(10, time.Minute)}, "requires a store that implements HotKeyRecorder[string]"},
+		{"deterministic hot keys", []Option{Deterministic(nil), PersistHotKeys(10, time.Minute)}, "Deterministic conflicts with PersistHotKeys"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTiered[string, int](store, tt.opts...)
+			if !errors.Is(err, ErrInvalidConfig) {
+				t.Fatalf("NewTiered error = %v; want ErrInvalidConfig", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q; want it to mention %q", err, tt.want)
+			}
+		})
+	}
+
+	// All problems are reported together.
+	_, err := NewTiered[string, int](store, Size(-1), MaxValueBytes(-1))
+	if err == nil || !strings.Contains(err.Error(), "Size") || !strings.Contains(err.Error(), "MaxValueBytes") {
+		t.Errorf("NewTiered error = %v; want both problems", err)
+	}
+
+	// A matching sizer makes LargeObjects valid.
+	cache, err := NewTiered[string, int](store, LargeObjects(10, 100), Sizer(func(int) int { return 8 }))
+	if err != nil {
+		t.Fatalf("NewTiered with sizer: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // test cleanup
+	if cache.Config().LargeThreshold != 10 {
+		t.Errorf("LargeThreshold = %d; want 10", cache.Config().LargeThreshold)
+	}
+}
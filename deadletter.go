@@ -0,0 +1,182 @@
+package fido
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// FailedWrite is an async persistence write that failed, held by DeadLetter.
+type FailedWrite[K comparable, V any] struct {
+	Time    time.Time // when the write failed
+	Err     error
+	Key     K
+	Value   V         // zero for a delete
+	Expiry  time.Time // persisted expiry the write would have set
+	Deleted bool      // a DeleteAsync rather than a SetAsync
+}
+
+// DeadLetterStats counts async writes that failed to persist.
+type DeadLetterStats struct {
+	Failed   uint64 // since creation
+	Dropped  uint64 // evicted from the full buffer before being redriven
+	Redriven uint64 // persisted later by Redrive
+	Buffered int    // held now
+}
+
+// deadLetters holds the latest failed async write per key, up to size,
+// dropping the oldest when full. A nil *deadLetters holds nothing.
+//
+//nolint:govet // fieldalignment: semantic grouping preferred
+type deadLetters[K comparable, V any] struct {
+	mu      sync.Mutex
+	order   *list.List          // of *FailedWrite[K, V], oldest first
+	byKey   map[K]*list.Element // entry for each buffered key
+	size    int
+	n       atomic.Int32 // buffered entries, read without mu
+	failed  atomic.Uint64
+	dropped atomic.Uint64
+	redrive atomic.Uint64
+}
+
+func newDeadLetters[K comparable, V any](cfg *config) *deadLetters[K, V] {
+	if cfg.deadLetterSize <= 0 {
+		return nil
+	}
+	return &deadLetters[K, V]{order: list.New(), byKey: make(map[K]*list.Element), size: cfg.deadLetterSize}
+}
+
+// add records a failed write, replacing any earlier failure for its key.
+func (d *deadLetters[K, V]) add(w *FailedWrite[K, V]) {
+	if d == nil {
+		return
+	}
+	d.failed.Add(1)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if e, ok := d.byKey[w.Key]; ok {
+		d.order.Remove(e)
+	}
+	d.byKey[w.Key] = d.order.PushBack(w)
+	if d.order.Len() > d.size {
+		oldest := d.order.Front()
+		delete(d.byKey, d.order.Remove(oldest).(*FailedWrite[K, V]).Key) //nolint:errcheck,forcetypeassert // only *FailedWrite is stored
+		d.dropped.Add(1)
+	}
+	d.n.Store(int32(d.order.Len())) //nolint:gosec // G115: bounded by size
+}
+
+// forget drops key's failed write, superseded by a newer write.
+func (d *deadLetters[K, V]) forget(key K) {
+	if d == nil || d.n.Load() == 0 {
+		return
+	}
+	d.remove(key, nil)
+}
+
+// remove drops key's failed write, if it is w or w is nil, reporting whether it did.
+func (d *deadLetters[K, V]) remove(key K, w *FailedWrite[K, V]) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e, ok := d.byKey[key]
+	if !ok || (w != nil && e.Value != w) {
+		return false
+	}
+	d.order.Remove(e)
+	delete(d.byKey, key)
+	d.n.Store(int32(d.order.Len())) //nolint:gosec // G115: bounded by size
+	return true
+}
+
+// clear drops every buffered write, e.g. after a Flush makes them stale.
+func (d *deadLetters[K, V]) clear() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.order.Init()
+	clear(d.byKey)
+	d.n.Store(0)
+}
+
+// snapshot returns the buffered writes, oldest first.
+func (d *deadLetters[K, V]) snapshot() []*FailedWrite[K, V] {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := make([]*FailedWrite[K, V], 0, d.order.Len())
+	for e := d.order.Front(); e != nil; e = e.Next() {
+		out = append(out, e.Value.(*FailedWrite[K, V])) //nolint:errcheck,forcetypeassert // only *FailedWrite is stored
+	}
+	return out
+}
+
+// FailedWrites returns the async writes that failed to persist and have not
+// been superseded or redriven, oldest first. It is empty unless the cache was
+// created with DeadLetter.
+func (c *TieredCache[K, V]) FailedWrites() []FailedWrite[K, V] {
+	ws := c.dead.snapshot()
+	out := make([]FailedWrite[K, V], len(ws))
+	for i, w := range ws {
+		out[i] = *w
+	}
+	return out
+}
+
+// DeadLetterStats reports counts of failed async writes, for metrics.
+// Counts are zero unless the cache was created with DeadLetter.
+func (c *TieredCache[K, V]) DeadLetterStats() DeadLetterStats {
+	if c.dead == nil {
+		return DeadLetterStats{}
+	}
+	return DeadLetterStats{
+		Failed:   c.dead.failed.Load(),
+		Dropped:  c.dead.dropped.Load(),
+		Redriven: c.dead.redrive.Load(),
+		Buffered: int(c.dead.n.Load()),
+	}
+}
+
+// Redrive retries the buffered failed writes synchronously, oldest first,
+// and returns how many reached persistence. Writes that fail again stay
+// buffered and are reported in a *MultiError. A write superseded by a newer
+// one to the same key while Redrive runs is not retried.
+func (c *TieredCache[K, V]) Redrive(ctx context.Context) (int, error) {
+	var errs map[K]error
+	n := 0
+	for _, w := range c.dead.snapshot() {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+		if _, ok := c.pending.tail.Load(w.Key); ok {
+			continue // a newer async write is in flight
+		}
+		if !w.Deleted && !w.Expiry.IsZero() && time.Now().After(w.Expiry) {
+			c.dead.remove(w.Key, w) // nothing left to persist
+			continue
+		}
+		var err error
+		if w.Deleted {
+			err = c.Store.Delete(ctx, w.Key)
+		} else {
+			err = c.Store.Set(ctx, w.Key, w.Value, w.Expiry)
+		}
+		if err != nil {
+			if errs == nil {
+				errs = make(map[K]error)
+			}
+			errs[w.Key] = err
+			continue
+		}
+		if c.dead.remove(w.Key, w) {
+			c.dead.redrive.Add(1)
+		}
+		n++
+	}
+	return n, multiErr(errs)
+}
@@ -0,0 +1,104 @@
+package fido
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTieredCache_DeadLetter(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store, DeadLetter(2))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+	if cache.Config().DeadLetterSize != 2 {
+		t.Errorf("DeadLetterSize = %d; want 2", cache.Config().DeadLetterSize)
+	}
+
+	store.setFailSet(true)
+	for i, k := range []string{"a", "b", "c"} {
+		if err := cache.SetAsync(ctx, k, i); err != nil {
+			t.Fatalf("SetAsync: %v", err)
+		}
+		waitFor(t, func() bool { return cache.DeadLetterStats().Failed == uint64(i+1) })
+	}
+	// The buffer holds the latest two; the oldest was dropped.
+	got := cache.FailedWrites()
+	if len(got) != 2 || got[0].Key != "b" || got[1].Key != "c" || got[1].Value != 2 || got[1].Err == nil {
+		t.Fatalf("FailedWrites = %+v; want b then c", got)
+	}
+	if s := cache.DeadLetterStats(); s.Dropped != 1 || s.Buffered != 2 {
+		t.Errorf("DeadLetterStats = %+v; want 1 dropped, 2 buffered", s)
+	}
+
+	// A newer write supersedes a failed one.
+	store.setFailSet(false)
+	if err := cache.Set(ctx, "c", 20); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got := cache.FailedWrites(); len(got) != 1 || got[0].Key != "b" {
+		t.Fatalf("FailedWrites after Set(c) = %+v; want only b", got)
+	}
+
+	n, err := cache.Redrive(ctx)
+	if err != nil || n != 1 {
+		t.Errorf("Redrive = %d, %v; want 1, nil", n, err)
+	}
+	if v, _, found, _ := store.Get(ctx, "b"); !found || v != 1 { //nolint:errcheck // mock
+		t.Errorf("redriven b = %d, %v; want 1, true", v, found)
+	}
+	if s := cache.DeadLetterStats(); s.Redriven != 1 || s.Buffered != 0 {
+		t.Errorf("DeadLetterStats = %+v; want 1 redriven, 0 buffered", s)
+	}
+
+	// A failed delete is kept, and stays buffered while redrive fails.
+	store.setFailSet(true) // also fails Delete
+	if err := cache.DeleteAsync(ctx, "b"); err != nil {
+		t.Fatalf("DeleteAsync: %v", err)
+	}
+	waitFor(t, func() bool { return len(cache.FailedWrites()) == 1 })
+	if w := cache.FailedWrites()[0]; w.Key != "b" || !w.Deleted {
+		t.Errorf("FailedWrites = %+v; want the delete of b", w)
+	}
+	n, err = cache.Redrive(ctx)
+	var me *MultiError[string]
+	if n != 0 || !errors.As(err, &me) || me.Errs["b"] == nil {
+		t.Errorf("Redrive = %d, %v; want 0 and a MultiError for b", n, err)
+	}
+	if len(cache.FailedWrites()) != 1 {
+		t.Error("write that failed again left the buffer")
+	}
+
+	_, _ = cache.Flush(ctx) //nolint:errcheck // the store fails; the buffer is cleared regardless
+	if len(cache.FailedWrites()) != 0 {
+		t.Error("Flush left failed writes buffered")
+	}
+}
+
+func TestTieredCache_DeadLetterDisabled(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	store.setFailSet(true)
+	if err := cache.SetAsync(ctx, "a", 1); err != nil {
+		t.Fatalf("SetAsync: %v", err)
+	}
+	waitFor(t, func() bool { return !cache.Info("a").PendingPersist })
+	if got := cache.FailedWrites(); len(got) != 0 {
+		t.Errorf("FailedWrites = %v; want none without DeadLetter", got)
+	}
+	if n, err := cache.Redrive(ctx); n != 0 || err != nil {
+		t.Errorf("Redrive = %d, %v; want 0, nil", n, err)
+	}
+	if s := cache.DeadLetterStats(); s != (DeadLetterStats{}) {
+		t.Errorf("DeadLetterStats = %+v; want zero", s)
+	}
+}
@@ -0,0 +1,221 @@
+package fido
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// depGraph records which keys were set with dependencies on which others, so
+// invalidating a key can cascade to everything derived from it. The zero
+// value is empty and ready to use.
+//
+// Edges live in process memory only: they are not persisted, not shared with
+// other processes, and lost on restart. A key's edges to its dependencies are
+// dropped when either end is invalidated through the graph, when the key is
+// set again with dependencies, when it leaves a Cache's memory, and once the
+// expiry it was linked with has passed, so the graph holds only live keys.
+type depGraph[K comparable] struct {
+	mu      sync.Mutex
+	down    map[K]map[K]struct{} // key -> keys that depend on it
+	up      map[K]depLink[K]     // key -> keys it depends on
+	sweepAt int                  // len(up) at which link next drops expired keys
+	n       atomic.Int64         // len(up), read without mu to skip it when empty
+}
+
+// depLink is a key's dependencies and the expiry it was set with.
+type depLink[K comparable] struct {
+	deps   []K
+	expiry time.Time // zero means never
+}
+
+// minDepSweep is the smallest graph link sweeps for expired keys, so small
+// graphs are not swept on every link.
+const minDepSweep = 1024
+
+// link replaces key's dependencies with deps, until expiry if it is not zero.
+// As the graph doubles in size it sweeps out keys whose expiry has passed,
+// which keeps the cost amortized O(1) per link.
+func (g *depGraph[K]) link(key K, deps []K, expiry time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	defer func() { g.n.Store(int64(len(g.up))) }()
+	g.unlink(key)
+	if len(deps) == 0 {
+		return
+	}
+	if g.down == nil {
+		g.down = make(map[K]map[K]struct{})
+		g.up = make(map[K]depLink[K])
+	}
+	for _, d := range deps {
+		if d == key {
+			continue
+		}
+		if g.down[d] == nil {
+			g.down[d] = make(map[K]struct{})
+		}
+		g.down[d][key] = struct{}{}
+	}
+	g.up[key] = depLink[K]{deps: append([]K(nil), deps...), expiry: expiry}
+	if len(g.up) >= g.sweepAt {
+		now := time.Now()
+		for k, l := range g.up {
+			if !l.expiry.IsZero() && now.After(l.expiry) {
+				g.unlink(k)
+			}
+		}
+		g.sweepAt = max(2*len(g.up), minDepSweep)
+	}
+}
+
+// unlink drops key's edges to the keys it depends on. g.mu must be held.
+func (g *depGraph[K]) unlink(key K) {
+	for _, d := range g.up[key].deps {
+		delete(g.down[d], key)
+		if len(g.down[d]) == 0 {
+			delete(g.down, d)
+		}
+	}
+	delete(g.up, key)
+}
+
+// forget drops key's edges to the keys it depends on, for a key that is gone
+// without invalidating its dependents.
+func (g *depGraph[K]) forget(key K) {
+	if g.n.Load() == 0 {
+		return
+	}
+	g.mu.Lock()
+	g.unlink(key)
+	g.n.Store(int64(len(g.up)))
+	g.mu.Unlock()
+}
+
+// cascade removes key and everything that transitively depends on it from
+// the graph, and returns the dependents, not including key. Cycles are
+// followed once.
+func (g *depGraph[K]) cascade(key K) []K {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.down) == 0 {
+		return nil
+	}
+	seen := map[K]struct{}{key: {}}
+	var out []K
+	queue := []K{key}
+	for len(queue) > 0 {
+		k := queue[0]
+		queue = queue[1:]
+		for d := range g.down[k] {
+			if _, ok := seen[d]; ok {
+				continue
+			}
+			seen[d] = struct{}{}
+			out = append(out, d)
+			queue = append(queue, d)
+		}
+		delete(g.down, k)
+	}
+	for k := range seen {
+		g.unlink(k)
+	}
+	g.n.Store(int64(len(g.up)))
+	return out
+}
+
+// clear drops every edge, for Flush and BumpEpoch.
+func (g *depGraph[K]) clear() {
+	g.mu.Lock()
+	g.down, g.up, g.sweepAt = nil, nil, 0
+	g.n.Store(0)
+	g.mu.Unlock()
+}
+
+// attachDeps forgets the dependencies of entries as they leave s through
+// eviction or expiry, after any eviction hook already set.
+func attachDeps[K comparable, V any](g *depGraph[K], s *s3fifo[K, V]) {
+	prev := s.onDrop
+	s.onDrop = func(key K, hash uint64, value V, expirySec uint32) {
+		if prev != nil {
+			prev(key, hash, value, expirySec)
+		}
+		g.forget(key)
+	}
+}
+
+// SetWithDependencies stores value like Set and records that key depends on
+// deps, so deleting any of them also deletes key, and in turn anything that
+// depends on key. Use it for values derived from other cached entries, such
+// as an aggregate built from several raw objects, so that invalidating one
+// source drops only what was built from it.
+//
+// Delete, GetAndDelete, and DeleteFunc cascade. Nothing else does:
+// overwriting a dependency with Set, expiry, and eviction leave its dependents
+// in place, and Flush and BumpEpoch remove everything. When key itself expires
+// or is evicted, its dependencies are forgotten. Setting key again with
+// dependencies replaces the ones recorded before.
+func (c *Cache[K, V]) SetWithDependencies(key K, value V, deps ...K) {
+	c.Set(key, value)
+	c.deps.link(key, deps, time.Time{}) // forgotten by attachDeps as key leaves memory
+}
+
+// deleteDependents deletes everything that depends on key and returns it.
+func (c *Cache[K, V]) deleteDependents(key K) []K {
+	deps := c.deps.cascade(key)
+	var zero V
+	for _, d := range deps {
+		forgetFlight(c.flights, d)
+		c.memory.del(d)
+		c.events.publish(EventDelete, d, zero)
+	}
+	return deps
+}
+
+// SetWithDependencies stores value like Set and records that key depends on
+// deps, as Cache.SetWithDependencies. Deleting a dependency deletes key from
+// memory and persistence. The dependencies are known only to this process:
+// other processes sharing the store, or this one after a restart, don't
+// cascade.
+//
+// Delete, DeleteAsync, GetAndDelete, DeleteFunc, and deletes committed by Tx
+// cascade. Nothing else does: overwriting a dependency with Set, expiry,
+// eviction from memory, and FlushOwner leave its dependents in place, and
+// Flush and BumpEpoch remove everything. Key's dependencies are forgotten
+// once its persisted entry expires, or when FlushOwner removes it; evicting
+// key from memory keeps them, since the store still holds it.
+func (c *TieredCache[K, V]) SetWithDependencies(ctx context.Context, key K, value V, deps ...K) error {
+	if err := c.Set(ctx, key, value); err != nil {
+		return err
+	}
+	c.deps.link(key, deps, c.expiry(value, 0))
+	return nil
+}
+
+// deleteDependents deletes everything that depends on key from memory and
+// persistence, and returns them.
+func (c *TieredCache[K, V]) deleteDependents(ctx context.Context, key K) ([]K, error) {
+	deps := c.deps.cascade(key)
+	return deps, c.deleteDeps(ctx, deps)
+}
+
+// deleteDeps deletes dependents returned by cascade from memory and persistence.
+func (c *TieredCache[K, V]) deleteDeps(ctx context.Context, deps []K) error {
+	var errs []error
+	for _, d := range deps {
+		forgetFlight(c.flights, d)
+		c.memory.del(d)
+		c.forget(ctx, d)
+		c.thaw(d)
+		if err := c.Store.Delete(ctx, d); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("dependent delete: %w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package fido
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCache_SetWithDependencies(t *testing.T) {
+	cache := New[string, int]()
+	cache.Set("user:1", 1)
+	cache.Set("user:2", 2)
+	cache.SetWithDependencies("team", 3, "user:1", "user:2")
+	cache.SetWithDependencies("org", 4, "team")
+	cache.SetWithDependencies("user:1:profile", 5, "user:1")
+
+	cache.Delete("user:2")
+	for _, k := range []string{"team", "org"} {
+		if _, ok := cache.Get(k); ok {
+			t.Errorf("Get(%s) found after deleting what it depends on", k)
+		}
+	}
+	for _, k := range []string{"user:1", "user:1:profile"} {
+		if _, ok := cache.Get(k); !ok {
+			t.Errorf("Get(%s) missing after deleting an unrelated key", k)
+		}
+	}
+
+	// Edges are dropped once cascaded, so a plain Set isn't invalidated later.
+	cache.Set("team", 6)
+	cache.Delete("user:1:profile")
+	cache.Delete("user:1")
+	if v, ok := cache.Get("team"); !ok || v != 6 {
+		t.Errorf("Get(team) = %d, %v; want 6, true", v, ok)
+	}
+}
+
+func TestCache_SetWithDependencies_Cycle(t *testing.T) {
+	cache := New[string, int]()
+	cache.SetWithDependencies("a", 1, "b")
+	cache.SetWithDependencies("b", 2, "a")
+	cache.Delete("a")
+	if _, ok := cache.Get("b"); ok {
+		t.Error("Get(b) found after deleting a")
+	}
+}
+
+func TestCache_SetWithDependencies_Replace(t *testing.T) {
+	cache := New[string, int]()
+	cache.SetWithDependencies("derived", 1, "old")
+	cache.SetWithDependencies("derived", 2, "new")
+	cache.Delete("old")
+	if _, ok := cache.Get("derived"); !ok {
+		t.Error("deleting a replaced dependency removed the key")
+	}
+	if v, ok := cache.GetAndDelete("new"); ok {
+		t.Errorf("GetAndDelete(new) = %d; want not found", v)
+	}
+	if _, ok := cache.Get("derived"); ok {
+		t.Error("GetAndDelete of a dependency left the key")
+	}
+}
+
+func TestCache_DeleteFunc_Dependencies(t *testing.T) {
+	cache := New[string, int]()
+	cache.Set("a", 1)
+	cache.Set("b", 1)
+	cache.SetWithDependencies("c", 2, "a", "b")
+	cache.SetWithDependencies("d", 3, "c")
+	if n := cache.DeleteFunc(func(_ string, v int) bool { return v == 1 }); n != 4 {
+		t.Errorf("DeleteFunc removed %d; want 4", n)
+	}
+	if cache.Len() != 0 {
+		t.Errorf("Len = %d; want 0", cache.Len())
+	}
+}
+
+func TestCache_SetWithDependencies_Evicted(t *testing.T) {
+	cache := New[int, int](Size(100))
+	for i := range 10_000 {
+		cache.SetWithDependencies(i, i, -1)
+	}
+	// Evicted keys' edges are gone, leaving roughly what memory holds.
+	if n := cache.deps.n.Load(); n > 1000 {
+		t.Errorf("graph holds %d keys after evicting most of 10000; want them pruned", n)
+	}
+}
+
+func TestDepGraph_SweepExpired(t *testing.T) {
+	var g depGraph[string]
+	past := time.Now().Add(-time.Second)
+	for i := range 10 * minDepSweep {
+		g.link(strconv.Itoa(i), []string{"src"}, past)
+	}
+	g.link("live", []string{"src"}, time.Time{})
+	if n := g.n.Load(); n > 2*minDepSweep {
+		t.Errorf("graph holds %d keys; want expired ones swept", n)
+	}
+	deps := g.cascade("src")
+	if !slices.Contains(deps, "live") {
+		t.Errorf("cascade(src) = %d keys without live; want live kept", len(deps))
+	}
+}
+
+func TestTieredCache_SetWithDependencies(t *testing.T) {
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	ctx := context.Background()
+	if err := cache.Set(ctx, "repo", 1); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := cache.SetWithDependencies(ctx, "stars", 2, "repo"); err != nil {
+		t.Fatalf("SetWithDependencies: %v", err)
+	}
+	if err := cache.SetWithDependencies(ctx, "rank", 3, "stars"); err != nil {
+		t.Fatalf("SetWithDependencies: %v", err)
+	}
+	if err := cache.Set(ctx, "other", 4); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	if err := cache.Delete(ctx, "repo"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	for _, k := range []string{"stars", "rank"} {
+		if _, found, _ := cache.Get(ctx, k); found { //nolint:errcheck // only checking presence
+			t.Errorf("Get(%s) found after deleting its dependency", k)
+		}
+		if _, _, found, _ := store.Get(ctx, k); found { //nolint:errcheck // only checking presence
+			t.Errorf("%s still persisted after deleting its dependency", k)
+		}
+	}
+	if _, found, _ := cache.Get(ctx, "other"); !found { //nolint:errcheck // only checking presence
+		t.Error("Get(other) missing after deleting an unrelated key")
+	}
+}
+
+func TestTieredCache_DeleteAsync_Dependencies(t *testing.T) {
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	ctx := context.Background()
+	if err := cache.Set(ctx, "src", 1); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := cache.SetWithDependencies(ctx, "derived", 2, "src"); err != nil {
+		t.Fatalf("SetWithDependencies: %v", err)
+	}
+	if err := cache.DeleteAsync(ctx, "src"); err != nil {
+		t.Fatalf("DeleteAsync: %v", err)
+	}
+	if _, found, _ := cache.Get(ctx, "derived"); found { //nolint:errcheck // only checking presence
+		t.Error("Get(derived) found after DeleteAsync of its dependency")
+	}
+	waitFor(t, func() bool { _, _, found, _ := store.Get(ctx, "derived"); return !found }) //nolint:errcheck // polling
+
+	if err := cache.Set(ctx, "a", 1); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := cache.SetWithDependencies(ctx, "b", 2, "a"); err != nil {
+		t.Fatalf("SetWithDependencies: %v", err)
+	}
+	n, err := cache.DeleteFunc(ctx, func(k string, _ int) bool { return k == "a" })
+	if err != nil || n != 2 {
+		t.Errorf("DeleteFunc = %d, %v; want 2, nil", n, err)
+	}
+	if _, _, found, _ := store.Get(ctx, "b"); found { //nolint:errcheck // only checking presence
+		t.Error("b still persisted after DeleteFunc removed its dependency")
+	}
+}
+
+func TestTieredCache_Tx_Dependencies(t *testing.T) {
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	ctx := context.Background()
+	for _, k := range []string{"derived", "rewritten"} {
+		if err := cache.SetWithDependencies(ctx, k, 1, "src"); err != nil {
+			t.Fatalf("SetWithDependencies(%s): %v", k, err)
+		}
+	}
+	err = cache.Tx(ctx, func(tx *Txn[string, int]) error {
+		_ = tx.Delete("src") //nolint:errcheck // buffered
+		return tx.Set("rewritten", 2)
+	})
+	if err != nil {
+		t.Fatalf("Tx: %v", err)
+	}
+	if _, _, found, _ := store.Get(ctx, "derived"); found { //nolint:errcheck // only checking presence
+		t.Error("derived still persisted after Tx deleted its dependency")
+	}
+	if _, found, _ := cache.Get(ctx, "derived"); found { //nolint:errcheck // only checking presence
+		t.Error("Get(derived) found after Tx deleted its dependency")
+	}
+	if v, found, _ := cache.Get(ctx, "rewritten"); !found || v != 2 { //nolint:errcheck // only checking presence
+		t.Errorf("Get(rewritten) = %d, %v; want the transaction's own 2, true", v, found)
+	}
+}
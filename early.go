@@ -0,0 +1,78 @@
+package fido
+
+import (
+	"math"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+// earlyExpiry decides when Fetch recomputes a value before it expires, for
+// EarlyExpiry. It follows XFetch (Vattani et al., "Optimal Probabilistic
+// Cache Stampede Prevention"): a hit recomputes when
+//
+//	now + delta * beta * -ln(rand()) >= expiry
+//
+// where delta is how long recomputing takes. Each hit draws independently,
+// so the chance of refreshing rises smoothly toward expiry and, under steady
+// traffic, one request refreshes a hot key before the others ever miss it.
+type earlyExpiry struct {
+	beta  float64
+	delta atomic.Int64 // moving average of loader durations, in nanoseconds
+}
+
+// newEarlyExpiry returns the EarlyExpiry policy, or nil when it is unset.
+func newEarlyExpiry(cfg *config) *earlyExpiry {
+	if cfg.earlyBeta <= 0 {
+		return nil
+	}
+	return &earlyExpiry{beta: cfg.earlyBeta}
+}
+
+// due reports whether a hit on a value expiring at expirySec, read at nowSec,
+// should recompute it. Values without an expiry, and any value before a
+// loader has been timed, are never due.
+func (e *earlyExpiry) due(expirySec, nowSec uint32) bool {
+	if e == nil || expirySec == 0 {
+		return false
+	}
+	delta := e.delta.Load()
+	if delta <= 0 {
+		return false
+	}
+	gap := float64(delta) * e.beta * -math.Log(rand.Float64()) //nolint:gosec // G404: sampling needs no crypto randomness
+	remaining := float64(int64(expirySec)-int64(nowSec)) * float64(time.Second)
+	return remaining <= gap
+}
+
+// observe folds a loader duration into delta. Racing updates may drop a
+// sample, which only nudges the average.
+func (e *earlyExpiry) observe(d time.Duration) {
+	if e == nil {
+		return
+	}
+	old := e.delta.Load()
+	if old == 0 {
+		e.delta.Store(max(int64(d), 1))
+		return
+	}
+	e.delta.Store(old + (int64(d)-old)/8)
+}
+
+// expiry returns the expiry in Unix seconds of key's value in the main
+// table, or 0 if it has none or is held elsewhere.
+func (c *s3fifo[K, V]) expiry(key K) uint32 {
+	ref, ok := c.entries.Load(key)
+	if !ok {
+		return 0
+	}
+	if sl := ref.load(); sl != nil {
+		return sl.expirySec
+	}
+	return 0
+}
+
+// refreshDue reports whether a Fetch hit on key should recompute it early.
+func (c *s3fifo[K, V]) refreshDue(e *earlyExpiry, key K) bool {
+	return e != nil && e.due(c.expiry(key), c.now())
+}
@@ -0,0 +1,122 @@
+package fido
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEarlyExpiry_Due(t *testing.T) {
+	e := newEarlyExpiry(&config{earlyBeta: 1})
+	now := unixSec()
+	if e.due(now+1, now) {
+		t.Error("due before any loader was timed")
+	}
+	e.observe(time.Second)
+	if e.due(0, now) {
+		t.Error("a value without expiry should never be due")
+	}
+	if !e.due(now, now) {
+		t.Error("a value at its expiry should be due")
+	}
+	// With delta 1s and beta 1, 100s ahead is due with probability e^-100.
+	for range 1000 {
+		if e.due(now+100, now) {
+			t.Fatal("a value 100 loader-times from expiry was due")
+		}
+	}
+	// 1s ahead is due with probability e^-1, so 1000 draws all but surely mix.
+	var hits int
+	for range 1000 {
+		if e.due(now+1, now) {
+			hits++
+		}
+	}
+	if hits == 0 || hits == 1000 {
+		t.Errorf("1s ahead was due %d of 1000 times; want some but not all", hits)
+	}
+
+	var off *earlyExpiry
+	off.observe(time.Second)
+	if off.due(now, now) {
+		t.Error("nil policy reported due")
+	}
+}
+
+func TestEarlyExpiry_Observe(t *testing.T) {
+	e := newEarlyExpiry(&config{earlyBeta: 1})
+	e.observe(800 * time.Millisecond)
+	for range 100 {
+		e.observe(0)
+	}
+	if d := time.Duration(e.delta.Load()); d > time.Millisecond {
+		t.Errorf("delta = %v after many instant loads; want it to decay toward 0", d)
+	}
+}
+
+func TestCache_EarlyExpiry(t *testing.T) {
+	// A huge beta makes every hit on a timed key due: even if the loader is
+	// timed at 1ns, a hit a minute from expiry is due unless the exponential
+	// draw lands below 6e-8.
+	cache := New[string, int](EarlyExpiry(1e18), TTL(time.Minute))
+	calls := 0
+	loader := func() (int, error) {
+		calls++
+		return calls, nil
+	}
+	if v, err := cache.Fetch("k", loader); err != nil || v != 1 {
+		t.Fatalf("Fetch = %d, %v; want 1, nil", v, err)
+	}
+	if v, err := cache.Fetch("k", loader); err != nil || v != 2 {
+		t.Errorf("Fetch = %d, %v; want an early refresh to 2", v, err)
+	}
+	if v, _ := cache.Get("k"); v != 2 {
+		t.Errorf("Get = %d; want the refreshed 2", v)
+	}
+
+	// A failed early refresh returns the still-live cached value.
+	v, err := cache.Fetch("k", func() (int, error) { return 0, errors.New("backend down") })
+	if err != nil || v != 2 {
+		t.Errorf("Fetch with failing loader = %d, %v; want cached 2, nil", v, err)
+	}
+
+	// Without a TTL, nothing is refreshed early.
+	cache.SetTTL("forever", 7, 0)
+	if v, err := cache.Fetch("forever", loader); err != nil || v != 7 {
+		t.Errorf("Fetch(forever) = %d, %v; want 7, nil", v, err)
+	}
+	if cache.Config().EarlyExpiry != 1e18 {
+		t.Errorf("Config().EarlyExpiry = %v; want 1e18", cache.Config().EarlyExpiry)
+	}
+}
+
+func TestTieredCache_EarlyExpiry(t *testing.T) {
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store, EarlyExpiry(1e18), TTL(time.Minute))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	ctx := context.Background()
+	calls := 0
+	loader := func(context.Context) (int, error) {
+		calls++
+		return calls, nil
+	}
+	if v, err := cache.Fetch(ctx, "k", loader); err != nil || v != 1 {
+		t.Fatalf("Fetch = %d, %v; want 1, nil", v, err)
+	}
+	if v, err := cache.Fetch(ctx, "k", loader); err != nil || v != 2 {
+		t.Errorf("Fetch = %d, %v; want an early refresh to 2", v, err)
+	}
+	if v, _, found, _ := store.Get(ctx, "k"); !found || v != 2 { //nolint:errcheck // mock never fails
+		t.Errorf("persisted = %d, %v; want the refreshed 2", v, found)
+	}
+
+	v, err := cache.Fetch(ctx, "k", func(context.Context) (int, error) { return 0, errors.New("backend down") })
+	if err != nil || v != 2 {
+		t.Errorf("Fetch with failing loader = %d, %v; want cached 2, nil", v, err)
+	}
+}
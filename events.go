@@ -0,0 +1,128 @@
+package fido
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventOp is the kind of change in an Event.
+type EventOp uint8
+
+// Event operations.
+const (
+	EventSet    EventOp = iota // Set, SetTTL, a stored GetOrSet, Swap, or a Fetch load
+	EventDelete                // Delete, GetAndDelete, or DeleteFunc
+	EventExpire                // an expired entry left memory
+	EventEvict                 // a live entry was evicted by capacity pressure
+)
+
+var eventOpNames = [...]string{"set", "delete", "expire", "evict"}
+
+func (op EventOp) String() string {
+	if int(op) < len(eventOpNames) {
+		return eventOpNames[op]
+	}
+	return "unknown"
+}
+
+// Event describes a change to a Cache, as delivered by Subscribe.
+type Event[K comparable, V any] struct {
+	Time  time.Time
+	Key   K
+	Value V // the value set or removed; zero for Delete and DeleteFunc
+	Op    EventOp
+}
+
+// subscriberBuffer is the channel capacity of each subscription.
+const subscriberBuffer = 256
+
+type subscriber[K comparable, V any] struct {
+	ch      chan Event[K, V]
+	filter  func(Event[K, V]) bool
+	dropped atomic.Uint64
+}
+
+// eventBus fans events out to subscribers. Publishing costs one atomic load
+// while there are none.
+type eventBus[K comparable, V any] struct {
+	mu   sync.RWMutex
+	subs map[*subscriber[K, V]]struct{}
+	n    atomic.Int32
+}
+
+func newEventBus[K comparable, V any]() *eventBus[K, V] {
+	return &eventBus[K, V]{subs: make(map[*subscriber[K, V]]struct{})}
+}
+
+// publish delivers an event to matching subscribers, dropping it for those
+// whose channel is full. Evictions are published under the s3fifo lock.
+func (b *eventBus[K, V]) publish(op EventOp, key K, value V) {
+	if b.n.Load() == 0 {
+		return
+	}
+	ev := Event[K, V]{Time: time.Now(), Key: key, Value: value, Op: op}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for s := range b.subs {
+		if s.filter != nil && !s.filter(ev) {
+			continue
+		}
+		select {
+		case s.ch <- ev:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// attach publishes s's evictions, after any eviction hook already set.
+func (b *eventBus[K, V]) attach(s *s3fifo[K, V]) {
+	prev := s.onDrop
+	s.onDrop = func(key K, hash uint64, value V, expirySec uint32) {
+		if prev != nil {
+			prev(key, hash, value, expirySec)
+		}
+		if b.n.Load() == 0 {
+			return
+		}
+		op := EventEvict
+		//nolint:gosec // G115: Unix seconds fit in uint32 until year 2106
+		if expirySec != 0 && uint32(time.Now().Unix()) > expirySec {
+			op = EventExpire
+		}
+		b.publish(op, key, value)
+	}
+}
+
+// Subscribe returns a channel of the cache's sets, deletes, expiries, and
+// evictions for which filter returns true, or all of them if filter is nil,
+// for maintaining derived indexes or invalidating computed aggregates. Expired
+// entries are reported as they leave memory, which may be well after they
+// expire; Flush, BumpEpoch, and evictions of LargeObjects values report nothing.
+//
+// Events are delivered without blocking the cache: when the channel is full,
+// events are dropped, and the returned cancel reports how many were. filter is
+// called synchronously, for evictions while the cache lock is held, so it
+// must be fast and must not call back into the cache. cancel closes the
+// channel; the subscriber should drain it until then.
+func (c *Cache[K, V]) Subscribe(filter func(Event[K, V]) bool) (events <-chan Event[K, V], cancel func() (dropped uint64)) {
+	s := &subscriber[K, V]{ch: make(chan Event[K, V], subscriberBuffer), filter: filter}
+	b := c.events
+	b.mu.Lock()
+	b.subs[s] = struct{}{}
+	b.n.Add(1)
+	b.mu.Unlock()
+
+	var once sync.Once
+	return s.ch, func() uint64 {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, s)
+			b.n.Add(-1)
+			b.mu.Unlock()
+			close(s.ch)
+		})
+		return s.dropped.Load()
+	}
+}
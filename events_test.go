@@ -0,0 +1,96 @@
+package fido
+
+import "testing"
+
+// drain returns the events buffered in ch.
+func drain[K comparable, V any](ch <-chan Event[K, V]) []Event[K, V] {
+	var out []Event[K, V]
+	for {
+		select {
+		case ev := <-ch:
+			out = append(out, ev)
+		default:
+			return out
+		}
+	}
+}
+
+func TestCache_Subscribe(t *testing.T) {
+	cache := New[string, int]()
+	events, cancel := cache.Subscribe(nil)
+
+	cache.Set("a", 1)
+	cache.GetOrSet("a", 9) // already set: no event
+	cache.GetOrSet("b", 2)
+	cache.Swap("a", 3)
+	cache.GetAndDelete("b")
+	cache.Delete("a")
+	cache.Set("c", 4)
+	cache.DeleteFunc(func(k string, _ int) bool { return k == "c" })
+
+	want := []struct {
+		op  EventOp
+		key string
+		val int
+	}{
+		{EventSet, "a", 1}, {EventSet, "b", 2}, {EventSet, "a", 3},
+		{EventDelete, "b", 2}, {EventDelete, "a", 0}, {EventSet, "c", 4}, {EventDelete, "c", 0},
+	}
+	got := drain(events)
+	if len(got) != len(want) {
+		t.Fatalf("got %d events %+v; want %d", len(got), got, len(want))
+	}
+	for i, w := range want {
+		if g := got[i]; g.Op != w.op || g.Key != w.key || g.Value != w.val || g.Time.IsZero() {
+			t.Errorf("event %d = %v %s=%d; want %v %s=%d", i, g.Op, g.Key, g.Value, w.op, w.key, w.val)
+		}
+	}
+
+	if dropped := cancel(); dropped != 0 {
+		t.Errorf("cancel reported %d dropped; want 0", dropped)
+	}
+	if _, open := <-events; open {
+		t.Error("channel open after cancel")
+	}
+	cancel() // idempotent
+	cache.Set("d", 5)
+}
+
+func TestCache_SubscribeEvictions(t *testing.T) {
+	cache := New[int, int](Size(100))
+	events, cancel := cache.Subscribe(func(ev Event[int, int]) bool { return ev.Op >= EventExpire })
+	defer cancel()
+
+	cache.memory.set(-1, -1, 1) // expired in 1970
+	for i := range 1000 {
+		cache.Set(i, i)
+	}
+	var expired, evicted int
+	for _, ev := range drain(events) {
+		switch {
+		case ev.Op == EventExpire && ev.Key == -1:
+			expired++
+		case ev.Op == EventEvict:
+			evicted++
+		default:
+			t.Errorf("unexpected event %v %d", ev.Op, ev.Key)
+		}
+	}
+	if expired != 1 || evicted == 0 {
+		t.Errorf("got %d expiries and %d evictions; want 1 and some", expired, evicted)
+	}
+}
+
+func TestCache_SubscribeDrops(t *testing.T) {
+	cache := New[int, int]()
+	events, cancel := cache.Subscribe(nil)
+	for i := range subscriberBuffer + 10 {
+		cache.Set(i, i)
+	}
+	if n := len(drain(events)); n != subscriberBuffer {
+		t.Errorf("received %d events; want the %d buffered", n, subscriberBuffer)
+	}
+	if dropped := cancel(); dropped != 10 {
+		t.Errorf("dropped = %d; want 10", dropped)
+	}
+}
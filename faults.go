@@ -0,0 +1,234 @@
+package fido
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrInjectedFault is the default error returned by a FaultInjector.
+var ErrInjectedFault = errors.New("injected persistence fault")
+
+// Faults describes the failures a FaultInjector applies to persistence
+// operations. Rates are fractions of operations in [0, 1].
+type Faults struct {
+	Err         error         // returned by failed operations; default ErrInjectedFault
+	ErrorRate   float64       // operations that fail without reaching the store
+	PartialRate float64       // writes applied to the store but reported as failed
+	LatencyRate float64       // operations delayed by Latency before running
+	Latency     time.Duration // delay, cut short if the context is done
+}
+
+// FaultInjector makes a TieredCache's persistence misbehave, so tests can check
+// how an application copes with a degraded store without running a broken one.
+// Faults apply to Get, Set, Delete, Cleanup, Flush, and Len. It is safe for
+// concurrent use, and Set may be called while the cache is running, e.g. to
+// start or end an outage.
+type FaultInjector struct {
+	rng      *rand.Rand
+	faults   Faults
+	mu       sync.Mutex
+	injected atomic.Uint64
+}
+
+// NewFaultInjector returns an injector applying f. A non-zero seed makes the
+// sequence of injected faults repeatable.
+func NewFaultInjector(f Faults, seed uint64) *FaultInjector {
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	return &FaultInjector{faults: f, rng: rand.New(rand.NewPCG(seed, seed))} //nolint:gosec // G404: test faults need no crypto randomness
+}
+
+// Set replaces the faults being applied.
+func (fi *FaultInjector) Set(f Faults) {
+	fi.mu.Lock()
+	fi.faults = f
+	fi.mu.Unlock()
+}
+
+// Injected returns how many faults (errors, partial failures, and delays) have been injected.
+func (fi *FaultInjector) Injected() uint64 {
+	return fi.injected.Load()
+}
+
+// fault is the outcome drawn for one operation.
+type fault struct {
+	err     error
+	delay   time.Duration
+	fail    bool // fail before the operation
+	partial bool // fail after the operation
+}
+
+func (fi *FaultInjector) draw(write bool) fault {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	f := fi.faults
+	var out fault
+	if f.LatencyRate > 0 && fi.rng.Float64() < f.LatencyRate {
+		out.delay = f.Latency
+		fi.injected.Add(1)
+	}
+	switch {
+	case f.ErrorRate > 0 && fi.rng.Float64() < f.ErrorRate:
+		out.fail = true
+	case write && f.PartialRate > 0 && fi.rng.Float64() < f.PartialRate:
+		out.partial = true
+	default:
+		return out
+	}
+	fi.injected.Add(1)
+	out.err = f.Err
+	if out.err == nil {
+		out.err = ErrInjectedFault
+	}
+	return out
+}
+
+// InjectFaults wraps a TieredCache's store with fi. Intended for tests.
+// The cache's Store field holds the wrapper, which does not expose the
+// underlying store's optional interfaces such as PrefixScanner.
+func InjectFaults(fi *FaultInjector) Option {
+	return func(c *config) { c.faults = fi }
+}
+
+// faultyStore applies a FaultInjector to a store.
+type faultyStore[K comparable, V any] struct {
+	Store[K, V]
+	fi *FaultInjector
+}
+
+// baseStore returns the store beneath any fault injection, miss filter,
+// concurrency limit, key hashing, or owner tagging, for optional interfaces.
+func baseStore[K comparable, V any](s Store[K, V]) Store[K, V] {
+	for {
+		switch w := s.(type) {
+		case *faultyStore[K, V]:
+			s = w.Store
+		case *missFilterStore[K, V]:
+			s = w.Store
+		case *limitedStore[K, V]:
+			s = w.Store
+		case *hashedKeyStore[K, V]:
+			s = w.Store
+		case *ownerStore[K, V]:
+			s = w.Store
+		case *noPersistStore[K, V]:
+			s = w.Store
+		default:
+			return s
+		}
+	}
+}
+
+// before draws a fault for an operation and applies its delay and early failure.
+func (s *faultyStore[K, V]) before(ctx context.Context, write bool) (fault, error) {
+	f := s.fi.draw(write)
+	if f.delay > 0 {
+		t := time.NewTimer(f.delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return f, ctx.Err()
+		}
+	}
+	if f.fail {
+		return f, f.err
+	}
+	return f, nil
+}
+
+func (s *faultyStore[K, V]) Get(ctx context.Context, key K) (V, time.Time, bool, error) {
+	if _, err := s.before(ctx, false); err != nil {
+		var zero V
+		return zero, time.Time{}, false, err
+	}
+	return s.Store.Get(ctx, key)
+}
+
+func (s *faultyStore[K, V]) Set(ctx context.Context, key K, value V, expiry time.Time) error {
+	f, err := s.before(ctx, true)
+	if err != nil {
+		return err
+	}
+	if err := s.Store.Set(ctx, key, value, expiry); err != nil {
+		return err
+	}
+	if f.partial {
+		return f.err
+	}
+	return nil
+}
+
+func (s *faultyStore[K, V]) Delete(ctx context.Context, key K) error {
+	f, err := s.before(ctx, true)
+	if err != nil {
+		return err
+	}
+	if err := s.Store.Delete(ctx, key); err != nil {
+		return err
+	}
+	if f.partial {
+		return f.err
+	}
+	return nil
+}
+
+// SetBatch injects at most one fault for the whole batch, as one round trip.
+func (s *faultyStore[K, V]) SetBatch(ctx context.Context, keys []K, values []V, expiries []time.Time) error {
+	f, err := s.before(ctx, true)
+	if err != nil {
+		return err
+	}
+	if err := setBatch(ctx, s.Store, keys, values, expiries); err != nil {
+		return err
+	}
+	if f.partial {
+		return f.err
+	}
+	return nil
+}
+
+func (s *faultyStore[K, V]) DeleteBatch(ctx context.Context, keys []K) error {
+	f, err := s.before(ctx, true)
+	if err != nil {
+		return err
+	}
+	if err := deleteBatch(ctx, s.Store, keys); err != nil {
+		return err
+	}
+	if f.partial {
+		return f.err
+	}
+	return nil
+}
+
+func (s *faultyStore[K, V]) Cleanup(ctx context.Context, maxAge time.Duration) (int, error) {
+	if _, err := s.before(ctx, false); err != nil {
+		return 0, err
+	}
+	return s.Store.Cleanup(ctx, maxAge)
+}
+
+func (s *faultyStore[K, V]) Flush(ctx context.Context) (int, error) {
+	f, err := s.before(ctx, true)
+	if err != nil {
+		return 0, err
+	}
+	n, err := s.Store.Flush(ctx)
+	if err == nil && f.partial {
+		err = f.err
+	}
+	return n, err
+}
+
+func (s *faultyStore[K, V]) Len(ctx context.Context) (int, error) {
+	if _, err := s.before(ctx, false); err != nil {
+		return 0, err
+	}
+	return s.Store.Len(ctx)
+}
@@ -0,0 +1,122 @@
+package fido
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFaultInjector_Errors(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	fi := NewFaultInjector(Faults{ErrorRate: 1}, 1)
+
+	cache, err := NewTiered[string, int](store, InjectFaults(fi))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	if err := cache.Set(ctx, "k", 1); !errors.Is(err, ErrInjectedFault) {
+		t.Errorf("Set error = %v; want ErrInjectedFault", err)
+	}
+	if _, _, found, _ := store.Get(ctx, "k"); found { //nolint:errcheck // presence only
+		t.Error("failed Set should not reach the store")
+	}
+	// Memory still serves the value: degraded mode keeps working.
+	if v, found, err := cache.Get(ctx, "k"); err != nil || !found || v != 1 {
+		t.Errorf("Get = %d, %v, %v; want 1 from memory", v, found, err)
+	}
+	if _, _, err := cache.Get(ctx, "other"); !errors.Is(err, ErrInjectedFault) {
+		t.Errorf("Get miss error = %v; want ErrInjectedFault", err)
+	}
+
+	// End the outage.
+	custom := errors.New("custom")
+	fi.Set(Faults{})
+	if err := cache.Set(ctx, "k", 2); err != nil {
+		t.Errorf("Set after outage: %v", err)
+	}
+	fi.Set(Faults{ErrorRate: 1, Err: custom})
+	if _, err := cache.Store.Len(ctx); !errors.Is(err, custom) {
+		t.Errorf("Len error = %v; want custom error", err)
+	}
+	if fi.Injected() != 3 {
+		t.Errorf("Injected = %d; want 3", fi.Injected())
+	}
+}
+
+func TestFaultInjector_Partial(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store, InjectFaults(NewFaultInjector(Faults{PartialRate: 1}, 1)))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	if err := cache.Set(ctx, "k", 1); !errors.Is(err, ErrInjectedFault) {
+		t.Errorf("Set error = %v; want ErrInjectedFault", err)
+	}
+	if v, _, found, _ := store.Get(ctx, "k"); !found || v != 1 { //nolint:errcheck // presence only
+		t.Error("partial failure should still write to the store")
+	}
+	// Reads are never partial failures.
+	if _, _, _, err := cache.Store.Get(ctx, "k"); err != nil {
+		t.Errorf("Get error = %v; want nil", err)
+	}
+}
+
+func TestFaultInjector_RateAndLatency(t *testing.T) {
+	ctx := context.Background()
+	fi := NewFaultInjector(Faults{ErrorRate: 0.3}, 42)
+	s := &faultyStore[string, int]{Store: newMockStore[string, int](), fi: fi}
+
+	failures := 0
+	for range 1000 {
+		if err := s.Set(ctx, "k", 1, time.Time{}); err != nil {
+			failures++
+		}
+	}
+	if failures < 230 || failures > 370 {
+		t.Errorf("failures = %d of 1000; want about 300", failures)
+	}
+
+	fi.Set(Faults{LatencyRate: 1, Latency: 20 * time.Millisecond})
+	start := time.Now()
+	if _, err := s.Len(ctx); err != nil {
+		t.Fatalf("Len: %v", err)
+	}
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Errorf("Len took %v; want injected 20ms delay", d)
+	}
+
+	// Delays respect cancellation.
+	fi.Set(Faults{LatencyRate: 1, Latency: time.Hour})
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.Len(cctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Len error = %v; want DeadlineExceeded", err)
+	}
+}
+
+func TestFaultInjector_KeepsOptionalInterfaces(t *testing.T) {
+	ctx := context.Background()
+	store := &epochMockStore[string, int]{mockStore: newMockStore[string, int]()}
+	cache, err := NewTiered[string, int](store, InjectFaults(NewFaultInjector(Faults{}, 1)))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	if err := cache.BumpEpoch(ctx); err != nil {
+		t.Fatalf("BumpEpoch: %v", err)
+	}
+	if store.bumps != 1 {
+		t.Errorf("store bumps = %d; want 1 through the fault wrapper", store.bumps)
+	}
+	if got := cache.Config().Store; got != "*fido.epochMockStore[string,int]" {
+		t.Errorf("Config().Store = %q; want the wrapped store type", got)
+	}
+}
@@ -0,0 +1,119 @@
+package fido
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"unicode/utf8"
+)
+
+// FuzzHashString checks hashString against hashStringPortable at every length
+// and at unaligned offsets within a larger allocation.
+func FuzzHashString(f *testing.F) {
+	for _, s := range []string{"", "a", "ab", "abc", "abcd", "abcdefg", "abcdefgh", "abcdefghi", "user:12345", "\x00\xff\x80"} {
+		f.Add(s, uint8(0))
+	}
+	f.Add("unaligned-offset-into-a-longer-string", uint8(3))
+
+	f.Fuzz(func(t *testing.T, s string, off uint8) {
+		if got, want := hashString(s), hashStringPortable(s); got != want {
+			t.Fatalf("hashString(%q) = %#x; want %#x", s, got, want)
+		}
+		// Substrings start at arbitrary addresses and end at the allocation's edge.
+		if o := int(off); o <= len(s) {
+			sub := s[o:]
+			if got, want := hashString(sub), hashStringPortable(sub); got != want {
+				t.Fatalf("hashString(%q[%d:]) = %#x; want %#x", s, o, got, want)
+			}
+		}
+	})
+}
+
+// fuzzRecord exercises the codecs with the kinds of fields cached values carry.
+type fuzzRecord struct {
+	Attrs map[string]int64
+	Name  string
+	Tags  []string
+	Data  []byte
+	ID    int64
+	Flag  bool
+}
+
+// FuzzCodecRoundTrip checks that JSON and Gob return what they encoded.
+func FuzzCodecRoundTrip(f *testing.F) {
+	f.Add("name", int64(1), "tag", []byte("data"), "attr", true)
+	f.Add("", int64(-1), "", []byte{}, "", false)
+	f.Add("日本語", int64(1<<62), "a\x00b", []byte{0xff, 0x00}, "k\"ey", true)
+
+	f.Fuzz(func(t *testing.T, name string, id int64, tag string, data []byte, attr string, flag bool) {
+		in := fuzzRecord{
+			Name:  name,
+			ID:    id,
+			Tags:  []string{tag, name},
+			Data:  data,
+			Attrs: map[string]int64{attr: id},
+			Flag:  flag,
+		}
+		codecs := map[string]Codec[fuzzRecord]{"gob": Gob[fuzzRecord]()}
+		// encoding/json replaces invalid UTF-8, so only valid strings round-trip.
+		if utf8.ValidString(name) && utf8.ValidString(tag) && utf8.ValidString(attr) {
+			codecs["json"] = JSON[fuzzRecord]()
+		}
+		for cname, c := range codecs {
+			b, err := c.Encode(in)
+			if err != nil {
+				t.Fatalf("%s Encode(%+v): %v", cname, in, err)
+			}
+			out, err := c.Decode(b)
+			if err != nil {
+				t.Fatalf("%s Decode: %v", cname, err)
+			}
+			if !equalRecords(in, out) {
+				t.Fatalf("%s round trip = %+v; want %+v", cname, out, in)
+			}
+		}
+	})
+}
+
+// equalRecords compares records, treating nil and empty byte slices alike
+// since gob does not distinguish them.
+func equalRecords(a, b fuzzRecord) bool {
+	if !bytes.Equal(a.Data, b.Data) {
+		return false
+	}
+	a.Data, b.Data = nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+// FuzzCodecDecode feeds arbitrary bytes to the decoders: they must fail cleanly,
+// and anything they accept must survive a re-encode.
+func FuzzCodecDecode(f *testing.F) {
+	for _, c := range []Codec[fuzzRecord]{JSON[fuzzRecord](), Gob[fuzzRecord]()} {
+		b, err := c.Encode(fuzzRecord{Name: "seed", Tags: []string{"t"}, Attrs: map[string]int64{"a": 1}})
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+	}
+	f.Add([]byte(`{"Name":1}`))
+	f.Add([]byte{0x03, 0xff, 0x81})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for cname, c := range map[string]Codec[fuzzRecord]{"json": JSON[fuzzRecord](), "gob": Gob[fuzzRecord]()} {
+			v, err := c.Decode(data)
+			if err != nil {
+				if e := newCodecError("decode", "k", err); e.Error() == "" {
+					t.Fatalf("%s: empty CodecError for %v", cname, err)
+				}
+				continue
+			}
+			b, err := c.Encode(v)
+			if err != nil {
+				t.Fatalf("%s: re-encode of decoded %+v: %v", cname, v, err)
+			}
+			if _, err := c.Decode(b); err != nil {
+				t.Fatalf("%s: decode of re-encoded %+v: %v", cname, v, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package fido
+
+import (
+	"math"
+	"time"
+)
+
+// hotTTL extends the TTLs of entries that turn hot, for HotTTL.
+type hotTTL struct {
+	factor float64
+	maxSec uint32
+}
+
+// newHotTTL returns the HotTTL policy, or nil when it is unset or invalid.
+func newHotTTL(cfg *config) *hotTTL {
+	if cfg.hotFactor <= 1 || cfg.hotMaxTTL < time.Second {
+		return nil
+	}
+	return &hotTTL{
+		factor: cfg.hotFactor,
+		maxSec: uint32(min(cfg.hotMaxTTL/time.Second, math.MaxUint32)), //nolint:gosec // G115: bounded above
+	}
+}
+
+// ttlSec returns the TTL a value written now with expirySec has, to be kept
+// in its slot, or 0 without HotTTL or an expiry.
+func (h *hotTTL) ttlSec(expirySec uint32) uint32 {
+	if h == nil || expirySec == 0 {
+		return 0
+	}
+	if now := unixSec(); expirySec > now {
+		return expirySec - now
+	}
+	return 0
+}
+
+// extend returns sl with its TTL multiplied by the factor, counted from when
+// it was written and capped at the maximum, or nil if it can't be extended.
+func extend[V any](h *hotTTL, sl *slot[V]) *slot[V] {
+	if sl.ttlSec == 0 || sl.ttlSec >= h.maxSec {
+		return nil
+	}
+	ttl := uint32(min(float64(sl.ttlSec)*h.factor, float64(h.maxSec)))
+	if ttl <= sl.ttlSec {
+		return nil
+	}
+	next := *sl
+	next.expirySec = sl.expirySec - sl.ttlSec + ttl
+	next.ttlSec = ttl
+	return &next
+}
+
+// heat extends the TTL of the value in sl, read through ref by a hit that
+// made its entry hot. A write that replaced the value since wins.
+func (c *s3fifo[K, V]) heat(ref entryRef[K, V], sl *slot[V]) {
+	if next := extend(c.hot, sl); next != nil {
+		ref.e.slot.CompareAndSwap(sl, next)
+	}
+}
@@ -0,0 +1,74 @@
+package fido
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCache_HotTTL(t *testing.T) {
+	cache := New[string, int](HotTTL(2, time.Hour))
+	if cfg := cache.Config(); cfg.HotTTL != 2 || cfg.HotTTLMax != time.Hour {
+		t.Errorf("Config() HotTTL = %v, %v; want 2, 1h", cfg.HotTTL, cfg.HotTTLMax)
+	}
+	ttl := func(key string) time.Duration {
+		t.Helper()
+		ent, ok := cache.memory.getEntry(key)
+		if !ok {
+			t.Fatalf("%s should be in memory", key)
+		}
+		if ent.expirySec() == 0 {
+			return 0
+		}
+		return time.Until(time.Unix(int64(ent.expirySec()), 0)).Round(time.Minute)
+	}
+	heat := func(key string) {
+		for range maxFreq {
+			cache.Get(key)
+		}
+	}
+
+	cache.SetTTL("warm", 1, 10*time.Minute)
+	for range maxFreq - 1 {
+		cache.Get("warm")
+	}
+	if got := ttl("warm"); got != 10*time.Minute {
+		t.Errorf("TTL below maxFreq = %v; want 10m", got)
+	}
+	cache.Get("warm")
+	if got := ttl("warm"); got != 20*time.Minute {
+		t.Errorf("TTL on reaching maxFreq = %v; want 20m", got)
+	}
+	heat("warm")
+	if got := ttl("warm"); got != 20*time.Minute {
+		t.Errorf("TTL after more hits = %v; want 20m, extended once per heating", got)
+	}
+
+	cache.SetTTL("warm", 1, 10*time.Minute)
+	if got := ttl("warm"); got != 10*time.Minute {
+		t.Errorf("TTL after rewrite = %v; want the written 10m", got)
+	}
+
+	cache.SetTTL("capped", 1, 40*time.Minute)
+	heat("capped")
+	if got := ttl("capped"); got != time.Hour {
+		t.Errorf("extended TTL = %v; want maxTTL's 1h", got)
+	}
+
+	cache.Set("forever", 1)
+	heat("forever")
+	if got := ttl("forever"); got != 0 {
+		t.Errorf("TTL of an entry without one = %v; want none", got)
+	}
+
+	cache.SetTTL("multi", 1, 5*time.Minute)
+	for range maxFreq {
+		cache.GetMulti([]string{"multi"})
+	}
+	if got := ttl("multi"); got != 10*time.Minute {
+		t.Errorf("TTL heated by GetMulti = %v; want 10m", got)
+	}
+
+	if New[string, int](HotTTL(1, time.Hour)).Config().HotTTL != 0 {
+		t.Error("New should ignore HotTTL with a factor of 1")
+	}
+}
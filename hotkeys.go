@@ -0,0 +1,113 @@
+package fido
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// hotKeySaver periodically saves the cache's hottest keys through the store's
+// HotKeyRecorder, for PersistHotKeys. The next instance loads their entries
+// first when it starts; see warmHotKeys.
+//
+//nolint:govet // fieldalignment: semantic grouping preferred
+type hotKeySaver[K comparable] struct {
+	rec      HotKeyRecorder[K]
+	hottest  func(n int) []K
+	n        int
+	interval time.Duration
+	done     chan struct{}
+	wg       sync.WaitGroup
+	once     sync.Once
+}
+
+// hotKeyRecorder returns the store's HotKeyRecorder if PersistHotKeys is set.
+func hotKeyRecorder[K comparable](cfg *config, store any) HotKeyRecorder[K] {
+	if cfg.hotKeys <= 0 {
+		return nil
+	}
+	rec, _ := store.(HotKeyRecorder[K]) //nolint:errcheck // checked by validateConfig
+	return rec
+}
+
+func newHotKeySaver[K comparable](rec HotKeyRecorder[K], cfg *config, hottest func(n int) []K) *hotKeySaver[K] {
+	if rec == nil {
+		return nil
+	}
+	s := &hotKeySaver[K]{
+		rec:      rec,
+		hottest:  hottest,
+		n:        cfg.hotKeys,
+		interval: cfg.hotKeysInterval,
+		done:     make(chan struct{}),
+	}
+	s.wg.Go(s.run)
+	return s
+}
+
+func (s *hotKeySaver[K]) run() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(BackgroundLane(context.Background()), s.interval)
+			if err := s.save(ctx); err != nil {
+				slog.Warn("persist hot keys failed", "error", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// save records the current hottest keys. An empty memory tier leaves the
+// saved list alone, so an instance restarted before traffic arrives doesn't
+// erase the list its predecessor saved.
+func (s *hotKeySaver[K]) save(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	keys := s.hottest(s.n)
+	if len(keys) == 0 {
+		return nil
+	}
+	if err := s.rec.SaveHotKeys(ctx, keys); err != nil {
+		return fmt.Errorf("save hot keys: %w", err)
+	}
+	return nil
+}
+
+func (s *hotKeySaver[K]) stop() {
+	if s == nil {
+		return
+	}
+	s.once.Do(func() {
+		close(s.done)
+		s.wg.Wait()
+	})
+}
+
+// warmHotKeys reads the entries for the last saved hot key list into memory
+// through load, hottest first, stopping early if ctx ends.
+func warmHotKeys[K comparable](ctx context.Context, rec HotKeyRecorder[K], load func(context.Context, K) bool) {
+	start := time.Now()
+	keys, err := rec.LoadHotKeys(ctx)
+	if err != nil {
+		slog.Warn("load hot keys failed", "error", err)
+		return
+	}
+	n := 0
+	for _, k := range keys {
+		if ctx.Err() != nil {
+			break
+		}
+		if load(ctx, k) {
+			n++
+		}
+	}
+	slog.Debug("hot key warmup finished", "keys", len(keys), "loaded", n, "duration", time.Since(start), "canceled", ctx.Err() != nil)
+}
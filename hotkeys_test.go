@@ -0,0 +1,107 @@
+package fido
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// hotKeyMockStore keeps the list saved by PersistHotKeys.
+type hotKeyMockStore[K comparable, V any] struct {
+	*mockStore[K, V]
+	mu    sync.Mutex
+	saved []K
+}
+
+func (m *hotKeyMockStore[K, V]) SaveHotKeys(_ context.Context, keys []K) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.saved = slices.Clone(keys)
+	return nil
+}
+
+func (m *hotKeyMockStore[K, V]) LoadHotKeys(context.Context) ([]K, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.saved), nil
+}
+
+func (m *hotKeyMockStore[K, V]) hotKeys() []K {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.saved
+}
+
+func TestTieredCache_PersistHotKeys(t *testing.T) {
+	ctx := context.Background()
+	store := &hotKeyMockStore[string, int]{mockStore: newMockStore[string, int]()}
+
+	cache, err := NewTiered[string, int](store, PersistHotKeys(2, 10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	for i, k := range []string{"warm", "hot", "cold"} {
+		if err := cache.Set(ctx, k, i); err != nil {
+			t.Fatalf("Set(%s): %v", k, err)
+		}
+	}
+	for range 3 {
+		_, _, _ = cache.Get(ctx, "hot")  //nolint:errcheck // heating
+		_, _, _ = cache.Get(ctx, "warm") //nolint:errcheck // heating
+	}
+	_, _, _ = cache.Get(ctx, "hot") //nolint:errcheck // heating
+
+	waitFor(t, func() bool { return slices.Equal(store.hotKeys(), []string{"hot", "warm"}) })
+	if got := cache.Config(); got.HotKeys != 2 || got.HotKeysInterval != 10*time.Millisecond {
+		t.Errorf("Config HotKeys = %d, %v; want 2, 10ms", got.HotKeys, got.HotKeysInterval)
+	}
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// The next instance loads the saved keys before its Prefetch source runs.
+	src := &batchSource{batches: [][]KV[string, int]{kvs("prefetched")}, calls: make(chan error, 2)}
+	next, err := NewTiered[string, int](store, PersistHotKeys(2, time.Hour), Prefetch[string, int](src, 0))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = next.Close() }() //nolint:errcheck // Test cleanup
+
+	<-src.calls
+	for _, k := range []string{"hot", "warm"} {
+		if _, ok := next.memory.entries.Load(k); !ok {
+			t.Errorf("%s not in memory when Prefetch started", k)
+		}
+	}
+	if _, ok := next.memory.entries.Load("cold"); ok {
+		t.Error("cold was loaded; want only the saved hot keys")
+	}
+	waitFor(t, func() bool { _, ok := next.memory.entries.Load("prefetched"); return ok })
+}
+
+func TestTieredCache_PersistHotKeys_Shutdown(t *testing.T) {
+	ctx := context.Background()
+	store := &hotKeyMockStore[string, int]{mockStore: newMockStore[string, int]()}
+	cache, err := NewTiered[string, int](store, PersistHotKeys(10, time.Hour))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+
+	// An instance with nothing in memory keeps its predecessor's list.
+	store.saved = []string{"old"}
+	if err := cache.hotKeys.save(ctx); err != nil || !slices.Equal(store.hotKeys(), []string{"old"}) {
+		t.Errorf("save with empty memory = %v, list %v; want the previous list kept", err, store.hotKeys())
+	}
+
+	if err := cache.Set(ctx, "k", 1); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := cache.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if got := store.hotKeys(); !slices.Equal(got, []string{"k"}) {
+		t.Errorf("hot keys after Shutdown = %v; want [k]", got)
+	}
+}
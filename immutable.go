@@ -0,0 +1,87 @@
+package fido
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+)
+
+// ErrImmutable is returned by TieredCache writes to a key stored with
+// SetImmutable, until the entry is deleted or expires.
+var ErrImmutable = errors.New("key is immutable")
+
+// SetImmutable stores value like Set and makes key write-once: later Set,
+// SetTTL, SetAsync, SetAsyncTTL, SetMemoryOnly, Swap, SwapTTL, SetMulti, and
+// Tx writes to it return ErrImmutable, until it is deleted, the persistence
+// tier is flushed by Flush, FlushPersist, or BumpEpoch, or its default TTL
+// passes. SetImmutable itself returns ErrImmutable if key is already
+// immutable. Writes that started before SetImmutable are not stopped. Use it
+// for content-addressed or build-artifact caches, where a second write to a
+// key signals a bug.
+//
+// The memory copy is exempt from S3-FIFO frequency decay, so it stays warm
+// however rarely it is read; it is evicted only when the memory tier holds
+// nothing else to evict. Immutability is tracked in this process only: other
+// processes sharing the store can still overwrite the key.
+func (c *TieredCache[K, V]) SetImmutable(ctx context.Context, key K, value V) error {
+	expiry := c.expiry(value, 0)
+	claimed := false
+	c.immutable.Compute(key, func(old time.Time, loaded bool) (time.Time, xsync.ComputeOp) {
+		if loaded && frozen(old) {
+			return old, xsync.CancelOp
+		}
+		claimed = true
+		return expiry, xsync.UpdateOp
+	})
+	if !claimed {
+		return fmt.Errorf("%w: %v", ErrImmutable, key)
+	}
+	if err := c.setTTL(ctx, key, value, 0); err != nil {
+		c.immutable.Delete(key)
+		return err
+	}
+	c.memory.pin(key)
+	return nil
+}
+
+// frozen reports whether an immutability mark expiring at expiry still holds.
+func frozen(expiry time.Time) bool {
+	return expiry.IsZero() || time.Now().Before(expiry)
+}
+
+// checkMutable returns ErrImmutable if key was stored with SetImmutable and
+// has not expired since. An expired mark is dropped. The check and the drop
+// are one Compute, so a mark SetImmutable claims meanwhile is never missed.
+func (c *TieredCache[K, V]) checkMutable(key K) error {
+	if _, ok := c.immutable.Load(key); !ok {
+		return nil
+	}
+	var err error
+	c.immutable.Compute(key, func(old time.Time, loaded bool) (time.Time, xsync.ComputeOp) {
+		switch {
+		case !loaded:
+			return old, xsync.CancelOp
+		case frozen(old):
+			err = fmt.Errorf("%w: %v", ErrImmutable, key)
+			return old, xsync.CancelOp
+		default:
+			return old, xsync.DeleteOp
+		}
+	})
+	return err
+}
+
+// thaw makes key writable again after it is deleted.
+func (c *TieredCache[K, V]) thaw(key K) {
+	c.immutable.Delete(key)
+}
+
+// repin keeps an immutable key warm when it is reloaded from persistence.
+func (c *TieredCache[K, V]) repin(key K) {
+	if expiry, ok := c.immutable.Load(key); ok && frozen(expiry) {
+		c.memory.pin(key)
+	}
+}
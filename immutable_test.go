@@ -0,0 +1,155 @@
+package fido
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTieredCache_SetImmutable(t *testing.T) {
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	ctx := context.Background()
+	if err := cache.SetImmutable(ctx, "sha256:abc", 1); err != nil {
+		t.Fatalf("SetImmutable: %v", err)
+	}
+	if err := cache.SetImmutable(ctx, "sha256:abc", 2); !errors.Is(err, ErrImmutable) {
+		t.Errorf("second SetImmutable error = %v; want ErrImmutable", err)
+	}
+
+	writes := map[string]func() error{
+		"Set":           func() error { return cache.Set(ctx, "sha256:abc", 2) },
+		"SetAsync":      func() error { return cache.SetAsync(ctx, "sha256:abc", 2) },
+		"Swap":          func() error { _, _, err := cache.Swap(ctx, "sha256:abc", 2); return err },
+		"SetMulti":      func() error { return cache.SetMulti(ctx, map[string]int{"sha256:abc": 2, "other": 3}) },
+		"SetMemoryOnly": func() error { return cache.SetMemoryOnly(ctx, "sha256:abc", 2, 0) },
+		"Tx": func() error {
+			return cache.Tx(ctx, func(tx *Txn[string, int]) error { return tx.Set("sha256:abc", 2) })
+		},
+	}
+	for name, write := range writes {
+		if err := write(); !errors.Is(err, ErrImmutable) {
+			t.Errorf("%s error = %v; want ErrImmutable", name, err)
+		}
+	}
+
+	if v, found, err := cache.Get(ctx, "sha256:abc"); err != nil || !found || v != 1 {
+		t.Errorf("Get = %d, %v, %v; want the immutable 1", v, found, err)
+	}
+	if v, _, found, _ := store.Get(ctx, "sha256:abc"); !found || v != 1 { //nolint:errcheck // mock never fails
+		t.Errorf("persisted = %d, %v; want the immutable 1", v, found)
+	}
+	if _, found, _ := cache.Get(ctx, "other"); !found { //nolint:errcheck // only checking presence
+		t.Error("SetMulti should store keys that aren't immutable")
+	}
+
+	// Deleting the key makes it writable again.
+	if err := cache.Delete(ctx, "sha256:abc"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := cache.Set(ctx, "sha256:abc", 2); err != nil {
+		t.Errorf("Set after Delete: %v", err)
+	}
+
+	// So does flushing persistence, which drops every mark.
+	if err := cache.SetImmutable(ctx, "sha256:def", 1); err != nil {
+		t.Fatalf("SetImmutable: %v", err)
+	}
+	if _, err := cache.FlushPersist(ctx); err != nil {
+		t.Fatalf("FlushPersist: %v", err)
+	}
+	if n := cache.immutable.Size(); n != 0 {
+		t.Errorf("%d immutability marks left after FlushPersist; want 0", n)
+	}
+	if err := cache.Set(ctx, "sha256:def", 2); err != nil {
+		t.Errorf("Set after FlushPersist: %v", err)
+	}
+}
+
+func TestTieredCache_SetImmutable_Expiry(t *testing.T) {
+	cache, err := NewTiered[string, int](newMockStore[string, int](), TTL(time.Second))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	ctx := context.Background()
+	if err := cache.SetImmutable(ctx, "artifact", 1); err != nil {
+		t.Fatalf("SetImmutable: %v", err)
+	}
+	cache.immutable.Store("artifact", time.Now().Add(-time.Second))
+	if err := cache.Set(ctx, "artifact", 2); err != nil {
+		t.Errorf("Set after the immutable entry expired: %v", err)
+	}
+	if _, ok := cache.immutable.Load("artifact"); ok {
+		t.Error("expired immutability mark was not dropped")
+	}
+}
+
+func TestTieredCache_SetImmutable_StaysWarm(t *testing.T) {
+	cache, err := NewTiered[string, int](newMockStore[string, int](), Size(64))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	ctx := context.Background()
+	if err := cache.SetImmutable(ctx, "pinned", 1); err != nil {
+		t.Fatalf("SetImmutable: %v", err)
+	}
+	if err := cache.Set(ctx, "plain", 1); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	for i := range 1000 {
+		_ = cache.SetMemoryOnly(ctx, fmt.Sprintf("k%d", i), i, 0) //nolint:errcheck // not immutable
+	}
+	if !cache.Info("pinned").InMemory {
+		t.Error("immutable entry was evicted by a scan of unread keys")
+	}
+	if cache.Info("plain").InMemory {
+		t.Error("unread plain entry survived the scan; the test doesn't exercise eviction")
+	}
+}
+
+func TestS3FIFO_PinnedEvictedWhenAllPinned(t *testing.T) {
+	c := newS3FIFO[int, int](&config{size: 8})
+	for i := range 32 {
+		c.set(i, i, 0)
+		c.pin(i)
+	}
+	if n := c.len(); n > 8 {
+		t.Errorf("len = %d with every entry pinned; want at most the capacity 8", n)
+	}
+}
+
+func TestS3FIFO_PinnedOffMain(t *testing.T) {
+	c := newS3FIFO[int, int](&config{size: 64})
+	for i := range 16 {
+		c.set(i, i, 0)
+		c.pin(i)
+	}
+	for i := 100; i < 1100; i++ {
+		c.set(i, i, 0)
+	}
+	for i := range 16 {
+		if _, ok := c.get(i); !ok {
+			t.Errorf("pinned %d was evicted while unpinned entries remained", i)
+		}
+	}
+	// Eviction scans only main, so pinned entries must not be on it.
+	for e := c.main.head; e != nil; e = e.next {
+		if e.pinned() {
+			t.Errorf("pinned %d is on main", e.key)
+		}
+	}
+	if c.pinned.len != 16 {
+		t.Errorf("pinned list holds %d; want all 16 once promoted out of small", c.pinned.len)
+	}
+}
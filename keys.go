@@ -0,0 +1,109 @@
+package fido
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"time"
+	"unicode/utf8"
+)
+
+// keyLimit returns the strictest KeyLimiter limit among stores, or 0 if none has one.
+func keyLimit(stores ...any) int {
+	n := 0
+	for _, s := range stores {
+		if l, ok := s.(KeyLimiter); ok && l.MaxKeyLength() > 0 && (n == 0 || l.MaxKeyLength() < n) {
+			n = l.MaxKeyLength()
+		}
+	}
+	return n
+}
+
+// hashedKeySuffix is the separator and hex SHA-256 prefix ending a hashed key.
+const hashedKeySuffix = 1 + 32
+
+// hashedKeyStore persists keys longer than limit under a fixed-length form,
+// for HashLongKeys. Keys within the limit pass through unchanged, and a hashed
+// key is itself within the limit, so hashing twice is harmless.
+type hashedKeyStore[K comparable, V any] struct {
+	Store[K, V]
+	limit int
+}
+
+// hashKeys wraps store for HashLongKeys, or returns it unchanged if store
+// has no key limit. The caller has checked that K is string.
+func hashKeys[K comparable, V any](store Store[K, V], cfg *config) Store[K, V] {
+	limit := keyLimit(baseStore(store))
+	if !cfg.hashLongKeys || limit == 0 {
+		return store
+	}
+	return &hashedKeyStore[K, V]{Store: store, limit: limit}
+}
+
+// hashKey returns key, or if key is longer than limit bytes, its leading bytes
+// and the hex of its SHA-256 joined by '#', at most limit bytes in all: fewer
+// when the prefix is cut back to a rune boundary.
+func hashKey[K comparable](key K, limit int) K {
+	v := reflect.ValueOf(&key).Elem()
+	s := v.String()
+	if len(s) <= limit {
+		return key
+	}
+	sum := sha256.Sum256([]byte(s))
+	keep := max(limit-hashedKeySuffix, 0)
+	for keep > 0 && !utf8.RuneStart(s[keep]) {
+		keep-- // keep the prefix valid UTF-8 for stores that require it
+	}
+	v.SetString(s[:keep] + "#" + hex.EncodeToString(sum[:])[:min(hashedKeySuffix-1, limit-keep-1)])
+	return key
+}
+
+func (s *hashedKeyStore[K, V]) ValidateKey(key K) error {
+	return s.Store.ValidateKey(hashKey(key, s.limit))
+}
+
+func (s *hashedKeyStore[K, V]) Get(ctx context.Context, key K) (V, time.Time, bool, error) {
+	return s.Store.Get(ctx, hashKey(key, s.limit))
+}
+
+func (s *hashedKeyStore[K, V]) Set(ctx context.Context, key K, value V, expiry time.Time) error {
+	return s.Store.Set(ctx, hashKey(key, s.limit), value, expiry)
+}
+
+func (s *hashedKeyStore[K, V]) Delete(ctx context.Context, key K) error {
+	return s.Store.Delete(ctx, hashKey(key, s.limit))
+}
+
+func (s *hashedKeyStore[K, V]) SetBatch(ctx context.Context, keys []K, values []V, expiries []time.Time) error {
+	return setBatch(ctx, s.Store, s.hashKeys(keys), values, expiries)
+}
+
+func (s *hashedKeyStore[K, V]) DeleteBatch(ctx context.Context, keys []K) error {
+	return deleteBatch(ctx, s.Store, s.hashKeys(keys))
+}
+
+// hashKeys returns a copy of keys, each shortened as hashKey does.
+func (s *hashedKeyStore[K, V]) hashKeys(keys []K) []K {
+	out := make([]K, len(keys))
+	for i, k := range keys {
+		out[i] = hashKey(k, s.limit)
+	}
+	return out
+}
+
+// storedKey returns the key store persists key under.
+func storedKey[K comparable, V any](store Store[K, V], key K) K {
+	for {
+		switch w := store.(type) {
+		case *hashedKeyStore[K, V]:
+			return hashKey(key, w.limit)
+		case *faultyStore[K, V]:
+			store = w.Store
+		case *noPersistStore[K, V]:
+			store = w.Store
+		default:
+			return key
+		}
+	}
+}
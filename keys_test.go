@@ -0,0 +1,160 @@
+package fido
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// shortKeyStore is a mockStore that rejects keys over limit bytes, like localfs.
+type shortKeyStore struct {
+	*mockStore[string, int]
+	limit int
+}
+
+func newShortKeyStore(limit int) *shortKeyStore {
+	return &shortKeyStore{mockStore: newMockStore[string, int](), limit: limit}
+}
+
+func (s *shortKeyStore) ValidateKey(key string) error {
+	if len(key) > s.limit {
+		return fmt.Errorf("key too long: %d bytes (max %d)", len(key), s.limit)
+	}
+	return nil
+}
+
+func (s *shortKeyStore) MaxKeyLength() int { return s.limit }
+
+func (s *shortKeyStore) Set(ctx context.Context, key string, value int, expiry time.Time) error {
+	if err := s.ValidateKey(key); err != nil {
+		return err
+	}
+	return s.mockStore.Set(ctx, key, value, expiry)
+}
+
+func TestHashKey(t *testing.T) {
+	long := strings.Repeat("a", 200)
+	for _, tt := range []struct {
+		name string
+		key  string
+	}{
+		{"ascii", long},
+		{"multibyte", strings.Repeat("é", 100)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := hashKey(tt.key, 127)
+			if len(got) > 127 || !strings.Contains(got, "#") {
+				t.Errorf("hashKey = %q (%d bytes); want at most 127 bytes with a hash", got, len(got))
+			}
+			if !strings.HasPrefix(tt.key, got[:strings.LastIndex(got, "#")]) {
+				t.Errorf("hashKey = %q; want a prefix of the key", got)
+			}
+			if again := hashKey(got, 127); again != got {
+				t.Errorf("hashKey(hashed) = %q; want it unchanged", again)
+			}
+		})
+	}
+	if got := hashKey("short", 127); got != "short" {
+		t.Errorf("hashKey(short) = %q; want it unchanged", got)
+	}
+	if a, b := hashKey(long+"x", 127), hashKey(long+"y", 127); a == b {
+		t.Errorf("keys sharing a prefix hashed alike: %q", a)
+	}
+
+	type name string
+	if got := hashKey(name(long), 40); len(got) != 40 {
+		t.Errorf("hashKey(named string) = %q; want 40 bytes", got)
+	}
+}
+
+func TestTieredCache_HashLongKeys(t *testing.T) {
+	ctx := context.Background()
+	store := newShortKeyStore(64)
+	long := strings.Repeat("k", 100)
+
+	plain, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = plain.Close() }() //nolint:errcheck // Test cleanup
+	if err := plain.Set(ctx, long, 1); err == nil {
+		t.Error("Set(long key) without HashLongKeys succeeded; want the store's error")
+	}
+	if got := plain.Config().MaxKeyLength; got != 64 {
+		t.Errorf("Config().MaxKeyLength = %d; want 64", got)
+	}
+
+	cache, err := NewTiered[string, int](store, HashLongKeys())
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+	if !cache.Config().HashLongKeys {
+		t.Error("Config().HashLongKeys = false")
+	}
+
+	if err := cache.Set(ctx, long, 1); err != nil {
+		t.Fatalf("Set(long key): %v", err)
+	}
+	if err := cache.Set(ctx, "short", 2); err != nil {
+		t.Fatalf("Set(short): %v", err)
+	}
+	if _, _, found, _ := store.Get(ctx, "short"); !found { //nolint:errcheck // only checking presence
+		t.Error("short key should be stored unchanged")
+	}
+	if _, _, found, _ := store.Get(ctx, hashKey(long, 64)); !found { //nolint:errcheck // only checking presence
+		t.Error("long key should be stored hashed")
+	}
+	if got := cache.Locations(long).Store; got != "mock://"+hashKey(long, 64) {
+		t.Errorf("Locations(long).Store = %q; want the hashed key", got)
+	}
+
+	cache.memory.del(long)
+	if v, found, err := cache.Get(ctx, long); err != nil || !found || v != 1 {
+		t.Errorf("Get(long key) = %d, %v, %v; want 1, true, nil", v, found, err)
+	}
+	if err := cache.Delete(ctx, long); err != nil {
+		t.Fatalf("Delete(long key): %v", err)
+	}
+	if n, _ := store.Len(ctx); n != 1 { //nolint:errcheck // store never fails
+		t.Errorf("store holds %d entries after Delete; want 1", n)
+	}
+}
+
+func TestTieredCache_VictimSkipsLongKeys(t *testing.T) {
+	victim := newShortKeyStore(16)
+	cache, err := NewTiered[string, int](newMockStore[string, int](), Victim[string, int](victim, time.Minute))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+	if got := cache.Config().MaxKeyLength; got != 16 {
+		t.Errorf("Config().MaxKeyLength = %d; want the victim's 16", got)
+	}
+
+	long := strings.Repeat("v", 40)
+	cache.victim.spill(long, 1, 0)
+	cache.victim.spill("short", 2, 0)
+	waitFor(t, func() bool { _, ok := cache.victim.spilled.Load("short"); return ok })
+	if _, ok := cache.victim.spilled.Load(long); ok {
+		t.Error("a key the victim store rejects should not be spilled")
+	}
+}
+
+func TestTieredCache_HashLongKeys_Victim(t *testing.T) {
+	victim := newShortKeyStore(16)
+	cache, err := NewTiered[string, int](newMockStore[string, int](), Victim[string, int](victim, time.Minute), HashLongKeys())
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	long := strings.Repeat("v", 40)
+	cache.victim.spill(long, 1, 0)
+	waitFor(t, func() bool { _, ok := cache.victim.spilled.Load(long); return ok })
+	if v, found, err := cache.Get(context.Background(), long); err != nil || !found || v != 1 {
+		t.Errorf("Get(long key) = %d, %v, %v; want 1, true, nil from the victim", v, found, err)
+	}
+}
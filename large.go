@@ -0,0 +1,200 @@
+package fido
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// largeRegion holds values too big for the S3-FIFO queues.
+// S3-FIFO counts entries, not bytes, so a handful of 50MB values would otherwise
+// occupy slots meant for thousands of small ones. The region is a plain FIFO
+// bounded by total bytes: large values are rare and rarely benefit from frequency tracking.
+//
+//nolint:govet // fieldalignment: mutex grouped with the data it protects
+type largeRegion[K comparable, V any] struct {
+	mu        sync.Mutex
+	items     map[K]*list.Element
+	order     *list.List   // front = oldest
+	count     atomic.Int64 // mirrors len(items) for lock-free emptiness checks
+	bytes     int
+	maxBytes  int
+	threshold int
+	sizer     func(V) int
+}
+
+type largeEntry[K comparable, V any] struct {
+	key       K
+	value     V
+	size      int
+	expirySec uint32
+	local     bool // written by SetMemoryOnly; see slot.local
+}
+
+// newLargeRegion returns nil when large-object routing is disabled or V cannot be sized.
+func newLargeRegion[K comparable, V any](cfg *config) *largeRegion[K, V] {
+	if cfg.largeThreshold <= 0 {
+		return nil
+	}
+	sizer, _ := cfg.sizer.(func(V) int) //nolint:errcheck // a mismatch is rejected by checkSizer
+	if sizer == nil {
+		sizer = defaultSizer[V]()
+	}
+	if sizer == nil {
+		return nil
+	}
+	return &largeRegion[K, V]{
+		items:     make(map[K]*list.Element),
+		order:     list.New(),
+		maxBytes:  cfg.largeMaxBytes,
+		threshold: cfg.largeThreshold,
+		sizer:     sizer,
+	}
+}
+
+// defaultSizer measures string and []byte values by length.
+// Other value types require an explicit Sizer.
+func defaultSizer[V any]() func(V) int {
+	var zero V
+	switch any(zero).(type) {
+	case string:
+		return func(v V) int { return len(any(v).(string)) } //nolint:errcheck,forcetypeassert // type checked above
+	case []byte:
+		return func(v V) int { return len(any(v).([]byte)) } //nolint:errcheck,forcetypeassert // type checked above
+	default:
+		return nil
+	}
+}
+
+// size returns the measured size of v, or -1 if v is not large.
+func (r *largeRegion[K, V]) size(v V) int {
+	n := r.sizer(v)
+	if n <= r.threshold {
+		return -1
+	}
+	return n
+}
+
+func (r *largeRegion[K, V]) get(key K) (V, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var zero V
+	el, ok := r.items[key]
+	if !ok {
+		return zero, false
+	}
+	e := el.Value.(*largeEntry[K, V]) //nolint:errcheck,forcetypeassert // list only holds *largeEntry
+	//nolint:gosec // G115: Unix seconds fit in uint32 until year 2106
+	if e.expirySec != 0 && uint32(time.Now().Unix()) > e.expirySec {
+		r.removeLocked(el)
+		return zero, false
+	}
+	return e.value, true
+}
+
+// expiry reports whether key is held and its expiry, without checking it.
+func (r *largeRegion[K, V]) expiry(key K) (uint32, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	el, ok := r.items[key]
+	if !ok {
+		return 0, false
+	}
+	return el.Value.(*largeEntry[K, V]).expirySec, true //nolint:errcheck,forcetypeassert // list only holds *largeEntry
+}
+
+// set stores a large value, evicting the oldest entries until it fits.
+// Values bigger than the whole region are not kept in memory.
+func (r *largeRegion[K, V]) set(key K, value V, size int, expirySec uint32, local bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if el, ok := r.items[key]; ok {
+		r.removeLocked(el)
+	}
+	if size > r.maxBytes {
+		return
+	}
+	for r.bytes+size > r.maxBytes {
+		r.removeLocked(r.order.Front())
+	}
+	r.items[key] = r.order.PushBack(&largeEntry[K, V]{key: key, value: value, size: size, expirySec: expirySec, local: local})
+	r.bytes += size
+	r.count.Add(1)
+}
+
+// take removes key and returns its value if it had not expired.
+func (r *largeRegion[K, V]) take(key K) (V, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var zero V
+	el, ok := r.items[key]
+	if !ok {
+		return zero, false
+	}
+	e := el.Value.(*largeEntry[K, V]) //nolint:errcheck,forcetypeassert // list only holds *largeEntry
+	r.removeLocked(el)
+	//nolint:gosec // G115: Unix seconds fit in uint32 until year 2106
+	if e.expirySec != 0 && uint32(time.Now().Unix()) > e.expirySec {
+		return zero, false
+	}
+	return e.value, true
+}
+
+func (r *largeRegion[K, V]) del(key K) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if el, ok := r.items[key]; ok {
+		r.removeLocked(el)
+	}
+}
+
+func (r *largeRegion[K, V]) removeLocked(el *list.Element) {
+	e := r.order.Remove(el).(*largeEntry[K, V]) //nolint:errcheck,forcetypeassert // list only holds *largeEntry
+	delete(r.items, e.key)
+	r.bytes -= e.size
+	r.count.Add(-1)
+}
+
+func (r *largeRegion[K, V]) flush() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Count only unexpired entries, as Get would have returned them.
+	//nolint:gosec // G115: Unix seconds fit in uint32 until year 2106
+	now := uint32(time.Now().Unix())
+	n := 0
+	for _, el := range r.items {
+		if e := el.Value.(*largeEntry[K, V]); e.expirySec == 0 || e.expirySec >= now { //nolint:errcheck,forcetypeassert // list only holds *largeEntry
+			n++
+		}
+	}
+	clear(r.items)
+	r.order.Init()
+	r.bytes = 0
+	r.count.Store(0)
+	return n
+}
+
+// snapshot returns the non-expired entries so callers can iterate without holding the lock.
+func (r *largeRegion[K, V]) snapshot() []largeEntry[K, V] {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	//nolint:gosec // G115: Unix seconds fit in uint32 until year 2106
+	now := uint32(time.Now().Unix())
+	out := make([]largeEntry[K, V], 0, len(r.items))
+	for el := r.order.Front(); el != nil; el = el.Next() {
+		e := el.Value.(*largeEntry[K, V]) //nolint:errcheck,forcetypeassert // list only holds *largeEntry
+		if e.expirySec != 0 && e.expirySec < now {
+			continue
+		}
+		out = append(out, *e)
+	}
+	return out
+}
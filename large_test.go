@@ -0,0 +1,179 @@
+package fido
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCache_LargeObjects(t *testing.T) {
+	cache := New[string, string](Size(100), LargeObjects(10, 100))
+
+	cache.Set("small", "tiny")
+	cache.Set("big", strings.Repeat("x", 50))
+
+	if v, ok := cache.Get("big"); !ok || len(v) != 50 {
+		t.Fatalf("Get(big) = %d bytes, %v; want 50 bytes, true", len(v), ok)
+	}
+	if _, ok := cache.memory.entries.Load("big"); ok {
+		t.Error("large value should bypass the S3-FIFO queues")
+	}
+	if cache.Len() != 2 {
+		t.Errorf("Len() = %d; want 2", cache.Len())
+	}
+
+	// A second large value pushes the first out of the 100-byte region.
+	cache.Set("big2", strings.Repeat("y", 60))
+	if _, ok := cache.Get("big"); ok {
+		t.Error("oldest large value should be evicted when region is full")
+	}
+	if _, ok := cache.Get("small"); !ok {
+		t.Error("small value should be unaffected by large-object eviction")
+	}
+
+	// Values bigger than the region are not cached.
+	cache.Set("huge", strings.Repeat("z", 500))
+	if _, ok := cache.Get("huge"); ok {
+		t.Error("value larger than the region should not be cached")
+	}
+}
+
+func TestCache_LargeObjects_Transition(t *testing.T) {
+	cache := New[string, string](LargeObjects(10, 1000))
+
+	cache.Set("k", "small")
+	cache.Set("k", strings.Repeat("x", 20))
+	if v, ok := cache.Get("k"); !ok || len(v) != 20 {
+		t.Fatalf("Get(k) after growing = %q, %v", v, ok)
+	}
+	if cache.Len() != 1 {
+		t.Errorf("Len() = %d; want 1", cache.Len())
+	}
+
+	cache.Set("k", "small")
+	if v, ok := cache.Get("k"); !ok || v != "small" {
+		t.Fatalf("Get(k) after shrinking = %q, %v", v, ok)
+	}
+	if cache.Len() != 1 {
+		t.Errorf("Len() = %d; want 1", cache.Len())
+	}
+
+	cache.Set("k", strings.Repeat("x", 20))
+	cache.Delete("k")
+	if _, ok := cache.Get("k"); ok {
+		t.Error("deleted large value should not be found")
+	}
+
+	cache.Set("a", strings.Repeat("a", 20))
+	cache.Set("b", "b")
+	n := 0
+	for range cache.Range() {
+		n++
+	}
+	if n != 2 {
+		t.Errorf("Range yielded %d entries; want 2", n)
+	}
+	if got := cache.Flush(); got != 2 {
+		t.Errorf("Flush() = %d; want 2", got)
+	}
+}
+
+func TestCache_LargeObjects_RacingTransition(t *testing.T) {
+	cache := New[string, string](LargeObjects(10, 1<<20))
+	big := strings.Repeat("x", 20)
+
+	for i := range 5000 {
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		for _, v := range []string{"small", big} {
+			wg.Go(func() { <-start; cache.Set("k", v) })
+		}
+		close(start)
+		wg.Wait()
+
+		_, queued := cache.memory.entries.Load("k")
+		_, large := cache.memory.large.expiry("k")
+		if queued == large {
+			t.Fatalf("round %d: key in queues=%v, large region=%v; want exactly one", i, queued, large)
+		}
+		cache.Delete("k")
+	}
+}
+
+func TestNew_SizerMismatch(t *testing.T) {
+	defer func() {
+		err, ok := recover().(error)
+		if !ok || !errors.Is(err, ErrInvalidConfig) || !strings.Contains(err.Error(), "Sizer takes func(int) int") {
+			t.Errorf("New panicked with %v; want ErrInvalidConfig naming the Sizer", err)
+		}
+	}()
+	New[string, string](LargeObjects(10, 100), Sizer(func(int) int { return 0 }))
+	t.Error("New accepted a Sizer for another value type")
+}
+
+func TestCache_LargeObjects_CustomSizer(t *testing.T) {
+	type blob struct{ data []byte }
+	cache := New[int, blob](LargeObjects(8, 64), Sizer(func(b blob) int { return len(b.data) }))
+
+	cache.Set(1, blob{data: make([]byte, 32)})
+	if _, ok := cache.memory.entries.Load(1); ok {
+		t.Error("large value should bypass the S3-FIFO queues")
+	}
+	if _, ok := cache.Get(1); !ok {
+		t.Error("large value should be retrievable")
+	}
+
+	// Without a sizer, non-byte types can't be measured and routing is disabled.
+	plain := New[int, blob](LargeObjects(8, 64))
+	if plain.memory.large != nil {
+		t.Error("large region should be disabled when V cannot be sized")
+	}
+}
+
+func TestTieredCache_LargeObjects_PersistOnly(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, string]()
+
+	cache, err := NewTiered[string, string](store, LargeObjects(10, 0))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	big := strings.Repeat("x", 100)
+	if err := cache.Set(ctx, "big", big); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if cache.Len() != 0 {
+		t.Errorf("Len() = %d; want 0 (large value should skip memory)", cache.Len())
+	}
+
+	v, found, err := cache.Get(ctx, "big")
+	if err != nil || !found || v != big {
+		t.Fatalf("Get(big) = %d bytes, %v, %v; want value from persistence", len(v), found, err)
+	}
+}
+
+func TestCache_LargeObjects_MissSkipsEmptyRegion(t *testing.T) {
+	cache := New[string, string](LargeObjects(10, 1000))
+	cache.Set("small", "tiny")
+
+	// With no large values held, misses must not wait on the region's lock.
+	cache.memory.large.mu.Lock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cache.Get("absent")
+		cache.GetMulti([]string{"absent", "small"})
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("a miss blocked on the large region's lock while it was empty")
+	}
+	cache.memory.large.mu.Unlock()
+	<-done
+}
@@ -0,0 +1,39 @@
+package fido
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrValueTooLarge is returned by TieredCache writes when a value exceeds MaxValueBytes.
+var ErrValueTooLarge = errors.New("value too large")
+
+// newValueLimit returns a check enforcing MaxValueBytes, or nil when no limit is set.
+// Values are measured with Sizer, len() for string and []byte, or else by their
+// JSON encoding, which matches how the bundled stores serialize them.
+func newValueLimit[V any](cfg *config) func(V) error {
+	if cfg.maxValueBytes <= 0 {
+		return nil
+	}
+	sizer, ok := cfg.sizer.(func(V) int)
+	if !ok {
+		sizer = defaultSizer[V]()
+	}
+	if sizer == nil {
+		sizer = func(v V) int {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return 0 // unencodable; let the store report it
+			}
+			return len(b)
+		}
+	}
+	limit := cfg.maxValueBytes
+	return func(v V) error {
+		if n := sizer(v); n > limit {
+			return fmt.Errorf("%w: %d bytes (max %d)", ErrValueTooLarge, n, limit)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,77 @@
+package fido
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTieredCache_MaxValueBytes(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, string]()
+
+	cache, err := NewTiered[string, string](store, MaxValueBytes(8))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	if err := cache.Set(ctx, "small", "12345678"); err != nil {
+		t.Fatalf("Set(small): %v", err)
+	}
+
+	big := strings.Repeat("x", 9)
+	if err := cache.Set(ctx, "big", big); !errors.Is(err, ErrValueTooLarge) {
+		t.Errorf("Set(big) = %v; want ErrValueTooLarge", err)
+	}
+	if err := cache.SetAsync(ctx, "big", big); !errors.Is(err, ErrValueTooLarge) {
+		t.Errorf("SetAsync(big) = %v; want ErrValueTooLarge", err)
+	}
+	if _, found, _ := cache.Get(ctx, "big"); found { //nolint:errcheck // checked via found
+		t.Error("rejected value should not be cached")
+	}
+	if _, _, found, _ := store.Get(ctx, "big"); found { //nolint:errcheck // checked via found
+		t.Error("rejected value should not be persisted")
+	}
+
+	// Fetch keeps the computed value in memory but does not persist it.
+	val, err := cache.Fetch(ctx, "fetched", func(context.Context) (string, error) { return big, nil })
+	if err != nil || val != big {
+		t.Fatalf("Fetch = %q, %v; want computed value", val, err)
+	}
+	if _, _, found, _ := store.Get(ctx, "fetched"); found { //nolint:errcheck // checked via found
+		t.Error("oversized Fetch value should not be persisted")
+	}
+}
+
+func TestTieredCache_MaxValueBytes_JSONSize(t *testing.T) {
+	type payload struct {
+		Data []int
+	}
+	ctx := context.Background()
+	cache, err := NewTiered[string, payload](newMockStore[string, payload](), MaxValueBytes(32))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	if err := cache.SetTTL(ctx, "small", payload{Data: []int{1, 2}}, time.Minute); err != nil {
+		t.Errorf("SetTTL(small): %v", err)
+	}
+	if err := cache.SetTTL(ctx, "big", payload{Data: make([]int, 32)}, time.Minute); !errors.Is(err, ErrValueTooLarge) {
+		t.Errorf("SetTTL(big) = %v; want ErrValueTooLarge", err)
+	}
+
+	// A Sizer overrides JSON measurement.
+	sized, err := NewTiered[string, payload](newMockStore[string, payload](),
+		MaxValueBytes(32), Sizer(func(p payload) int { return len(p.Data) }))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = sized.Close() }() //nolint:errcheck // Test cleanup
+	if err := sized.SetTTL(ctx, "big", payload{Data: make([]int, 32)}, time.Minute); err != nil {
+		t.Errorf("SetTTL with Sizer: %v", err)
+	}
+}
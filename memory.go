@@ -2,6 +2,8 @@
 package fido
 
 import (
+	"context"
+	"io"
 	"iter"
 	"sync"
 	"time"
@@ -26,15 +28,122 @@ type Cache[K comparable, V any] struct {
 	flights    *xsync.Map[K, *flightCall[V]]
 	memory     *s3fifo[K, V]
 	defaultTTL time.Duration
+	stats      *hitStats        // nil unless TrackStats
+	access     *accessLog[K, V] // nil unless AccessLog
+	events     *eventBus[K, V]  // Subscribe
+	clone      cloner[V]        // nil unless CopyOnRead
+	valueTTL   valueTTL[V]      // nil unless TTLFromValue
+	early      *earlyExpiry     // nil unless EarlyExpiry
+	deps       depGraph[K]      // SetWithDependencies
+	audit      auditLog         // AuditLog
+	settings   Config
+}
+
+// cloner copies values handed to callers so they can't mutate cached ones.
+type cloner[V any] func(V) V
+
+// newCloner returns the CopyOnRead function for V, or nil.
+func newCloner[V any](cfg *config) cloner[V] {
+	if fn, ok := cfg.clone.(func(V) V); ok {
+		return fn
+	}
+	return nil
+}
+
+// copy returns a copy of v if it was found, or v unchanged without CopyOnRead.
+func (f cloner[V]) copy(v V, found bool) V {
+	if f == nil || !found {
+		return v
+	}
+	return f(v)
+}
+
+// valueTTL derives an entry's TTL from its value.
+type valueTTL[V any] func(V) time.Duration
+
+// newValueTTL returns the TTLFromValue function for V, or nil.
+func newValueTTL[V any](cfg *config) valueTTL[V] {
+	if fn, ok := cfg.ttlFromValue.(func(V) time.Duration); ok {
+		return fn
+	}
+	return nil
+}
+
+// or returns the TTL derived from v, or def if there is none.
+func (f valueTTL[V]) or(v V, def time.Duration) time.Duration {
+	if f == nil {
+		return def
+	}
+	if ttl := f(v); ttl > 0 {
+		return ttl
+	}
+	return def
+}
+
+// copyAll wraps seq to copy each value it yields.
+func copyAll[K comparable, V any](f cloner[V], seq iter.Seq2[K, V]) iter.Seq2[K, V] {
+	if f == nil {
+		return seq
+	}
+	return func(yield func(K, V) bool) {
+		for k, v := range seq {
+			if !yield(k, f(v)) {
+				return
+			}
+		}
+	}
 }
 
 // flightCall holds an in-flight computation for singleflight deduplication.
 //
 //nolint:govet // fieldalignment: semantic grouping preferred
 type flightCall[V any] struct {
-	wg  sync.WaitGroup
-	val V
-	err error
+	wg        sync.WaitGroup
+	val       V
+	err       error
+	mu        sync.Mutex // orders the leader's cache write against Forget
+	forgotten bool
+}
+
+// cache runs set, which caches the call's result, unless the call was
+// forgotten while its loader ran.
+func (fc *flightCall[V]) cache(set func()) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if !fc.forgotten {
+		set()
+	}
+}
+
+// endFlight removes fc from flights once its result is ready, unless
+// Forget already replaced it with a newer call.
+func endFlight[K comparable, V any](flights *xsync.Map[K, *flightCall[V]], key K, fc *flightCall[V]) {
+	flights.Compute(key, func(cur *flightCall[V], loaded bool) (*flightCall[V], xsync.ComputeOp) {
+		if loaded && cur == fc {
+			return nil, xsync.DeleteOp
+		}
+		return cur, xsync.CancelOp
+	})
+}
+
+// forgetFlight detaches key's in-flight load, if any, so its result isn't
+// cached and the next Fetch starts a fresh load. Callers already waiting on
+// it still receive its result.
+func forgetFlight[K comparable, V any](flights *xsync.Map[K, *flightCall[V]], key K) {
+	fc, ok := flights.LoadAndDelete(key)
+	if !ok {
+		return
+	}
+	fc.mu.Lock()
+	fc.forgotten = true
+	fc.mu.Unlock()
+}
+
+// forgetFlights is forgetFlight for every key, for flushes.
+func forgetFlights[K comparable, V any](flights *xsync.Map[K, *flightCall[V]]) {
+	for key := range flights.All() {
+		forgetFlight(flights, key)
+	}
 }
 
 // New creates an in-memory cache.
@@ -43,39 +152,219 @@ func New[K comparable, V any](opts ...Option) *Cache[K, V] {
 	for _, opt := range opts {
 		opt(cfg)
 	}
+	return newCache[K, V](cfg)
+}
 
-	return &Cache[K, V]{
+// NewSmall creates an in-memory cache for constrained devices such as phones,
+// embedded boards, and WebAssembly workers, holding a few hundred entries.
+// It defaults to Size(256) and ExactGhosts, and trades the allocations New
+// makes up front to avoid growing as the cache fills for a smaller footprint:
+// the entry table starts at its minimum size and the ghost lists are sized to
+// the cache, scanned rather than indexed. Options apply as for New.
+func NewSmall[K comparable, V any](opts ...Option) *Cache[K, V] {
+	cfg := &config{size: 256, exactGhosts: true, compact: true}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return newCache[K, V](cfg)
+}
+
+// newCache creates an in-memory cache from resolved options.
+func newCache[K comparable, V any](cfg *config) *Cache[K, V] {
+	mustCheckSizer[V](cfg)
+	memory := newS3FIFO[K, V](cfg)
+	access := newAccessLog[K, V](cfg, memory.hasher)
+	access.attach(memory)
+	events := newEventBus[K, V]()
+	events.attach(memory)
+	c := &Cache[K, V]{
 		flights:    xsync.NewMap[K, *flightCall[V]](),
-		memory:     newS3FIFO[K, V](cfg),
+		memory:     memory,
 		defaultTTL: cfg.defaultTTL,
+		stats:      newHitStats(cfg),
+		access:     access,
+		events:     events,
+		clone:      newCloner[V](cfg),
+		valueTTL:   newValueTTL[V](cfg),
+		early:      newEarlyExpiry(cfg),
+		settings:   resolveConfig(cfg, memory),
+	}
+	attachDeps(&c.deps, memory)
+	if !cfg.deterministic {
+		startPrefetching(cfg, nil, func(k K, v V) { c.GetOrSet(k, v) })
 	}
+	return c
 }
 
 // Get returns the value for key, or zero and false if not found.
 func (c *Cache[K, V]) Get(key K) (V, bool) {
-	return c.memory.get(key)
+	if h, ok := c.access.sample(key); ok {
+		return c.getLogged(key, h)
+	}
+	val, ok := c.memory.get(key)
+	c.stats.record(ok)
+	return c.clone.copy(val, ok), ok
 }
 
-// Set stores a value using the default TTL specified at cache creation.
-// If no default TTL was set, the entry never expires.
+// GetMulti returns the cached values for keys in one pass. Keys that are not
+// found are absent from the result.
+func (c *Cache[K, V]) GetMulti(keys []K) map[K]V {
+	out := make(map[K]V, len(keys))
+	if c.access != nil {
+		// Sampled keys need per-key timing.
+		for _, k := range keys {
+			if v, ok := c.Get(k); ok {
+				out[k] = v
+			}
+		}
+		return out
+	}
+	hits := 0
+	c.memory.getMulti(keys, func(k K, v V) {
+		out[k] = c.clone.copy(v, true)
+		hits++
+	})
+	if c.stats != nil {
+		for i := range len(keys) {
+			c.stats.record(i < hits)
+		}
+	}
+	return out
+}
+
+// getLogged is Get for a key sampled by AccessLog.
+func (c *Cache[K, V]) getLogged(key K, hash uint64) (V, bool) {
+	start := time.Now()
+	val, ok, resurrected := c.memory.getResurrected(key)
+	c.stats.record(ok)
+	tier := TierMiss
+	switch {
+	case resurrected:
+		tier = TierDeathRow
+	case ok:
+		tier = TierMemory
+	}
+	c.access.emit(AccessGet, hash, val, tier, start)
+	return c.clone.copy(val, ok), ok
+}
+
+// Set stores a value using the TTL derived by TTLFromValue, else the default
+// TTL specified at cache creation. If neither is set, the entry never expires.
 func (c *Cache[K, V]) Set(key K, value V) {
-	c.SetTTL(key, value, c.defaultTTL)
+	c.SetTTL(key, value, c.valueTTL.or(value, c.defaultTTL))
 }
 
 // SetTTL stores a value with an explicit TTL.
 // A zero or negative TTL means the entry never expires.
 func (c *Cache[K, V]) SetTTL(key K, value V, ttl time.Duration) {
-	if ttl <= 0 {
-		c.memory.set(key, value, 0)
-		return
+	if h, ok := c.access.sample(key); ok {
+		defer c.access.emit(AccessSet, h, value, TierMemory, time.Now())
 	}
-	//nolint:gosec // G115: Unix seconds fit in uint32 until year 2106
-	c.memory.set(key, value, uint32(time.Now().Add(ttl).Unix()))
+	var exp uint32
+	if ttl > 0 {
+		//nolint:gosec // G115: Unix seconds fit in uint32 until year 2106
+		exp = uint32(time.Now().Add(ttl).Unix())
+	}
+	c.memory.set(key, value, exp)
+	c.events.publish(EventSet, key, value)
+}
+
+// GetOrSet returns the cached value for key if there is one. Otherwise it stores
+// value with the default TTL and returns it. loaded reports whether the value was
+// already cached. Like sync.Map's LoadOrStore, concurrent calls for the same key
+// agree on a single winner, so it can be used to claim a key.
+func (c *Cache[K, V]) GetOrSet(key K, value V) (actual V, loaded bool) {
+	return c.GetOrSetTTL(key, value, c.valueTTL.or(value, c.defaultTTL))
+}
+
+// GetOrSetTTL is like GetOrSet but stores value with an explicit TTL.
+// A zero or negative TTL means the entry never expires.
+func (c *Cache[K, V]) GetOrSetTTL(key K, value V, ttl time.Duration) (actual V, loaded bool) {
+	var exp uint32
+	if ttl > 0 {
+		//nolint:gosec // G115: Unix seconds fit in uint32 until year 2106
+		exp = uint32(time.Now().Add(ttl).Unix())
+	}
+	actual, loaded = c.memory.getOrSet(key, value, exp)
+	if !loaded {
+		c.events.publish(EventSet, key, value)
+	}
+	return c.clone.copy(actual, loaded), loaded
+}
+
+// Swap stores value with the default TTL and returns the value it replaced.
+// existed reports whether there was a cached value.
+func (c *Cache[K, V]) Swap(key K, value V) (old V, existed bool) {
+	return c.SwapTTL(key, value, c.valueTTL.or(value, c.defaultTTL))
+}
+
+// SwapTTL is like Swap but stores value with an explicit TTL.
+// A zero or negative TTL means the entry never expires.
+func (c *Cache[K, V]) SwapTTL(key K, value V, ttl time.Duration) (old V, existed bool) {
+	var exp uint32
+	if ttl > 0 {
+		//nolint:gosec // G115: Unix seconds fit in uint32 until year 2106
+		exp = uint32(time.Now().Add(ttl).Unix())
+	}
+	old, existed = c.memory.swap(key, value, exp)
+	c.events.publish(EventSet, key, value)
+	return old, existed
 }
 
 // Delete removes a key from the cache.
 func (c *Cache[K, V]) Delete(key K) {
+	forgetFlight(c.flights, key)
 	c.memory.del(key)
+	var zero V
+	c.events.publish(EventDelete, key, zero)
+	c.deleteDependents(key)
+}
+
+// Forget detaches an in-flight Fetch for key, so the next Fetch calls its
+// loader afresh instead of waiting for a result that may be stale, e.g. after
+// the source changed. The detached load still returns its result to its
+// callers but doesn't cache it. Delete, GetAndDelete, Flush, and BumpEpoch
+// forget in-flight loads themselves.
+func (c *Cache[K, V]) Forget(key K) {
+	forgetFlight(c.flights, key)
+}
+
+// DeleteFunc removes every entry for which fn returns true, and their
+// dependents from SetWithDependencies, and returns the count removed.
+// fn must not call back into the cache. Entries written concurrently may be missed.
+func (c *Cache[K, V]) DeleteFunc(fn func(key K, value V) bool) int {
+	start := time.Now()
+	keys := c.memory.deleteFunc(fn)
+	var zero V
+	for _, k := range keys {
+		c.events.publish(EventDelete, k, zero)
+	}
+	removed := len(keys)
+	matched := make(map[K]struct{}, len(keys))
+	for _, k := range keys {
+		matched[k] = struct{}{}
+	}
+	for _, k := range keys {
+		for _, d := range c.deleteDependents(k) {
+			if _, ok := matched[d]; !ok {
+				removed++
+			}
+		}
+	}
+	c.audit.record(context.Background(), AdminOp{Op: "DeleteFunc", Time: start, Removed: removed})
+	return removed
+}
+
+// GetAndDelete removes key and returns its value. Of concurrent callers for the
+// same key, only one receives the value, so it suits one-shot tokens.
+func (c *Cache[K, V]) GetAndDelete(key K) (V, bool) {
+	forgetFlight(c.flights, key)
+	val, ok := c.memory.getAndDelete(key)
+	if ok {
+		c.events.publish(EventDelete, key, val)
+	}
+	c.deleteDependents(key)
+	return val, ok
 }
 
 // Fetch returns cached value or calls loader to compute it.
@@ -90,9 +379,46 @@ func (c *Cache[K, V]) FetchTTL(key K, ttl time.Duration, loader func() (V, error
 	return c.getSet(key, loader, ttl)
 }
 
+// FetchMulti returns the cached values for keys, calling loader once with the
+// keys that missed, so a batch of misses costs one query or RPC rather than
+// one per key. Loaded values are stored with the default TTL. Keys the loader
+// does not return are absent from the result and are not cached; values for
+// keys it was not asked for are ignored. Unlike Fetch, concurrent calls are
+// not deduplicated. If loader fails, FetchMulti returns its error.
+func (c *Cache[K, V]) FetchMulti(keys []K, loader func(missing []K) (map[K]V, error)) (map[K]V, error) {
+	out := c.GetMulti(keys)
+	var missing []K
+	seen := make(map[K]struct{})
+	for _, k := range keys {
+		if _, ok := out[k]; ok {
+			continue
+		}
+		if _, ok := seen[k]; !ok {
+			seen[k] = struct{}{}
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) == 0 {
+		return out, nil
+	}
+
+	loaded, err := loader(missing)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range missing {
+		if v, ok := loaded[k]; ok {
+			c.Set(k, v)
+			out[k] = c.clone.copy(v, true)
+		}
+	}
+	return out, nil
+}
+
 func (c *Cache[K, V]) getSet(key K, loader func() (V, error), ttl time.Duration) (V, error) {
-	if val, ok := c.memory.get(key); ok {
-		return val, nil
+	cached, hit := c.Get(key)
+	if hit && !c.memory.refreshDue(c.early, key) {
+		return cached, nil
 	}
 
 	call, loaded := c.flights.LoadOrCompute(key, func() (*flightCall[V], bool) {
@@ -102,75 +428,147 @@ func (c *Cache[K, V]) getSet(key K, loader func() (V, error), ttl time.Duration)
 	})
 
 	if loaded {
+		if hit {
+			return cached, nil // another caller is already refreshing it
+		}
 		call.wg.Wait()
-		return call.val, call.err
+		return c.clone.copy(call.val, call.err == nil), call.err
 	}
 
-	if val, ok := c.memory.get(key); ok {
-		call.val = val
-		c.flights.Delete(key)
-		call.wg.Done()
-		return val, nil
+	if !hit {
+		if val, ok := c.memory.get(key); ok {
+			call.val = val
+			endFlight(c.flights, key, call)
+			call.wg.Done()
+			return c.clone.copy(val, true), nil
+		}
 	}
 
+	start := time.Now()
 	val, err := loader()
-	if err == nil {
-		if ttl <= 0 {
-			c.Set(key, val)
-		} else {
-			c.SetTTL(key, val, ttl)
-		}
+	c.early.observe(time.Since(start))
+	switch {
+	case err == nil:
+		call.cache(func() {
+			if ttl <= 0 {
+				c.Set(key, val)
+			} else {
+				c.SetTTL(key, val, ttl)
+			}
+		})
+	case hit:
+		// An early refresh failed, but the cached value has not expired.
+		val, err = cached, nil
 	}
 
 	call.val, call.err = val, err
-	c.flights.Delete(key)
+	endFlight(c.flights, key, call)
 	call.wg.Done()
 
-	return val, err
+	// The loaded value is cached, so the caller gets a copy too.
+	return c.clone.copy(val, err == nil), err
 }
 
-// Len returns the number of entries.
+// Len returns the number of entries, excluding those on death row.
 func (c *Cache[K, V]) Len() int {
 	return c.memory.len()
 }
 
-// Flush removes all entries. Returns count removed.
+// Flush removes all entries and returns how many were live. A Set that
+// starts before Flush is removed with the rest, even if it finishes after.
 func (c *Cache[K, V]) Flush() int {
-	return c.memory.flush()
+	start := time.Now()
+	forgetFlights(c.flights)
+	c.deps.clear()
+	n := c.memory.flush()
+	c.audit.record(context.Background(), AdminOp{Op: "Flush", Time: start, Removed: n})
+	return n
+}
+
+// BumpEpoch invalidates all entries in O(1) without deleting them.
+// Invalidated entries read as misses and are evicted as new entries arrive,
+// so Len may count them until then.
+func (c *Cache[K, V]) BumpEpoch() {
+	start := time.Now()
+	forgetFlights(c.flights)
+	c.deps.clear()
+	c.memory.bumpEpoch()
+	c.audit.record(context.Background(), AdminOp{Op: "BumpEpoch", Time: start, Removed: -1})
+}
+
+// HotKeys returns up to n of the most frequently accessed keys in memory,
+// hottest first. Frequencies are the approximate counters S3-FIFO keeps for
+// eviction, so keys with similar traffic may appear in either order. Values
+// above LargeObjects' threshold are not ranked and never returned. It scans
+// every entry; call it periodically, not per request.
+func (c *Cache[K, V]) HotKeys(n int) []K {
+	return c.memory.hottest(n)
 }
 
 // Range returns an iterator over all non-expired key-value pairs.
 // Iteration order is undefined. Safe for concurrent use.
 // Changes during iteration may or may not be reflected.
 func (c *Cache[K, V]) Range() iter.Seq2[K, V] {
-	return func(yield func(K, V) bool) {
-		//nolint:gosec // G115: Unix seconds fit in uint32 until year 2106
-		now := uint32(time.Now().Unix())
-		c.memory.entries.Range(func(key K, e *entry[K, V]) bool {
-			// Skip expired entries.
-			expiry := e.expirySec.Load()
-			if expiry != 0 && expiry < now {
-				return true
-			}
-
-			// Load value with seqlock.
-			v, ok := e.loadValue()
-			if !ok {
-				return true
-			}
-
-			// Yield to caller.
-			return yield(key, v)
-		})
-	}
+	return copyAll(c.clone, c.memory.all())
 }
 
 type config struct {
-	size       int
-	defaultTTL time.Duration
+	sizer                 any // func(V) int, asserted by newLargeRegion
+	clone                 any // func(V) V, asserted by newCloner
+	ttlFromValue          any // func(V) time.Duration, asserted by newValueTTL
+	decodeFallback        any // func([]byte) (V, error), asserted by validateConfig
+	prefetch              any // Prefetcher[K, V], asserted by startPrefetching
+	prefetchRate          int
+	deadLetterSize        int
+	thresholdPct          float64
+	thresholdFn           func(Usage)
+	accessSink            func(AccessEvent)
+	faults                *FaultInjector
+	trace                 io.Writer
+	victim                any // Store[K, V], asserted by newVictimCache
+	victimTTL             time.Duration
+	accessRate            float64
+	size                  int
+	defaultTTL            time.Duration
+	memoryTTL             time.Duration
+	hotMaxTTL             time.Duration
+	hotFactor             float64
+	earlyBeta             float64
+	persistTTL            time.Duration
+	largeThreshold        int
+	largeMaxBytes         int
+	maxValueBytes         int
+	missFilterKeys        int
+	hashLongKeys          bool
+	noPersistPatterns     []string
+	noPersistFunc         any // func(K) bool, asserted by newNoPersist
+	version               string
+	purgeVersions         bool
+	asyncWait             time.Duration
+	asyncQueueSize        int
+	asyncQueueTimeout     time.Duration
+	backpressure          Backpressure
+	persistConcurrency    int
+	backgroundConcurrency int
+	cleanupInterval       time.Duration
+	cleanupMaxAge         time.Duration
+	hotKeys               int
+	hotKeysInterval       time.Duration
+	clockResolution       time.Duration
+	ghostFPRate           float64
+	ghostFreqs            int
+	exactGhosts           bool
+	compact               bool // NewSmall: favor footprint over fill-time allocation
+	deterministic         bool
+	evictionTrace         int
+	readYourWrites        bool
+	snapshotOnShutdown    bool
+	trackStats            bool
+	cpuLocalStats         bool
 }
 
-// Option configures a Cache.
+// Option configures a Cache. NewTiered rejects invalid or conflicting options
+// with ErrInvalidConfig; Config reports the settings in effect.
 type Option func(*config)
 
 // Size sets maximum entries. Default 16384.
@@ -182,3 +580,383 @@ func Size(n int) Option {
 func TTL(d time.Duration) Option {
 	return func(c *config) { c.defaultTTL = d }
 }
+
+// TTLFromValue sets a function that derives each entry's TTL from its value,
+// such as a token's expiry or an HTTP response's Cache-Control max-age, so
+// call sites can use Set and Fetch instead of computing a TTL for SetTTL and
+// FetchTTL. It applies wherever the default TTL would: explicit TTLs win, and
+// when fn returns zero or less the default TTL is used. A TieredCache
+// persists entries with the derived TTL too, overriding PersistTTL; MemoryTTL
+// still caps it in memory. V must match the cache's value type: NewTiered
+// returns ErrInvalidConfig on a mismatch, and New ignores the function.
+func TTLFromValue[V any](fn func(V) time.Duration) Option {
+	return func(c *config) { c.ttlFromValue = fn }
+}
+
+// EarlyExpiry makes Fetch recompute a value shortly before it expires, so a
+// hot key is refreshed by one request while the others keep reading the
+// cached value, instead of all of them missing at once when it expires. This
+// is XFetch's probabilistic early expiration: each Fetch hit recomputes with a
+// probability that rises toward the value's expiry, scaled by how long recent
+// loaders took and by beta. Beta 1 is the usual choice; above 1 refreshes
+// earlier, below 1 later. There are no locks or markers, so it works as well
+// across replicas sharing a store as within one process. Values without a TTL
+// are never refreshed early, and if an early refresh fails the cached value is
+// returned. Default 0 (off).
+func EarlyExpiry(beta float64) Option {
+	return func(c *config) { c.earlyBeta = beta }
+}
+
+// HotTTL extends the TTL of entries that prove hot, so they stay cached
+// without callers renewing them. When hits raise an entry's S3-FIFO frequency
+// to its maximum, its TTL is multiplied by factor, counted from when it was
+// written, up to maxTTL. An entry that cools in the main queue and heats up
+// again is extended again. Entries without a TTL are unaffected, and so is
+// the persisted copy of a TieredCache entry; in memory, extensions may run
+// past MemoryTTL up to maxTTL. factor must exceed 1 and maxTTL be at least a
+// second: NewTiered returns ErrInvalidConfig otherwise, and New ignores the
+// option.
+func HotTTL(factor float64, maxTTL time.Duration) Option {
+	return func(c *config) {
+		c.hotFactor = factor
+		c.hotMaxTTL = maxTTL
+	}
+}
+
+// MemoryTTL caps how long a TieredCache keeps entries in memory, independent of
+// their persisted TTL. Expired memory entries are reloaded from persistence on the
+// next Get, so memory can be kept fresher than the durable copy. Default 0 (no cap).
+func MemoryTTL(d time.Duration) Option {
+	return func(c *config) { c.memoryTTL = d }
+}
+
+// PersistTTL sets the default expiration for entries written to a TieredCache's
+// persistence tier, overriding TTL. Default 0 (use TTL).
+func PersistTTL(d time.Duration) Option {
+	return func(c *config) { c.persistTTL = d }
+}
+
+// Victim spills recently-hot entries evicted from a TieredCache's memory tier to
+// store (typically localfs) with a short ttl, instead of discarding them. Memory
+// misses for spilled keys are served from store before falling back to the main
+// persistence tier. The cache takes ownership of store and closes it on Close.
+// K and V must match the cache's types, or NewTiered returns ErrInvalidConfig.
+func Victim[K comparable, V any](store Store[K, V], ttl time.Duration) Option {
+	return func(c *config) {
+		c.victim = store
+		c.victimTTL = ttl
+	}
+}
+
+// ReadYourWrites makes a TieredCache consult in-flight SetAsync writes on Get, so a
+// value written asynchronously is never replaced by an older persisted copy when it
+// is evicted from memory before its persistence write completes.
+func ReadYourWrites() Option {
+	return func(c *config) { c.readYourWrites = true }
+}
+
+// AsyncWait makes a TieredCache's SetAsync wait up to d for its persistence write
+// before returning; slower writes continue in the background. On platforms that
+// throttle CPU outside request handling, such as Cloud Run without "CPU always
+// allocated", background goroutines may barely run once the response is sent, so
+// async writes can stall until the instance is shut down. Waiting while the request
+// is still being handled lets most writes finish with CPU available, at a bounded
+// latency cost. Default 0 (return immediately).
+func AsyncWait(d time.Duration) Option {
+	return func(c *config) { c.asyncWait = d }
+}
+
+// AsyncQueue bounds how many SetAsync and DeleteAsync writes a TieredCache
+// holds at once, from the call until the write reaches persistence, so a slow
+// or unreachable store makes callers feel back-pressure instead of piling up
+// goroutines until the process runs out of memory. When size writes are
+// outstanding, policy decides what a new one does: BackpressureReject returns
+// ErrBackpressure; BackpressureBlock waits for a slot up to timeout (0 waits
+// as long as ctx allows), then returns ErrBackpressure; BackpressureDropOldest
+// cancels the oldest outstanding write, recording it with DeadLetter, and
+// waits for its slot the same way. A refused write changes nothing; a dropped
+// one stays in memory but may not reach persistence. Stats reports the queue
+// depth and refused and dropped writes. Default 0 (unbounded).
+func AsyncQueue(size int, policy Backpressure, timeout time.Duration) Option {
+	return func(c *config) {
+		c.asyncQueueSize = size
+		c.backpressure = policy
+		c.asyncQueueTimeout = timeout
+	}
+}
+
+// PersistConcurrency bounds how many persistence operations a TieredCache
+// runs at once, sync and async together, so a burst of misses can't open
+// thousands of files or exhaust a Valkey connection pool. Operations beyond n
+// wait for a slot, or fail with their context's error if it ends first; async
+// writes wait within their timeout. The victim store and SetReader and
+// GetReader share the limit; a stream read through GetReader's reader does
+// not hold a slot. The cache's Store field holds the limiting wrapper, which
+// does not expose the underlying store's optional interfaces. Default 0 (no
+// limit).
+func PersistConcurrency(n int) Option {
+	return func(c *config) { c.persistConcurrency = n }
+}
+
+// BackgroundConcurrency gives a TieredCache's background persistence traffic
+// its own budget of n operations at once, so a backlog of async writes,
+// victim spills, or AutoCleanup never holds the slots interactive reads and
+// writes need. Background operations then no longer count against
+// PersistConcurrency, which bounds foreground traffic alone; without it,
+// foreground traffic is unbounded. Callers can put their own bulk work in
+// the background lane with BackgroundLane. Default 0 (background traffic
+// shares PersistConcurrency).
+func BackgroundConcurrency(n int) Option {
+	return func(c *config) { c.backgroundConcurrency = n }
+}
+
+// SnapshotOnShutdown makes TieredCache.Shutdown write every live memory entry to
+// persistence after draining async writes, so the next instance warms from the
+// latest values. Entries keep their memory expiry. Values stored with
+// SetMemoryOnly are not written.
+func SnapshotOnShutdown() Option {
+	return func(c *config) { c.snapshotOnShutdown = true }
+}
+
+// Version namespaces a TieredCache's persisted entries by v, typically the
+// application's build or schema version, so a deploy with incompatible value
+// types never decodes entries written by an older build. With purge, entries
+// from other versions are deleted in the background after NewTiered returns;
+// during a rolling deploy, replicas still running the old version will miss.
+// The store must implement Versioner, as localfs and valkey do.
+func Version(v string, purge bool) Option {
+	return func(c *config) {
+		c.version = v
+		c.purgeVersions = purge
+	}
+}
+
+// DecodeFallback sets a function a TieredCache's store calls on values its
+// codec cannot decode, such as entries written before a field changed type,
+// instead of reporting them as corrupt. raw is the value as encoded by the
+// old build; fn converts it to the current type, or returns an error if it
+// cannot. Entries are migrated lazily: each is converted when read, and
+// rewritten in the current format by the next Set.
+//
+// The store must implement FallbackDecoder, as localfs and valkey do, and V
+// must match the cache's value type: NewTiered returns ErrInvalidConfig
+// otherwise.
+func DecodeFallback[V any](fn func(raw []byte) (V, error)) Option {
+	return func(c *config) { c.decodeFallback = fn }
+}
+
+// Prefetch loads entries from p into memory in the background after New or
+// NewTiered returns, so a cold instance starts warm without delaying startup.
+// At most perSecond entries are loaded a second, on average, to spare the
+// source; 0 means no limit. Entries get the default TTL and never replace a
+// value the cache already holds. TieredCache keeps them in memory only, as
+// SetMemoryOnly, and Close stops a prefetch still in progress; a Cache runs it
+// until p is exhausted. K and V must match the cache's types: NewTiered
+// returns ErrInvalidConfig on a mismatch, and New ignores the prefetcher.
+func Prefetch[K comparable, V any](p Prefetcher[K, V], perSecond int) Option {
+	return func(c *config) {
+		c.prefetch = p
+		c.prefetchRate = perSecond
+	}
+}
+
+// DeadLetter makes a TieredCache keep up to size async writes that failed to
+// persist, instead of only logging them, so they can be inspected with
+// FailedWrites, counted with DeadLetterStats, and retried with Redrive. Only
+// the latest failure per key is kept, and it is discarded once a newer write
+// to the key supersedes it; when the buffer is full, the oldest is dropped.
+func DeadLetter(size int) Option {
+	return func(c *config) { c.deadLetterSize = size }
+}
+
+// ThresholdCallback makes a TieredCache call fn when a resource's occupancy
+// rises to pct percent or above: memory entries against Size, pending async
+// writes against Size, and, for stores implementing DiskUsager, disk usage.
+// Resources are checked every second, and each crossing is reported once,
+// until the resource drops back below pct. fn runs on the checking goroutine
+// and should return quickly, e.g. by signaling a load shedder or an alert.
+func ThresholdCallback(pct float64, fn func(Usage)) Option {
+	return func(c *config) {
+		c.thresholdPct = pct
+		c.thresholdFn = fn
+	}
+}
+
+// MissFilter makes a TieredCache keep a bloom filter of the keys in its store,
+// sized for expectedKeys, so a memory miss for a key that was never persisted
+// returns without a store read. The filter is seeded by scanning the store in
+// the background; reads go to the store until the scan finishes. It requires
+// string keys and a store that implements PrefixScanner.
+//
+// Keys written to the store by anything other than this cache, such as another
+// replica, read as misses once the filter is seeded. Use MissFilter only where
+// this cache is the store's sole writer, or where such misses are acceptable.
+func MissFilter(expectedKeys int) Option {
+	return func(c *config) { c.missFilterKeys = expectedKeys }
+}
+
+// HashLongKeys makes a TieredCache persist a key too long for one of its
+// store tiers, such as a 300-byte key with a localfs victim under a Valkey
+// store, under a shortened form: the key's leading bytes and a SHA-256 of the
+// whole key, at most as long as the tier's limit. Keys within the limit are
+// stored unchanged. It requires string keys and a tier that implements
+// KeyLimiter. Without it, keys the main store rejects fail with its error, and
+// keys only the victim store rejects are never spilled to it.
+//
+// A hashed key is what PrefixScanner iteration and DeleteFunc see for such
+// entries, and prefix scans match only its leading bytes.
+func HashLongKeys() Option {
+	return func(c *config) { c.hashLongKeys = true }
+}
+
+// NoPersistPattern keeps keys matching any of the glob patterns, in the
+// syntax of path.Match, out of a TieredCache's persistence and victim
+// stores, such as "nonce:*" for per-request nonces. Matching entries are
+// cached in memory only: writes skip the stores, and misses don't read them.
+// It requires string keys; NewTiered rejects malformed patterns.
+func NoPersistPattern(patterns ...string) Option {
+	return func(c *config) { c.noPersistPatterns = append(c.noPersistPatterns, patterns...) }
+}
+
+// NoPersistFunc keeps keys for which fn returns true out of a TieredCache's
+// persistence and victim stores, as NoPersistPattern does for keys matching a
+// pattern. fn is called on every store operation, so it must be fast. K must
+// match the cache's key type, or NewTiered returns ErrInvalidConfig.
+func NoPersistFunc[K comparable](fn func(K) bool) Option {
+	return func(c *config) { c.noPersistFunc = fn }
+}
+
+// AutoCleanup makes a TieredCache call Store.Cleanup(maxAge) every interval
+// until Close. When replicas share a store that implements Leaser (Valkey,
+// Datastore), only the replica holding the cleanup lease runs it.
+func AutoCleanup(interval, maxAge time.Duration) Option {
+	return func(c *config) {
+		c.cleanupInterval = interval
+		c.cleanupMaxAge = maxAge
+	}
+}
+
+// PersistHotKeys makes a TieredCache save its n hottest keys, as HotKeys
+// ranks them, to the store every interval and again on Shutdown. A new
+// TieredCache on the same store loads the entries for the last saved list
+// into memory in the background, hottest first, before Prefetch runs, so its
+// warmup starts with the keys that see the most traffic rather than whatever
+// the prefetcher yields first. Close stops both. The store must implement
+// HotKeyRecorder, as localfs and valkey do; NewTiered returns
+// ErrInvalidConfig otherwise.
+func PersistHotKeys(n int, interval time.Duration) Option {
+	return func(c *config) {
+		c.hotKeys = n
+		c.hotKeysInterval = interval
+	}
+}
+
+// TrackStats makes the cache count Get and Fetch hits and misses for Stats,
+// including hit rates over the last minute, five minutes, and hour. It adds a
+// clock read and atomic increments to every lookup. Default off.
+func TrackStats() Option {
+	return func(c *config) { c.trackStats = true }
+}
+
+// CPULocalStats makes TrackStats count into per-P stripes, one per
+// GOMAXPROCS up to 16, instead of counters every core increments, for
+// read-heavy caches where those shared cache lines bounce between cores. A
+// lookup picks its stripe through sync.Pool's per-P cache; Stats sums the
+// stripes. Each stripe holds about 9KB of rolling-window buckets. It requires
+// TrackStats.
+func CPULocalStats() Option {
+	return func(c *config) { c.cpuLocalStats = true }
+}
+
+// LargeObjects routes values larger than threshold bytes into a separate FIFO region
+// capped at maxBytes, so a few huge values can't crowd out the main queues.
+// With maxBytes of 0, large values are not kept in memory at all; a TieredCache
+// still writes them to persistence. Values are measured with Sizer, or len() for
+// string and []byte values.
+func LargeObjects(threshold, maxBytes int) Option {
+	return func(c *config) {
+		c.largeThreshold = threshold
+		c.largeMaxBytes = maxBytes
+	}
+}
+
+// MaxValueBytes makes TieredCache writes reject values larger than n bytes with
+// ErrValueTooLarge, before they reach memory or persistence. Backends such as
+// Datastore fail at their own limits with less helpful errors, and SetAsync
+// would only log them. Values are measured with Sizer, len() for string and
+// []byte values, or else their JSON encoding. Default 0 (no limit).
+func MaxValueBytes(n int) Option {
+	return func(c *config) { c.maxValueBytes = n }
+}
+
+// ClockResolution sets how often the clock used for expiry checks on reads is
+// refreshed. Reads then cost an atomic load instead of a time.Now call, but an
+// entry may be served for up to d past its expiry. The clock holds whole
+// seconds, so d is rounded up to a whole number of seconds. A negative d reads
+// the exact time on every check. Default 1s.
+func ClockResolution(d time.Duration) Option {
+	return func(c *config) { c.clockResolution = d }
+}
+
+// Deterministic makes eviction reproducible, for replaying a workload behind a
+// hit-rate anomaly: the same operations issued from one goroutine make the
+// same decisions in the same order. Expiry checks read the exact time, as with
+// ClockResolution(-1), and nothing runs in the background: New skips Prefetch,
+// and NewTiered rejects it and AutoCleanup. TTLs still follow the wall clock.
+//
+// If trace is non-nil, each eviction decision is written to it as a numbered
+// line naming the action (promote, demote, second-chance, death-row, evict,
+// resurrect), the key and its hash, the entry's frequency and peak frequency,
+// and the death row admission threshold when consulted. Writes happen under
+// the cache lock, so trace should be fast, such as a bytes.Buffer or bufio.Writer.
+func Deterministic(trace io.Writer) Option {
+	return func(c *config) {
+		c.deterministic = true
+		c.trace = trace
+		c.clockResolution = -1
+	}
+}
+
+// GhostFrequencies sets how many recently evicted keys have their access
+// frequency remembered, so a key readmitted soon after eviction regains its
+// standing instead of starting cold. Default 256, scaled up for caches whose
+// ghost queue tracks more than 16384 keys.
+func GhostFrequencies(n int) Option {
+	return func(c *config) { c.ghostFreqs = n }
+}
+
+// GhostFPRate sets the false positive rate of the bloom filters that remember
+// recently evicted keys. Lower rates use more memory; higher rates readmit more
+// keys as if they had been seen before. Default 0.00001.
+func GhostFPRate(p float64) Option {
+	return func(c *config) { c.ghostFPRate = p }
+}
+
+// ExactGhosts tracks recently evicted keys in an exact FIFO queue instead of
+// rotating bloom filters. Admission decisions never see a false positive and
+// keys are forgotten strictly in eviction order, at the cost of ~16 bytes per
+// remembered key rather than ~3. GhostFPRate has no effect when set.
+func ExactGhosts() Option {
+	return func(c *config) { c.exactGhosts = true }
+}
+
+// CopyOnRead sets a function that copies values on their way out of the cache,
+// for values such as pointers, slices, and maps that callers might mutate.
+// Get, GetMulti, GetOrSet, Fetch, and Range return copies; values removed by
+// Swap and GetAndDelete are no longer shared and are returned as is.
+//
+// Without it, readers share the stored value. Each Set publishes a new value
+// atomically, so readers never observe a partial update, but a caller that
+// mutates a value after Set or Get changes it for everyone. V must match the
+// cache's value type: NewTiered returns ErrInvalidConfig on a mismatch, and
+// New ignores the function.
+func CopyOnRead[V any](clone func(V) V) Option {
+	return func(c *config) { c.clone = clone }
+}
+
+// Sizer sets the function used to measure values for LargeObjects and MaxValueBytes.
+// V must match the cache's value type: NewTiered returns ErrInvalidConfig
+// on a mismatch, and New panics with it.
+func Sizer[V any](fn func(V) int) Option {
+	return func(c *config) { c.sizer = fn }
+}
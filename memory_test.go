@@ -1,7 +1,11 @@
 package fido
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -159,6 +163,18 @@ func BenchmarkCache_Get_Hit(b *testing.B) {
 	}
 }
 
+func BenchmarkCache_Get_HitTTL(b *testing.B) {
+	cache := New[int, int](TTL(time.Hour))
+	for i := range 10000 {
+		cache.Set(i, i)
+	}
+
+	b.ResetTimer()
+	for i := range b.N {
+		cache.Get(i % 10000)
+	}
+}
+
 func BenchmarkCache_Get_Miss(b *testing.B) {
 	cache := New[int, int]()
 
@@ -420,6 +436,364 @@ func TestCache_Set_NoDefaultTTL(t *testing.T) {
 	}
 }
 
+func TestCache_GetOrSet(t *testing.T) {
+	cache := New[string, int]()
+
+	if v, loaded := cache.GetOrSet("a", 1); loaded || v != 1 {
+		t.Errorf("GetOrSet(a, 1) = %d, %v; want 1, false", v, loaded)
+	}
+	if v, loaded := cache.GetOrSet("a", 2); !loaded || v != 1 {
+		t.Errorf("GetOrSet(a, 2) = %d, %v; want 1, true", v, loaded)
+	}
+	if v, _ := cache.Get("a"); v != 1 {
+		t.Errorf("Get(a) = %d; want 1", v)
+	}
+
+	cache.Delete("a")
+	if v, loaded := cache.GetOrSet("a", 3); loaded || v != 3 {
+		t.Errorf("GetOrSet after Delete = %d, %v; want 3, false", v, loaded)
+	}
+
+	cache.BumpEpoch()
+	if v, loaded := cache.GetOrSet("a", 4); loaded || v != 4 {
+		t.Errorf("GetOrSet after BumpEpoch = %d, %v; want 4, false", v, loaded)
+	}
+	if got := cache.Len(); got != 1 {
+		t.Errorf("Len = %d; want 1", got)
+	}
+}
+
+func TestCache_GetOrSetTTL_Expired(t *testing.T) {
+	cache := New[string, int]()
+	cache.SetTTL("a", 1, time.Second)
+	ent, ok := cache.memory.getEntry("a")
+	if !ok {
+		t.Fatal("entry missing")
+	}
+	ent.storeValue(1, 1, ent.slot.Load().epoch, 0) // expired long ago
+
+	if v, loaded := cache.GetOrSetTTL("a", 2, time.Hour); loaded || v != 2 {
+		t.Errorf("GetOrSetTTL over expired = %d, %v; want 2, false", v, loaded)
+	}
+	if v, ok := cache.Get("a"); !ok || v != 2 {
+		t.Errorf("Get(a) = %d, %v; want 2, true", v, ok)
+	}
+}
+
+func TestCache_GetOrSet_Concurrent(t *testing.T) {
+	cache := New[int, int](Size(100))
+	for key := range 50 {
+		var wg sync.WaitGroup
+		var winners atomic.Int32
+		results := make([]int, 16)
+		for i := range results {
+			wg.Go(func() {
+				v, loaded := cache.GetOrSet(key, i)
+				if !loaded {
+					winners.Add(1)
+				}
+				results[i] = v
+			})
+		}
+		wg.Wait()
+		if n := winners.Load(); n != 1 {
+			t.Fatalf("key %d: %d winners; want 1", key, n)
+		}
+		for i, v := range results {
+			if v != results[0] {
+				t.Fatalf("key %d: caller %d saw %d, caller 0 saw %d", key, i, v, results[0])
+			}
+		}
+	}
+}
+
+func TestCache_GetOrSet_LargeObjects(t *testing.T) {
+	cache := New[string, string](LargeObjects(8, 1024))
+	big := strings.Repeat("x", 64)
+
+	if _, loaded := cache.GetOrSet("k", big); loaded {
+		t.Error("first GetOrSet should store")
+	}
+	if v, loaded := cache.GetOrSet("k", "small"); !loaded || v != big {
+		t.Errorf("GetOrSet = %q, %v; want large value, true", v, loaded)
+	}
+	cache.Set("s", "small")
+	if v, loaded := cache.GetOrSet("s", big); !loaded || v != "small" {
+		t.Errorf("GetOrSet(s) = %q, %v; want small, true", v, loaded)
+	}
+}
+
+func TestCache_Swap(t *testing.T) {
+	cache := New[string, int]()
+
+	if old, existed := cache.Swap("a", 1); existed || old != 0 {
+		t.Errorf("Swap(a, 1) = %d, %v; want 0, false", old, existed)
+	}
+	if old, existed := cache.Swap("a", 2); !existed || old != 1 {
+		t.Errorf("Swap(a, 2) = %d, %v; want 1, true", old, existed)
+	}
+	if v, _ := cache.Get("a"); v != 2 {
+		t.Errorf("Get(a) = %d; want 2", v)
+	}
+
+	cache.BumpEpoch()
+	if old, existed := cache.SwapTTL("a", 3, time.Hour); existed {
+		t.Errorf("SwapTTL after BumpEpoch = %d, %v; want 0, false", old, existed)
+	}
+	if v, ok := cache.Get("a"); !ok || v != 3 {
+		t.Errorf("Get(a) = %d, %v; want 3, true", v, ok)
+	}
+	if got := cache.Len(); got != 1 {
+		t.Errorf("Len = %d; want 1", got)
+	}
+}
+
+func TestCache_Swap_LargeObjects(t *testing.T) {
+	cache := New[string, string](LargeObjects(8, 1024))
+	big := strings.Repeat("x", 64)
+
+	cache.Set("k", "small")
+	if old, existed := cache.Swap("k", big); !existed || old != "small" {
+		t.Errorf("Swap to large = %q, %v; want small, true", old, existed)
+	}
+	if old, existed := cache.Swap("k", "tiny"); !existed || old != big {
+		t.Errorf("Swap from large = %q, %v; want large value, true", old, existed)
+	}
+	if v, ok := cache.Get("k"); !ok || v != "tiny" {
+		t.Errorf("Get(k) = %q, %v; want tiny, true", v, ok)
+	}
+	if got := cache.Len(); got != 1 {
+		t.Errorf("Len = %d; want 1", got)
+	}
+}
+
+func TestCache_GetAndDelete(t *testing.T) {
+	cache := New[string, string](LargeObjects(8, 1024))
+	big := strings.Repeat("x", 64)
+	cache.Set("a", "small")
+	cache.Set("b", big)
+
+	if v, ok := cache.GetAndDelete("a"); !ok || v != "small" {
+		t.Errorf("GetAndDelete(a) = %q, %v; want small, true", v, ok)
+	}
+	if v, ok := cache.GetAndDelete("b"); !ok || v != big {
+		t.Errorf("GetAndDelete(b) = %q, %v; want large value, true", v, ok)
+	}
+	for _, k := range []string{"a", "b", "missing"} {
+		if _, ok := cache.GetAndDelete(k); ok {
+			t.Errorf("GetAndDelete(%s) after delete should miss", k)
+		}
+	}
+	if got := cache.Len(); got != 0 {
+		t.Errorf("Len = %d; want 0", got)
+	}
+}
+
+func TestCache_GetAndDelete_Concurrent(t *testing.T) {
+	cache := New[int, int]()
+	for key := range 100 {
+		cache.Set(key, key)
+		var wg sync.WaitGroup
+		var winners atomic.Int32
+		for range 8 {
+			wg.Go(func() {
+				if _, ok := cache.GetAndDelete(key); ok {
+					winners.Add(1)
+				}
+			})
+		}
+		wg.Wait()
+		if n := winners.Load(); n != 1 {
+			t.Fatalf("key %d: %d callers got the value; want 1", key, n)
+		}
+	}
+}
+
+func TestCache_DeleteFunc(t *testing.T) {
+	cache := New[int, string](LargeObjects(8, 1024))
+	for i := range 100 {
+		cache.Set(i, fmt.Sprintf("org%d", i%3))
+	}
+	cache.Set(100, "org0"+strings.Repeat("x", 64))
+
+	n := cache.DeleteFunc(func(_ int, v string) bool { return strings.HasPrefix(v, "org0") })
+	if n != 35 {
+		t.Errorf("DeleteFunc = %d; want 35", n)
+	}
+	if got := cache.Len(); got != 66 {
+		t.Errorf("Len = %d; want 66", got)
+	}
+	for k, v := range cache.Range() {
+		if strings.HasPrefix(v, "org0") {
+			t.Errorf("key %d = %q survived DeleteFunc", k, v)
+		}
+	}
+	if n := cache.DeleteFunc(func(int, string) bool { return false }); n != 0 {
+		t.Errorf("DeleteFunc matching nothing = %d; want 0", n)
+	}
+}
+
+func TestCache_CopyOnRead(t *testing.T) {
+	var copies atomic.Int32
+	clone := func(v []int) []int {
+		copies.Add(1)
+		return append([]int(nil), v...)
+	}
+	cache := New[string, []int](CopyOnRead(clone))
+	if !cache.Config().CopyOnRead {
+		t.Error("Config().CopyOnRead = false; want true")
+	}
+	cache.Set("a", []int{1, 2})
+
+	mutate := func(name string, v []int) {
+		t.Helper()
+		if len(v) != 2 {
+			t.Fatalf("%s = %v; want 2 elements", name, v)
+		}
+		v[0] = 99
+		if got, _ := cache.memory.get("a"); got[0] != 1 {
+			t.Errorf("mutating %s result changed the cached value to %v", name, got)
+		}
+	}
+	v, _ := cache.Get("a")
+	mutate("Get", v)
+	mutate("GetMulti", cache.GetMulti([]string{"a", "missing"})["a"])
+	v, loaded := cache.GetOrSet("a", nil)
+	if !loaded {
+		t.Error("GetOrSet loaded = false; want true")
+	}
+	mutate("GetOrSet", v)
+	v, _ = cache.Fetch("a", func() ([]int, error) { return nil, nil }) //nolint:nilnil // never called
+	mutate("Fetch", v)
+	for k, v := range cache.Range() {
+		mutate("Range "+k, v)
+	}
+
+	// Computed values are cached, so the loader's caller gets a copy as well.
+	v, _ = cache.Fetch("b", func() ([]int, error) { return []int{1, 2}, nil })
+	v[0] = 99
+	if got, _ := cache.Get("b"); got[0] != 1 {
+		t.Errorf("mutating Fetch result changed the cached value to %v", got)
+	}
+
+	// Misses aren't cloned; the removed value from Swap is no longer shared.
+	before := copies.Load()
+	cache.Get("missing")
+	old, _ := cache.Swap("a", []int{3})
+	if copies.Load() != before || old[0] != 1 {
+		t.Errorf("clone calls = %d, Swap old = %v; want %d, [1 2]", copies.Load(), old, before)
+	}
+
+	// A clone for a different value type is ignored by New.
+	plain := New[string, []int](CopyOnRead(func(s string) string { return s }))
+	if plain.Config().CopyOnRead {
+		t.Error("mismatched CopyOnRead should be ignored")
+	}
+}
+
+func TestCache_TTLFromValue(t *testing.T) {
+	type token struct {
+		value  string
+		expiry time.Time
+	}
+	ttlOf := func(tok token) time.Duration { return time.Until(tok.expiry) }
+	cache := New[string, token](TTL(time.Hour), TTLFromValue(ttlOf))
+	if !cache.Config().TTLFromValue {
+		t.Error("Config().TTLFromValue = false; want true")
+	}
+	memTTL := func(key string) time.Duration {
+		t.Helper()
+		ent, ok := cache.memory.getEntry(key)
+		if !ok {
+			t.Fatalf("%s should be in memory", key)
+		}
+		return time.Until(time.Unix(int64(ent.expirySec()), 0))
+	}
+
+	cache.Set("short", token{"a", time.Now().Add(10 * time.Second)})
+	if d := memTTL("short"); d > 11*time.Second || d < 8*time.Second {
+		t.Errorf("Set TTL = %v; want ~10s from the value", d)
+	}
+	cache.Set("expired", token{"b", time.Now().Add(-time.Minute)})
+	if d := memTTL("expired"); d < 59*time.Minute {
+		t.Errorf("Set TTL = %v; want the 1h default when the value gives none", d)
+	}
+	cache.SetTTL("explicit", token{"c", time.Now().Add(10 * time.Second)}, 2*time.Hour)
+	if d := memTTL("explicit"); d < 119*time.Minute {
+		t.Errorf("SetTTL TTL = %v; want the explicit 2h", d)
+	}
+	if _, err := cache.Fetch("fetched", func() (token, error) {
+		return token{"d", time.Now().Add(20 * time.Second)}, nil
+	}); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if d := memTTL("fetched"); d > 21*time.Second || d < 18*time.Second {
+		t.Errorf("Fetch TTL = %v; want ~20s from the value", d)
+	}
+	cache.GetOrSet("claimed", token{"e", time.Now().Add(30 * time.Second)})
+	if d := memTTL("claimed"); d > 31*time.Second || d < 28*time.Second {
+		t.Errorf("GetOrSet TTL = %v; want ~30s from the value", d)
+	}
+
+	plain := New[string, token](TTLFromValue(func(string) time.Duration { return time.Second }))
+	if plain.Config().TTLFromValue {
+		t.Error("mismatched TTLFromValue should be ignored")
+	}
+}
+
+func TestCache_GetMulti(t *testing.T) {
+	cache := New[string, string](LargeObjects(8, 1024), TrackStats())
+	big := strings.Repeat("x", 64)
+	cache.Set("a", "1")
+	cache.Set("b", "2")
+	cache.Set("big", big)
+	cache.SetTTL("old", "3", time.Hour)
+	ent, _ := cache.memory.getEntry("old")
+	ent.storeValue("3", 1, cache.memory.epoch.Load(), 0) // expired long ago
+
+	got := cache.GetMulti([]string{"a", "missing", "big", "old", "b", "a"})
+	want := map[string]string{"a": "1", "b": "2", "big": big}
+	if len(got) != len(want) {
+		t.Errorf("GetMulti returned %d values; want %d", len(got), len(want))
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("GetMulti[%s] = %q; want %q", k, got[k], v)
+		}
+	}
+	if s := cache.Stats(); s.Hits+s.Misses != 6 {
+		t.Errorf("Stats recorded %d lookups; want 6", s.Hits+s.Misses)
+	}
+	if got := cache.GetMulti(nil); len(got) != 0 {
+		t.Errorf("GetMulti(nil) = %v; want empty", got)
+	}
+}
+
+func BenchmarkCache_GetMulti(b *testing.B) {
+	cache := New[int, int](TTL(time.Hour))
+	keys := make([]int, 100)
+	for i := range keys {
+		keys[i] = i * 7
+		cache.Set(keys[i], i)
+	}
+
+	b.Run("GetMulti", func(b *testing.B) {
+		for range b.N {
+			cache.GetMulti(keys)
+		}
+	})
+	b.Run("GetLoop", func(b *testing.B) {
+		for range b.N {
+			out := make(map[int]int, len(keys))
+			for _, k := range keys {
+				if v, ok := cache.Get(k); ok {
+					out[k] = v
+				}
+			}
+		}
+	})
+}
+
 func TestCache_Fetch_Basic(t *testing.T) {
 	cache := New[string, int]()
 
@@ -473,6 +847,89 @@ func TestCache_Fetch_LoaderError(t *testing.T) {
 	}
 }
 
+func TestCache_Forget(t *testing.T) {
+	cache := New[string, int]()
+	started, release := make(chan struct{}), make(chan struct{})
+	stale := make(chan int)
+	go func() {
+		v, _ := cache.Fetch("k", func() (int, error) { //nolint:errcheck // loader never fails
+			close(started)
+			<-release
+			return 1, nil
+		})
+		stale <- v
+	}()
+	<-started
+
+	// After Forget, Fetch loads afresh rather than joining the stale load.
+	cache.Forget("k")
+	if v, err := cache.Fetch("k", func() (int, error) { return 2, nil }); err != nil || v != 2 {
+		t.Fatalf("Fetch after Forget = %d, %v; want 2, nil", v, err)
+	}
+	close(release)
+	if v := <-stale; v != 1 {
+		t.Errorf("forgotten Fetch = %d; want its own result 1", v)
+	}
+	if v, ok := cache.Get("k"); !ok || v != 2 {
+		t.Errorf("Get = %d, %v; want 2: the forgotten load must not overwrite it", v, ok)
+	}
+}
+
+func TestCache_DeleteForgetsFetch(t *testing.T) {
+	cache := New[string, int]()
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = cache.Fetch("k", func() (int, error) { //nolint:errcheck // loader never fails
+			close(started)
+			<-release
+			return 1, nil
+		})
+	}()
+	<-started
+	cache.Delete("k")
+	close(release)
+	<-done
+	if v, ok := cache.Get("k"); ok {
+		t.Errorf("Get = %d after Delete; want a miss, not the in-flight result", v)
+	}
+}
+
+func TestCache_FetchMulti(t *testing.T) {
+	cache := New[string, int]()
+	cache.Set("hit", 1)
+
+	var calls [][]string
+	loader := func(missing []string) (map[string]int, error) {
+		calls = append(calls, missing)
+		return map[string]int{"a": 10, "b": 20}, nil
+	}
+	got, err := cache.FetchMulti([]string{"hit", "a", "b", "a", "none"}, loader)
+	if err != nil {
+		t.Fatalf("FetchMulti: %v", err)
+	}
+	if want := map[string]int{"hit": 1, "a": 10, "b": 20}; !maps.Equal(got, want) {
+		t.Errorf("FetchMulti = %v; want %v", got, want)
+	}
+	if len(calls) != 1 || !slices.Equal(calls[0], []string{"a", "b", "none"}) {
+		t.Errorf("loader calls = %v; want one call with [a b none]", calls)
+	}
+	if v, ok := cache.Get("b"); !ok || v != 20 {
+		t.Errorf("Get(b) = %d, %v; want 20, true", v, ok)
+	}
+	if _, ok := cache.Get("none"); ok {
+		t.Error("key the loader did not return was cached")
+	}
+
+	_, err = cache.FetchMulti([]string{"c"}, func([]string) (map[string]int, error) {
+		return nil, errors.New("db down")
+	})
+	if err == nil {
+		t.Error("FetchMulti should return the loader's error")
+	}
+}
+
 func TestCache_Fetch_ThunderingHerd(t *testing.T) {
 	cache := New[string, int]()
 
@@ -651,8 +1108,50 @@ func TestCache_CapacityEfficiency_StringKeys(t *testing.T) {
 	}
 }
 
-// TestCache_Fetch_CacheHitDuringSingleflight is in memory_race_test.go
-// It tests concurrent cache access which triggers seqlock races.
+func TestCache_Fetch_CacheHitDuringSingleflight(t *testing.T) {
+	cache := New[string, int](Size(1000))
+
+	var wg sync.WaitGroup
+	loaderCalls := atomic.Int32{}
+
+	// Start first loader that's slow
+	wg.Go(func() {
+		if _, err := cache.Fetch("key1", func() (int, error) {
+			loaderCalls.Add(1)
+			// While loader is running, another goroutine populates cache
+			time.Sleep(100 * time.Millisecond)
+			return 42, nil
+		}); err != nil {
+			t.Errorf("Fetch error: %v", err)
+		}
+	})
+
+	// Let first goroutine start and enter singleflight
+	time.Sleep(10 * time.Millisecond)
+
+	// While first is waiting, directly set the value in cache
+	cache.Set("key1", 99)
+
+	// Start second loader that should wait for first
+	wg.Go(func() {
+		val, err := cache.Fetch("key1", func() (int, error) {
+			loaderCalls.Add(1)
+			return 77, nil
+		})
+		if err != nil {
+			t.Errorf("Fetch error: %v", err)
+			return
+		}
+		// Second should get either 99 (from cache) or 42 (from first loader)
+		if val != 99 && val != 42 {
+			t.Errorf("unexpected value: %d", val)
+		}
+	})
+
+	wg.Wait()
+
+	t.Logf("loader calls: %d", loaderCalls.Load())
+}
 
 func TestCache_Fetch_RaceCondition(t *testing.T) {
 	// Test the path where cache is populated between first check and singleflight
@@ -798,6 +1297,29 @@ func TestCache_Fetch_DoubleCheckPath(t *testing.T) {
 	}
 }
 
+func TestCache_HotKeys(t *testing.T) {
+	cache := New[string, int]()
+	for i, k := range []string{"cold", "warm", "hot"} {
+		cache.Set(k, i)
+		for range i * 3 {
+			cache.Get(k)
+		}
+	}
+	if got := cache.HotKeys(2); !slices.Equal(got, []string{"hot", "warm"}) {
+		t.Errorf("HotKeys(2) = %v; want [hot warm]", got)
+	}
+	if got := cache.HotKeys(10); len(got) != 3 || got[2] != "cold" {
+		t.Errorf("HotKeys(10) = %v; want all 3 keys, cold last", got)
+	}
+	if got := cache.HotKeys(0); got != nil {
+		t.Errorf("HotKeys(0) = %v; want nil", got)
+	}
+	cache.Delete("hot")
+	if got := cache.HotKeys(1); !slices.Equal(got, []string{"warm"}) {
+		t.Errorf("HotKeys(1) after Delete = %v; want [warm]", got)
+	}
+}
+
 func TestCache_Range_Empty(t *testing.T) {
 	cache := New[string, int]()
 
@@ -1092,3 +1614,69 @@ func BenchmarkCache_Range_KeysOnly(b *testing.B) {
 		}
 	}
 }
+
+func TestCache_BumpEpoch(t *testing.T) {
+	cache := New[string, int]()
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+
+	cache.BumpEpoch()
+
+	if _, ok := cache.Get("a"); ok {
+		t.Error("entries written before BumpEpoch should read as misses")
+	}
+	for k := range cache.Range() {
+		t.Errorf("Range yielded stale key %q", k)
+	}
+
+	// Rewriting a stale key makes it visible again.
+	cache.Set("a", 10)
+	if v, ok := cache.Get("a"); !ok || v != 10 {
+		t.Errorf("Get(a) = %d, %v; want 10, true", v, ok)
+	}
+	if _, ok := cache.Get("b"); ok {
+		t.Error("b should still be stale")
+	}
+}
+
+func TestCache_NewSmall(t *testing.T) {
+	c := NewSmall[int, int]()
+	cfg := c.Config()
+	if cfg.Size != 256 || !cfg.ExactGhosts {
+		t.Errorf("Size, ExactGhosts = %d, %v; want 256, true", cfg.Size, cfg.ExactGhosts)
+	}
+	if cfg.GhostFreqs > cfg.GhostCapacity || c.memory.ghostExact.counts != nil {
+		t.Errorf("GhostFreqs = %d, ghost index = %v; want at most %d and no index",
+			cfg.GhostFreqs, c.memory.ghostExact.counts != nil, cfg.GhostCapacity)
+	}
+
+	for i := range 1000 {
+		c.Set(i, i)
+	}
+	if n := c.Len(); n > 256 {
+		t.Errorf("Len = %d; want at most 256", n)
+	}
+	// Frequently read keys survive a scan twice the cache's size.
+	for range 3 {
+		for i := range 20 {
+			c.Set(i, i)
+			c.Get(i)
+		}
+	}
+	for i := 2000; i < 2512; i++ {
+		c.Set(i, i)
+	}
+	hits := 0
+	for i := range 20 {
+		if _, ok := c.Get(i); ok {
+			hits++
+		}
+	}
+	if hits < 15 {
+		t.Errorf("%d of 20 hot keys survived a scan; want at least 15", hits)
+	}
+
+	if got := NewSmall[int, int](Size(64), GhostFrequencies(8)).Config(); got.Size != 64 || got.GhostFreqs != 8 {
+		t.Errorf("Size, GhostFreqs = %d, %d; want options to override defaults 64, 8", got.Size, got.GhostFreqs)
+	}
+}
@@ -0,0 +1,110 @@
+package fido
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// missFilterFPRate is the false positive rate of MissFilter at its expected size.
+// A false positive costs one store read, the same as having no filter.
+const missFilterFPRate = 0.01
+
+// missFilterStore answers Get without a store round trip for keys that were
+// never written. Keys are added on every Set and by a one-time scan of the
+// store; until the scan finishes, every Get reaches the store.
+//
+//nolint:govet // fieldalignment: semantic grouping preferred
+type missFilterStore[K comparable, V any] struct {
+	Store[K, V]
+	filter *atomicBloom
+	hasher func(K) uint64
+	flush  sync.RWMutex // Set holds it shared so Flush cannot clear a key mid-write
+	ready  atomic.Bool
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// newMissFilterStore wraps store, scanning its keys in the background with
+// scanner. The caller has checked that K is string.
+func newMissFilterStore[K comparable, V any](store Store[K, V], scanner PrefixScanner[V], n int, hasher func(K) uint64) *missFilterStore[K, V] {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &missFilterStore[K, V]{
+		Store:  store,
+		filter: newAtomicBloom(n, missFilterFPRate),
+		hasher: hasher,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go s.seed(ctx, scanner)
+	return s
+}
+
+func (s *missFilterStore[K, V]) seed(ctx context.Context, scanner PrefixScanner[V]) {
+	defer close(s.done)
+	start := time.Now()
+	n := 0
+	for k := range scanner.Keys(ctx, "") {
+		key, ok := any(k).(K)
+		if !ok {
+			return
+		}
+		s.filter.Add(s.hasher(key))
+		n++
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	s.ready.Store(true)
+	slog.Debug("miss filter seeded", "keys", n, "duration", time.Since(start))
+}
+
+//nolint:revive // function-result-limit: implements Store
+func (s *missFilterStore[K, V]) Get(ctx context.Context, key K) (V, time.Time, bool, error) {
+	if s.ready.Load() && !s.filter.Contains(s.hasher(key)) {
+		var zero V
+		return zero, time.Time{}, false, nil
+	}
+	return s.Store.Get(ctx, key)
+}
+
+func (s *missFilterStore[K, V]) Set(ctx context.Context, key K, value V, expiry time.Time) error {
+	s.flush.RLock()
+	defer s.flush.RUnlock()
+	// Add first: a Get racing the write must not be answered from the filter.
+	s.filter.Add(s.hasher(key))
+	return s.Store.Set(ctx, key, value, expiry)
+}
+
+func (s *missFilterStore[K, V]) SetBatch(ctx context.Context, keys []K, values []V, expiries []time.Time) error {
+	s.flush.RLock()
+	defer s.flush.RUnlock()
+	for _, k := range keys {
+		s.filter.Add(s.hasher(k))
+	}
+	return setBatch(ctx, s.Store, keys, values, expiries)
+}
+
+func (s *missFilterStore[K, V]) DeleteBatch(ctx context.Context, keys []K) error {
+	return deleteBatch(ctx, s.Store, keys)
+}
+
+// Flush empties the store and the filter.
+func (s *missFilterStore[K, V]) Flush(ctx context.Context) (int, error) {
+	s.flush.Lock()
+	defer s.flush.Unlock()
+	n, err := s.Store.Flush(ctx)
+	if err == nil {
+		s.filter.Reset()
+	}
+	return n, err
+}
+
+// Close stops a seeding scan still in progress, then closes the store.
+func (s *missFilterStore[K, V]) Close() error {
+	s.cancel()
+	<-s.done
+	return s.Store.Close()
+}
@@ -0,0 +1,112 @@
+package fido
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingScanStore counts Gets that reach the store.
+type countingScanStore struct {
+	scanMockStore[int]
+	gets atomic.Int64
+}
+
+func (s *countingScanStore) Get(ctx context.Context, key string) (int, time.Time, bool, error) {
+	s.gets.Add(1)
+	return s.scanMockStore.Get(ctx, key)
+}
+
+// waitSeeded waits for a MissFilter's background scan to finish.
+func waitSeeded(t *testing.T, cache *TieredCache[string, int]) {
+	t.Helper()
+	mf, ok := cache.Store.(*missFilterStore[string, int])
+	if !ok {
+		t.Fatalf("Store is %T; want *missFilterStore", cache.Store)
+	}
+	<-mf.done
+	if !mf.ready.Load() {
+		t.Fatal("miss filter did not become ready")
+	}
+}
+
+func TestTieredCache_MissFilter(t *testing.T) {
+	ctx := context.Background()
+	store := &countingScanStore{scanMockStore: scanMockStore[int]{newMockStore[string, int]()}}
+	_ = store.Set(ctx, "old", 1, time.Time{}) //nolint:errcheck // Test fixture
+
+	cache, err := NewTiered[string, int](store, MissFilter(1000))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+	waitSeeded(t, cache)
+	if got := cache.Config().MissFilterKeys; got != 1000 {
+		t.Errorf("Config().MissFilterKeys = %d; want 1000", got)
+	}
+	if got := cache.Config().Store; got != "*fido.countingScanStore" {
+		t.Errorf("Config().Store = %q; want the wrapped store", got)
+	}
+
+	// Keys the scan found still reach the store.
+	if v, ok, err := cache.Get(ctx, "old"); err != nil || !ok || v != 1 {
+		t.Errorf("Get(old) = %d, %v, %v; want 1, true, nil", v, ok, err)
+	}
+	if n := store.gets.Load(); n != 1 {
+		t.Errorf("store Gets = %d; want 1", n)
+	}
+
+	// Keys never written are answered without a store read.
+	store.gets.Store(0)
+	for _, k := range []string{"a", "b", "c", "d"} {
+		if _, ok, err := cache.Get(ctx, k); err != nil || ok {
+			t.Errorf("Get(%s) = %v, %v; want miss", k, ok, err)
+		}
+	}
+	if n := store.gets.Load(); n != 0 {
+		t.Errorf("store Gets for never-written keys = %d; want 0", n)
+	}
+
+	// Keys written through the cache are found once memory forgets them.
+	if err := cache.Set(ctx, "new", 2); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	cache.memory.del("new")
+	if v, ok, err := cache.Get(ctx, "new"); err != nil || !ok || v != 2 {
+		t.Errorf("Get(new) = %d, %v, %v; want 2, true, nil", v, ok, err)
+	}
+
+	// Flush clears the filter along with the store.
+	if _, err := cache.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	store.gets.Store(0)
+	if _, ok, _ := cache.Get(ctx, "new"); ok { //nolint:errcheck // mock
+		t.Error("Get(new) should miss after Flush")
+	}
+	if n := store.gets.Load(); n != 0 {
+		t.Errorf("store Gets after Flush = %d; want 0", n)
+	}
+}
+
+func TestTieredCache_MissFilter_Config(t *testing.T) {
+	_, err := NewTiered[int, int](newMockStore[int, int](), MissFilter(10))
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("NewTiered with int keys = %v; want ErrInvalidConfig", err)
+	}
+
+	// Fault injection wraps the filter; optional interfaces still reach the store.
+	store := scanMockStore[int]{newMockStore[string, int]()}
+	cache, err := NewTiered[string, int](store, MissFilter(10), InjectFaults(NewFaultInjector(Faults{}, 1)))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	if _, ok := baseStore(cache.Store).(PrefixScanner[int]); !ok {
+		t.Errorf("baseStore(%T) does not reach the PrefixScanner", cache.Store)
+	}
+	if err := cache.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
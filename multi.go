@@ -0,0 +1,135 @@
+package fido
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// MultiError reports the keys a multi-key operation failed for, such as a
+// key the store rejects or a lookup that timed out. The operation's other
+// keys succeeded. errors.Is and errors.As match any of the key errors.
+type MultiError[K comparable] struct {
+	Errs map[K]error // failed keys and why
+}
+
+func (e *MultiError[K]) Error() string {
+	// Name the failures in a stable order.
+	msgs := make([]string, 0, len(e.Errs))
+	for k, err := range e.Errs {
+		msgs = append(msgs, fmt.Sprintf("%v: %v", k, err))
+	}
+	slices.Sort(msgs)
+	if len(msgs) > 3 {
+		msgs = append(msgs[:3], "...")
+	}
+	return fmt.Sprintf("%d keys failed: %s", len(e.Errs), strings.Join(msgs, "; "))
+}
+
+func (e *MultiError[K]) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errs))
+	for _, err := range e.Errs {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// multiErr returns errs as a *MultiError, or nil if it is empty.
+func multiErr[K comparable](errs map[K]error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &MultiError[K]{Errs: errs}
+}
+
+// GetMulti returns the values for keys, checking memory, then persistence,
+// as Get does for each. Keys that are not found are absent from the result.
+// Keys whose lookup fails are also absent and are reported in a *MultiError,
+// without affecting the rest of the batch.
+func (c *TieredCache[K, V]) GetMulti(ctx context.Context, keys []K) (map[K]V, error) {
+	out := make(map[K]V, len(keys))
+	var errs map[K]error
+	for _, k := range keys {
+		v, found, err := c.Get(ctx, k)
+		switch {
+		case err != nil:
+			if errs == nil {
+				errs = make(map[K]error)
+			}
+			errs[k] = err
+		case found:
+			out[k] = v
+		}
+	}
+	return out, multiErr(errs)
+}
+
+// SetMulti stores entries with the default TTL, as Set does for each. Keys
+// that cannot be stored, such as those the store rejects, are reported in a
+// *MultiError; the other entries are stored regardless.
+func (c *TieredCache[K, V]) SetMulti(ctx context.Context, entries map[K]V) error {
+	var errs map[K]error
+	for k, v := range entries {
+		if err := c.Set(ctx, k, v); err != nil {
+			if errs == nil {
+				errs = make(map[K]error)
+			}
+			errs[k] = err
+		}
+	}
+	return multiErr(errs)
+}
+
+// FetchMulti returns the values for keys, calling loader once with the keys
+// found in neither memory nor persistence, so a batch of misses costs one
+// query or RPC rather than one per key. Loaded values are stored like Fetch's,
+// with the default TTL. Keys the loader does not return are absent from the
+// result and are not cached; values for keys it was not asked for are ignored.
+// Unlike Fetch, concurrent calls are not deduplicated: each calls loader for
+// the keys it misses.
+//
+// Keys whose lookup fails are reported in a *MultiError and not passed to
+// loader. If loader fails, every key it was passed is reported with its error.
+// The result holds the keys that succeeded either way.
+func (c *TieredCache[K, V]) FetchMulti(
+	ctx context.Context, keys []K, loader func(ctx context.Context, missing []K) (map[K]V, error),
+) (map[K]V, error) {
+	out := make(map[K]V, len(keys))
+	errs := make(map[K]error)
+	seen := make(map[K]struct{}, len(keys))
+	var missing []K
+	for _, k := range keys {
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		v, found, err := c.Get(ctx, k)
+		switch {
+		case err != nil:
+			errs[k] = err
+		case found:
+			out[k] = v
+		default:
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) == 0 {
+		return out, multiErr(errs)
+	}
+
+	loaded, err := loader(ctx, missing)
+	if err != nil {
+		for _, k := range missing {
+			errs[k] = err
+		}
+		return out, multiErr(errs)
+	}
+	for _, k := range missing {
+		if v, ok := loaded[k]; ok {
+			c.setLoaded(ctx, k, v, 0)
+			out[k] = c.clone.copy(v, true)
+		}
+	}
+	return out, multiErr(errs)
+}
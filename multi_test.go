@@ -0,0 +1,148 @@
+package fido
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+var errDown = errors.New("db down")
+
+// getFailStore fails Get for one key.
+type getFailStore struct {
+	*mockStore[string, int]
+	key string
+}
+
+//nolint:revive // function-result-limit: implements Store
+func (s *getFailStore) Get(ctx context.Context, key string) (int, time.Time, bool, error) {
+	if key == s.key {
+		return 0, time.Time{}, false, errors.New("timeout")
+	}
+	return s.mockStore.Get(ctx, key)
+}
+
+func TestMultiError(t *testing.T) {
+	err := multiErr(map[string]error{"b": errDown, "a": ErrValueTooLarge, "c": errDown, "d": errDown})
+	if got := err.Error(); !strings.HasPrefix(got, "4 keys failed: a: value too large; b: db down; c: db down; ...") {
+		t.Errorf("Error() = %q", got)
+	}
+	if !errors.Is(err, ErrValueTooLarge) {
+		t.Error("errors.Is should match a key's error")
+	}
+	if multiErr[string](nil) != nil {
+		t.Error("multiErr of no errors should be nil")
+	}
+}
+
+func TestTieredCache_GetMulti(t *testing.T) {
+	ctx := context.Background()
+	store := &getFailStore{mockStore: newMockStore[string, int](), key: "bad"}
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+	if err := cache.Set(ctx, "mem", 1); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	_ = store.mockStore.Set(ctx, "disk", 2, time.Time{}) //nolint:errcheck // Test setup
+
+	got, err := cache.GetMulti(ctx, []string{"mem", "disk", "bad", "none"})
+	var me *MultiError[string]
+	if !errors.As(err, &me) || len(me.Errs) != 1 || me.Errs["bad"] == nil {
+		t.Errorf("GetMulti error = %v; want a MultiError for bad only", err)
+	}
+	if want := map[string]int{"mem": 1, "disk": 2}; !maps.Equal(got, want) {
+		t.Errorf("GetMulti = %v; want %v", got, want)
+	}
+	if _, err := cache.GetMulti(ctx, []string{"mem"}); err != nil {
+		t.Errorf("GetMulti of good keys = %v; want nil", err)
+	}
+}
+
+func TestTieredCache_SetMulti(t *testing.T) {
+	ctx := context.Background()
+	store := &failKeyStore{mockStore: newMockStore[string, int](), key: "full"}
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	err = cache.SetMulti(ctx, map[string]int{"a": 1, "full": 2, "b": 3})
+	var me *MultiError[string]
+	if !errors.As(err, &me) || len(me.Errs) != 1 || me.Errs["full"] == nil {
+		t.Errorf("SetMulti error = %v; want a MultiError for full only", err)
+	}
+	for k, want := range map[string]int{"a": 1, "b": 3} {
+		if v, _, found, _ := store.Get(ctx, k); !found || v != want { //nolint:errcheck // mock
+			t.Errorf("persisted %s = %d, %v; want %d, true", k, v, found, want)
+		}
+	}
+	if err := cache.SetMulti(ctx, map[string]int{"c": 4}); err != nil {
+		t.Errorf("SetMulti of good keys = %v; want nil", err)
+	}
+}
+
+func TestTieredCache_FetchMulti(t *testing.T) {
+	store := &getFailStore{mockStore: newMockStore[string, int](), key: "bad"}
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered failed: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	ctx := context.Background()
+	if err := cache.Set(ctx, "mem", 1); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	_ = store.Set(ctx, "disk", 2, time.Time{}) //nolint:errcheck // Test setup
+
+	var calls [][]string
+	loader := func(_ context.Context, missing []string) (map[string]int, error) {
+		calls = append(calls, missing)
+		return map[string]int{"a": 10, "b": 20, "extra": 99}, nil
+	}
+	got, err := cache.FetchMulti(ctx, []string{"mem", "a", "disk", "b", "a", "none", "bad"}, loader)
+	var me *MultiError[string]
+	if !errors.As(err, &me) || len(me.Errs) != 1 || me.Errs["bad"] == nil {
+		t.Errorf("FetchMulti error = %v; want a MultiError for bad only", err)
+	}
+	if want := map[string]int{"mem": 1, "disk": 2, "a": 10, "b": 20}; !maps.Equal(got, want) {
+		t.Errorf("FetchMulti = %v; want %v", got, want)
+	}
+	if len(calls) != 1 || !slices.Equal(calls[0], []string{"a", "b", "none"}) {
+		t.Errorf("loader calls = %v; want one call with [a b none]", calls)
+	}
+	if pVal, _, found, _ := store.Get(ctx, "b"); !found || pVal != 20 { //nolint:errcheck // Test helper
+		t.Errorf("persisted b = %d, %v; want 20, true", pVal, found)
+	}
+	if _, _, found, _ := store.Get(ctx, "extra"); found { //nolint:errcheck // Test helper
+		t.Error("value for a key not requested was cached")
+	}
+
+	// All hits: the loader is not called.
+	if _, err := cache.FetchMulti(ctx, []string{"a", "b"}, loader); err != nil || len(calls) != 1 {
+		t.Errorf("FetchMulti of hits = %v with %d loader calls; want nil, 1", err, len(calls))
+	}
+
+	// A failed loader caches nothing; its keys are reported, hits are returned.
+	got, err = cache.FetchMulti(ctx, []string{"a", "c", "d"}, func(context.Context, []string) (map[string]int, error) {
+		return map[string]int{"c": 3}, errDown
+	})
+	if !errors.As(err, &me) || len(me.Errs) != 2 || me.Errs["c"] != errDown || me.Errs["d"] != errDown {
+		t.Errorf("FetchMulti error = %v; want MultiError for c and d", err)
+	}
+	if !maps.Equal(got, map[string]int{"a": 10}) {
+		t.Errorf("FetchMulti with failed loader = %v; want the hit for a", got)
+	}
+	if _, found, _ := cache.Get(ctx, "c"); found { //nolint:errcheck // Test helper
+		t.Error("failed loader's value was cached")
+	}
+
+}
@@ -0,0 +1,125 @@
+package fido
+
+import (
+	"context"
+	"path"
+	"reflect"
+	"time"
+)
+
+// noPersistStore keeps the keys NoPersistPattern and NoPersistFunc exclude
+// out of a store: their writes, reads, and deletes return without reaching
+// it, so those entries live only in memory.
+type noPersistStore[K comparable, V any] struct {
+	Store[K, V]
+	skip func(K) bool
+}
+
+// excludeKeys wraps store for NoPersistPattern and NoPersistFunc, or returns
+// it unchanged if neither is set. The caller has checked the options.
+func excludeKeys[K comparable, V any](store Store[K, V], cfg *config) Store[K, V] {
+	skip := newNoPersist[K](cfg)
+	if skip == nil {
+		return store
+	}
+	return &noPersistStore[K, V]{Store: store, skip: skip}
+}
+
+// newNoPersist returns a func reporting whether a key is excluded from
+// persistence, or nil if no key is.
+func newNoPersist[K comparable](cfg *config) func(K) bool {
+	fn, _ := cfg.noPersistFunc.(func(K) bool) //nolint:errcheck // nil unless set
+	patterns := cfg.noPersistPatterns
+	if fn == nil && len(patterns) == 0 {
+		return nil
+	}
+	return func(key K) bool {
+		if fn != nil && fn(key) {
+			return true
+		}
+		if len(patterns) == 0 {
+			return false
+		}
+		s := reflect.ValueOf(&key).Elem().String()
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, s); ok { //nolint:errcheck // patterns checked by validateConfig
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// excluded reports whether store, as wrapped by NewTiered, keeps key out of
+// persistence.
+func excluded[K comparable, V any](store Store[K, V], key K) bool {
+	for {
+		switch w := store.(type) {
+		case *noPersistStore[K, V]:
+			return w.skip(key)
+		case *faultyStore[K, V]:
+			store = w.Store
+		default:
+			return false
+		}
+	}
+}
+
+// ValidateKey accepts excluded keys without asking the store, as they are
+// never sent to it.
+func (s *noPersistStore[K, V]) ValidateKey(key K) error {
+	if s.skip(key) {
+		return nil
+	}
+	return s.Store.ValidateKey(key)
+}
+
+//nolint:revive // function-result-limit: implements Store
+func (s *noPersistStore[K, V]) Get(ctx context.Context, key K) (V, time.Time, bool, error) {
+	if s.skip(key) {
+		var zero V
+		return zero, time.Time{}, false, nil
+	}
+	return s.Store.Get(ctx, key)
+}
+
+func (s *noPersistStore[K, V]) Set(ctx context.Context, key K, value V, expiry time.Time) error {
+	if s.skip(key) {
+		return nil
+	}
+	return s.Store.Set(ctx, key, value, expiry)
+}
+
+func (s *noPersistStore[K, V]) Delete(ctx context.Context, key K) error {
+	if s.skip(key) {
+		return nil
+	}
+	return s.Store.Delete(ctx, key)
+}
+
+func (s *noPersistStore[K, V]) SetBatch(ctx context.Context, keys []K, values []V, expiries []time.Time) error {
+	var kept []int
+	for i, k := range keys {
+		if !s.skip(k) {
+			kept = append(kept, i)
+		}
+	}
+	if len(kept) == len(keys) {
+		return setBatch(ctx, s.Store, keys, values, expiries)
+	}
+	ks, vs, es := make([]K, len(kept)), make([]V, len(kept)), make([]time.Time, len(kept))
+	for j, i := range kept {
+		ks[j], vs[j], es[j] = keys[i], values[i], expiries[i]
+	}
+	return setBatch(ctx, s.Store, ks, vs, es)
+}
+
+func (s *noPersistStore[K, V]) DeleteBatch(ctx context.Context, keys []K) error {
+	var kept []K
+	for _, k := range keys {
+		if !s.skip(k) {
+			kept = append(kept, k)
+		}
+	}
+	return deleteBatch(ctx, s.Store, kept)
+}
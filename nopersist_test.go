@@ -0,0 +1,77 @@
+package fido
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTieredCache_NoPersistPattern(t *testing.T) {
+	store := newShortKeyStore(64)
+	cache, err := NewTiered[string, int](store, NoPersistPattern("nonce:*", "req:*:scratch"))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+	if !cache.Config().NoPersist {
+		t.Error("Config().NoPersist = false")
+	}
+
+	ctx := context.Background()
+	long := "nonce:" + strings.Repeat("n", 100)
+	for _, k := range []string{"nonce:abc", "req:42:scratch", long} {
+		if err := cache.Set(ctx, k, 1); err != nil {
+			t.Fatalf("Set(%s): %v", k, err)
+		}
+		if v, found, err := cache.Get(ctx, k); err != nil || !found || v != 1 {
+			t.Errorf("Get(%s) = %d, %v, %v; want 1 from memory", k, v, found, err)
+		}
+		if loc := cache.Locations(k); loc.Store != "" {
+			t.Errorf("Locations(%s).Store = %q; want none", k, loc.Store)
+		}
+	}
+	if err := cache.SetAsync(ctx, "nonce:async", 2); err != nil {
+		t.Fatalf("SetAsync: %v", err)
+	}
+	if err := cache.Set(ctx, "user:1", 3); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := cache.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	if n, _ := store.Len(ctx); n != 1 { //nolint:errcheck // store never fails
+		t.Errorf("store holds %d entries; want only user:1", n)
+	}
+	if _, _, found, _ := store.Get(ctx, "user:1"); !found { //nolint:errcheck // only checking presence
+		t.Error("keys outside the patterns should be persisted")
+	}
+}
+
+func TestTieredCache_NoPersistFunc(t *testing.T) {
+	store := newMockStore[string, int]()
+	victim := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store,
+		NoPersistFunc(func(k string) bool { return strings.HasPrefix(k, "tmp/") }),
+		Victim[string, int](victim, time.Minute))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	ctx := context.Background()
+	if err := store.Set(ctx, "tmp/stale", 1, time.Time{}); err != nil {
+		t.Fatalf("store.Set: %v", err)
+	}
+	if _, found, _ := cache.Get(ctx, "tmp/stale"); found { //nolint:errcheck // only checking presence
+		t.Error("Get read an excluded key from the store")
+	}
+
+	cache.victim.spill("tmp/evicted", 2, 0)
+	cache.victim.spill("kept", 3, 0)
+	waitFor(t, func() bool { _, ok := cache.victim.spilled.Load("kept"); return ok })
+	if _, ok := cache.victim.spilled.Load("tmp/evicted"); ok {
+		t.Error("an excluded key was spilled to the victim store")
+	}
+}
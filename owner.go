@@ -0,0 +1,218 @@
+package fido
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+)
+
+// ErrOwnersUnsupported is returned by FlushOwner, CleanupOwner, and OwnerLen
+// when the store does not implement OwnerTagger.
+var ErrOwnersUnsupported = errors.New("store does not support owner tags")
+
+type ownerKey struct{}
+
+// WithOwner returns a context that tags entries a TieredCache persists with
+// it as belonging to owner, such as the team or service writing them, and
+// counts its lookups toward OwnerStats. Platform teams running one shared
+// store for many consumers use the tags to flush, clean up, and measure each
+// consumer's entries separately. Tags are kept by stores that implement
+// OwnerTagger; memory and victim copies are untagged.
+func WithOwner(ctx context.Context, owner string) context.Context {
+	return context.WithValue(ctx, ownerKey{}, owner)
+}
+
+// ownerOf returns the owner set by WithOwner, or "" for none.
+func ownerOf(ctx context.Context) string {
+	owner, _ := ctx.Value(ownerKey{}).(string) //nolint:errcheck // "" when unset
+	return owner
+}
+
+// ownerStore writes entries through the store's SetOwned when the context
+// names an owner. It sits directly above the base store, so limits, hashing,
+// and fault injection still apply to tagged writes.
+type ownerStore[K comparable, V any] struct {
+	Store[K, V]
+	tagger OwnerTagger[K, V]
+}
+
+// tagOwners wraps store if it implements OwnerTagger, else returns it unchanged.
+func tagOwners[K comparable, V any](store Store[K, V]) Store[K, V] {
+	t, ok := store.(OwnerTagger[K, V])
+	if !ok {
+		return store
+	}
+	return &ownerStore[K, V]{Store: store, tagger: t}
+}
+
+func (s *ownerStore[K, V]) Set(ctx context.Context, key K, value V, expiry time.Time) error {
+	if owner := ownerOf(ctx); owner != "" {
+		return s.tagger.SetOwned(ctx, key, value, expiry, owner)
+	}
+	return s.Store.Set(ctx, key, value, expiry)
+}
+
+// SetBatch tags each entry with the context's owner one SetOwned at a time,
+// as Batcher has no owner; without an owner it batches.
+func (s *ownerStore[K, V]) SetBatch(ctx context.Context, keys []K, values []V, expiries []time.Time) error {
+	owner := ownerOf(ctx)
+	if owner == "" {
+		return setBatch(ctx, s.Store, keys, values, expiries)
+	}
+	for i, k := range keys {
+		if err := s.tagger.SetOwned(ctx, k, values[i], expiries[i], owner); err != nil {
+			return fmt.Errorf("key %v: %w", k, err)
+		}
+	}
+	return nil
+}
+
+func (s *ownerStore[K, V]) DeleteBatch(ctx context.Context, keys []K) error {
+	return deleteBatch(ctx, s.Store, keys)
+}
+
+// ownerStats keeps hit stats per owner, created on an owner's first lookup.
+// A nil *ownerStats records nothing.
+type ownerStats struct {
+	byOwner *xsync.Map[string, *hitStats]
+}
+
+func newOwnerStats(cfg *config) *ownerStats {
+	if !cfg.trackStats {
+		return nil
+	}
+	return &ownerStats{byOwner: xsync.NewMap[string, *hitStats]()}
+}
+
+func (o *ownerStats) record(ctx context.Context, hit bool) {
+	if o == nil {
+		return
+	}
+	owner := ownerOf(ctx)
+	if owner == "" {
+		return
+	}
+	s, _ := o.byOwner.LoadOrCompute(owner, func() (*hitStats, bool) {
+		return &hitStats{now: time.Now}, false
+	})
+	s.record(hit)
+}
+
+func (o *ownerStats) stats(owner string) Stats {
+	if o == nil {
+		return Stats{}
+	}
+	s, _ := o.byOwner.Load(owner)
+	return s.stats()
+}
+
+// record counts a lookup in the cache's stats and, for a ctx from WithOwner,
+// in its owner's.
+func (c *TieredCache[K, V]) record(ctx context.Context, hit bool) {
+	c.stats.record(hit)
+	c.ownerStats.record(ctx, hit)
+}
+
+// OwnerStats returns hit and miss counts and rates for lookups made with a
+// context from WithOwner(ctx, owner). Requires TrackStats.
+func (c *TieredCache[K, V]) OwnerStats(owner string) Stats {
+	return c.ownerStats.stats(owner)
+}
+
+// ownerKeys returns the stored keys tagged with owner. Keys are collected
+// before any is deleted, as stores may not support deleting while iterating.
+func (c *TieredCache[K, V]) ownerKeys(ctx context.Context, owner string) ([]K, error) {
+	t, ok := baseStore(c.Store).(OwnerTagger[K, V])
+	if !ok {
+		return nil, ErrOwnersUnsupported
+	}
+	var keys []K
+	for sk := range t.OwnerKeys(ctx, owner) {
+		k, ok := any(sk).(K)
+		if !ok {
+			break // OwnerKeys is only meaningful for string keys
+		}
+		keys = append(keys, k)
+	}
+	return keys, ctx.Err()
+}
+
+// FlushOwner removes every entry tagged with owner from persistence, and
+// those keys from memory, leaving other owners' entries intact. Returns the
+// number of keys removed. It requires a store that implements OwnerTagger.
+func (c *TieredCache[K, V]) FlushOwner(ctx context.Context, owner string) (int, error) {
+	start := time.Now()
+	n, err := c.flushOwner(ctx, owner)
+	c.audit.record(ctx, AdminOp{Op: "FlushOwner", Time: start, Removed: n, Target: owner, Err: err})
+	return n, err
+}
+
+func (c *TieredCache[K, V]) flushOwner(ctx context.Context, owner string) (int, error) {
+	keys, err := c.ownerKeys(ctx, owner)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	var errs []error
+	for _, k := range keys {
+		forgetFlight(c.flights, k)
+		c.memory.del(k)
+		c.forget(ctx, k)
+		c.thaw(k)
+		if err := c.Store.Delete(ctx, k); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		c.deps.forget(k)
+		n++
+	}
+	if len(errs) > 0 {
+		return n, fmt.Errorf("persistence delete: %w", errors.Join(errs...))
+	}
+	return n, nil
+}
+
+// CleanupOwner removes owner's expired entries from persistence, like
+// Store.Cleanup limited to one owner. Returns the number removed. It requires
+// a store that implements OwnerTagger.
+func (c *TieredCache[K, V]) CleanupOwner(ctx context.Context, owner string) (int, error) {
+	start := time.Now()
+	n, err := c.cleanupOwner(ctx, owner)
+	c.audit.record(ctx, AdminOp{Op: "CleanupOwner", Time: start, Removed: n, Target: owner, Err: err})
+	return n, err
+}
+
+func (c *TieredCache[K, V]) cleanupOwner(ctx context.Context, owner string) (int, error) {
+	keys, err := c.ownerKeys(ctx, owner)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	var errs []error
+	for _, k := range keys {
+		_, _, found, err := c.Store.Get(ctx, k)
+		if err != nil || found {
+			continue // live, or undecidable: leave it
+		}
+		if err := c.Store.Delete(ctx, k); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		n++
+	}
+	if len(errs) > 0 {
+		return n, fmt.Errorf("persistence delete: %w", errors.Join(errs...))
+	}
+	return n, nil
+}
+
+// OwnerLen returns the number of persisted entries tagged with owner. Like
+// Store.Len it may include expired entries not yet cleaned up. It requires a
+// store that implements OwnerTagger.
+func (c *TieredCache[K, V]) OwnerLen(ctx context.Context, owner string) (int, error) {
+	keys, err := c.ownerKeys(ctx, owner)
+	return len(keys), err
+}
@@ -0,0 +1,161 @@
+package fido
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"sync"
+	"testing"
+	"time"
+)
+
+// ownedStore is a mockStore that implements OwnerTagger.
+type ownedStore struct {
+	*mockStore[string, int]
+	mu     sync.Mutex
+	owners map[string]string
+}
+
+func newOwnedStore() *ownedStore {
+	return &ownedStore{mockStore: newMockStore[string, int](), owners: make(map[string]string)}
+}
+
+func (s *ownedStore) SetOwned(ctx context.Context, key string, value int, expiry time.Time, owner string) error {
+	s.mu.Lock()
+	s.owners[key] = owner
+	s.mu.Unlock()
+	return s.mockStore.Set(ctx, key, value, expiry)
+}
+
+func (s *ownedStore) Set(ctx context.Context, key string, value int, expiry time.Time) error {
+	s.mu.Lock()
+	delete(s.owners, key)
+	s.mu.Unlock()
+	return s.mockStore.Set(ctx, key, value, expiry)
+}
+
+func (s *ownedStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	delete(s.owners, key)
+	s.mu.Unlock()
+	return s.mockStore.Delete(ctx, key)
+}
+
+func (s *ownedStore) OwnerKeys(_ context.Context, owner string) iter.Seq[string] {
+	s.mu.Lock()
+	var keys []string
+	for k, o := range s.owners {
+		if o == owner {
+			keys = append(keys, k)
+		}
+	}
+	s.mu.Unlock()
+	return func(yield func(string) bool) {
+		for _, k := range keys {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+func TestTieredCache_Owners(t *testing.T) {
+	store := newOwnedStore()
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	ctx := context.Background()
+	teamA, teamB := WithOwner(ctx, "team-a"), WithOwner(ctx, "team-b")
+	for _, k := range []string{"a1", "a2"} {
+		if err := cache.Set(teamA, k, 1); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	if err := cache.Set(teamB, "b1", 2); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := cache.Set(ctx, "untagged", 3); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := cache.SetAsync(teamB, "b2", 4); err != nil {
+		t.Fatalf("SetAsync: %v", err)
+	}
+	waitFor(t, func() bool { _, _, found, _ := store.Get(ctx, "b2"); return found }) //nolint:errcheck // polling
+
+	if n, err := cache.OwnerLen(ctx, "team-b"); err != nil || n != 2 {
+		t.Errorf("OwnerLen(team-b) = %d, %v; want 2, nil", n, err)
+	}
+
+	n, err := cache.FlushOwner(ctx, "team-a")
+	if err != nil || n != 2 {
+		t.Fatalf("FlushOwner(team-a) = %d, %v; want 2, nil", n, err)
+	}
+	for _, k := range []string{"a1", "a2"} {
+		if _, found, _ := cache.Get(ctx, k); found { //nolint:errcheck // only checking presence
+			t.Errorf("Get(%s) found after FlushOwner", k)
+		}
+	}
+	for _, k := range []string{"b1", "b2", "untagged"} {
+		if _, found, _ := cache.Get(ctx, k); !found { //nolint:errcheck // only checking presence
+			t.Errorf("Get(%s) missing after flushing another owner", k)
+		}
+	}
+}
+
+func TestTieredCache_CleanupOwner(t *testing.T) {
+	store := newOwnedStore()
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	ctx := WithOwner(context.Background(), "team-a")
+	if err := store.SetOwned(ctx, "old", 1, time.Now().Add(-time.Minute), "team-a"); err != nil {
+		t.Fatalf("SetOwned: %v", err)
+	}
+	if err := cache.Set(ctx, "live", 2); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if n, err := cache.CleanupOwner(ctx, "team-a"); err != nil || n != 1 {
+		t.Errorf("CleanupOwner = %d, %v; want 1, nil", n, err)
+	}
+	if n, _ := cache.OwnerLen(ctx, "team-a"); n != 1 { //nolint:errcheck // store never fails
+		t.Errorf("OwnerLen after cleanup = %d; want 1", n)
+	}
+}
+
+func TestTieredCache_OwnerStats(t *testing.T) {
+	cache, err := NewTiered[string, int](newMockStore[string, int](), TrackStats())
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	ctx := context.Background()
+	teamA := WithOwner(ctx, "team-a")
+	if err := cache.Set(teamA, "k", 1); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	cache.Get(teamA, "k")       //nolint:errcheck // counting lookups
+	cache.Get(teamA, "missing") //nolint:errcheck // counting lookups
+	cache.Get(ctx, "k")         //nolint:errcheck // counting lookups
+
+	if s := cache.OwnerStats("team-a"); s.Hits != 1 || s.Misses != 1 {
+		t.Errorf("OwnerStats(team-a) = %d hits, %d misses; want 1, 1", s.Hits, s.Misses)
+	}
+	if s := cache.Stats(); s.Hits != 2 || s.Misses != 1 {
+		t.Errorf("Stats = %d hits, %d misses; want 2, 1", s.Hits, s.Misses)
+	}
+	if s := cache.OwnerStats("team-b"); s.Hits+s.Misses != 0 {
+		t.Errorf("OwnerStats(team-b) = %+v; want zero", s)
+	}
+
+	// Owner operations need a store that keeps tags.
+	if _, err := cache.FlushOwner(ctx, "team-a"); !errors.Is(err, ErrOwnersUnsupported) {
+		t.Errorf("FlushOwner error = %v; want ErrOwnersUnsupported", err)
+	}
+}
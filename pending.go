@@ -0,0 +1,114 @@
+package fido
+
+import (
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+)
+
+// pendingWrite is a SetAsync value, or a DeleteAsync tombstone, that may not
+// have reached persistence yet.
+type pendingWrite[V any] struct {
+	value   V
+	expiry  time.Time
+	deleted bool            // DeleteAsync tombstone
+	after   <-chan struct{} // previous write to the key finished; nil if there was none
+	done    chan struct{}   // this write finished
+}
+
+// pendingWrites tracks in-flight async persistence writes by key.
+// Always maintained for EntryInfo.PendingPersist and PendingDelete; values are consulted on Get
+// only with ReadYourWrites, while tombstones always make Get miss.
+// Only the latest write per key is kept; older writes finishing later don't remove it.
+//
+// Writes to a key reach persistence one at a time, in the order they were
+// queued, so the store ends up with the latest. A write still waiting its turn
+// when a newer one supersedes it is skipped, as the newer one would replace it.
+type pendingWrites[K comparable, V any] struct {
+	m    *xsync.Map[K, *pendingWrite[V]]
+	tail *xsync.Map[K, *pendingWrite[V]] // latest write queued per key, until it finishes; unaffected by drop
+}
+
+func newPendingWrites[K comparable, V any]() *pendingWrites[K, V] {
+	return &pendingWrites[K, V]{
+		m:    xsync.NewMap[K, *pendingWrite[V]](),
+		tail: xsync.NewMap[K, *pendingWrite[V]](),
+	}
+}
+
+// add records a write and returns the handle to pass to wait and done.
+func (p *pendingWrites[K, V]) add(key K, value V, expiry time.Time) *pendingWrite[V] {
+	return p.queue(key, &pendingWrite[V]{value: value, expiry: expiry})
+}
+
+// addDelete records a tombstone and returns the handle to pass to wait and done.
+func (p *pendingWrites[K, V]) addDelete(key K) *pendingWrite[V] {
+	return p.queue(key, &pendingWrite[V]{deleted: true})
+}
+
+func (p *pendingWrites[K, V]) queue(key K, w *pendingWrite[V]) *pendingWrite[V] {
+	w.done = make(chan struct{})
+	p.tail.Compute(key, func(prev *pendingWrite[V], loaded bool) (*pendingWrite[V], xsync.ComputeOp) {
+		if loaded {
+			w.after = prev.done
+		}
+		p.m.Store(key, w)
+		return w, xsync.UpdateOp
+	})
+	return w
+}
+
+// wait blocks until the write before w has finished, and reports whether w
+// should still run: false if it has been superseded since, as current reports.
+func (p *pendingWrites[K, V]) wait(key K, w *pendingWrite[V]) bool {
+	if w.after == nil {
+		return true
+	}
+	<-w.after
+	return p.current(key, w)
+}
+
+// current reports whether w is still key's latest pending write: not replaced
+// by a newer async write, nor dropped for a synchronous write or a flush.
+func (p *pendingWrites[K, V]) current(key K, w *pendingWrite[V]) bool {
+	cur, ok := p.m.Load(key)
+	return ok && cur == w
+}
+
+// done marks w finished, removing it if it is still the latest write for key.
+func (p *pendingWrites[K, V]) done(key K, w *pendingWrite[V]) {
+	close(w.done)
+	latest := func(cur *pendingWrite[V], loaded bool) (*pendingWrite[V], xsync.ComputeOp) {
+		if loaded && cur == w {
+			return nil, xsync.DeleteOp
+		}
+		return cur, xsync.CancelOp
+	}
+	p.m.Compute(key, latest)
+	p.tail.Compute(key, latest)
+}
+
+// deleted reports whether the latest pending write for key is a tombstone.
+func (p *pendingWrites[K, V]) deleted(key K) bool {
+	w, ok := p.m.Load(key)
+	return ok && w.deleted
+}
+
+// get returns the latest unexpired pending value for key.
+func (p *pendingWrites[K, V]) get(key K) (V, time.Time, bool) {
+	w, ok := p.m.Load(key)
+	if !ok || w.deleted || (!w.expiry.IsZero() && time.Now().After(w.expiry)) {
+		var zero V
+		return zero, time.Time{}, false
+	}
+	return w.value, w.expiry, true
+}
+
+// drop forgets any pending write for key, e.g. after a newer synchronous write.
+func (p *pendingWrites[K, V]) drop(key K) {
+	p.m.Delete(key)
+}
+
+func (p *pendingWrites[K, V]) clear() {
+	p.m.Clear()
+}
@@ -1,11 +1,14 @@
 package fido
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"iter"
 	"log/slog"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/puzpuzpuz/xsync/v4"
@@ -15,10 +18,40 @@ const asyncTimeout = 5 * time.Second
 
 // TieredCache combines an in-memory cache with persistent storage.
 type TieredCache[K comparable, V any] struct {
-	Store      Store[K, V] // direct access to persistence layer
-	flights    *xsync.Map[K, *flightCall[V]]
-	memory     *s3fifo[K, V]
-	defaultTTL time.Duration
+	Store          Store[K, V] // direct access to persistence layer
+	flights        *xsync.Map[K, *flightCall[V]]
+	memory         *s3fifo[K, V]
+	defaultTTL     time.Duration // default for persisted entries (PersistTTL, else TTL)
+	memoryTTL      time.Duration // caps how long entries stay in memory; 0 means no cap
+	victim         *victimCache[K, V]
+	limit          *persistLimit            // PersistConcurrency; nil when unbounded
+	pending        *pendingWrites[K, V]     // in-flight SetAsync writes
+	asyncWait      time.Duration            // how long SetAsync waits for its write
+	async          sync.WaitGroup           // SetAsync persistence goroutines, drained by Shutdown
+	asyncMu        sync.RWMutex             // read-held while SetAsync and DeleteAsync start writes
+	closed         bool                     // async writes refused; guarded by asyncMu
+	queue          *asyncQueue              // AsyncQueue; counts async writes even when unbounded
+	snapshot       bool                     // Shutdown persists the memory tier
+	cleaner        *cleaner[K, V]           // AutoCleanup; nil when disabled
+	hotKeys        *hotKeySaver[K]          // PersistHotKeys; nil when disabled
+	purge          *versionPurge            // Version purge; nil when disabled
+	prefetch       *prefetch                // Prefetch in progress or done; nil when disabled
+	dead           *deadLetters[K, V]       // DeadLetter; nil when disabled
+	thresholds     *thresholdWatch          // ThresholdCallback; nil when disabled
+	repairs        atomic.Uint64            // persisted copies rewritten by read repair
+	checkValue     func(V) error            // MaxValueBytes; nil when disabled
+	valueValidator ValueValidator[V]        // Store's value checks; nil if unsupported
+	stats          *hitStats                // nil unless TrackStats
+	ownerStats     *ownerStats              // nil unless TrackStats
+	access         *accessLog[K, V]         // nil unless AccessLog
+	clone          cloner[V]                // nil unless CopyOnRead
+	valueTTL       valueTTL[V]              // nil unless TTLFromValue
+	early          *earlyExpiry             // nil unless EarlyExpiry
+	deps           depGraph[K]              // SetWithDependencies
+	immutable      *xsync.Map[K, time.Time] // SetImmutable keys and when they expire; zero for never
+	audit          auditLog                 // AuditLog
+	settings       Config
+	readYourWrites bool
 }
 
 // NewTiered creates a cache backed by the given store.
@@ -31,25 +64,203 @@ func NewTiered[K comparable, V any](store Store[K, V], opts ...Option) (*TieredC
 	if store == nil {
 		return nil, errors.New("store cannot be nil")
 	}
+	if err := validateConfig(cfg, store); err != nil {
+		return nil, err
+	}
+	if cfg.version != "" {
+		//nolint:errcheck,forcetypeassert // checked by validateConfig
+		if err := store.(Versioner).SetVersion(cfg.version); err != nil {
+			return nil, fmt.Errorf("%w: Version(%q): %w", ErrInvalidConfig, cfg.version, err)
+		}
+	}
+
+	if fn, ok := cfg.decodeFallback.(func([]byte) (V, error)); ok {
+		store.(FallbackDecoder[V]).SetDecodeFallback(fn) //nolint:errcheck,forcetypeassert // checked by validateConfig
+	}
+
+	memory := newS3FIFO[K, V](cfg)
+	limit := newPersistLimit(cfg)
+	store = limitStore(tagOwners(store), limit)
+	if cfg.missFilterKeys > 0 {
+		scanner, _ := baseStore(store).(PrefixScanner[V]) //nolint:errcheck // checked by validateConfig
+		store = newMissFilterStore(store, scanner, cfg.missFilterKeys, memory.hasher)
+	}
+	store = excludeKeys(hashKeys(store, cfg), cfg)
+	if cfg.faults != nil {
+		store = &faultyStore[K, V]{Store: store, fi: cfg.faults}
+	}
 
 	cache := &TieredCache[K, V]{
-		Store:      store,
-		flights:    xsync.NewMap[K, *flightCall[V]](),
-		memory:     newS3FIFO[K, V](cfg),
-		defaultTTL: cfg.defaultTTL,
+		Store:          store,
+		flights:        xsync.NewMap[K, *flightCall[V]](),
+		memory:         memory,
+		defaultTTL:     cmp.Or(cfg.persistTTL, cfg.defaultTTL),
+		memoryTTL:      cfg.memoryTTL,
+		pending:        newPendingWrites[K, V](),
+		immutable:      xsync.NewMap[K, time.Time](),
+		dead:           newDeadLetters[K, V](cfg),
+		asyncWait:      cfg.asyncWait,
+		queue:          newAsyncQueue(cfg),
+		checkValue:     newValueLimit[V](cfg),
+		snapshot:       cfg.snapshotOnShutdown,
+		stats:          newHitStats(cfg),
+		ownerStats:     newOwnerStats(cfg),
+		clone:          newCloner[V](cfg),
+		valueTTL:       newValueTTL[V](cfg),
+		early:          newEarlyExpiry(cfg),
+		readYourWrites: cfg.readYourWrites,
+		limit:          limit,
+		victim: newVictimCache[K, V](cfg, limit, func(k K) bool {
+			_, ok := memory.entries.Load(k)
+			return ok
+		}),
+	}
+	if cache.victim != nil {
+		memory.onEvict = cache.victim.spill
+	}
+	cache.access = newAccessLog[K, V](cfg, memory.hasher)
+	cache.access.attach(memory)
+	cache.cleaner = newCleaner(store, cfg, &cache.audit)
+	if cfg.purgeVersions {
+		cache.purge = startVersionPurge(baseStore(store).(Versioner)) //nolint:errcheck,forcetypeassert // checked by validateConfig
 	}
+	var warm func(context.Context)
+	if rec := hotKeyRecorder[K](cfg, baseStore(store)); rec != nil {
+		cache.hotKeys = newHotKeySaver(rec, cfg, memory.hottest)
+		warm = func(ctx context.Context) {
+			warmHotKeys(BackgroundLane(ctx), rec, func(ctx context.Context, k K) bool {
+				_, found, err := cache.get(ctx, k)
+				return found && err == nil
+			})
+		}
+	}
+	cache.prefetch = startPrefetching(cfg, warm, func(k K, v V) {
+		cache.memory.getOrSet(k, v, cache.memExpiry(cache.expiry(v, 0)))
+	})
+	cache.thresholds = startThresholds(cfg, cache.usageProbes()...)
+	if vv, ok := baseStore(store).(ValueValidator[V]); ok {
+		cache.valueValidator = vv
+	}
+	cache.settings = resolveTiered(cfg, resolveConfig(cfg, memory), baseStore(store), cfg.victim)
 
 	return cache, nil
 }
 
+// expiry returns when value stored with ttl expires. A zero or negative ttl
+// uses the TTL derived by TTLFromValue, else the default TTL.
+func (c *TieredCache[K, V]) expiry(value V, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		ttl = c.valueTTL.or(value, c.defaultTTL)
+	}
+	return calculateExpiry(ttl, 0)
+}
+
+// memExpiry converts a persistence expiry to a memory expiry, applying the MemoryTTL cap.
+func (c *TieredCache[K, V]) memExpiry(expiry time.Time) uint32 {
+	if c.memoryTTL <= 0 {
+		return timeToSec(expiry)
+	}
+	capped := time.Now().Add(c.memoryTTL)
+	if expiry.IsZero() || capped.Before(expiry) {
+		return timeToSec(capped)
+	}
+	return timeToSec(expiry)
+}
+
+// validateValue rejects values the cache is configured not to persist
+// or that the store reports it cannot hold.
+func (c *TieredCache[K, V]) validateValue(value V) error {
+	if c.checkValue != nil {
+		if err := c.checkValue(value); err != nil {
+			return err
+		}
+	}
+	if c.valueValidator != nil {
+		if err := c.valueValidator.ValidateValue(value); err != nil {
+			return fmt.Errorf("invalid value: %w", err)
+		}
+	}
+	return nil
+}
+
 // Get checks memory, then persistence. Found values are cached in memory.
 //
 //nolint:gocritic // unnamedResult: public API signature is intentionally clear
 func (c *TieredCache[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	if h, ok := c.access.sample(key); ok {
+		val, tier, err := c.getLogged(ctx, key, h)
+		return val, tier != TierMiss, err
+	}
+	val, found, err := c.get(ctx, key)
+	c.record(ctx, found)
+	return c.clone.copy(val, found), found, err
+}
+
+//nolint:gocritic // unnamedResult: matches Get
+func (c *TieredCache[K, V]) get(ctx context.Context, key K) (V, bool, error) {
 	if val, ok := c.memory.get(key); ok {
 		return val, true, nil
 	}
+	if c.pending.deleted(key) {
+		var zero V
+		return zero, false, nil
+	}
+	if val, ok := c.loadPending(key); ok {
+		return val, true, nil
+	}
+	if val, ok := c.loadVictim(ctx, key); ok {
+		return val, true, nil
+	}
+	return c.loadStore(ctx, key)
+}
+
+// GetWithInfo is like Get but also reports which tier served the value, so
+// memory hit rate can be measured separately from overall hit rate.
+// A miss returns TierMiss.
+func (c *TieredCache[K, V]) GetWithInfo(ctx context.Context, key K) (V, Tier, error) {
+	if h, ok := c.access.sample(key); ok {
+		return c.getLogged(ctx, key, h)
+	}
+	val, tier, err := c.getWithInfo(ctx, key)
+	c.record(ctx, tier != TierMiss)
+	return c.clone.copy(val, tier != TierMiss), tier, err
+}
+
+// getLogged is GetWithInfo for a key sampled by AccessLog.
+func (c *TieredCache[K, V]) getLogged(ctx context.Context, key K, hash uint64) (V, Tier, error) {
+	start := time.Now()
+	val, tier, err := c.getWithInfo(ctx, key)
+	c.record(ctx, tier != TierMiss)
+	c.access.emit(AccessGet, hash, val, tier, start)
+	return c.clone.copy(val, tier != TierMiss), tier, err
+}
+
+func (c *TieredCache[K, V]) getWithInfo(ctx context.Context, key K) (V, Tier, error) {
+	if val, ok, resurrected := c.memory.getResurrected(key); ok {
+		if resurrected {
+			return val, TierDeathRow, nil
+		}
+		return val, TierMemory, nil
+	}
+	if c.pending.deleted(key) {
+		var zero V
+		return zero, TierMiss, nil
+	}
+	if val, ok := c.loadPending(key); ok {
+		return val, TierPending, nil
+	}
+	if val, ok := c.loadVictim(ctx, key); ok {
+		return val, TierVictim, nil
+	}
+	val, found, err := c.loadStore(ctx, key)
+	if err != nil || !found {
+		return val, TierMiss, err
+	}
+	return val, TierStore, nil
+}
 
+// loadStore reads key from persistence, caching hits in memory.
+func (c *TieredCache[K, V]) loadStore(ctx context.Context, key K) (V, bool, error) {
 	var zero V
 	if err := c.Store.ValidateKey(key); err != nil {
 		return zero, false, fmt.Errorf("invalid key: %w", err)
@@ -57,16 +268,92 @@ func (c *TieredCache[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
 
 	val, expiry, found, err := c.Store.Get(ctx, key)
 	if err != nil {
+		if val, ok := c.readRepair(ctx, key, err); ok {
+			return val, true, nil
+		}
 		return zero, false, fmt.Errorf("persistence load: %w", err)
 	}
 	if !found {
 		return zero, false, nil
 	}
 
-	c.memory.set(key, val, timeToSec(expiry))
+	c.memory.set(key, val, c.memExpiry(expiry))
+	c.repin(key)
 	return val, true, nil
 }
 
+// readRepair handles a persistence read that failed with err. If the store
+// reported key's entry corrupt and memory holds a live copy, as it can when a
+// write lands while persistence is read, the copy is written back over the
+// damaged entry and returned. Repairs are logged and counted by ReadRepairs.
+func (c *TieredCache[K, V]) readRepair(ctx context.Context, key K, err error) (V, bool) {
+	if !isCorrupt(err) {
+		var zero V
+		return zero, false
+	}
+	val, ok := c.memory.get(key)
+	if !ok {
+		return val, false
+	}
+	if verr := c.validateValue(val); verr != nil {
+		return val, true
+	}
+	// Memory's expiry is the persisted one unless MemoryTTL shortened it.
+	expiry := c.Info(key).Expiry
+	if c.memoryTTL > 0 {
+		expiry = c.expiry(val, 0)
+	}
+	if serr := c.Store.Set(ctx, key, val, expiry); serr != nil {
+		slog.Warn("read repair failed", "key", key, "error", err, "repair_error", serr)
+		return val, true
+	}
+	c.repairs.Add(1)
+	slog.Warn("read repair: rewrote corrupt persisted entry from memory", "key", key, "error", err)
+	return val, true
+}
+
+// ReadRepairs returns how many corrupt persisted entries have been rewritten
+// from memory since creation.
+func (c *TieredCache[K, V]) ReadRepairs() uint64 {
+	return c.repairs.Load()
+}
+
+// loadPending restores a value whose async persistence hasn't completed yet.
+// Returns false unless ReadYourWrites is enabled.
+func (c *TieredCache[K, V]) loadPending(key K) (V, bool) {
+	if !c.readYourWrites {
+		var zero V
+		return zero, false
+	}
+	val, expiry, ok := c.pending.get(key)
+	if ok {
+		c.memory.set(key, val, c.memExpiry(expiry))
+	}
+	return val, ok
+}
+
+// loadVictim restores a spilled entry to memory. Returns false if there is no victim store.
+func (c *TieredCache[K, V]) loadVictim(ctx context.Context, key K) (V, bool) {
+	if c.victim == nil {
+		var zero V
+		return zero, false
+	}
+	val, expiry, ok := c.victim.load(ctx, key)
+	if ok {
+		c.memory.set(key, val, c.memExpiry(expiry))
+	}
+	return val, ok
+}
+
+// forget drops pending and spilled copies of key so they can't shadow a newer write.
+func (c *TieredCache[K, V]) forget(ctx context.Context, key K) {
+	c.pending.drop(key)
+	c.dead.forget(key)
+	if c.victim != nil {
+		c.victim.forget(ctx, key)
+	}
+}
+
 // Set stores to memory first (always), then persistence.
 // Uses the default TTL specified at cache creation.
 func (c *TieredCache[K, V]) Set(ctx context.Context, key K, value V) error {
@@ -76,13 +363,27 @@ func (c *TieredCache[K, V]) Set(ctx context.Context, key K, value V) error {
 // SetTTL stores to memory first (always), then persistence with explicit TTL.
 // A zero or negative TTL means the entry never expires.
 func (c *TieredCache[K, V]) SetTTL(ctx context.Context, key K, value V, ttl time.Duration) error {
-	expiry := calculateExpiry(ttl, c.defaultTTL)
+	if err := c.checkMutable(key); err != nil {
+		return err
+	}
+	return c.setTTL(ctx, key, value, ttl)
+}
+
+func (c *TieredCache[K, V]) setTTL(ctx context.Context, key K, value V, ttl time.Duration) error {
+	if h, ok := c.access.sample(key); ok {
+		defer c.access.emit(AccessSet, h, value, TierStore, time.Now())
+	}
+	expiry := c.expiry(value, ttl)
 
 	if err := c.Store.ValidateKey(key); err != nil {
 		return err
 	}
+	if err := c.validateValue(value); err != nil {
+		return err
+	}
 
-	c.memory.set(key, value, timeToSec(expiry))
+	c.memory.set(key, value, c.memExpiry(expiry))
+	c.forget(ctx, key)
 
 	if err := c.Store.Set(ctx, key, value, expiry); err != nil {
 		return fmt.Errorf("persistence store failed: %w", err)
@@ -90,32 +391,307 @@ func (c *TieredCache[K, V]) SetTTL(ctx context.Context, key K, value V, ttl time
 	return nil
 }
 
+// SetMemoryOnly stores to memory without writing to persistence, for values too large
+// or sensitive to leave the process: evicting it never spills it to the Victim
+// store either. A zero or negative TTL uses the default TTL.
+// Any previously persisted copy of key is left in place and may be served again
+// once the memory entry is evicted; call Delete first if that matters. Like Set,
+// it returns ErrImmutable, storing nothing, for a key stored with SetImmutable.
+func (c *TieredCache[K, V]) SetMemoryOnly(ctx context.Context, key K, value V, ttl time.Duration) error {
+	if err := c.checkMutable(key); err != nil {
+		return err
+	}
+	c.memory.setLocal(key, value, c.memExpiry(c.expiry(value, ttl)))
+	c.forget(ctx, key)
+	return nil
+}
+
 // SetAsync stores to memory synchronously, persistence asynchronously.
-// Uses the default TTL. Persistence errors are logged, not returned.
+// Uses the default TTL. Persistence errors are logged, not returned; with
+// AsyncQueue, ErrBackpressure is.
 func (c *TieredCache[K, V]) SetAsync(ctx context.Context, key K, value V) error {
 	return c.SetAsyncTTL(ctx, key, value, 0)
 }
 
 // SetAsyncTTL stores to memory synchronously, persistence asynchronously with explicit TTL.
-// Persistence errors are logged, not returned.
+// Persistence errors are logged, not returned. With ReadYourWrites, Get returns the
+// value even if it is evicted from memory before the persistence write completes.
+// With AsyncWait, it first waits (bounded) for the write to finish.
+//
+// Async writes to one key, by SetAsync and DeleteAsync, reach persistence one
+// at a time in call order, so the store ends up with the last. A write still
+// waiting for its predecessor when a newer one arrives is skipped. Synchronous
+// Set and Delete are not ordered with async writes.
+//
+// With AsyncQueue, a write that finds the queue full is handled by its
+// policy; if refused, SetAsyncTTL returns ErrBackpressure and stores nothing.
+// Once Shutdown or Close has begun, it returns ErrClosed and stores nothing.
 func (c *TieredCache[K, V]) SetAsyncTTL(ctx context.Context, key K, value V, ttl time.Duration) error {
-	expiry := calculateExpiry(ttl, c.defaultTTL)
+	if h, ok := c.access.sample(key); ok {
+		defer c.access.emit(AccessSet, h, value, TierPending, time.Now())
+	}
+	expiry := c.expiry(value, ttl)
 
 	if err := c.Store.ValidateKey(key); err != nil {
 		return err
 	}
+	if err := c.validateValue(value); err != nil {
+		return err
+	}
+	if err := c.checkMutable(key); err != nil {
+		return err
+	}
+	if err := c.beginAsync(); err != nil {
+		return err
+	}
+	defer c.asyncMu.RUnlock()
+	t, err := c.queue.admit(ctx)
+	if err != nil {
+		return err
+	}
 
-	c.memory.set(key, value, timeToSec(expiry))
+	c.memory.set(key, value, c.memExpiry(expiry))
+	c.forget(ctx, key)
+
+	pw := c.pending.add(key, value, expiry)
+	c.writeBehind(ctx, t, key, pw, "async persistence failed", func(ctx context.Context) error {
+		return c.Store.Set(ctx, key, value, expiry)
+	})
+	return nil
+}
+
+// DeleteAsync removes key from memory synchronously and from persistence
+// asynchronously, for callers that only need eventual removal. Until the
+// persistence delete completes, Get treats key as missing rather than
+// reloading the stored value. It is ordered with SetAsync writes to key as
+// SetAsyncTTL describes, so an earlier write cannot resurrect the value.
+// Persistence errors are logged, not returned. With AsyncWait, it first
+// waits (bounded) for the delete to finish. With AsyncQueue, it returns
+// ErrBackpressure, leaving key in place, if the queue refuses the delete; a
+// dependent whose delete is refused is deleted synchronously instead. Once
+// Shutdown or Close has begun, it returns ErrClosed, leaving key in place.
+func (c *TieredCache[K, V]) DeleteAsync(ctx context.Context, key K) error {
+	if err := c.Store.ValidateKey(key); err != nil {
+		return fmt.Errorf("invalid key: %w", err)
+	}
+	if err := c.beginAsync(); err != nil {
+		return err
+	}
+	defer c.asyncMu.RUnlock()
+	t, err := c.queue.admit(ctx)
+	if err != nil {
+		return err
+	}
+	c.deleteAsync(ctx, t, key)
+	var errs []error
+	for _, d := range c.deps.cascade(key) {
+		forgetFlight(c.flights, d)
+		if t, err := c.queue.admit(ctx); err == nil {
+			c.deleteAsync(ctx, t, d)
+			continue
+		}
+		c.memory.del(d)
+		c.forget(ctx, d)
+		c.thaw(d)
+		if err := c.Store.Delete(ctx, d); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("dependent delete: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// deleteAsync removes key from memory and queues its persistence delete under t.
+func (c *TieredCache[K, V]) deleteAsync(ctx context.Context, t *asyncTicket, key K) {
+	c.memory.del(key)
+	c.forget(ctx, key)
+	c.thaw(key)
 
-	go func() {
-		storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), asyncTimeout)
+	pw := c.pending.addDelete(key)
+	c.writeBehind(ctx, t, key, pw, "async delete failed", func(ctx context.Context) error {
+		return c.Store.Delete(ctx, key)
+	})
+}
+
+// writeBehind runs the persistence write for pw in the background under t,
+// after the previous async write to key, skipping it if a newer one is queued
+// by then. With AsyncWait, it waits (bounded) for the write before returning.
+// Failures, including being dropped by AsyncQueue, are logged with msg.
+func (c *TieredCache[K, V]) writeBehind(ctx context.Context, t *asyncTicket, key K, pw *pendingWrite[V], msg string, write func(context.Context) error) {
+	done := make(chan struct{})
+	c.async.Go(func() {
+		defer close(done)
+		defer c.queue.release(t)
+		defer c.pending.done(key, pw)
+		if !c.pending.wait(key, pw) {
+			return // superseded
+		}
+		// Marked before the ctx check, so a drop either frees the slot at once
+		// or leaves it to this call, which then sees the cancellation.
+		t.started.Store(true)
+		storeCtx, cancel := context.WithTimeout(t.ctx, asyncTimeout)
 		defer cancel()
-		if err := c.Store.Set(storeCtx, key, value, expiry); err != nil {
-			slog.Error("async persistence failed", "key", key, "error", err)
+		err := storeCtx.Err()
+		if err == nil {
+			err = write(storeCtx)
+		}
+		if err != nil && t.dropped.Load() {
+			err = fmt.Errorf("%w: dropped for a newer write", ErrBackpressure)
 		}
-	}()
+		if err != nil {
+			slog.Error(msg, "key", key, "error", err)
+			if c.pending.current(key, pw) {
+				c.dead.add(&FailedWrite[K, V]{
+					Time: time.Now(), Err: err, Key: key, Value: pw.value, Expiry: pw.expiry, Deleted: pw.deleted,
+				})
+			}
+		}
+	})
 
-	return nil
+	if c.asyncWait > 0 {
+		timer := time.NewTimer(c.asyncWait)
+		defer timer.Stop()
+		select {
+		case <-done:
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+}
+
+// GetOrSet returns the value for key from any tier if there is one. Otherwise it
+// stores value in memory and persistence with the default TTL and returns it.
+// loaded reports whether the value already existed. The claim is atomic against
+// the memory tier, like sync.Map's LoadOrStore: concurrent calls in this process
+// agree on a single winner. Other processes sharing the store are not coordinated.
+//
+//nolint:gocritic // unnamedResult: matches Get
+func (c *TieredCache[K, V]) GetOrSet(ctx context.Context, key K, value V) (V, bool, error) {
+	return c.GetOrSetTTL(ctx, key, value, 0)
+}
+
+// GetOrSetTTL is like GetOrSet but stores value with an explicit TTL.
+//
+//nolint:gocritic // unnamedResult: matches Get
+func (c *TieredCache[K, V]) GetOrSetTTL(ctx context.Context, key K, value V, ttl time.Duration) (V, bool, error) {
+	var zero V
+	if err := c.Store.ValidateKey(key); err != nil {
+		return zero, false, err
+	}
+
+	if val, found, err := c.get(ctx, key); err != nil || found {
+		return c.clone.copy(val, found), found, err
+	}
+
+	if err := c.validateValue(value); err != nil {
+		return zero, false, err
+	}
+	expiry := c.expiry(value, ttl)
+	if val, loaded := c.memory.getOrSet(key, value, c.memExpiry(expiry)); loaded {
+		return c.clone.copy(val, true), true, nil
+	}
+	c.forget(ctx, key)
+
+	if err := c.Store.Set(ctx, key, value, expiry); err != nil {
+		return value, false, fmt.Errorf("persistence store failed: %w", err)
+	}
+	return value, false, nil
+}
+
+// Swap stores value in memory and persistence with the default TTL and returns
+// the value it replaced from any tier. existed reports whether there was one.
+// The exchange is atomic against the memory tier; other processes sharing the
+// store are not coordinated.
+//
+//nolint:gocritic // unnamedResult: matches Get
+func (c *TieredCache[K, V]) Swap(ctx context.Context, key K, value V) (V, bool, error) {
+	return c.SwapTTL(ctx, key, value, 0)
+}
+
+// SwapTTL is like Swap but stores value with an explicit TTL.
+// A zero or negative TTL means the entry never expires.
+//
+//nolint:gocritic // unnamedResult: matches Get
+func (c *TieredCache[K, V]) SwapTTL(ctx context.Context, key K, value V, ttl time.Duration) (V, bool, error) {
+	var zero V
+	expiry := c.expiry(value, ttl)
+	if err := c.Store.ValidateKey(key); err != nil {
+		return zero, false, err
+	}
+	if err := c.validateValue(value); err != nil {
+		return zero, false, err
+	}
+	if err := c.checkMutable(key); err != nil {
+		return zero, false, err
+	}
+
+	old, existed := c.memory.swap(key, value, c.memExpiry(expiry))
+	var loadErr error
+	if !existed {
+		// The store still holds the replaced value until the write below.
+		old, existed, loadErr = c.loadBelowMemory(ctx, key)
+	}
+	c.forget(ctx, key)
+
+	if err := c.Store.Set(ctx, key, value, expiry); err != nil {
+		return old, existed, fmt.Errorf("persistence store failed: %w", err)
+	}
+	return old, existed, loadErr
+}
+
+// GetAndDelete removes key from memory and persistence and returns its value
+// from any tier. Of concurrent callers in this process, only one receives the
+// value from memory; other processes sharing the store are not coordinated.
+//
+//nolint:gocritic // unnamedResult: matches Get
+func (c *TieredCache[K, V]) GetAndDelete(ctx context.Context, key K) (V, bool, error) {
+	var zero V
+	if err := c.Store.ValidateKey(key); err != nil {
+		return zero, false, fmt.Errorf("invalid key: %w", err)
+	}
+
+	forgetFlight(c.flights, key)
+	val, found := c.memory.getAndDelete(key)
+	var loadErr error
+	if !found {
+		val, found, loadErr = c.loadBelowMemory(ctx, key)
+	}
+	c.forget(ctx, key)
+	c.thaw(key)
+
+	if err := c.Store.Delete(ctx, key); err != nil {
+		return val, found, fmt.Errorf("persistence delete: %w", err)
+	}
+	if _, err := c.deleteDependents(ctx, key); err != nil {
+		return val, found, err
+	}
+	return val, found, loadErr
+}
+
+// loadBelowMemory reads key from the pending, victim, and persistence tiers
+// without caching it in memory.
+func (c *TieredCache[K, V]) loadBelowMemory(ctx context.Context, key K) (V, bool, error) {
+	if c.pending.deleted(key) {
+		var zero V
+		return zero, false, nil
+	}
+	if c.readYourWrites {
+		if val, _, ok := c.pending.get(key); ok {
+			return val, true, nil
+		}
+	}
+	if c.victim != nil {
+		if val, _, ok := c.victim.load(ctx, key); ok {
+			return val, true, nil
+		}
+	}
+	val, _, found, err := c.Store.Get(ctx, key)
+	if err != nil {
+		return val, false, fmt.Errorf("persistence load: %w", err)
+	}
+	return val, found, nil
 }
 
 // Fetch returns cached value or calls loader. Concurrent calls share one loader.
@@ -132,21 +708,12 @@ func (c *TieredCache[K, V]) FetchTTL(ctx context.Context, key K, ttl time.Durati
 func (c *TieredCache[K, V]) getSet(ctx context.Context, key K, loader func(context.Context) (V, error), ttl time.Duration) (V, error) {
 	var zero V
 
-	if val, ok := c.memory.get(key); ok {
-		return val, nil
-	}
-
-	if err := c.Store.ValidateKey(key); err != nil {
-		return zero, fmt.Errorf("invalid key: %w", err)
-	}
-
-	val, expiry, found, err := c.Store.Get(ctx, key)
+	cached, hit, err := c.Get(ctx, key)
 	if err != nil {
-		return zero, fmt.Errorf("persistence load: %w", err)
+		return zero, err
 	}
-	if found {
-		c.memory.set(key, val, timeToSec(expiry))
-		return val, nil
+	if hit && !c.memory.refreshDue(c.early, key) {
+		return cached, nil
 	}
 
 	call, loaded := c.flights.LoadOrCompute(key, func() (*flightCall[V], bool) {
@@ -156,57 +723,95 @@ func (c *TieredCache[K, V]) getSet(ctx context.Context, key K, loader func(conte
 	})
 
 	if loaded {
+		if hit {
+			return cached, nil // another caller is already refreshing it
+		}
 		call.wg.Wait()
-		return call.val, call.err
+		return c.clone.copy(call.val, call.err == nil), call.err
 	}
 
-	if v, ok := c.memory.get(key); ok {
-		call.val = v
-		c.flights.Delete(key)
-		call.wg.Done()
-		return v, nil
-	}
+	if !hit {
+		if v, ok := c.memory.get(key); ok {
+			call.val = v
+			endFlight(c.flights, key, call)
+			call.wg.Done()
+			return c.clone.copy(v, true), nil
+		}
 
-	val, expiry, found, err = c.Store.Get(ctx, key)
-	if err != nil {
-		call.err = fmt.Errorf("persistence load: %w", err)
-		c.flights.Delete(key)
-		call.wg.Done()
-		return zero, call.err
+		var val V
+		var expiry time.Time
+		var found bool
+		if !c.pending.deleted(key) {
+			val, expiry, found, err = c.Store.Get(ctx, key)
+		}
+		if err != nil {
+			if v, ok := c.readRepair(ctx, key, err); ok {
+				call.val = v
+				endFlight(c.flights, key, call)
+				call.wg.Done()
+				return c.clone.copy(v, true), nil
+			}
+			call.err = fmt.Errorf("persistence load: %w", err)
+			endFlight(c.flights, key, call)
+			call.wg.Done()
+			return zero, call.err
+		}
+		if found {
+			call.cache(func() { c.memory.set(key, val, c.memExpiry(expiry)) })
+			call.val = val
+			endFlight(c.flights, key, call)
+			call.wg.Done()
+			return c.clone.copy(val, true), nil
+		}
 	}
-	if found {
-		c.memory.set(key, val, timeToSec(expiry))
-		call.val = val
-		c.flights.Delete(key)
+
+	start := time.Now()
+	val, err := loader(ctx)
+	c.early.observe(time.Since(start))
+	if err != nil && hit {
+		// An early refresh failed, but the cached value has not expired.
+		call.val = cached
+		endFlight(c.flights, key, call)
 		call.wg.Done()
-		return val, nil
+		return cached, nil
 	}
-
-	val, err = loader(ctx)
 	if err != nil {
 		call.err = err
-		c.flights.Delete(key)
+		endFlight(c.flights, key, call)
 		call.wg.Done()
 		return zero, err
 	}
 
-	exp := calculateExpiry(ttl, c.defaultTTL)
-	c.memory.set(key, val, timeToSec(exp))
-
-	if err := c.Store.Set(ctx, key, val, exp); err != nil {
-		slog.Warn("Fetch persistence failed", "key", key, "error", err)
-	}
+	call.cache(func() { c.setLoaded(ctx, key, val, ttl) })
 
 	call.val = val
-	c.flights.Delete(key)
+	endFlight(c.flights, key, call)
 	call.wg.Done()
 
-	return val, nil
+	// The loaded value is cached, so the caller gets a copy too.
+	return c.clone.copy(val, true), nil
+}
+
+// setLoaded stores a value computed by a Fetch loader in memory and
+// persistence. Persistence failures are logged: the caller has its value.
+func (c *TieredCache[K, V]) setLoaded(ctx context.Context, key K, val V, ttl time.Duration) {
+	exp := c.expiry(val, ttl)
+	c.memory.set(key, val, c.memExpiry(exp))
+	c.forget(ctx, key)
+
+	if err := c.validateValue(val); err != nil {
+		slog.Warn("Fetch value not persisted", "key", key, "error", err)
+	} else if err := c.Store.Set(ctx, key, val, exp); err != nil {
+		slog.Warn("Fetch persistence failed", "key", key, "error", err)
+	}
 }
 
 // Delete removes from memory and persistence.
 func (c *TieredCache[K, V]) Delete(ctx context.Context, key K) error {
+	forgetFlight(c.flights, key)
 	c.memory.del(key)
+	c.forget(ctx, key)
+	c.thaw(key)
 
 	if err := c.Store.ValidateKey(key); err != nil {
 		return fmt.Errorf("invalid key: %w", err)
@@ -214,53 +819,356 @@ func (c *TieredCache[K, V]) Delete(ctx context.Context, key K) error {
 	if err := c.Store.Delete(ctx, key); err != nil {
 		return fmt.Errorf("persistence delete: %w", err)
 	}
-	return nil
+	_, err := c.deleteDependents(ctx, key)
+	return err
+}
+
+// Forget detaches an in-flight Fetch for key, so the next Fetch loads afresh
+// instead of waiting for a result that may be stale, as Cache.Forget. The
+// detached load's result is returned to its callers but neither cached nor
+// persisted. Delete, GetAndDelete, Flush, FlushMemory, and BumpEpoch forget
+// in-flight loads themselves.
+func (c *TieredCache[K, V]) Forget(key K) {
+	forgetFlight(c.flights, key)
+}
+
+// DeleteFunc removes every entry for which fn returns true from memory and
+// persistence, for targeted invalidation such as all values referencing one
+// account. Matches in memory and the victim tier are deleted from persistence
+// too. Entries only in persistence are found only if the store implements
+// PrefixScanner, which loads every stored value. Returns the number of distinct
+// keys removed, including dependents from SetWithDependencies. SetAsync
+// writes still in flight may land afterwards.
+func (c *TieredCache[K, V]) DeleteFunc(ctx context.Context, fn func(key K, value V) bool) (int, error) {
+	start := time.Now()
+	n, err := c.deleteFunc(ctx, fn)
+	c.audit.record(ctx, AdminOp{Op: "DeleteFunc", Time: start, Removed: n, Err: err})
+	return n, err
+}
+
+func (c *TieredCache[K, V]) deleteFunc(ctx context.Context, fn func(key K, value V) bool) (int, error) {
+	removed := make(map[K]struct{})
+	for _, k := range c.memory.deleteFunc(fn) {
+		removed[k] = struct{}{}
+	}
+	if c.victim != nil {
+		for _, k := range c.victim.deleteFunc(ctx, fn) {
+			removed[k] = struct{}{}
+		}
+	}
+
+	// Collect first: stores may not support deleting while iterating.
+	var stored []K
+	if ps, ok := baseStore(c.Store).(PrefixScanner[V]); ok {
+		for sk, v := range ps.Range(ctx, "") {
+			k, ok := any(sk).(K)
+			if !ok {
+				break // PrefixScanner is only meaningful for string keys
+			}
+			if _, done := removed[k]; !done && fn(k, v) {
+				stored = append(stored, k)
+			}
+		}
+	}
+
+	var errs []error
+	for k := range removed {
+		c.pending.drop(k)
+		c.thaw(k)
+		if err := c.Store.Delete(ctx, k); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, k := range stored {
+		c.memory.del(k)
+		c.forget(ctx, k)
+		c.thaw(k)
+		if err := c.Store.Delete(ctx, k); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		removed[k] = struct{}{}
+	}
+	matched := make([]K, 0, len(removed))
+	for k := range removed {
+		matched = append(matched, k)
+	}
+	for _, k := range matched {
+		deps, err := c.deleteDependents(ctx, k)
+		for _, d := range deps {
+			removed[d] = struct{}{}
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return len(removed), fmt.Errorf("persistence delete: %w", errors.Join(errs...))
+	}
+	return len(removed), nil
+}
+
+// FlushResult counts the entries TieredCache.Flush removed from each tier.
+// An entry held in both tiers is counted in each.
+type FlushResult struct {
+	Memory  int // live entries removed from memory, as by FlushMemory
+	Persist int // entries removed from persistence, as by FlushPersist
+}
+
+// Total returns the entries removed from both tiers.
+func (r FlushResult) Total() int {
+	return r.Memory + r.Persist
+}
+
+// Flush clears memory and persistence, returning the entries removed from
+// each. Memory is cleared even if the persistence flush fails.
+func (c *TieredCache[K, V]) Flush(ctx context.Context) (FlushResult, error) {
+	start := time.Now()
+	c.deps.clear()
+	c.pending.clear()
+	c.dead.clear()
+	var r FlushResult
+	var err error
+	r.Memory = c.flushMemory(ctx)
+	r.Persist, err = c.flushPersist(ctx)
+	c.audit.record(ctx, AdminOp{Op: "Flush", Time: start, Removed: r.Total(), Persisted: r.Persist, Err: err})
+	return r, err
 }
 
-// Flush clears memory and persistence. Returns total entries removed.
-func (c *TieredCache[K, V]) Flush(ctx context.Context) (int, error) {
-	memoryRemoved := c.memory.flush()
-	persistRemoved, err := c.Store.Flush(ctx)
+// FlushMemory clears the memory tier, leaving persisted entries intact.
+// Use it to drop a poisoned memory tier; entries reload from persistence on demand.
+// Spilled victim entries are memory-derived and are cleared too. Returns the
+// live entries removed. As with Cache.Flush, sets that start first don't survive
+// it, and Fetch loads in flight are forgotten rather than cached when they finish.
+func (c *TieredCache[K, V]) FlushMemory(ctx context.Context) int {
+	start := time.Now()
+	n := c.flushMemory(ctx)
+	c.audit.record(ctx, AdminOp{Op: "FlushMemory", Time: start, Removed: n})
+	return n
+}
+
+func (c *TieredCache[K, V]) flushMemory(ctx context.Context) int {
+	forgetFlights(c.flights)
+	n := c.memory.flush()
+	if c.victim != nil {
+		if _, err := c.victim.flush(ctx); err != nil {
+			slog.Warn("victim flush failed", "error", err)
+		}
+	}
+	return n
+}
+
+// FlushPersist clears the persistence tier, leaving memory intact. Returns entries removed.
+func (c *TieredCache[K, V]) FlushPersist(ctx context.Context) (int, error) {
+	start := time.Now()
+	n, err := c.flushPersist(ctx)
+	c.audit.record(ctx, AdminOp{Op: "FlushPersist", Time: start, Removed: n, Err: err})
+	return n, err
+}
+
+func (c *TieredCache[K, V]) flushPersist(ctx context.Context) (int, error) {
+	c.immutable.Clear() // the entries the marks protect are going
+	n, err := c.Store.Flush(ctx)
 	if err != nil {
-		return memoryRemoved, fmt.Errorf("persistence flush: %w", err)
+		return n, fmt.Errorf("persistence flush: %w", err)
 	}
-	return memoryRemoved + persistRemoved, nil
+	return n, nil
+}
+
+// BumpEpoch invalidates all entries in memory and persistence, e.g. after an
+// upstream schema change. Memory invalidation is O(1); stores implementing
+// EpochBumper invalidate in O(1) too, while other stores are flushed.
+func (c *TieredCache[K, V]) BumpEpoch(ctx context.Context) error {
+	start := time.Now()
+	err := c.bumpEpoch(ctx)
+	c.audit.record(ctx, AdminOp{Op: "BumpEpoch", Time: start, Removed: -1, Err: err})
+	return err
 }
 
-// Len returns the memory cache size. Use Store.Len for persistence count.
+func (c *TieredCache[K, V]) bumpEpoch(ctx context.Context) error {
+	forgetFlights(c.flights)
+	c.deps.clear()
+	c.immutable.Clear()
+	c.memory.bumpEpoch()
+	c.pending.clear()
+	c.dead.clear()
+	if c.victim != nil {
+		if _, err := c.victim.flush(ctx); err != nil {
+			slog.Warn("victim flush failed", "error", err)
+		}
+	}
+	if eb, ok := baseStore(c.Store).(EpochBumper); ok {
+		if _, err := eb.BumpEpoch(ctx); err != nil {
+			return fmt.Errorf("persistence epoch bump: %w", err)
+		}
+		return nil
+	}
+	if _, err := c.Store.Flush(ctx); err != nil {
+		return fmt.Errorf("persistence flush: %w", err)
+	}
+	return nil
+}
+
+// Tier identifies the layer of a TieredCache that served a read.
+type Tier uint8
+
+// Tiers, in the order Get consults them.
+const (
+	TierMiss     Tier = iota // not found
+	TierMemory               // live memory entry
+	TierDeathRow             // memory entry resurrected from pending eviction
+	TierPending              // in-flight SetAsync write (ReadYourWrites)
+	TierVictim               // local victim store (Victim)
+	TierStore                // persistence store
+)
+
+var tierNames = [...]string{"miss", "memory", "deathrow", "pending", "victim", "store"}
+
+func (t Tier) String() string {
+	if int(t) < len(tierNames) {
+		return tierNames[t]
+	}
+	return fmt.Sprintf("Tier(%d)", t)
+}
+
+// EntryInfo describes a key's state in a TieredCache, for diagnostics.
+type EntryInfo struct {
+	Expiry         time.Time // memory expiry; zero if the entry never expires or isn't in memory
+	InMemory       bool      // a live copy is in the memory tier
+	PendingPersist bool      // a SetAsync write is in flight; false once it succeeds or fails
+	PendingDelete  bool      // a DeleteAsync is in flight; Get misses until it finishes
+}
+
+// Info reports where key currently lives without touching persistence or
+// affecting eviction. PendingPersist distinguishes "not persisted yet" from a
+// failed write, which is logged and leaves PendingPersist false; PendingDelete
+// does the same for DeleteAsync. At most one is set, for the latest async write.
+func (c *TieredCache[K, V]) Info(key K) EntryInfo {
+	var info EntryInfo
+	if w, ok := c.pending.m.Load(key); ok {
+		info.PendingPersist = !w.deleted
+		info.PendingDelete = w.deleted
+	}
+
+	var exp uint32
+	if ref, ok := c.memory.entries.Load(key); ok {
+		if sl := ref.load(); sl != nil {
+			info.InMemory = !ref.e.onDeathRow() && sl.epoch == c.memory.epoch.Load()
+			exp = sl.expirySec
+		}
+	} else if c.memory.large != nil {
+		exp, info.InMemory = c.memory.large.expiry(key)
+	}
+	//nolint:gosec // G115: Unix seconds fit in uint32 until year 2106
+	if info.InMemory && exp != 0 {
+		if uint32(time.Now().Unix()) > exp {
+			info.InMemory = false
+		} else {
+			info.Expiry = time.Unix(int64(exp), 0)
+		}
+	}
+	return info
+}
+
+// KeyLocation reports where a key is kept, so a key can be traced to the
+// memory queue, file, or Valkey key holding it. See TieredCache.Locations.
+type KeyLocation struct {
+	Hash   uint64 // key hash, as reported in AccessLog events
+	Queue  string // memory queue: "small", "main", "death row", "large", or "" if not in memory
+	Store  string // persistence store's Location; "" if the store doesn't implement Locator
+	Victim string // victim store's Location; "" without Victim or Locator
+}
+
+// Locations reports where key is kept in memory and where each persistence
+// store keeps or would keep it, without reading persistence. Keys excluded by
+// NoPersistPattern or NoPersistFunc have no store locations.
+func (c *TieredCache[K, V]) Locations(key K) KeyLocation {
+	loc := KeyLocation{Hash: c.memory.hasher(key), Queue: c.memory.queueName(key)}
+	if excluded(c.Store, key) {
+		return loc
+	}
+	if l, ok := baseStore(c.Store).(Locator[K]); ok {
+		loc.Store = l.Location(storedKey(c.Store, key))
+	}
+	if c.victim != nil {
+		if l, ok := baseStore(c.victim.store).(Locator[K]); ok {
+			loc.Victim = l.Location(storedKey(c.victim.store, key))
+		}
+	}
+	return loc
+}
+
+// Len returns the number of entries in memory, excluding those on death row.
+// Use Lengths for the persistence count.
 func (c *TieredCache[K, V]) Len() int {
 	return c.memory.len()
 }
 
+// Lengths counts entries in each tier, for sizing Size against persistence.
+type Lengths struct {
+	Memory    int // as Len
+	Persisted int // as Store.Len; may include expired entries not yet cleaned up
+	Pending   int // async writes not yet persisted
+	// Total estimates distinct keys across tiers. Persistence holds every
+	// write-through entry, so memory adds only what it holds beyond that;
+	// memory-only entries (SetMemoryOnly, Prefetch) make it an underestimate.
+	Total int
+}
+
+// Lengths reports per-tier entry counts. Persisted requires a Store.Len call,
+// which may scan the store; if it fails, Persisted and Total are zero.
+func (c *TieredCache[K, V]) Lengths(ctx context.Context) (Lengths, error) {
+	l := Lengths{Memory: c.memory.len()}
+	c.pending.m.Range(func(_ K, w *pendingWrite[V]) bool {
+		if !w.deleted {
+			l.Pending++
+		}
+		return true
+	})
+	n, err := c.Store.Len(ctx)
+	if err != nil {
+		return l, fmt.Errorf("persistence len: %w", err)
+	}
+	l.Persisted = n
+	l.Total = max(l.Memory, l.Persisted+l.Pending)
+	return l, nil
+}
+
+// HotKeys returns up to n of the most frequently accessed keys in memory,
+// hottest first, as Cache.HotKeys. PersistHotKeys saves them so a new
+// instance loads its hottest entries from persistence before traffic arrives.
+func (c *TieredCache[K, V]) HotKeys(n int) []K {
+	return c.memory.hottest(n)
+}
+
 // Range returns an iterator over all non-expired key-value pairs in memory.
 // Does not iterate the persistence layer.
 // Iteration order is undefined. Safe for concurrent use.
 // Changes during iteration may or may not be reflected.
 func (c *TieredCache[K, V]) Range() iter.Seq2[K, V] {
-	return func(yield func(K, V) bool) {
-		//nolint:gosec // G115: Unix seconds fit in uint32 until year 2106
-		now := uint32(time.Now().Unix())
-		c.memory.entries.Range(func(key K, e *entry[K, V]) bool {
-			// Skip expired entries.
-			expiry := e.expirySec.Load()
-			if expiry != 0 && expiry < now {
-				return true
-			}
-
-			// Load value with seqlock.
-			v, ok := e.loadValue()
-			if !ok {
-				return true
-			}
-
-			// Yield to caller.
-			return yield(key, v)
-		})
-	}
+	return copyAll(c.clone, c.memory.all())
 }
 
-// Close releases store resources.
+// Close releases store resources. SetAsync and DeleteAsync return ErrClosed
+// from then on; writes they already started are not waited for, as Shutdown
+// does.
 func (c *TieredCache[K, V]) Close() error {
+	c.closeAsync()
+	if c.cleaner != nil {
+		c.cleaner.stop()
+	}
+	c.hotKeys.stop()
+	c.purge.stop()
+	c.prefetch.stop()
+	c.thresholds.stop()
+	if c.victim != nil {
+		if err := c.victim.close(); err != nil {
+			slog.Warn("close victim store", "error", err)
+		}
+	}
 	if err := c.Store.Close(); err != nil {
 		return fmt.Errorf("close persistence: %w", err)
 	}
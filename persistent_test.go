@@ -2,7 +2,12 @@ package fido
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"iter"
+	"maps"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -699,9 +704,9 @@ func TestTieredCache_Flush(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Flush: %v", err)
 	}
-	// Should remove 10 from memory + 10 from persistence = 20 total
-	if removed != 20 {
-		t.Errorf("Flush removed %d items; want 20", removed)
+	// Should remove 10 from memory and 10 from persistence
+	if removed != (FlushResult{Memory: 10, Persist: 10}) || removed.Total() != 20 {
+		t.Errorf("Flush removed %+v; want 10 from each tier", removed)
 	}
 
 	// Memory cache should be empty
@@ -1164,6 +1169,71 @@ func TestTieredCache_Fetch_Basic(t *testing.T) {
 	}
 }
 
+func TestTieredCache_DeleteForgetsFetch(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = cache.Fetch(ctx, "k", func(context.Context) (int, error) { //nolint:errcheck // loader never fails
+			close(started)
+			<-release
+			return 1, nil
+		})
+	}()
+	<-started
+	if err := cache.Delete(ctx, "k"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	// The next Fetch loads afresh.
+	if v, err := cache.Fetch(ctx, "k", func(context.Context) (int, error) { return 2, nil }); err != nil || v != 2 {
+		t.Errorf("Fetch after Delete = %d, %v; want 2, nil", v, err)
+	}
+	close(release)
+	<-done
+	if v, _, _, _ := store.Get(ctx, "k"); v != 2 { //nolint:errcheck // mock
+		t.Errorf("persisted k = %d; want 2, not the deleted load's result", v)
+	}
+	if v, _, _ := cache.Get(ctx, "k"); v != 2 { //nolint:errcheck // mock
+		t.Errorf("Get = %d; want 2", v)
+	}
+}
+
+func TestTieredCache_FlushMemoryForgetsFetch(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = cache.Fetch(ctx, "k", func(context.Context) (int, error) { //nolint:errcheck // loader never fails
+			close(started)
+			<-release
+			return 1, nil
+		})
+	}()
+	<-started
+	cache.FlushMemory(ctx)
+	close(release)
+	<-done
+	if _, found, _ := cache.Get(ctx, "k"); found { //nolint:errcheck // only checking presence
+		t.Error("a load started before FlushMemory repopulated the cache")
+	}
+}
+
 func TestTieredCache_Fetch_FromPersistence(t *testing.T) {
 	store := newMockStore[string, int]()
 	cache, err := NewTiered[string, int](store)
@@ -1590,8 +1660,8 @@ func TestTieredCache_Flush_PersistenceError(t *testing.T) {
 	}
 
 	// Should return memory count even on persistence error
-	if removed != 5 {
-		t.Errorf("Flush removed %d items; want 5 (memory count)", removed)
+	if removed != (FlushResult{Memory: 5}) {
+		t.Errorf("Flush removed %+v; want 5 from memory", removed)
 	}
 }
 
@@ -1902,3 +1972,1132 @@ func TestTieredCache_Fetch_SecondStoreGetFound(t *testing.T) {
 		t.Error("loader should not be called when second store.Get finds value")
 	}
 }
+
+func TestTieredCache_MemoryTTL_PersistTTL(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+
+	cache, err := NewTiered[string, int](store, MemoryTTL(time.Minute), PersistTTL(24*time.Hour))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	if err := cache.Set(ctx, "key1", 42); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	_, expiry, found, err := store.Get(ctx, "key1")
+	if err != nil || !found {
+		t.Fatalf("store.Get: found=%v err=%v", found, err)
+	}
+	if d := time.Until(expiry); d < 23*time.Hour || d > 24*time.Hour {
+		t.Errorf("persisted TTL = %v; want ~24h", d)
+	}
+
+	ent, ok := cache.memory.getEntry("key1")
+	if !ok {
+		t.Fatal("key1 should be in memory")
+	}
+	memTTL := time.Until(time.Unix(int64(ent.expirySec()), 0))
+	if memTTL > time.Minute+time.Second || memTTL < 58*time.Second {
+		t.Errorf("memory TTL = %v; want ~1m", memTTL)
+	}
+
+	// Explicit TTL shorter than MemoryTTL applies to both tiers.
+	if err := cache.SetTTL(ctx, "key2", 7, 10*time.Second); err != nil {
+		t.Fatalf("SetTTL: %v", err)
+	}
+	ent, _ = cache.memory.getEntry("key2")
+	if memTTL := time.Until(time.Unix(int64(ent.expirySec()), 0)); memTTL > 11*time.Second {
+		t.Errorf("memory TTL = %v; want <= 10s", memTTL)
+	}
+}
+
+func TestTieredCache_TTLFromValue(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+
+	// Values are their own TTL in seconds.
+	ttlOf := func(v int) time.Duration { return time.Duration(v) * time.Second }
+	cache, err := NewTiered[string, int](store, PersistTTL(time.Hour), MemoryTTL(time.Minute), TTLFromValue(ttlOf))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	persisted := func(key string) time.Duration {
+		t.Helper()
+		_, expiry, found, err := store.Get(ctx, key)
+		if err != nil || !found {
+			t.Fatalf("store.Get(%s): found=%v err=%v", key, found, err)
+		}
+		return time.Until(expiry)
+	}
+	memTTL := func(key string) time.Duration {
+		t.Helper()
+		ent, ok := cache.memory.getEntry(key)
+		if !ok {
+			t.Fatalf("%s should be in memory", key)
+		}
+		return time.Until(time.Unix(int64(ent.expirySec()), 0))
+	}
+
+	if err := cache.Set(ctx, "derived", 7200); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if d := persisted("derived"); d < 119*time.Minute || d > 2*time.Hour {
+		t.Errorf("persisted TTL = %v; want ~2h from the value, overriding PersistTTL", d)
+	}
+	if d := memTTL("derived"); d > time.Minute+time.Second {
+		t.Errorf("memory TTL = %v; want MemoryTTL's 1m cap", d)
+	}
+
+	if err := cache.Set(ctx, "default", 0); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if d := persisted("default"); d < 59*time.Minute || d > time.Hour {
+		t.Errorf("persisted TTL = %v; want the 1h PersistTTL when the value gives none", d)
+	}
+
+	if err := cache.SetTTL(ctx, "explicit", 7200, 10*time.Second); err != nil {
+		t.Fatalf("SetTTL: %v", err)
+	}
+	if d := persisted("explicit"); d > 10*time.Second {
+		t.Errorf("persisted TTL = %v; want the explicit 10s", d)
+	}
+
+	if _, err := cache.Fetch(ctx, "fetched", func(context.Context) (int, error) { return 30, nil }); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if d := persisted("fetched"); d > 30*time.Second || d < 28*time.Second {
+		t.Errorf("Fetch persisted TTL = %v; want ~30s from the value", d)
+	}
+}
+
+func TestTieredCache_MemoryTTL_NoExpiry(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	_ = store.Set(ctx, "key1", 42, time.Time{}) //nolint:errcheck // Test fixture
+
+	cache, err := NewTiered[string, int](store, MemoryTTL(time.Minute))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	if _, found, err := cache.Get(ctx, "key1"); err != nil || !found {
+		t.Fatalf("Get: found=%v err=%v", found, err)
+	}
+
+	// Entries loaded without expiry still get the memory cap.
+	ent, _ := cache.memory.getEntry("key1")
+	if ent.expirySec() == 0 {
+		t.Error("memory entry should expire even when persisted copy never does")
+	}
+}
+
+func TestTieredCache_SetMemoryOnly(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	_ = cache.SetMemoryOnly(ctx, "secret", 42, time.Hour) //nolint:errcheck // not immutable
+
+	val, found, err := cache.Get(ctx, "secret")
+	if err != nil || !found || val != 42 {
+		t.Fatalf("Get(secret) = %d, %v, %v; want 42, true, nil", val, found, err)
+	}
+
+	if _, _, found, _ := store.Get(ctx, "secret"); found { //nolint:errcheck // only checking presence
+		t.Error("memory-only value should not be persisted")
+	}
+}
+
+func TestTieredCache_FlushMemory(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	for i := range 5 {
+		if err := cache.Set(ctx, fmt.Sprintf("key%d", i), i); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+
+	if n := cache.FlushMemory(ctx); n != 5 {
+		t.Errorf("FlushMemory() = %d; want 5", n)
+	}
+	if cache.Len() != 0 {
+		t.Errorf("memory Len() = %d; want 0", cache.Len())
+	}
+	if n, _ := store.Len(ctx); n != 5 { //nolint:errcheck // mock never fails
+		t.Errorf("persisted entries = %d; want 5", n)
+	}
+
+	// Entries reload from persistence.
+	if val, found, err := cache.Get(ctx, "key3"); err != nil || !found || val != 3 {
+		t.Errorf("Get(key3) = %d, %v, %v; want 3, true, nil", val, found, err)
+	}
+}
+
+func TestTieredCache_FlushPersist(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	for i := range 3 {
+		if err := cache.Set(ctx, fmt.Sprintf("key%d", i), i); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+
+	n, err := cache.FlushPersist(ctx)
+	if err != nil || n != 3 {
+		t.Errorf("FlushPersist() = %d, %v; want 3, nil", n, err)
+	}
+	if cache.Len() != 3 {
+		t.Errorf("memory Len() = %d; want 3", cache.Len())
+	}
+	if n, _ := store.Len(ctx); n != 0 { //nolint:errcheck // mock never fails
+		t.Errorf("persisted entries = %d; want 0", n)
+	}
+}
+
+// epochMockStore records BumpEpoch calls instead of flushing.
+type epochMockStore[K comparable, V any] struct {
+	*mockStore[K, V]
+	bumps int
+}
+
+func (m *epochMockStore[K, V]) BumpEpoch(ctx context.Context) (uint64, error) {
+	m.bumps++
+	m.mu.Lock()
+	clear(m.data)
+	m.mu.Unlock()
+	return uint64(m.bumps), nil
+}
+
+func TestTieredCache_BumpEpoch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("epoch store", func(t *testing.T) {
+		store := &epochMockStore[string, int]{mockStore: newMockStore[string, int]()}
+		cache, err := NewTiered[string, int](store)
+		if err != nil {
+			t.Fatalf("NewTiered: %v", err)
+		}
+		if err := cache.Set(ctx, "key1", 1); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+		if err := cache.BumpEpoch(ctx); err != nil {
+			t.Fatalf("BumpEpoch: %v", err)
+		}
+		if store.bumps != 1 {
+			t.Errorf("store BumpEpoch calls = %d; want 1", store.bumps)
+		}
+		if _, found, _ := cache.Get(ctx, "key1"); found { //nolint:errcheck // only checking presence
+			t.Error("key1 should be invalidated")
+		}
+	})
+
+	t.Run("flush fallback", func(t *testing.T) {
+		store := newMockStore[string, int]()
+		cache, err := NewTiered[string, int](store)
+		if err != nil {
+			t.Fatalf("NewTiered: %v", err)
+		}
+		if err := cache.Set(ctx, "key1", 1); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+		if err := cache.BumpEpoch(ctx); err != nil {
+			t.Fatalf("BumpEpoch: %v", err)
+		}
+		if _, found, _ := cache.Get(ctx, "key1"); found { //nolint:errcheck // only checking presence
+			t.Error("key1 should be invalidated")
+		}
+		if n, _ := store.Len(ctx); n != 0 { //nolint:errcheck // mock never fails
+			t.Errorf("store Len() = %d; want 0 after flush fallback", n)
+		}
+	})
+}
+
+// gatedMockStore blocks Set until the gate channel is closed.
+type gatedMockStore[K comparable, V any] struct {
+	*mockStore[K, V]
+	gate chan struct{}
+}
+
+func (m *gatedMockStore[K, V]) Set(ctx context.Context, key K, value V, expiry time.Time) error {
+	<-m.gate
+	return m.mockStore.Set(ctx, key, value, expiry)
+}
+
+// orderedMockStore reports each Set and Delete as it starts, then holds it
+// until released.
+type orderedMockStore struct {
+	*mockStore[string, int]
+	started chan string
+	release chan struct{}
+}
+
+func (m *orderedMockStore) Set(ctx context.Context, key string, value int, expiry time.Time) error {
+	m.started <- "set"
+	<-m.release
+	return m.mockStore.Set(ctx, key, value, expiry)
+}
+
+func (m *orderedMockStore) Delete(ctx context.Context, key string) error {
+	m.started <- "delete"
+	<-m.release
+	return m.mockStore.Delete(ctx, key)
+}
+
+// next returns the next store operation to start, or "" if none starts soon.
+func (m *orderedMockStore) next() string {
+	select {
+	case op := <-m.started:
+		return op
+	case <-time.After(50 * time.Millisecond):
+		return ""
+	}
+}
+
+func TestTieredCache_DeleteAsync(t *testing.T) {
+	ctx := context.Background()
+	store := &orderedMockStore{mockStore: newMockStore[string, int](), started: make(chan string, 4), release: make(chan struct{})}
+	_ = store.mockStore.Set(ctx, "k", 1, time.Time{}) //nolint:errcheck // Test fixture
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	// A delete is ordered before a later SetAsync.
+	if err := cache.DeleteAsync(ctx, "k"); err != nil {
+		t.Fatalf("DeleteAsync: %v", err)
+	}
+	if op := store.next(); op != "delete" {
+		t.Fatalf("first store op = %q; want delete", op)
+	}
+	if info := cache.Info("k"); !info.PendingDelete || info.PendingPersist {
+		t.Errorf("Info during DeleteAsync = %+v; want PendingDelete only", info)
+	}
+	// The stored value is still there, but the tombstone hides it.
+	if _, found, _ := cache.Get(ctx, "k"); found { //nolint:errcheck // mock
+		t.Error("Get found a value pending deletion")
+	}
+	if _, tier, _ := cache.GetWithInfo(ctx, "k"); tier != TierMiss { //nolint:errcheck // mock
+		t.Errorf("GetWithInfo tier = %v; want miss", tier)
+	}
+	if err := cache.SetAsync(ctx, "k", 2); err != nil {
+		t.Fatalf("SetAsync: %v", err)
+	}
+	if op := store.next(); op != "" {
+		t.Fatalf("store op %q started before the delete finished", op)
+	}
+	store.release <- struct{}{}
+	if op := store.next(); op != "set" {
+		t.Fatalf("store op after delete = %q; want set", op)
+	}
+	store.release <- struct{}{}
+
+	// A delete is ordered after an earlier SetAsync.
+	waitFor(t, func() bool { return !cache.Info("k").PendingPersist })
+	if err := cache.SetAsync(ctx, "k", 3); err != nil {
+		t.Fatalf("SetAsync: %v", err)
+	}
+	if err := cache.DeleteAsync(ctx, "k"); err != nil {
+		t.Fatalf("DeleteAsync: %v", err)
+	}
+	if op := store.next(); op != "set" {
+		t.Fatalf("store op = %q; want set", op)
+	}
+	if op := store.next(); op != "" {
+		t.Fatalf("store op %q started before the set finished", op)
+	}
+	store.release <- struct{}{}
+	if op := store.next(); op != "delete" {
+		t.Fatalf("store op after sets = %q; want delete", op)
+	}
+	store.release <- struct{}{}
+	waitFor(t, func() bool { return !cache.Info("k").PendingDelete })
+	if _, _, found, _ := store.mockStore.Get(ctx, "k"); found { //nolint:errcheck // mock
+		t.Error("key persisted after DeleteAsync")
+	}
+}
+
+func TestTieredCache_SetAsyncOrder(t *testing.T) {
+	ctx := context.Background()
+	store := &orderedMockStore{mockStore: newMockStore[string, int](), started: make(chan string, 4), release: make(chan struct{})}
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	for v := range 4 {
+		if err := cache.SetAsync(ctx, "k", v); err != nil {
+			t.Fatalf("SetAsync: %v", err)
+		}
+	}
+	// Other keys are not held up.
+	if err := cache.SetAsync(ctx, "other", 9); err != nil {
+		t.Fatalf("SetAsync: %v", err)
+	}
+	for range 2 {
+		if op := store.next(); op != "set" {
+			t.Fatalf("store op = %q; want the first write to each key", op)
+		}
+	}
+	if op := store.next(); op != "" {
+		t.Fatalf("store op %q started before the key's previous write finished", op)
+	}
+	store.release <- struct{}{}
+	store.release <- struct{}{}
+	// The writes queued behind the first are superseded by the last.
+	if op := store.next(); op != "set" {
+		t.Fatalf("store op = %q; want the last write", op)
+	}
+	store.release <- struct{}{}
+	if op := store.next(); op != "" {
+		t.Errorf("superseded write %q reached the store", op)
+	}
+	waitFor(t, func() bool { return !cache.Info("k").PendingPersist })
+	if v, _, _, _ := store.mockStore.Get(ctx, "k"); v != 3 { //nolint:errcheck // mock
+		t.Errorf("persisted k = %d; want 3, the last write", v)
+	}
+}
+
+func TestTieredCache_ReadYourWrites(t *testing.T) {
+	ctx := context.Background()
+	inner := newMockStore[string, int]()
+	_ = inner.Set(ctx, "key1", 1, time.Time{}) //nolint:errcheck // Test fixture: older persisted value
+	store := &gatedMockStore[string, int]{mockStore: inner, gate: make(chan struct{})}
+
+	cache, err := NewTiered[string, int](store, ReadYourWrites())
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	if err := cache.SetAsync(ctx, "key1", 2); err != nil {
+		t.Fatalf("SetAsync: %v", err)
+	}
+
+	// Simulate eviction before the async write lands.
+	cache.memory.del("key1")
+
+	val, found, err := cache.Get(ctx, "key1")
+	if err != nil || !found || val != 2 {
+		t.Fatalf("Get(key1) = %d, %v, %v; want 2 from pending write", val, found, err)
+	}
+
+	close(store.gate)
+	waitFor(t, func() bool {
+		_, ok := cache.pending.m.Load("key1")
+		return !ok
+	})
+}
+
+func TestTieredCache_ReadYourWrites_SyncWriteSupersedes(t *testing.T) {
+	ctx := context.Background()
+	store := &gatedMockStore[string, int]{mockStore: newMockStore[string, int](), gate: make(chan struct{})}
+
+	cache, err := NewTiered[string, int](store, ReadYourWrites())
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	if err := cache.SetAsync(ctx, "key1", 1); err != nil {
+		t.Fatalf("SetAsync: %v", err)
+	}
+	_ = cache.SetMemoryOnly(ctx, "key1", 2, 0) //nolint:errcheck // not immutable
+	cache.memory.del("key1")
+
+	if _, found, _ := cache.Get(ctx, "key1"); found { //nolint:errcheck // only checking presence
+		t.Error("pending write should be dropped once superseded")
+	}
+	close(store.gate)
+}
+
+func TestTieredCache_Info(t *testing.T) {
+	ctx := context.Background()
+	store := &gatedMockStore[string, int]{mockStore: newMockStore[string, int](), gate: make(chan struct{})}
+
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	if info := cache.Info("key1"); info.InMemory || info.PendingPersist {
+		t.Errorf("Info(missing) = %+v; want zero", info)
+	}
+
+	if err := cache.SetAsyncTTL(ctx, "key1", 1, time.Hour); err != nil {
+		t.Fatalf("SetAsyncTTL: %v", err)
+	}
+	info := cache.Info("key1")
+	if !info.InMemory || !info.PendingPersist {
+		t.Errorf("Info during async write = %+v; want InMemory and PendingPersist", info)
+	}
+	if d := time.Until(info.Expiry); d < 59*time.Minute || d > time.Hour+time.Second {
+		t.Errorf("Info.Expiry in %v; want ~1h", d)
+	}
+
+	close(store.gate)
+	waitFor(t, func() bool { return !cache.Info("key1").PendingPersist })
+}
+
+// locStore is a mockStore with its own Location scheme.
+type locStore struct {
+	*mockStore[string, int]
+	prefix string
+}
+
+func (s *locStore) Location(key string) string { return s.prefix + key }
+
+func TestTieredCache_Locations(t *testing.T) {
+	ctx := context.Background()
+	victim := &locStore{mockStore: newMockStore[string, int](), prefix: "/tmp/victim/"}
+	cache, err := NewTiered[string, int](newMockStore[string, int](), Victim[string, int](victim, time.Minute))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	loc := cache.Locations("k")
+	if loc.Queue != "" || loc.Store != "mock://k" || loc.Victim != "/tmp/victim/k" {
+		t.Errorf("Locations(missing) = %+v; want store and victim paths only", loc)
+	}
+	if err := cache.Set(ctx, "k", 1); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if loc := cache.Locations("k"); loc.Queue != "small" || loc.Hash != cache.memory.hasher("k") {
+		t.Errorf("Locations(k) = %+v; want the small queue and key hash", loc)
+	}
+
+}
+
+func TestTieredCache_Lengths(t *testing.T) {
+	ctx := context.Background()
+	store := &gatedMockStore[string, int]{mockStore: newMockStore[string, int](), gate: make(chan struct{})}
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	// One key persisted, then dropped from memory; one only in memory; one pending.
+	if err := store.mockStore.Set(ctx, "a", 1, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	_ = cache.SetMemoryOnly(ctx, "b", 2, 0) //nolint:errcheck // not immutable
+	if err := cache.SetAsync(ctx, "c", 3); err != nil {
+		t.Fatalf("SetAsync: %v", err)
+	}
+	l, err := cache.Lengths(ctx)
+	if err != nil {
+		t.Fatalf("Lengths: %v", err)
+	}
+	if want := (Lengths{Memory: 2, Persisted: 1, Pending: 1, Total: 2}); l != want {
+		t.Errorf("Lengths = %+v; want %+v", l, want)
+	}
+
+	close(store.gate)
+	waitFor(t, func() bool { return !cache.Info("c").PendingPersist })
+	cache.FlushMemory(ctx)
+	l, err = cache.Lengths(ctx)
+	if err != nil {
+		t.Fatalf("Lengths: %v", err)
+	}
+	if want := (Lengths{Persisted: 2, Total: 2}); l != want {
+		t.Errorf("Lengths after FlushMemory = %+v; want %+v", l, want)
+	}
+}
+
+func TestTieredCache_AsyncWait(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+
+	cache, err := NewTiered[string, int](store, AsyncWait(time.Second))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	if err := cache.SetAsync(ctx, "key1", 1); err != nil {
+		t.Fatalf("SetAsync: %v", err)
+	}
+	// A fast write has landed by the time SetAsync returns.
+	if val, _, found, err := store.Get(ctx, "key1"); err != nil || !found || val != 1 {
+		t.Errorf("store.Get(key1) = %d, %v, %v; want 1 persisted", val, found, err)
+	}
+}
+
+func TestTieredCache_AsyncWait_Bounded(t *testing.T) {
+	ctx := context.Background()
+	store := &gatedMockStore[string, int]{mockStore: newMockStore[string, int](), gate: make(chan struct{})}
+
+	cache, err := NewTiered[string, int](store, AsyncWait(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	start := time.Now()
+	if err := cache.SetAsync(ctx, "key1", 1); err != nil {
+		t.Fatalf("SetAsync: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond || elapsed > time.Second {
+		t.Errorf("SetAsync took %v; want about the 20ms wait bound", elapsed)
+	}
+
+	// A cancelled request context stops the wait immediately.
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	start = time.Now()
+	if err := cache.SetAsync(cctx, "key2", 2); err != nil {
+		t.Fatalf("SetAsync: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 15*time.Millisecond {
+		t.Errorf("SetAsync with cancelled ctx took %v; want immediate return", elapsed)
+	}
+
+	close(store.gate)
+	waitFor(t, func() bool {
+		_, _, found, _ := store.mockStore.Get(ctx, "key2") //nolint:errcheck // polling
+		return found
+	})
+}
+
+// valueCheckingMockStore rejects negative values via ValueValidator.
+type valueCheckingMockStore[K comparable] struct {
+	*mockStore[K, int]
+}
+
+func (*valueCheckingMockStore[K]) ValidateValue(v int) error {
+	if v < 0 {
+		return fmt.Errorf("negative value %d", v)
+	}
+	return nil
+}
+
+func TestTieredCache_ValueValidator(t *testing.T) {
+	ctx := context.Background()
+	store := &valueCheckingMockStore[string]{mockStore: newMockStore[string, int]()}
+
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	if err := cache.Set(ctx, "ok", 1); err != nil {
+		t.Errorf("Set(ok): %v", err)
+	}
+	if err := cache.Set(ctx, "bad", -1); err == nil {
+		t.Error("Set(bad) should fail validation")
+	}
+	// SetAsync surfaces the rejection to the caller rather than logging it.
+	if err := cache.SetAsync(ctx, "bad", -1); err == nil {
+		t.Error("SetAsync(bad) should fail validation before dispatch")
+	}
+	if _, found, _ := cache.Get(ctx, "bad"); found { //nolint:errcheck // checked via found
+		t.Error("rejected value should not be cached")
+	}
+}
+
+func TestTieredCache_GetWithInfo(t *testing.T) {
+	ctx := context.Background()
+	inner := newMockStore[string, int]()
+	store := &gatedMockStore[string, int]{mockStore: inner, gate: make(chan struct{})}
+	close(store.gate)
+	victim := newMockStore[string, int]()
+
+	cache, err := NewTiered[string, int](store, ReadYourWrites(), Victim[string, int](victim, time.Minute))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	check := func(key string, wantVal int, want Tier) {
+		t.Helper()
+		val, tier, err := cache.GetWithInfo(ctx, key)
+		if err != nil || tier != want || val != wantVal {
+			t.Errorf("GetWithInfo(%s) = %d, %v, %v; want %d, %v", key, val, tier, err, wantVal, want)
+		}
+	}
+
+	check("missing", 0, TierMiss)
+
+	_ = inner.Set(ctx, "persisted", 1, time.Time{}) //nolint:errcheck // Test fixture
+	check("persisted", 1, TierStore)
+	check("persisted", 1, TierMemory)
+
+	cache.victim.spill("spilled", 2, 0)
+	waitFor(t, func() bool { _, ok := cache.victim.spilled.Load("spilled"); return ok })
+	check("spilled", 2, TierVictim)
+
+	store.gate = make(chan struct{})
+	if err := cache.SetAsync(ctx, "async", 3); err != nil {
+		t.Fatalf("SetAsync: %v", err)
+	}
+	cache.memory.del("async")
+	check("async", 3, TierPending)
+	close(store.gate)
+}
+
+func TestTieredCache_GetWithInfo_DeathRow(t *testing.T) {
+	ctx := context.Background()
+	cache, err := NewTiered[int, int](newMockStore[int, int](), Size(100))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	cache.memory.set(1, 10, 0)
+	cache.memory.get(1) // raise peak frequency for death row admission
+
+	// Evict the entry onto death row.
+	m := cache.memory
+	ent, _ := m.getEntry(1)
+	m.mu.Lock()
+	if ent.inSmall() {
+		m.small.remove(ent)
+	} else {
+		m.main.remove(ent)
+	}
+	m.sendToDeathRow(ent)
+	m.mu.Unlock()
+	if !ent.onDeathRow() {
+		t.Fatal("entry not admitted to death row")
+	}
+
+	val, tier, err := cache.GetWithInfo(ctx, 1)
+	if err != nil || tier != TierDeathRow || val != 10 {
+		t.Fatalf("GetWithInfo = %d, %v, %v; want 10, deathrow", val, tier, err)
+	}
+	if _, tier, _ := cache.GetWithInfo(ctx, 1); tier != TierMemory {
+		t.Errorf("second GetWithInfo tier = %v; want memory", tier)
+	}
+}
+
+func TestTier_String(t *testing.T) {
+	if TierDeathRow.String() != "deathrow" || TierStore.String() != "store" || Tier(99).String() != "Tier(99)" {
+		t.Errorf("Tier strings = %v, %v, %v", TierDeathRow, TierStore, Tier(99))
+	}
+}
+
+func TestTieredCache_CopyOnRead(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, []int]()
+	cache, err := NewTiered[string, []int](store, CopyOnRead(func(v []int) []int {
+		return append([]int(nil), v...)
+	}))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	mutate := func(name string, v []int, err error) {
+		t.Helper()
+		if err != nil || len(v) != 2 {
+			t.Fatalf("%s = %v, %v; want 2 elements", name, v, err)
+		}
+		v[0] = 99
+		if got, _ := cache.memory.get("a"); got[0] != 1 {
+			t.Errorf("mutating %s result changed the cached value to %v", name, got)
+		}
+	}
+
+	// Loaded from the store and cached in memory.
+	_ = store.Set(ctx, "a", []int{1, 2}, time.Time{}) //nolint:errcheck // Test fixture
+	v, _, err := cache.Get(ctx, "a")
+	mutate("Get from store", v, err)
+	v, _, err = cache.Get(ctx, "a")
+	mutate("Get", v, err)
+	v, _, err = cache.GetWithInfo(ctx, "a")
+	mutate("GetWithInfo", v, err)
+	v, _, err = cache.GetOrSet(ctx, "a", nil)
+	mutate("GetOrSet", v, err)
+	v, err = cache.Fetch(ctx, "a", func(context.Context) ([]int, error) { return nil, nil }) //nolint:nilnil // never called
+	mutate("Fetch", v, err)
+	for k, v := range cache.Range() {
+		mutate("Range "+k, v, nil)
+	}
+}
+
+func TestTieredCache_GetOrSet(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	v, loaded, err := cache.GetOrSet(ctx, "a", 1)
+	if err != nil || loaded || v != 1 {
+		t.Errorf("GetOrSet(a, 1) = %d, %v, %v; want 1, false, nil", v, loaded, err)
+	}
+	if pv, _, found, _ := store.Get(ctx, "a"); !found || pv != 1 { //nolint:errcheck // mock
+		t.Errorf("store has %d, %v; want 1, true", pv, found)
+	}
+	v, loaded, err = cache.GetOrSet(ctx, "a", 2)
+	if err != nil || !loaded || v != 1 {
+		t.Errorf("GetOrSet(a, 2) = %d, %v, %v; want 1, true, nil", v, loaded, err)
+	}
+
+	// A value only in persistence wins over the new one.
+	_ = store.Set(ctx, "b", 10, time.Time{}) //nolint:errcheck // Test fixture
+	v, loaded, err = cache.GetOrSetTTL(ctx, "b", 20, time.Hour)
+	if err != nil || !loaded || v != 10 {
+		t.Errorf("GetOrSetTTL(b) = %d, %v, %v; want 10, true, nil", v, loaded, err)
+	}
+	if v, ok := cache.memory.get("b"); !ok || v != 10 {
+		t.Errorf("memory has %d, %v; want 10, true", v, ok)
+	}
+}
+
+func TestTieredCache_GetOrSet_Errors(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	store.setFailGet(true)
+	if _, _, err := cache.GetOrSet(ctx, "a", 1); err == nil {
+		t.Error("GetOrSet should fail when persistence load fails")
+	}
+	if _, ok := cache.memory.get("a"); ok {
+		t.Error("value should not be claimed when persistence load fails")
+	}
+	store.setFailGet(false)
+
+	store.setFailSet(true)
+	v, loaded, err := cache.GetOrSet(ctx, "a", 1)
+	if err == nil {
+		t.Error("GetOrSet should report persistence store failure")
+	}
+	if loaded || v != 1 {
+		t.Errorf("GetOrSet = %d, %v; want 1, false", v, loaded)
+	}
+	// Like SetTTL, the memory tier keeps the value.
+	if v, ok := cache.memory.get("a"); !ok || v != 1 {
+		t.Errorf("memory has %d, %v; want 1, true", v, ok)
+	}
+}
+
+func TestTieredCache_Swap(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	old, existed, err := cache.Swap(ctx, "a", 1)
+	if err != nil || existed {
+		t.Errorf("Swap(a, 1) = %d, %v, %v; want 0, false, nil", old, existed, err)
+	}
+	old, existed, err = cache.Swap(ctx, "a", 2)
+	if err != nil || !existed || old != 1 {
+		t.Errorf("Swap(a, 2) = %d, %v, %v; want 1, true, nil", old, existed, err)
+	}
+
+	// The replaced value may live only in persistence.
+	_ = store.Set(ctx, "b", 10, time.Time{}) //nolint:errcheck // Test fixture
+	old, existed, err = cache.SwapTTL(ctx, "b", 20, time.Hour)
+	if err != nil || !existed || old != 10 {
+		t.Errorf("SwapTTL(b) = %d, %v, %v; want 10, true, nil", old, existed, err)
+	}
+	if pv, _, _, _ := store.Get(ctx, "b"); pv != 20 { //nolint:errcheck // mock
+		t.Errorf("store has %d; want 20", pv)
+	}
+
+	store.setFailSet(true)
+	if _, _, err := cache.Swap(ctx, "a", 3); err == nil {
+		t.Error("Swap should report persistence store failure")
+	}
+}
+
+func TestTieredCache_GetAndDelete(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	if err := cache.Set(ctx, "a", 1); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	_ = store.Set(ctx, "b", 2, time.Time{}) //nolint:errcheck // Test fixture
+
+	for key, want := range map[string]int{"a": 1, "b": 2} {
+		v, found, err := cache.GetAndDelete(ctx, key)
+		if err != nil || !found || v != want {
+			t.Errorf("GetAndDelete(%s) = %d, %v, %v; want %d, true, nil", key, v, found, err, want)
+		}
+		if _, _, found, _ := store.Get(ctx, key); found { //nolint:errcheck // mock
+			t.Errorf("%s still persisted", key)
+		}
+		if _, found, _ := cache.Get(ctx, key); found { //nolint:errcheck // checked above
+			t.Errorf("%s still cached", key)
+		}
+	}
+
+	store.setFailGet(true)
+	if _, _, err := cache.GetAndDelete(ctx, "c"); err == nil {
+		t.Error("GetAndDelete should report persistence load failure")
+	}
+}
+
+// scanMockStore is a mockStore that implements PrefixScanner.
+type scanMockStore[V any] struct {
+	*mockStore[string, V]
+}
+
+func (m scanMockStore[V]) Keys(ctx context.Context, prefix string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for k := range m.Range(ctx, prefix) {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+func (m scanMockStore[V]) Range(_ context.Context, prefix string) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		m.mu.RLock()
+		snap := maps.Clone(m.data)
+		m.mu.RUnlock()
+		for k, e := range snap {
+			if strings.HasPrefix(k, prefix) && !yield(k, e.value) {
+				return
+			}
+		}
+	}
+}
+
+func TestTieredCache_DeleteFunc(t *testing.T) {
+	ctx := context.Background()
+	store := scanMockStore[int]{newMockStore[string, int]()}
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	for i := range 10 {
+		if err := cache.Set(ctx, fmt.Sprintf("mem%d", i), i); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+		_ = store.Set(ctx, fmt.Sprintf("disk%d", i), i, time.Time{}) //nolint:errcheck // Test fixture
+	}
+
+	even := func(_ string, v int) bool { return v%2 == 0 }
+	n, err := cache.DeleteFunc(ctx, even)
+	if err != nil {
+		t.Fatalf("DeleteFunc: %v", err)
+	}
+	if n != 10 {
+		t.Errorf("DeleteFunc = %d; want 10", n)
+	}
+	for i := range 10 {
+		for _, k := range []string{fmt.Sprintf("mem%d", i), fmt.Sprintf("disk%d", i)} {
+			_, found, err := cache.Get(ctx, k)
+			if err != nil {
+				t.Fatalf("Get(%s): %v", k, err)
+			}
+			if found == even(k, i) {
+				t.Errorf("Get(%s) found = %v; want %v", k, found, !even(k, i))
+			}
+		}
+	}
+}
+
+func TestTieredCache_DeleteFunc_NoScanner(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	if err := cache.Set(ctx, "mem", 1); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	_ = store.Set(ctx, "disk", 1, time.Time{}) //nolint:errcheck // Test fixture
+
+	n, err := cache.DeleteFunc(ctx, func(string, int) bool { return true })
+	if err != nil || n != 1 {
+		t.Errorf("DeleteFunc = %d, %v; want 1, nil", n, err)
+	}
+	// Memory matches are deleted from persistence too.
+	if _, _, found, _ := store.Get(ctx, "mem"); found { //nolint:errcheck // only checking presence
+		t.Error("mem should be deleted from persistence")
+	}
+	// Without PrefixScanner, persistence-only entries can't be found.
+	if _, _, found, _ := store.Get(ctx, "disk"); !found { //nolint:errcheck // only checking presence
+		t.Error("disk should remain in persistence")
+	}
+
+	if err := cache.Set(ctx, "mem", 1); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	store.setFailSet(true) // also fails Delete
+	if _, err := cache.DeleteFunc(ctx, func(string, int) bool { return true }); err == nil {
+		t.Error("DeleteFunc should report persistence delete failure")
+	}
+}
+
+// fallbackStore is a mockStore holding JSON values that implements FallbackDecoder.
+type fallbackStore struct {
+	*mockStore[string, int]
+	raw      map[string][]byte
+	fallback func([]byte) (int, error)
+}
+
+func (s *fallbackStore) SetDecodeFallback(fn func([]byte) (int, error)) {
+	s.fallback = fn
+}
+
+//nolint:revive // function-result-limit: implements Store
+func (s *fallbackStore) Get(ctx context.Context, key string) (int, time.Time, bool, error) {
+	data, ok := s.raw[key]
+	if !ok {
+		return s.mockStore.Get(ctx, key)
+	}
+	var v int
+	if err := json.Unmarshal(data, &v); err != nil {
+		if s.fallback == nil {
+			return 0, time.Time{}, false, err
+		}
+		if v, err = s.fallback(data); err != nil {
+			return 0, time.Time{}, false, err
+		}
+	}
+	return v, time.Time{}, true, nil
+}
+
+func TestTieredCache_DecodeFallback(t *testing.T) {
+	ctx := context.Background()
+	store := &fallbackStore{
+		mockStore: newMockStore[string, int](),
+		raw:       map[string][]byte{"new": []byte(`7`), "old": []byte(`"42"`), "bad": []byte(`"x"`)},
+	}
+	// Values were once stored as strings.
+	cache, err := NewTiered[string, int](store, DecodeFallback(func(raw []byte) (int, error) {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return 0, err
+		}
+		return strconv.Atoi(s)
+	}))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+	if !cache.Config().DecodeFallback {
+		t.Error("Config().DecodeFallback = false; want true")
+	}
+
+	for key, want := range map[string]int{"new": 7, "old": 42} {
+		if v, found, err := cache.Get(ctx, key); err != nil || !found || v != want {
+			t.Errorf("Get(%q) = %d, %v, %v; want %d, true, nil", key, v, found, err, want)
+		}
+	}
+	if _, _, err := cache.Get(ctx, "bad"); err == nil {
+		t.Error("Get(bad) should fail when the fallback does")
+	}
+}
+
+// corruptErr is a store error for an undecodable entry.
+type corruptErr struct{}
+
+func (corruptErr) Error() string { return "decode file: unexpected EOF" }
+func (corruptErr) Corrupt() bool { return true }
+
+// corruptStore reports its entries corrupt while corrupt is set, calling
+// onGet first to let a test race a write against the read.
+type corruptStore struct {
+	*mockStore[string, int]
+	corrupt bool
+	onGet   func()
+}
+
+func (s *corruptStore) Get(ctx context.Context, key string) (int, time.Time, bool, error) {
+	if s.onGet != nil {
+		s.onGet()
+	}
+	if s.corrupt {
+		return 0, time.Time{}, false, fmt.Errorf("read %s: %w", key, corruptErr{})
+	}
+	return s.mockStore.Get(ctx, key)
+}
+
+func TestTieredCache_ReadRepair(t *testing.T) {
+	ctx := context.Background()
+	store := &corruptStore{mockStore: newMockStore[string, int](), corrupt: true}
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	// Without a memory copy there is nothing to repair from.
+	if _, _, err := cache.Get(ctx, "a"); err == nil {
+		t.Fatal("Get of corrupt entry succeeded without a memory copy")
+	}
+
+	// A write landing during the read leaves a copy to repair from.
+	store.onGet = func() { _ = cache.SetMemoryOnly(ctx, "a", 7, 0) } //nolint:errcheck // not immutable
+	v, found, err := cache.Get(ctx, "a")
+	if err != nil || !found || v != 7 {
+		t.Fatalf("Get = %d, %v, %v; want 7, true, nil", v, found, err)
+	}
+	if cache.ReadRepairs() != 1 {
+		t.Errorf("ReadRepairs = %d; want 1", cache.ReadRepairs())
+	}
+	store.corrupt, store.onGet = false, nil
+	if v, _, found, _ := store.Get(ctx, "a"); !found || v != 7 { //nolint:errcheck // mock
+		t.Errorf("persisted a = %d, %v; want the repaired 7", v, found)
+	}
+
+	// Other errors are not repaired.
+	cache.FlushMemory(ctx)
+	store.setFailGet(true)
+	store.onGet = func() { _ = cache.SetMemoryOnly(ctx, "a", 8, 0) } //nolint:errcheck // not immutable
+	if _, _, err := cache.Get(ctx, "a"); err == nil {
+		t.Error("Get succeeded despite a non-corruption store error")
+	}
+	if cache.ReadRepairs() != 1 {
+		t.Errorf("ReadRepairs = %d after a failed read; want 1", cache.ReadRepairs())
+	}
+}
@@ -0,0 +1,81 @@
+// Package authcache caches the credentials services fetch over and over:
+// OAuth 2.0 client-credentials access tokens and the JSON Web Key Sets used to
+// verify JWTs. Each is fetched once for all concurrent callers, refreshed
+// before it expires, and kept usable while its issuer is briefly unreachable.
+//
+//	tokens := authcache.NewTokens(authcache.ClientCredentials{
+//		TokenURL: "https://auth.example.com/oauth/token",
+//		ClientID: id, ClientSecret: secret,
+//	})
+//	tok, err := tokens.Token(ctx, "orders:read")
+//	req.Header.Set("Authorization", "Bearer "+tok.AccessToken)
+//
+//	keys := authcache.NewJWKS(authcache.JWKSConfig{URL: "https://auth.example.com/.well-known/jwks.json"})
+//	pub, err := keys.Key(ctx, kid) // kid from the JWT header
+package authcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ErrKeyNotFound is returned by JWKS.Key when the set has no key with the ID.
+var ErrKeyNotFound = errors.New("key not found")
+
+// group runs one fetch per key at a time, sharing its result with callers
+// that arrive while it runs.
+type group[V any] struct {
+	mu      sync.Mutex
+	flights map[string]*flight[V]
+}
+
+type flight[V any] struct {
+	done chan struct{}
+	val  V
+	err  error
+}
+
+// do returns fn's result, calling it unless a call for key is in progress.
+// A caller whose ctx ends stops waiting; the call carries on for the others.
+func (g *group[V]) do(ctx context.Context, key string, fn func() (V, error)) (V, error) {
+	g.mu.Lock()
+	if g.flights == nil {
+		g.flights = make(map[string]*flight[V])
+	}
+	if f, ok := g.flights[key]; ok {
+		g.mu.Unlock()
+		select {
+		case <-f.done:
+			return f.val, f.err
+		case <-ctx.Done():
+			var zero V
+			return zero, ctx.Err()
+		}
+	}
+	f := &flight[V]{done: make(chan struct{})}
+	g.flights[key] = f
+	g.mu.Unlock()
+
+	f.val, f.err = fn()
+	g.mu.Lock()
+	delete(g.flights, key)
+	g.mu.Unlock()
+	close(f.done)
+	return f.val, f.err
+}
+
+// statusError describes an unsuccessful HTTP response, including the start
+// of its body, where OAuth servers explain what was wrong.
+func statusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512)) //nolint:errcheck // best effort
+	msg := strings.TrimSpace(string(body))
+	if msg == "" {
+		return fmt.Errorf("%s %s: %s", resp.Request.Method, resp.Request.URL.Redacted(), resp.Status)
+	}
+	return fmt.Errorf("%s %s: %s: %s", resp.Request.Method, resp.Request.URL.Redacted(), resp.Status, msg)
+}
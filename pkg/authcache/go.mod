@@ -0,0 +1,9 @@
+module github.com/codeGROOVE-dev/fido/pkg/authcache
+
+go 1.25.4
+
+require github.com/codeGROOVE-dev/fido v1.10.0
+
+require github.com/puzpuzpuz/xsync/v4 v4.3.0 // indirect
+
+replace github.com/codeGROOVE-dev/fido => ../..
@@ -0,0 +1,234 @@
+package authcache
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codeGROOVE-dev/fido"
+)
+
+// JWKSConfig configures a JWKS. Zero values select the defaults.
+type JWKSConfig struct {
+	// URL is the JSON Web Key Set document, e.g. the jwks_uri from an
+	// issuer's OpenID configuration. Required.
+	URL string
+	// HTTPClient fetches the set. Default http.DefaultClient.
+	HTTPClient *http.Client
+	// TTL is how long the set is cached when its response has no
+	// Cache-Control max-age. Default 1h.
+	TTL time.Duration
+	// MinRefresh is the least time between fetches prompted by an unknown
+	// key ID, so tokens with made-up IDs can't make every request refetch.
+	// Default 1m.
+	MinRefresh time.Duration
+}
+
+// JWKS fetches and caches a JSON Web Key Set, refetching it when it expires
+// or a token names a key it lacks, as after the issuer rotates keys. RSA,
+// EC (P-256, P-384, P-521), and Ed25519 keys are parsed; others are ignored.
+// It is safe for concurrent use.
+type JWKS struct {
+	cache   *fido.Cache[string, *keySet]
+	flights group[*keySet]
+	cfg     JWKSConfig
+	now     func() time.Time
+}
+
+type keySet struct {
+	fetched time.Time
+	expires time.Time
+	keys    map[string]crypto.PublicKey
+}
+
+// NewJWKS returns a JWKS fetching from cfg.URL.
+func NewJWKS(cfg JWKSConfig) *JWKS {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = time.Hour
+	}
+	if cfg.MinRefresh <= 0 {
+		cfg.MinRefresh = time.Minute
+	}
+	return &JWKS{
+		cache: fido.New[string, *keySet](fido.Size(1)),
+		cfg:   cfg,
+		now:   time.Now,
+	}
+}
+
+// Key returns the public key with ID kid: an *rsa.PublicKey,
+// *ecdsa.PublicKey, or ed25519.PublicKey. It returns ErrKeyNotFound if the
+// set has no such key even after refetching. If the set cannot be fetched
+// once it expires, the expired set is used.
+func (j *JWKS) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	ks, ok := j.cache.Get(j.cfg.URL)
+	now := j.now()
+	if ok && now.Before(ks.expires) {
+		if k, found := ks.keys[kid]; found {
+			return k, nil
+		}
+		if now.Sub(ks.fetched) < j.cfg.MinRefresh {
+			return nil, fmt.Errorf("%w: %q", ErrKeyNotFound, kid)
+		}
+	}
+
+	fresh, err := j.flights.do(ctx, j.cfg.URL, func() (*keySet, error) {
+		return j.fetch(ctx)
+	})
+	if err != nil {
+		if !ok {
+			return nil, err
+		}
+		fresh = ks
+	}
+	if k, found := fresh.keys[kid]; found {
+		return k, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrKeyNotFound, kid)
+}
+
+// jwk is a JSON Web Key (RFC 7517), with the members of the supported types.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetch downloads and parses the set and caches it.
+func (j *JWKS) fetch(ctx context.Context) (*keySet, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.cfg.URL, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := j.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck // read-only body
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decode JWKS: %w", err)
+	}
+
+	ks := &keySet{fetched: j.now(), keys: make(map[string]crypto.PublicKey, len(doc.Keys))}
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		switch {
+		case err == nil:
+			ks.keys[k.Kid] = pub
+		case !errors.Is(err, errUnsupported):
+			// One bad key must not stop tokens signed with the others verifying.
+			slog.Warn("authcache: skipping invalid JWKS key", "url", j.cfg.URL, "kid", k.Kid, "error", err)
+		}
+	}
+	ttl := maxAge(resp.Header.Get("Cache-Control"), j.cfg.TTL)
+	ks.expires = ks.fetched.Add(ttl)
+	j.cache.Set(j.cfg.URL, ks) // kept past expiry, for use while refetches fail
+	return ks, nil
+}
+
+// maxAge returns the max-age in a Cache-Control header, or def without one.
+func maxAge(cacheControl string, def time.Duration) time.Duration {
+	for directive := range strings.SplitSeq(cacheControl, ",") {
+		v, ok := strings.CutPrefix(strings.TrimSpace(directive), "max-age=")
+		if !ok {
+			continue
+		}
+		if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+			return time.Duration(secs) * time.Second
+		}
+	}
+	return def
+}
+
+// errUnsupported reports a key type or curve JWKS doesn't parse.
+var errUnsupported = errors.New("unsupported key type")
+
+// publicKey parses k.
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	dec := base64.RawURLEncoding.DecodeString
+	switch k.Kty {
+	case "RSA":
+		n, err := dec(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("modulus: %w", err)
+		}
+		e, err := dec(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("exponent: %w", err)
+		}
+		exp := new(big.Int).SetBytes(e)
+		if len(n) == 0 || !exp.IsInt64() || exp.Int64() < 3 || exp.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errUnsupported
+		}
+		x, err := dec(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := dec(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, errors.New("invalid EC coordinates")
+		}
+		return ecdsa.ParseUncompressedPublicKey(curve, append(append([]byte{4}, x...), y...))
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, errUnsupported
+		}
+		x, err := dec(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, errUnsupported
+}
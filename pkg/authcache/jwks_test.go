@@ -0,0 +1,182 @@
+package authcache
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// jwksServer serves a key set that tests can rotate.
+type jwksServer struct {
+	*httptest.Server
+	fetches      atomic.Int64
+	fail         atomic.Bool
+	cacheControl string
+
+	mu   sync.Mutex
+	keys []map[string]string
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	t.Helper()
+	js := &jwksServer{}
+	js.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		js.fetches.Add(1)
+		if js.fail.Load() {
+			http.Error(w, "down", http.StatusBadGateway)
+			return
+		}
+		js.mu.Lock()
+		defer js.mu.Unlock()
+		if js.cacheControl != "" {
+			w.Header().Set("Cache-Control", js.cacheControl)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"keys": js.keys}) //nolint:errcheck // test server
+	}))
+	t.Cleanup(js.Close)
+	return js
+}
+
+func (js *jwksServer) add(k map[string]string) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	js.keys = append(js.keys, k)
+}
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+func newJWKS(js *jwksServer, cfg JWKSConfig) (keys *JWKS, tick func(time.Duration)) {
+	cfg.URL = js.URL
+	keys = NewJWKS(cfg)
+	var mu sync.Mutex
+	now := time.Now()
+	keys.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	return keys, func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+}
+
+func TestJWKS_KeyTypes(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecBytes, err := ecKey.PublicKey.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	js := newJWKSServer(t)
+	js.add(map[string]string{"kty": "RSA", "kid": "rsa", "use": "sig",
+		"n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())})
+	js.add(map[string]string{"kty": "EC", "kid": "ec", "crv": "P-384",
+		"x": b64(ecBytes[1:49]), "y": b64(ecBytes[49:])})
+	js.add(map[string]string{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": b64(edPub)})
+	js.add(map[string]string{"kty": "RSA", "kid": "enc", "use": "enc",
+		"n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())})
+	js.add(map[string]string{"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"})
+	js.add(map[string]string{"kty": "EC", "kid": "short", "crv": "P-256", "x": "AQ", "y": "AQ"})
+	keys, _ := newJWKS(js, JWKSConfig{})
+	ctx := context.Background()
+
+	if k, err := keys.Key(ctx, "rsa"); err != nil || !rsaKey.PublicKey.Equal(k) {
+		t.Errorf("Key(rsa) = %v, %v; want the RSA key", k, err)
+	}
+	if k, err := keys.Key(ctx, "ec"); err != nil || !ecKey.PublicKey.Equal(k) {
+		t.Errorf("Key(ec) = %v, %v; want the P-384 key", k, err)
+	}
+	if k, err := keys.Key(ctx, "ed"); err != nil || !edPub.Equal(k) {
+		t.Errorf("Key(ed) = %v, %v; want the Ed25519 key", k, err)
+	}
+	for _, kid := range []string{"enc", "hmac", "short"} {
+		if _, err := keys.Key(ctx, kid); !errors.Is(err, ErrKeyNotFound) {
+			t.Errorf("Key(%s) = %v; want ErrKeyNotFound", kid, err)
+		}
+	}
+	if n := js.fetches.Load(); n != 1 {
+		t.Errorf("fetched %d times; want 1, with unknown kids rate-limited", n)
+	}
+}
+
+func TestJWKS_Rotation(t *testing.T) {
+	js := newJWKSServer(t)
+	_, ed1, _ := ed25519.GenerateKey(rand.Reader)
+	_, ed2, _ := ed25519.GenerateKey(rand.Reader)
+	js.add(map[string]string{"kty": "OKP", "kid": "1", "crv": "Ed25519", "x": b64(ed1.Public().(ed25519.PublicKey))})
+	keys, tick := newJWKS(js, JWKSConfig{MinRefresh: 30 * time.Second})
+	ctx := context.Background()
+
+	if _, err := keys.Key(ctx, "1"); err != nil {
+		t.Fatalf("Key(1): %v", err)
+	}
+	js.add(map[string]string{"kty": "OKP", "kid": "2", "crv": "Ed25519", "x": b64(ed2.Public().(ed25519.PublicKey))})
+	if _, err := keys.Key(ctx, "2"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Key(2) within MinRefresh = %v; want ErrKeyNotFound", err)
+	}
+	tick(31 * time.Second)
+	if _, err := keys.Key(ctx, "2"); err != nil {
+		t.Errorf("Key(2) after MinRefresh: %v; want the rotated-in key", err)
+	}
+	if n := js.fetches.Load(); n != 2 {
+		t.Errorf("fetched %d times; want 2", n)
+	}
+}
+
+func TestJWKS_Expiry(t *testing.T) {
+	js := newJWKSServer(t)
+	js.cacheControl = "public, max-age=120"
+	_, ed, _ := ed25519.GenerateKey(rand.Reader)
+	js.add(map[string]string{"kty": "OKP", "kid": "1", "crv": "Ed25519", "x": b64(ed.Public().(ed25519.PublicKey))})
+	keys, tick := newJWKS(js, JWKSConfig{})
+	ctx := context.Background()
+
+	if _, err := keys.Key(ctx, "1"); err != nil {
+		t.Fatalf("Key(1): %v", err)
+	}
+	tick(119 * time.Second)
+	keys.Key(ctx, "1") //nolint:errcheck // counting fetches
+	if n := js.fetches.Load(); n != 1 {
+		t.Errorf("fetched %d times within max-age; want 1", n)
+	}
+
+	tick(2 * time.Second)
+	js.fail.Store(true)
+	if _, err := keys.Key(ctx, "1"); err != nil {
+		t.Errorf("Key(1) with the server failing: %v; want the expired set", err)
+	}
+	if n := js.fetches.Load(); n != 2 {
+		t.Errorf("fetched %d times; want a refetch after max-age", n)
+	}
+
+	empty, _ := newJWKS(js, JWKSConfig{})
+	if _, err := empty.Key(ctx, "1"); err == nil || errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Key(1) with no set = %v; want the fetch error", err)
+	}
+}
@@ -0,0 +1,173 @@
+package authcache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/codeGROOVE-dev/fido"
+)
+
+// DefaultTokenLifetime is how long a token is used when its response has no expires_in.
+const DefaultTokenLifetime = time.Hour
+
+// ClientCredentials configures Tokens. Zero values select the defaults.
+type ClientCredentials struct {
+	// TokenURL is the authorization server's token endpoint. Required.
+	TokenURL string
+	// ClientID and ClientSecret authenticate the client with HTTP Basic auth.
+	ClientID     string
+	ClientSecret string
+	// Scopes are requested when Token is called without any.
+	Scopes []string
+	// Params are added to each token request, e.g. audience or resource.
+	Params url.Values
+	// HTTPClient sends token requests. Default http.DefaultClient.
+	HTTPClient *http.Client
+	// EarlyRefresh is how long before expiry a token is replaced. Default 1m,
+	// and at most half the token's lifetime.
+	EarlyRefresh time.Duration
+}
+
+// Token is an OAuth 2.0 access token.
+type Token struct {
+	Expiry      time.Time
+	AccessToken string
+	TokenType   string // usually "Bearer"
+	Scope       string // as granted, if the server reported it
+}
+
+// Tokens fetches and caches client-credentials access tokens, one per set of
+// scopes. It is safe for concurrent use.
+type Tokens struct {
+	cache   *fido.Cache[string, cachedToken]
+	flights group[cachedToken]
+	cfg     ClientCredentials
+	now     func() time.Time
+}
+
+type cachedToken struct {
+	tok       Token
+	refreshAt time.Time
+}
+
+// NewTokens returns a Tokens fetching from cfg.TokenURL.
+func NewTokens(cfg ClientCredentials) *Tokens {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	if cfg.EarlyRefresh <= 0 {
+		cfg.EarlyRefresh = time.Minute
+	}
+	return &Tokens{
+		cache: fido.New[string, cachedToken](fido.Size(64)),
+		cfg:   cfg,
+		now:   time.Now,
+	}
+}
+
+// Token returns an access token for scopes, or for the configured Scopes if
+// none are given. A cached token is returned until EarlyRefresh before it
+// expires; then one caller fetches a new token while others wait for it. If
+// that fetch fails, the old token is returned for as long as it is valid.
+func (t *Tokens) Token(ctx context.Context, scopes ...string) (Token, error) {
+	if len(scopes) == 0 {
+		scopes = t.cfg.Scopes
+	}
+	scopes = slices.Sorted(slices.Values(scopes))
+	key := strings.Join(scopes, " ")
+
+	old, ok := t.cache.Get(key)
+	if ok && t.now().Before(old.refreshAt) {
+		return old.tok, nil
+	}
+	ct, err := t.flights.do(ctx, key, func() (cachedToken, error) {
+		return t.fetch(ctx, key)
+	})
+	if err != nil {
+		if ok && t.now().Before(old.tok.Expiry) {
+			return old.tok, nil
+		}
+		return Token{}, err
+	}
+	return ct.tok, nil
+}
+
+// Forget drops the cached token for scopes, e.g. after a resource server
+// rejected it as revoked.
+func (t *Tokens) Forget(scopes ...string) {
+	if len(scopes) == 0 {
+		scopes = t.cfg.Scopes
+	}
+	t.cache.Delete(strings.Join(slices.Sorted(slices.Values(scopes)), " "))
+}
+
+// tokenResponse is a token endpoint's successful response (RFC 6749 section 5.1).
+type tokenResponse struct {
+	AccessToken string      `json:"access_token"`
+	TokenType   string      `json:"token_type"`
+	Scope       string      `json:"scope"`
+	ExpiresIn   json.Number `json:"expires_in"` // some servers send a string
+}
+
+// fetch requests a token for the space-separated scope and caches it.
+func (t *Tokens) fetch(ctx context.Context, scope string) (cachedToken, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	for k, v := range t.cfg.Params {
+		form[k] = v
+	}
+	if scope != "" {
+		form.Set("scope", scope)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return cachedToken{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(t.cfg.ClientID), url.QueryEscape(t.cfg.ClientSecret))
+
+	start := t.now()
+	resp, err := t.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return cachedToken{}, err
+	}
+	defer resp.Body.Close() //nolint:errcheck // read-only body
+	if resp.StatusCode != http.StatusOK {
+		return cachedToken{}, statusError(resp)
+	}
+	var tr tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return cachedToken{}, fmt.Errorf("decode token response: %w", err)
+	}
+	if tr.AccessToken == "" {
+		return cachedToken{}, fmt.Errorf("token response from %s has no access_token", t.cfg.TokenURL)
+	}
+
+	lifetime := DefaultTokenLifetime
+	if tr.ExpiresIn != "" {
+		secs, err := tr.ExpiresIn.Int64()
+		if err != nil || secs <= 0 {
+			return cachedToken{}, fmt.Errorf("token response has invalid expires_in %q", tr.ExpiresIn)
+		}
+		lifetime = time.Duration(secs) * time.Second
+	}
+	// Lifetimes count from when the server issued the token, so count from
+	// before the request was sent.
+	ct := cachedToken{
+		tok: Token{
+			AccessToken: tr.AccessToken,
+			TokenType:   tr.TokenType,
+			Scope:       tr.Scope,
+			Expiry:      start.Add(lifetime),
+		},
+		refreshAt: start.Add(lifetime - min(t.cfg.EarlyRefresh, lifetime/2)),
+	}
+	t.cache.SetTTL(scope, ct, lifetime)
+	return ct, nil
+}
@@ -0,0 +1,168 @@
+package authcache
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tokenServer is a client-credentials token endpoint issuing numbered tokens.
+type tokenServer struct {
+	*httptest.Server
+	issued    atomic.Int64
+	expiresIn atomic.Value // JSON literal for expires_in
+	fail      atomic.Bool
+	gate      chan struct{} // if set, requests wait for it to close
+}
+
+func newTokenServer(t *testing.T) *tokenServer {
+	t.Helper()
+	ts := &tokenServer{}
+	ts.expiresIn.Store(`3600`)
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ts.gate != nil {
+			<-ts.gate
+		}
+		id, secret, _ := r.BasicAuth()
+		if r.FormValue("grant_type") != "client_credentials" || id != "client%3A1" || secret != "s3cret" {
+			http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+			return
+		}
+		if ts.fail.Load() {
+			http.Error(w, `{"error":"temporarily_unavailable"}`, http.StatusServiceUnavailable)
+			return
+		}
+		n := ts.issued.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"tok-%d-%s-%s","token_type":"Bearer","expires_in":%s}`,
+			n, r.FormValue("scope"), r.FormValue("audience"), ts.expiresIn.Load())
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// newTokens returns Tokens for ts whose clock advances only by tick.
+func newTokens(ts *tokenServer, cfg ClientCredentials) (tokens *Tokens, tick func(time.Duration)) {
+	cfg.TokenURL = ts.URL
+	cfg.ClientID, cfg.ClientSecret = "client:1", "s3cret"
+	tokens = NewTokens(cfg)
+	var mu sync.Mutex
+	now := time.Now()
+	tokens.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	return tokens, func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+}
+
+func token(t *testing.T, tokens *Tokens, scopes ...string) string {
+	t.Helper()
+	tok, err := tokens.Token(context.Background(), scopes...)
+	if err != nil {
+		t.Fatalf("Token(%v): %v", scopes, err)
+	}
+	return tok.AccessToken
+}
+
+func TestTokens(t *testing.T) {
+	ts := newTokenServer(t)
+	tokens, tick := newTokens(ts, ClientCredentials{Scopes: []string{"b", "a"}})
+
+	first := token(t, tokens)
+	if first != "tok-1-a b-" {
+		t.Errorf("Token() = %q; want the configured scopes, sorted", first)
+	}
+	if got := token(t, tokens, "a", "b"); got != first {
+		t.Errorf("Token(a, b) = %q; want the cached %q", got, first)
+	}
+	if got := token(t, tokens, "c"); got != "tok-2-c-" {
+		t.Errorf("Token(c) = %q; want a token of its own", got)
+	}
+
+	tick(58 * time.Minute)
+	if got := token(t, tokens); got != first {
+		t.Errorf("Token() before EarlyRefresh = %q; want cached %q", got, first)
+	}
+	tick(time.Minute + time.Second)
+	if got := token(t, tokens); got != "tok-3-a b-" {
+		t.Errorf("Token() within EarlyRefresh = %q; want a new token", got)
+	}
+}
+
+func TestTokens_ExpiresIn(t *testing.T) {
+	ts := newTokenServer(t)
+	ts.expiresIn.Store(`"60"`) // some servers send a string
+	tokens, tick := newTokens(ts, ClientCredentials{EarlyRefresh: 5 * time.Minute, Params: map[string][]string{"audience": {"api"}}})
+
+	tok, err := tokens.Token(context.Background())
+	if err != nil || tok.AccessToken != "tok-1--api" || tok.TokenType != "Bearer" {
+		t.Fatalf("Token() = %+v, %v; want tok-1 for audience api", tok, err)
+	}
+	// EarlyRefresh is capped at half the lifetime.
+	tick(29 * time.Second)
+	token(t, tokens)
+	tick(2 * time.Second)
+	token(t, tokens)
+	if n := ts.issued.Load(); n != 2 {
+		t.Errorf("issued %d tokens; want a refresh 30s into a 60s lifetime", n)
+	}
+
+	ts.expiresIn.Store(`0`)
+	tokens.Forget()
+	if _, err := tokens.Token(context.Background()); err == nil {
+		t.Error("Token() should reject expires_in 0")
+	}
+}
+
+func TestTokens_StaleOnError(t *testing.T) {
+	ts := newTokenServer(t)
+	tokens, tick := newTokens(ts, ClientCredentials{})
+	first := token(t, tokens)
+
+	ts.fail.Store(true)
+	tick(59*time.Minute + 30*time.Second)
+	if got := token(t, tokens); got != first {
+		t.Errorf("Token() with the server failing = %q; want the still-valid %q", got, first)
+	}
+	tick(time.Minute)
+	if _, err := tokens.Token(context.Background()); err == nil {
+		t.Error("Token() should fail once the old token has expired")
+	}
+}
+
+func TestTokens_Shared(t *testing.T) {
+	ts := newTokenServer(t)
+	ts.gate = make(chan struct{})
+	tokens, _ := newTokens(ts, ClientCredentials{})
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() { token(t, tokens) })
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(ts.gate)
+	wg.Wait()
+	if n := ts.issued.Load(); n != 1 {
+		t.Errorf("issued %d tokens for concurrent callers; want 1", n)
+	}
+}
+
+func TestTokens_Rejected(t *testing.T) {
+	ts := newTokenServer(t)
+	tokens := NewTokens(ClientCredentials{TokenURL: ts.URL, ClientID: "wrong"})
+	_, err := tokens.Token(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "invalid_client") {
+		t.Errorf("Token() = %v; want the server's invalid_client error", err)
+	}
+}
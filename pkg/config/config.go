@@ -0,0 +1,201 @@
+// Package config builds fido caches from YAML files and environment variables,
+// so one binary can run with different cache settings per deployment.
+//
+//	cfg, err := config.Load(os.Getenv("CACHE_CONFIG")) // file optional; FIDO_* env vars override
+//	cache, err := config.NewFromConfig[string, User](ctx, cfg)
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codeGROOVE-dev/fido"
+	"github.com/codeGROOVE-dev/fido/pkg/store/registry"
+	"go.yaml.in/yaml/v3"
+)
+
+// EnvPrefix prefixes the environment variables read by Load.
+const EnvPrefix = "FIDO_"
+
+// Config describes a TieredCache. Zero values keep fido's defaults.
+// Durations are written as Go durations ("90s", "24h").
+type Config struct {
+	// Name namespaces persisted entries, as the store's cacheID. Required with Store.
+	Name string `yaml:"name"`
+	// Store is the persistence backend URI, e.g. "valkey://cache:6379/0" (see
+	// pkg/store/registry), whose backend must be registered for the cache's key
+	// and value types. Default "null://": memory only.
+	Store string `yaml:"store"`
+
+	Size       int           `yaml:"size"`
+	TTL        time.Duration `yaml:"ttl"`
+	MemoryTTL  time.Duration `yaml:"memory_ttl"`
+	PersistTTL time.Duration `yaml:"persist_ttl"`
+
+	MaxValueBytes  int `yaml:"max_value_bytes"`
+	LargeThreshold int `yaml:"large_threshold"`
+	LargeMaxBytes  int `yaml:"large_max_bytes"`
+
+	AsyncWait             time.Duration `yaml:"async_wait"`
+	PersistConcurrency    int           `yaml:"persist_concurrency"`
+	BackgroundConcurrency int           `yaml:"background_concurrency"`
+	ReadYourWrites        bool          `yaml:"read_your_writes"`
+	SnapshotOnShutdown    bool          `yaml:"snapshot_on_shutdown"`
+	CleanupInterval       time.Duration `yaml:"cleanup_interval"`
+	CleanupMaxAge         time.Duration `yaml:"cleanup_max_age"`
+	TrackStats            bool          `yaml:"track_stats"`
+}
+
+// Load reads the YAML file at path, if path is non-empty, then applies FIDO_*
+// environment variables on top. Each field's variable is EnvPrefix plus its
+// upper-cased YAML key: FIDO_STORE, FIDO_MEMORY_TTL, FIDO_READ_YOUR_WRITES.
+func Load(path string) (Config, error) {
+	var cfg Config
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("read config: %w", err)
+		}
+		if cfg, err = Parse(data); err != nil {
+			return Config{}, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err := cfg.FromEnv(EnvPrefix); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// Parse decodes a YAML config. Unknown keys are rejected to catch typos.
+func Parse(data []byte) (Config, error) {
+	var cfg Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, fmt.Errorf("parse config: %w", err)
+	}
+	return cfg, nil
+}
+
+// FromEnv overrides fields with environment variables named prefix plus the
+// upper-cased YAML key. Unset variables leave fields unchanged.
+func (c *Config) FromEnv(prefix string) error {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := range t.NumField() {
+		name := prefix + strings.ToUpper(t.Field(i).Tag.Get("yaml"))
+		s, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setField(v.Field(i), s); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+func setField(f reflect.Value, s string) error {
+	switch {
+	case f.Type() == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		f.SetInt(int64(d))
+	case f.Kind() == reflect.String:
+		f.SetString(s)
+	case f.Kind() == reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		f.SetInt(int64(n))
+	case f.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported type %s", f.Type())
+	}
+	return nil
+}
+
+// Options returns the fido options described by c.
+func (c Config) Options() []fido.Option {
+	var opts []fido.Option
+	if c.Size > 0 {
+		opts = append(opts, fido.Size(c.Size))
+	}
+	if c.TTL > 0 {
+		opts = append(opts, fido.TTL(c.TTL))
+	}
+	if c.MemoryTTL > 0 {
+		opts = append(opts, fido.MemoryTTL(c.MemoryTTL))
+	}
+	if c.PersistTTL > 0 {
+		opts = append(opts, fido.PersistTTL(c.PersistTTL))
+	}
+	if c.MaxValueBytes > 0 {
+		opts = append(opts, fido.MaxValueBytes(c.MaxValueBytes))
+	}
+	if c.LargeThreshold > 0 {
+		opts = append(opts, fido.LargeObjects(c.LargeThreshold, c.LargeMaxBytes))
+	}
+	if c.AsyncWait > 0 {
+		opts = append(opts, fido.AsyncWait(c.AsyncWait))
+	}
+	if c.PersistConcurrency > 0 {
+		opts = append(opts, fido.PersistConcurrency(c.PersistConcurrency))
+	}
+	if c.BackgroundConcurrency > 0 {
+		opts = append(opts, fido.BackgroundConcurrency(c.BackgroundConcurrency))
+	}
+	if c.ReadYourWrites {
+		opts = append(opts, fido.ReadYourWrites())
+	}
+	if c.SnapshotOnShutdown {
+		opts = append(opts, fido.SnapshotOnShutdown())
+	}
+	if c.TrackStats {
+		opts = append(opts, fido.TrackStats())
+	}
+	if c.CleanupInterval > 0 {
+		opts = append(opts, fido.AutoCleanup(c.CleanupInterval, c.CleanupMaxAge))
+	}
+	return opts
+}
+
+// NewFromConfig opens cfg.Store and returns a TieredCache configured by cfg.
+// Options in opts are applied after cfg's, for settings that can't be expressed
+// in a file, such as Sizer. Other than mem:// and null://, the store's backend
+// must first be registered for K and V, e.g. with valkey.Register[K, V]().
+func NewFromConfig[K comparable, V any](ctx context.Context, cfg Config, opts ...fido.Option) (*fido.TieredCache[K, V], error) {
+	uri := cfg.Store
+	if uri == "" {
+		uri = "null://"
+	} else if cfg.Name == "" {
+		return nil, errors.New("config: name is required with store")
+	}
+	store, err := registry.Open[K, V](ctx, uri, cfg.Name)
+	if err != nil {
+		return nil, fmt.Errorf("open store: %w", err)
+	}
+	cache, err := fido.NewTiered[K, V](store, append(cfg.Options(), opts...)...)
+	if err != nil {
+		return nil, errors.Join(err, store.Close())
+	}
+	return cache, nil
+}
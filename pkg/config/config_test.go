@@ -0,0 +1,118 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codeGROOVE-dev/fido/pkg/store/localfs"
+)
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse([]byte(`
+name: myapp
+store: file:///tmp/x?compress=s2
+size: 1000
+ttl: 1h
+memory_ttl: 5m
+async_wait: 50ms
+persist_concurrency: 16
+background_concurrency: 4
+read_your_writes: true
+cleanup_interval: 1h
+cleanup_max_age: 24h
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := Config{
+		Name:                  "myapp",
+		Store:                 "file:///tmp/x?compress=s2",
+		Size:                  1000,
+		TTL:                   time.Hour,
+		MemoryTTL:             5 * time.Minute,
+		AsyncWait:             50 * time.Millisecond,
+		PersistConcurrency:    16,
+		BackgroundConcurrency: 4,
+		ReadYourWrites:        true,
+		CleanupInterval:       time.Hour,
+		CleanupMaxAge:         24 * time.Hour,
+	}
+	if cfg != want {
+		t.Errorf("Parse = %+v; want %+v", cfg, want)
+	}
+
+	if _, err := Parse([]byte("sise: 10\n")); err == nil {
+		t.Error("unknown key should fail")
+	}
+	if cfg, err := Parse(nil); err != nil || cfg != (Config{}) {
+		t.Errorf("Parse(empty) = %+v, %v; want zero config", cfg, err)
+	}
+}
+
+func TestLoad_EnvOverridesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.yaml")
+	if err := os.WriteFile(path, []byte("name: fromfile\nsize: 10\nttl: 1m\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FIDO_SIZE", "20")
+	t.Setenv("FIDO_PERSIST_TTL", "2h")
+	t.Setenv("FIDO_SNAPSHOT_ON_SHUTDOWN", "true")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Name != "fromfile" || cfg.TTL != time.Minute {
+		t.Errorf("file values lost: %+v", cfg)
+	}
+	if cfg.Size != 20 || cfg.PersistTTL != 2*time.Hour || !cfg.SnapshotOnShutdown {
+		t.Errorf("env overrides not applied: %+v", cfg)
+	}
+
+	t.Setenv("FIDO_TTL", "soon")
+	if _, err := Load(path); err == nil {
+		t.Error("invalid duration should fail")
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("missing file should fail")
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	ctx := context.Background()
+	localfs.Register[string, int]()
+	cfg := Config{Name: "test-config", Store: "file://" + t.TempDir(), Size: 100, TTL: time.Hour}
+
+	cache, err := NewFromConfig[string, int](ctx, cfg)
+	if err != nil {
+		t.Fatalf("NewFromConfig: %v", err)
+	}
+	if err := cache.Set(ctx, "k", 1); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if n, err := cache.Store.Len(ctx); err != nil || n != 1 {
+		t.Errorf("store Len = %d, %v; want 1", n, err)
+	}
+	if err := cache.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+
+	// Memory only by default.
+	mem, err := NewFromConfig[string, int](ctx, Config{})
+	if err != nil {
+		t.Fatalf("NewFromConfig(empty): %v", err)
+	}
+	if err := mem.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+
+	if _, err := NewFromConfig[string, int](ctx, Config{Store: "null://"}); err == nil {
+		t.Error("store without name should fail")
+	}
+	if _, err := NewFromConfig[string, int](ctx, Config{Name: "x", Store: "bogus://"}); err == nil {
+		t.Error("unknown scheme should fail")
+	}
+}
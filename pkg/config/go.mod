@@ -0,0 +1,34 @@
+module github.com/codeGROOVE-dev/fido/pkg/config
+
+go 1.25.4
+
+require (
+	github.com/codeGROOVE-dev/fido v1.10.0
+	github.com/codeGROOVE-dev/fido/pkg/store/registry v1.10.0
+	go.yaml.in/yaml/v3 v3.0.4
+)
+
+// Test-only: NewFromConfig's test opens a file:// store.
+require github.com/codeGROOVE-dev/fido/pkg/store/localfs v1.10.0
+
+require (
+	github.com/codeGROOVE-dev/fido/pkg/store/compress v1.10.0 // indirect
+	github.com/codeGROOVE-dev/fido/pkg/store/memstore v1.10.0 // indirect
+	github.com/codeGROOVE-dev/fido/pkg/store/null v1.10.0 // indirect
+	github.com/klauspost/compress v1.18.3 // indirect
+	github.com/puzpuzpuz/xsync/v4 v4.3.0 // indirect
+)
+
+replace github.com/codeGROOVE-dev/fido => ../..
+
+replace github.com/codeGROOVE-dev/fido/pkg/store/registry => ../store/registry
+
+replace github.com/codeGROOVE-dev/fido/pkg/store/compress => ../store/compress
+
+replace github.com/codeGROOVE-dev/fido/pkg/store/localfs => ../store/localfs
+
+replace github.com/codeGROOVE-dev/fido/pkg/store/null => ../store/null
+
+replace github.com/codeGROOVE-dev/fido/pkg/store/persisttest => ../store/persisttest
+
+replace github.com/codeGROOVE-dev/fido/pkg/store/memstore => ../store/memstore
@@ -0,0 +1,252 @@
+// Package dnscache caches host lookups in a fido Cache, as a drop-in for
+// net.Resolver.LookupIPAddr in clients that resolve the same hosts often:
+//
+//	r := dnscache.New(dnscache.Config{Lookup: dnscache.Server("10.0.0.2:53")})
+//	defer r.Close()
+//	addrs, err := r.LookupIPAddr(ctx, "api.internal")
+//
+// Answers are cached for their record TTLs, and a host that does not exist is
+// cached too (negative caching), so a typo doesn't send every request to DNS.
+// A host read in the last part of its TTL is refreshed in the background, so
+// steady traffic never waits on DNS, and if DNS is unreachable once an answer
+// expires, the stale answer is served for up to MaxStale.
+package dnscache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/codeGROOVE-dev/fido"
+)
+
+// LookupFunc resolves host to its addresses and how long they may be cached.
+// A host that does not exist is reported as a *net.DNSError with IsNotFound
+// set, with a TTL for how long to cache its absence, or 0 for NegativeTTL.
+type LookupFunc func(ctx context.Context, host string) (addrs []net.IPAddr, ttl time.Duration, err error)
+
+// Config configures a Resolver. Zero values select the defaults.
+type Config struct {
+	// Lookup resolves hosts. Default System, which cannot see record TTLs and
+	// caches answers for DefaultTTL; use Server to honor them.
+	Lookup LookupFunc
+	// Size is the number of hosts cached. Default 1024.
+	Size int
+	// MinTTL and MaxTTL bound how long answers are cached, whatever their
+	// records say. Default 5s and 1h.
+	MinTTL time.Duration
+	MaxTTL time.Duration
+	// NegativeTTL caps how long a host that does not exist is cached. Default 30s.
+	NegativeTTL time.Duration
+	// MaxStale is how long past its TTL an answer may be served while DNS
+	// fails. Default 5m; negative disables stale answers.
+	MaxStale time.Duration
+	// RefreshAhead is the fraction of a TTL, at its end, in which a read
+	// refreshes the answer in the background. Default 0.1; negative disables.
+	RefreshAhead float64
+	// Timeout bounds background refreshes. Default 5s.
+	Timeout time.Duration
+}
+
+// DefaultTTL is how long System answers are cached.
+const DefaultTTL = 30 * time.Second
+
+// System is a LookupFunc using net.DefaultResolver, caching answers for DefaultTTL.
+func System(ctx context.Context, host string) ([]net.IPAddr, time.Duration, error) {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	return addrs, DefaultTTL, err
+}
+
+// Resolver is a caching DNS resolver. It is safe for concurrent use.
+type Resolver struct {
+	cache  *fido.Cache[string, *answer]
+	cfg    Config
+	now    func() time.Time
+	ctx    context.Context // canceled by Close, ending background refreshes
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	flights map[string]*flight // lookups in progress, shared by concurrent callers
+}
+
+// answer is a cached lookup result, positive or negative.
+type answer struct {
+	refreshAt  time.Time // reads from here on refresh in the background
+	expires    time.Time
+	err        error // non-nil for a host that does not exist
+	addrs      []net.IPAddr
+	refreshing atomic.Bool
+}
+
+type flight struct {
+	done chan struct{}
+	ans  *answer
+	err  error
+}
+
+// New returns a Resolver configured by cfg. Close stops background refreshes.
+func New(cfg Config) *Resolver {
+	if cfg.Lookup == nil {
+		cfg.Lookup = System
+	}
+	if cfg.Size <= 0 {
+		cfg.Size = 1024
+	}
+	if cfg.MinTTL <= 0 {
+		cfg.MinTTL = 5 * time.Second
+	}
+	if cfg.MaxTTL <= 0 {
+		cfg.MaxTTL = time.Hour
+	}
+	if cfg.NegativeTTL <= 0 {
+		cfg.NegativeTTL = 30 * time.Second
+	}
+	switch {
+	case cfg.MaxStale == 0:
+		cfg.MaxStale = 5 * time.Minute
+	case cfg.MaxStale < 0:
+		cfg.MaxStale = 0
+	}
+	switch {
+	case cfg.RefreshAhead == 0:
+		cfg.RefreshAhead = 0.1
+	case cfg.RefreshAhead < 0:
+		cfg.RefreshAhead = 0
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Resolver{
+		cache:   fido.New[string, *answer](fido.Size(cfg.Size)),
+		cfg:     cfg,
+		now:     time.Now,
+		ctx:     ctx,
+		cancel:  cancel,
+		flights: make(map[string]*flight),
+	}
+}
+
+// LookupIPAddr returns host's addresses, from the cache when it has a current
+// answer. It returns a *net.DNSError with IsNotFound set for a host that does
+// not exist, whether the answer was cached or not.
+func (r *Resolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IPAddr{{IP: ip}}, nil
+	}
+
+	stale, ok := r.cache.Get(host)
+	if ok {
+		now := r.now()
+		if now.Before(stale.expires) {
+			if !now.Before(stale.refreshAt) {
+				r.refresh(host, stale)
+			}
+			return stale.result()
+		}
+	}
+
+	ans, err := r.resolve(ctx, host)
+	if err != nil {
+		if ok && !errors.Is(err, context.Canceled) {
+			return stale.result()
+		}
+		return nil, err
+	}
+	return ans.result()
+}
+
+// Forget drops host's cached answer, so the next lookup resolves it again.
+func (r *Resolver) Forget(host string) {
+	r.cache.Delete(strings.ToLower(strings.TrimSuffix(host, ".")))
+}
+
+// Close stops background refreshes and waits for those running to finish.
+func (r *Resolver) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+// result returns the addresses or not-found error a lookup reported.
+func (a *answer) result() ([]net.IPAddr, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	return append([]net.IPAddr(nil), a.addrs...), nil
+}
+
+// refresh resolves host in the background, once per cached answer.
+func (r *Resolver) refresh(host string, a *answer) {
+	if !a.refreshing.CompareAndSwap(false, true) {
+		return
+	}
+	r.wg.Go(func() {
+		ctx, cancel := context.WithTimeout(r.ctx, r.cfg.Timeout)
+		defer cancel()
+		if _, err := r.resolve(ctx, host); err != nil {
+			a.refreshing.Store(false) // let a later read try again
+		}
+	})
+}
+
+// resolve looks host up and caches the answer, sharing the lookup with
+// concurrent callers. Errors other than not-found are returned uncached.
+func (r *Resolver) resolve(ctx context.Context, host string) (*answer, error) {
+	r.mu.Lock()
+	if f, ok := r.flights[host]; ok {
+		r.mu.Unlock()
+		select {
+		case <-f.done:
+			return f.ans, f.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	f := &flight{done: make(chan struct{})}
+	r.flights[host] = f
+	r.mu.Unlock()
+
+	f.ans, f.err = r.lookup(ctx, host)
+	r.mu.Lock()
+	delete(r.flights, host)
+	r.mu.Unlock()
+	close(f.done)
+	return f.ans, f.err
+}
+
+func (r *Resolver) lookup(ctx context.Context, host string) (*answer, error) {
+	addrs, ttl, err := r.cfg.Lookup(ctx, host)
+	var dnsErr *net.DNSError
+	switch {
+	case err == nil && len(addrs) > 0:
+		ttl = min(max(ttl, r.cfg.MinTTL), r.cfg.MaxTTL)
+	case err == nil:
+		err = &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		ttl = r.cfg.NegativeTTL
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		if ttl <= 0 || ttl > r.cfg.NegativeTTL {
+			ttl = r.cfg.NegativeTTL
+		}
+	default:
+		return nil, err
+	}
+
+	now := r.now()
+	a := &answer{
+		addrs:     addrs,
+		err:       err,
+		expires:   now.Add(ttl),
+		refreshAt: now.Add(ttl - time.Duration(float64(ttl)*r.cfg.RefreshAhead)),
+	}
+	if a.err != nil {
+		a.refreshAt = a.expires // absence isn't worth refreshing ahead
+	}
+	r.cache.SetTTL(host, a, ttl+r.cfg.MaxStale)
+	return a, nil
+}
@@ -0,0 +1,212 @@
+package dnscache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDNS is a LookupFunc answering from a table, counting lookups.
+type fakeDNS struct {
+	mu      sync.Mutex
+	hosts   map[string][]net.IPAddr
+	ttl     time.Duration
+	down    bool // fail every lookup as if DNS were unreachable
+	lookups int
+}
+
+func (f *fakeDNS) lookup(_ context.Context, host string) ([]net.IPAddr, time.Duration, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lookups++
+	if f.down {
+		return nil, 0, &net.DNSError{Err: "timeout", Name: host, IsTimeout: true}
+	}
+	addrs, ok := f.hosts[host]
+	if !ok {
+		return nil, 0, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return addrs, f.ttl, nil
+}
+
+func (f *fakeDNS) set(host, ip string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.hosts[host] = []net.IPAddr{{IP: net.ParseIP(ip)}}
+}
+
+func (f *fakeDNS) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.lookups
+}
+
+func (f *fakeDNS) setDown(down bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.down = down
+}
+
+// newResolver returns a Resolver over f whose clock advances only by tick.
+func newResolver(t *testing.T, f *fakeDNS, cfg Config) (r *Resolver, tick func(time.Duration)) {
+	t.Helper()
+	cfg.Lookup = f.lookup
+	r = New(cfg)
+	t.Cleanup(r.Close)
+	var mu sync.Mutex
+	now := time.Now()
+	r.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	return r, func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+}
+
+func lookup(t *testing.T, r *Resolver, host string) string {
+	t.Helper()
+	addrs, err := r.LookupIPAddr(context.Background(), host)
+	if err != nil {
+		t.Fatalf("LookupIPAddr(%q): %v", host, err)
+	}
+	return addrs[0].IP.String()
+}
+
+func TestLookupIPAddr_TTL(t *testing.T) {
+	f := &fakeDNS{hosts: map[string][]net.IPAddr{}, ttl: time.Minute}
+	f.set("a.test", "10.0.0.1")
+	r, tick := newResolver(t, f, Config{RefreshAhead: -1})
+
+	if got := lookup(t, r, "A.test."); got != "10.0.0.1" {
+		t.Errorf("lookup = %s; want 10.0.0.1", got)
+	}
+	f.set("a.test", "10.0.0.2")
+	tick(50 * time.Second)
+	if got := lookup(t, r, "a.test"); got != "10.0.0.1" || f.count() != 1 {
+		t.Errorf("lookup within TTL = %s after %d lookups; want cached 10.0.0.1", got, f.count())
+	}
+	tick(11 * time.Second)
+	if got := lookup(t, r, "a.test"); got != "10.0.0.2" || f.count() != 2 {
+		t.Errorf("lookup after TTL = %s after %d lookups; want fresh 10.0.0.2", got, f.count())
+	}
+
+	if got := lookup(t, r, "192.0.2.1"); got != "192.0.2.1" || f.count() != 2 {
+		t.Errorf("IP literal = %s after %d lookups; want itself without a lookup", got, f.count())
+	}
+}
+
+func TestLookupIPAddr_Clamp(t *testing.T) {
+	f := &fakeDNS{hosts: map[string][]net.IPAddr{}, ttl: 0}
+	f.set("a.test", "10.0.0.1")
+	r, tick := newResolver(t, f, Config{MinTTL: 10 * time.Second, RefreshAhead: -1})
+	lookup(t, r, "a.test")
+	tick(9 * time.Second)
+	lookup(t, r, "a.test")
+	if n := f.count(); n != 1 {
+		t.Errorf("%d lookups; want a zero TTL raised to MinTTL", n)
+	}
+}
+
+func TestLookupIPAddr_Negative(t *testing.T) {
+	f := &fakeDNS{hosts: map[string][]net.IPAddr{}, ttl: time.Minute}
+	r, tick := newResolver(t, f, Config{NegativeTTL: 10 * time.Second})
+
+	for range 3 {
+		var dnsErr *net.DNSError
+		if _, err := r.LookupIPAddr(context.Background(), "typo.test"); !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+			t.Fatalf("LookupIPAddr = %v; want not found", err)
+		}
+	}
+	if n := f.count(); n != 1 {
+		t.Errorf("%d lookups; want the absence cached", n)
+	}
+
+	f.set("typo.test", "10.0.0.3")
+	tick(11 * time.Second)
+	if got := lookup(t, r, "typo.test"); got != "10.0.0.3" {
+		t.Errorf("lookup after NegativeTTL = %s; want 10.0.0.3", got)
+	}
+}
+
+func TestLookupIPAddr_StaleOnError(t *testing.T) {
+	f := &fakeDNS{hosts: map[string][]net.IPAddr{}, ttl: 10 * time.Second}
+	f.set("a.test", "10.0.0.1")
+	r, tick := newResolver(t, f, Config{RefreshAhead: -1})
+	lookup(t, r, "a.test")
+
+	f.setDown(true)
+	tick(time.Minute)
+	if got := lookup(t, r, "a.test"); got != "10.0.0.1" {
+		t.Errorf("lookup with DNS down = %s; want stale 10.0.0.1", got)
+	}
+	if _, err := r.LookupIPAddr(context.Background(), "b.test"); err == nil {
+		t.Error("lookup of an uncached host with DNS down should fail")
+	}
+
+	f.setDown(false)
+	f.set("a.test", "10.0.0.2")
+	if got := lookup(t, r, "a.test"); got != "10.0.0.2" {
+		t.Errorf("lookup once DNS is back = %s; want 10.0.0.2", got)
+	}
+}
+
+func TestLookupIPAddr_RefreshAhead(t *testing.T) {
+	f := &fakeDNS{hosts: map[string][]net.IPAddr{}, ttl: 100 * time.Second}
+	f.set("a.test", "10.0.0.1")
+	r, tick := newResolver(t, f, Config{RefreshAhead: 0.2})
+	lookup(t, r, "a.test")
+
+	f.set("a.test", "10.0.0.2")
+	tick(85 * time.Second)
+	// The read in the refresh window is served from cache, and triggers one refresh.
+	if got := lookup(t, r, "a.test"); got != "10.0.0.1" {
+		t.Errorf("lookup in refresh window = %s; want cached 10.0.0.1", got)
+	}
+	lookup(t, r, "a.test")
+	deadline := time.Now().Add(5 * time.Second)
+	for lookup(t, r, "a.test") != "10.0.0.2" {
+		if time.Now().After(deadline) {
+			t.Fatal("background refresh never replaced the answer")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if n := f.count(); n != 2 {
+		t.Errorf("%d lookups; want 1 plus one refresh", n)
+	}
+}
+
+func TestLookupIPAddr_Shared(t *testing.T) {
+	gate := make(chan struct{})
+	var mu sync.Mutex
+	calls := 0
+	r := New(Config{Lookup: func(context.Context, string) ([]net.IPAddr, time.Duration, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		<-gate
+		return []net.IPAddr{{IP: net.ParseIP("10.0.0.1")}}, time.Minute, nil
+	}})
+	defer r.Close()
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			if _, err := r.LookupIPAddr(context.Background(), "a.test"); err != nil {
+				t.Errorf("LookupIPAddr: %v", err)
+			}
+		})
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(gate)
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("%d lookups for concurrent misses; want 1", calls)
+	}
+}
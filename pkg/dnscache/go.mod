@@ -0,0 +1,12 @@
+module github.com/codeGROOVE-dev/fido/pkg/dnscache
+
+go 1.25.4
+
+require (
+	github.com/codeGROOVE-dev/fido v1.10.0
+	golang.org/x/net v0.57.0
+)
+
+require github.com/puzpuzpuz/xsync/v4 v4.3.0 // indirect
+
+replace github.com/codeGROOVE-dev/fido => ../..
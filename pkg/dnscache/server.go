@@ -0,0 +1,190 @@
+package dnscache
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// maxUDPSize is the response size advertised with EDNS(0), which RFC 9715
+// recommends to avoid IP fragmentation.
+const maxUDPSize = 1232
+
+// Server returns a LookupFunc that queries the DNS server at addr
+// ("host:port") for A and AAAA records over UDP, retrying over TCP when a
+// response is truncated. Answers are cached for their shortest record TTL, and
+// a host without addresses for the TTL its zone's SOA record gives absences.
+func Server(addr string) LookupFunc {
+	return func(ctx context.Context, host string) ([]net.IPAddr, time.Duration, error) {
+		name, err := dnsmessage.NewName(host + ".")
+		if err != nil {
+			return nil, 0, &net.DNSError{Err: err.Error(), Name: host, Server: addr}
+		}
+		var addrs []net.IPAddr
+		var ttl, negTTL time.Duration
+		nxdomain := false
+		for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+			resp, err := exchange(ctx, addr, name, qtype)
+			if ctx.Err() != nil {
+				return nil, 0, ctx.Err()
+			}
+			if err != nil {
+				return nil, 0, &net.DNSError{Err: err.Error(), Name: host, Server: addr, IsTemporary: true}
+			}
+			switch resp.RCode {
+			case dnsmessage.RCodeSuccess:
+			case dnsmessage.RCodeNameError:
+				nxdomain = true
+			default:
+				return nil, 0, &net.DNSError{Err: "server error: " + resp.RCode.String(), Name: host, Server: addr, IsTemporary: true}
+			}
+			found, rttl := addresses(resp)
+			if len(found) > 0 {
+				addrs = append(addrs, found...)
+				ttl = minTTL(ttl, rttl)
+			} else {
+				negTTL = minTTL(negTTL, soaTTL(resp))
+			}
+			if nxdomain {
+				break // no records of any type
+			}
+		}
+		if len(addrs) == 0 {
+			return nil, negTTL, &net.DNSError{Err: "no such host", Name: host, Server: addr, IsNotFound: true}
+		}
+		return addrs, ttl, nil
+	}
+}
+
+// minTTL returns the smaller of a and b, treating 0 as unset.
+func minTTL(a, b time.Duration) time.Duration {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// addresses returns the A and AAAA records in resp's answer section and the
+// shortest TTL among all its answers, including the CNAMEs leading to them.
+func addresses(resp *dnsmessage.Message) ([]net.IPAddr, time.Duration) {
+	var addrs []net.IPAddr
+	var ttl time.Duration
+	for _, rr := range resp.Answers {
+		ttl = minTTL(ttl, max(time.Duration(rr.Header.TTL)*time.Second, time.Second))
+		switch b := rr.Body.(type) {
+		case *dnsmessage.AResource:
+			addrs = append(addrs, net.IPAddr{IP: net.IP(b.A[:])})
+		case *dnsmessage.AAAAResource:
+			addrs = append(addrs, net.IPAddr{IP: net.IP(b.AAAA[:])})
+		}
+	}
+	return addrs, ttl
+}
+
+// soaTTL returns how long resp's absence of records may be cached: the
+// smaller of its SOA record's TTL and minimum (RFC 2308), or 0 without one.
+func soaTTL(resp *dnsmessage.Message) time.Duration {
+	for _, rr := range resp.Authorities {
+		if soa, ok := rr.Body.(*dnsmessage.SOAResource); ok {
+			return time.Duration(min(rr.Header.TTL, soa.MinTTL)) * time.Second
+		}
+	}
+	return 0
+}
+
+// exchange sends a recursive query for name and qtype to addr and returns the
+// response, over TCP if the UDP response was truncated.
+func exchange(ctx context.Context, addr string, name dnsmessage.Name, qtype dnsmessage.Type) (*dnsmessage.Message, error) {
+	//nolint:gosec // G404: query IDs guard against mixed-up responses, not spoofing
+	id := uint16(rand.Uint32())
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id, RecursionDesired: true})
+	b.EnableCompression()
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(dnsmessage.Question{Name: name, Type: qtype, Class: dnsmessage.ClassINET}); err != nil {
+		return nil, err
+	}
+	if err := b.StartAdditionals(); err != nil {
+		return nil, err
+	}
+	var opt dnsmessage.ResourceHeader
+	if err := opt.SetEDNS0(maxUDPSize, dnsmessage.RCodeSuccess, false); err != nil {
+		return nil, err
+	}
+	if err := b.OPTResource(opt, dnsmessage.OPTResource{}); err != nil {
+		return nil, err
+	}
+	query, err := b.Finish()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := roundTrip(ctx, "udp", addr, query, id)
+	if err == nil && resp.Truncated {
+		resp, err = roundTrip(ctx, "tcp", addr, query, id)
+	}
+	return resp, err
+}
+
+// roundTrip sends query to addr over network and reads the response to id.
+func roundTrip(ctx context.Context, network, addr string, query []byte, id uint16) (*dnsmessage.Message, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close() //nolint:errcheck // read-only use
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) }) //nolint:errcheck // best effort
+	defer stop()
+
+	var buf []byte
+	if network == "tcp" {
+		msg := binary.BigEndian.AppendUint16(nil, uint16(len(query))) //nolint:gosec // G115: queries are small
+		if _, err := conn.Write(append(msg, query...)); err != nil {
+			return nil, err
+		}
+		var n [2]byte
+		if _, err := io.ReadFull(conn, n[:]); err != nil {
+			return nil, err
+		}
+		buf = make([]byte, binary.BigEndian.Uint16(n[:]))
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err := conn.Write(query); err != nil {
+			return nil, err
+		}
+		buf = make([]byte, maxUDPSize)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		buf = buf[:n]
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	var resp dnsmessage.Message
+	if err := resp.Unpack(buf); err != nil {
+		return nil, fmt.Errorf("bad response: %w", err)
+	}
+	if !resp.Response || resp.ID != id {
+		return nil, errors.New("response does not match query")
+	}
+	return &resp, nil
+}
@@ -0,0 +1,127 @@
+package dnscache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// serveDNS answers queries on a local UDP socket from records, keyed by
+// question name and type, and returns its address. Names absent from
+// records get NXDOMAIN; absent types get an empty answer. Both carry an SOA
+// record with a 60s TTL and a 20s minimum.
+func serveDNS(t *testing.T, records map[string][]dnsmessage.Resource) string {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() }) //nolint:errcheck // test cleanup
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, from, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var q dnsmessage.Message
+			if err := q.Unpack(buf[:n]); err != nil {
+				continue
+			}
+			question := q.Questions[0]
+			resp := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: q.ID, Response: true, RecursionAvailable: true},
+				Questions: q.Questions,
+			}
+			rrs, known := records[question.Name.String()]
+			if !known {
+				resp.RCode = dnsmessage.RCodeNameError
+			}
+			for _, rr := range rrs {
+				if rr.Header.Type == question.Type || rr.Header.Type == dnsmessage.TypeCNAME {
+					resp.Answers = append(resp.Answers, rr)
+				}
+			}
+			if len(resp.Answers) == 0 {
+				resp.Authorities = []dnsmessage.Resource{{
+					Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName("test."), Class: dnsmessage.ClassINET, TTL: 60},
+					Body: &dnsmessage.SOAResource{
+						NS: dnsmessage.MustNewName("ns.test."), MBox: dnsmessage.MustNewName("admin.test."), MinTTL: 20,
+					},
+				}}
+			}
+			out, err := resp.Pack()
+			if err != nil {
+				t.Errorf("pack: %v", err)
+				return
+			}
+			if _, err := pc.WriteTo(out, from); err != nil {
+				return
+			}
+		}
+	}()
+	return pc.LocalAddr().String()
+}
+
+func aRecord(name string, ttl uint32, ip [4]byte) dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name), Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: ttl},
+		Body:   &dnsmessage.AResource{A: ip},
+	}
+}
+
+func TestServer(t *testing.T) {
+	v6 := dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName("www.test."), Type: dnsmessage.TypeAAAA, Class: dnsmessage.ClassINET, TTL: 300},
+		Body:   &dnsmessage.AAAAResource{AAAA: [16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 1}},
+	}
+	cname := dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName("alias.test."), Type: dnsmessage.TypeCNAME, Class: dnsmessage.ClassINET, TTL: 30},
+		Body:   &dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName("www.test.")},
+	}
+	addr := serveDNS(t, map[string][]dnsmessage.Resource{
+		"www.test.":   {aRecord("www.test.", 120, [4]byte{192, 0, 2, 1}), v6},
+		"alias.test.": {cname, aRecord("www.test.", 120, [4]byte{192, 0, 2, 1})},
+		"v4.test.":    {aRecord("v4.test.", 90, [4]byte{192, 0, 2, 2})},
+		"empty.test.": nil,
+	})
+	lookup := Server(addr)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	addrs, ttl, err := lookup(ctx, "www.test")
+	if err != nil || len(addrs) != 2 || ttl != 120*time.Second {
+		t.Errorf("www.test = %v, %v, %v; want A and AAAA with the shorter TTL, 120s", addrs, ttl, err)
+	}
+	if _, ttl, err := lookup(ctx, "alias.test"); err != nil || ttl != 30*time.Second {
+		t.Errorf("alias.test TTL = %v, %v; want the CNAME's 30s", ttl, err)
+	}
+	if addrs, ttl, err := lookup(ctx, "v4.test"); err != nil || len(addrs) != 1 || ttl != 90*time.Second {
+		t.Errorf("v4.test = %v, %v, %v; want its A record alone, 90s", addrs, ttl, err)
+	}
+
+	for _, host := range []string{"missing.test", "empty.test"} {
+		var dnsErr *net.DNSError
+		_, ttl, err := lookup(ctx, host)
+		if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound || ttl != 20*time.Second {
+			t.Errorf("%s = %v, %v; want not found for the SOA minimum, 20s", host, ttl, err)
+		}
+	}
+}
+
+func TestServer_Unreachable(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close() //nolint:errcheck // test cleanup
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, _, err := Server(pc.LocalAddr().String())(ctx, "www.test"); err == nil {
+		t.Error("lookup against a silent server should fail")
+	}
+}
@@ -0,0 +1,263 @@
+// Package filecache caches values built from files, such as parsed templates
+// or compiled assets, in a fido TieredCache keyed by path. It watches the
+// files each value was built from and drops the value when one changes, so a
+// dev server serves edits without restarting or rebuilding everything:
+//
+//	pages, err := filecache.New(tiered, func(ctx context.Context, path string) (*template.Template, []string, error) {
+//		t, err := template.ParseFiles(path, "layout.html")
+//		return t, []string{"layout.html"}, err
+//	})
+//	defer pages.Close()
+//	page, err := pages.Get(ctx, "templates/index.html")
+//
+// With a persistent store, values survive restarts: on first use in a process
+// each one is checked against its files' modification times and sizes, and
+// rebuilt if they differ. Values must be encodable by the store; parsed
+// templates are not, so cache them with the null store (memory only).
+package filecache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/codeGROOVE-dev/fido"
+	"github.com/fsnotify/fsnotify"
+)
+
+// Stamp identifies a version of a file.
+type Stamp struct {
+	ModTime time.Time `json:"mod_time"`
+	Size    int64     `json:"size"`
+}
+
+// Entry is a cached value and the files it was built from.
+type Entry[V any] struct {
+	Value V                `json:"value"`
+	Files map[string]Stamp `json:"files"` // absolute paths, including the key's
+}
+
+// Loader builds the value for path. deps lists other files it read, such as
+// layouts or imports, so changes to them invalidate the value too.
+type Loader[V any] func(ctx context.Context, path string) (value V, deps []string, err error)
+
+// Cache caches values built by a Loader, invalidating them when their files
+// change. It is safe for concurrent use.
+type Cache[V any] struct {
+	cache   *fido.TieredCache[string, Entry[V]]
+	load    Loader[V]
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+
+	mu      sync.Mutex
+	keys    map[string]map[string]struct{} // file -> keys built from it
+	dirs    map[string]bool                // directories being watched
+	watched map[string]bool                // keys whose files are watched
+}
+
+// New returns a Cache storing values in cache, which should be dedicated to
+// it, and building them with load. Close stops watching files.
+func New[V any](cache *fido.TieredCache[string, Entry[V]], load Loader[V]) (*Cache[V], error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("watch files: %w", err)
+	}
+	c := &Cache[V]{
+		cache:   cache,
+		load:    load,
+		watcher: w,
+		done:    make(chan struct{}),
+		keys:    make(map[string]map[string]struct{}),
+		dirs:    make(map[string]bool),
+		watched: make(map[string]bool),
+	}
+	go c.watch()
+	return c, nil
+}
+
+// Get returns the value for path, building it if it is not cached or its files
+// have changed. Concurrent calls for the same path share one build.
+func (c *Cache[V]) Get(ctx context.Context, path string) (V, error) {
+	var zero V
+	key, err := filepath.Abs(path)
+	if err != nil {
+		return zero, err
+	}
+	var e Entry[V]
+	// A file may change between a build and its watch starting; checking the
+	// stamps once watched catches that, and a rebuild is watched in turn.
+	for range 3 {
+		e, err = c.cache.Fetch(ctx, key, func(ctx context.Context) (Entry[V], error) {
+			return c.build(ctx, key)
+		})
+		if err != nil {
+			return zero, err
+		}
+		if c.isWatched(key) {
+			return e.Value, nil
+		}
+		if err := c.watchFiles(key, e.Files); err != nil {
+			return zero, err
+		}
+		if e.current() {
+			return e.Value, nil
+		}
+		if err := c.invalidate(ctx, key); err != nil {
+			return zero, err
+		}
+	}
+	// The files keep changing; serve the latest build.
+	return e.Value, nil
+}
+
+// Invalidate drops the value for path, so the next Get rebuilds it.
+func (c *Cache[V]) Invalidate(ctx context.Context, path string) error {
+	key, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	return c.invalidate(ctx, key)
+}
+
+// Close stops watching files. The TieredCache is left open.
+func (c *Cache[V]) Close() error {
+	err := c.watcher.Close()
+	<-c.done
+	return err
+}
+
+// build loads key and stamps the files it read.
+func (c *Cache[V]) build(ctx context.Context, key string) (Entry[V], error) {
+	// Stamp key before loading, so an edit during the load is seen as a change.
+	own, err := stamp(key)
+	if err != nil {
+		return Entry[V]{}, err
+	}
+	v, deps, err := c.load(ctx, key)
+	if err != nil {
+		return Entry[V]{}, err
+	}
+	e := Entry[V]{Value: v, Files: map[string]Stamp{key: own}}
+	for _, d := range deps {
+		abs, err := filepath.Abs(d)
+		if err != nil {
+			return Entry[V]{}, err
+		}
+		if e.Files[abs], err = stamp(abs); err != nil {
+			return Entry[V]{}, err
+		}
+	}
+	return e, nil
+}
+
+// current reports whether e's files are unchanged since it was built.
+func (e Entry[V]) current() bool {
+	for path, want := range e.Files {
+		got, err := stamp(path)
+		if err != nil || got.Size != want.Size || !got.ModTime.Equal(want.ModTime) {
+			return false
+		}
+	}
+	return true
+}
+
+func stamp(path string) (Stamp, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return Stamp{}, err
+	}
+	return Stamp{ModTime: fi.ModTime(), Size: fi.Size()}, nil
+}
+
+func (c *Cache[V]) isWatched(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.watched[key]
+}
+
+// watchFiles watches the directories of files, which key was built from.
+// Directories rather than files are watched, because editors often save by
+// replacing the file, which ends a watch on the file itself.
+func (c *Cache[V]) watchFiles(key string, files map[string]Stamp) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for path := range files {
+		dir := filepath.Dir(path)
+		if !c.dirs[dir] {
+			if err := c.watcher.Add(dir); err != nil {
+				return fmt.Errorf("watch %s: %w", dir, err)
+			}
+			c.dirs[dir] = true
+		}
+		if c.keys[path] == nil {
+			c.keys[path] = make(map[string]struct{})
+		}
+		c.keys[path][key] = struct{}{}
+	}
+	c.watched[key] = true
+	return nil
+}
+
+// invalidate drops key from the cache and detaches a build in progress.
+func (c *Cache[V]) invalidate(ctx context.Context, key string) error {
+	c.mu.Lock()
+	delete(c.watched, key)
+	c.mu.Unlock()
+	return c.cache.Delete(ctx, key)
+}
+
+// changed invalidates every key built from path.
+func (c *Cache[V]) changed(path string) {
+	c.mu.Lock()
+	keys := c.keys[path]
+	delete(c.keys, path)
+	c.mu.Unlock()
+	for key := range keys {
+		if err := c.invalidate(context.Background(), key); err != nil {
+			slog.Warn("filecache: invalidate failed", "key", key, "error", err)
+		}
+	}
+}
+
+// changedAll invalidates every key, after the watcher lost events.
+func (c *Cache[V]) changedAll() {
+	c.mu.Lock()
+	paths := make([]string, 0, len(c.keys))
+	for path := range c.keys {
+		paths = append(paths, path)
+	}
+	c.mu.Unlock()
+	for _, path := range paths {
+		c.changed(path)
+	}
+}
+
+// watch invalidates keys as their files change, until the watcher is closed.
+func (c *Cache[V]) watch() {
+	defer close(c.done)
+	for {
+		select {
+		case ev, ok := <-c.watcher.Events:
+			if !ok {
+				return
+			}
+			if ev.Op == fsnotify.Chmod {
+				continue
+			}
+			c.changed(ev.Name)
+		case err, ok := <-c.watcher.Errors:
+			if !ok {
+				return
+			}
+			slog.Warn("filecache: watch error", "error", err)
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				c.changedAll()
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package filecache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codeGROOVE-dev/fido"
+	"github.com/codeGROOVE-dev/fido/pkg/store/memstore"
+)
+
+// upper is a Loader returning a file's contents upper-cased, followed by
+// those of each file named on its "include" lines.
+type upper struct{ builds atomic.Int64 }
+
+func (u *upper) load(_ context.Context, path string) (string, []string, error) {
+	u.builds.Add(1)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+	var out strings.Builder
+	var deps []string
+	for line := range strings.Lines(string(data)) {
+		if name, ok := strings.CutPrefix(strings.TrimSpace(line), "include "); ok {
+			dep := filepath.Join(filepath.Dir(path), name)
+			inc, err := os.ReadFile(dep)
+			if err != nil {
+				return "", nil, err
+			}
+			deps = append(deps, dep)
+			out.WriteString(strings.ToUpper(string(inc)))
+			continue
+		}
+		out.WriteString(strings.ToUpper(line))
+	}
+	return out.String(), deps, nil
+}
+
+func newCache(t *testing.T, store *memstore.Store[string, Entry[string]]) (*Cache[string], *upper) {
+	t.Helper()
+	tiered, err := fido.NewTiered[string, Entry[string]](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	u := &upper{}
+	c, err := New(tiered, u.load)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Errorf("Close: %v", err)
+		}
+	})
+	return c, u
+}
+
+func write(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitFor polls get until it returns want.
+func waitFor(t *testing.T, get func() (string, error), want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, err := get()
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if got == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Get = %q; want %q", got, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestGet_InvalidatesOnChange(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	page, layout := filepath.Join(dir, "page.txt"), filepath.Join(dir, "layout.txt")
+	write(t, layout, "header\n")
+	write(t, page, "include layout.txt\nbody\n")
+
+	c, u := newCache(t, memstore.New[string, Entry[string]]())
+	get := func() (string, error) { return c.Get(ctx, page) }
+	waitFor(t, get, "HEADER\nBODY\n")
+	waitFor(t, get, "HEADER\nBODY\n")
+	if n := u.builds.Load(); n != 1 {
+		t.Errorf("built %d times; want 1 while unchanged", n)
+	}
+
+	write(t, page, "include layout.txt\nnew body\n")
+	waitFor(t, get, "HEADER\nNEW BODY\n")
+
+	// Editors often save by writing a temporary file and renaming it over.
+	tmp := filepath.Join(dir, "layout.txt~")
+	write(t, tmp, "new header\n")
+	if err := os.Rename(tmp, layout); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, get, "NEW HEADER\nNEW BODY\n")
+
+	write(t, page, "again\n")
+	waitFor(t, get, "AGAIN\n")
+}
+
+func TestGet_StalePersisted(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	page := filepath.Join(dir, "page.txt")
+	write(t, page, "one\n")
+	store := memstore.New[string, Entry[string]]()
+
+	first, _ := newCache(t, store)
+	if got, err := first.Get(ctx, page); err != nil || got != "ONE\n" {
+		t.Fatalf("Get = %q, %v; want ONE", got, err)
+	}
+
+	// A later process finds the unchanged value persisted.
+	second, u := newCache(t, store)
+	if got, err := second.Get(ctx, page); err != nil || got != "ONE\n" || u.builds.Load() != 0 {
+		t.Errorf("Get = %q, %v after %d builds; want persisted ONE", got, err, u.builds.Load())
+	}
+
+	// One started after the file changed rebuilds it.
+	if err := first.Close(); err != nil {
+		t.Fatal(err)
+	}
+	write(t, page, "three!\n")
+	third, u := newCache(t, store)
+	if got, err := third.Get(ctx, page); err != nil || got != "THREE!\n" || u.builds.Load() != 1 {
+		t.Errorf("Get = %q, %v after %d builds; want rebuilt THREE!", got, err, u.builds.Load())
+	}
+}
+
+func TestGet_Missing(t *testing.T) {
+	c, _ := newCache(t, memstore.New[string, Entry[string]]())
+	if _, err := c.Get(context.Background(), filepath.Join(t.TempDir(), "missing")); !os.IsNotExist(err) {
+		t.Errorf("Get of a missing file: %v; want not exist", err)
+	}
+}
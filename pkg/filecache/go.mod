@@ -0,0 +1,20 @@
+module github.com/codeGROOVE-dev/fido/pkg/filecache
+
+go 1.25.4
+
+require (
+	github.com/codeGROOVE-dev/fido v1.10.0
+	github.com/codeGROOVE-dev/fido/pkg/store/memstore v1.10.0
+	github.com/fsnotify/fsnotify v1.9.0
+)
+
+require (
+	github.com/puzpuzpuz/xsync/v4 v4.3.0 // indirect
+	golang.org/x/sys v0.40.0 // indirect
+)
+
+replace github.com/codeGROOVE-dev/fido => ../..
+
+replace github.com/codeGROOVE-dev/fido/pkg/store/memstore => ../store/memstore
+
+replace github.com/codeGROOVE-dev/fido/pkg/store/persisttest => ../store/persisttest
@@ -0,0 +1,244 @@
+// Package sqlcache caches database/sql query results in a fido TieredCache,
+// keyed by the normalized query text and its arguments, and invalidated by
+// the tables each query reads:
+//
+//	users := sqlcache.New(tiered, sqlcache.Rows(scanUser))
+//	active, err := users.Query(ctx, db, []string{"users"}, "SELECT * FROM users WHERE active = ?", true)
+//	_, err = users.Exec(ctx, db, []string{"users"}, "UPDATE users SET active = ? WHERE id = ?", false, id)
+//
+// Invalidation covers the keys this process has queried, in memory and in the
+// store. Other processes sharing the store keep serving their memory copies
+// until they expire, so give the TieredCache a MemoryTTL short enough to bound
+// that staleness.
+package sqlcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codeGROOVE-dev/fido"
+)
+
+// Querier runs queries; *sql.DB, *sql.Tx, and *sql.Conn implement it.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Execer runs statements; *sql.DB, *sql.Tx, and *sql.Conn implement it.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Cache caches the results of queries scanned into T. It is safe for concurrent use.
+type Cache[T any] struct {
+	cache *fido.TieredCache[string, T]
+	scan  func(*sql.Rows) (T, error)
+	limit int // keys tracked per table before it is invalidated wholesale
+
+	mu     sync.Mutex
+	tables map[string]map[string]struct{} // table -> keys queried for it
+}
+
+// New returns a Cache storing results in cache, which should be dedicated to
+// it, and reading them with scan. scan must not close rows.
+//
+// To bound its index, a Cache tracks at most four times cache's Size keys per
+// table; a table reaching that many is invalidated wholesale.
+func New[T any](cache *fido.TieredCache[string, T], scan func(*sql.Rows) (T, error)) *Cache[T] {
+	return &Cache[T]{
+		cache:  cache,
+		scan:   scan,
+		limit:  4 * cache.Config().Size,
+		tables: make(map[string]map[string]struct{}),
+	}
+}
+
+// Rows returns a scan function for New that reads every row with scanRow.
+func Rows[T any](scanRow func(*sql.Rows) (T, error)) func(*sql.Rows) ([]T, error) {
+	return func(rows *sql.Rows) ([]T, error) {
+		var out []T
+		for rows.Next() {
+			v, err := scanRow(rows)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	}
+}
+
+// Query returns the cached result of query with args, or runs it on db and
+// caches the result with the cache's default TTL. tables names the tables the
+// query reads; Invalidate or Exec on any of them drops the result. Concurrent
+// misses for the same query share one database round trip.
+func (c *Cache[T]) Query(ctx context.Context, db Querier, tables []string, query string, args ...any) (T, error) {
+	return c.QueryTTL(ctx, db, 0, tables, query, args...)
+}
+
+// QueryTTL is like Query but caches a result it loads for ttl.
+func (c *Cache[T]) QueryTTL(ctx context.Context, db Querier, ttl time.Duration, tables []string, query string, args ...any) (T, error) {
+	var zero T
+	key, err := Key(query, args...)
+	if err != nil {
+		return zero, err
+	}
+	// Tracking first lets a concurrent Invalidate detach this load; tracking
+	// again afterwards covers an Invalidate that ran before the load began.
+	if err := c.track(ctx, key, tables); err != nil {
+		return zero, err
+	}
+	val, err := c.cache.FetchTTL(ctx, key, ttl, func(ctx context.Context) (T, error) {
+		return c.load(ctx, db, query, args)
+	})
+	if err != nil {
+		return zero, err
+	}
+	return val, c.track(ctx, key, tables)
+}
+
+// load runs query on db and scans its result.
+func (c *Cache[T]) load(ctx context.Context, db Querier, query string, args []any) (T, error) {
+	var zero T
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return zero, err
+	}
+	val, err := c.scan(rows)
+	if err == nil {
+		err = rows.Err()
+	}
+	if cerr := rows.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return zero, err
+	}
+	return val, nil
+}
+
+// Exec runs a statement on db and, if it succeeds, invalidates tables. Inside
+// a *sql.Tx, call Invalidate after Commit instead: until then, other
+// connections can read and cache the old rows again.
+func (c *Cache[T]) Exec(ctx context.Context, db Execer, tables []string, query string, args ...any) (sql.Result, error) {
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Invalidate(ctx, tables...); err != nil {
+		return res, fmt.Errorf("invalidate: %w", err)
+	}
+	return res, nil
+}
+
+// Invalidate drops the cached result of every query this process ran against
+// any of tables, and detaches loads in progress so their results are not cached.
+func (c *Cache[T]) Invalidate(ctx context.Context, tables ...string) error {
+	keys := make(map[string]struct{})
+	c.mu.Lock()
+	for _, t := range tables {
+		for k := range c.tables[t] {
+			keys[k] = struct{}{}
+		}
+		delete(c.tables, t)
+	}
+	c.mu.Unlock()
+
+	var errs []error
+	for k := range keys {
+		if err := c.cache.Delete(ctx, k); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// track records key under tables, first invalidating any table whose index is full.
+func (c *Cache[T]) track(ctx context.Context, key string, tables []string) error {
+	var full []string
+	c.mu.Lock()
+	for _, t := range tables {
+		keys := c.tables[t]
+		if _, ok := keys[key]; !ok && len(keys) >= c.limit {
+			full = append(full, t)
+		}
+	}
+	c.mu.Unlock()
+	if err := c.Invalidate(ctx, full...); err != nil {
+		return fmt.Errorf("invalidate: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, t := range tables {
+		keys := c.tables[t]
+		if keys == nil {
+			keys = make(map[string]struct{})
+			c.tables[t] = keys
+		}
+		keys[key] = struct{}{}
+	}
+	return nil
+}
+
+// Key returns the cache key for query with args: a hash of the normalized
+// query and the arguments as the database driver would receive them. Queries
+// differing only in whitespace outside quotes share a key. It fails for
+// arguments database/sql cannot convert by default, such as structs.
+func Key(query string, args ...any) (string, error) {
+	h := sha256.New()
+	h.Write([]byte(Normalize(query)))
+	for i, a := range args {
+		if na, ok := a.(sql.NamedArg); ok {
+			fmt.Fprintf(h, "\x00@%s", na.Name)
+			a = na.Value
+		}
+		v, err := driver.DefaultParameterConverter.ConvertValue(a)
+		if err != nil {
+			return "", fmt.Errorf("argument %d: %w", i, err)
+		}
+		switch v := v.(type) {
+		case time.Time:
+			fmt.Fprintf(h, "\x00%T:%s", v, v.UTC().Format(time.RFC3339Nano))
+		default:
+			fmt.Fprintf(h, "\x00%T:%v", v, v)
+		}
+	}
+	return "sql:" + hex.EncodeToString(h.Sum(nil)[:16]), nil
+}
+
+// Normalize collapses each run of whitespace outside quoted strings and
+// identifiers to one space, and trims whitespace and trailing semicolons.
+func Normalize(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+	var quote rune
+	space := false
+	for _, r := range strings.TrimRight(strings.TrimSpace(query), "; \t\r\n") {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == ' ' || r == '\t' || r == '\r' || r == '\n':
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,227 @@
+package sqlcache
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codeGROOVE-dev/fido"
+	"github.com/codeGROOVE-dev/fido/pkg/store/memstore"
+)
+
+// countDriver is a database/sql driver whose queries return one row holding
+// the number of queries run so far, so a test can tell fresh results from
+// cached ones. Statements succeed and do nothing.
+type countDriver struct {
+	queries atomic.Int64
+}
+
+func (d *countDriver) Open(string) (driver.Conn, error) { return &countConn{d: d}, nil }
+
+type countConn struct{ d *countDriver }
+
+func (*countConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (*countConn) Close() error                        { return nil }
+func (*countConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func (c *countConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return &countRows{n: c.d.queries.Add(1)}, nil
+}
+
+func (*countConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+type countRows struct {
+	n    int64
+	done bool
+}
+
+func (*countRows) Columns() []string { return []string{"n"} }
+func (*countRows) Close() error      { return nil }
+
+func (r *countRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.n
+	return nil
+}
+
+func openDB(t *testing.T) (*sql.DB, *countDriver) {
+	t.Helper()
+	d := &countDriver{}
+	name := "sqlcache-" + t.Name()
+	sql.Register(name, d)
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() }) //nolint:errcheck // test cleanup
+	return db, d
+}
+
+func newCache(t *testing.T, opts ...fido.Option) *Cache[[]int64] {
+	t.Helper()
+	tiered, err := fido.NewTiered[string, []int64](memstore.New[string, []int64](), opts...)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	return New(tiered, Rows(func(rows *sql.Rows) (int64, error) {
+		var n int64
+		err := rows.Scan(&n)
+		return n, err
+	}))
+}
+
+func TestQuery(t *testing.T) {
+	ctx := context.Background()
+	db, d := openDB(t)
+	c := newCache(t)
+
+	users := []string{"users"}
+	first, err := c.Query(ctx, db, users, "SELECT n FROM users WHERE id = ?", 1)
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	again, err := c.Query(ctx, db, users, "select n\n  FROM users   WHERE id = ?;", 1)
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	// Keys are case-sensitive; only whitespace and semicolons are normalized.
+	if first[0] != 1 || d.queries.Load() != 2 || again[0] != 2 {
+		t.Fatalf("results %v, %v after %d queries; want a distinct key for different case", first, again, d.queries.Load())
+	}
+	cached, err := c.Query(ctx, db, users, "SELECT n  FROM users\tWHERE id = ?", 1)
+	if err != nil || cached[0] != 1 {
+		t.Errorf("Query = %v, %v; want cached [1]", cached, err)
+	}
+	other, err := c.Query(ctx, db, users, "SELECT n FROM users WHERE id = ?", 2)
+	if err != nil || other[0] != 3 {
+		t.Errorf("Query with other args = %v, %v; want fresh [3]", other, err)
+	}
+	if n := d.queries.Load(); n != 3 {
+		t.Errorf("ran %d queries; want 3", n)
+	}
+}
+
+func TestInvalidate(t *testing.T) {
+	ctx := context.Background()
+	db, d := openDB(t)
+	c := newCache(t)
+
+	const joined = "SELECT n FROM users JOIN orgs USING (org_id)"
+	if _, err := c.Query(ctx, db, []string{"users", "orgs"}, joined); err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if _, err := c.Query(ctx, db, []string{"orgs"}, "SELECT n FROM orgs"); err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if _, err := c.Exec(ctx, db, []string{"users"}, "UPDATE users SET name = ?", "x"); err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+
+	got, err := c.Query(ctx, db, []string{"users", "orgs"}, joined)
+	if err != nil || got[0] != 3 {
+		t.Errorf("joined query after users changed = %v, %v; want fresh [3]", got, err)
+	}
+	got, err = c.Query(ctx, db, []string{"orgs"}, "SELECT n FROM orgs")
+	if err != nil || got[0] != 2 {
+		t.Errorf("orgs query after users changed = %v, %v; want cached [2]", got, err)
+	}
+
+	if err := c.Invalidate(ctx, "orgs"); err != nil {
+		t.Fatalf("Invalidate: %v", err)
+	}
+	if _, err := c.Query(ctx, db, []string{"users", "orgs"}, joined); err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if n := d.queries.Load(); n != 4 {
+		t.Errorf("ran %d queries; want 4", n)
+	}
+}
+
+func TestInvalidate_Persisted(t *testing.T) {
+	ctx := context.Background()
+	db, d := openDB(t)
+	c := newCache(t)
+
+	if _, err := c.Query(ctx, db, []string{"users"}, "SELECT n FROM users"); err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	c.cache.FlushMemory(ctx) // as after a restart: only the store has the result
+	if got, err := c.Query(ctx, db, []string{"users"}, "SELECT n FROM users"); err != nil || got[0] != 1 {
+		t.Fatalf("Query = %v, %v; want [1] from the store", got, err)
+	}
+	if err := c.Invalidate(ctx, "users"); err != nil {
+		t.Fatalf("Invalidate: %v", err)
+	}
+	c.cache.FlushMemory(ctx)
+	if got, err := c.Query(ctx, db, []string{"users"}, "SELECT n FROM users"); err != nil || got[0] != 2 {
+		t.Errorf("Query = %v, %v; want fresh [2]", got, err)
+	}
+	if n := d.queries.Load(); n != 2 {
+		t.Errorf("ran %d queries; want 2", n)
+	}
+}
+
+func TestTrackLimit(t *testing.T) {
+	ctx := context.Background()
+	db, _ := openDB(t)
+	c := newCache(t, fido.Size(2))
+
+	for i := range 20 {
+		if _, err := c.Query(ctx, db, []string{"users"}, "SELECT n FROM users WHERE id = ?", i); err != nil {
+			t.Fatalf("Query: %v", err)
+		}
+		c.mu.Lock()
+		n := len(c.tables["users"])
+		c.mu.Unlock()
+		if n > c.limit {
+			t.Fatalf("tracking %d keys for users; want at most %d", n, c.limit)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	day := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, tt := range []struct {
+		q1, q2 string
+		a1, a2 []any
+	}{
+		{"SELECT 1", "  SELECT   1 ; ", nil, nil},
+		{"SELECT ?", "SELECT ?", []any{int32(7)}, []any{int64(7)}},
+		{"SELECT ?", "SELECT ?", []any{day}, []any{day.In(time.FixedZone("x", 3600))}},
+	} {
+		k1, err1 := Key(tt.q1, tt.a1...)
+		k2, err2 := Key(tt.q2, tt.a2...)
+		if err1 != nil || err2 != nil || k1 != k2 {
+			t.Errorf("Key(%q, %v) = %q, %v and Key(%q, %v) = %q, %v; want equal", tt.q1, tt.a1, k1, err1, tt.q2, tt.a2, k2, err2)
+		}
+	}
+
+	for _, tt := range []struct {
+		q1, q2 string
+		a1, a2 []any
+	}{
+		{"SELECT 'a  b'", "SELECT 'a b'", nil, nil},
+		{"SELECT ?", "SELECT ?", []any{"1"}, []any{1}},
+		{"SELECT ?, ?", "SELECT ?, ?", []any{"a", "b c"}, []any{"a b", "c"}},
+		{"SELECT @x", "SELECT @x", []any{sql.Named("x", 1)}, []any{sql.Named("y", 1)}},
+	} {
+		k1, _ := Key(tt.q1, tt.a1...)
+		k2, _ := Key(tt.q2, tt.a2...)
+		if k1 == k2 {
+			t.Errorf("Key(%q, %v) == Key(%q, %v); want different", tt.q1, tt.a1, tt.q2, tt.a2)
+		}
+	}
+
+	if _, err := Key("SELECT ?", struct{}{}); err == nil {
+		t.Error("Key with a struct argument should fail")
+	}
+}
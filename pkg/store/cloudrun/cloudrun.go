@@ -1,6 +1,7 @@
 // Package cloudrun provides automatic persistence backend selection for Cloud Run.
 // Detects Cloud Run via K_SERVICE env var and tries Datastore first,
-// falling back to local files if unavailable.
+// falling back to local files if unavailable. Under WebAssembly (js and
+// wasip1), which has no OS cache directory, the fallback is an in-memory store.
 package cloudrun
 
 import (
@@ -10,7 +11,6 @@ import (
 
 	"github.com/codeGROOVE-dev/fido/pkg/store/compress"
 	"github.com/codeGROOVE-dev/fido/pkg/store/datastore"
-	"github.com/codeGROOVE-dev/fido/pkg/store/localfs"
 )
 
 // Store is the persistence interface returned by New.
@@ -28,7 +28,7 @@ type Store[K comparable, V any] interface {
 
 // New creates a persistence layer for Cloud Run environments.
 // In Cloud Run: tries Datastore, falls back to local files on error.
-// Outside Cloud Run: uses local files directly.
+// Outside Cloud Run: uses local files directly, or memory under WebAssembly.
 // Optional compressor enables compression (e.g., compress.S2() for Snappy-compatible).
 func New[K comparable, V any](ctx context.Context, cacheID string, c ...compress.Compressor) (Store[K, V], error) {
 	if os.Getenv("K_SERVICE") != "" {
@@ -36,5 +36,5 @@ func New[K comparable, V any](ctx context.Context, cacheID string, c ...compress
 			return p, nil
 		}
 	}
-	return localfs.New[K, V](cacheID, "", c...)
+	return local[K, V](cacheID, c...)
 }
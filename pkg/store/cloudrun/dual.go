@@ -0,0 +1,123 @@
+package cloudrun
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/codeGROOVE-dev/fido/pkg/store/compress"
+	"github.com/codeGROOVE-dev/fido/pkg/store/datastore"
+	"github.com/codeGROOVE-dev/fido/pkg/store/localfs"
+)
+
+// NewDualWrite creates a persistence layer that writes to both instance-local
+// files and Datastore. Reads try local files first, so a restarted instance
+// warms from its own disk; misses fall through to Datastore, which keeps data
+// durable across instances and repopulates the local copy.
+//
+// Local copies are per-instance: an update made by another instance is not seen
+// locally until the local entry expires or is deleted here. Use it for values
+// where that staleness is acceptable.
+//
+// Outside Cloud Run, or when Datastore is unavailable, it returns local files only, like New.
+func NewDualWrite[K comparable, V any](ctx context.Context, cacheID string, c ...compress.Compressor) (Store[K, V], error) {
+	local, err := localfs.New[K, V](cacheID, "", c...)
+	if err != nil {
+		return nil, err
+	}
+	if os.Getenv("K_SERVICE") == "" {
+		return local, nil
+	}
+	remote, err := datastore.New[K, V](ctx, cacheID, c...)
+	if err != nil {
+		return local, nil //nolint:nilerr // fall back to local files, as New does
+	}
+	return newDual[K, V](local, remote), nil
+}
+
+// dual writes through to a local and a remote store, reading local first.
+type dual[K comparable, V any] struct {
+	local  Store[K, V]
+	remote Store[K, V]
+}
+
+func newDual[K comparable, V any](local, remote Store[K, V]) *dual[K, V] {
+	return &dual[K, V]{local: local, remote: remote}
+}
+
+// ValidateKey accepts keys valid for both stores.
+func (d *dual[K, V]) ValidateKey(key K) error {
+	if err := d.local.ValidateKey(key); err != nil {
+		return err
+	}
+	return d.remote.ValidateKey(key)
+}
+
+// MaxKeyLength returns the stricter of the two stores' key limits, or 0 if
+// neither has one. Implements fido.KeyLimiter.
+func (d *dual[K, V]) MaxKeyLength() int {
+	n := 0
+	for _, s := range []Store[K, V]{d.local, d.remote} {
+		if l, ok := s.(interface{ MaxKeyLength() int }); ok && l.MaxKeyLength() > 0 && (n == 0 || l.MaxKeyLength() < n) {
+			n = l.MaxKeyLength()
+		}
+	}
+	return n
+}
+
+// Get reads the local copy first, falling back to the remote store.
+// Remote hits are copied back to local storage.
+func (d *dual[K, V]) Get(ctx context.Context, key K) (V, time.Time, bool, error) {
+	v, expiry, found, err := d.local.Get(ctx, key)
+	if err != nil {
+		slog.Warn("cloudrun: local get failed", "key", key, "error", err)
+	}
+	if found && err == nil {
+		return v, expiry, true, nil
+	}
+
+	v, expiry, found, err = d.remote.Get(ctx, key)
+	if err != nil || !found {
+		return v, expiry, found, err
+	}
+	if err := d.local.Set(ctx, key, v, expiry); err != nil {
+		slog.Warn("cloudrun: local repopulate failed", "key", key, "error", err)
+	}
+	return v, expiry, true, nil
+}
+
+// Set writes to both stores.
+func (d *dual[K, V]) Set(ctx context.Context, key K, value V, expiry time.Time) error {
+	return errors.Join(d.local.Set(ctx, key, value, expiry), d.remote.Set(ctx, key, value, expiry))
+}
+
+// Delete removes the key from both stores.
+func (d *dual[K, V]) Delete(ctx context.Context, key K) error {
+	return errors.Join(d.local.Delete(ctx, key), d.remote.Delete(ctx, key))
+}
+
+// Cleanup removes expired entries from both stores, returning the combined count.
+func (d *dual[K, V]) Cleanup(ctx context.Context, maxAge time.Duration) (int, error) {
+	nl, errl := d.local.Cleanup(ctx, maxAge)
+	nr, errr := d.remote.Cleanup(ctx, maxAge)
+	return nl + nr, errors.Join(errl, errr)
+}
+
+// Flush removes all entries from both stores, returning the combined count.
+func (d *dual[K, V]) Flush(ctx context.Context) (int, error) {
+	nl, errl := d.local.Flush(ctx)
+	nr, errr := d.remote.Flush(ctx)
+	return nl + nr, errors.Join(errl, errr)
+}
+
+// Len returns the remote entry count, which is authoritative across instances.
+func (d *dual[K, V]) Len(ctx context.Context) (int, error) {
+	return d.remote.Len(ctx)
+}
+
+// Close closes both stores.
+func (d *dual[K, V]) Close() error {
+	return errors.Join(d.local.Close(), d.remote.Close())
+}
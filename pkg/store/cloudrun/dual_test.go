@@ -0,0 +1,131 @@
+package cloudrun
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/codeGROOVE-dev/fido/pkg/store/localfs"
+)
+
+func newTestDual(t *testing.T) (d *dual[string, int], local, remote Store[string, int]) {
+	t.Helper()
+	l, err := localfs.New[string, int]("local", t.TempDir())
+	if err != nil {
+		t.Fatalf("localfs.New: %v", err)
+	}
+	r, err := localfs.New[string, int]("remote", t.TempDir())
+	if err != nil {
+		t.Fatalf("localfs.New: %v", err)
+	}
+	d = newDual[string, int](l, r)
+	t.Cleanup(func() {
+		if err := d.Close(); err != nil {
+			t.Logf("Close error: %v", err)
+		}
+	})
+	return d, l, r
+}
+
+func TestDual_WritesBoth(t *testing.T) {
+	ctx := context.Background()
+	d, local, remote := newTestDual(t)
+
+	if err := d.Set(ctx, "k", 1, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	for name, s := range map[string]Store[string, int]{"local": local, "remote": remote} {
+		if v, _, found, err := s.Get(ctx, "k"); err != nil || !found || v != 1 {
+			t.Errorf("%s Get = %d, %v, %v; want 1, true, nil", name, v, found, err)
+		}
+	}
+
+	if err := d.Delete(ctx, "k"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	for name, s := range map[string]Store[string, int]{"local": local, "remote": remote} {
+		if _, _, found, err := s.Get(ctx, "k"); err != nil || found {
+			t.Errorf("%s Get after Delete = %v, %v; want miss", name, found, err)
+		}
+	}
+}
+
+func TestDual_ReadsLocalFirst(t *testing.T) {
+	ctx := context.Background()
+	d, local, remote := newTestDual(t)
+
+	// Diverging copies: local wins.
+	if err := local.Set(ctx, "k", 1, time.Time{}); err != nil {
+		t.Fatalf("local Set: %v", err)
+	}
+	if err := remote.Set(ctx, "k", 2, time.Time{}); err != nil {
+		t.Fatalf("remote Set: %v", err)
+	}
+	if v, _, found, err := d.Get(ctx, "k"); err != nil || !found || v != 1 {
+		t.Errorf("Get = %d, %v, %v; want local value 1", v, found, err)
+	}
+}
+
+func TestDual_RemoteHitRepopulatesLocal(t *testing.T) {
+	ctx := context.Background()
+	d, local, remote := newTestDual(t)
+
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+	if err := remote.Set(ctx, "k", 7, expiry); err != nil {
+		t.Fatalf("remote Set: %v", err)
+	}
+	v, exp, found, err := d.Get(ctx, "k")
+	if err != nil || !found || v != 7 {
+		t.Fatalf("Get = %d, %v, %v; want 7 from remote", v, found, err)
+	}
+	if !exp.Equal(expiry) {
+		t.Errorf("expiry = %v; want %v", exp, expiry)
+	}
+	if v, _, found, err := local.Get(ctx, "k"); err != nil || !found || v != 7 {
+		t.Errorf("local Get = %d, %v, %v; want repopulated 7", v, found, err)
+	}
+}
+
+func TestDual_FlushAndLen(t *testing.T) {
+	ctx := context.Background()
+	d, _, _ := newTestDual(t)
+
+	for i, k := range []string{"a", "b", "c"} {
+		if err := d.Set(ctx, k, i, time.Time{}); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	if n, err := d.Len(ctx); err != nil || n != 3 {
+		t.Errorf("Len = %d, %v; want 3", n, err)
+	}
+	if n, err := d.Flush(ctx); err != nil || n != 6 {
+		t.Errorf("Flush = %d, %v; want 6 (both stores)", n, err)
+	}
+	if n, err := d.Len(ctx); err != nil || n != 0 {
+		t.Errorf("Len after Flush = %d, %v; want 0", n, err)
+	}
+}
+
+func TestNewDualWrite_LocalOutsideCloudRun(t *testing.T) {
+	t.Setenv("K_SERVICE", "")
+
+	p, err := NewDualWrite[string, int](context.Background(), "test-dual")
+	if err != nil {
+		t.Fatalf("NewDualWrite: %v", err)
+	}
+	defer func() {
+		if err := p.Close(); err != nil {
+			t.Logf("Close error: %v", err)
+		}
+	}()
+	if _, ok := p.(*localfs.Store[string, int]); !ok {
+		t.Errorf("NewDualWrite outside Cloud Run = %T; want *localfs.Store", p)
+	}
+}
+
+func TestDual_MaxKeyLength(t *testing.T) {
+	d, _, _ := newTestDual(t)
+	if got := d.MaxKeyLength(); got != 127 {
+		t.Errorf("MaxKeyLength() = %d; want localfs's 127", got)
+	}
+}
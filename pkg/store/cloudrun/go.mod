@@ -6,10 +6,13 @@ require (
 	github.com/codeGROOVE-dev/fido/pkg/store/compress v1.10.0
 	github.com/codeGROOVE-dev/fido/pkg/store/datastore v1.10.0
 	github.com/codeGROOVE-dev/fido/pkg/store/localfs v1.10.0
+	github.com/codeGROOVE-dev/fido/pkg/store/memstore v1.10.0
+	github.com/codeGROOVE-dev/fido/pkg/store/registry v1.10.0
 )
 
 require (
 	github.com/codeGROOVE-dev/ds9 v0.8.1 // indirect
+	github.com/codeGROOVE-dev/fido/pkg/store/null v1.10.0 // indirect
 	github.com/klauspost/compress v1.18.3 // indirect
 )
 
@@ -18,3 +21,11 @@ replace github.com/codeGROOVE-dev/fido/pkg/store/datastore => ../datastore
 replace github.com/codeGROOVE-dev/fido/pkg/store/localfs => ../localfs
 
 replace github.com/codeGROOVE-dev/fido/pkg/store/compress => ../compress
+
+replace github.com/codeGROOVE-dev/fido/pkg/store/persisttest => ../persisttest
+
+replace github.com/codeGROOVE-dev/fido/pkg/store/memstore => ../memstore
+
+replace github.com/codeGROOVE-dev/fido/pkg/store/registry => ../registry
+
+replace github.com/codeGROOVE-dev/fido/pkg/store/null => ../null
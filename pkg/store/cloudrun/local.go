@@ -0,0 +1,13 @@
+//go:build !js && !wasip1
+
+package cloudrun
+
+import (
+	"github.com/codeGROOVE-dev/fido/pkg/store/compress"
+	"github.com/codeGROOVE-dev/fido/pkg/store/localfs"
+)
+
+// local returns the fallback store: files under the user cache directory.
+func local[K comparable, V any](cacheID string, c ...compress.Compressor) (Store[K, V], error) {
+	return localfs.New[K, V](cacheID, "", c...)
+}
@@ -0,0 +1,14 @@
+//go:build js || wasip1
+
+package cloudrun
+
+import (
+	"github.com/codeGROOVE-dev/fido/pkg/store/compress"
+	"github.com/codeGROOVE-dev/fido/pkg/store/memstore"
+)
+
+// local returns the fallback store. WebAssembly has no user cache directory,
+// and in browsers no filesystem, so entries are kept in memory only.
+func local[K comparable, V any](_ string, _ ...compress.Compressor) (Store[K, V], error) {
+	return memstore.New[K, V](), nil
+}
@@ -0,0 +1,22 @@
+package cloudrun
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/codeGROOVE-dev/fido/pkg/store/compress"
+	"github.com/codeGROOVE-dev/fido/pkg/store/registry"
+)
+
+// Register makes registry.Open construct a store with New for cloudrun://
+// URIs, which take only the compress parameter. Call it once for each K, V
+// pair before Open.
+func Register[K comparable, V any]() {
+	registry.Register("cloudrun", func(ctx context.Context, u *url.URL, cacheID string) (registry.Store[K, V], error) {
+		c, err := compress.Parse(u.Query().Get("compress"))
+		if err != nil {
+			return nil, err
+		}
+		return New[K, V](ctx, cacheID, c)
+	})
+}
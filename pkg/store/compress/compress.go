@@ -2,6 +2,10 @@
 package compress
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/klauspost/compress/s2"
 	"github.com/klauspost/compress/zstd"
 )
@@ -53,3 +57,26 @@ func Zstd(level int) Compressor {
 func (z *zstdc) Encode(data []byte) ([]byte, error) { return z.enc.EncodeAll(data, nil), nil }
 func (z *zstdc) Decode(data []byte) ([]byte, error) { return z.dec.DecodeAll(data, nil) }
 func (*zstdc) Extension() string                    { return ".z" }
+
+// Parse returns the compressor named by s: none (or empty), s2, zstd, or
+// zstd:N for level N. Stores use it for their URI's compress parameter.
+func Parse(s string) (Compressor, error) {
+	name, level, _ := strings.Cut(strings.ToLower(s), ":")
+	switch name {
+	case "", "none":
+		return None(), nil
+	case "s2":
+		return S2(), nil
+	case "zstd":
+		n := 2
+		if level != "" {
+			var err error
+			if n, err = strconv.Atoi(level); err != nil {
+				return nil, fmt.Errorf("invalid zstd level %q: %w", level, err)
+			}
+		}
+		return Zstd(n), nil
+	default:
+		return nil, fmt.Errorf("unknown compressor %q", s)
+	}
+}
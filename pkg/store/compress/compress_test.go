@@ -94,3 +94,21 @@ func TestNoneZeroCopy(t *testing.T) {
 		t.Error("None.Decode should return same slice (zero-copy)")
 	}
 }
+
+func TestParse(t *testing.T) {
+	for s, ext := range map[string]string{"": "", "none": "", "s2": ".s", "zstd": ".z", "ZSTD:4": ".z"} {
+		c, err := Parse(s)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", s, err)
+			continue
+		}
+		if c.Extension() != ext {
+			t.Errorf("Parse(%q).Extension() = %q; want %q", s, c.Extension(), ext)
+		}
+	}
+	for _, s := range []string{"zstd:x", "lz4"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q) succeeded; want error", s)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package datastore
+
+import (
+	"testing"
+
+	"github.com/codeGROOVE-dev/fido/pkg/store/persisttest"
+)
+
+// TestConformance needs the emulator: the ds9 mock ignores Cleanup's expiry filters.
+func TestConformance(t *testing.T) {
+	skipIfNoEmulator(t)
+	persisttest.TestStore(t, func(t *testing.T) persisttest.Store[string, string] {
+		s, err := New[string, string](t.Context(), "conformance")
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		if _, err := s.Flush(t.Context()); err != nil {
+			t.Fatalf("Flush: %v", err)
+		}
+		return s
+	})
+}
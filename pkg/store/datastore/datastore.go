@@ -2,30 +2,59 @@
 package datastore
 
 import (
+	"cmp"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"iter"
+	"log/slog"
+	"os"
 	"strings"
 	"time"
 
+	"github.com/codeGROOVE-dev/ds9/auth"
 	ds "github.com/codeGROOVE-dev/ds9/pkg/datastore"
 	"github.com/codeGROOVE-dev/fido/pkg/store/compress"
 )
 
 const (
-	datastoreKind      = "CacheEntry"
-	maxDatastoreKeyLen = 1500 // Datastore has stricter key length limits
+	datastoreKind        = "CacheEntry"
+	maxDatastoreKeyLen   = 1500    // Datastore has stricter key length limits
+	maxDatastoreValueLen = 1048487 // Maximum size of an unindexed property
 )
 
+// ErrValueTooLarge is returned by Set when an encoded value exceeds Datastore's
+// unindexed property limit (about 1MiB). Compression raises the effective limit.
+var ErrValueTooLarge = errors.New("value too large for datastore")
+
 // Store implements persistence using Google Cloud Datastore.
 type Store[K comparable, V any] struct {
 	client     *ds.Client
 	kind       string
 	compressor compress.Compressor
 	ext        string
+	project    string
+	database   string
+	adminURL   string       // Firestore Admin API base, overridden in tests
+	authCfg    *auth.Config // nil uses ADC / metadata server defaults
+	emulator   *metadataStub
+}
+
+// Config configures a Datastore store.
+type Config struct {
+	// Project is the GCP project ID. Default: from the metadata server.
+	Project string
+	// Compressor enables compression (default: no compression).
+	Compressor compress.Compressor
+	// EnsureTTL configures the native TTL policy on the expiry property
+	// during construction. See EnsureTTLPolicy.
+	EnsureTTL bool
+	// EmulatorHost is the host:port of a Datastore emulator.
+	// Defaults to $DATASTORE_EMULATOR_HOST; the emulator project comes from
+	// $DATASTORE_PROJECT_ID (default "fido-emulator").
+	EmulatorHost string
 }
 
 // ValidateKey checks if a key is valid for Datastore persistence.
@@ -41,6 +70,25 @@ func (*Store[K, V]) ValidateKey(key K) error {
 	return nil
 }
 
+// MaxKeyLength returns the longest key ValidateKey accepts. Implements fido.KeyLimiter.
+func (*Store[K, V]) MaxKeyLength() int {
+	return maxDatastoreKeyLen
+}
+
+// ValidateValue checks that value can be JSON-encoded and, without compression,
+// that it fits Datastore's property size limit. Compressed values are only
+// checked at Set, once their encoded size is known. Implements fido.ValueValidator.
+func (s *Store[K, V]) ValidateValue(value V) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal value: %w", err)
+	}
+	if s.compressor.Extension() == "" && len(b) > maxDatastoreValueLen {
+		return fmt.Errorf("%w: %d bytes (max %d)", ErrValueTooLarge, len(b), maxDatastoreValueLen)
+	}
+	return nil
+}
+
 // Location returns the Datastore key path for a given cache key.
 // Implements the Store interface Location() method.
 // Format: "kind/key" (e.g., "CacheEntry/mykey").
@@ -49,34 +97,97 @@ func (s *Store[K, V]) Location(key K) string {
 }
 
 // entry represents a cache entry in Datastore.
-// We use base64-encoded string for Value to avoid datastore []byte limitations.
+// Data holds the encoded value as a raw blob property. Value is the legacy
+// base64-encoded string form, still read so entries written by older
+// versions remain loadable; it is never written.
 // The key is stored in the Datastore entity key itself.
 type entry struct {
 	Expiry    time.Time `datastore:"expiry,omitempty,noindex"`
 	UpdatedAt time.Time `datastore:"updated_at"`
-	Value     string    `datastore:"value,noindex"`
+	Data      []byte    `datastore:"data,noindex"`
+	Value     string    `datastore:"value,omitempty,noindex"`
+}
+
+// payload returns the encoded value bytes, handling the legacy base64 format.
+func (e *entry) payload() ([]byte, error) {
+	if len(e.Data) > 0 || e.Value == "" {
+		return e.Data, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(e.Value)
+	if err != nil {
+		return nil, fmt.Errorf("decode base64: %w", err)
+	}
+	return b, nil
 }
 
 // New creates a new Datastore-based persistence layer.
 // The cacheID is used as the Datastore database name.
 // Optional compressor enables compression (default: no compression).
 func New[K comparable, V any](ctx context.Context, cacheID string, c ...compress.Compressor) (*Store[K, V], error) {
-	comp := compress.None()
-	if len(c) > 0 && c[0] != nil {
-		comp = c[0]
+	var cfg Config
+	if len(c) > 0 {
+		cfg.Compressor = c[0]
 	}
+	return NewWithConfig[K, V](ctx, cacheID, cfg)
+}
 
-	client, err := ds.NewClientWithDatabase(ctx, "", cacheID)
-	if err != nil {
-		return nil, fmt.Errorf("create datastore client: %w", err)
+// NewWithConfig creates a new Datastore-based persistence layer using cfg.
+// The cacheID is used as the Datastore database name.
+func NewWithConfig[K comparable, V any](ctx context.Context, cacheID string, cfg Config) (*Store[K, V], error) {
+	comp := cfg.Compressor
+	if comp == nil {
+		comp = compress.None()
 	}
 
-	return &Store[K, V]{
-		client:     client,
+	s := &Store[K, V]{
 		kind:       datastoreKind,
 		compressor: comp,
 		ext:        comp.Extension(),
-	}, nil
+		database:   cacheID,
+		adminURL:   firestoreAdminURL,
+	}
+
+	var opts []ds.ClientOption
+	if host := cmp.Or(cfg.EmulatorHost, os.Getenv(emulatorHostEnv)); host != "" {
+		stub, err := startMetadataStub(cmp.Or(os.Getenv(emulatorProjectEnv), defaultEmulatorProject))
+		if err != nil {
+			return nil, fmt.Errorf("start emulator metadata stub: %w", err)
+		}
+		s.emulator = stub
+		s.authCfg = &auth.Config{MetadataURL: stub.url, SkipADC: true}
+		opts = append(opts, ds.WithEndpoint("http://"+host+"/v1"), ds.WithAuth(s.authCfg))
+	}
+
+	authCtx := ctx
+	if s.authCfg != nil {
+		authCtx = auth.WithConfig(ctx, s.authCfg)
+	}
+	project := cfg.Project
+	if project == "" {
+		p, err := auth.ProjectID(authCtx)
+		if err != nil {
+			s.closeEmulator()
+			return nil, fmt.Errorf("project ID required: %w", err)
+		}
+		project = p
+	}
+	s.project = project
+
+	client, err := ds.NewClientWithDatabase(ctx, project, cacheID, opts...)
+	if err != nil {
+		s.closeEmulator()
+		return nil, fmt.Errorf("create datastore client: %w", err)
+	}
+	s.client = client
+
+	if cfg.EnsureTTL {
+		if err := s.EnsureTTLPolicy(ctx); err != nil {
+			s.closeEmulator()
+			return nil, err
+		}
+	}
+
+	return s, nil
 }
 
 // makeKey creates a Datastore key from a cache key.
@@ -106,9 +217,9 @@ func (s *Store[K, V]) Get(ctx context.Context, key K) (value V, expiry time.Time
 		return zero, time.Time{}, false, nil
 	}
 
-	b, err := base64.StdEncoding.DecodeString(e.Value)
+	b, err := e.payload()
 	if err != nil {
-		return zero, time.Time{}, false, fmt.Errorf("decode base64: %w", err)
+		return zero, time.Time{}, false, err
 	}
 
 	jsonData, err := s.compressor.Decode(b)
@@ -135,8 +246,12 @@ func (s *Store[K, V]) Set(ctx context.Context, key K, value V, expiry time.Time)
 		return fmt.Errorf("compress: %w", err)
 	}
 
+	if len(data) > maxDatastoreValueLen {
+		return fmt.Errorf("%w: %d bytes (max %d)", ErrValueTooLarge, len(data), maxDatastoreValueLen)
+	}
+
 	e := entry{
-		Value:     base64.StdEncoding.EncodeToString(data),
+		Data:      data,
 		Expiry:    expiry,
 		UpdatedAt: time.Now(),
 	}
@@ -216,9 +331,20 @@ func (s *Store[K, V]) Len(ctx context.Context) (int, error) {
 
 // Close releases Datastore client resources.
 func (s *Store[K, V]) Close() error {
+	s.closeEmulator()
 	return s.client.Close()
 }
 
+func (s *Store[K, V]) closeEmulator() {
+	if s.emulator == nil {
+		return
+	}
+	if err := s.emulator.Close(); err != nil {
+		slog.Warn("close datastore emulator metadata stub", "error", err)
+	}
+	s.emulator = nil
+}
+
 // Keys returns an iterator over keys matching prefix.
 // Implements PrefixScanner[V] interface (only usable when K is string).
 // Uses Datastore keys-only query for efficiency.
@@ -287,7 +413,7 @@ func (s *Store[K, V]) Range(ctx context.Context, prefix string) iter.Seq2[string
 			}
 
 			// Decode value.
-			b, err := base64.StdEncoding.DecodeString(e.Value)
+			b, err := e.payload()
 			if err != nil {
 				continue
 			}
@@ -0,0 +1,60 @@
+package datastore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	emulatorHostEnv        = "DATASTORE_EMULATOR_HOST"
+	emulatorProjectEnv     = "DATASTORE_PROJECT_ID"
+	defaultEmulatorProject = "fido-emulator"
+)
+
+// metadataStub impersonates the GCP metadata server for the emulator.
+// ds9 always fetches an access token and project ID; the emulator ignores
+// credentials, so a loopback stub keeps requests off the real metadata
+// server and away from ADC.
+type metadataStub struct {
+	srv *http.Server
+	url string
+}
+
+func startMetadataStub(project string) (*metadataStub, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("listen: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/project/project-id", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, project) //nolint:errcheck // loopback stub
+	})
+	mux.HandleFunc("/instance/service-accounts/default/token", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"access_token":"emulator","expires_in":3600}`) //nolint:errcheck // loopback stub
+	})
+
+	m := &metadataStub{
+		srv: &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second},
+		url: "http://" + ln.Addr().String(),
+	}
+	go func() {
+		if err := m.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Warn("datastore emulator metadata stub stopped", "error", err)
+		}
+	}()
+	return m, nil
+}
+
+func (m *metadataStub) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return m.srv.Shutdown(ctx)
+}
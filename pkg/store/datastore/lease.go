@@ -0,0 +1,48 @@
+package datastore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ds "github.com/codeGROOVE-dev/ds9/pkg/datastore"
+)
+
+const leaseKind = "CacheLease"
+
+// lease is a named, time-limited claim held by one owner.
+type lease struct {
+	Owner  string    `datastore:"owner,noindex"`
+	Expiry time.Time `datastore:"expiry,noindex"`
+}
+
+// AcquireLease takes or extends the named lease for owner until ttl elapses.
+// Leases are stored as CacheLease entities, so they are untouched by Flush and Len.
+// Implements fido.Leaser.
+func (s *Store[K, V]) AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	key := ds.NameKey(leaseKind, name, nil)
+	held := false
+
+	_, err := s.client.RunInTransaction(ctx, func(tx *ds.Transaction) error {
+		held = false
+		var l lease
+		err := tx.Get(key, &l)
+		if err != nil && !errors.Is(err, ds.ErrNoSuchEntity) {
+			return err
+		}
+		now := time.Now()
+		if err == nil && l.Owner != owner && now.Before(l.Expiry) {
+			return nil
+		}
+		if _, err := tx.Put(key, &lease{Owner: owner, Expiry: now.Add(ttl)}); err != nil {
+			return err
+		}
+		held = true
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("datastore lease: %w", err)
+	}
+	return held, nil
+}
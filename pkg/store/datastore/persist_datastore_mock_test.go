@@ -2,7 +2,10 @@ package datastore
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -614,3 +617,104 @@ func TestDatastorePersist_Mock_FlushEmpty(t *testing.T) {
 		t.Errorf("Flush deleted %d entries from empty datastore; want 0", deleted)
 	}
 }
+
+func TestDatastorePersist_Mock_LegacyBase64(t *testing.T) {
+	dp, cleanup := newMockDatastorePersist[string, string](t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	// Entities written before values were stored as raw blobs.
+	type legacyEntry struct {
+		Expiry    time.Time `datastore:"expiry,omitempty,noindex"`
+		UpdatedAt time.Time `datastore:"updated_at"`
+		Value     string    `datastore:"value,noindex"`
+	}
+	legacy := legacyEntry{
+		Value:     base64.StdEncoding.EncodeToString([]byte(`"old"`)),
+		UpdatedAt: time.Now(),
+	}
+	if _, err := dp.client.Put(ctx, dp.makeKey("legacy"), &legacy); err != nil {
+		t.Fatalf("Put legacy: %v", err)
+	}
+
+	val, _, found, err := dp.Get(ctx, "legacy")
+	if err != nil {
+		t.Fatalf("Get legacy: %v", err)
+	}
+	if !found || val != "old" {
+		t.Errorf("Get legacy = %q, %v; want old, true", val, found)
+	}
+
+	// Rewriting stores the raw blob form without the legacy property.
+	if err := dp.Set(ctx, "legacy", "new", time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	var e entry
+	if err := dp.client.Get(ctx, dp.makeKey("legacy"), &e); err != nil {
+		t.Fatalf("raw Get: %v", err)
+	}
+	if string(e.Data) != `"new"` || e.Value != "" {
+		t.Errorf("stored entry = data %q, value %q; want raw blob only", e.Data, e.Value)
+	}
+
+	got := map[string]string{}
+	for k, v := range dp.Range(ctx, "") {
+		got[k] = v
+	}
+	if got["legacy"] != "new" {
+		t.Errorf("Range = %v; want legacy=new", got)
+	}
+}
+
+func TestDatastorePersist_Mock_AcquireLease(t *testing.T) {
+	dp, cleanup := newMockDatastorePersist[string, int](t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	if ok, err := dp.AcquireLease(ctx, "cleanup", "a", 200*time.Millisecond); err != nil || !ok {
+		t.Fatalf("AcquireLease(a) = %v, %v; want true", ok, err)
+	}
+	if ok, err := dp.AcquireLease(ctx, "cleanup", "b", 200*time.Millisecond); err != nil || ok {
+		t.Fatalf("AcquireLease(b) = %v, %v; want false while a holds it", ok, err)
+	}
+	if ok, err := dp.AcquireLease(ctx, "cleanup", "a", 200*time.Millisecond); err != nil || !ok {
+		t.Fatalf("AcquireLease(a) renew = %v, %v; want true", ok, err)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	if ok, err := dp.AcquireLease(ctx, "cleanup", "b", time.Second); err != nil || !ok {
+		t.Errorf("AcquireLease(b) after expiry = %v, %v; want true", ok, err)
+	}
+
+	// Leases don't count as cache entries.
+	if n, err := dp.Len(ctx); err != nil || n != 0 {
+		t.Errorf("Len = %d, %v; want 0", n, err)
+	}
+}
+
+func TestDatastorePersist_Mock_ValueTooLarge(t *testing.T) {
+	dp, cleanup := newMockDatastorePersist[string, string](t)
+	defer cleanup()
+
+	big := strings.Repeat("x", maxDatastoreValueLen)
+	if err := dp.Set(context.Background(), "big", big, time.Time{}); !errors.Is(err, ErrValueTooLarge) {
+		t.Errorf("Set(big) = %v; want ErrValueTooLarge", err)
+	}
+}
+
+func TestDatastorePersist_Mock_ValidateValue(t *testing.T) {
+	dp, cleanup := newMockDatastorePersist[string, any](t)
+	defer cleanup()
+
+	if err := dp.ValidateValue("ok"); err != nil {
+		t.Errorf("ValidateValue(ok): %v", err)
+	}
+	if err := dp.ValidateValue(make(chan int)); err == nil {
+		t.Error("ValidateValue(chan) should fail: not JSON-encodable")
+	}
+	if err := dp.ValidateValue(strings.Repeat("x", maxDatastoreValueLen)); !errors.Is(err, ErrValueTooLarge) {
+		t.Errorf("ValidateValue(big) = %v; want ErrValueTooLarge", err)
+	}
+}
@@ -2,12 +2,37 @@ package datastore
 
 import (
 	"context"
+	"io"
 	"maps"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
+// skipIfNoEmulator skips the test unless a Datastore emulator is reachable
+// at $DATASTORE_EMULATOR_HOST, e.g. started with:
+//
+//	gcloud beta emulators datastore start --host-port=localhost:8081
+func skipIfNoEmulator(t *testing.T) {
+	t.Helper()
+	host := os.Getenv("DATASTORE_EMULATOR_HOST")
+	if host == "" {
+		t.Skip("Skipping datastore emulator tests: DATASTORE_EMULATOR_HOST not set")
+	}
+	conn, err := net.DialTimeout("tcp", host, 2*time.Second)
+	if err != nil {
+		t.Skipf("Skipping datastore emulator tests: %v", err)
+	}
+	if err := conn.Close(); err != nil {
+		t.Logf("close probe connection: %v", err)
+	}
+}
+
 // createTestStore creates a store for testing.
 // It tries to use a real Datastore if environment variables are set.
 // Otherwise, it falls back to the mock client from persist_datastore_mock_test.go.
@@ -512,3 +537,75 @@ func TestDatastorePersist_Range_SkipsExpired(t *testing.T) {
 	_ = dp.Delete(ctx, "valid-2")   //nolint:errcheck // test cleanup
 	_ = dp.Delete(ctx, "expired-1") //nolint:errcheck // test cleanup
 }
+
+func TestDatastorePersist_Emulator(t *testing.T) {
+	skipIfNoEmulator(t)
+
+	ctx := context.Background()
+	dp, err := NewWithConfig[string, string](ctx, "", Config{EnsureTTL: true})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %v", err)
+	}
+	defer func() {
+		if _, err := dp.Flush(ctx); err != nil {
+			t.Logf("Flush: %v", err)
+		}
+		if err := dp.Close(); err != nil {
+			t.Logf("Close: %v", err)
+		}
+	}()
+
+	if err := dp.Set(ctx, "emu", "value", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	val, _, found, err := dp.Get(ctx, "emu")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !found || val != "value" {
+		t.Errorf("Get = %q, %v; want value, true", val, found)
+	}
+	n, err := dp.Len(ctx)
+	if err != nil {
+		t.Fatalf("Len: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Len = %d; want 1", n)
+	}
+}
+
+func TestDatastorePersist_EmulatorEndpoint(t *testing.T) {
+	t.Setenv("DATASTORE_PROJECT_ID", "")
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/projects/fido-emulator:lookup" || r.Header.Get("Authorization") != "Bearer emulator" {
+			http.Error(w, r.URL.Path, http.StatusBadRequest)
+			return
+		}
+		hits.Add(1)
+		_, _ = io.WriteString(w, `{}`) //nolint:errcheck // test server
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	dp, err := NewWithConfig[string, string](ctx, "", Config{EmulatorHost: strings.TrimPrefix(srv.URL, "http://")})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %v", err)
+	}
+	defer func() {
+		if err := dp.Close(); err != nil {
+			t.Logf("Close: %v", err)
+		}
+	}()
+
+	if _, _, found, err := dp.Get(ctx, "missing"); err != nil || found {
+		t.Fatalf("Get = found %v, err %v; want miss", found, err)
+	}
+	if hits.Load() != 1 {
+		t.Errorf("emulator hits = %d; want 1", hits.Load())
+	}
+	if err := dp.EnsureTTLPolicy(ctx); err != nil {
+		t.Errorf("EnsureTTLPolicy on emulator: %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package datastore
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/codeGROOVE-dev/fido/pkg/store/compress"
+	"github.com/codeGROOVE-dev/fido/pkg/store/registry"
+)
+
+// Register makes registry.Open construct a Store[K, V] for
+// datastore://[project][/database][?ttl=1] URIs. The database defaults to the
+// cacheID and the project to the metadata server's. Call it once for each
+// K, V pair before Open.
+func Register[K comparable, V any]() {
+	registry.Register("datastore", open[K, V])
+}
+
+func open[K comparable, V any](ctx context.Context, u *url.URL, cacheID string) (registry.Store[K, V], error) {
+	db, cfg := uriConfig(u, cacheID)
+	var err error
+	if cfg.Compressor, err = compress.Parse(u.Query().Get("compress")); err != nil {
+		return nil, err
+	}
+	s, err := NewWithConfig[K, V](ctx, db, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// uriConfig parses the URI form accepted by Register, returning the database.
+func uriConfig(u *url.URL, cacheID string) (string, Config) {
+	db := cacheID
+	if p := strings.Trim(u.Path, "/"); p != "" {
+		db = p
+	}
+	b, err := strconv.ParseBool(u.Query().Get("ttl"))
+	return db, Config{Project: u.Host, EnsureTTL: err == nil && b}
+}
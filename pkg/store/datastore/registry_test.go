@@ -0,0 +1,27 @@
+package datastore
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestURIConfig(t *testing.T) {
+	tests := []struct {
+		uri, project, db string
+		ttl              bool
+	}{
+		{uri: "datastore://", db: "cache-id"},
+		{uri: "datastore://my-project", project: "my-project", db: "cache-id"},
+		{uri: "datastore://my-project/other-db?ttl=1", project: "my-project", db: "other-db", ttl: true},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.uri)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tt.uri, err)
+		}
+		db, cfg := uriConfig(u, "cache-id")
+		if db != tt.db || cfg.Project != tt.project || cfg.EnsureTTL != tt.ttl {
+			t.Errorf("uriConfig(%q) = %q, %+v; want db %q, project %q, ttl %v", tt.uri, db, cfg, tt.db, tt.project, tt.ttl)
+		}
+	}
+}
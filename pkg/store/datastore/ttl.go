@@ -0,0 +1,125 @@
+package datastore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/codeGROOVE-dev/ds9/auth"
+)
+
+const (
+	firestoreAdminURL = "https://firestore.googleapis.com/v1"
+	expiryField       = "expiry"
+	defaultDatabase   = "(default)"
+	maxAdminBodySize  = 1 << 20
+)
+
+// TTL policy states reported by the Firestore Admin API.
+const (
+	ttlStateCreating    = "CREATING"
+	ttlStateActive      = "ACTIVE"
+	ttlStateNeedsRepair = "NEEDS_REPAIR"
+)
+
+var adminClient = &http.Client{Timeout: 30 * time.Second}
+
+// ErrTTLPolicyNeedsRepair is returned when Datastore reports the TTL policy as
+// broken and the repair request could not be submitted.
+var ErrTTLPolicyNeedsRepair = errors.New("datastore TTL policy needs repair")
+
+// field mirrors the parts of a Firestore Admin API Field resource we use.
+type field struct {
+	TTLConfig *struct {
+		State string `json:"state"`
+	} `json:"ttlConfig,omitempty"`
+}
+
+// EnsureTTLPolicy verifies that native TTL is enabled on the expiry property
+// of the CacheEntry kind, enabling it if it is missing or needs repair.
+// With the policy in place Datastore deletes expired entries itself (within
+// about 24 hours) and Cleanup finds nothing; without it expired entries
+// accumulate until Cleanup is called.
+//
+// Enabling the policy is asynchronous: a nil return means the policy is active
+// or being created. The caller needs the datastore.indexes.update permission
+// (roles/datastore.indexAdmin) to enable it; verifying only needs read access.
+//
+// It is a no-op against the emulator, which has no admin API and no TTL.
+func (s *Store[K, V]) EnsureTTLPolicy(ctx context.Context) error {
+	if s.emulator != nil {
+		return nil
+	}
+	if s.authCfg != nil {
+		ctx = auth.WithConfig(ctx, s.authCfg)
+	}
+	token, err := auth.AccessToken(ctx)
+	if err != nil {
+		return fmt.Errorf("get access token: %w", err)
+	}
+
+	db := s.database
+	if db == "" {
+		db = defaultDatabase
+	}
+	u := fmt.Sprintf("%s/projects/%s/databases/%s/collectionGroups/%s/fields/%s",
+		s.adminURL, url.PathEscape(s.project), url.PathEscape(db), url.PathEscape(s.kind), expiryField)
+
+	var f field
+	if err := adminRequest(ctx, http.MethodGet, u, token, nil, &f); err != nil {
+		return fmt.Errorf("get TTL policy: %w", err)
+	}
+	if f.TTLConfig != nil && (f.TTLConfig.State == ttlStateActive || f.TTLConfig.State == ttlStateCreating) {
+		return nil
+	}
+
+	// Missing or NEEDS_REPAIR: (re)submit the policy. The response is a
+	// long-running operation we don't need to wait on.
+	body := []byte(`{"ttlConfig":{}}`)
+	if err := adminRequest(ctx, http.MethodPatch, u+"?updateMask=ttlConfig", token, body, nil); err != nil {
+		if f.TTLConfig != nil && f.TTLConfig.State == ttlStateNeedsRepair {
+			return fmt.Errorf("%w: %w", ErrTTLPolicyNeedsRepair, err)
+		}
+		return fmt.Errorf("enable TTL policy: %w", err)
+	}
+	return nil
+}
+
+// adminRequest sends a Firestore Admin API request, decoding the response into out if non-nil.
+func adminRequest(ctx context.Context, method, u, token string, body []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := adminClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck // best-effort close of response body
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAdminBodySize))
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, data)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
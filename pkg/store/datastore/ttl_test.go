@@ -0,0 +1,129 @@
+package datastore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/codeGROOVE-dev/ds9/auth"
+	"github.com/codeGROOVE-dev/ds9/pkg/mock"
+)
+
+// fakeAdmin serves the Firestore Admin fields endpoint for the expiry property.
+//
+//nolint:govet // fieldalignment: test helper
+type fakeAdmin struct {
+	mu      sync.Mutex
+	state   string // "" means no TTL policy
+	patches int
+	failPut bool
+}
+
+func (f *fakeAdmin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path != "/projects/test-project/databases/mydb/collectionGroups/CacheEntry/fields/expiry" {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		if f.state == "" {
+			_, _ = io.WriteString(w, `{"name":"expiry"}`) //nolint:errcheck // test server
+			return
+		}
+		_, _ = io.WriteString(w, `{"name":"expiry","ttlConfig":{"state":"`+f.state+`"}}`) //nolint:errcheck // test server
+	case http.MethodPatch:
+		if r.URL.Query().Get("updateMask") != "ttlConfig" {
+			http.Error(w, "bad mask", http.StatusBadRequest)
+			return
+		}
+		if f.failPut {
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+		f.patches++
+		f.state = ttlStateCreating
+		_, _ = io.WriteString(w, `{"name":"operations/1"}`) //nolint:errcheck // test server
+	default:
+		http.Error(w, "method", http.StatusMethodNotAllowed)
+	}
+}
+
+func newTTLTestStore(t *testing.T, admin *fakeAdmin) *Store[string, int] {
+	t.Helper()
+	metadataURL, _, cleanup := mock.NewMockServers(t)
+	t.Cleanup(cleanup)
+	srv := httptest.NewServer(admin)
+	t.Cleanup(srv.Close)
+
+	return &Store[string, int]{
+		kind:     datastoreKind,
+		project:  "test-project",
+		database: "mydb",
+		adminURL: srv.URL,
+		authCfg:  &auth.Config{MetadataURL: metadataURL, SkipADC: true},
+	}
+}
+
+func TestDatastorePersist_EnsureTTLPolicy(t *testing.T) {
+	ctx := context.Background()
+	admin := &fakeAdmin{}
+	s := newTTLTestStore(t, admin)
+
+	if err := s.EnsureTTLPolicy(ctx); err != nil {
+		t.Fatalf("EnsureTTLPolicy: %v", err)
+	}
+	if admin.patches != 1 {
+		t.Errorf("patches = %d; want 1", admin.patches)
+	}
+
+	// Already configured: verify only.
+	if err := s.EnsureTTLPolicy(ctx); err != nil {
+		t.Fatalf("EnsureTTLPolicy (creating): %v", err)
+	}
+	admin.state = ttlStateActive
+	if err := s.EnsureTTLPolicy(ctx); err != nil {
+		t.Fatalf("EnsureTTLPolicy (active): %v", err)
+	}
+	if admin.patches != 1 {
+		t.Errorf("patches = %d; want 1 (no re-enable when configured)", admin.patches)
+	}
+}
+
+func TestDatastorePersist_EnsureTTLPolicy_Repair(t *testing.T) {
+	ctx := context.Background()
+	admin := &fakeAdmin{state: ttlStateNeedsRepair}
+	s := newTTLTestStore(t, admin)
+
+	if err := s.EnsureTTLPolicy(ctx); err != nil {
+		t.Fatalf("EnsureTTLPolicy: %v", err)
+	}
+	if admin.patches != 1 {
+		t.Errorf("patches = %d; want 1", admin.patches)
+	}
+
+	admin.state = ttlStateNeedsRepair
+	admin.failPut = true
+	if err := s.EnsureTTLPolicy(ctx); !errors.Is(err, ErrTTLPolicyNeedsRepair) {
+		t.Errorf("EnsureTTLPolicy error = %v; want ErrTTLPolicyNeedsRepair", err)
+	}
+}
+
+func TestDatastorePersist_EnsureTTLPolicy_PermissionDenied(t *testing.T) {
+	admin := &fakeAdmin{failPut: true}
+	s := newTTLTestStore(t, admin)
+
+	err := s.EnsureTTLPolicy(context.Background())
+	if err == nil {
+		t.Fatal("EnsureTTLPolicy should fail when the policy cannot be enabled")
+	}
+	if errors.Is(err, ErrTTLPolicyNeedsRepair) {
+		t.Errorf("missing policy should not report NeedsRepair: %v", err)
+	}
+}
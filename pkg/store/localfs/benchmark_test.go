@@ -58,6 +58,7 @@ func runSequentialBenchmarks(t *testing.T) {
 		{"Set (warm)", benchSetWarm},
 		{"Get (hit)", benchGetHit},
 		{"Get (miss)", benchGetMiss},
+		{"Get (indexed)", benchGetMissIndex},
 		{"Delete", benchDelete},
 	}
 
@@ -103,6 +104,7 @@ func BenchmarkLocalFSSetCold(b *testing.B)       { benchSetCold(b) }
 func BenchmarkLocalFSSetWarm(b *testing.B)       { benchSetWarm(b) }
 func BenchmarkLocalFSGetHit(b *testing.B)        { benchGetHit(b) }
 func BenchmarkLocalFSGetMiss(b *testing.B)       { benchGetMiss(b) }
+func BenchmarkLocalFSGetMissIndex(b *testing.B)  { benchGetMissIndex(b) }
 func BenchmarkLocalFSDelete(b *testing.B)        { benchDelete(b) }
 func BenchmarkLocalFSConcurrent(b *testing.B)    { benchConcurrent(b) }
 func BenchmarkLocalFSSetSmall(b *testing.B)      { benchSetValueSizeFactory(smallValueSize)(b) }
@@ -185,6 +187,20 @@ func benchGetMiss(b *testing.B) {
 	}
 }
 
+func benchGetMissIndex(b *testing.B) {
+	store, err := NewWithConfig[string, []byte]("bench", Config{Dir: b.TempDir(), KeyIndex: true})
+	if err != nil {
+		b.Fatalf("failed to create store: %v", err)
+	}
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := range b.N {
+		key := "miss-" + strconv.Itoa(i)
+		store.Get(ctx, key)
+	}
+}
+
 func benchDelete(b *testing.B) {
 	store := createTestStore(b)
 	ctx := context.Background()
@@ -0,0 +1,34 @@
+package localfs
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/codeGROOVE-dev/fido/pkg/store/compress"
+	"github.com/codeGROOVE-dev/fido/pkg/store/persisttest"
+)
+
+func TestConformance(t *testing.T) {
+	for name, c := range map[string]compress.Compressor{"none": compress.None(), "s2": compress.S2()} {
+		t.Run(name, func(t *testing.T) {
+			persisttest.TestStore(t, func(t *testing.T) persisttest.Store[string, string] {
+				dir := t.TempDir()
+				s, err := New[string, string](filepath.Base(dir), filepath.Dir(dir), c)
+				if err != nil {
+					t.Fatalf("New: %v", err)
+				}
+				return s
+			})
+		})
+	}
+	t.Run("keyindex", func(t *testing.T) {
+		persisttest.TestStore(t, func(t *testing.T) persisttest.Store[string, string] {
+			dir := t.TempDir()
+			s, err := NewWithConfig[string, string](filepath.Base(dir), Config{Dir: filepath.Dir(dir), KeyIndex: true})
+			if err != nil {
+				t.Fatalf("NewWithConfig: %v", err)
+			}
+			return s
+		})
+	})
+}
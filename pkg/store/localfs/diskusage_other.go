@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package localfs
+
+import (
+	"context"
+	"errors"
+)
+
+// DiskUsage is only implemented on Linux and macOS.
+// Implements fido.DiskUsager.
+func (*Store[K, V]) DiskUsage(context.Context) (used, total int64, err error) {
+	return 0, 0, errors.ErrUnsupported
+}
@@ -0,0 +1,22 @@
+//go:build linux || darwin
+
+package localfs
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+)
+
+// DiskUsage returns the bytes used and the total bytes of the filesystem
+// holding Dir. Implements fido.DiskUsager.
+func (s *Store[K, V]) DiskUsage(context.Context) (used, total int64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(s.Dir, &st); err != nil {
+		return 0, 0, fmt.Errorf("statfs: %w", err)
+	}
+	bsize := int64(st.Bsize)                 //nolint:unconvert // int64 on Linux, uint32 on macOS
+	total = int64(st.Blocks) * bsize         //nolint:gosec // G115: block counts fit in int64
+	used = int64(st.Blocks-st.Bfree) * bsize //nolint:gosec // G115: block counts fit in int64
+	return used, total, nil
+}
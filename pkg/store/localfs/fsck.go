@@ -0,0 +1,170 @@
+package localfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+const (
+	// fsckTempAge is how old a .tmp file must be before Fsck treats it as left
+	// behind by a crashed write rather than one still in progress.
+	fsckTempAge = time.Hour
+	// fsckMaxExpiry is how far ahead an expiry may be before Fsck calls it absurd.
+	fsckMaxExpiry = 100 * 365 * 24 * time.Hour
+)
+
+// fsckMinExpiry is the earliest expiry Fsck considers plausible; no entry
+// could have been written with an earlier one.
+var fsckMinExpiry = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Report lists the problems Fsck found, as paths under Dir.
+type Report struct {
+	TempFiles []string // .tmp files left behind by interrupted writes
+	EmptyDirs []string // fan-out subdirectories holding no files
+	Corrupt   []string // entries and streamed values that cannot be decoded
+	BadExpiry []string // entries expiring before 2000 or over a century from now
+	Removed   int      // files and directories removed, when fixing
+}
+
+// Fsck checks the store directory for temp files left by interrupted writes,
+// empty fan-out subdirectories, undecodable entries, and entries with absurd
+// expiry values. With fix set, it removes each of them and reports how many
+// it removed; otherwise it only reports them. Entries the decode fallback
+// can read are not corrupt.
+//
+// Fixing is meant for quiet periods: a Set racing the removal of the empty
+// directory it is writing to may fail.
+func (s *Store[K, V]) Fsck(ctx context.Context, fix bool) (Report, error) {
+	var r Report
+	var dirs []string
+	var errs []error
+	now := time.Now()
+
+	walkErr := filepath.WalkDir(s.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("walk %s: %w", path, err))
+			return nil
+		}
+		if d.IsDir() {
+			if path != s.Dir {
+				dirs = append(dirs, path)
+			}
+			return nil
+		}
+		switch name := d.Name(); {
+		case filepath.Ext(name) == ".tmp":
+			fi, err := d.Info()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("stat %s: %w", path, err))
+			} else if now.Sub(fi.ModTime()) > fsckTempAge {
+				r.TempFiles = append(r.TempFiles, path)
+			}
+		case isBlobFile(name):
+			expiry, err := readBlobExpiry(path)
+			errs = r.check(path, expiry, err, now, errs)
+		case s.isCacheFile(name):
+			expiry, err := s.readExpiry(path)
+			errs = r.check(path, expiry, err, now, errs)
+		}
+		return nil
+	})
+	if walkErr != nil {
+		return r, errors.Join(append(errs, fmt.Errorf("walk directory: %w", walkErr))...)
+	}
+
+	if fix {
+		for _, path := range slices.Concat(r.TempFiles, r.Corrupt, r.BadExpiry) {
+			rm := os.Remove
+			if s.isCacheFile(path) {
+				rm = s.removeEntry
+			}
+			if err := rm(path); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, fmt.Errorf("remove %s: %w", path, err))
+			} else {
+				r.Removed++
+			}
+		}
+	}
+
+	// Deepest first, so a parent emptied by removing its children is seen empty.
+	for _, dir := range slices.Backward(dirs) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				errs = append(errs, fmt.Errorf("read %s: %w", dir, err))
+			}
+			continue
+		}
+		if len(entries) > 0 {
+			continue
+		}
+		r.EmptyDirs = append(r.EmptyDirs, dir)
+		if fix {
+			if err := s.removeDir(dir); err != nil {
+				errs = append(errs, fmt.Errorf("remove %s: %w", dir, err))
+			} else {
+				r.Removed++
+			}
+		}
+	}
+	return r, errors.Join(errs...)
+}
+
+// check records an entry that failed to decode or has an absurd expiry,
+// appending other read errors to errs.
+func (r *Report) check(path string, expiry time.Time, err error, now time.Time, errs []error) []error {
+	var corrupt *corruptError
+	switch {
+	case errors.As(err, &corrupt):
+		r.Corrupt = append(r.Corrupt, path)
+	case err != nil:
+		errs = append(errs, fmt.Errorf("read %s: %w", path, err))
+	case !expiry.IsZero() && (expiry.Before(fsckMinExpiry) || expiry.After(now.Add(fsckMaxExpiry))):
+		r.BadExpiry = append(r.BadExpiry, path)
+	}
+	return errs
+}
+
+// readExpiry decodes the entry at path and returns its expiry.
+func (s *Store[K, V]) readExpiry(path string) (time.Time, error) {
+	e, err := s.readEntry(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return e.Expiry, nil
+}
+
+// readBlobExpiry reads the expiry from the header of the streamed value at path.
+func readBlobExpiry(path string) (time.Time, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close() //nolint:errcheck // read-only
+	_, expiry, err := readBlobHeader(f)
+	if err != nil {
+		return time.Time{}, &corruptError{fmt.Errorf("decode file: %w", err)}
+	}
+	return expiry, nil
+}
+
+// removeDir removes an empty subdirectory and forgets that ensureDir made it,
+// so the next Set into it creates it again.
+func (s *Store[K, V]) removeDir(dir string) error {
+	s.subdirsMu.Lock()
+	defer s.subdirsMu.Unlock()
+	if err := os.Remove(dir); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	delete(s.subdirsMade, dir)
+	return nil
+}
@@ -0,0 +1,60 @@
+package localfs
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// FuzzKeyToFilename checks that every key ValidateKey accepts maps to a file
+// inside the store directory, whatever bytes the key holds.
+func FuzzKeyToFilename(f *testing.F) {
+	for _, k := range []string{"a", "key", "../../etc/passwd", `..\..\x`, "/abs", "a/b", "\x00", ".", "C:\\x", strings.Repeat("x", maxKeyLength)} {
+		f.Add(k, uint8(0))
+	}
+	f.Add("epoch", uint8(3))
+
+	dir := f.TempDir()
+	s, err := New[string, int](filepath.Base(dir), filepath.Dir(dir))
+	if err != nil {
+		f.Fatalf("New: %v", err)
+	}
+
+	f.Fuzz(func(t *testing.T, key string, epoch uint8) {
+		if err := s.ValidateKey(key); err != nil {
+			if key != "" && len(key) <= maxKeyLength {
+				t.Fatalf("ValidateKey(%q) = %v; want nil", key, err)
+			}
+			return
+		}
+
+		// Only this goroutine uses s, so setting the epoch directly is safe.
+		s.epoch.Store(uint64(epoch))
+		name := s.keyToFilename(key)
+		if !filepath.IsLocal(name) {
+			t.Fatalf("keyToFilename(%q) = %q; want a local path", key, name)
+		}
+		sub, base := filepath.Split(name)
+		if len(sub) != 3 || !strings.HasPrefix(base, sub[:2]) || filepath.Ext(base) != s.ext {
+			t.Fatalf("keyToFilename(%q) = %q; want xx/xx...%s", key, name, s.ext)
+		}
+		// Lowercase hex names cannot collide on case-insensitive filesystems
+		// or hit Windows reserved names.
+		for _, part := range strings.Split(name, string(filepath.Separator)) {
+			if part != strings.ToLower(part) || checkWindowsName(part) != nil {
+				t.Fatalf("keyToFilename(%q) = %q; %q is not portable", key, name, part)
+			}
+		}
+		if again := s.keyToFilename(key); again != name {
+			t.Fatalf("keyToFilename(%q) not deterministic: %q then %q", key, name, again)
+		}
+		if other := s.keyToFilename(key + "\x00"); other == name {
+			t.Fatalf("keys %q and %q share file %q", key, key+"\x00", name)
+		}
+
+		loc := s.Location(key)
+		if rel, err := filepath.Rel(s.Dir, loc); err != nil || rel != name {
+			t.Fatalf("Location(%q) = %q; want %q under %q", key, loc, name, s.Dir)
+		}
+	})
+}
@@ -4,8 +4,25 @@ go 1.25.4
 
 require (
 	github.com/codeGROOVE-dev/fido/pkg/store/compress v1.10.0
+	github.com/codeGROOVE-dev/fido/pkg/store/registry v1.10.0
 	github.com/klauspost/compress v1.18.3
 	github.com/pierrec/lz4/v4 v4.1.22
 )
 
+// Test-only: the persisttest conformance suite.
+require github.com/codeGROOVE-dev/fido/pkg/store/persisttest v1.10.0
+
+require (
+	github.com/codeGROOVE-dev/fido/pkg/store/memstore v1.10.0 // indirect
+	github.com/codeGROOVE-dev/fido/pkg/store/null v1.10.0 // indirect
+)
+
 replace github.com/codeGROOVE-dev/fido/pkg/store/compress => ../compress
+
+replace github.com/codeGROOVE-dev/fido/pkg/store/persisttest => ../persisttest
+
+replace github.com/codeGROOVE-dev/fido/pkg/store/registry => ../registry
+
+replace github.com/codeGROOVE-dev/fido/pkg/store/null => ../null
+
+replace github.com/codeGROOVE-dev/fido/pkg/store/memstore => ../memstore
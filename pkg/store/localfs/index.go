@@ -0,0 +1,218 @@
+package localfs
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	indexFile  = ".keyindex" // KeyIndex saved by Close
+	indexMagic = "fidokix1"
+	indexLocks = 64
+)
+
+// keyIndex holds the ids of entry files on disk, so Get can skip opening files
+// that don't exist. An id is the first 8 bytes of the filename hash; a collision
+// only costs the open that the index would otherwise have saved.
+//
+//nolint:govet // fieldalignment: semantic grouping preferred
+type keyIndex struct {
+	mu    sync.RWMutex
+	ids   map[uint64]struct{}
+	locks [indexLocks]sync.Mutex // pair a file change with its index update
+	path  string
+	ext   string
+	mode  os.FileMode
+}
+
+// fileID returns the index id of the file named for sum.
+func fileID(sum [sha256.Size]byte) uint64 {
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+// nameID parses the index id from an entry's file name.
+func nameID(name string) (uint64, bool) {
+	if len(name) < 16 {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(name[:16], 16, 64)
+	return id, err == nil
+}
+
+// loadKeyIndex reads the index saved by the last Close and removes it, so an
+// unclean shutdown leaves no stale index behind. Without a usable saved index,
+// it lists dir for entry files instead.
+func loadKeyIndex(dir, ext string, mode os.FileMode) (*keyIndex, error) {
+	x := &keyIndex{path: filepath.Join(dir, indexFile), ext: ext, mode: mode}
+	f, err := os.Open(x.path)
+	switch {
+	case err == nil:
+		x.ids, err = readIndex(bufio.NewReader(f), ext)
+		cerr := f.Close()
+		rmErr := os.Remove(x.path)
+		if cerr != nil || (rmErr != nil && !os.IsNotExist(rmErr)) {
+			return nil, fmt.Errorf("consume key index: %w", errors.Join(cerr, rmErr))
+		}
+		if err == nil {
+			return x, nil
+		}
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("open key index: %w", err)
+	}
+
+	x.ids = make(map[uint64]struct{})
+	err = filepath.WalkDir(dir, func(_ string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(d.Name()) != ext {
+			return nil
+		}
+		if id, ok := nameID(d.Name()); ok {
+			x.ids[id] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("build key index: %w", err)
+	}
+	return x, nil
+}
+
+// readIndex decodes a saved index: magic, extension, count, then sorted ids.
+// An index saved for another extension is rejected.
+func readIndex(r io.Reader, ext string) (map[uint64]struct{}, error) {
+	var hdr [len(indexMagic) + 2]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+	if string(hdr[:len(indexMagic)]) != indexMagic {
+		return nil, errors.New("bad key index magic")
+	}
+	e := make([]byte, binary.BigEndian.Uint16(hdr[len(indexMagic):]))
+	if _, err := io.ReadFull(r, e); err != nil {
+		return nil, err
+	}
+	if string(e) != ext {
+		return nil, fmt.Errorf("key index is for %q files", e)
+	}
+	var n uint64
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return nil, err
+	}
+	ids := make(map[uint64]struct{}, min(n, 1<<20))
+	var buf [8]byte
+	for range n {
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return nil, err
+		}
+		ids[binary.BigEndian.Uint64(buf[:])] = struct{}{}
+	}
+	return ids, nil
+}
+
+// save writes the index for the next New to load.
+func (x *keyIndex) save() error {
+	x.mu.RLock()
+	ids := make([]uint64, 0, len(x.ids))
+	for id := range x.ids {
+		ids = append(ids, id)
+	}
+	x.mu.RUnlock()
+
+	tmp := x.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, x.mode)
+	if err != nil {
+		return fmt.Errorf("create key index: %w", err)
+	}
+	if x.mode != defaultFileMode {
+		err = f.Chmod(x.mode) // OpenFile's mode is subject to umask
+	}
+	w := bufio.NewWriter(f)
+	buf := append([]byte(indexMagic), 0, 0)
+	binary.BigEndian.PutUint16(buf[len(indexMagic):], uint16(len(x.ext))) //nolint:gosec // G115: extensions are short
+	buf = append(buf, x.ext...)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(len(ids)))
+	if err == nil {
+		_, err = w.Write(buf)
+	}
+	for _, id := range ids {
+		if err != nil {
+			break
+		}
+		_, err = w.Write(binary.BigEndian.AppendUint64(buf[:0], id))
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, x.path)
+	}
+	if err != nil {
+		rmErr := os.Remove(tmp)
+		return errors.Join(fmt.Errorf("save key index: %w", err), rmErr)
+	}
+	return nil
+}
+
+func (x *keyIndex) contains(id uint64) bool {
+	x.mu.RLock()
+	_, ok := x.ids[id]
+	x.mu.RUnlock()
+	return ok
+}
+
+// update runs op, which creates (exists) or removes the file for id, and
+// records the outcome. Changes to the same id are serialized so the index
+// matches whichever change reached the disk last.
+func (x *keyIndex) update(id uint64, exists bool, op func() error) error {
+	l := &x.locks[id%indexLocks]
+	l.Lock()
+	defer l.Unlock()
+	if err := op(); err != nil {
+		return err
+	}
+	x.mu.Lock()
+	if exists {
+		x.ids[id] = struct{}{}
+	} else {
+		delete(x.ids, id)
+	}
+	x.mu.Unlock()
+	return nil
+}
+
+// indexed runs op and records it in the index, if the store keeps one.
+func (s *Store[K, V]) indexed(id uint64, exists bool, op func() error) error {
+	if s.index == nil {
+		return op()
+	}
+	return s.index.update(id, exists, op)
+}
+
+// removeEntry removes the entry file at path, treating an absent file as removed.
+func (s *Store[K, V]) removeEntry(path string) error {
+	rm := func() error {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	id, ok := nameID(strings.TrimSuffix(filepath.Base(path), s.ext))
+	if !ok {
+		return rm()
+	}
+	return s.indexed(id, false, rm)
+}
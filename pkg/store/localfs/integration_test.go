@@ -2,11 +2,15 @@ package localfs
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -295,6 +299,10 @@ func TestFilePersist_ValidateKey(t *testing.T) {
 			}
 		})
 	}
+
+	if got := fp.MaxKeyLength(); got != len(validMaxKey) {
+		t.Errorf("MaxKeyLength() = %d; want %d", got, len(validMaxKey))
+	}
 }
 
 func TestFilePersist_Cleanup(t *testing.T) {
@@ -427,8 +435,11 @@ func TestFilePersist_LoadErrors(t *testing.T) {
 	if found {
 		t.Error("Load should not find corrupted entry")
 	}
-	// Error is acceptable for corrupted data
-	_ = err
+	// The error marks the entry corrupt, for read repair.
+	var c interface{ Corrupt() bool }
+	if !errors.As(err, &c) || !c.Corrupt() {
+		t.Errorf("Get error = %v; want one reporting Corrupt", err)
+	}
 }
 
 func TestFilePersist_StoreCreateDir(t *testing.T) {
@@ -1533,3 +1544,796 @@ func TestFilePersist_Compression_FlushRespectExtension(t *testing.T) {
 		t.Errorf("S2 Len = %d; want 5 (should not be affected by None flush)", n)
 	}
 }
+
+func TestFilePersist_HotKeys(t *testing.T) {
+	dir := t.TempDir()
+	fp, err := New[string, int](filepath.Base(dir), filepath.Dir(dir))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	ctx := context.Background()
+	if keys, err := fp.LoadHotKeys(ctx); err != nil || keys != nil {
+		t.Fatalf("LoadHotKeys before any save = %v, %v; want nil, nil", keys, err)
+	}
+	if err := fp.Set(ctx, "a", 1, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := fp.SaveHotKeys(ctx, []string{"b", "a"}); err != nil {
+		t.Fatalf("SaveHotKeys: %v", err)
+	}
+
+	// The list survives a restart and is neither an entry nor flushed as one.
+	fp2, err := New[string, int](filepath.Base(dir), filepath.Dir(dir))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if n, err := fp2.Len(ctx); err != nil || n != 1 {
+		t.Errorf("Len = %d, %v; want 1", n, err)
+	}
+	if n, err := fp2.Flush(ctx); err != nil || n != 1 {
+		t.Errorf("Flush = %d, %v; want 1", n, err)
+	}
+	if keys, err := fp2.LoadHotKeys(ctx); err != nil || !slices.Equal(keys, []string{"b", "a"}) {
+		t.Errorf("LoadHotKeys = %v, %v; want [b a]", keys, err)
+	}
+}
+
+func TestFilePersist_BumpEpoch(t *testing.T) {
+	dir := t.TempDir()
+	fp, err := New[string, int](filepath.Base(dir), filepath.Dir(dir))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := fp.Set(ctx, "key1", 1, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	epoch, err := fp.BumpEpoch(ctx)
+	if err != nil {
+		t.Fatalf("BumpEpoch: %v", err)
+	}
+	if epoch != 1 {
+		t.Errorf("BumpEpoch() = %d; want 1", epoch)
+	}
+
+	if _, _, found, err := fp.Get(ctx, "key1"); err != nil || found {
+		t.Errorf("Get after bump: found=%v err=%v; want miss", found, err)
+	}
+
+	if err := fp.Set(ctx, "key1", 2, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	// Epoch survives a restart.
+	fp2, err := New[string, int](filepath.Base(dir), filepath.Dir(dir))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if val, _, found, err := fp2.Get(ctx, "key1"); err != nil || !found || val != 2 {
+		t.Errorf("Get after restart = %d, %v, %v; want 2, true, nil", val, found, err)
+	}
+
+	// Cleanup reclaims the orphaned pre-bump file.
+	n, err := fp2.Cleanup(ctx, 0)
+	if err != nil {
+		t.Fatalf("Cleanup: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Cleanup() = %d; want 1 orphaned file", n)
+	}
+	if l, _ := fp2.Len(ctx); l != 1 { //nolint:errcheck // test
+		t.Errorf("Len() = %d; want 1", l)
+	}
+}
+
+func TestFilePersist_OrphansHidden(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	fp, err := New[string, int](filepath.Base(dir), filepath.Dir(dir))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := fp.SetOwned(ctx, "a", 1, time.Time{}, "team-a"); err != nil {
+		t.Fatalf("SetOwned: %v", err)
+	}
+	if err := fp.SetReader(ctx, "blob", strings.NewReader("data"), time.Time{}); err != nil {
+		t.Fatalf("SetReader: %v", err)
+	}
+	if n, err := fp.Len(ctx); err != nil || n != 2 {
+		t.Fatalf("Len = %d, %v; want 2, nil", n, err)
+	}
+
+	// Scans see only what Get can reach, after either way of orphaning files.
+	check := func(step string, want []string) {
+		t.Helper()
+		if n, err := fp.Len(ctx); err != nil || n != len(want) {
+			t.Errorf("%s: Len = %d, %v; want %d", step, n, err, len(want))
+		}
+		if got := slices.Sorted(fp.Keys(ctx, "")); !slices.Equal(got, want) {
+			t.Errorf("%s: Keys = %v; want %v", step, got, want)
+		}
+		var owned []string
+		if slices.Contains(want, "a") {
+			owned = []string{"a"}
+		}
+		if got := slices.Collect(fp.OwnerKeys(ctx, "team-a")); !slices.Equal(got, owned) {
+			t.Errorf("%s: OwnerKeys = %v; want %v", step, got, owned)
+		}
+	}
+	if _, err := fp.BumpEpoch(ctx); err != nil {
+		t.Fatalf("BumpEpoch: %v", err)
+	}
+	check("after BumpEpoch", nil)
+
+	if err := fp.SetOwned(ctx, "a", 2, time.Time{}, "team-a"); err != nil {
+		t.Fatalf("SetOwned: %v", err)
+	}
+	check("after rewriting a", []string{"a"})
+	for k, v := range fp.Range(ctx, "") {
+		if v != 2 {
+			t.Errorf("Range yielded %s = %d; want the current epoch's 2", k, v)
+		}
+	}
+
+	if err := fp.SetVersion("v2"); err != nil {
+		t.Fatalf("SetVersion: %v", err)
+	}
+	check("after SetVersion", nil)
+}
+
+func TestFilePersist_ValidateValue(t *testing.T) {
+	fp, err := New[string, any]("test", t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer func() {
+		if err := fp.Close(); err != nil {
+			t.Logf("Close error: %v", err)
+		}
+	}()
+
+	if err := fp.ValidateValue(map[string]int{"a": 1}); err != nil {
+		t.Errorf("ValidateValue(map): %v", err)
+	}
+	if err := fp.ValidateValue(func() {}); err == nil {
+		t.Error("ValidateValue(func) should fail: not JSON-encodable")
+	}
+}
+
+func TestFilePersist_Stream(t *testing.T) {
+	dir := t.TempDir()
+	fp, err := New[string, int](filepath.Base(dir), filepath.Dir(dir))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+
+	payload := strings.Repeat("0123456789", 100_000)
+	exp := time.Now().Add(time.Hour).Truncate(time.Nanosecond)
+	if err := fp.SetReader(ctx, "big", strings.NewReader(payload), exp); err != nil {
+		t.Fatalf("SetReader: %v", err)
+	}
+	if err := fp.Set(ctx, "big", 7, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	rc, gotExp, found, err := fp.GetReader(ctx, "big")
+	if err != nil || !found {
+		t.Fatalf("GetReader = %v, %v; want found", found, err)
+	}
+	b, err := io.ReadAll(rc)
+	if cerr := rc.Close(); cerr != nil {
+		t.Errorf("Close: %v", cerr)
+	}
+	if err != nil || string(b) != payload {
+		t.Errorf("read %d bytes, %v; want %d bytes", len(b), err, len(payload))
+	}
+	if !gotExp.Equal(exp) {
+		t.Errorf("expiry = %v; want %v", gotExp, exp)
+	}
+	// Streams and values share keys but not files.
+	if v, _, found, _ := fp.Get(ctx, "big"); !found || v != 7 { //nolint:errcheck // checked via found
+		t.Errorf("Get(big) = %d, %v; want 7, true", v, found)
+	}
+	if n, _ := fp.Len(ctx); n != 2 { //nolint:errcheck // checked via n
+		t.Errorf("Len = %d; want 2", n)
+	}
+
+	if err := fp.Delete(ctx, "big"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, _, found, _ := fp.GetReader(ctx, "big"); found { //nolint:errcheck // checked via found
+		t.Error("Delete should remove streamed values")
+	}
+
+	// Expired streams read as missing and are removed.
+	if err := fp.SetReader(ctx, "old", strings.NewReader("x"), time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("SetReader: %v", err)
+	}
+	if _, _, found, err := fp.GetReader(ctx, "old"); found || err != nil {
+		t.Errorf("GetReader(old) = %v, %v; want not found", found, err)
+	}
+	if _, err := os.Stat(filepath.Join(fp.Dir, fp.blobFilename("old"))); !os.IsNotExist(err) {
+		t.Errorf("expired stream file remains: %v", err)
+	}
+
+	// A cancelled context aborts the copy and leaves nothing behind.
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := fp.SetReader(cctx, "cancelled", strings.NewReader(payload), time.Time{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("SetReader(cancelled) = %v; want context.Canceled", err)
+	}
+	if _, _, found, _ := fp.GetReader(ctx, "cancelled"); found { //nolint:errcheck // checked via found
+		t.Error("cancelled SetReader left a stream")
+	}
+}
+
+func TestFilePersist_Stream_CleanupFlush(t *testing.T) {
+	dir := t.TempDir()
+	fp, err := New[string, int](filepath.Base(dir), filepath.Dir(dir))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+
+	for _, k := range []string{"a", "b", "orphan"} {
+		if err := fp.SetReader(ctx, k, strings.NewReader(k), time.Time{}); err != nil {
+			t.Fatalf("SetReader(%s): %v", k, err)
+		}
+	}
+	if err := fp.SetReader(ctx, "stale", strings.NewReader("x"), time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("SetReader(stale): %v", err)
+	}
+
+	// After an epoch bump, old streams are unreachable and reclaimed by Cleanup.
+	if _, err := fp.BumpEpoch(ctx); err != nil {
+		t.Fatalf("BumpEpoch: %v", err)
+	}
+	if _, _, found, _ := fp.GetReader(ctx, "a"); found { //nolint:errcheck // checked via found
+		t.Error("stream visible after BumpEpoch")
+	}
+	if err := fp.SetReader(ctx, "fresh", strings.NewReader("x"), time.Time{}); err != nil {
+		t.Fatalf("SetReader(fresh): %v", err)
+	}
+	n, err := fp.Cleanup(ctx, 0)
+	if err != nil || n != 4 {
+		t.Errorf("Cleanup = %d, %v; want 4 (3 orphaned, 1 expired)", n, err)
+	}
+	if _, _, found, _ := fp.GetReader(ctx, "fresh"); !found { //nolint:errcheck // checked via found
+		t.Error("Cleanup removed a live stream")
+	}
+
+	if n, err := fp.Flush(ctx); err != nil || n != 1 {
+		t.Errorf("Flush = %d, %v; want 1", n, err)
+	}
+}
+
+func TestFilePersist_KeyIndex(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	id, base := filepath.Base(dir), filepath.Dir(dir)
+	open := func() *Store[string, int] {
+		t.Helper()
+		s, err := NewWithConfig[string, int](id, Config{Dir: base, KeyIndex: true})
+		if err != nil {
+			t.Fatalf("NewWithConfig: %v", err)
+		}
+		return s
+	}
+	plain, err := New[string, int](id, base)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	s := open()
+	for i, k := range []string{"a", "b"} {
+		if err := s.Set(ctx, k, i, time.Time{}); err != nil {
+			t.Fatalf("Set(%s): %v", k, err)
+		}
+	}
+	if v, _, found, err := s.Get(ctx, "b"); err != nil || !found || v != 1 {
+		t.Errorf("Get(b) = %d, %v, %v; want 1, true, nil", v, found, err)
+	}
+	if err := s.Delete(ctx, "a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, _, found, _ := s.Get(ctx, "a"); found { //nolint:errcheck // found is enough
+		t.Error("Get(a) found a deleted key")
+	}
+
+	// Files written behind the index's back are not opened.
+	if err := plain.Set(ctx, "c", 2, time.Time{}); err != nil {
+		t.Fatalf("Set(c): %v", err)
+	}
+	if _, _, found, _ := s.Get(ctx, "c"); found { //nolint:errcheck // found is enough
+		t.Error("Get(c) opened a file missing from the index")
+	}
+
+	// Close saves the index; the next store loads and consumes it.
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	s = open()
+	if _, err := os.Stat(filepath.Join(dir, indexFile)); !os.IsNotExist(err) {
+		t.Errorf("saved index left on disk after load: %v", err)
+	}
+	if _, _, found, _ := s.Get(ctx, "b"); !found { //nolint:errcheck // found is enough
+		t.Error("Get(b) missed after reloading the index")
+	}
+	if _, _, found, _ := s.Get(ctx, "c"); found { //nolint:errcheck // found is enough
+		t.Error("Get(c) found a key the saved index never saw")
+	}
+
+	// Without a saved index (unclean shutdown), the index is rebuilt from the files.
+	s = open()
+	if _, _, found, _ := s.Get(ctx, "c"); !found { //nolint:errcheck // found is enough
+		t.Error("Get(c) missed after rebuilding the index")
+	}
+
+	// A corrupt or mismatched index is rebuilt too.
+	if err := os.WriteFile(filepath.Join(dir, indexFile), []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s = open()
+	if _, _, found, _ := s.Get(ctx, "b"); !found { //nolint:errcheck // found is enough
+		t.Error("Get(b) missed after replacing a corrupt index")
+	}
+
+	// Flush and epoch bumps keep the index in step with the files.
+	if _, err := s.BumpEpoch(ctx); err != nil {
+		t.Fatalf("BumpEpoch: %v", err)
+	}
+	if _, _, found, _ := s.Get(ctx, "b"); found { //nolint:errcheck // found is enough
+		t.Error("Get(b) found an entry orphaned by BumpEpoch")
+	}
+	if err := s.Set(ctx, "d", 3, time.Time{}); err != nil {
+		t.Fatalf("Set(d): %v", err)
+	}
+	if _, err := s.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	s.index.mu.RLock()
+	n := len(s.index.ids)
+	s.index.mu.RUnlock()
+	if n != 0 {
+		t.Errorf("index holds %d ids after Flush; want 0", n)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestFilePersist_FanOut(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	id, base := filepath.Base(dir), filepath.Dir(dir)
+
+	for _, cfg := range []Config{{FanOutLevels: 5}, {FanOutWidth: 4}, {FanOutLevels: -1}} {
+		cfg.Dir = base
+		if _, err := NewWithConfig[string, int](id, cfg); err == nil {
+			t.Errorf("NewWithConfig(%+v) succeeded; want error", cfg)
+		}
+	}
+
+	flat, err := New[string, int](id, base)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := flat.Set(ctx, "old", 1, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	s, err := NewWithConfig[string, int](id, Config{Dir: base, FanOutLevels: 2, FanOutWidth: 1, KeyIndex: true})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %v", err)
+	}
+	if err := s.Set(ctx, "k", 2, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	rel, err := filepath.Rel(s.Dir, s.Location("k"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(rel, string(filepath.Separator))
+	if len(parts) != 3 || len(parts[0]) != 1 || len(parts[1]) != 1 || !strings.HasPrefix(parts[2], parts[0]+parts[1]) {
+		t.Errorf("Location(k) = %s; want x/y/xy...", rel)
+	}
+	if _, err := os.Stat(s.Location("k")); err != nil {
+		t.Errorf("entry not at Location: %v", err)
+	}
+	if v, _, found, err := s.Get(ctx, "k"); err != nil || !found || v != 2 {
+		t.Errorf("Get(k) = %d, %v, %v; want 2, true, nil", v, found, err)
+	}
+
+	// Entries in the old layout are unreachable, and Cleanup reclaims them.
+	if _, _, found, _ := s.Get(ctx, "old"); found { //nolint:errcheck // found is enough
+		t.Error("Get(old) found an entry from another layout")
+	}
+	if n, err := s.Cleanup(ctx, time.Hour); err != nil || n != 1 {
+		t.Errorf("Cleanup = %d, %v; want 1, nil", n, err)
+	}
+	if n, err := s.Len(ctx); err != nil || n != 1 {
+		t.Errorf("Len = %d, %v; want 1, nil", n, err)
+	}
+}
+
+func TestFilePersist_WindowsNames(t *testing.T) {
+	for _, name := range []string{"CON", "con", "nul.txt", "Com1", "LPT9 ", "a:b", "a|b", "what?", "trailing.", "trailing ", "tab\tname"} {
+		if err := checkWindowsName(name); err == nil {
+			t.Errorf("checkWindowsName(%q) = nil; want error", name)
+		}
+	}
+	for _, name := range []string{"myapp", "console", "COM10", "nul-cache", "a.b", "ab12"} {
+		if err := checkWindowsName(name); err != nil {
+			t.Errorf("checkWindowsName(%q) = %v; want nil", name, err)
+		}
+	}
+
+	defer func(prev string) { goos = prev }(goos)
+	goos = "windows"
+	dir := t.TempDir()
+	if _, err := New[string, int]("aux", dir); err == nil {
+		t.Error(`New("aux") succeeded on windows; want error`)
+	}
+	if _, err := New[string, int]("myapp", dir); err != nil {
+		t.Errorf(`New("myapp") on windows: %v`, err)
+	}
+}
+
+func TestFilePersist_RelativeDir(t *testing.T) {
+	t.Chdir(t.TempDir())
+	s, err := New[string, int]("myapp", "cache")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if !filepath.IsAbs(s.Dir) || !filepath.IsAbs(s.Location("k")) {
+		t.Errorf("Dir = %q, Location = %q; want absolute paths", s.Dir, s.Location("k"))
+	}
+}
+
+func TestFilePersist_Sync(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewWithConfig[string, int](filepath.Base(dir), Config{Dir: filepath.Dir(dir), Sync: true})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %v", err)
+	}
+	if s.sync == s.Volatile() {
+		t.Errorf("sync = %v with Volatile() = %v; Sync applies only off tmpfs", s.sync, s.Volatile())
+	}
+	if err := s.Set(ctx, "k", 1, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := s.SetReader(ctx, "k", strings.NewReader("blob"), time.Time{}); err != nil {
+		t.Fatalf("SetReader: %v", err)
+	}
+	if v, _, found, err := s.Get(ctx, "k"); err != nil || !found || v != 1 {
+		t.Errorf("Get(k) = %d, %v, %v; want 1, true, nil", v, found, err)
+	}
+}
+
+func TestFilePersist_Volatile(t *testing.T) {
+	const shm = "/dev/shm"
+	if !isVolatile(shm) {
+		t.Skipf("%s is not a tmpfs mount", shm)
+	}
+	dir, err := os.MkdirTemp(shm, "fido-test-")
+	if err != nil {
+		t.Skipf("%s not writable: %v", shm, err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) }) //nolint:errcheck // Test cleanup
+
+	s, err := NewWithConfig[string, int]("cache", Config{Dir: dir, Sync: true})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %v", err)
+	}
+	if !s.Volatile() || s.sync {
+		t.Errorf("Volatile() = %v, sync = %v on tmpfs; want true, false", s.Volatile(), s.sync)
+	}
+	if err := s.Set(context.Background(), "k", 1, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+}
+
+func TestFilePersist_Modes(t *testing.T) {
+	if goos == "windows" {
+		t.Skip("Windows does not have Unix permissions")
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewWithConfig[string, int]("shared", Config{Dir: dir, DirMode: 0o770, FileMode: 0o660, KeyIndex: true})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %v", err)
+	}
+	if err := s.Set(ctx, "k", 1, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, err := s.BumpEpoch(ctx); err != nil {
+		t.Fatalf("BumpEpoch: %v", err)
+	}
+	if err := s.Set(ctx, "k", 2, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	for path, want := range map[string]os.FileMode{
+		s.Dir:                           0o770 | os.ModeDir,
+		filepath.Dir(s.Location("k")):   0o770 | os.ModeDir,
+		s.Location("k"):                 0o660,
+		filepath.Join(s.Dir, epochFile): 0o660,
+		filepath.Join(s.Dir, indexFile): 0o660,
+	} {
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Errorf("Stat: %v", err)
+			continue
+		}
+		if fi.Mode() != want {
+			t.Errorf("mode of %s = %v; want %v", path, fi.Mode(), want)
+		}
+	}
+}
+
+func TestFilePersist_BaseLocation(t *testing.T) {
+	cache := t.TempDir()
+	t.Setenv("CACHE_DIRECTORY", cache+":/elsewhere")
+	s, err := NewWithConfig[string, int]("daemon", Config{Location: SystemCache})
+	if err != nil {
+		t.Fatalf("NewWithConfig: %v", err)
+	}
+	if want := filepath.Join(cache, "daemon"); s.Dir != want {
+		t.Errorf("SystemCache Dir = %q; want %q from CACHE_DIRECTORY", s.Dir, want)
+	}
+
+	t.Setenv("CACHE_DIRECTORY", "")
+	if d, err := SystemCache.dir(); err != nil || (goos != "windows" && d != "/var/cache") {
+		t.Errorf("SystemCache.dir() = %q, %v; want /var/cache", d, err)
+	}
+	if _, err := NewWithConfig[string, int]("x", Config{Location: Location(9)}); err == nil {
+		t.Error("NewWithConfig with an unknown Location succeeded")
+	}
+}
+
+func TestFilePersist_Version(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := New[string, int](filepath.Base(dir), filepath.Dir(dir))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := s.Set(ctx, "k", 1, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	unversioned := s.Location("k")
+
+	if err := s.SetVersion("v2"); err != nil {
+		t.Fatalf("SetVersion: %v", err)
+	}
+	if s.Location("k") == unversioned {
+		t.Fatal("SetVersion did not change the key's file")
+	}
+	if _, _, found, _ := s.Get(ctx, "k"); found { //nolint:errcheck // found is enough
+		t.Error("Get(k) found an entry from another version")
+	}
+	if err := s.Set(ctx, "k", 2, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := s.SetReader(ctx, "k", strings.NewReader("v2 blob"), time.Time{}); err != nil {
+		t.Fatalf("SetReader: %v", err)
+	}
+
+	n, err := s.PurgeVersions(ctx)
+	if err != nil || n != 1 {
+		t.Errorf("PurgeVersions = %d, %v; want 1, nil", n, err)
+	}
+	if _, err := os.Stat(unversioned); !os.IsNotExist(err) {
+		t.Errorf("old version's file survived PurgeVersions: %v", err)
+	}
+	if v, _, found, err := s.Get(ctx, "k"); err != nil || !found || v != 2 {
+		t.Errorf("Get(k) = %d, %v, %v; want 2, true, nil", v, found, err)
+	}
+	if rc, _, found, err := s.GetReader(ctx, "k"); err != nil || !found {
+		t.Errorf("GetReader(k) = %v, %v; want the current version's blob", found, err)
+	} else {
+		rc.Close() //nolint:errcheck,gosec // read-only
+	}
+
+	// Switching back restores the original namespace.
+	if err := s.SetVersion(""); err != nil {
+		t.Fatalf("SetVersion: %v", err)
+	}
+	if s.Location("k") != unversioned {
+		t.Error(`SetVersion("") did not restore the original layout`)
+	}
+}
+
+func TestFilePersist_DecodeFallback(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	type oldUser struct{ ID string }
+	type user struct{ ID int }
+
+	old, err := New[string, oldUser](filepath.Base(dir), filepath.Dir(dir))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for k, id := range map[string]string{"a": "7", "b": "x"} {
+		if err := old.Set(ctx, k, oldUser{ID: id}, time.Time{}); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	if err := old.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	s, err := New[string, user](filepath.Base(dir), filepath.Dir(dir))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer s.Close() //nolint:errcheck // test cleanup
+	s.SetDecodeFallback(func(raw []byte) (user, error) {
+		var u oldUser
+		if err := json.Unmarshal(raw, &u); err != nil {
+			return user{}, err
+		}
+		id, err := strconv.Atoi(u.ID)
+		return user{ID: id}, err
+	})
+
+	if v, _, found, err := s.Get(ctx, "a"); err != nil || !found || v.ID != 7 {
+		t.Errorf("Get(a) = %+v, %v, %v; want {ID:7}, true, nil", v, found, err)
+	}
+	var got []string
+	for k := range s.Keys(ctx, "") {
+		got = append(got, k)
+	}
+	if !slices.Equal(got, []string{"a"}) {
+		t.Errorf("Keys = %v; want [a], skipping the undecodable entry", got)
+	}
+	if _, _, found, err := s.Get(ctx, "b"); err == nil || found {
+		t.Errorf("Get(b) = %v, %v; want the fallback's error", found, err)
+	}
+}
+
+func TestFilePersist_Fsck(t *testing.T) {
+	ctx := context.Background()
+	fp, err := New[string, int]("test", t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer fp.Close() //nolint:errcheck // test cleanup
+
+	for _, k := range []string{"good", "corrupt", "ancient", "distant"} {
+		if err := fp.Set(ctx, k, 1, time.Time{}); err != nil {
+			t.Fatalf("Set(%s): %v", k, err)
+		}
+	}
+	if err := os.WriteFile(fp.Location("corrupt"), []byte("not json"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	// Entries with absurd expiries, as written by a bad clock or a bug.
+	for k, exp := range map[string]time.Time{
+		"ancient": time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC),
+		"distant": time.Now().AddDate(500, 0, 0),
+	} {
+		data, err := json.Marshal(Entry[string, int]{Key: k, Value: 1, Expiry: exp})
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		if err := os.WriteFile(fp.Location(k), data, 0o600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	// An old temp file from a crashed write, a fresh one from a write in progress.
+	stale := filepath.Join(filepath.Dir(fp.Location("good")), "x.j.123.tmp")
+	fresh := filepath.Join(filepath.Dir(fp.Location("good")), "y.j.456.tmp")
+	for _, fn := range []string{stale, fresh} {
+		if err := os.WriteFile(fn, []byte("partial"), 0o600); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+	empty := filepath.Join(fp.Dir, "zz")
+	if err := os.Mkdir(empty, 0o750); err != nil {
+		t.Fatalf("Mkdir: %v", err)
+	}
+
+	r, err := fp.Fsck(ctx, false)
+	if err != nil {
+		t.Fatalf("Fsck: %v", err)
+	}
+	slices.Sort(r.BadExpiry)
+	if !slices.Equal(r.TempFiles, []string{stale}) ||
+		!slices.Equal(r.Corrupt, []string{fp.Location("corrupt")}) ||
+		!slices.Equal(r.BadExpiry, slices.Sorted(slices.Values([]string{fp.Location("ancient"), fp.Location("distant")}))) ||
+		!slices.Equal(r.EmptyDirs, []string{empty}) || r.Removed != 0 {
+		t.Fatalf("Fsck(check) = %+v", r)
+	}
+	if _, err := os.Stat(stale); err != nil {
+		t.Error("Fsck without fix removed a file")
+	}
+
+	r, err = fp.Fsck(ctx, true)
+	if err != nil {
+		t.Fatalf("Fsck(fix): %v", err)
+	}
+	// Removing the bad entries may empty their fan-out directories too.
+	if !slices.Contains(r.EmptyDirs, empty) || r.Removed != 4+len(r.EmptyDirs) {
+		t.Errorf("Fsck(fix) = %+v; want 4 files and each empty directory removed", r)
+	}
+	if r, err := fp.Fsck(ctx, false); err != nil || len(r.TempFiles)+len(r.Corrupt)+len(r.BadExpiry)+len(r.EmptyDirs) != 0 {
+		t.Errorf("Fsck after fix = %+v, %v; want clean", r, err)
+	}
+	if v, _, found, err := fp.Get(ctx, "good"); err != nil || !found || v != 1 {
+		t.Errorf("Get(good) = %d, %v, %v; want 1, true, nil", v, found, err)
+	}
+	// Directories Fsck removed are recreated on the next write.
+	if err := fp.Set(ctx, "corrupt", 2, time.Time{}); err != nil {
+		t.Errorf("Set after Fsck removed its directory: %v", err)
+	}
+	if _, err := os.Stat(fresh); err != nil {
+		t.Error("Fsck removed a temp file that may belong to a write in progress")
+	}
+}
+
+func TestFilePersist_DiskUsage(t *testing.T) {
+	fp, err := New[string, int]("test", t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer fp.Close() //nolint:errcheck // test cleanup
+
+	used, total, err := fp.DiskUsage(context.Background())
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip("DiskUsage unsupported on this platform")
+	}
+	if err != nil || total <= 0 || used < 0 || used > total {
+		t.Errorf("DiskUsage = %d, %d, %v; want 0 <= used <= total", used, total, err)
+	}
+}
+
+func TestFilePersist_Owners(t *testing.T) {
+	ctx := context.Background()
+	fp, err := New[string, int]("test", t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer func() {
+		if err := fp.Close(); err != nil {
+			t.Logf("Close error: %v", err)
+		}
+	}()
+
+	if err := fp.SetOwned(ctx, "a1", 1, time.Time{}, "team-a"); err != nil {
+		t.Fatalf("SetOwned: %v", err)
+	}
+	if err := fp.SetOwned(ctx, "a2", 2, time.Now().Add(-time.Minute), "team-a"); err != nil {
+		t.Fatalf("SetOwned: %v", err)
+	}
+	if err := fp.SetOwned(ctx, "b1", 3, time.Time{}, "team-b"); err != nil {
+		t.Fatalf("SetOwned: %v", err)
+	}
+	if err := fp.Set(ctx, "untagged", 4, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	// Expired entries are listed so they can be cleaned up.
+	if got := slices.Sorted(fp.OwnerKeys(ctx, "team-a")); !slices.Equal(got, []string{"a1", "a2"}) {
+		t.Errorf("OwnerKeys(team-a) = %v; want [a1 a2]", got)
+	}
+	// A plain Set replaces the entry, and with it the tag.
+	if err := fp.Set(ctx, "b1", 5, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if got := slices.Collect(fp.OwnerKeys(ctx, "team-b")); len(got) != 0 {
+		t.Errorf("OwnerKeys(team-b) = %v; want none after an untagged Set", got)
+	}
+}
@@ -2,17 +2,23 @@
 package localfs
 
 import (
+	"cmp"
 	"context"
 	"crypto/sha256"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"iter"
+	"math"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/codeGROOVE-dev/fido/pkg/store/compress"
@@ -24,26 +30,139 @@ type Entry[K comparable, V any] struct {
 	Value     V
 	Expiry    time.Time
 	UpdatedAt time.Time
+	Owner     string `json:",omitempty"` // set by SetOwned
 }
 
-const maxKeyLength = 127 // Maximum key length to avoid filesystem constraints
+const (
+	maxKeyLength = 127        // Maximum key length to avoid filesystem constraints
+	epochFile    = ".epoch"   // Persists the current epoch across restarts
+	hotKeysFile  = ".hotkeys" // SaveHotKeys' list, as JSON
+	blobExt      = ".blob"    // Streamed values, stored raw beside regular entries
+
+	defaultDirMode  = 0o750
+	defaultFileMode = 0o600 // os.CreateTemp's mode
+
+	defaultFanOutLevels = 1 // squid-style: one level of
+	defaultFanOutWidth  = 2 // 256 directories
+	maxFanOutLevels     = 4
+	maxFanOutWidth      = 3
+)
 
 // Store implements file-based persistence using local files with JSON encoding.
 //
 //nolint:govet // fieldalignment - current layout groups related fields logically (mutex with map it protects)
 type Store[K comparable, V any] struct {
 	subdirsMu   sync.RWMutex
-	Dir         string              // Exported for testing - directory path
-	subdirsMade map[string]bool     // Cache of created subdirectories
-	compressor  compress.Compressor // Compression algorithm
-	ext         string              // File extension based on compressor
+	Dir         string                  // Resolved cache directory: Config.Dir (or the OS cache dir) plus cacheID
+	subdirsMade map[string]bool         // Cache of created subdirectories
+	compressor  compress.Compressor     // Compression algorithm
+	ext         string                  // File extension based on compressor
+	epochMu     sync.Mutex              // Serializes BumpEpoch
+	epoch       atomic.Uint64           // Mixed into filenames; bumping orphans all entries
+	version     atomic.Pointer[string]  // SetVersion; mixed into filenames like epoch
+	fallback    func([]byte) (V, error) // SetDecodeFallback; nil when unset
+	index       *keyIndex               // nil unless Config.KeyIndex
+	levels      int                     // directory levels above each entry
+	width       int                     // hex characters of the key hash per level
+	dirMode     os.FileMode             // Config.DirMode or 0o750
+	fileMode    os.FileMode             // Config.FileMode or 0o600
+	exactModes  bool                    // Config.DirMode was set; apply it despite umask
+	volatile    bool                    // Dir is RAM-backed (tmpfs)
+	sync        bool                    // Config.Sync, unless volatile
+}
+
+// Location selects the base directory when Config.Dir is empty.
+type Location int
+
+const (
+	// UserCache is the per-user cache directory from os.UserCacheDir:
+	// $XDG_CACHE_HOME or ~/.cache on Linux, ~/Library/Caches on macOS,
+	// %LocalAppData% on Windows.
+	UserCache Location = iota
+	// SystemCache is the system-wide cache directory for daemons running as
+	// system users: $CACHE_DIRECTORY (set by systemd's CacheDirectory=) if
+	// present, else /var/cache, or %ProgramData% on Windows.
+	SystemCache
+)
+
+// dir returns the base directory for l.
+func (l Location) dir() (string, error) {
+	switch l {
+	case UserCache:
+		d, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("get user cache dir: %w", err)
+		}
+		return d, nil
+	case SystemCache:
+		// systemd may list several directories; the first is this unit's own.
+		if d, _, _ := strings.Cut(os.Getenv("CACHE_DIRECTORY"), ":"); d != "" {
+			return d, nil
+		}
+		if goos == "windows" {
+			if d := os.Getenv("ProgramData"); d != "" {
+				return d, nil
+			}
+			return `C:\ProgramData`, nil
+		}
+		return "/var/cache", nil
+	default:
+		return "", fmt.Errorf("invalid Location %d", l)
+	}
+}
+
+// Config holds optional settings for NewWithConfig.
+type Config struct {
+	// Dir is the base directory. Default: the directory selected by Location.
+	Dir string
+	// Location selects the base directory when Dir is empty. Default UserCache.
+	Location Location
+	// DirMode and FileMode are the permissions of created directories and
+	// entry files. Default 0o750 and 0o600. Explicit modes are applied exactly,
+	// regardless of umask, so replicas running as different users in one group
+	// can share a directory with 0o770 and 0o660.
+	DirMode  os.FileMode
+	FileMode os.FileMode
+	// Compressor enables compression. Default: no compression, plain JSON with .j extension.
+	Compressor compress.Compressor
+	// KeyIndex keeps the hashes of stored keys in memory so Get can report a
+	// miss without opening a file. The index is saved on Close and loaded by the
+	// next New; after an unclean shutdown it is rebuilt by listing the directory.
+	// Only enable it when this Store is the directory's sole writer: entries
+	// written by another process after the index is loaded read as misses.
+	KeyIndex bool
+	// FanOutLevels is how many levels of subdirectories entries are spread
+	// across, and FanOutWidth how many hex digits of the key hash name each
+	// level, giving 16^FanOutWidth directories per level. The default, 1 level
+	// of width 2, puts 256 directories under Dir; caches with tens of millions
+	// of entries can use 2 levels to keep directories small. Changing the
+	// layout of an existing directory orphans its entries until Cleanup or
+	// Flush removes them.
+	FanOutLevels int
+	FanOutWidth  int
+	// Sync makes Set and SetReader fsync each file and its directory before
+	// returning, so acknowledged writes survive a power loss. It is ignored
+	// on RAM-backed filesystems such as tmpfs, where nothing survives a reboot;
+	// see Store.Volatile.
+	Sync bool
 }
 
 // New creates a new file-based persistence layer.
 // The cacheID is used as a subdirectory name under the OS cache directory.
+// On Windows it must be a valid file name, and on Windows and macOS, whose
+// filesystems ignore case by default, cacheIDs differing only in case share a directory.
 // If dir is provided (non-empty), it's used as the base directory instead of OS cache dir.
 // Optional compressor enables compression (default: no compression, plain JSON with .j extension).
 func New[K comparable, V any](cacheID, dir string, c ...compress.Compressor) (*Store[K, V], error) {
+	cfg := Config{Dir: dir}
+	if len(c) > 0 {
+		cfg.Compressor = c[0]
+	}
+	return NewWithConfig[K, V](cacheID, cfg)
+}
+
+// NewWithConfig creates a new file-based persistence layer with explicit settings.
+func NewWithConfig[K comparable, V any](cacheID string, cfg Config) (*Store[K, V], error) {
 	if cacheID == "" {
 		return nil, errors.New("cacheID cannot be empty")
 	}
@@ -53,24 +172,57 @@ func New[K comparable, V any](cacheID, dir string, c ...compress.Compressor) (*S
 	if strings.Contains(cacheID, "\x00") {
 		return nil, errors.New("invalid cacheID: contains null byte")
 	}
+	if goos == "windows" {
+		if err := checkWindowsName(cacheID); err != nil {
+			return nil, fmt.Errorf("invalid cacheID: %w", err)
+		}
+	}
+
+	levels := cmp.Or(cfg.FanOutLevels, defaultFanOutLevels)
+	width := cmp.Or(cfg.FanOutWidth, defaultFanOutWidth)
+	if levels < 1 || levels > maxFanOutLevels {
+		return nil, fmt.Errorf("invalid FanOutLevels %d: must be 1-%d", cfg.FanOutLevels, maxFanOutLevels)
+	}
+	if width < 1 || width > maxFanOutWidth {
+		return nil, fmt.Errorf("invalid FanOutWidth %d: must be 1-%d", cfg.FanOutWidth, maxFanOutWidth)
+	}
 
 	comp := compress.None()
-	if len(c) > 0 && c[0] != nil {
-		comp = c[0]
+	if cfg.Compressor != nil {
+		comp = cfg.Compressor
 	}
 
-	var fullDir string
-	if dir != "" {
-		fullDir = filepath.Join(dir, cacheID)
-	} else {
-		baseDir, err := os.UserCacheDir()
-		if err != nil {
-			return nil, fmt.Errorf("get user cache dir: %w", err)
+	baseDir := cfg.Dir
+	if baseDir == "" {
+		var err error
+		if baseDir, err = cfg.Location.dir(); err != nil {
+			return nil, err
 		}
-		fullDir = filepath.Join(baseDir, cacheID)
+	}
+	// Windows only lifts the 260-character path limit for absolute paths.
+	fullDir, err := filepath.Abs(filepath.Join(baseDir, cacheID))
+	if err != nil {
+		return nil, fmt.Errorf("resolve cache dir: %w", err)
+	}
+
+	ext := comp.Extension()
+	if ext == "" {
+		ext = ".j"
 	}
 
-	if err := os.MkdirAll(fullDir, 0o750); err != nil {
+	s := &Store[K, V]{
+		Dir:         fullDir,
+		subdirsMade: make(map[string]bool),
+		compressor:  comp,
+		ext:         ext,
+		levels:      levels,
+		width:       width,
+		dirMode:     cmp.Or(cfg.DirMode, defaultDirMode),
+		fileMode:    cmp.Or(cfg.FileMode, defaultFileMode),
+		exactModes:  cfg.DirMode != 0,
+	}
+	s.version.Store(new(string))
+	if err := s.mkdir(fullDir); err != nil {
 		return nil, fmt.Errorf("create cache dir: %w", err)
 	}
 
@@ -80,17 +232,28 @@ func New[K comparable, V any](cacheID, dir string, c ...compress.Compressor) (*S
 	}
 	_ = os.Remove(testFile) //nolint:errcheck // best-effort cleanup
 
-	ext := comp.Extension()
-	if ext == "" {
-		ext = ".j"
+	s.volatile = isVolatile(fullDir)
+	s.sync = cfg.Sync && !s.volatile
+
+	if b, err := os.ReadFile(filepath.Join(fullDir, epochFile)); err == nil {
+		e, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse epoch file: %w", err)
+		}
+		s.epoch.Store(e)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read epoch file: %w", err)
 	}
 
-	return &Store[K, V]{
-		Dir:         fullDir,
-		subdirsMade: make(map[string]bool),
-		compressor:  comp,
-		ext:         ext,
-	}, nil
+	if cfg.KeyIndex {
+		idx, err := loadKeyIndex(fullDir, ext, s.fileMode)
+		if err != nil {
+			return nil, err
+		}
+		s.index = idx
+	}
+
+	return s, nil
 }
 
 // ValidateKey checks if a key is valid for file persistence.
@@ -107,13 +270,105 @@ func (*Store[K, V]) ValidateKey(key K) error {
 	return nil
 }
 
+// MaxKeyLength returns the longest key ValidateKey accepts. Implements fido.KeyLimiter.
+func (*Store[K, V]) MaxKeyLength() int {
+	return maxKeyLength
+}
+
+// ValidateValue checks that value can be JSON-encoded.
+// Implements fido.ValueValidator.
+func (*Store[K, V]) ValidateValue(value V) error {
+	if _, err := json.Marshal(value); err != nil {
+		return fmt.Errorf("encode value: %w", err)
+	}
+	return nil
+}
+
 // keyToFilename converts a cache key to a filename with squid-style directory layout.
-// Hashes the key and uses first 2 characters of hex hash as subdirectory for even distribution
-// (e.g., key "mykey" -> "a3/a3f2....j" or "a3/a3f2....s" with S2 compression).
+// Hashes the key and uses leading characters of the hex hash as subdirectories for even distribution
+// (e.g., key "mykey" -> "a3/a3f2....j", "a3/a3f2....s" with S2 compression, or "a3/f2/a3f2....j" with 2 levels).
+// Epochs after the first are mixed into the hash, so bumping the epoch orphans every existing file.
 func (s *Store[K, V]) keyToFilename(key K) string {
-	sum := sha256.Sum256(fmt.Appendf(nil, "%v", key))
+	return s.filename(fmt.Sprintf("%v", key), s.ext)
+}
+
+// blobFilename is keyToFilename for a value streamed by SetReader.
+func (s *Store[K, V]) blobFilename(key K) string {
+	return s.filename(fmt.Sprintf("%v", key), blobExt)
+}
+
+// filename maps a key's string form to its file under the current epoch.
+func (s *Store[K, V]) filename(key, ext string) string {
+	return s.sumFilename(s.sum(key), ext)
+}
+
+// sumFilename is the file for a key hash under the configured fan-out.
+func (s *Store[K, V]) sumFilename(sum [sha256.Size]byte, ext string) string {
 	h := hex.EncodeToString(sum[:])
-	return filepath.Join(h[:2], h+s.ext)
+	parts := make([]string, 0, s.levels+1)
+	for i := range s.levels {
+		parts = append(parts, h[i*s.width:(i+1)*s.width])
+	}
+	return filepath.Join(append(parts, h+ext)...)
+}
+
+// sum hashes a key's string form under the current epoch.
+func (s *Store[K, V]) sum(key string) [sha256.Size]byte {
+	if v := *s.version.Load(); v != "" {
+		return sha256.Sum256(fmt.Appendf(nil, "%s\x00%d\x00%s", v, s.epoch.Load(), key))
+	}
+	if e := s.epoch.Load(); e > 0 {
+		return sha256.Sum256(fmt.Appendf(nil, "%d\x00%s", e, key))
+	}
+	return sha256.Sum256([]byte(key))
+}
+
+// SetVersion namespaces later operations under version by mixing it into
+// every filename, like the epoch. Implements fido.Versioner.
+func (s *Store[K, V]) SetVersion(version string) error {
+	s.version.Store(&version)
+	return nil
+}
+
+// PurgeVersions removes files unreachable under the current version and epoch.
+// Implements fido.Versioner.
+func (s *Store[K, V]) PurgeVersions(ctx context.Context) (int, error) {
+	return s.sweep(ctx, time.Time{})
+}
+
+// SetDecodeFallback sets a function that decodes entry values json.Unmarshal
+// rejects as V, such as those written before V's fields changed type. fn
+// receives the value's JSON. Call it before using the store.
+// Implements fido.FallbackDecoder.
+func (s *Store[K, V]) SetDecodeFallback(fn func(raw []byte) (V, error)) {
+	s.fallback = fn
+}
+
+// corruptError wraps a failure to decode a stored entry. Its Corrupt method
+// lets fido.TieredCache tell a damaged entry from an unreachable store.
+type corruptError struct{ err error }
+
+func (e *corruptError) Error() string { return e.err.Error() }
+func (e *corruptError) Unwrap() error { return e.err }
+func (*corruptError) Corrupt() bool   { return true }
+
+// decodeEntry decodes an entry file's JSON, passing a value that does not
+// unmarshal as V to the decode fallback, if one is set.
+func (s *Store[K, V]) decodeEntry(data []byte) (Entry[K, V], error) {
+	var e Entry[K, V]
+	err := json.Unmarshal(data, &e)
+	if err == nil || s.fallback == nil {
+		return e, err
+	}
+	var raw Entry[K, json.RawMessage]
+	if json.Unmarshal(data, &raw) != nil {
+		return e, err // not a value problem
+	}
+	v, ferr := s.fallback(raw.Value)
+	if ferr != nil {
+		return e, errors.Join(err, fmt.Errorf("decode fallback: %w", ferr))
+	}
+	return Entry[K, V]{Key: raw.Key, Value: v, Expiry: raw.Expiry, UpdatedAt: raw.UpdatedAt, Owner: raw.Owner}, nil
 }
 
 // Location returns the full file path where a key is stored.
@@ -126,7 +381,11 @@ func (s *Store[K, V]) Location(key K) string {
 //nolint:revive // function-result-limit - required by persist.Store interface
 func (s *Store[K, V]) Get(ctx context.Context, key K) (value V, expiry time.Time, found bool, err error) {
 	var zero V
-	fn := filepath.Join(s.Dir, s.keyToFilename(key))
+	sum := s.sum(fmt.Sprintf("%v", key))
+	if s.index != nil && !s.index.contains(fileID(sum)) {
+		return zero, time.Time{}, false, nil
+	}
+	fn := filepath.Join(s.Dir, s.sumFilename(sum, s.ext))
 
 	data, err := os.ReadFile(fn)
 	if err != nil {
@@ -138,21 +397,21 @@ func (s *Store[K, V]) Get(ctx context.Context, key K) (value V, expiry time.Time
 
 	jsonData, err := s.compressor.Decode(data)
 	if err != nil {
-		rmErr := os.Remove(fn)
-		return zero, time.Time{}, false, errors.Join(fmt.Errorf("decompress: %w", err), rmErr)
+		rmErr := s.removeEntry(fn)
+		return zero, time.Time{}, false, errors.Join(&corruptError{fmt.Errorf("decompress: %w", err)}, rmErr)
 	}
 
-	var e Entry[K, V]
-	if err := json.Unmarshal(jsonData, &e); err != nil {
-		rmErr := os.Remove(fn)
+	e, err := s.decodeEntry(jsonData)
+	if err != nil {
+		rmErr := s.removeEntry(fn)
 		return zero, time.Time{}, false, errors.Join(
-			fmt.Errorf("decode file: %w", err),
+			&corruptError{fmt.Errorf("decode file: %w", err)},
 			rmErr,
 		)
 	}
 
 	if !e.Expiry.IsZero() && time.Now().After(e.Expiry) {
-		if err := os.Remove(fn); err != nil && !os.IsNotExist(err) {
+		if err := s.removeEntry(fn); err != nil {
 			return zero, time.Time{}, false, fmt.Errorf("remove expired file: %w", err)
 		}
 		return zero, time.Time{}, false, nil
@@ -163,28 +422,20 @@ func (s *Store[K, V]) Get(ctx context.Context, key K) (value V, expiry time.Time
 
 // Set saves a value to a file.
 func (s *Store[K, V]) Set(ctx context.Context, key K, value V, expiry time.Time) error {
-	fn := filepath.Join(s.Dir, s.keyToFilename(key))
-	dir := filepath.Dir(fn)
+	return s.set(key, value, expiry, "")
+}
 
-	// Check if subdirectory already created (cache to avoid syscalls)
-	s.subdirsMu.RLock()
-	exists := s.subdirsMade[dir]
-	s.subdirsMu.RUnlock()
+// SetOwned is Set, recording owner in the entry. Implements fido.OwnerTagger.
+func (s *Store[K, V]) SetOwned(_ context.Context, key K, value V, expiry time.Time, owner string) error {
+	return s.set(key, value, expiry, owner)
+}
 
-	if !exists {
-		// Hold write lock during check-and-create to avoid race
-		s.subdirsMu.Lock()
-		// Double-check after acquiring write lock
-		if !s.subdirsMade[dir] {
-			// Create subdirectory if needed (MkdirAll is idempotent)
-			if err := os.MkdirAll(dir, 0o750); err != nil {
-				s.subdirsMu.Unlock()
-				return fmt.Errorf("create subdirectory: %w", err)
-			}
-			// Cache that we created it
-			s.subdirsMade[dir] = true
-		}
-		s.subdirsMu.Unlock()
+func (s *Store[K, V]) set(key K, value V, expiry time.Time, owner string) error {
+	sum := s.sum(fmt.Sprintf("%v", key))
+	fn := filepath.Join(s.Dir, s.sumFilename(sum, s.ext))
+	dir := filepath.Dir(fn)
+	if err := s.ensureDir(dir); err != nil {
+		return err
 	}
 
 	e := Entry[K, V]{
@@ -192,6 +443,7 @@ func (s *Store[K, V]) Set(ctx context.Context, key K, value V, expiry time.Time)
 		Value:     value,
 		Expiry:    expiry,
 		UpdatedAt: time.Now(),
+		Owner:     owner,
 	}
 
 	jsonData, err := json.Marshal(e)
@@ -204,40 +456,385 @@ func (s *Store[K, V]) Set(ctx context.Context, key K, value V, expiry time.Time)
 		return fmt.Errorf("compress: %w", err)
 	}
 
-	// Write to temp file first, then rename for atomicity
-	tmp := fn + ".tmp"
-	if err := os.WriteFile(tmp, data, 0o600); err != nil {
-		return fmt.Errorf("write temp file: %w", err)
+	// Write to a uniquely named temp file, then rename for atomicity,
+	// so concurrent writers of the same key cannot clobber each other's data.
+	f, err := os.CreateTemp(dir, filepath.Base(fn)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmp := f.Name()
+	err = s.chmod(f)
+	if err == nil {
+		_, err = f.Write(data)
+	}
+	if err == nil {
+		err = s.syncFile(f)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		rmErr := os.Remove(tmp)
+		return errors.Join(fmt.Errorf("write temp file: %w", err), rmErr)
 	}
 
 	// Atomic rename
-	if err := os.Rename(tmp, fn); err != nil {
+	if err := s.indexed(fileID(sum), true, func() error { return os.Rename(tmp, fn) }); err != nil {
 		rmErr := os.Remove(tmp)
 		return errors.Join(fmt.Errorf("rename file: %w", err), rmErr)
 	}
 
+	return s.syncDir(dir)
+}
+
+// mkdir creates dir and any missing parents with DirMode.
+func (s *Store[K, V]) mkdir(dir string) error {
+	if err := os.MkdirAll(dir, s.dirMode); err != nil {
+		return err
+	}
+	if s.exactModes {
+		return os.Chmod(dir, s.dirMode)
+	}
+	return nil
+}
+
+// chmod gives a file made by os.CreateTemp the configured FileMode.
+func (s *Store[K, V]) chmod(f *os.File) error {
+	if s.fileMode == defaultFileMode {
+		return nil
+	}
+	return f.Chmod(s.fileMode)
+}
+
+// writeFile writes a small metadata file with FileMode.
+func (s *Store[K, V]) writeFile(path string, data []byte) error {
+	if err := os.WriteFile(path, data, s.fileMode); err != nil {
+		return err
+	}
+	if s.fileMode == defaultFileMode {
+		return nil
+	}
+	return os.Chmod(path, s.fileMode)
+}
+
+// Volatile reports whether the store's directory is on a RAM-backed filesystem
+// such as tmpfs, where entries are lost on reboot and Config.Sync has no effect.
+// Detection is only implemented on Linux.
+func (s *Store[K, V]) Volatile() bool {
+	return s.volatile
+}
+
+// syncFile flushes f to stable storage if Config.Sync is in effect.
+func (s *Store[K, V]) syncFile(f *os.File) error {
+	if !s.sync {
+		return nil
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("sync file: %w", err)
+	}
 	return nil
 }
 
-// Delete removes a file.
-func (s *Store[K, V]) Delete(ctx context.Context, key K) error {
-	fn := filepath.Join(s.Dir, s.keyToFilename(key))
-	if err := os.Remove(fn); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("remove file: %w", err)
+// syncDir makes a rename into dir durable if Config.Sync is in effect.
+// Windows cannot sync directories; its renames are journaled by NTFS.
+func (s *Store[K, V]) syncDir(dir string) error {
+	if !s.sync || goos == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("sync dir: %w", err)
+	}
+	err = d.Sync()
+	if cerr := d.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("sync dir: %w", err)
+	}
+	return nil
+}
+
+// ensureDir creates a squid-style subdirectory the first time it is written to.
+func (s *Store[K, V]) ensureDir(dir string) error {
+	// Check if subdirectory already created (cache to avoid syscalls)
+	s.subdirsMu.RLock()
+	exists := s.subdirsMade[dir]
+	s.subdirsMu.RUnlock()
+	if exists {
+		return nil
+	}
+
+	// Hold write lock during check-and-create to avoid race
+	s.subdirsMu.Lock()
+	defer s.subdirsMu.Unlock()
+	// Double-check after acquiring write lock
+	if !s.subdirsMade[dir] {
+		// Create subdirectory if needed (MkdirAll is idempotent)
+		if err := s.mkdir(dir); err != nil {
+			return fmt.Errorf("create subdirectory: %w", err)
+		}
+		// Cache that we created it
+		s.subdirsMade[dir] = true
 	}
 	return nil
 }
 
+// SetReader streams r to a file under key without buffering it in memory.
+// Streamed values are stored raw (uncompressed) and separately from values
+// written by Set: Get does not see them, and Delete removes both.
+// Implements fido.Streamer.
+func (s *Store[K, V]) SetReader(ctx context.Context, key K, r io.Reader, expiry time.Time) error {
+	fn := filepath.Join(s.Dir, s.blobFilename(key))
+	dir := filepath.Dir(fn)
+	if err := s.ensureDir(dir); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, filepath.Base(fn)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmp := f.Name()
+	err = s.chmod(f)
+	if err == nil {
+		err = writeBlobHeader(f, fmt.Sprintf("%v", key), expiry)
+	}
+	if err == nil {
+		_, err = io.Copy(f, ctxReader{ctx: ctx, r: r})
+	}
+	if err == nil {
+		err = s.syncFile(f)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		rmErr := os.Remove(tmp)
+		return errors.Join(fmt.Errorf("write temp file: %w", err), rmErr)
+	}
+
+	if err := os.Rename(tmp, fn); err != nil {
+		rmErr := os.Remove(tmp)
+		return errors.Join(fmt.Errorf("rename file: %w", err), rmErr)
+	}
+	return s.syncDir(dir)
+}
+
+// GetReader opens a value stored by SetReader. The caller must close it.
+// Implements fido.Streamer.
+//
+//nolint:revive // function-result-limit - required by fido.Streamer interface
+func (s *Store[K, V]) GetReader(ctx context.Context, key K) (rc io.ReadCloser, expiry time.Time, found bool, err error) {
+	fn := filepath.Join(s.Dir, s.blobFilename(key))
+	f, err := os.Open(fn)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, time.Time{}, false, nil
+		}
+		return nil, time.Time{}, false, fmt.Errorf("open file: %w", err)
+	}
+
+	_, expiry, err = readBlobHeader(f)
+	if err != nil {
+		cerr := f.Close()
+		rmErr := os.Remove(fn)
+		return nil, time.Time{}, false, errors.Join(fmt.Errorf("decode file: %w", err), cerr, rmErr)
+	}
+	if !expiry.IsZero() && time.Now().After(expiry) {
+		cerr := f.Close()
+		if err := os.Remove(fn); err != nil && !os.IsNotExist(err) {
+			return nil, time.Time{}, false, errors.Join(fmt.Errorf("remove expired file: %w", err), cerr)
+		}
+		return nil, time.Time{}, false, cerr
+	}
+	return f, expiry, true, nil
+}
+
+// writeBlobHeader writes a streamed value's expiry (Unix nanoseconds, 0 for none)
+// and key, which Cleanup needs to recognize files orphaned by BumpEpoch.
+func writeBlobHeader(w io.Writer, key string, expiry time.Time) error {
+	var exp int64
+	if !expiry.IsZero() {
+		exp = expiry.UnixNano()
+	}
+	hdr := binary.BigEndian.AppendUint64(nil, uint64(exp)) //nolint:gosec // G115: bit reinterpretation, reversed on read
+	hdr = binary.BigEndian.AppendUint16(hdr, uint16(min(len(key), math.MaxUint16)))
+	hdr = append(hdr, key...)
+	_, err := w.Write(hdr)
+	return err
+}
+
+func readBlobHeader(r io.Reader) (key string, expiry time.Time, err error) {
+	var fixed [10]byte
+	if _, err := io.ReadFull(r, fixed[:]); err != nil {
+		return "", time.Time{}, err
+	}
+	k := make([]byte, binary.BigEndian.Uint16(fixed[8:]))
+	if _, err := io.ReadFull(r, k); err != nil {
+		return "", time.Time{}, err
+	}
+	if exp := int64(binary.BigEndian.Uint64(fixed[:8])); exp != 0 { //nolint:gosec // G115: reverses writeBlobHeader
+		expiry = time.Unix(0, exp)
+	}
+	return string(k), expiry, nil
+}
+
+// ctxReader stops a copy when ctx is done.
+type ctxReader struct {
+	ctx context.Context //nolint:containedctx // scoped to one SetReader call
+	r   io.Reader
+}
+
+func (c ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// BumpEpoch makes all existing entries unreachable in O(1) by changing how keys map
+// to filenames. Orphaned files are reclaimed by Cleanup or Flush.
+// Implements fido.EpochBumper.
+func (s *Store[K, V]) BumpEpoch(_ context.Context) (uint64, error) {
+	s.epochMu.Lock()
+	defer s.epochMu.Unlock()
+
+	e := s.epoch.Load() + 1
+	fn := filepath.Join(s.Dir, epochFile)
+	tmp := fn + ".tmp"
+	if err := s.writeFile(tmp, []byte(strconv.FormatUint(e, 10))); err != nil {
+		return 0, fmt.Errorf("write epoch file: %w", err)
+	}
+	if err := os.Rename(tmp, fn); err != nil {
+		rmErr := os.Remove(tmp)
+		return 0, errors.Join(fmt.Errorf("rename epoch file: %w", err), rmErr)
+	}
+	s.epoch.Store(e)
+	return e, nil
+}
+
+// SaveHotKeys replaces the saved hot key list with keys, hottest first.
+// Implements fido.HotKeyRecorder.
+func (s *Store[K, V]) SaveHotKeys(_ context.Context, keys []K) error {
+	b, err := json.Marshal(keys)
+	if err != nil {
+		return fmt.Errorf("marshal hot keys: %w", err)
+	}
+	f, err := os.CreateTemp(s.Dir, hotKeysFile+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create hot keys file: %w", err)
+	}
+	tmp := f.Name()
+	_, err = f.Write(b)
+	if err == nil {
+		err = s.chmod(f)
+	}
+	if err == nil {
+		err = s.syncFile(f)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, filepath.Join(s.Dir, hotKeysFile))
+	}
+	if err != nil {
+		rmErr := os.Remove(tmp)
+		return errors.Join(fmt.Errorf("write hot keys file: %w", err), rmErr)
+	}
+	return s.syncDir(s.Dir)
+}
+
+// LoadHotKeys returns the list SaveHotKeys last saved, or nil if there is none.
+// Implements fido.HotKeyRecorder.
+func (s *Store[K, V]) LoadHotKeys(context.Context) ([]K, error) {
+	b, err := os.ReadFile(filepath.Join(s.Dir, hotKeysFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read hot keys file: %w", err)
+	}
+	var keys []K
+	if err := json.Unmarshal(b, &keys); err != nil {
+		return nil, fmt.Errorf("parse hot keys file: %w", err)
+	}
+	return keys, nil
+}
+
+// Delete removes a file, along with any value streamed by SetReader.
+func (s *Store[K, V]) Delete(ctx context.Context, key K) error {
+	var errs []error
+	if err := s.removeEntry(filepath.Join(s.Dir, s.keyToFilename(key))); err != nil {
+		errs = append(errs, fmt.Errorf("remove file: %w", err))
+	}
+	if err := os.Remove(filepath.Join(s.Dir, s.blobFilename(key))); err != nil && !os.IsNotExist(err) {
+		errs = append(errs, fmt.Errorf("remove file: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// reachable reports whether the entry file at path is key's under the current
+// version and epoch, rather than one orphaned by BumpEpoch or SetVersion.
+func (s *Store[K, V]) reachable(path string, key K) bool {
+	return filepath.Join(s.Dir, s.keyToFilename(key)) == path
+}
+
+// readEntry reads and decodes the entry file at path.
+func (s *Store[K, V]) readEntry(path string) (Entry[K, V], error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Entry[K, V]{}, err
+	}
+	jsonData, err := s.compressor.Decode(data)
+	if err != nil {
+		return Entry[K, V]{}, &corruptError{fmt.Errorf("decompress: %w", err)}
+	}
+	e, err := s.decodeEntry(jsonData)
+	if err != nil {
+		return Entry[K, V]{}, &corruptError{fmt.Errorf("decode file: %w", err)}
+	}
+	return e, nil
+}
+
 // isCacheFile returns true if the file matches the store's cache file extension.
 func (s *Store[K, V]) isCacheFile(name string) bool {
 	return filepath.Ext(name) == s.ext
 }
 
+// isBlobFile returns true if the file holds a value streamed by SetReader.
+func isBlobFile(name string) bool {
+	return filepath.Ext(name) == blobExt
+}
+
+// blobStale reports whether the streamed value at path has expired before
+// cutoff or was orphaned by BumpEpoch.
+func (s *Store[K, V]) blobStale(path string, cutoff time.Time) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close() //nolint:errcheck // read-only
+	key, expiry, err := readBlobHeader(f)
+	if err != nil {
+		return false, err
+	}
+	expired := !expiry.IsZero() && expiry.Before(cutoff)
+	return expired || filepath.Join(s.Dir, s.filename(key, blobExt)) != path, nil
+}
+
 // Cleanup removes expired entries from file storage.
-// Walks through all cache files and deletes those with expired timestamps.
+// Walks through all cache files and deletes those with expired timestamps,
+// along with files orphaned by BumpEpoch or SetVersion.
 // Returns the count of deleted entries and any errors encountered.
 func (s *Store[K, V]) Cleanup(ctx context.Context, maxAge time.Duration) (int, error) {
-	cutoff := time.Now().Add(-maxAge)
+	return s.sweep(ctx, time.Now().Add(-maxAge))
+}
+
+// sweep removes files that expired before cutoff or are unreachable under the
+// current version and epoch. A zero cutoff removes only unreachable files.
+func (s *Store[K, V]) sweep(ctx context.Context, cutoff time.Time) (int, error) {
 	n := 0
 	var errs []error
 
@@ -255,6 +852,21 @@ func (s *Store[K, V]) Cleanup(ctx context.Context, maxAge time.Duration) (int, e
 			return nil
 		}
 
+		if !fi.IsDir() && isBlobFile(fi.Name()) {
+			stale, err := s.blobStale(path, cutoff)
+			switch {
+			case err != nil:
+				errs = append(errs, fmt.Errorf("read %s: %w", path, err))
+			case stale:
+				if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+					errs = append(errs, fmt.Errorf("remove %s: %w", path, err))
+				} else {
+					n++
+				}
+			}
+			return nil
+		}
+
 		// Skip directories and non-matching files
 		if fi.IsDir() || !s.isCacheFile(fi.Name()) {
 			return nil
@@ -272,15 +884,16 @@ func (s *Store[K, V]) Cleanup(ctx context.Context, maxAge time.Duration) (int, e
 			return nil
 		}
 
-		var e Entry[K, V]
-		if err := json.Unmarshal(jsonData, &e); err != nil {
+		e, err := s.decodeEntry(jsonData)
+		if err != nil {
 			errs = append(errs, fmt.Errorf("decode %s: %w", path, err))
 			return nil
 		}
 
-		// Delete if expired
-		if !e.Expiry.IsZero() && e.Expiry.Before(cutoff) {
-			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		// Delete if expired or left behind by an epoch bump
+		expired := !e.Expiry.IsZero() && e.Expiry.Before(cutoff)
+		if expired || !s.reachable(path, e.Key) {
+			if err := s.removeEntry(path); err != nil {
 				errs = append(errs, fmt.Errorf("remove %s: %w", path, err))
 			} else {
 				n++
@@ -297,7 +910,7 @@ func (s *Store[K, V]) Cleanup(ctx context.Context, maxAge time.Duration) (int, e
 	return n, errors.Join(errs...)
 }
 
-// Flush removes all entries from the file-based cache.
+// Flush removes all entries from the file-based cache, including streamed values.
 // Returns the number of entries removed and any errors encountered.
 func (s *Store[K, V]) Flush(ctx context.Context) (int, error) {
 	n := 0
@@ -313,10 +926,14 @@ func (s *Store[K, V]) Flush(ctx context.Context) (int, error) {
 			errs = append(errs, fmt.Errorf("walk %s: %w", path, err))
 			return nil
 		}
-		if fi.IsDir() || !s.isCacheFile(fi.Name()) {
+		if fi.IsDir() || (!s.isCacheFile(fi.Name()) && !isBlobFile(fi.Name())) {
 			return nil
 		}
-		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		rm := s.removeEntry
+		if isBlobFile(fi.Name()) {
+			rm = os.Remove
+		}
+		if err := rm(path); err != nil && !os.IsNotExist(err) {
 			errs = append(errs, fmt.Errorf("remove %s: %w", path, err))
 		} else {
 			n++
@@ -335,12 +952,14 @@ func (s *Store[K, V]) Flush(ctx context.Context) (int, error) {
 	return n, errors.Join(errs...)
 }
 
-// Len returns the number of entries in the file-based cache.
+// Len returns the number of entries in the file-based cache, counting streamed
+// values and expired entries not yet cleaned up. Files orphaned by BumpEpoch or
+// SetVersion are not counted; telling them apart means reading every file.
 func (s *Store[K, V]) Len(ctx context.Context) (int, error) {
 	n := 0
 	var errs []error
 
-	walkErr := filepath.Walk(s.Dir, func(_ string, fi os.FileInfo, err error) error {
+	walkErr := filepath.Walk(s.Dir, func(path string, fi os.FileInfo, err error) error {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
@@ -350,10 +969,19 @@ func (s *Store[K, V]) Len(ctx context.Context) (int, error) {
 			errs = append(errs, err)
 			return nil
 		}
-		if fi.IsDir() || !s.isCacheFile(fi.Name()) {
+		if fi.IsDir() || (!s.isCacheFile(fi.Name()) && !isBlobFile(fi.Name())) {
 			return nil
 		}
-		n++
+		if isBlobFile(fi.Name()) {
+			if stale, err := s.blobStale(path, time.Time{}); err == nil && !stale {
+				n++
+			}
+			return nil
+		}
+		// Unreadable files are skipped, as Get would miss them.
+		if e, err := s.readEntry(path); err == nil && s.reachable(path, e.Key) {
+			n++
+		}
 		return nil
 	})
 
@@ -364,10 +992,12 @@ func (s *Store[K, V]) Len(ctx context.Context) (int, error) {
 	return n, errors.Join(errs...)
 }
 
-// Close cleans up resources.
-func (*Store[K, V]) Close() error {
-	// No resources to clean up for file-based persistence
-	return nil
+// Close saves the key index, if enabled, for the next New to load.
+func (s *Store[K, V]) Close() error {
+	if s.index == nil {
+		return nil
+	}
+	return s.index.save()
 }
 
 // Keys returns an iterator over keys matching prefix.
@@ -384,7 +1014,8 @@ func (s *Store[K, V]) Keys(ctx context.Context, prefix string) iter.Seq[string]
 
 // Range returns an iterator over key-value pairs matching prefix.
 // Implements PrefixScanner[V] interface (only usable when K is string).
-// Walks all subdirectories and reads files to extract keys and values.
+// Walks all subdirectories and reads files to extract keys and values,
+// skipping files orphaned by BumpEpoch or SetVersion.
 func (s *Store[K, V]) Range(ctx context.Context, prefix string) iter.Seq2[string, V] {
 	return func(yield func(string, V) bool) {
 		//nolint:errcheck // Walk errors are benign - we skip problematic files
@@ -402,26 +1033,14 @@ func (s *Store[K, V]) Range(ctx context.Context, prefix string) iter.Seq2[string
 			}
 
 			// Read file to get original key and value from Entry.
-			b, err := os.ReadFile(path)
-			//nolint:nilerr // Skip unreadable files
+			e, err := s.readEntry(path)
+			//nolint:nilerr // Skip unreadable and corrupted files
 			if err != nil {
 				return nil
 			}
 
-			data, err := s.compressor.Decode(b)
-			//nolint:nilerr // Skip corrupted files
-			if err != nil {
-				return nil
-			}
-
-			var e Entry[K, V]
-			//nolint:nilerr // Skip malformed files
-			if err := json.Unmarshal(data, &e); err != nil {
-				return nil
-			}
-
-			// Skip expired entries.
-			if !e.Expiry.IsZero() && time.Now().After(e.Expiry) {
+			// Skip expired and orphaned entries.
+			if (!e.Expiry.IsZero() && time.Now().After(e.Expiry)) || !s.reachable(path, e.Key) {
 				return nil
 			}
 
@@ -441,3 +1060,33 @@ func (s *Store[K, V]) Range(ctx context.Context, prefix string) iter.Seq2[string
 		})
 	}
 }
+
+// OwnerKeys returns an iterator over the keys of entries written by SetOwned
+// for owner, including expired entries not yet cleaned up but not files
+// orphaned by BumpEpoch or SetVersion.
+// Implements fido.OwnerTagger (only usable when K is string).
+func (s *Store[K, V]) OwnerKeys(ctx context.Context, owner string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		//nolint:errcheck // Walk errors are benign - we skip problematic files
+		_ = filepath.Walk(s.Dir, func(path string, fi os.FileInfo, err error) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+
+			//nolint:nilerr // Skip files with errors
+			if err != nil || fi.IsDir() || !s.isCacheFile(fi.Name()) {
+				return nil
+			}
+			e, err := s.readEntry(path)
+			if err != nil || e.Owner != owner || !s.reachable(path, e.Key) {
+				return nil //nolint:nilerr // Skip unreadable, orphaned, and other owners' entries
+			}
+			if !yield(fmt.Sprintf("%v", e.Key)) {
+				return filepath.SkipAll
+			}
+			return nil
+		})
+	}
+}
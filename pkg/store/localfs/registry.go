@@ -0,0 +1,69 @@
+package localfs
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/codeGROOVE-dev/fido/pkg/store/compress"
+	"github.com/codeGROOVE-dev/fido/pkg/store/registry"
+)
+
+// Register makes registry.Open construct a Store[K, V] for file:// URIs:
+//
+//	file:///var/cache/myapp?compress=s2   files under the given directory
+//	file://                               files under the user cache dir
+//	file://?location=system&dirmode=0770  files under /var/cache (or $CACHE_DIRECTORY)
+//
+// Call it once for each K, V pair before Open.
+func Register[K comparable, V any]() {
+	registry.Register("file", open[K, V])
+}
+
+func open[K comparable, V any](_ context.Context, u *url.URL, cacheID string) (registry.Store[K, V], error) {
+	cfg, err := fileConfig(u)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Compressor, err = compress.Parse(u.Query().Get("compress")); err != nil {
+		return nil, err
+	}
+	s, err := NewWithConfig[K, V](cacheID, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// fileConfig parses file://[dir][?location=user|system&dirmode=0750&filemode=0600].
+// Both file:///abs/path and file://relative/path forms are accepted; an empty
+// dir selects the location's cache dir.
+func fileConfig(u *url.URL) (Config, error) {
+	q := u.Query()
+	cfg := Config{Dir: u.Host + u.Path}
+	switch loc := strings.ToLower(q.Get("location")); loc {
+	case "", "user":
+	case "system":
+		cfg.Location = SystemCache
+	default:
+		return Config{}, fmt.Errorf("unknown file location %q", loc)
+	}
+	for _, m := range []struct {
+		param string
+		mode  *os.FileMode
+	}{{"dirmode", &cfg.DirMode}, {"filemode", &cfg.FileMode}} {
+		v := q.Get(m.param)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.ParseUint(v, 8, 32)
+		if err != nil || n > 0o777 {
+			return Config{}, fmt.Errorf("invalid %s %q: want octal permissions like 0750", m.param, v)
+		}
+		*m.mode = os.FileMode(n)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,59 @@
+package localfs
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/codeGROOVE-dev/fido/pkg/store/registry"
+)
+
+func TestRegister(t *testing.T) {
+	ctx := context.Background()
+	Register[string, int]()
+
+	s, err := registry.Open[string, int](ctx, "file://"+t.TempDir()+"?compress=s2", "test-registry")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer func() {
+		if err := s.Close(); err != nil {
+			t.Logf("Close error: %v", err)
+		}
+	}()
+	if _, ok := s.(*Store[string, int]); !ok {
+		t.Fatalf("Open(file://) = %T; want *localfs.Store", s)
+	}
+	if err := s.Set(ctx, "k", 42, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if v, _, found, err := s.Get(ctx, "k"); err != nil || !found || v != 42 {
+		t.Errorf("Get = %d, %v, %v; want 42, true, nil", v, found, err)
+	}
+
+	if _, err := registry.Open[string, int](ctx, "file://"+t.TempDir()+"?compress=lz4", "c"); err == nil {
+		t.Error("unknown compressor should fail")
+	}
+}
+
+func TestFileConfig(t *testing.T) {
+	u, err := url.Parse("file://?location=system&dirmode=0770&filemode=660")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := fileConfig(u)
+	if err != nil {
+		t.Fatalf("fileConfig: %v", err)
+	}
+	if cfg.Dir != "" || cfg.Location != SystemCache || cfg.DirMode != 0o770 || cfg.FileMode != 0o660 {
+		t.Errorf("fileConfig = %+v; want system location, 0770, 0660", cfg)
+	}
+
+	for _, q := range []string{"location=elsewhere", "dirmode=rwx", "filemode=1777"} {
+		u, _ := url.Parse("file:///tmp/x?" + q) //nolint:errcheck // valid
+		if _, err := fileConfig(u); err == nil {
+			t.Errorf("fileConfig(?%s) succeeded; want error", q)
+		}
+	}
+}
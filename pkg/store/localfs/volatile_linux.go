@@ -0,0 +1,24 @@
+package localfs
+
+import "syscall"
+
+// Filesystem magic numbers from statfs(2).
+const (
+	tmpfsMagic = 0x01021994
+	ramfsMagic = 0x858458f6
+)
+
+// isVolatile reports whether dir is on a RAM-backed filesystem, whose
+// contents cannot survive a reboot however carefully they are written.
+func isVolatile(dir string) bool {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return false
+	}
+	//nolint:gosec,unconvert // G115: Type is int32 or int64 depending on GOARCH
+	switch uint32(st.Type) {
+	case tmpfsMagic, ramfsMagic:
+		return true
+	}
+	return false
+}
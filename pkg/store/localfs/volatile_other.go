@@ -0,0 +1,9 @@
+//go:build !linux
+
+package localfs
+
+// isVolatile reports whether dir is on a RAM-backed filesystem.
+// Detection is only implemented on Linux.
+func isVolatile(string) bool {
+	return false
+}
@@ -0,0 +1,41 @@
+package localfs
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// goos is runtime.GOOS, replaceable by tests.
+var goos = runtime.GOOS
+
+// windowsReserved are device names Windows refuses as file names, with or without an extension.
+var windowsReserved = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// checkWindowsName reports why name cannot be a file or directory name on Windows.
+// Entry names are lowercase hex digests and always pass; this guards the cacheID.
+func checkWindowsName(name string) error {
+	if i := strings.IndexAny(name, `<>:"|?*`); i >= 0 {
+		return fmt.Errorf("contains %q, which Windows does not allow in file names", name[i])
+	}
+	for _, r := range name {
+		if r < 0x20 {
+			return errors.New("contains a control character")
+		}
+	}
+	if strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") {
+		return errors.New("ends in a dot or space, which Windows strips")
+	}
+	base, _, _ := strings.Cut(name, ".")
+	if windowsReserved[strings.ToUpper(strings.TrimRight(base, " "))] {
+		return fmt.Errorf("%q is a reserved device name on Windows", base)
+	}
+	return nil
+}
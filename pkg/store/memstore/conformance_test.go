@@ -0,0 +1,13 @@
+package memstore
+
+import (
+	"testing"
+
+	"github.com/codeGROOVE-dev/fido/pkg/store/persisttest"
+)
+
+func TestConformance(t *testing.T) {
+	persisttest.TestStore(t, func(*testing.T) persisttest.Store[string, string] {
+		return New[string, string]()
+	})
+}
@@ -0,0 +1,8 @@
+module github.com/codeGROOVE-dev/fido/pkg/store/memstore
+
+go 1.25.4
+
+// Test-only: the persisttest conformance suite.
+require github.com/codeGROOVE-dev/fido/pkg/store/persisttest v1.10.0
+
+replace github.com/codeGROOVE-dev/fido/pkg/store/persisttest => ../persisttest
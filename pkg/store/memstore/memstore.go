@@ -0,0 +1,198 @@
+// Package memstore provides an in-memory store implementation for fido.
+// Entries live in a map, so unit tests get deterministic persistence behavior
+// without temporary directories or network services. Data does not survive
+// the process; use localfs or a remote store for real persistence.
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type entry[V any] struct {
+	value  V
+	expiry time.Time
+}
+
+// Store implements fido.Store, PrefixScanner, EpochBumper, and Batcher in memory.
+// It is safe for concurrent use.
+type Store[K comparable, V any] struct {
+	// Now returns the current time, for expiry checks. Tests may replace it
+	// before use to control expiration; it defaults to time.Now.
+	Now func() time.Time
+
+	entries map[K]entry[V]
+	epoch   uint64
+	mu      sync.RWMutex
+}
+
+// New creates an empty in-memory store.
+func New[K comparable, V any]() *Store[K, V] {
+	return &Store[K, V]{Now: time.Now, entries: make(map[K]entry[V])}
+}
+
+// expired reports whether e has expired at now.
+func (e entry[V]) expired(now time.Time) bool {
+	return !e.expiry.IsZero() && now.After(e.expiry)
+}
+
+// ValidateKey always returns nil (all keys are valid).
+func (*Store[K, V]) ValidateKey(_ K) error {
+	return nil
+}
+
+// Location returns a description of where key is stored.
+func (*Store[K, V]) Location(key K) string {
+	return fmt.Sprintf("memory:%v", key)
+}
+
+// Get retrieves a value. Expired entries are removed and reported as not found.
+//
+//nolint:revive // function-result-limit: required by Store interface
+func (s *Store[K, V]) Get(_ context.Context, key K) (value V, expiry time.Time, found bool, err error) {
+	s.mu.RLock()
+	e, ok := s.entries[key]
+	s.mu.RUnlock()
+	if !ok {
+		return value, time.Time{}, false, nil
+	}
+	if e.expired(s.Now()) {
+		s.mu.Lock()
+		if cur, ok := s.entries[key]; ok && cur.expiry.Equal(e.expiry) {
+			delete(s.entries, key)
+		}
+		s.mu.Unlock()
+		return value, time.Time{}, false, nil
+	}
+	return e.value, e.expiry, true, nil
+}
+
+// Set stores a value. A zero expiry means the entry never expires.
+func (s *Store[K, V]) Set(_ context.Context, key K, value V, expiry time.Time) error {
+	s.mu.Lock()
+	s.entries[key] = entry[V]{value: value, expiry: expiry}
+	s.mu.Unlock()
+	return nil
+}
+
+// Delete removes a value. Deleting a missing key is not an error.
+func (s *Store[K, V]) Delete(_ context.Context, key K) error {
+	s.mu.Lock()
+	delete(s.entries, key)
+	s.mu.Unlock()
+	return nil
+}
+
+// SetBatch stores several values under one lock. Implements fido.Batcher.
+func (s *Store[K, V]) SetBatch(_ context.Context, keys []K, values []V, expiries []time.Time) error {
+	s.mu.Lock()
+	for i, key := range keys {
+		s.entries[key] = entry[V]{value: values[i], expiry: expiries[i]}
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// DeleteBatch removes several values under one lock. Implements fido.Batcher.
+func (s *Store[K, V]) DeleteBatch(_ context.Context, keys []K) error {
+	s.mu.Lock()
+	for _, key := range keys {
+		delete(s.entries, key)
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// Cleanup removes entries that expired more than maxAge ago.
+// Returns the count of deleted entries.
+func (s *Store[K, V]) Cleanup(ctx context.Context, maxAge time.Duration) (int, error) {
+	cutoff := s.Now().Add(-maxAge)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for k, e := range s.entries {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+		if !e.expiry.IsZero() && e.expiry.Before(cutoff) {
+			delete(s.entries, k)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Flush removes all entries. Returns the number of entries removed.
+func (s *Store[K, V]) Flush(_ context.Context) (int, error) {
+	s.mu.Lock()
+	n := len(s.entries)
+	clear(s.entries)
+	s.mu.Unlock()
+	return n, nil
+}
+
+// BumpEpoch removes all entries and returns the new epoch.
+// Implements fido.EpochBumper.
+func (s *Store[K, V]) BumpEpoch(_ context.Context) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.entries)
+	s.epoch++
+	return s.epoch, nil
+}
+
+// Len returns the number of entries, including expired entries not yet
+// removed by Get or Cleanup.
+func (s *Store[K, V]) Len(_ context.Context) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.entries), nil
+}
+
+// Keys returns an iterator over unexpired keys matching prefix, in sorted order.
+// Implements PrefixScanner[V] interface (only usable when K is string).
+func (s *Store[K, V]) Keys(ctx context.Context, prefix string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for k := range s.Range(ctx, prefix) {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Range returns an iterator over unexpired key-value pairs matching prefix,
+// in sorted key order. It iterates over a snapshot, so the store may be
+// modified during iteration.
+// Implements PrefixScanner[V] interface (only usable when K is string).
+func (s *Store[K, V]) Range(ctx context.Context, prefix string) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		now := s.Now()
+		s.mu.RLock()
+		matched := make(map[string]V)
+		for k, e := range s.entries {
+			name := fmt.Sprintf("%v", k)
+			if strings.HasPrefix(name, prefix) && !e.expired(now) {
+				matched[name] = e.value
+			}
+		}
+		s.mu.RUnlock()
+
+		for _, name := range slices.Sorted(maps.Keys(matched)) {
+			if ctx.Err() != nil || !yield(name, matched[name]) {
+				return
+			}
+		}
+	}
+}
+
+// Close is a no-op and returns nil. The store remains usable.
+func (*Store[K, V]) Close() error {
+	return nil
+}
@@ -0,0 +1,227 @@
+package memstore
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// clock is a manually advanced time source.
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+func newClocked(t *testing.T) (*Store[string, int], *clock) {
+	t.Helper()
+	c := &clock{t: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s := New[string, int]()
+	s.Now = c.now
+	return s, c
+}
+
+func TestSetGet(t *testing.T) {
+	s, c := newClocked(t)
+	ctx := context.Background()
+	exp := c.t.Add(time.Hour)
+
+	if err := s.Set(ctx, "a", 1, exp); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	v, gotExp, found, err := s.Get(ctx, "a")
+	if err != nil || !found || v != 1 || !gotExp.Equal(exp) {
+		t.Errorf("Get(a) = %d, %v, %v, %v; want 1, %v, true, nil", v, gotExp, found, err, exp)
+	}
+
+	if _, _, found, err := s.Get(ctx, "missing"); err != nil || found {
+		t.Errorf("Get(missing) found = %v, err = %v; want false, nil", found, err)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	s, c := newClocked(t)
+	ctx := context.Background()
+
+	if err := s.Set(ctx, "short", 1, c.t.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set(ctx, "forever", 2, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	c.t = c.t.Add(2 * time.Minute)
+	if _, _, found, _ := s.Get(ctx, "short"); found {
+		t.Error("Get(short) after expiry found = true; want false")
+	}
+	if n, _ := s.Len(ctx); n != 1 {
+		t.Errorf("Len() = %d; want 1 (expired entry removed by Get)", n)
+	}
+	if v, _, found, _ := s.Get(ctx, "forever"); !found || v != 2 {
+		t.Errorf("Get(forever) = %d, %v; want 2, true", v, found)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	s, c := newClocked(t)
+	ctx := context.Background()
+
+	if err := s.Set(ctx, "a", 1, c.t.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, "a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, _, found, _ := s.Get(ctx, "a"); found {
+		t.Error("Get after Delete found = true; want false")
+	}
+	if err := s.Delete(ctx, "a"); err != nil {
+		t.Errorf("Delete(missing) = %v; want nil", err)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	s, c := newClocked(t)
+	ctx := context.Background()
+
+	exp := c.t.Add(time.Hour)
+	if err := s.SetBatch(ctx, []string{"a", "b", "c"}, []int{1, 2, 3}, []time.Time{exp, {}, exp}); err != nil {
+		t.Fatalf("SetBatch: %v", err)
+	}
+	if v, gotExp, found, _ := s.Get(ctx, "b"); !found || v != 2 || !gotExp.IsZero() {
+		t.Errorf("Get(b) = %d, %v, %v; want 2, zero, true", v, gotExp, found)
+	}
+	if err := s.DeleteBatch(ctx, []string{"a", "c", "missing"}); err != nil {
+		t.Fatalf("DeleteBatch: %v", err)
+	}
+	if n, _ := s.Len(ctx); n != 1 {
+		t.Errorf("Len() = %d; want 1", n)
+	}
+}
+
+func TestCleanup(t *testing.T) {
+	s, c := newClocked(t)
+	ctx := context.Background()
+	start := c.t
+
+	for k, exp := range map[string]time.Time{
+		"old":     start.Add(time.Minute),
+		"recent":  start.Add(50 * time.Minute),
+		"live":    start.Add(2 * time.Hour),
+		"forever": {},
+	} {
+		if err := s.Set(ctx, k, 1, exp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c.t = start.Add(time.Hour)
+	n, err := s.Cleanup(ctx, 30*time.Minute)
+	if err != nil {
+		t.Fatalf("Cleanup: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Cleanup() = %d; want 1", n)
+	}
+	if got, _ := s.Len(ctx); got != 3 {
+		t.Errorf("Len() = %d; want 3", got)
+	}
+
+	n, err = s.Cleanup(ctx, 0)
+	if err != nil || n != 1 {
+		t.Errorf("Cleanup(0) = %d, %v; want 1, nil", n, err)
+	}
+}
+
+func TestFlushAndBumpEpoch(t *testing.T) {
+	s, c := newClocked(t)
+	ctx := context.Background()
+
+	for _, k := range []string{"a", "b", "c"} {
+		if err := s.Set(ctx, k, 1, c.t.Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	n, err := s.Flush(ctx)
+	if err != nil || n != 3 {
+		t.Errorf("Flush() = %d, %v; want 3, nil", n, err)
+	}
+	if got, _ := s.Len(ctx); got != 0 {
+		t.Errorf("Len() after Flush = %d; want 0", got)
+	}
+
+	if err := s.Set(ctx, "d", 1, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	for want := uint64(1); want <= 2; want++ {
+		e, err := s.BumpEpoch(ctx)
+		if err != nil || e != want {
+			t.Errorf("BumpEpoch() = %d, %v; want %d, nil", e, err, want)
+		}
+	}
+	if _, _, found, _ := s.Get(ctx, "d"); found {
+		t.Error("Get after BumpEpoch found = true; want false")
+	}
+}
+
+func TestKeysRange(t *testing.T) {
+	s, c := newClocked(t)
+	ctx := context.Background()
+
+	for i, k := range []string{"user:2", "user:1", "org:1", "user:3"} {
+		exp := c.t.Add(time.Hour)
+		if k == "user:3" {
+			exp = c.t.Add(-time.Second)
+		}
+		if err := s.Set(ctx, k, i, exp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keys := slices.Collect(s.Keys(ctx, "user:"))
+	if want := []string{"user:1", "user:2"}; !slices.Equal(keys, want) {
+		t.Errorf("Keys(user:) = %v; want %v", keys, want)
+	}
+
+	got := make(map[string]int)
+	for k, v := range s.Range(ctx, "") {
+		got[k] = v
+	}
+	if len(got) != 3 || got["org:1"] != 2 || got["user:2"] != 0 {
+		t.Errorf("Range() = %v; want org:1=2 user:1=1 user:2=0", got)
+	}
+
+	for range s.Keys(ctx, "") {
+		break // early exit must not panic
+	}
+}
+
+func TestLocation(t *testing.T) {
+	s := New[int, string]()
+	if got := s.Location(42); got != "memory:42" {
+		t.Errorf("Location(42) = %q; want %q", got, "memory:42")
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	s := New[int, int]()
+	ctx := context.Background()
+	done := make(chan struct{})
+	for g := range 8 {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for i := range 200 {
+				_ = s.Set(ctx, i, g, time.Time{}) //nolint:errcheck // never fails
+				_, _, _, _ = s.Get(ctx, i)        //nolint:dogsled // only exercising locking
+				if i%10 == 0 {
+					_ = s.Delete(ctx, i) //nolint:errcheck // never fails
+				}
+			}
+		}()
+	}
+	for range 8 {
+		<-done
+	}
+	if n, _ := s.Len(ctx); n > 200 {
+		t.Errorf("Len() = %d; want <= 200", n)
+	}
+}
@@ -0,0 +1,361 @@
+// Package persisttest is a conformance suite for fido persistence stores.
+// Backend authors call TestStore from their own tests so every store honors
+// the same contract:
+//
+//	func TestConformance(t *testing.T) {
+//		persisttest.TestStore(t, func(t *testing.T) persisttest.Store[string, string] {
+//			s, err := mystore.New[string, string](t.Context(), "test")
+//			if err != nil {
+//				t.Skipf("store unavailable: %v", err)
+//			}
+//			return s
+//		})
+//	}
+package persisttest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Store is the persistence interface under test.
+// Matches fido.Store so any backend usable with fido.NewTiered can be tested.
+type Store[K comparable, V any] interface {
+	ValidateKey(key K) error
+	Get(ctx context.Context, key K) (V, time.Time, bool, error)
+	Set(ctx context.Context, key K, value V, expiry time.Time) error
+	Delete(ctx context.Context, key K) error
+	Cleanup(ctx context.Context, maxAge time.Duration) (int, error)
+	Flush(ctx context.Context) (int, error)
+	Len(ctx context.Context) (int, error)
+	Close() error
+}
+
+// expirySlack is how far a stored expiry may drift from the one written,
+// allowing for backends that keep coarser timestamps.
+const expirySlack = time.Second
+
+// LargeValueBytes is the size of the value written by the large-value test.
+const LargeValueBytes = 512 << 10
+
+// TestStore runs the conformance suite against stores returned by newStore.
+// Each subtest calls newStore for a fresh, empty store and closes it when done;
+// newStore may call t.Skip if the backend is unavailable.
+//
+// The suite covers reads and writes, overwrites, deletes, expiry, empty and
+// large values, keys with special characters, concurrent use, Cleanup, Flush,
+// and Len. Keys rejected by ValidateKey and values rejected by an optional
+// ValidateValue method are skipped rather than failed.
+func TestStore(t *testing.T, newStore func(t *testing.T) Store[string, string]) {
+	t.Helper()
+	tests := []struct {
+		name string
+		fn   func(*testing.T, Store[string, string])
+	}{
+		{"SetGet", testSetGet},
+		{"Missing", testMissing},
+		{"Overwrite", testOverwrite},
+		{"Delete", testDelete},
+		{"Expired", testExpired},
+		{"TTL", testTTL},
+		{"NoExpiry", testNoExpiry},
+		{"EmptyValue", testEmptyValue},
+		{"LargeValue", testLargeValue},
+		{"SpecialKeys", testSpecialKeys},
+		{"Concurrent", testConcurrent},
+		{"Cleanup", testCleanup},
+		{"FlushLen", testFlushLen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStore(t)
+			t.Cleanup(func() {
+				if err := s.Close(); err != nil {
+					t.Errorf("Close: %v", err)
+				}
+			})
+			tt.fn(t, s)
+		})
+	}
+}
+
+// set writes key, failing the test on error.
+func set(t *testing.T, s Store[string, string], key, value string, expiry time.Time) {
+	t.Helper()
+	if err := s.Set(t.Context(), key, value, expiry); err != nil {
+		t.Fatalf("Set(%q): %v", key, err)
+	}
+}
+
+// want fails the test unless key holds value.
+func want(t *testing.T, s Store[string, string], key, value string) {
+	t.Helper()
+	got, _, found, err := s.Get(t.Context(), key)
+	if err != nil {
+		t.Fatalf("Get(%q): %v", key, err)
+	}
+	if !found {
+		t.Fatalf("Get(%q) found = false; want true", key)
+	}
+	if got != value {
+		t.Errorf("Get(%q) = %q; want %q", key, abbrev(got), abbrev(value))
+	}
+}
+
+// wantMissing fails the test unless key is absent.
+func wantMissing(t *testing.T, s Store[string, string], key string) {
+	t.Helper()
+	got, _, found, err := s.Get(t.Context(), key)
+	if err != nil {
+		t.Fatalf("Get(%q): %v", key, err)
+	}
+	if found {
+		t.Errorf("Get(%q) = %q, found; want not found", key, abbrev(got))
+	}
+}
+
+// wantLen fails the test unless the store holds n entries.
+func wantLen(t *testing.T, s Store[string, string], n int) {
+	t.Helper()
+	got, err := s.Len(t.Context())
+	if err != nil {
+		t.Fatalf("Len: %v", err)
+	}
+	if got != n {
+		t.Errorf("Len() = %d; want %d", got, n)
+	}
+}
+
+func abbrev(s string) string {
+	if len(s) > 64 {
+		return fmt.Sprintf("%s... (%d bytes)", s[:64], len(s))
+	}
+	return s
+}
+
+func testSetGet(t *testing.T, s Store[string, string]) {
+	exp := time.Now().Add(time.Hour)
+	set(t, s, "key1", "value1", exp)
+
+	got, gotExp, found, err := s.Get(t.Context(), "key1")
+	if err != nil || !found || got != "value1" {
+		t.Fatalf("Get(key1) = %q, found=%v, err=%v; want value1, true, nil", got, found, err)
+	}
+	if d := gotExp.Sub(exp).Abs(); d > expirySlack {
+		t.Errorf("Get(key1) expiry = %v; want %v (within %v)", gotExp, exp, expirySlack)
+	}
+}
+
+func testMissing(t *testing.T, s Store[string, string]) {
+	wantMissing(t, s, "never-set")
+}
+
+func testOverwrite(t *testing.T, s Store[string, string]) {
+	exp := time.Now().Add(time.Hour)
+	set(t, s, "key", "first", exp)
+	set(t, s, "key", "second", exp)
+	want(t, s, "key", "second")
+	wantLen(t, s, 1)
+}
+
+func testDelete(t *testing.T, s Store[string, string]) {
+	set(t, s, "gone", "v", time.Now().Add(time.Hour))
+	set(t, s, "kept", "v", time.Now().Add(time.Hour))
+	if err := s.Delete(t.Context(), "gone"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	wantMissing(t, s, "gone")
+	want(t, s, "kept", "v")
+
+	if err := s.Delete(t.Context(), "never-set"); err != nil {
+		t.Errorf("Delete(missing) = %v; want nil", err)
+	}
+}
+
+func testExpired(t *testing.T, s Store[string, string]) {
+	set(t, s, "past", "v", time.Now().Add(-time.Minute))
+	wantMissing(t, s, "past")
+}
+
+func testTTL(t *testing.T, s Store[string, string]) {
+	if testing.Short() {
+		t.Skip("waits for expiry")
+	}
+	set(t, s, "short", "v", time.Now().Add(time.Second))
+	set(t, s, "long", "v", time.Now().Add(time.Hour))
+	want(t, s, "short", "v")
+
+	time.Sleep(1500 * time.Millisecond)
+	wantMissing(t, s, "short")
+	want(t, s, "long", "v")
+}
+
+func testNoExpiry(t *testing.T, s Store[string, string]) {
+	set(t, s, "forever", "v", time.Time{})
+	_, exp, found, err := s.Get(t.Context(), "forever")
+	if err != nil || !found {
+		t.Fatalf("Get(forever) found=%v, err=%v; want true, nil", found, err)
+	}
+	if !exp.IsZero() {
+		t.Errorf("Get(forever) expiry = %v; want zero", exp)
+	}
+}
+
+// validValue reports whether s accepts value, for stores with an optional ValidateValue.
+func validValue(s Store[string, string], value string) error {
+	if v, ok := s.(interface{ ValidateValue(string) error }); ok {
+		return v.ValidateValue(value)
+	}
+	return nil
+}
+
+func testEmptyValue(t *testing.T, s Store[string, string]) {
+	set(t, s, "empty", "", time.Now().Add(time.Hour))
+	want(t, s, "empty", "")
+}
+
+func testLargeValue(t *testing.T, s Store[string, string]) {
+	// Mixed content so compressing backends still store a large payload.
+	var b strings.Builder
+	for i := 0; b.Len() < LargeValueBytes; i++ {
+		fmt.Fprintf(&b, "%08x", uint32(i)*2654435761) //nolint:gosec // G115: wraparound intended
+	}
+	value := b.String()[:LargeValueBytes]
+	if err := validValue(s, value); err != nil {
+		t.Skipf("store rejects %d-byte values: %v", len(value), err)
+	}
+	set(t, s, "large", value, time.Now().Add(time.Hour))
+	want(t, s, "large", value)
+}
+
+// specialKeys exercise encoding, path handling, and key namespacing.
+var specialKeys = []string{
+	"with space",
+	"with/slash",
+	"../../escape",
+	`back\slash`,
+	"colon:separated:key",
+	"percent%20encoded",
+	"query?a=b&c=d",
+	"quote\"'`",
+	"tab\tnewline\n",
+	"nul\x00byte",
+	"unicode-日本語-🚀",
+	"*glob[chars]",
+	".",
+	"..",
+	"UPPER",
+	"upper",
+	strings.Repeat("k", 200),
+}
+
+func testSpecialKeys(t *testing.T, s Store[string, string]) {
+	var stored []string
+	for _, k := range specialKeys {
+		if err := s.ValidateKey(k); err != nil {
+			t.Logf("ValidateKey(%q) rejected: %v", k, err)
+			continue
+		}
+		set(t, s, k, "value-"+k, time.Now().Add(time.Hour))
+		stored = append(stored, k)
+	}
+	for _, k := range stored {
+		want(t, s, k, "value-"+k)
+	}
+	wantLen(t, s, len(stored))
+
+	for _, k := range stored {
+		if err := s.Delete(t.Context(), k); err != nil {
+			t.Fatalf("Delete(%q): %v", k, err)
+		}
+		wantMissing(t, s, k)
+	}
+}
+
+func testConcurrent(t *testing.T, s Store[string, string]) {
+	const workers, ops = 8, 25
+	exp := time.Now().Add(time.Hour)
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*ops)
+	for w := range workers {
+		wg.Go(func() {
+			ctx := context.WithoutCancel(t.Context())
+			for i := range ops {
+				key := fmt.Sprintf("w%d-%d", w, i)
+				if err := s.Set(ctx, key, key, exp); err != nil {
+					errs <- fmt.Errorf("Set(%q): %w", key, err)
+				}
+				if i == ops/2 {
+					// Writers racing on one key must each leave a whole value.
+					if err := s.Set(ctx, "shared", key, exp); err != nil {
+						errs <- fmt.Errorf("Set(shared): %w", err)
+					}
+				}
+				if _, _, _, err := s.Get(ctx, "shared"); err != nil {
+					errs <- fmt.Errorf("Get(shared): %w", err)
+				}
+			}
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	for w := range workers {
+		for i := range ops {
+			key := fmt.Sprintf("w%d-%d", w, i)
+			want(t, s, key, key)
+		}
+	}
+	got, _, found, err := s.Get(t.Context(), "shared")
+	if err != nil || !found || !strings.HasPrefix(got, "w") {
+		t.Errorf("Get(shared) = %q, found=%v, err=%v; want a written value", got, found, err)
+	}
+	wantLen(t, s, workers*ops+1)
+}
+
+func testCleanup(t *testing.T, s Store[string, string]) {
+	set(t, s, "live", "v", time.Now().Add(time.Hour))
+	set(t, s, "forever", "v", time.Time{})
+	set(t, s, "expired", "v", time.Now().Add(-time.Hour))
+
+	if _, err := s.Cleanup(t.Context(), 0); err != nil {
+		t.Fatalf("Cleanup: %v", err)
+	}
+	want(t, s, "live", "v")
+	want(t, s, "forever", "v")
+	wantMissing(t, s, "expired")
+	wantLen(t, s, 2)
+}
+
+func testFlushLen(t *testing.T, s Store[string, string]) {
+	wantLen(t, s, 0)
+	const n = 10
+	for i := range n {
+		set(t, s, fmt.Sprintf("key%d", i), "v", time.Now().Add(time.Hour))
+	}
+	wantLen(t, s, n)
+
+	removed, err := s.Flush(t.Context())
+	if err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if removed != n {
+		t.Errorf("Flush() = %d; want %d", removed, n)
+	}
+	wantLen(t, s, 0)
+	for i := range n {
+		wantMissing(t, s, fmt.Sprintf("key%d", i))
+	}
+
+	removed, err = s.Flush(t.Context())
+	if err != nil || removed != 0 {
+		t.Errorf("Flush() on empty store = %d, %v; want 0, nil", removed, err)
+	}
+}
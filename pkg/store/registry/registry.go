@@ -0,0 +1,98 @@
+// Package registry constructs fido persistence stores from URI strings, so the
+// backend can be chosen by configuration rather than code. Two schemes are
+// built in:
+//
+//	mem://    in-process memory only (tests, WebAssembly)
+//	null://   no persistence
+//
+// Each other backend registers its own schemes, so a program links only the
+// backends it uses. Stores are generic, so a backend cannot register itself for
+// every key and value type at init; call its Register once for each K, V pair
+// before Open:
+//
+//	localfs.Register[K, V]()    file://
+//	valkey.Register[K, V]()     valkey://, valkeys://, redis://, rediss://
+//	datastore.Register[K, V]()  datastore://
+//	cloudrun.Register[K, V]()   cloudrun://
+//
+// Third-party stores add schemes the same way, with Register.
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codeGROOVE-dev/fido/pkg/store/memstore"
+	"github.com/codeGROOVE-dev/fido/pkg/store/null"
+)
+
+// Store is the persistence interface returned by Open.
+// Matches fido.Store so callers can pass it to fido.NewTiered.
+type Store[K comparable, V any] interface {
+	ValidateKey(key K) error
+	Get(ctx context.Context, key K) (V, time.Time, bool, error)
+	Set(ctx context.Context, key K, value V, expiry time.Time) error
+	Delete(ctx context.Context, key K) error
+	Cleanup(ctx context.Context, maxAge time.Duration) (int, error)
+	Flush(ctx context.Context) (int, error)
+	Len(ctx context.Context) (int, error)
+	Close() error
+}
+
+// Opener constructs a store for a parsed URI.
+type Opener[K comparable, V any] func(ctx context.Context, u *url.URL, cacheID string) (Store[K, V], error)
+
+// ErrUnknownScheme is returned by Open for a scheme with no registered or
+// built-in store, including one whose backend was registered only for other
+// key and value types.
+var ErrUnknownScheme = errors.New("unknown store scheme")
+
+type regKey struct {
+	scheme string
+	opener reflect.Type // Opener[K, V], identifying the key and value types
+}
+
+var (
+	mu     sync.RWMutex
+	opener = map[regKey]any{}
+)
+
+// Register makes a store available to Open under scheme for the K and V types
+// of fn. Since stores are generic, register once for each K, V pair in use.
+// Registering a built-in scheme overrides it for those types.
+func Register[K comparable, V any](scheme string, fn Opener[K, V]) {
+	mu.Lock()
+	defer mu.Unlock()
+	opener[regKey{scheme: strings.ToLower(scheme), opener: reflect.TypeFor[Opener[K, V]]()}] = fn
+}
+
+// Open constructs the store described by uri, namespaced by cacheID.
+func Open[K comparable, V any](ctx context.Context, uri, cacheID string) (Store[K, V], error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("parse store URI: %w", err)
+	}
+	scheme := strings.ToLower(u.Scheme)
+
+	mu.RLock()
+	fn, ok := opener[regKey{scheme: scheme, opener: reflect.TypeFor[Opener[K, V]]()}]
+	mu.RUnlock()
+	if ok {
+		return fn.(Opener[K, V])(ctx, u, cacheID) //nolint:errcheck,forcetypeassert // keyed by its type
+	}
+
+	switch scheme {
+	case "mem":
+		return memstore.New[K, V](), nil
+	case "null":
+		return null.New[K, V](), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownScheme, u.Scheme)
+	}
+}
@@ -0,0 +1,66 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/codeGROOVE-dev/fido/pkg/store/memstore"
+	"github.com/codeGROOVE-dev/fido/pkg/store/null"
+)
+
+func TestOpen_Null(t *testing.T) {
+	s, err := Open[string, int](context.Background(), "null://", "test-registry")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, ok := s.(*null.Store[string, int]); !ok {
+		t.Errorf("Open(null://) = %T; want *null.Store", s)
+	}
+}
+
+func TestOpen_Mem(t *testing.T) {
+	s, err := Open[string, int](context.Background(), "mem://", "test-registry")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, ok := s.(*memstore.Store[string, int]); !ok {
+		t.Errorf("Open(mem://) = %T; want *memstore.Store", s)
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	ctx := context.Background()
+	if _, err := Open[string, int](ctx, "bogus://x", "c"); !errors.Is(err, ErrUnknownScheme) {
+		t.Errorf("unknown scheme error = %v; want ErrUnknownScheme", err)
+	}
+	// Backends register themselves; none has here.
+	if _, err := Open[string, int](ctx, "valkey://localhost:6379/0", "c"); !errors.Is(err, ErrUnknownScheme) {
+		t.Errorf("unregistered backend error = %v; want ErrUnknownScheme", err)
+	}
+	if _, err := Open[string, int](ctx, "file://\x00", "c"); err == nil {
+		t.Error("unparseable URI should fail")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	ctx := context.Background()
+	var gotHost, gotID string
+	Register("custom", func(_ context.Context, u *url.URL, cacheID string) (Store[string, int], error) {
+		gotHost, gotID = u.Host, cacheID
+		return null.New[string, int](), nil
+	})
+
+	if _, err := Open[string, int](ctx, "CUSTOM://somewhere", "my-cache"); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if gotHost != "somewhere" || gotID != "my-cache" {
+		t.Errorf("opener got host %q, cacheID %q; want somewhere, my-cache", gotHost, gotID)
+	}
+
+	// Registrations are per key/value type.
+	if _, err := Open[string, string](ctx, "custom://somewhere", "my-cache"); !errors.Is(err, ErrUnknownScheme) {
+		t.Errorf("Open with other types = %v; want ErrUnknownScheme", err)
+	}
+}
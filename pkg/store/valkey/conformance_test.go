@@ -0,0 +1,26 @@
+package valkey
+
+import (
+	"os"
+	"testing"
+
+	"github.com/codeGROOVE-dev/fido/pkg/store/persisttest"
+)
+
+func TestConformance(t *testing.T) {
+	skipIfNoValkey(t)
+	addr := os.Getenv("VALKEY_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+	persisttest.TestStore(t, func(t *testing.T) persisttest.Store[string, string] {
+		s, err := New[string, string](t.Context(), "test-conformance", addr)
+		if err != nil {
+			t.Skipf("Valkey not available: %v", err)
+		}
+		if _, err := s.Flush(t.Context()); err != nil {
+			t.Fatalf("Flush: %v", err)
+		}
+		return s
+	})
+}
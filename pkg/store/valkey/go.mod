@@ -4,12 +4,26 @@ go 1.25.4
 
 require (
 	github.com/codeGROOVE-dev/fido/pkg/store/compress v1.10.0
+	github.com/codeGROOVE-dev/fido/pkg/store/registry v1.10.0
 	github.com/valkey-io/valkey-go v1.0.70
 )
 
+// Test-only: the persisttest conformance suite.
+require github.com/codeGROOVE-dev/fido/pkg/store/persisttest v1.10.0
+
 require (
+	github.com/codeGROOVE-dev/fido/pkg/store/memstore v1.10.0 // indirect
+	github.com/codeGROOVE-dev/fido/pkg/store/null v1.10.0 // indirect
 	github.com/klauspost/compress v1.18.3 // indirect
 	golang.org/x/sys v0.40.0 // indirect
 )
 
 replace github.com/codeGROOVE-dev/fido/pkg/store/compress => ../compress
+
+replace github.com/codeGROOVE-dev/fido/pkg/store/persisttest => ../persisttest
+
+replace github.com/codeGROOVE-dev/fido/pkg/store/registry => ../registry
+
+replace github.com/codeGROOVE-dev/fido/pkg/store/null => ../null
+
+replace github.com/codeGROOVE-dev/fido/pkg/store/memstore => ../memstore
@@ -0,0 +1,65 @@
+package valkey
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/codeGROOVE-dev/fido/pkg/store/compress"
+	"github.com/codeGROOVE-dev/fido/pkg/store/registry"
+)
+
+// Register makes registry.Open construct a Store[K, V] for
+// valkey://[user:pass@]host:port[/db][?tls=1&hashtag=1&sweep=1&checkttl=1] URIs and their
+// aliases redis://, valkeys:// and rediss:// (the last two with TLS). Call it
+// once for each K, V pair before Open.
+func Register[K comparable, V any]() {
+	for _, scheme := range []string{"valkey", "valkeys", "redis", "rediss"} {
+		registry.Register(scheme, open[K, V])
+	}
+}
+
+func open[K comparable, V any](ctx context.Context, u *url.URL, cacheID string) (registry.Store[K, V], error) {
+	cfg, err := uriConfig(u)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Compressor, err = compress.Parse(u.Query().Get("compress")); err != nil {
+		return nil, err
+	}
+	s, err := NewWithConfig[K, V](ctx, cacheID, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// uriConfig parses the URI forms accepted by Register.
+func uriConfig(u *url.URL) (Config, error) {
+	q := u.Query()
+	scheme := strings.ToLower(u.Scheme)
+	cfg := Config{
+		Addr:         u.Host,
+		Username:     u.User.Username(),
+		TLS:          scheme == "valkeys" || scheme == "rediss" || truthy(q.Get("tls")),
+		HashTag:      truthy(q.Get("hashtag")),
+		SweepUntimed: truthy(q.Get("sweep")),
+		CheckTTL:     truthy(q.Get("checkttl")),
+	}
+	cfg.Password, _ = u.User.Password()
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		n, err := strconv.Atoi(db)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid valkey database %q: %w", db, err)
+		}
+		cfg.DB = n
+	}
+	return cfg, nil
+}
+
+func truthy(s string) bool {
+	b, err := strconv.ParseBool(s)
+	return err == nil && b
+}
@@ -0,0 +1,52 @@
+package valkey
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/codeGROOVE-dev/fido/pkg/store/registry"
+)
+
+func TestRegister_BadURI(t *testing.T) {
+	ctx := context.Background()
+	Register[string, int]()
+	if _, err := registry.Open[string, int](ctx, "valkey://localhost:6379/notanumber", "c"); err == nil {
+		t.Error("non-numeric valkey database should fail")
+	}
+	if _, err := registry.Open[string, int](ctx, "redis://localhost:6379?compress=lz4", "c"); err == nil {
+		t.Error("unknown compressor should fail")
+	}
+}
+
+func TestURIConfig(t *testing.T) {
+	tests := []struct {
+		uri                 string
+		addr, user, pass    string
+		db                  int
+		tls, hashTag, sweep bool
+		checkTTL            bool
+	}{
+		{uri: "valkey://localhost:6379", addr: "localhost:6379"},
+		{uri: "redis://u:p@cache:6380/3?hashtag=1", addr: "cache:6380", user: "u", pass: "p", db: 3, hashTag: true},
+		{uri: "valkey://cache:6379/0?tls=true&sweep=1", addr: "cache:6379", tls: true, sweep: true},
+		{uri: "rediss://:secret@cache:6379", addr: "cache:6379", pass: "secret", tls: true},
+		{uri: "valkeys://cache:6379", addr: "cache:6379", tls: true},
+		{uri: "valkey://cache:6379?checkttl=1", addr: "cache:6379", checkTTL: true},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.uri)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tt.uri, err)
+		}
+		cfg, err := uriConfig(u)
+		if err != nil {
+			t.Errorf("uriConfig(%q): %v", tt.uri, err)
+			continue
+		}
+		if cfg.Addr != tt.addr || cfg.Username != tt.user || cfg.Password != tt.pass || cfg.DB != tt.db ||
+			cfg.TLS != tt.tls || cfg.HashTag != tt.hashTag || cfg.SweepUntimed != tt.sweep || cfg.CheckTTL != tt.checkTTL {
+			t.Errorf("uriConfig(%q) = %+v", tt.uri, cfg)
+		}
+	}
+}
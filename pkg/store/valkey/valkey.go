@@ -3,25 +3,85 @@ package valkey
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"iter"
+	"log/slog"
+	"net"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/codeGROOVE-dev/fido/pkg/store/compress"
 	"github.com/valkey-io/valkey-go"
 )
 
-const maxKeyLength = 512 // Maximum key length for Valkey
+const (
+	maxKeyLength     = 512       // Maximum key length for Valkey
+	maxValueLength   = 512 << 20 // Valkey's proto-max-bulk-len default
+	pttlMissing      = -2        // PTTL reply for a key that does not exist
+	maxVersionLength = 64
+	epochCacheTTL    = time.Minute // Client-side lifetime of the cached epoch; the server invalidates it sooner on change
+	ttlTolerance     = time.Second // CheckTTL drift allowed between the recorded expiry and PTTL
+)
+
+// ErrValueTooLarge is returned by Set when an encoded value exceeds Valkey's
+// default 512MB bulk string limit.
+var ErrValueTooLarge = errors.New("value too large for valkey")
 
 // Store implements persistence using Valkey/Redis.
 type Store[K comparable, V any] struct {
 	client     valkey.Client
-	prefix     string // Key prefix to namespace cache entries
+	prefix     atomic.Pointer[string] // Key prefix to namespace cache entries, including version and epoch
+	ns         string                 // cacheID, or {cacheID} with HashTag
+	nsMu       sync.Mutex             // Serializes prefix changes
+	epoch      atomic.Uint64          // Written under nsMu; only ever rises
+	swept      atomic.Uint64          // Epoch whose predecessors Cleanup last deleted
+	version    string                 // Guarded by nsMu
 	compressor compress.Compressor
 	ext        string
+	fallback   func([]byte) (V, error) // SetDecodeFallback; nil when unset
+	sweep      bool                    // Cleanup deletes entries that lost their TTL past their recorded expiry
+	track      bool                    // Set records each entry's intended expiry; see expiryKey
+	checkTTL   bool                    // Get compares the recorded expiry with PTTL
+	mismatches atomic.Uint64           // CheckTTL disagreements seen by Get
+}
+
+// Config holds optional settings for NewWithConfig.
+type Config struct {
+	// Addr is the server address in "host:port" form. Default "localhost:6379".
+	Addr string
+	// Username and Password authenticate with the server (AUTH). Optional.
+	Username string
+	Password string
+	// DB selects the logical database. Default 0.
+	DB int
+	// TLS enables TLS using the system root CAs.
+	TLS bool
+	// Compressor enables compression. Default: no compression.
+	Compressor compress.Compressor
+	// HashTag wraps the cacheID in a {hash tag} so every key of this cache maps to
+	// one cluster slot. This enables multi-key commands (and MULTI/EXEC) across the
+	// cache's keys, at the cost of concentrating its load on a single shard.
+	// Leave false to spread keys across the cluster.
+	HashTag bool
+	// SweepUntimed makes Set record each entry's intended expiry, as CheckTTL
+	// does, and Cleanup(maxAge) SCAN for entries that have no server TTL although
+	// their recorded expiry passed more than maxAge ago, such as after a PERSIST
+	// or a restore that dropped TTLs, deleting them. Entries stored deliberately
+	// without expiry are kept, as are entries written without a record (before
+	// the option was enabled, or by a process without it).
+	SweepUntimed bool
+	// CheckTTL makes Set record each entry's intended expiry beside it, and Get
+	// compare that with the server's PTTL, logging and counting (TTLMismatches)
+	// entries whose expiry has drifted, such as after an EXPIRE or PERSIST from
+	// a CLI. Get still returns the server's expiry. It adds a command to each
+	// Set and Get pipeline.
+	CheckTTL bool
 }
 
 // New creates a new Valkey-based persistence layer.
@@ -29,19 +89,43 @@ type Store[K comparable, V any] struct {
 // addr should be in the format "host:port" (e.g., "localhost:6379").
 // Optional compressor enables compression (default: no compression).
 func New[K comparable, V any](ctx context.Context, cacheID, addr string, c ...compress.Compressor) (*Store[K, V], error) {
+	cfg := Config{Addr: addr}
+	if len(c) > 0 {
+		cfg.Compressor = c[0]
+	}
+	return NewWithConfig[K, V](ctx, cacheID, cfg)
+}
+
+// NewWithConfig creates a new Valkey-based persistence layer with explicit settings.
+func NewWithConfig[K comparable, V any](ctx context.Context, cacheID string, cfg Config) (*Store[K, V], error) {
 	if cacheID == "" {
 		return nil, errors.New("cacheID cannot be empty")
 	}
+	addr := cfg.Addr
 	if addr == "" {
 		addr = "localhost:6379"
 	}
 
 	comp := compress.None()
-	if len(c) > 0 && c[0] != nil {
-		comp = c[0]
+	if cfg.Compressor != nil {
+		comp = cfg.Compressor
+	}
+
+	opt := valkey.ClientOption{
+		InitAddress: []string{addr},
+		Username:    cfg.Username,
+		Password:    cfg.Password,
+		SelectDB:    cfg.DB,
+	}
+	if cfg.TLS {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		opt.TLSConfig = &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
 	}
 
-	client, err := valkey.NewClient(valkey.ClientOption{InitAddress: []string{addr}})
+	client, err := valkey.NewClient(opt)
 	if err != nil {
 		return nil, fmt.Errorf("create valkey client: %w", err)
 	}
@@ -51,12 +135,328 @@ func New[K comparable, V any](ctx context.Context, cacheID, addr string, c ...co
 		return nil, fmt.Errorf("valkey ping failed: %w", err)
 	}
 
-	return &Store[K, V]{
+	ns := cacheID
+	if cfg.HashTag {
+		ns = "{" + cacheID + "}"
+	}
+
+	s := &Store[K, V]{
 		client:     client,
-		prefix:     cacheID + ":",
+		ns:         ns,
+		sweep:      cfg.SweepUntimed,
+		track:      cfg.CheckTTL || cfg.SweepUntimed,
+		checkTTL:   cfg.CheckTTL,
 		compressor: comp,
 		ext:        comp.Extension(),
-	}, nil
+	}
+
+	s.setEpoch(0)
+	if err := s.syncEpoch(ctx); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// epochKey is where the current epoch is stored. It deliberately matches no entry pattern.
+func (s *Store[K, V]) epochKey() string {
+	return s.ns + "@epoch"
+}
+
+// setEpoch switches the key namespace to epoch, unless a later one is already in use.
+func (s *Store[K, V]) setEpoch(epoch uint64) {
+	s.nsMu.Lock()
+	defer s.nsMu.Unlock()
+	if s.prefix.Load() != nil && epoch <= s.epoch.Load() {
+		return
+	}
+	s.epoch.Store(epoch)
+	s.setPrefix()
+}
+
+// syncEpoch follows a BumpEpoch made by another process. The epoch is read
+// through valkey-go's client-side cache, which the server invalidates when the
+// counter changes, so this normally costs no round trip.
+func (s *Store[K, V]) syncEpoch(ctx context.Context) error {
+	epoch, err := s.client.DoCache(ctx, s.client.B().Get().Key(s.epochKey()).Cache(), epochCacheTTL).AsUint64()
+	if err != nil && !valkey.IsValkeyNil(err) {
+		return fmt.Errorf("load epoch: %w", err)
+	}
+	if epoch > s.epoch.Load() {
+		s.setEpoch(epoch)
+	}
+	return nil
+}
+
+// setPrefix publishes the key prefix for the current version and epoch:
+// "cacheID:" for neither, "cacheID@epoch:", "cacheID#version:", or
+// "cacheID#version@epoch:". The hash tag, if any, stays outside both so
+// switching never moves the slot. Callers hold nsMu.
+func (s *Store[K, V]) setPrefix() {
+	p := s.ns
+	if s.version != "" {
+		p += "#" + s.version
+	}
+	if epoch := s.epoch.Load(); epoch > 0 {
+		p += "@" + strconv.FormatUint(epoch, 10)
+	}
+	p += ":"
+	s.prefix.Store(&p)
+}
+
+// SetVersion namespaces later operations under version. Versions may use
+// letters, digits, and ".-_+", so they cannot be confused with the epoch or
+// Valkey glob patterns. Implements fido.Versioner.
+func (s *Store[K, V]) SetVersion(version string) error {
+	if len(version) > maxVersionLength {
+		return fmt.Errorf("version too long: %d bytes (max %d)", len(version), maxVersionLength)
+	}
+	for _, r := range version {
+		if !isVersionRune(r) {
+			return fmt.Errorf("invalid character %q in version %q", r, version)
+		}
+	}
+	s.nsMu.Lock()
+	defer s.nsMu.Unlock()
+	s.version = version
+	s.setPrefix()
+	return nil
+}
+
+// SetDecodeFallback sets a function that decodes values json.Unmarshal
+// rejects as V, such as those written before V's fields changed type. fn
+// receives the value's JSON. Call it before using the store.
+// Implements fido.FallbackDecoder.
+func (s *Store[K, V]) SetDecodeFallback(fn func(raw []byte) (V, error)) {
+	s.fallback = fn
+}
+
+// corruptError wraps a failure to decode a stored entry. Its Corrupt method
+// lets fido.TieredCache tell a damaged entry from an unreachable store.
+type corruptError struct{ err error }
+
+func (e *corruptError) Error() string { return e.err.Error() }
+func (e *corruptError) Unwrap() error { return e.err }
+func (*corruptError) Corrupt() bool   { return true }
+
+// decodeValue decodes a value's JSON, falling back to the decode fallback.
+func (s *Store[K, V]) decodeValue(data []byte) (V, error) {
+	var v V
+	err := json.Unmarshal(data, &v)
+	if err == nil || s.fallback == nil {
+		return v, err
+	}
+	v, ferr := s.fallback(data)
+	if ferr != nil {
+		return v, errors.Join(err, fmt.Errorf("decode fallback: %w", ferr))
+	}
+	return v, nil
+}
+
+func isVersionRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune(".-_+", r)
+}
+
+// PurgeVersions deletes this cache's entries from other versions and epochs,
+// which otherwise linger until their TTL. Implements fido.Versioner.
+func (s *Store[K, V]) PurgeVersions(ctx context.Context) (int, error) {
+	if err := s.syncEpoch(ctx); err != nil {
+		return 0, err
+	}
+	cur := s.keyPrefix()
+	return s.deleteEntries(ctx, func(k string) bool {
+		return !strings.HasPrefix(k, cur) && s.isEntryKey(k)
+	})
+}
+
+// deleteEntries SCANs every key under ns, across versions and epochs, and
+// deletes those match selects.
+func (s *Store[K, V]) deleteEntries(ctx context.Context, match func(k string) bool) (int, error) {
+	n := 0
+	pat := s.ns + "[:@#]*"
+	var cursor uint64
+
+	for {
+		select {
+		case <-ctx.Done():
+			return n, ctx.Err()
+		default:
+		}
+
+		scan, err := s.client.Do(ctx, s.client.B().Scan().Cursor(cursor).Match(pat).Count(100).Build()).AsScanEntry()
+		if err != nil {
+			return n, fmt.Errorf("scan keys: %w", err)
+		}
+
+		var stale, records []string
+		for _, k := range scan.Elements {
+			if rest, ok := strings.CutPrefix(k, s.ns+"@exp"); ok {
+				if match(s.ns + rest) {
+					records = append(records, k) // an expiry record, gone with its entry
+				}
+				continue
+			}
+			if match(k) {
+				stale = append(stale, k)
+			}
+		}
+		if len(stale) > 0 {
+			c, err := s.client.Do(ctx, s.client.B().Del().Key(stale...).Build()).AsInt64()
+			if err != nil {
+				return n, fmt.Errorf("delete keys: %w", err)
+			}
+			n += int(c)
+		}
+		if len(records) > 0 {
+			if err := s.client.Do(ctx, s.client.B().Del().Key(records...).Build()).Error(); err != nil {
+				return n, fmt.Errorf("delete expiry records: %w", err)
+			}
+		}
+
+		cursor = scan.Cursor
+		if cursor == 0 {
+			break
+		}
+	}
+
+	return n, nil
+}
+
+// olderEpoch returns a match for deleteEntries selecting entries of the
+// current version, in the current epoch too when current is set.
+func (s *Store[K, V]) olderEpoch(current bool) func(k string) bool {
+	s.nsMu.Lock()
+	version, epoch := s.version, s.epoch.Load()
+	s.nsMu.Unlock()
+	return func(k string) bool {
+		v, e, ok := s.parseEntryKey(k)
+		return ok && v == version && (e < epoch || current && e == epoch)
+	}
+}
+
+// parseEntryKey splits an entry key, which starts with ns, into its version
+// and epoch. It reports false for metadata such as the epoch counter or a lease.
+func (s *Store[K, V]) parseEntryKey(k string) (version string, epoch uint64, ok bool) {
+	rest := k[len(s.ns):]
+	if strings.HasPrefix(rest, "#") {
+		i := strings.IndexAny(rest, "@:")
+		if i < 0 {
+			return "", 0, false
+		}
+		version, rest = rest[1:i], rest[i:]
+	}
+	if strings.HasPrefix(rest, "@") {
+		e, _, found := strings.Cut(rest[1:], ":")
+		n, err := strconv.ParseUint(e, 10, 64)
+		if !found || err != nil {
+			return "", 0, false
+		}
+		return version, n, true
+	}
+	return version, 0, strings.HasPrefix(rest, ":")
+}
+
+// isEntryKey reports whether k, which starts with ns, is an entry under some
+// version and epoch rather than metadata such as the epoch counter or a lease.
+func (s *Store[K, V]) isEntryKey(k string) bool {
+	_, _, ok := s.parseEntryKey(k)
+	return ok
+}
+
+// keyPrefix returns the namespace prefix for the current epoch.
+func (s *Store[K, V]) keyPrefix() string {
+	return *s.prefix.Load()
+}
+
+// BumpEpoch makes all existing entries unreachable in O(1) by moving to a new key
+// namespace. The epoch is shared through Valkey: other processes using the same
+// cacheID move on their next operation. Orphaned entries expire via their TTL, and
+// Cleanup and Flush delete those of the current version that have none.
+// Implements fido.EpochBumper.
+func (s *Store[K, V]) BumpEpoch(ctx context.Context) (uint64, error) {
+	n, err := s.client.Do(ctx, s.client.B().Incr().Key(s.epochKey()).Build()).AsInt64()
+	if err != nil {
+		return 0, fmt.Errorf("valkey incr epoch: %w", err)
+	}
+	//nolint:gosec // G115: INCR never returns a negative value here
+	epoch := uint64(n)
+	s.setEpoch(epoch)
+	return epoch, nil
+}
+
+// leaseScript extends a lease held by ARGV[1], or takes it if free.
+var leaseScript = valkey.NewLuaScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+  return 1
+end
+return 0`)
+
+// leaseKey is where the named lease is stored. Like epochKey, it matches no entry pattern.
+func (s *Store[K, V]) leaseKey(name string) string {
+	return s.ns + "@lease:" + name
+}
+
+// ownersKey is the hash mapping Valkey keys to the owners that wrote them
+// with SetOwned. Like epochKey, it matches no entry pattern.
+func (s *Store[K, V]) ownersKey() string {
+	return s.ns + "@owners"
+}
+
+// hotKeysKey holds the SaveHotKeys list. Like epochKey, it matches no entry pattern.
+func (s *Store[K, V]) hotKeysKey() string {
+	return s.ns + "@hotkeys"
+}
+
+// SaveHotKeys replaces the saved hot key list with keys, hottest first.
+// Implements fido.HotKeyRecorder.
+func (s *Store[K, V]) SaveHotKeys(ctx context.Context, keys []K) error {
+	b, err := json.Marshal(keys)
+	if err != nil {
+		return fmt.Errorf("marshal hot keys: %w", err)
+	}
+	if err := s.client.Do(ctx, s.client.B().Set().Key(s.hotKeysKey()).Value(string(b)).Build()).Error(); err != nil {
+		return fmt.Errorf("valkey set hot keys: %w", err)
+	}
+	return nil
+}
+
+// LoadHotKeys returns the list SaveHotKeys last saved, or nil if there is none.
+// Implements fido.HotKeyRecorder.
+func (s *Store[K, V]) LoadHotKeys(ctx context.Context) ([]K, error) {
+	b, err := s.client.Do(ctx, s.client.B().Get().Key(s.hotKeysKey()).Build()).AsBytes()
+	if valkey.IsValkeyNil(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("valkey get hot keys: %w", err)
+	}
+	var keys []K
+	if err := json.Unmarshal(b, &keys); err != nil {
+		return nil, fmt.Errorf("parse hot keys: %w", err)
+	}
+	return keys, nil
+}
+
+// expiryKey is where Set records the intended expiry of the entry at Valkey key
+// k, in Unix milliseconds or 0 for none. It carries the entry's TTL, so both
+// expire together, and like epochKey it matches no entry pattern.
+func (s *Store[K, V]) expiryKey(k string) string {
+	return s.ns + "@exp" + k[len(s.ns):]
+}
+
+// AcquireLease takes or extends the named lease for owner until ttl elapses.
+// Implements fido.Leaser.
+func (s *Store[K, V]) AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	ms := strconv.FormatInt(ttl.Milliseconds(), 10)
+	n, err := leaseScript.Exec(ctx, s.client, []string{s.leaseKey(name)}, []string{owner, ms}).AsInt64()
+	if err != nil {
+		return false, fmt.Errorf("valkey lease: %w", err)
+	}
+	return n == 1, nil
 }
 
 // ValidateKey checks if a key is valid for Valkey persistence.
@@ -71,9 +471,27 @@ func (*Store[K, V]) ValidateKey(key K) error {
 	return nil
 }
 
+// MaxKeyLength returns the longest key ValidateKey accepts. Implements fido.KeyLimiter.
+func (*Store[K, V]) MaxKeyLength() int {
+	return maxKeyLength
+}
+
 // makeKey creates a Valkey key from a cache key with prefix and extension.
+// ValidateValue checks that value can be JSON-encoded and, without compression,
+// that it fits Valkey's bulk string limit. Implements fido.ValueValidator.
+func (s *Store[K, V]) ValidateValue(value V) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("marshal value: %w", err)
+	}
+	if s.compressor.Extension() == "" && len(b) > maxValueLength {
+		return fmt.Errorf("%w: %d bytes (max %d)", ErrValueTooLarge, len(b), maxValueLength)
+	}
+	return nil
+}
+
 func (s *Store[K, V]) makeKey(key K) string {
-	return s.prefix + fmt.Sprintf("%v", key) + s.ext
+	return s.keyPrefix() + fmt.Sprintf("%v", key) + s.ext
 }
 
 // Location returns the Valkey key for a given cache key.
@@ -86,6 +504,9 @@ func (s *Store[K, V]) Location(key K) string {
 //nolint:revive,gocritic // function-result-limit, unnamedResult - required by persist.Store interface
 func (s *Store[K, V]) Get(ctx context.Context, key K) (V, time.Time, bool, error) {
 	var zero V
+	if err := s.syncEpoch(ctx); err != nil {
+		return zero, time.Time{}, false, err
+	}
 	k := s.makeKey(key)
 
 	// Get value and TTL in a pipeline for efficiency
@@ -93,6 +514,9 @@ func (s *Store[K, V]) Get(ctx context.Context, key K) (V, time.Time, bool, error
 		s.client.B().Get().Key(k).Build(),
 		s.client.B().Pttl().Key(k).Build(),
 	}
+	if s.checkTTL {
+		cmds = append(cmds, s.client.B().Get().Key(s.expiryKey(k)).Build())
+	}
 
 	resps := s.client.DoMulti(ctx, cmds...)
 
@@ -106,96 +530,321 @@ func (s *Store[K, V]) Get(ctx context.Context, key K) (V, time.Time, bool, error
 
 	jsonData, err := s.compressor.Decode(data)
 	if err != nil {
-		return zero, time.Time{}, false, fmt.Errorf("decompress: %w", err)
+		return zero, time.Time{}, false, &corruptError{fmt.Errorf("decompress: %w", err)}
 	}
 
-	var v V
-	if err := json.Unmarshal(jsonData, &v); err != nil {
-		return zero, time.Time{}, false, fmt.Errorf("unmarshal value: %w", err)
+	v, err := s.decodeValue(jsonData)
+	if err != nil {
+		return zero, time.Time{}, false, &corruptError{fmt.Errorf("unmarshal value: %w", err)}
 	}
 
-	// Parse TTL
+	// Expiry comes from the server's PTTL rather than anything recorded at Set time,
+	// so EXPIRE/PERSIST issued from a CLI is reflected accurately.
+	// -2 means the key expired between GET and PTTL; -1 means it has no TTL.
 	var exp time.Time
 	ms, err := resps[1].AsInt64()
-	if err == nil && ms > 0 {
-		exp = time.Now().Add(time.Duration(ms) * time.Millisecond)
+	if err == nil {
+		if ms == pttlMissing {
+			return zero, time.Time{}, false, nil
+		}
+		if ms > 0 {
+			exp = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		if s.checkTTL {
+			s.checkExpiry(k, exp, resps[2])
+		}
 	}
 
 	return v, exp, true, nil
 }
 
+// checkExpiry compares the expiry recorded for the entry at k with exp, the
+// server's, for Config.CheckTTL. Entries written without a record are skipped.
+func (s *Store[K, V]) checkExpiry(k string, exp time.Time, recorded valkey.ValkeyResult) {
+	ms, err := recorded.AsInt64()
+	if err != nil {
+		return
+	}
+	var want time.Time
+	if ms > 0 {
+		want = time.UnixMilli(ms)
+	}
+	if want.IsZero() == exp.IsZero() && (want.IsZero() || absDuration(want.Sub(exp)) <= ttlTolerance) {
+		return
+	}
+	s.mismatches.Add(1)
+	slog.Warn("valkey expiry differs from the one set", "key", k, "set", want, "server", exp)
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// TTLMismatches returns how many times Get found an entry whose server expiry
+// differs from the one it was written with, when Config.CheckTTL is set.
+func (s *Store[K, V]) TTLMismatches() uint64 {
+	return s.mismatches.Load()
+}
+
+// TTL returns the server-side remaining lifetime for a key, for consistency checks
+// against the expiry held by the memory tier; Config.CheckTTL makes Get check it
+// against the expiry the entry was written with. A zero duration with found=true
+// means the key never expires.
+func (s *Store[K, V]) TTL(ctx context.Context, key K) (ttl time.Duration, found bool, err error) {
+	if err := s.syncEpoch(ctx); err != nil {
+		return 0, false, err
+	}
+	ms, err := s.client.Do(ctx, s.client.B().Pttl().Key(s.makeKey(key)).Build()).AsInt64()
+	if err != nil {
+		return 0, false, fmt.Errorf("valkey pttl: %w", err)
+	}
+	switch {
+	case ms == pttlMissing:
+		return 0, false, nil
+	case ms < 0:
+		return 0, true, nil
+	default:
+		return time.Duration(ms) * time.Millisecond, true, nil
+	}
+}
+
 // Set saves a value to Valkey with optional expiry.
 func (s *Store[K, V]) Set(ctx context.Context, key K, value V, expiry time.Time) error {
+	return s.set(ctx, key, value, expiry, "")
+}
+
+// SetOwned is Set, tagging the entry with owner in the cache's owner index.
+// The tag is replaced by the next SetOwned and removed by Delete; a plain Set
+// leaves it, so in a namespace shared by several owners, write every entry
+// with SetOwned. Implements fido.OwnerTagger.
+func (s *Store[K, V]) SetOwned(ctx context.Context, key K, value V, expiry time.Time, owner string) error {
+	return s.set(ctx, key, value, expiry, owner)
+}
+
+func (s *Store[K, V]) set(ctx context.Context, key K, value V, expiry time.Time, owner string) error {
+	if err := s.syncEpoch(ctx); err != nil {
+		return err
+	}
+	cmds, err := s.setCmds(nil, key, value, expiry)
+	if err != nil || len(cmds) == 0 {
+		return err
+	}
+	if owner != "" {
+		cmds = append(cmds, s.client.B().Hset().Key(s.ownersKey()).FieldValue().FieldValue(s.makeKey(key), owner).Build())
+	}
+	for _, r := range s.client.DoMulti(ctx, cmds...) {
+		if err := r.Error(); err != nil {
+			return fmt.Errorf("valkey set: %w", err)
+		}
+	}
+	return nil
+}
+
+// setCmds encodes value and appends the SET for key to cmds, along with the
+// expiry record if Set records them. Nothing is appended if expiry has already
+// passed and there is nothing to write.
+func (s *Store[K, V]) setCmds(cmds []valkey.Completed, key K, value V, expiry time.Time) ([]valkey.Completed, error) {
 	jsonData, err := json.Marshal(value)
 	if err != nil {
-		return fmt.Errorf("marshal value: %w", err)
+		return cmds, fmt.Errorf("marshal value: %w", err)
 	}
 
 	data, err := s.compressor.Encode(jsonData)
 	if err != nil {
-		return fmt.Errorf("compress: %w", err)
+		return cmds, fmt.Errorf("compress: %w", err)
 	}
 
-	k := s.makeKey(key)
-	var cmd valkey.Completed
+	if len(data) > maxValueLength {
+		return cmds, fmt.Errorf("%w: %d bytes (max %d)", ErrValueTooLarge, len(data), maxValueLength)
+	}
 
-	if !expiry.IsZero() {
-		ttl := time.Until(expiry)
-		if ttl <= 0 {
-			return nil // Already expired
+	k := s.makeKey(key)
+	if expiry.IsZero() {
+		cmds = append(cmds, s.client.B().Set().Key(k).Value(string(data)).Build())
+		if s.track {
+			cmds = append(cmds, s.client.B().Set().Key(s.expiryKey(k)).Value("0").Build())
 		}
-		cmd = s.client.B().Set().Key(k).Value(string(data)).Px(ttl).Build()
-	} else {
-		cmd = s.client.B().Set().Key(k).Value(string(data)).Build()
+		return cmds, nil
+	}
+	ttl := time.Until(expiry)
+	if ttl <= 0 {
+		return cmds, nil // Already expired
 	}
+	cmds = append(cmds, s.client.B().Set().Key(k).Value(string(data)).Px(ttl).Build())
+	if s.track {
+		ms := strconv.FormatInt(expiry.UnixMilli(), 10)
+		cmds = append(cmds, s.client.B().Set().Key(s.expiryKey(k)).Value(ms).Px(ttl).Build())
+	}
+	return cmds, nil
+}
 
-	if err := s.client.Do(ctx, cmd).Error(); err != nil {
-		return fmt.Errorf("valkey set: %w", err)
+// SetBatch saves several values in one pipelined round trip. The writes are not
+// atomic: on error some of them may have been applied. Implements fido.Batcher.
+func (s *Store[K, V]) SetBatch(ctx context.Context, keys []K, values []V, expiries []time.Time) error {
+	if err := s.syncEpoch(ctx); err != nil {
+		return err
+	}
+	cmds := make([]valkey.Completed, 0, len(keys))
+	for i, key := range keys {
+		var err error
+		if cmds, err = s.setCmds(cmds, key, values[i], expiries[i]); err != nil {
+			return fmt.Errorf("key %v: %w", key, err)
+		}
+	}
+	if len(cmds) == 0 {
+		return nil
+	}
+	for _, r := range s.client.DoMulti(ctx, cmds...) {
+		if err := r.Error(); err != nil {
+			return fmt.Errorf("valkey set: %w", err)
+		}
 	}
 	return nil
 }
 
 // Delete removes a value from Valkey.
 func (s *Store[K, V]) Delete(ctx context.Context, key K) error {
+	if err := s.syncEpoch(ctx); err != nil {
+		return err
+	}
 	k := s.makeKey(key)
-	if err := s.client.Do(ctx, s.client.B().Del().Key(k).Build()).Error(); err != nil {
-		return fmt.Errorf("valkey delete: %w", err)
+	for _, r := range s.client.DoMulti(ctx,
+		s.client.B().Del().Key(k).Build(),
+		s.client.B().Del().Key(s.expiryKey(k)).Build(),
+		s.client.B().Hdel().Key(s.ownersKey()).Field(k).Build()) {
+		if err := r.Error(); err != nil {
+			return fmt.Errorf("valkey delete: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeleteBatch removes several values, and their owner tags, in one pipelined
+// round trip. Implements fido.Batcher.
+func (s *Store[K, V]) DeleteBatch(ctx context.Context, keys []K) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if err := s.syncEpoch(ctx); err != nil {
+		return err
+	}
+	// One DEL per key, so keys in different cluster slots do not fail together.
+	ks := make([]string, len(keys))
+	cmds := make([]valkey.Completed, 0, 2*len(keys)+1)
+	for i, key := range keys {
+		ks[i] = s.makeKey(key)
+		cmds = append(cmds,
+			s.client.B().Del().Key(ks[i]).Build(),
+			s.client.B().Del().Key(s.expiryKey(ks[i])).Build())
+	}
+	cmds = append(cmds, s.client.B().Hdel().Key(s.ownersKey()).Field(ks...).Build())
+	for _, r := range s.client.DoMulti(ctx, cmds...) {
+		if err := r.Error(); err != nil {
+			return fmt.Errorf("valkey delete: %w", err)
+		}
 	}
 	return nil
 }
 
 // Cleanup removes expired entries from Valkey.
-// Valkey handles expiration automatically via TTL, so this is a no-op.
-func (*Store[K, V]) Cleanup(_ context.Context, _ time.Duration) (int, error) {
-	// Valkey automatically handles TTL expiration
-	return 0, nil
+// Valkey handles expiration automatically via TTL, so this only deletes the
+// current version's entries from epochs before the latest BumpEpoch, once per
+// epoch, and, if Config.SweepUntimed is set, entries that lost their TTL more
+// than maxAge after their recorded expiry.
+func (s *Store[K, V]) Cleanup(ctx context.Context, maxAge time.Duration) (int, error) {
+	if err := s.syncEpoch(ctx); err != nil {
+		return 0, err
+	}
+
+	n := 0
+	if epoch := s.epoch.Load(); epoch > s.swept.Load() {
+		c, err := s.deleteEntries(ctx, s.olderEpoch(false))
+		n += c
+		if err != nil {
+			return n, err
+		}
+		s.swept.Store(epoch)
+	}
+	if !s.sweep {
+		// Valkey automatically handles TTL expiration
+		return n, nil
+	}
+
+	c, err := s.sweepUntimed(ctx, time.Now().Add(-maxAge))
+	return n + c, err
 }
 
-// Flush removes all entries with this cache's prefix from Valkey.
-// Returns the number of entries removed and any error.
-func (s *Store[K, V]) Flush(ctx context.Context) (int, error) {
+// sweepUntimed deletes entries that have no server TTL although the expiry
+// recorded when they were written passed before cutoff. Entries recorded as
+// never expiring, and entries without a record, are kept: nothing says they
+// were meant to expire. Keys whose state can't be read are reported, not skipped.
+func (s *Store[K, V]) sweepUntimed(ctx context.Context, cutoff time.Time) (int, error) {
 	n := 0
-	pat := s.prefix + "*"
+	var errs []error
+	pat := s.keyPrefix() + "*"
 	var cur uint64
 
 	for {
 		select {
 		case <-ctx.Done():
-			return n, ctx.Err()
+			return n, errors.Join(append(errs, ctx.Err())...)
 		default:
 		}
 
 		scan, err := s.client.Do(ctx, s.client.B().Scan().Cursor(cur).Match(pat).Count(100).Build()).AsScanEntry()
 		if err != nil {
-			return n, fmt.Errorf("scan keys: %w", err)
+			return n, errors.Join(append(errs, fmt.Errorf("scan keys: %w", err))...)
 		}
 
 		if len(scan.Elements) > 0 {
-			c, err := s.client.Do(ctx, s.client.B().Del().Key(scan.Elements...).Build()).AsInt64()
-			if err != nil {
-				return n, fmt.Errorf("delete keys: %w", err)
+			cmds := make([]valkey.Completed, 0, 2*len(scan.Elements))
+			for _, k := range scan.Elements {
+				cmds = append(cmds,
+					s.client.B().Pttl().Key(k).Build(),
+					s.client.B().Get().Key(s.expiryKey(k)).Build())
+			}
+			resps := s.client.DoMulti(ctx, cmds...)
+
+			var stale []string
+			for i, k := range scan.Elements {
+				ms, err := resps[2*i].AsInt64()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("pttl %s: %w", k, err))
+					continue
+				}
+				if ms != -1 {
+					continue // has a TTL, or vanished
+				}
+				exp, err := resps[2*i+1].AsInt64()
+				if valkey.IsValkeyNil(err) {
+					continue // no record
+				}
+				if err != nil {
+					errs = append(errs, fmt.Errorf("read expiry record of %s: %w", k, err))
+					continue
+				}
+				if exp > 0 && time.UnixMilli(exp).Before(cutoff) {
+					stale = append(stale, k, s.expiryKey(k))
+				}
+			}
+
+			// One DEL per key, so keys in different cluster slots do not fail together.
+			dels := make([]valkey.Completed, len(stale))
+			for i, k := range stale {
+				dels[i] = s.client.B().Del().Key(k).Build()
+			}
+			for i, r := range s.client.DoMulti(ctx, dels...) {
+				c, err := r.AsInt64()
+				switch {
+				case err != nil:
+					errs = append(errs, fmt.Errorf("delete %s: %w", stale[i], err))
+				case i%2 == 0:
+					n += int(c) // entries; odd indexes are their records
+				}
 			}
-			n += int(c)
 		}
 
 		cur = scan.Cursor
@@ -204,13 +853,26 @@ func (s *Store[K, V]) Flush(ctx context.Context) (int, error) {
 		}
 	}
 
-	return n, nil
+	return n, errors.Join(errs...)
 }
 
-// Len returns the number of entries with this cache's prefix in Valkey.
+// Flush removes this cache's entries in the current version from Valkey, including
+// those BumpEpoch left behind in earlier epochs.
+// Returns the number of entries removed and any error.
+func (s *Store[K, V]) Flush(ctx context.Context) (int, error) {
+	if err := s.syncEpoch(ctx); err != nil {
+		return 0, err
+	}
+	return s.deleteEntries(ctx, s.olderEpoch(true))
+}
+
+// Len returns the number of entries with this cache's prefix (in the current epoch) in Valkey.
 func (s *Store[K, V]) Len(ctx context.Context) (int, error) {
+	if err := s.syncEpoch(ctx); err != nil {
+		return 0, err
+	}
 	n := 0
-	pat := s.prefix + "*"
+	pat := s.keyPrefix() + "*"
 	var cur uint64
 
 	for {
@@ -246,7 +908,11 @@ func (s *Store[K, V]) Close() error {
 // Uses SCAN with pattern matching for efficiency.
 func (s *Store[K, V]) Keys(ctx context.Context, prefix string) iter.Seq[string] {
 	return func(yield func(string) bool) {
-		pat := s.prefix + prefix + "*" + s.ext
+		if s.syncEpoch(ctx) != nil {
+			return
+		}
+		ns := s.keyPrefix()
+		pat := ns + prefix + "*" + s.ext
 		var cur uint64
 
 		for {
@@ -264,7 +930,7 @@ func (s *Store[K, V]) Keys(ctx context.Context, prefix string) iter.Seq[string]
 
 			for _, rkey := range scan.Elements {
 				// Extract original key (remove prefix and extension).
-				name := strings.TrimPrefix(rkey, s.prefix)
+				name := strings.TrimPrefix(rkey, ns)
 				if s.ext != "" {
 					name = strings.TrimSuffix(name, s.ext)
 				}
@@ -288,7 +954,11 @@ func (s *Store[K, V]) Keys(ctx context.Context, prefix string) iter.Seq[string]
 // Uses SCAN with pattern matching, then GET pipeline for values.
 func (s *Store[K, V]) Range(ctx context.Context, prefix string) iter.Seq2[string, V] {
 	return func(yield func(string, V) bool) {
-		pat := s.prefix + prefix + "*" + s.ext
+		if s.syncEpoch(ctx) != nil {
+			return
+		}
+		ns := s.keyPrefix()
+		pat := ns + prefix + "*" + s.ext
 		var cur uint64
 
 		for {
@@ -319,13 +989,13 @@ func (s *Store[K, V]) Range(ctx context.Context, prefix string) iter.Seq2[string
 					continue
 				}
 
-				var v V
-				if err := json.Unmarshal(data, &v); err != nil {
+				v, err := s.decodeValue(data)
+				if err != nil {
 					continue
 				}
 
 				// Extract original key.
-				name := strings.TrimPrefix(rkey, s.prefix)
+				name := strings.TrimPrefix(rkey, ns)
 				if s.ext != "" {
 					name = strings.TrimSuffix(name, s.ext)
 				}
@@ -343,3 +1013,71 @@ func (s *Store[K, V]) Range(ctx context.Context, prefix string) iter.Seq2[string
 		}
 	}
 }
+
+// OwnerKeys returns an iterator over the keys of entries tagged with owner
+// by SetOwned, in the current version and epoch. Tags of entries that have
+// expired or been flushed are dropped from the owner index as they are found.
+// Implements fido.OwnerTagger (only usable when K is string).
+func (s *Store[K, V]) OwnerKeys(ctx context.Context, owner string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		if s.syncEpoch(ctx) != nil {
+			return
+		}
+		ns := s.keyPrefix()
+		idx := s.ownersKey()
+		var cur uint64
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			scan, err := s.client.Do(ctx, s.client.B().Hscan().Key(idx).Cursor(cur).Count(100).Build()).AsScanEntry()
+			if err != nil {
+				return
+			}
+
+			// Elements alternate field (Valkey key) and value (owner).
+			var keys []string
+			var cmds []valkey.Completed
+			for i := 0; i+1 < len(scan.Elements); i += 2 {
+				rkey := scan.Elements[i]
+				if scan.Elements[i+1] != owner || !strings.HasPrefix(rkey, ns) || !strings.HasSuffix(rkey, s.ext) {
+					continue
+				}
+				keys = append(keys, rkey)
+				cmds = append(cmds, s.client.B().Exists().Key(rkey).Build())
+			}
+
+			var gone []string
+			for i, r := range s.client.DoMulti(ctx, cmds...) {
+				n, err := r.AsInt64()
+				if err != nil {
+					continue
+				}
+				if n == 0 {
+					gone = append(gone, keys[i])
+					continue
+				}
+				name := strings.TrimPrefix(keys[i], ns)
+				if s.ext != "" {
+					name = strings.TrimSuffix(name, s.ext)
+				}
+				if !yield(name) {
+					return
+				}
+			}
+			if len(gone) > 0 {
+				//nolint:errcheck // best effort: a later scan prunes them again
+				s.client.Do(ctx, s.client.B().Hdel().Key(idx).Field(gone...).Build()).Error()
+			}
+
+			cur = scan.Cursor
+			if cur == 0 {
+				break
+			}
+		}
+	}
+}
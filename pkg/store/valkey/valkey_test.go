@@ -2,11 +2,16 @@ package valkey
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"maps"
 	"os"
+	"slices"
+	"strconv"
 	"testing"
 	"time"
+
+	"github.com/valkey-io/valkey-go"
 )
 
 // skipIfNoValkey skips the test if Valkey is not available.
@@ -42,6 +47,90 @@ func TestValkey_New_InvalidCacheID(t *testing.T) {
 	}
 }
 
+func TestValkey_Version(t *testing.T) {
+	s := &Store[string, int]{ns: "app", ext: ".s"}
+	s.setEpoch(0)
+	if got := s.Location("k"); got != "app:k.s" {
+		t.Errorf("Location = %q; want app:k.s", got)
+	}
+	if err := s.SetVersion("v1.2+build-3"); err != nil {
+		t.Fatalf("SetVersion: %v", err)
+	}
+	if got := s.Location("k"); got != "app#v1.2+build-3:k.s" {
+		t.Errorf("versioned Location = %q; want app#v1.2+build-3:k.s", got)
+	}
+	s.setEpoch(7)
+	if got := s.Location("k"); got != "app#v1.2+build-3@7:k.s" {
+		t.Errorf("versioned Location after epoch = %q; want app#v1.2+build-3@7:k.s", got)
+	}
+	for _, bad := range []string{"v:1", "v@1", "v*", "a b", string(make([]byte, maxVersionLength+1))} {
+		if err := s.SetVersion(bad); err == nil {
+			t.Errorf("SetVersion(%q) succeeded; want error", bad)
+		}
+	}
+
+	for k, want := range map[string]bool{
+		"app:k.s":          true,
+		"app@3:k.s":        true,
+		"app#v1:k.s":       true,
+		"app#v1@2:k.s":     true,
+		"app@epoch":        false,
+		"app@lease:clean":  false,
+		"app@:k":           false,
+		"app@owners":       false,
+		"app#v1":           false,
+		"app@hotkeys":      false,
+		"app@exp:k.s":      false,
+		"app@exp#v1@2:k.s": false,
+	} {
+		if got := s.isEntryKey(k); got != want {
+			t.Errorf("isEntryKey(%q) = %v; want %v", k, got, want)
+		}
+	}
+
+	if got := s.expiryKey("app#v1@2:k.s"); got != "app@exp#v1@2:k.s" {
+		t.Errorf("expiryKey = %q; want app@exp#v1@2:k.s", got)
+	}
+
+	// Cleanup deletes this version's entries from earlier epochs; Flush also the current one.
+	older, flush := s.olderEpoch(false), s.olderEpoch(true)
+	for k, want := range map[string][2]bool{
+		"app#v1.2+build-3@3:k.s": {true, true},
+		"app#v1.2+build-3:k.s":   {true, true},
+		"app#v1.2+build-3@7:k.s": {false, true},
+		"app#v1.2+build-3@8:k.s": {false, false},
+		"app@3:k.s":              {false, false},
+		"app#v2@3:k.s":           {false, false},
+		"app@epoch":              {false, false},
+	} {
+		if got := [2]bool{older(k), flush(k)}; got != want {
+			t.Errorf("olderEpoch(%q) = %v; want %v", k, got, want)
+		}
+	}
+}
+
+func TestValkey_DecodeFallback(t *testing.T) {
+	s := &Store[string, int]{}
+	if _, err := s.decodeValue([]byte(`"42"`)); err == nil {
+		t.Fatal("decodeValue of a string succeeded without a fallback")
+	}
+	s.SetDecodeFallback(func(raw []byte) (int, error) {
+		var v string
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return 0, err
+		}
+		return strconv.Atoi(v)
+	})
+	for raw, want := range map[string]int{`7`: 7, `"42"`: 42} {
+		if v, err := s.decodeValue([]byte(raw)); err != nil || v != want {
+			t.Errorf("decodeValue(%s) = %d, %v; want %d, nil", raw, v, err, want)
+		}
+	}
+	if _, err := s.decodeValue([]byte(`"x"`)); err == nil {
+		t.Error("decodeValue should fail when the fallback does")
+	}
+}
+
 func TestValkey_ValidateKey(t *testing.T) {
 	skipIfNoValkey(t)
 
@@ -1071,3 +1160,516 @@ func TestValkeyPersist_Keys_ContextCancellation(t *testing.T) {
 		_ = p.Delete(ctx, fmt.Sprintf("key-%d", i)) //nolint:errcheck // test cleanup
 	}
 }
+
+func TestValkey_BumpEpoch(t *testing.T) {
+	skipIfNoValkey(t)
+
+	ctx := context.Background()
+	cacheID := fmt.Sprintf("test-epoch-%d", time.Now().UnixNano())
+	p, err := New[string, int](ctx, cacheID, "localhost:6379")
+	if err != nil {
+		t.Skip("Valkey not available")
+	}
+	defer func() {
+		if _, err := p.Flush(ctx); err != nil {
+			t.Logf("Flush error: %v", err)
+		}
+		if err := p.client.Do(ctx, p.client.B().Del().Key(p.epochKey()).Build()).Error(); err != nil {
+			t.Logf("Del epoch error: %v", err)
+		}
+		if err := p.Close(); err != nil {
+			t.Logf("Close error: %v", err)
+		}
+	}()
+
+	if err := p.Set(ctx, "key1", 1, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	epoch, err := p.BumpEpoch(ctx)
+	if err != nil {
+		t.Fatalf("BumpEpoch: %v", err)
+	}
+	if epoch != 1 {
+		t.Errorf("BumpEpoch() = %d; want 1", epoch)
+	}
+	if _, _, found, err := p.Get(ctx, "key1"); err != nil || found {
+		t.Errorf("Get after bump: found=%v err=%v; want miss", found, err)
+	}
+
+	// A new client picks up the shared epoch.
+	p2, err := New[string, int](ctx, cacheID, "localhost:6379")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer func() { _ = p2.Close() }() //nolint:errcheck // test cleanup
+	if got := p2.keyPrefix(); got != cacheID+"@1:" {
+		t.Errorf("keyPrefix() = %q; want %q", got, cacheID+"@1:")
+	}
+}
+
+func TestValkey_BumpEpoch_OtherStore(t *testing.T) {
+	skipIfNoValkey(t)
+
+	ctx := context.Background()
+	cacheID := fmt.Sprintf("test-epoch-shared-%d", time.Now().UnixNano())
+	p, err := New[string, int](ctx, cacheID, "localhost:6379")
+	if err != nil {
+		t.Skip("Valkey not available")
+	}
+	p2, err := New[string, int](ctx, cacheID, "localhost:6379")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer func() {
+		if _, err := p.Flush(ctx); err != nil {
+			t.Logf("Flush error: %v", err)
+		}
+		if err := p.client.Do(ctx, p.client.B().Del().Key(p.epochKey()).Build()).Error(); err != nil {
+			t.Logf("Del epoch error: %v", err)
+		}
+		_ = p2.Close() //nolint:errcheck // test cleanup
+		_ = p.Close()  //nolint:errcheck // test cleanup
+	}()
+
+	if err := p.Set(ctx, "timed", 1, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := p.Set(ctx, "forever", 2, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	old := p.Location("forever")
+
+	if _, err := p2.BumpEpoch(ctx); err != nil {
+		t.Fatalf("BumpEpoch: %v", err)
+	}
+
+	// p never reconnects, yet follows the bump on its next operation.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		_, _, found, err := p.Get(ctx, "forever")
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if !found {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Get still sees the entry from before another store's BumpEpoch")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := p.Set(ctx, "fresh", 3, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, _, found, err := p2.Get(ctx, "fresh"); err != nil || !found {
+		t.Errorf("p2.Get(fresh) found=%v err=%v; want the write made under the new epoch", found, err)
+	}
+
+	// Cleanup reclaims the entries left behind in the old epoch, even those without a TTL.
+	n, err := p.Cleanup(ctx, time.Hour)
+	if err != nil {
+		t.Fatalf("Cleanup: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Cleanup() = %d; want the 2 old-epoch entries", n)
+	}
+	if c, err := p.client.Do(ctx, p.client.B().Exists().Key(old).Build()).AsInt64(); err != nil || c != 0 {
+		t.Errorf("EXISTS %s = %d, %v; want 0", old, c, err)
+	}
+	if _, _, found, err := p2.Get(ctx, "fresh"); err != nil || !found {
+		t.Errorf("Cleanup removed a current entry: found=%v err=%v", found, err)
+	}
+}
+
+func TestValkey_HotKeys(t *testing.T) {
+	skipIfNoValkey(t)
+
+	ctx := context.Background()
+	p, err := New[string, int](ctx, fmt.Sprintf("test-hotkeys-%d", time.Now().UnixNano()), "localhost:6379")
+	if err != nil {
+		t.Skip("Valkey not available")
+	}
+	defer func() {
+		if err := p.client.Do(ctx, p.client.B().Del().Key(p.hotKeysKey()).Build()).Error(); err != nil {
+			t.Logf("Del hot keys error: %v", err)
+		}
+		if err := p.Close(); err != nil {
+			t.Logf("Close error: %v", err)
+		}
+	}()
+
+	if keys, err := p.LoadHotKeys(ctx); err != nil || keys != nil {
+		t.Fatalf("LoadHotKeys before any save = %v, %v; want nil, nil", keys, err)
+	}
+	if err := p.SaveHotKeys(ctx, []string{"b", "a"}); err != nil {
+		t.Fatalf("SaveHotKeys: %v", err)
+	}
+	if keys, err := p.LoadHotKeys(ctx); err != nil || !slices.Equal(keys, []string{"b", "a"}) {
+		t.Errorf("LoadHotKeys = %v, %v; want [b a]", keys, err)
+	}
+	if n, err := p.Len(ctx); err != nil || n != 0 {
+		t.Errorf("Len = %d, %v; the hot key list must not count as an entry", n, err)
+	}
+}
+
+func TestValkey_TTL(t *testing.T) {
+	skipIfNoValkey(t)
+
+	ctx := context.Background()
+	p, err := New[string, int](ctx, "test-ttl", "localhost:6379")
+	if err != nil {
+		t.Skip("Valkey not available")
+	}
+	defer func() {
+		if _, err := p.Flush(ctx); err != nil {
+			t.Logf("Flush error: %v", err)
+		}
+		if err := p.Close(); err != nil {
+			t.Logf("Close error: %v", err)
+		}
+	}()
+
+	if _, found, err := p.TTL(ctx, "missing"); err != nil || found {
+		t.Errorf("TTL(missing) found=%v err=%v; want false, nil", found, err)
+	}
+
+	if err := p.Set(ctx, "forever", 1, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if ttl, found, err := p.TTL(ctx, "forever"); err != nil || !found || ttl != 0 {
+		t.Errorf("TTL(forever) = %v, %v, %v; want 0, true, nil", ttl, found, err)
+	}
+
+	if err := p.Set(ctx, "short", 1, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	// Simulate an operator shortening the TTL from a CLI.
+	k := p.makeKey("short")
+	if err := p.client.Do(ctx, p.client.B().Expire().Key(k).Seconds(60).Build()).Error(); err != nil {
+		t.Fatalf("EXPIRE: %v", err)
+	}
+	_, exp, found, err := p.Get(ctx, "short")
+	if err != nil || !found {
+		t.Fatalf("Get: found=%v err=%v", found, err)
+	}
+	if d := time.Until(exp); d > time.Minute {
+		t.Errorf("Get expiry in %v; want <= 1m after EXPIRE", d)
+	}
+}
+
+func TestValkey_CheckTTL(t *testing.T) {
+	skipIfNoValkey(t)
+
+	ctx := context.Background()
+	p, err := NewWithConfig[string, int](ctx, fmt.Sprintf("test-checkttl-%d", time.Now().UnixNano()), Config{CheckTTL: true})
+	if err != nil {
+		t.Skip("Valkey not available")
+	}
+	defer func() {
+		if _, err := p.Flush(ctx); err != nil {
+			t.Logf("Flush error: %v", err)
+		}
+		if err := p.Close(); err != nil {
+			t.Logf("Close error: %v", err)
+		}
+	}()
+
+	for k, exp := range map[string]time.Time{"short": time.Now().Add(time.Hour), "forever": {}, "persisted": time.Now().Add(time.Hour)} {
+		if err := p.Set(ctx, k, 1, exp); err != nil {
+			t.Fatalf("Set(%s): %v", k, err)
+		}
+		if _, _, _, err := p.Get(ctx, k); err != nil {
+			t.Fatalf("Get(%s): %v", k, err)
+		}
+	}
+	if n := p.TTLMismatches(); n != 0 {
+		t.Fatalf("TTLMismatches = %d before any drift; want 0", n)
+	}
+
+	// An operator shortens one TTL and removes another from a CLI.
+	if err := p.client.Do(ctx, p.client.B().Expire().Key(p.makeKey("short")).Seconds(60).Build()).Error(); err != nil {
+		t.Fatalf("EXPIRE: %v", err)
+	}
+	if err := p.client.Do(ctx, p.client.B().Persist().Key(p.makeKey("persisted")).Build()).Error(); err != nil {
+		t.Fatalf("PERSIST: %v", err)
+	}
+	for _, k := range []string{"short", "persisted", "forever"} {
+		if _, _, found, err := p.Get(ctx, k); err != nil || !found {
+			t.Fatalf("Get(%s): found=%v err=%v", k, found, err)
+		}
+	}
+	if n := p.TTLMismatches(); n != 2 {
+		t.Errorf("TTLMismatches = %d; want 2", n)
+	}
+
+	// Delete removes the record with its entry.
+	if err := p.Delete(ctx, "forever"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	rec := p.expiryKey(p.makeKey("forever"))
+	if c, err := p.client.Do(ctx, p.client.B().Exists().Key(rec).Build()).AsInt64(); err != nil || c != 0 {
+		t.Errorf("EXISTS %s = %d, %v; want 0 after Delete", rec, c, err)
+	}
+}
+
+func TestValkey_HashTag(t *testing.T) {
+	skipIfNoValkey(t)
+
+	ctx := context.Background()
+	p, err := NewWithConfig[string, int](ctx, "test-hashtag", Config{HashTag: true})
+	if err != nil {
+		t.Skip("Valkey not available")
+	}
+	defer func() {
+		if _, err := p.Flush(ctx); err != nil {
+			t.Logf("Flush error: %v", err)
+		}
+		if err := p.Close(); err != nil {
+			t.Logf("Close error: %v", err)
+		}
+	}()
+
+	if got := p.Location("user:1"); got != "{test-hashtag}:user:1" {
+		t.Errorf("Location() = %q; want %q", got, "{test-hashtag}:user:1")
+	}
+
+	if err := p.Set(ctx, "user:1", 1, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if v, _, found, err := p.Get(ctx, "user:1"); err != nil || !found || v != 1 {
+		t.Errorf("Get = %d, %v, %v; want 1, true, nil", v, found, err)
+	}
+	if n, err := p.Len(ctx); err != nil || n != 1 {
+		t.Errorf("Len() = %d, %v; want 1, nil", n, err)
+	}
+}
+
+func TestValkey_Cleanup_SweepUntimed(t *testing.T) {
+	skipIfNoValkey(t)
+
+	ctx := context.Background()
+	p, err := NewWithConfig[string, int](ctx, "test-sweep", Config{SweepUntimed: true})
+	if err != nil {
+		t.Skip("Valkey not available")
+	}
+	defer func() {
+		if _, err := p.Flush(ctx); err != nil {
+			t.Logf("Flush error: %v", err)
+		}
+		if err := p.Close(); err != nil {
+			t.Logf("Close error: %v", err)
+		}
+	}()
+
+	if err := p.Set(ctx, "untimed", 1, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := p.Set(ctx, "timed", 2, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	// An entry whose TTL was lost, as after a PERSIST, past its recorded expiry.
+	if err := p.Set(ctx, "lost", 3, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	lost := p.makeKey("lost")
+	past := strconv.FormatInt(time.Now().Add(-time.Minute).UnixMilli(), 10)
+	for _, cmd := range []valkey.Completed{
+		p.client.B().Persist().Key(lost).Build(),
+		p.client.B().Set().Key(p.expiryKey(lost)).Value(past).Build(),
+	} {
+		if err := p.client.Do(ctx, cmd).Error(); err != nil {
+			t.Fatalf("losing TTL: %v", err)
+		}
+	}
+	// An untimed entry written without a record, e.g. by an older release.
+	legacy := p.makeKey("legacy")
+	if err := p.client.Do(ctx, p.client.B().Set().Key(legacy).Value("4").Build()).Error(); err != nil {
+		t.Fatalf("Set legacy: %v", err)
+	}
+
+	n, err := p.Cleanup(ctx, 0)
+	if err != nil {
+		t.Fatalf("Cleanup: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Cleanup() = %d; want 1", n)
+	}
+	if _, _, found, _ := p.Get(ctx, "lost"); found { //nolint:errcheck // only checking presence
+		t.Error("entry past its recorded expiry should be swept")
+	}
+	if c, err := p.client.Do(ctx, p.client.B().Exists().Key(p.expiryKey(lost)).Build()).AsInt64(); err != nil || c != 0 {
+		t.Errorf("expiry record after sweep: exists=%d, %v; want 0", c, err)
+	}
+	for _, k := range []string{"timed", "untimed"} {
+		if _, _, found, _ := p.Get(ctx, k); !found { //nolint:errcheck // only checking presence
+			t.Errorf("%s should survive the sweep", k)
+		}
+	}
+	if c, err := p.client.Do(ctx, p.client.B().Exists().Key(legacy).Build()).AsInt64(); err != nil || c != 1 {
+		t.Errorf("unrecorded entry: exists=%d, %v; want 1", c, err)
+	}
+	if err := p.client.Do(ctx, p.client.B().Del().Key(legacy).Build()).Error(); err != nil {
+		t.Logf("Del legacy error: %v", err)
+	}
+}
+
+func TestValkey_AcquireLease(t *testing.T) {
+	skipIfNoValkey(t)
+
+	ctx := context.Background()
+	cacheID := fmt.Sprintf("test-lease-%d", time.Now().UnixNano())
+	p, err := New[string, int](ctx, cacheID, "localhost:6379")
+	if err != nil {
+		t.Skip("Valkey not available")
+	}
+	defer func() {
+		if err := p.client.Do(ctx, p.client.B().Del().Key(p.leaseKey("cleanup")).Build()).Error(); err != nil {
+			t.Logf("Del lease error: %v", err)
+		}
+		if err := p.Close(); err != nil {
+			t.Logf("Close error: %v", err)
+		}
+	}()
+
+	if ok, err := p.AcquireLease(ctx, "cleanup", "a", 200*time.Millisecond); err != nil || !ok {
+		t.Fatalf("AcquireLease(a) = %v, %v; want true", ok, err)
+	}
+	if ok, err := p.AcquireLease(ctx, "cleanup", "b", 200*time.Millisecond); err != nil || ok {
+		t.Fatalf("AcquireLease(b) = %v, %v; want false while a holds it", ok, err)
+	}
+	if ok, err := p.AcquireLease(ctx, "cleanup", "a", 200*time.Millisecond); err != nil || !ok {
+		t.Fatalf("AcquireLease(a) renew = %v, %v; want true", ok, err)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	if ok, err := p.AcquireLease(ctx, "cleanup", "b", time.Second); err != nil || !ok {
+		t.Errorf("AcquireLease(b) after expiry = %v, %v; want true", ok, err)
+	}
+}
+
+func TestValkeyPersist_PurgeVersions(t *testing.T) {
+	skipIfNoValkey(t)
+
+	ctx := context.Background()
+	addr := os.Getenv("VALKEY_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+
+	p, err := New[string, int](ctx, "test-cache-versions", addr)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer func() {
+		if err := p.Close(); err != nil {
+			t.Logf("Close error: %v", err)
+		}
+	}()
+	if _, err := p.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	if err := p.Set(ctx, "k", 1, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := p.SetVersion("v2"); err != nil {
+		t.Fatalf("SetVersion: %v", err)
+	}
+	if _, _, found, _ := p.Get(ctx, "k"); found { //nolint:errcheck // found is enough
+		t.Error("Get(k) found an entry from another version")
+	}
+	if err := p.Set(ctx, "k", 2, time.Time{}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	if n, err := p.PurgeVersions(ctx); err != nil || n != 1 {
+		t.Errorf("PurgeVersions = %d, %v; want 1, nil", n, err)
+	}
+	if v, _, found, err := p.Get(ctx, "k"); err != nil || !found || v != 2 {
+		t.Errorf("Get(k) = %d, %v, %v; want 2, true, nil", v, found, err)
+	}
+	if err := p.Delete(ctx, "k"); err != nil {
+		t.Errorf("Delete: %v", err)
+	}
+}
+
+func TestValkey_Owners(t *testing.T) {
+	skipIfNoValkey(t)
+
+	ctx := context.Background()
+	p, err := New[string, int](ctx, "test-owners", "localhost:6379")
+	if err != nil {
+		t.Skip("Valkey not available")
+	}
+	defer func() {
+		if _, err := p.Flush(ctx); err != nil {
+			t.Logf("Flush error: %v", err)
+		}
+		if err := p.Close(); err != nil {
+			t.Logf("Close error: %v", err)
+		}
+	}()
+
+	for k, owner := range map[string]string{"a1": "team-a", "a2": "team-a", "b1": "team-b"} {
+		if err := p.SetOwned(ctx, k, 1, time.Now().Add(time.Hour), owner); err != nil {
+			t.Fatalf("SetOwned(%s): %v", k, err)
+		}
+	}
+	if err := p.Delete(ctx, "a2"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	got := slices.Sorted(p.OwnerKeys(ctx, "team-a"))
+	if !slices.Equal(got, []string{"a1"}) {
+		t.Errorf("OwnerKeys(team-a) = %v; want [a1]", got)
+	}
+
+	// Retagging moves the key to its new owner.
+	if err := p.SetOwned(ctx, "a1", 2, time.Time{}, "team-b"); err != nil {
+		t.Fatalf("SetOwned: %v", err)
+	}
+	if got := slices.Sorted(p.OwnerKeys(ctx, "team-b")); !slices.Equal(got, []string{"a1", "b1"}) {
+		t.Errorf("OwnerKeys(team-b) = %v; want [a1 b1]", got)
+	}
+}
+
+func TestValkey_Batch(t *testing.T) {
+	skipIfNoValkey(t)
+
+	ctx := context.Background()
+	p, err := New[string, int](ctx, "test-batch", "localhost:6379")
+	if err != nil {
+		t.Skip("Valkey not available")
+	}
+	defer func() {
+		if _, err := p.Flush(ctx); err != nil {
+			t.Logf("Flush error: %v", err)
+		}
+		if err := p.Close(); err != nil {
+			t.Logf("Close error: %v", err)
+		}
+	}()
+
+	past := time.Now().Add(-time.Second)
+	if err := p.SetBatch(ctx, []string{"a", "b", "gone"}, []int{1, 2, 3}, []time.Time{{}, time.Now().Add(time.Hour), past}); err != nil {
+		t.Fatalf("SetBatch: %v", err)
+	}
+	for k, want := range map[string]bool{"a": true, "b": true, "gone": false} {
+		if _, _, found, err := p.Get(ctx, k); err != nil || found != want {
+			t.Errorf("Get(%s) found = %v, %v; want %v", k, found, err, want)
+		}
+	}
+	if err := p.SetOwned(ctx, "c", 3, time.Time{}, "team"); err != nil {
+		t.Fatalf("SetOwned: %v", err)
+	}
+	if err := p.DeleteBatch(ctx, []string{"a", "c", "missing"}); err != nil {
+		t.Fatalf("DeleteBatch: %v", err)
+	}
+	if n, err := p.Len(ctx); err != nil || n != 1 {
+		t.Errorf("Len() = %d, %v; want 1 (b), nil", n, err)
+	}
+	if got := slices.Collect(p.OwnerKeys(ctx, "team")); len(got) != 0 {
+		t.Errorf("OwnerKeys(team) = %v; want none after DeleteBatch", got)
+	}
+}
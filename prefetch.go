@@ -0,0 +1,96 @@
+package fido
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// KV is a key-value pair.
+type KV[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// Prefetcher supplies entries to load into a new cache's memory, such as the
+// results of a database query or another instance's export. See Prefetch.
+type Prefetcher[K comparable, V any] interface {
+	// NextBatch returns the next entries and whether more follow. The cache
+	// stops calling it once more is false or ctx is done; a Prefetcher that
+	// fails should log the error and return false.
+	NextBatch(ctx context.Context) (batch []KV[K, V], more bool)
+}
+
+// prefetch drains a Prefetcher into memory in the background.
+type prefetch struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startPrefetching starts the Prefetch option's prefetch, if any, after warm,
+// if not nil, returning nil when there is neither. A prefetcher whose types
+// don't match the cache's is ignored.
+func startPrefetching[K comparable, V any](cfg *config, warm func(context.Context), insert func(K, V)) *prefetch {
+	src, ok := cfg.prefetch.(Prefetcher[K, V])
+	if !ok && warm == nil {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &prefetch{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(p.done)
+		if warm != nil {
+			warm(ctx)
+		}
+		if ok {
+			drainPrefetcher(ctx, src, cfg.prefetchRate, insert)
+		}
+	}()
+	return p
+}
+
+// drainPrefetcher calls insert for each entry src yields, averaging at most
+// perSecond entries a second, or as fast as src allows when perSecond is 0.
+func drainPrefetcher[K comparable, V any](ctx context.Context, src Prefetcher[K, V], perSecond int, insert func(K, V)) {
+	start := time.Now()
+	n := 0
+	for {
+		batch, more := src.NextBatch(ctx)
+		for _, kv := range batch {
+			insert(kv.Key, kv.Value)
+		}
+		n += len(batch)
+		if !more || ctx.Err() != nil {
+			break
+		}
+		if perSecond > 0 && !sleepUntil(ctx, start.Add(time.Duration(n)*time.Second/time.Duration(perSecond))) {
+			break
+		}
+	}
+	slog.Debug("prefetch finished", "entries", n, "duration", time.Since(start), "canceled", ctx.Err() != nil)
+}
+
+// sleepUntil waits until t, reporting false if ctx ended first.
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	d := time.Until(t)
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// stop cancels a prefetch still in progress and waits for it to return.
+func (p *prefetch) stop() {
+	if p == nil {
+		return
+	}
+	p.cancel()
+	<-p.done
+}
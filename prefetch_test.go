@@ -0,0 +1,137 @@
+package fido
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// batchSource is a Prefetcher returning its batches in order, after gate
+// (if set) is closed. calls receives ctx.Err() at each call.
+type batchSource struct {
+	batches [][]KV[string, int]
+	gate    chan struct{}
+	calls   chan error
+}
+
+func (s *batchSource) NextBatch(ctx context.Context) ([]KV[string, int], bool) {
+	if s.gate != nil {
+		select {
+		case <-s.gate:
+		case <-ctx.Done():
+		}
+	}
+	if s.calls != nil {
+		s.calls <- ctx.Err()
+	}
+	if len(s.batches) == 0 || ctx.Err() != nil {
+		return nil, false
+	}
+	b := s.batches[0]
+	s.batches = s.batches[1:]
+	return b, len(s.batches) > 0
+}
+
+// stringSource is an empty Prefetcher of string values.
+type stringSource struct{}
+
+func (stringSource) NextBatch(context.Context) ([]KV[string, string], bool) { return nil, false }
+
+func kvs(keys ...string) []KV[string, int] {
+	out := make([]KV[string, int], len(keys))
+	for i, k := range keys {
+		out[i] = KV[string, int]{Key: k, Value: i + 1}
+	}
+	return out
+}
+
+// waitLen waits for n entries in memory, failing after a second.
+func waitLen(t *testing.T, n int, length func() int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for length() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Len = %d after 1s; want %d", length(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCache_Prefetch(t *testing.T) {
+	src := &batchSource{batches: [][]KV[string, int]{kvs("a", "b"), kvs("c")}}
+	cache := New[string, int](Prefetch[string, int](src, 0))
+	waitLen(t, 3, cache.Len)
+	if v, ok := cache.Get("c"); !ok || v != 1 {
+		t.Errorf("Get(c) = %d, %v; want 1, true", v, ok)
+	}
+	if cfg := cache.Config(); !cfg.Prefetch || cfg.PrefetchRate != 0 {
+		t.Errorf("Config Prefetch, PrefetchRate = %v, %d; want true, 0", cfg.Prefetch, cfg.PrefetchRate)
+	}
+
+	// New ignores a prefetcher of other types.
+	if New[string, int](Prefetch[string, string](stringSource{}, 0)).Config().Prefetch {
+		t.Error("mismatched Prefetch reported in Config")
+	}
+}
+
+func TestTieredCache_Prefetch(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	src := &batchSource{batches: [][]KV[string, int]{kvs("a", "b")}, gate: make(chan struct{})}
+	cache, err := NewTiered[string, int](store, Prefetch[string, int](src, 0))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // test cleanup
+
+	// A value set before the prefetch arrives is newer and kept.
+	if err := cache.Set(ctx, "a", 100); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	close(src.gate)
+	waitLen(t, 2, cache.Len)
+	if v, _, _ := cache.Get(ctx, "a"); v != 100 { //nolint:errcheck // mock store
+		t.Errorf("Get(a) = %d; want 100, not the prefetched value", v)
+	}
+	if v, _, _ := cache.Get(ctx, "b"); v != 2 { //nolint:errcheck // mock store
+		t.Errorf("Get(b) = %d; want 2", v)
+	}
+	// Prefetched entries stay in memory.
+	if _, _, found, _ := store.Get(ctx, "b"); found { //nolint:errcheck // mock store
+		t.Error("prefetched entry was persisted")
+	}
+}
+
+func TestTieredCache_PrefetchRate(t *testing.T) {
+	src := &batchSource{batches: [][]KV[string, int]{kvs("a", "b"), kvs("c", "d"), kvs("e")}}
+	start := time.Now()
+	cache, err := NewTiered[string, int](newMockStore[string, int](), Prefetch[string, int](src, 20))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer cache.Close() //nolint:errcheck // test cleanup
+	waitLen(t, 5, cache.Len)
+	// 4 entries at 20/s must be spread over 200ms before the last batch.
+	if d := time.Since(start); d < 200*time.Millisecond {
+		t.Errorf("prefetch took %v; want at least 200ms at 20 entries/s", d)
+	}
+}
+
+func TestTieredCache_PrefetchClose(t *testing.T) {
+	src := &batchSource{gate: make(chan struct{}), calls: make(chan error, 1)}
+	cache, err := NewTiered[string, int](newMockStore[string, int](), Prefetch[string, int](src, 0))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	if err := cache.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	select {
+	case err := <-src.calls:
+		if err == nil {
+			t.Error("NextBatch context was not canceled by Close")
+		}
+	default:
+		t.Error("Close returned before NextBatch did")
+	}
+}
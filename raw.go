@@ -0,0 +1,150 @@
+package fido
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// RawCache is a TieredCache of encoded values. Views of different value types
+// share its memory budget and persistence store, so a service caching many
+// types needs one S3-FIFO instance rather than one per type.
+type RawCache = TieredCache[string, []byte]
+
+// NewRaw creates a RawCache backed by store. Use NewView to read and write typed values.
+// LargeObjects and MaxValueBytes measure encoded sizes.
+func NewRaw(store Store[string, []byte], opts ...Option) (*RawCache, error) {
+	return NewTiered(store, opts...)
+}
+
+// View is a typed window onto a RawCache. Keys are namespaced by the view's
+// name, so views sharing a RawCache never see each other's entries.
+// Values are encoded on every write and decoded on every read.
+type View[V any] struct {
+	raw    *RawCache
+	codec  Codec[V]
+	prefix string
+}
+
+// NewView returns a view of raw storing V values under name.
+// A nil codec uses JSON. Names must be unique within raw.
+func NewView[V any](raw *RawCache, name string, codec Codec[V]) *View[V] {
+	if codec == nil {
+		codec = JSON[V]()
+	}
+	return &View[V]{raw: raw, codec: codec, prefix: name + ":"}
+}
+
+func (v *View[V]) key(key string) string {
+	return v.prefix + key
+}
+
+func (v *View[V]) decode(key string, data []byte) (V, error) {
+	val, err := v.codec.Decode(data)
+	if err != nil {
+		return val, newCodecError("decode", v.key(key), err)
+	}
+	return val, nil
+}
+
+func (v *View[V]) encode(key string, value V) ([]byte, error) {
+	data, err := v.codec.Encode(value)
+	if err != nil {
+		return nil, newCodecError("encode", v.key(key), err)
+	}
+	return data, nil
+}
+
+// Get returns the value for key, checking memory, then persistence.
+//
+//nolint:gocritic // unnamedResult: mirrors TieredCache.Get
+func (v *View[V]) Get(ctx context.Context, key string) (V, bool, error) {
+	var zero V
+	data, found, err := v.raw.Get(ctx, v.key(key))
+	if err != nil || !found {
+		return zero, found, err
+	}
+	val, err := v.decode(key, data)
+	if err != nil {
+		return zero, false, err
+	}
+	return val, true, nil
+}
+
+// Set stores value with the default TTL.
+func (v *View[V]) Set(ctx context.Context, key string, value V) error {
+	return v.SetTTL(ctx, key, value, 0)
+}
+
+// SetTTL stores value with an explicit TTL, as TieredCache.SetTTL.
+func (v *View[V]) SetTTL(ctx context.Context, key string, value V, ttl time.Duration) error {
+	data, err := v.encode(key, value)
+	if err != nil {
+		return err
+	}
+	return v.raw.SetTTL(ctx, v.key(key), data, ttl)
+}
+
+// SetCAS stores value under a key derived from a SHA-256 hash of its encoding
+// and returns that key, for content-addressed entries such as rendered templates
+// or compiled artifacts. Identical values map to the same key, so a value that
+// is already cached is not written again. Uses the default TTL.
+func (v *View[V]) SetCAS(ctx context.Context, value V) (string, error) {
+	return v.SetCASTTL(ctx, value, 0)
+}
+
+// SetCASTTL is like SetCAS but stores value with an explicit TTL. A value that
+// is already cached keeps its original expiry.
+func (v *View[V]) SetCASTTL(ctx context.Context, value V, ttl time.Duration) (string, error) {
+	data, err := v.codec.Encode(value)
+	if err != nil {
+		return "", newCodecError("encode", v.prefix+"<cas>", err)
+	}
+	sum := sha256.Sum256(data)
+	key := hex.EncodeToString(sum[:])
+	if _, _, err := v.raw.GetOrSetTTL(ctx, v.key(key), data, ttl); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// SetAsync stores value in memory and persists it in the background, as TieredCache.SetAsync.
+func (v *View[V]) SetAsync(ctx context.Context, key string, value V) error {
+	data, err := v.encode(key, value)
+	if err != nil {
+		return err
+	}
+	return v.raw.SetAsync(ctx, v.key(key), data)
+}
+
+// Fetch returns the cached value or calls loader, as TieredCache.Fetch.
+func (v *View[V]) Fetch(ctx context.Context, key string, loader func(context.Context) (V, error)) (V, error) {
+	return v.FetchTTL(ctx, key, 0, loader)
+}
+
+// FetchTTL is like Fetch but stores computed values with an explicit TTL.
+func (v *View[V]) FetchTTL(ctx context.Context, key string, ttl time.Duration, loader func(context.Context) (V, error)) (V, error) {
+	var zero, loadedVal V
+	var loaded bool
+	data, err := v.raw.FetchTTL(ctx, v.key(key), ttl, func(ctx context.Context) ([]byte, error) {
+		val, err := loader(ctx)
+		if err != nil {
+			return nil, err
+		}
+		loadedVal, loaded = val, true
+		return v.encode(key, val)
+	})
+	if err != nil {
+		return zero, err
+	}
+	if loaded {
+		return loadedVal, nil
+	}
+	return v.decode(key, data)
+}
+
+// Delete removes key from memory and persistence.
+func (v *View[V]) Delete(ctx context.Context, key string) error {
+	return v.raw.Delete(ctx, v.key(key))
+}
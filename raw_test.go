@@ -0,0 +1,162 @@
+package fido
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type rawUser struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func TestView_SharedRawCache(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, []byte]()
+	raw, err := NewRaw(store, Size(100))
+	if err != nil {
+		t.Fatalf("NewRaw: %v", err)
+	}
+	defer raw.Close() //nolint:errcheck // test cleanup
+
+	users := NewView[rawUser](raw, "user", nil)
+	counts := NewView[int](raw, "count", nil)
+
+	if err := users.Set(ctx, "1", rawUser{Name: "ann", Age: 30}); err != nil {
+		t.Fatalf("users.Set: %v", err)
+	}
+	if err := counts.Set(ctx, "1", 7); err != nil {
+		t.Fatalf("counts.Set: %v", err)
+	}
+	if raw.Len() != 2 {
+		t.Errorf("raw Len = %d; want 2 entries in the shared memory tier", raw.Len())
+	}
+
+	if u, found, err := users.Get(ctx, "1"); err != nil || !found || u.Name != "ann" || u.Age != 30 {
+		t.Errorf("users.Get = %+v, %v, %v", u, found, err)
+	}
+	if n, found, err := counts.Get(ctx, "1"); err != nil || !found || n != 7 {
+		t.Errorf("counts.Get = %d, %v, %v; want 7", n, found, err)
+	}
+
+	// Served from persistence after memory is cleared.
+	raw.FlushMemory(ctx)
+	if u, found, err := users.Get(ctx, "1"); err != nil || !found || u.Name != "ann" {
+		t.Errorf("users.Get from store = %+v, %v, %v", u, found, err)
+	}
+
+	if err := counts.Delete(ctx, "1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, found, _ := counts.Get(ctx, "1"); found {
+		t.Error("counts.Get after Delete found value")
+	}
+	if _, found, _ := users.Get(ctx, "1"); !found {
+		t.Error("Delete in one view removed another view's key")
+	}
+}
+
+func TestView_Fetch(t *testing.T) {
+	ctx := context.Background()
+	raw, err := NewRaw(newMockStore[string, []byte]())
+	if err != nil {
+		t.Fatalf("NewRaw: %v", err)
+	}
+	defer raw.Close() //nolint:errcheck // test cleanup
+	users := NewView[rawUser](raw, "user", nil)
+
+	calls := 0
+	load := func(context.Context) (rawUser, error) {
+		calls++
+		return rawUser{Name: "bob"}, nil
+	}
+	for range 2 {
+		u, err := users.Fetch(ctx, "2", load)
+		if err != nil || u.Name != "bob" {
+			t.Errorf("Fetch = %+v, %v; want bob", u, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("loader calls = %d; want 1", calls)
+	}
+
+	wantErr := errors.New("boom")
+	if _, err := users.Fetch(ctx, "3", func(context.Context) (rawUser, error) { return rawUser{}, wantErr }); !errors.Is(err, wantErr) {
+		t.Errorf("Fetch error = %v; want %v", err, wantErr)
+	}
+}
+
+type failingCodec struct{}
+
+func (failingCodec) Encode(int) ([]byte, error) { return []byte("x"), nil }
+func (failingCodec) Decode([]byte) (int, error) { return 0, errors.New("bad data") }
+
+func TestView_CodecErrors(t *testing.T) {
+	ctx := context.Background()
+	raw, err := NewRaw(newMockStore[string, []byte]())
+	if err != nil {
+		t.Fatalf("NewRaw: %v", err)
+	}
+	defer raw.Close() //nolint:errcheck // test cleanup
+
+	v := NewView[int](raw, "n", failingCodec{})
+	if err := v.Set(ctx, "k", 1); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, found, err := v.Get(ctx, "k"); err == nil || found {
+		t.Errorf("Get = %v, %v; want decode error", found, err)
+	}
+
+	ch := NewView[chan int](raw, "ch", nil)
+	if err := ch.Set(ctx, "k", make(chan int)); err == nil {
+		t.Error("Set of unencodable value should fail")
+	}
+}
+
+func TestView_SetCAS(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, []byte]()
+	raw, err := NewRaw(store)
+	if err != nil {
+		t.Fatalf("NewRaw: %v", err)
+	}
+	defer raw.Close() //nolint:errcheck // test cleanup
+
+	pages := NewView[string](raw, "page", nil)
+	k1, err := pages.SetCAS(ctx, "<h1>hi</h1>")
+	if err != nil {
+		t.Fatalf("SetCAS: %v", err)
+	}
+	if len(k1) != 64 {
+		t.Errorf("key %q; want 64 hex characters", k1)
+	}
+	if v, found, err := pages.Get(ctx, k1); err != nil || !found || v != "<h1>hi</h1>" {
+		t.Errorf("Get(%s) = %q, %v, %v", k1, v, found, err)
+	}
+
+	// Identical values dedupe to one entry; different ones don't.
+	k2, err := pages.SetCASTTL(ctx, "<h1>hi</h1>", time.Hour)
+	if err != nil || k2 != k1 {
+		t.Errorf("SetCASTTL(same) = %q, %v; want %q", k2, err, k1)
+	}
+	k3, _ := pages.SetCAS(ctx, "<h1>bye</h1>") //nolint:errcheck // checked via key
+	if k3 == k1 {
+		t.Error("different values should get different keys")
+	}
+	if raw.Len() != 2 {
+		t.Errorf("raw Len = %d; want 2", raw.Len())
+	}
+
+	// A value already persisted by another process is not rewritten.
+	raw.FlushMemory(ctx)
+	store.failSet = true
+	if k, err := pages.SetCAS(ctx, "<h1>hi</h1>"); err != nil || k != k1 {
+		t.Errorf("SetCAS of persisted value = %q, %v; want %q, nil", k, err, k1)
+	}
+
+	if _, err := NewView[chan int](raw, "ch", nil).SetCAS(ctx, make(chan int)); err == nil {
+		t.Error("SetCAS of unencodable value should fail")
+	}
+}
@@ -0,0 +1,89 @@
+package fido
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Registered is a cache that can be added to the process-wide registry:
+// a *Cache or *TieredCache of any key and value types.
+type Registered interface {
+	Len() int
+	Stats() Stats
+	Config() Config
+	hitStats() *hitStats
+}
+
+func (c *Cache[K, V]) hitStats() *hitStats       { return c.stats }
+func (c *TieredCache[K, V]) hitStats() *hitStats { return c.stats }
+
+// registry holds the caches added by Register.
+var registry struct {
+	mu     sync.RWMutex
+	caches map[string]Registered
+}
+
+// Register adds c to the process-wide registry under name, replacing any
+// cache registered under it, so a process with many caches can report them
+// all from one place with Lookup, Summaries, and TotalStats. Caches are never
+// removed implicitly: call Unregister when discarding one, such as after
+// TieredCache.Close.
+func Register(name string, c Registered) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if registry.caches == nil {
+		registry.caches = make(map[string]Registered)
+	}
+	registry.caches[name] = c
+}
+
+// Unregister removes the cache registered under name, if any.
+func Unregister(name string) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	delete(registry.caches, name)
+}
+
+// Lookup returns the cache registered under name. Callers that know its
+// types can assert it back, e.g. to *fido.Cache[string, User].
+func Lookup(name string) (Registered, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	c, ok := registry.caches[name]
+	return c, ok
+}
+
+// Summary describes a registered cache, for debug endpoints. It encodes
+// to JSON as is.
+type Summary struct {
+	Name   string
+	Len    int // entries in memory
+	Stats  Stats
+	Config Config
+}
+
+// Summaries describes every registered cache, sorted by name.
+func Summaries() []Summary {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	out := make([]Summary, 0, len(registry.caches))
+	for name, c := range registry.caches {
+		out = append(out, Summary{Name: name, Len: c.Len(), Stats: c.Stats(), Config: c.Config()})
+	}
+	slices.SortFunc(out, func(a, b Summary) int { return strings.Compare(a.Name, b.Name) })
+	return out
+}
+
+// TotalStats returns hit and miss counts summed over every registered cache,
+// with rates over all of their lookups. Caches without TrackStats contribute
+// nothing.
+func TotalStats() Stats {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	ss := make([]*hitStats, 0, len(registry.caches))
+	for _, c := range registry.caches {
+		ss = append(ss, c.hitStats())
+	}
+	return sumStats(ss...)
+}
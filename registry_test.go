@@ -0,0 +1,73 @@
+package fido
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	users := New[string, int](TrackStats(), Size(100))
+	sessions, err := NewTiered[string, int](newMockStore[string, int](), TrackStats())
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = sessions.Close() }() //nolint:errcheck // Test cleanup
+	untracked := New[int, string]()
+
+	Register("users", users)
+	Register("sessions", sessions)
+	Register("untracked", untracked)
+	t.Cleanup(func() {
+		for _, name := range []string{"users", "sessions", "untracked"} {
+			Unregister(name)
+		}
+	})
+
+	ctx := context.Background()
+	users.Set("a", 1)
+	users.Get("a")
+	users.Get("b")
+	if err := sessions.Set(ctx, "s", 1); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	for range 2 {
+		if _, _, err := sessions.Get(ctx, "s"); err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+	}
+	untracked.Get(1)
+
+	c, ok := Lookup("users")
+	if !ok || c.(*Cache[string, int]) != users {
+		t.Errorf("Lookup(users) = %v, %v; want the registered cache", c, ok)
+	}
+	if _, ok := Lookup("missing"); ok {
+		t.Error("Lookup(missing) should fail")
+	}
+
+	total := TotalStats()
+	if total.Hits != 3 || total.Misses != 1 || total.HitRate != 0.75 || total.HitRate1m != 0.75 {
+		t.Errorf("TotalStats() = %+v; want 3 hits and 1 miss over both tracked caches", total)
+	}
+
+	sums := Summaries()
+	if len(sums) != 3 || sums[0].Name != "sessions" || sums[1].Name != "untracked" || sums[2].Name != "users" {
+		t.Fatalf("Summaries() = %+v; want 3 caches sorted by name", sums)
+	}
+	if u := sums[2]; u.Len != 1 || u.Stats.Hits != 1 || u.Config.Size != 100 {
+		t.Errorf("Summaries()[users] = %+v; want its length, stats, and config", u)
+	}
+	if _, err := json.Marshal(sums); err != nil {
+		t.Errorf("json.Marshal(Summaries()): %v", err)
+	}
+
+	Register("users", untracked)
+	Unregister("sessions")
+	if c, _ := Lookup("users"); c != Registered(untracked) {
+		t.Error("Register should replace a cache under the same name")
+	}
+	if total := TotalStats(); total.Hits != 0 {
+		t.Errorf("TotalStats() after unregistering = %+v; want none", total)
+	}
+}
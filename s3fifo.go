@@ -1,8 +1,11 @@
 package fido
 
 import (
+	"cmp"
 	"fmt"
-	"math/bits"
+	"iter"
+	"slices"
+	"sync"
 	"sync/atomic"
 	"time"
 	"unsafe"
@@ -10,44 +13,6 @@ import (
 	"github.com/puzpuzpuz/xsync/v4"
 )
 
-// wyhash constants for fast string hashing.
-// Using wyhash instead of maphash: benchmarked +12% string-get, +16% getOrSet throughput.
-// maphash.String with fixed seed was tested and showed -12.1% string-get, -16.3% getOrSet.
-const (
-	wyp0 = 0xa0761d6478bd642f
-	wyp1 = 0xe7037ed1a0b428db
-)
-
-// hashString hashes a string using wyhash.
-// Uses unsafe.Pointer for direct memory access - benchmarked 2.6x faster than maphash.String.
-// Replacing with maphash causes -12% string-get throughput, -16% getOrSet throughput.
-func hashString(s string) uint64 {
-	n := len(s)
-	if n == 0 {
-		return 0
-	}
-
-	p := unsafe.Pointer(unsafe.StringData(s))
-	var a, b uint64
-
-	if n <= 8 {
-		if n >= 4 {
-			a = uint64(*(*uint32)(p))
-			b = uint64(*(*uint32)(unsafe.Add(p, n-4)))
-		} else {
-			a = uint64(*(*byte)(p))<<16 | uint64(*(*byte)(unsafe.Add(p, n>>1)))<<8 | uint64(*(*byte)(unsafe.Add(p, n-1)))
-			b = 0
-		}
-	} else {
-		a = *(*uint64)(p)
-		b = *(*uint64)(unsafe.Add(p, n-8))
-	}
-
-	// wymix
-	hi, lo := bits.Mul64(a^wyp0, b^uint64(n)^wyp1)
-	return hi ^ lo
-}
-
 const (
 	// maxFreq caps the frequency counter for eviction. Paper uses 3; 5 tuned via binary search.
 	// WARNING: Must be >= 2. Setting to 1 creates infinite loop in eviction (items with
@@ -57,9 +22,17 @@ const (
 	// maxPeakFreq caps peakFreq for death row admission decisions.
 	maxPeakFreq = 21
 
-	// ghostFPRate is the bloom filter false positive rate for ghost tracking.
+	// ghostFPRate is the default bloom filter false positive rate for ghost tracking.
 	ghostFPRate = 0.00001
 
+	// defaultGhostFreqs is the minimum ghost frequency ring size. Caches whose ghost
+	// capacity exceeds ghostFreqScale times this scale the ring up with it.
+	defaultGhostFreqs = 256
+	ghostFreqScale    = 64
+	// maxScanGhostFreqs is the largest ring or exact ghost queue searched by
+	// linear scan. Larger ones keep a hash index so lookups stay O(1).
+	maxScanGhostFreqs = 256
+
 	// deathRowThresholdPerMille scales the death row admission threshold.
 	// 1000 = average peakFreq. Wide plateau from 10-1500 (all ~61.62%).
 	deathRowThresholdPerMille = 1000
@@ -156,6 +129,12 @@ func ghostRatio(capacity int) int {
 	return points[len(points)-1].ratio
 }
 
+// perMille returns ratio per-mille of n. It multiplies in int64 so capacities
+// in the millions don't overflow int on 32-bit platforms.
+func perMille(n, ratio int) int {
+	return int(int64(n) * int64(ratio) / 1000)
+}
+
 // s3fifo implements the S3-FIFO cache eviction algorithm.
 // See "FIFO queues are all you need for cache eviction" (SOSP'23).
 //
@@ -170,62 +149,118 @@ func ghostRatio(capacity int) int {
 //
 //nolint:govet // fieldalignment: padding prevents false sharing
 type s3fifo[K comparable, V any] struct {
-	mu      *xsync.RBMutex              // reader-biased mutex for write operations
-	_       [32]byte                    // pad to cache line
-	entries *xsync.Map[K, *entry[K, V]] // lock-free concurrent map
+	mu      *xsync.RBMutex                // reader-biased mutex for write operations
+	_       [32]byte                      // pad to cache line
+	entries *xsync.Map[K, entryRef[K, V]] // lock-free concurrent map
 	small   entryList[K, V]
 	main    entryList[K, V]
+	pinned  entryList[K, V] // pinned entries out of small, kept off main so eviction never walks them
 
-	// Ghost uses two rotating bloom filters for approximate FIFO eviction tracking.
+	// Ghost uses two rotating bloom filters for approximate FIFO eviction tracking,
+	// or an exact queue when ghostExact is set.
 	ghostActive  *bloomFilter
 	ghostAging   *bloomFilter
+	ghostExact   *ghostQueue
 	ghostFreqRng ghostFreqRing // ring buffer for ghost frequencies (replaces maps)
 	ghostCap     int
+	ghostFPRate  float64
 	hasher       func(K) uint64
 
 	// Death row: buffer of recently evicted items for instant resurrection.
 	// Items on death row remain in memory, so larger death row effectively
 	// increases cache size. Increase sparingly.
-	deathRow    []*entry[K, V] // ring buffer of pending evictions
-	deathRowPos int            // next slot to use
+	deathRow    []deathRowSlot[K, V] // ring buffer of pending evictions
+	deathRowPos int                  // next slot to use
 
-	// Entry recycling to reduce allocations during eviction.
+	// Entry recycling to reduce allocations. freeEntry is reused first since
+	// eviction usually frees one entry per insert; pool absorbs deletes.
+	// Lock-free readers detect reuse through entryRef generations.
 	freeEntry *entry[K, V]
+	pool      sync.Pool
+
+	// Oversized values bypass the queues entirely. Nil unless LargeObjects is set.
+	large *largeRegion[K, V]
+
+	// onEvict is called under lock when a death row entry is finally evicted,
+	// except for values written by setLocal. Must not block. Nil unless a
+	// TieredCache configures a victim store.
+	onEvict func(key K, value V, expirySec uint32)
+
+	// onDrop is called under lock when any entry finally leaves memory through
+	// eviction. Must not block. Nil unless AccessLog or Cache events are set.
+	onDrop func(key K, hash uint64, value V, expirySec uint32)
+
+	// onStep is called under lock with each eviction decision, before the
+	// entry is changed. Must not block. Nil unless Deterministic has a trace
+	// or EvictionTrace is set.
+	onStep    func(evictionStep[K])
+	evictions *evictionRing // nil unless EvictionTrace is set
 
 	capacity       int
 	smallThresh    int // adaptive small queue threshold
 	warmupComplete bool
-	totalEntries   atomic.Int64
+	totalEntries   *xsync.Counter // striped per P; len() sums the stripes
+	epoch          atomic.Uint32  // bumped to invalidate all entries in O(1)
+	clock          *coarseClock   // expiry checks read this; nil means exact time
+	hot            *hotTTL        // nil unless HotTTL
 
 	// Type flags cache key type detection done once at construction.
 	// Enables fast paths that avoid interface{} boxing on every get/set.
 	// Removing these and using runtime type switches causes -6.4% throughput.
 	keyIsInt    bool
 	keyIsInt64  bool
+	keyIsUint64 bool
 	keyIsString bool
 }
 
 // ghostFreqRing is a fixed-size ring buffer for ghost frequency tracking.
 // Replaces map[uint64]uint32 to eliminate allocation during ghost rotation.
-// 256 entries with uint8 wrapping = zero-cost modulo.
 // Uses uint32 hashes (sufficient for ghost queue collision avoidance).
+// The zero value holds defaultGhostFreqs entries.
 type ghostFreqRing struct {
-	hashes [256]uint32
-	freqs  [256]uint32
-	pos    uint8
+	hashes []uint32
+	freqs  []uint32
+	index  map[uint32]uint32 // hash -> position; nil for rings small enough to scan
+	pos    int
+}
+
+func newGhostFreqRing(n int) ghostFreqRing {
+	r := ghostFreqRing{hashes: make([]uint32, n), freqs: make([]uint32, n)}
+	if n > maxScanGhostFreqs {
+		// Sized once and never rebuilt, so it doesn't churn the GC.
+		r.index = make(map[uint32]uint32, n)
+	}
+	return r
 }
 
 func (r *ghostFreqRing) add(h uint32, freq uint32) {
+	if r.hashes == nil {
+		*r = newGhostFreqRing(defaultGhostFreqs)
+	}
+	if r.index != nil {
+		if old := r.hashes[r.pos]; r.index[old] == uint32(r.pos) { //nolint:gosec // G115: ring size fits in uint32
+			delete(r.index, old)
+		}
+		r.index[h] = uint32(r.pos) //nolint:gosec // G115: ring size fits in uint32
+	}
 	r.hashes[r.pos] = h
 	r.freqs[r.pos] = freq
-	r.pos++ // uint8 wraps at 256
+	if r.pos++; r.pos == len(r.hashes) {
+		r.pos = 0
+	}
 }
 
-// lookup performs O(256) linear scan to find frequency for hash.
+// lookup finds the frequency for hash. Small rings use a linear scan.
 // This is acceptable because: (1) 256 iterations is constant-time,
 // (2) only called during eviction (not on every get), (3) cache-friendly
 // sequential access, (4) replaces map that caused GC pressure.
 func (r *ghostFreqRing) lookup(h uint32) (uint32, bool) {
+	if r.index != nil {
+		if p, ok := r.index[h]; ok {
+			return r.freqs[p], true
+		}
+		return 0, false
+	}
 	for i := range r.hashes {
 		if r.hashes[i] == h {
 			return r.freqs[i], true
@@ -234,6 +269,70 @@ func (r *ghostFreqRing) lookup(h uint32) (uint32, bool) {
 	return 0, false
 }
 
+// ghostQueue is an exact FIFO of evicted key hashes, an alternative to the
+// rotating bloom filters that never reports a false positive and forgets keys
+// strictly in eviction order. Costs ~16 bytes per tracked hash versus ~3.
+type ghostQueue struct {
+	hashes []uint64
+	counts map[uint64]uint32 // hash -> occurrences in hashes; nil for queues small enough to scan
+	pos    int
+	full   bool
+}
+
+func newGhostQueue(n int) *ghostQueue {
+	n = max(n, 1)
+	q := &ghostQueue{hashes: make([]uint64, n)}
+	if n > maxScanGhostFreqs {
+		q.counts = make(map[uint64]uint32, n)
+	}
+	return q
+}
+
+func (q *ghostQueue) add(h uint64) {
+	if q.counts != nil {
+		if q.full {
+			old := q.hashes[q.pos]
+			if n := q.counts[old]; n > 1 {
+				q.counts[old] = n - 1
+			} else {
+				delete(q.counts, old)
+			}
+		}
+		q.counts[h]++
+	}
+	q.hashes[q.pos] = h
+	if q.pos++; q.pos == len(q.hashes) {
+		q.pos, q.full = 0, true
+	}
+}
+
+// contains reports whether h is queued. Small queues use a linear scan of
+// the slots filled so far, like ghostFreqRing.lookup.
+func (q *ghostQueue) contains(h uint64) bool {
+	if q.counts != nil {
+		_, ok := q.counts[h]
+		return ok
+	}
+	n := q.pos
+	if q.full {
+		n = len(q.hashes)
+	}
+	return slices.Contains(q.hashes[:n], h)
+}
+
+func (q *ghostQueue) reset() {
+	clear(q.counts)
+	q.pos, q.full = 0, false
+}
+
+// reset forgets all frequencies, keeping the ring's size.
+func (r *ghostFreqRing) reset() {
+	clear(r.hashes)
+	clear(r.freqs)
+	clear(r.index)
+	r.pos = 0
+}
+
 // entryList is an intrusive doubly-linked list. Zero value is valid.
 type entryList[K comparable, V any] struct {
 	head *entry[K, V]
@@ -278,61 +377,104 @@ func timeToSec(t time.Time) uint32 {
 }
 
 // entry is a cached key-value pair with eviction metadata.
-// Uses seqlock for zero-allocation value storage.
 //
 //nolint:govet // fieldalignment: generic struct layout varies by type parameters
 type entry[K comparable, V any] struct {
 	key       K
-	value     V             // stored inline, protected by seqlock
-	seq       atomic.Uint64 // seqlock: odd = write in progress
+	slot      atomic.Pointer[slot[V]] // current value; nil until first stored
 	prev      *entry[K, V]
 	next      *entry[K, V]
 	hash64    uint64        // full 64-bit hash for bloom filter (avoids re-hashing on eviction)
-	expirySec atomic.Uint32 // 0 means no expiry; seconds since Unix epoch
-	freqFlags atomic.Uint32 // bits 0-3: freq, bits 4-9: peakFreq, bit 30: inSmall, bit 31: onDeathRow
+	gen       atomic.Uint32 // bumped each time the entry leaves the cache; see retire
+	freqFlags atomic.Uint32 // bits 0-3: freq, bits 4-9: peakFreq, bit 29: pinned, bit 30: inSmall, bit 31: onDeathRow
 }
 
-// storeValue stores a value using seqlock protocol (zero allocations).
-// Uses CAS to ensure only one writer can be active at a time, preventing
-// sequence corruption when multiple goroutines update the same entry.
-func (e *entry[K, V]) storeValue(v V) {
-	for {
-		seq := e.seq.Load()
-		if seq&1 != 0 {
-			// Another writer is in progress, spin
-			continue
-		}
-		if e.seq.CompareAndSwap(seq, seq+1) {
-			// Successfully marked as writing (seq is now odd)
-			e.value = v
-			e.seq.Store(seq + 2) // End write (seq is now even)
-			return
-		}
+// entryRef is what the entries map holds: an entry and the generation it was
+// published at. Entries are recycled, so a lock-free reader may load an entry
+// that has since been reused for another key; slots carry the generation they
+// were written at, and a mismatch reads as a miss. Under c.mu, entries in the
+// map are never recycled and e can be used directly.
+type entryRef[K comparable, V any] struct {
+	e   *entry[K, V]
+	gen uint32
+}
+
+// load returns the entry's current slot, or nil if the entry was retired or
+// reused since this reference was taken.
+func (r entryRef[K, V]) load() *slot[V] {
+	if s := r.e.slot.Load(); s != nil && s.gen == r.gen {
+		return s
 	}
+	return nil
+}
+
+// deathRowSlot records an entry sent to death row along with its generation,
+// so a slot whose entry was since deleted (and possibly reused) is ignored.
+type deathRowSlot[K comparable, V any] struct {
+	ent *entry[K, V]
+	gen uint32
+}
+
+// live reports whether the slot still holds the entry it was filled with.
+func (s deathRowSlot[K, V]) live() bool {
+	return s.ent != nil && s.ent.gen.Load() == s.gen
 }
 
-// loadValue loads a value using seqlock protocol.
+// slot is an immutable snapshot of an entry's value, expiry, and epoch.
+// Writers publish a new slot rather than modifying one in place, so lock-free
+// readers see all three fields from the same write, with no torn values.
+type slot[V any] struct {
+	value     V
+	expirySec uint32 // 0 means no expiry; seconds since Unix epoch
+	epoch     uint32 // cache epoch at write; stale epochs read as misses
+	gen       uint32 // entry generation at write; see entryRef
+	ttlSec    uint32 // TTL as written or last extended; kept only with HotTTL
+	local     bool   // written by SetMemoryOnly: must never leave the process
+}
+
+// storeValue publishes a value with its expiry and epoch. Must hold c.mu.
+// Costs one allocation per write, in exchange for race-free reads.
+func (e *entry[K, V]) storeValue(v V, expirySec, epoch, ttlSec uint32) {
+	e.slot.Store(&slot[V]{value: v, expirySec: expirySec, epoch: epoch, gen: e.gen.Load(), ttlSec: ttlSec})
+}
+
+// loadValue returns the entry's value, or false if none has been stored.
 func (e *entry[K, V]) loadValue() (V, bool) {
-	for range 1000 { // bounded retry
-		s1 := e.seq.Load() // acquire semantics
-		if s1&1 != 0 {
-			continue // write in progress, retry
-		}
-		v := e.value
-		s2 := e.seq.Load() // acquire semantics
-		if s2 == s1 {
-			return v, s1 > 0 // s1>0 means value was stored at least once
-		}
+	if s := e.slot.Load(); s != nil {
+		return s.value, true
 	}
 	var zero V
 	return zero, false
 }
 
+// expirySec returns the current value's expiry, 0 if none.
+func (e *entry[K, V]) expirySec() uint32 {
+	if s := e.slot.Load(); s != nil {
+		return s.expirySec
+	}
+	return 0
+}
+
+// live returns the current slot if it is unexpired and from epoch.
+func (e *entry[K, V]) live(now, epoch uint32) (*slot[V], bool) {
+	s := e.slot.Load()
+	if !s.live(now, epoch) {
+		return nil, false
+	}
+	return s, true
+}
+
+// live reports whether s is non-nil, unexpired, and from epoch.
+func (s *slot[V]) live(now, epoch uint32) bool {
+	return s != nil && s.epoch == epoch && (s.expirySec == 0 || now <= s.expirySec)
+}
+
 // Bitfield constants for freqFlags.
 const (
 	freqMask      = 0xF  // bits 0-3 for freq (0-15)
 	peakFreqShift = 4    // peakFreq starts at bit 4
 	peakFreqMask  = 0x3F // bits 4-9 for peakFreq (0-63), accessed after shift
+	pinnedBit     = 1 << 29
 	inSmallBit    = 1 << 30
 	onDeathRowBit = 1 << 31
 )
@@ -356,17 +498,18 @@ func (e *entry[K, V]) setFreq(f uint32) {
 	}
 }
 
-// incFreq increments freq up to limit via CAS loop.
-func (e *entry[K, V]) incFreq(limit uint32) {
+// incFreq increments freq up to limit via CAS loop. It reports whether this
+// increment brought freq to limit.
+func (e *entry[K, V]) incFreq(limit uint32) bool {
 	for {
 		cur := e.freqFlags.Load()
 		f := cur & freqMask
 		if f >= limit {
-			return
+			return false
 		}
 		updated := (cur &^ freqMask) | (f + 1)
 		if e.freqFlags.CompareAndSwap(cur, updated) {
-			return
+			return f+1 == limit
 		}
 	}
 }
@@ -390,10 +533,13 @@ func (e *entry[K, V]) incPeakFreq(limit uint32) {
 // setFreqPeak sets freq and peakFreq, preserving flags. Must be called under mutex.
 func (e *entry[K, V]) setFreqPeak(f, p uint32) {
 	cur := e.freqFlags.Load()
-	flags := cur & (inSmallBit | onDeathRowBit)
+	flags := cur & (pinnedBit | inSmallBit | onDeathRowBit)
 	e.freqFlags.Store((f & freqMask) | ((p & peakFreqMask) << peakFreqShift) | flags)
 }
 
+// pinned returns true if entry is exempt from frequency decay.
+func (e *entry[K, V]) pinned() bool { return e.freqFlags.Load()&pinnedBit != 0 }
+
 // inSmall returns true if entry is in small queue.
 func (e *entry[K, V]) inSmall() bool { return e.freqFlags.Load()&inSmallBit != 0 }
 
@@ -410,6 +556,20 @@ func (e *entry[K, V]) setInSmall(v bool) {
 	}
 }
 
+// touch records a hit. A single load checks whether either counter needs an
+// increment: under Zipf, most hits are on entries already at max, so the CAS
+// loops are skipped. It reports whether the hit brought freq to maxFreq.
+func (e *entry[K, V]) touch() (hot bool) {
+	flags := e.freqFlags.Load()
+	if flags&freqMask < maxFreq {
+		hot = e.incFreq(maxFreq)
+	}
+	if (flags>>peakFreqShift)&peakFreqMask < maxPeakFreq {
+		e.incPeakFreq(maxPeakFreq)
+	}
+	return hot
+}
+
 // setOnDeathRow sets the onDeathRow flag. Must be called under mutex.
 func (e *entry[K, V]) setOnDeathRow(v bool) {
 	cur := e.freqFlags.Load()
@@ -431,16 +591,56 @@ func newS3FIFO[K comparable, V any](cfg *config) *s3fifo[K, V] {
 	// becomes a second cache that distorts benchmark results.
 	deathRowSize := max(minDeathRowSize, size/768)
 
+	ghostCap := perMille(size, ghostRatio(size))
+	ghostFreqs := cfg.ghostFreqs
+	if ghostFreqs <= 0 {
+		ghostFreqs = max(defaultGhostFreqs, ghostCap/ghostFreqScale)
+		if cfg.compact {
+			ghostFreqs = min(defaultGhostFreqs, max(ghostCap, 1))
+		}
+	}
+	fpRate := cfg.ghostFPRate
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = ghostFPRate
+	}
+	bloomSize := size
+	var exact *ghostQueue
+	if cfg.exactGhosts {
+		// The blooms remember between ghostCap and 2*ghostCap keys, but on hit-rate
+		// benchmarks an exact queue does as well at the lower bound.
+		exact = newGhostQueue(ghostCap)
+		bloomSize = 1 // unused, but kept non-nil so flush needn't check
+	}
+
+	// Compact caches let the map grow from its minimum size instead.
+	var presize []func(*xsync.MapConfig)
+	if !cfg.compact {
+		presize = append(presize, xsync.WithPresize(size))
+	}
+
 	c := &s3fifo[K, V]{
-		mu:          xsync.NewRBMutex(),
-		entries:     xsync.NewMap[K, *entry[K, V]](xsync.WithPresize(size)),
-		capacity:    size,
-		smallThresh: size * smallRatio(size) / 1000,
-		ghostCap:    size * ghostRatio(size) / 1000,
-		ghostActive: newBloomFilter(size, ghostFPRate),
-		ghostAging:  newBloomFilter(size, ghostFPRate),
-		deathRow:    make([]*entry[K, V], deathRowSize),
+		mu:           xsync.NewRBMutex(),
+		entries:      xsync.NewMap[K, entryRef[K, V]](presize...),
+		capacity:     size,
+		smallThresh:  perMille(size, smallRatio(size)),
+		ghostCap:     ghostCap,
+		ghostActive:  newBloomFilter(bloomSize, fpRate),
+		ghostAging:   newBloomFilter(bloomSize, fpRate),
+		ghostExact:   exact,
+		ghostFreqRng: newGhostFreqRing(ghostFreqs),
+		ghostFPRate:  fpRate,
+		deathRow:     make([]deathRowSlot[K, V], deathRowSize),
+		large:        newLargeRegion[K, V](cfg),
+		clock:        clockFor(cfg.clockResolution),
+		hot:          newHotTTL(cfg),
+		totalEntries: xsync.NewCounter(),
 	}
+	var tw func(evictionStep[K])
+	if cfg.trace != nil {
+		tw = traceWriter[K](cfg.trace)
+	}
+	c.evictions = newEvictionRing(cfg.evictionTrace)
+	c.onStep = chainSteps(tw, recordSteps[K](c.evictions))
 
 	// Detect key type once to avoid type switch on every operation.
 	var zk K
@@ -449,32 +649,31 @@ func newS3FIFO[K comparable, V any](cfg *config) *s3fifo[K, V] {
 		c.keyIsInt = true
 	case int64:
 		c.keyIsInt64 = true
+	case uint64:
+		c.keyIsUint64 = true
 	case string:
 		c.keyIsString = true
 	}
 
-	switch {
-	case c.keyIsInt:
-		c.hasher = func(k K) uint64 {
-			return hashInt64(int64(*(*int)(unsafe.Pointer(&k))))
-		}
-	case c.keyIsInt64:
-		c.hasher = func(k K) uint64 {
-			return hashInt64(*(*int64)(unsafe.Pointer(&k)))
-		}
-	case c.keyIsString:
-		c.hasher = func(k K) uint64 {
-			return hashString(*(*string)(unsafe.Pointer(&k)))
-		}
-	default:
+	if c.keyIsInt || c.keyIsInt64 || c.keyIsUint64 || c.keyIsString {
+		c.hasher = c.keyHash
+	} else {
 		c.hasher = func(k K) uint64 {
+			// Interface keys, as in SyncMap, hash their common dynamic types
+			// as keyHash would.
 			switch v := any(k).(type) {
-			case uint:
-				//nolint:gosec // G115: intentional bit reinterpretation for hashing
+			case string:
+				return hashString(v)
+			case int:
 				return hashInt64(int64(v))
+			case int64:
+				return hashInt64(v)
 			case uint64:
 				//nolint:gosec // G115: intentional bit reinterpretation for hashing
 				return hashInt64(int64(v))
+			case uint:
+				//nolint:gosec // G115: intentional bit reinterpretation for hashing
+				return hashInt64(int64(v))
 			case fmt.Stringer:
 				return hashString(v.String())
 			default:
@@ -486,31 +685,131 @@ func newS3FIFO[K comparable, V any](cfg *config) *s3fifo[K, V] {
 	return c
 }
 
+// keyHash hashes int, int64, uint64, and string keys without boxing them.
+// It returns 0 for other key types, which insert hashes with c.hasher.
+// Write paths call it before taking c.mu to keep hashing out of the critical section.
+func (c *s3fifo[K, V]) keyHash(key K) uint64 {
+	switch {
+	case c.keyIsInt:
+		return hashInt64(int64(*(*int)(unsafe.Pointer(&key))))
+	case c.keyIsInt64:
+		return hashInt64(*(*int64)(unsafe.Pointer(&key)))
+	case c.keyIsUint64:
+		//nolint:gosec // G115: intentional bit reinterpretation for hashing
+		return hashInt64(int64(*(*uint64)(unsafe.Pointer(&key))))
+	case c.keyIsString:
+		return hashString(*(*string)(unsafe.Pointer(&key)))
+	}
+	return 0
+}
+
+// now returns the current Unix second for expiry checks.
+func (c *s3fifo[K, V]) now() uint32 {
+	if c.clock == nil {
+		return unixSec()
+	}
+	return c.clock.now()
+}
+
 // get retrieves a value, incrementing its frequency on hit.
 func (c *s3fifo[K, V]) get(key K) (V, bool) {
-	ent, ok := c.entries.Load(key)
+	ref, ok := c.entries.Load(key)
 	if !ok {
+		// Misses skip the region's lock while it is empty.
+		if c.large != nil && c.large.count.Load() > 0 {
+			return c.large.get(key)
+		}
 		var zero V
 		return zero, false
 	}
+	ent := ref.e
 	if ent.onDeathRow() {
 		return c.resurrectFromDeathRow(key)
 	}
-	//nolint:gosec // G115: Unix seconds fit in uint32 until year 2106
-	if exp := ent.expirySec.Load(); exp != 0 && uint32(time.Now().Unix()) > exp {
+	// One load sees value, expiry, and epoch from the same write.
+	// The clock is only consulted for entries that can expire.
+	sl := ref.load()
+	if sl == nil || sl.epoch != c.epoch.Load() || (sl.expirySec != 0 && c.now() > sl.expirySec) {
 		var zero V
 		return zero, false
 	}
-	// Hot path: single Load to check if both counters need increment.
-	// Under Zipf, most accesses hit entries already at max - skip CAS loops.
-	flags := ent.freqFlags.Load()
-	if flags&freqMask < maxFreq {
-		ent.incFreq(maxFreq)
+	if ent.touch() && c.hot != nil {
+		c.heat(ref, sl)
 	}
-	if (flags>>peakFreqShift)&peakFreqMask < maxPeakFreq {
-		ent.incPeakFreq(maxPeakFreq)
+	return sl.value, true
+}
+
+// getMulti looks up keys in one pass, calling found for each hit. The epoch
+// and clock are read once for the whole batch rather than once per key.
+func (c *s3fifo[K, V]) getMulti(keys []K, found func(key K, value V)) {
+	epoch := c.epoch.Load()
+	var now uint32
+	for _, key := range keys {
+		ref, ok := c.entries.Load(key)
+		if !ok {
+			if c.large != nil && c.large.count.Load() > 0 {
+				if v, ok := c.large.get(key); ok {
+					found(key, v)
+				}
+			}
+			continue
+		}
+		if ref.e.onDeathRow() {
+			if v, ok := c.resurrectFromDeathRow(key); ok {
+				found(key, v)
+			}
+			continue
+		}
+		sl := ref.load()
+		if sl == nil || sl.epoch != epoch {
+			continue
+		}
+		if sl.expirySec != 0 {
+			if now == 0 {
+				now = c.now()
+			}
+			if now > sl.expirySec {
+				continue
+			}
+		}
+		if ref.e.touch() && c.hot != nil {
+			c.heat(ref, sl)
+		}
+		found(key, sl.value)
+	}
+}
+
+// queueName names the queue holding key: "small", "main", "death row", or
+// "large", or "" if memory doesn't hold it. Expired entries awaiting eviction
+// are still named. The answer is racy against eviction; it is for diagnostics.
+func (c *s3fifo[K, V]) queueName(key K) string {
+	if ref, ok := c.entries.Load(key); ok {
+		switch {
+		case ref.e.onDeathRow():
+			return "death row"
+		case ref.e.inSmall():
+			return "small"
+		default:
+			return "main"
+		}
+	}
+	if c.large != nil && c.large.count.Load() > 0 {
+		if _, ok := c.large.expiry(key); ok {
+			return "large"
+		}
 	}
-	return ent.loadValue()
+	return ""
+}
+
+// getResurrected is get that also reports whether the hit came back from death row.
+// The check is racy against concurrent eviction; it is meant for diagnostics.
+func (c *s3fifo[K, V]) getResurrected(key K) (val V, ok, resurrected bool) {
+	if ref, found := c.entries.Load(key); found && ref.e.onDeathRow() {
+		val, ok = c.resurrectFromDeathRow(key)
+		return val, ok, ok
+	}
+	val, ok = c.get(key)
+	return val, ok, false
 }
 
 // resurrectFromDeathRow brings an entry back from pending eviction.
@@ -519,51 +818,146 @@ func (c *s3fifo[K, V]) get(key K) (V, bool) {
 // NOTE: Uses manual unlock instead of defer for -6% throughput improvement on hot path.
 func (c *s3fifo[K, V]) resurrectFromDeathRow(key K) (V, bool) {
 	c.mu.Lock()
-	ent, ok := c.entries.Load(key)
-	if !ok || !ent.onDeathRow() {
+	ref, ok := c.entries.Load(key)
+	ent := ref.e
+	if ok && !ent.onDeathRow() {
+		// Resurrected or replaced while we waited for the lock.
+		c.mu.Unlock()
+		return c.get(key)
+	}
+	if !ok {
 		c.mu.Unlock()
 		var zero V
-		return zero, ok
+		return zero, false
 	}
+	val, ok := c.resurrect(ent)
+	c.mu.Unlock()
+	return val, ok
+}
 
-	// Remove from death row.
-	for i := range c.deathRow {
-		if c.deathRow[i] == ent {
-			c.deathRow[i] = nil
-			break
-		}
+// resurrect moves ent from death row back to main if its value is still live. Must hold c.mu.
+func (c *s3fifo[K, V]) resurrect(ent *entry[K, V]) (V, bool) {
+	var zero V
+	if !c.fromDeathRow(ent) {
+		return zero, false
+	}
+	sl, ok := ent.live(c.now(), c.epoch.Load())
+	if !ok {
+		// Stale values stay on death row until evicted; they must not re-enter the queues.
+		return zero, false
 	}
+	c.step(ent, actionResurrect, 0)
+	c.removeFromDeathRow(ent)
 
 	// Resurrect to main queue with boosted frequency.
-	ent.setOnDeathRow(false)
 	ent.setInSmall(false)
 	ent.setFreqPeak(3, 3)
-	c.main.pushBack(ent)
-	c.totalEntries.Add(1)
+	c.pushMain(ent)
+	c.totalEntries.Inc()
 
 	// Evict to maintain capacity after resurrection.
-	if c.totalEntries.Load() > int64(c.capacity) {
+	if c.queued() > c.capacity {
 		c.evictOne()
 	}
+	return sl.value, true
+}
 
-	val, ok := ent.loadValue()
-	c.mu.Unlock()
-	return val, ok
+// fromDeathRow reports whether ent holds a live death row slot. Must hold c.mu.
+func (c *s3fifo[K, V]) fromDeathRow(ent *entry[K, V]) bool {
+	gen := ent.gen.Load()
+	for _, s := range c.deathRow {
+		if s.ent == ent && s.gen == gen {
+			return true
+		}
+	}
+	return false
+}
+
+// removeFromDeathRow clears ent's live death row slot. Must hold c.mu.
+func (c *s3fifo[K, V]) removeFromDeathRow(ent *entry[K, V]) {
+	gen := ent.gen.Load()
+	for i := range c.deathRow {
+		if c.deathRow[i].ent == ent && c.deathRow[i].gen == gen {
+			c.deathRow[i] = deathRowSlot[K, V]{}
+			break
+		}
+	}
+	ent.setOnDeathRow(false)
+}
+
+// retire removes ent from the cache after it has left the queues and death row.
+// Bumping the generation invalidates stale death row slots, and clearing the
+// value turns concurrent lock-free updates into fresh inserts instead of writes
+// into a detached entry. Must hold c.mu.
+func (c *s3fifo[K, V]) retire(ent *entry[K, V]) {
+	if cur, ok := c.entries.Load(ent.key); ok && cur.e == ent {
+		c.entries.Delete(ent.key)
+	}
+	ent.gen.Add(1)
+	ent.slot.Store(nil)
+	ent.setOnDeathRow(false)
+	ent.prev, ent.next = nil, nil
 }
 
 // set adds or updates a value. expirySec of 0 means no expiry.
 func (c *s3fifo[K, V]) set(key K, value V, expirySec uint32) {
-	var h uint64
-	if c.keyIsString {
-		h = hashString(*(*string)(unsafe.Pointer(&key)))
+	if c.large != nil && c.setLarge(key, value, expirySec, false) {
+		return
+	}
+	c.setEntry(key, value, expirySec, 0, false)
+}
+
+// setLocal is set for a value that must stay in this process, for
+// SetMemoryOnly: it is never handed to onEvict or eachPersistable.
+func (c *s3fifo[K, V]) setLocal(key K, value V, expirySec uint32) {
+	if c.large != nil && c.setLarge(key, value, expirySec, true) {
+		return
 	}
-	c.setWithHash(key, value, expirySec, h)
+	c.setEntry(key, value, expirySec, 0, true)
+}
+
+// setLarge routes oversized values to the large-object region.
+// Returns false if value is small enough for the queues.
+func (c *s3fifo[K, V]) setLarge(key K, value V, expirySec uint32, local bool) bool {
+	size := c.large.size(value)
+	if size < 0 {
+		if c.large.count.Load() > 0 {
+			c.large.del(key)
+		}
+		return false
+	}
+	// The move between regions happens under c.mu, as does a new queue entry's
+	// insert, so racing sets of key leave it in one region, the last writer's.
+	c.mu.Lock()
+	if ref, ok := c.entries.Load(key); ok {
+		c.unlink(ref.e)
+	}
+	c.large.set(key, value, size, expirySec, local)
+	c.mu.Unlock()
+	return true
 }
 
 // updateEntry updates an existing entry's value and frequency counters.
-func (*s3fifo[K, V]) updateEntry(ent *entry[K, V], value V, expirySec uint32) {
-	ent.storeValue(value)
-	ent.expirySec.Store(expirySec)
+// It returns false if the entry was retired or reused since ref was loaded,
+// in which case the caller must insert.
+func (c *s3fifo[K, V]) updateEntry(ref entryRef[K, V], value V, expirySec, epoch uint32) bool {
+	return c.updateSlot(ref, &slot[V]{value: value, expirySec: expirySec, epoch: epoch, ttlSec: c.hot.ttlSec(expirySec)})
+}
+
+// updateSlot is updateEntry with the new slot built by the caller. next must
+// not be published yet; its generation is set from ref.
+func (c *s3fifo[K, V]) updateSlot(ref entryRef[K, V], next *slot[V]) bool {
+	ent := ref.e
+	next.gen = ref.gen
+	for {
+		cur := ent.slot.Load()
+		if cur == nil || cur.gen != ref.gen {
+			return false
+		}
+		if ent.slot.CompareAndSwap(cur, next) {
+			break
+		}
+	}
 	// Hot path: single Load to check if counters need increment.
 	flags := ent.freqFlags.Load()
 	if flags&freqMask < maxFreq {
@@ -572,39 +966,62 @@ func (*s3fifo[K, V]) updateEntry(ent *entry[K, V], value V, expirySec uint32) {
 	if (flags>>peakFreqShift)&peakFreqMask < maxPeakFreq {
 		ent.incPeakFreq(maxPeakFreq)
 	}
+	return true
 }
 
 // setWithHash adds or updates a value. hash=0 means compute when needed.
 //
 // NOTE: Uses manual unlock instead of defer for -5% throughput improvement on hot path.
 func (c *s3fifo[K, V]) setWithHash(key K, value V, expirySec uint32, hash uint64) {
+	c.setEntry(key, value, expirySec, hash, false)
+}
+
+// setEntry is setWithHash, marking the value local if it must never leave
+// the process.
+func (c *s3fifo[K, V]) setEntry(key K, value V, expirySec uint32, hash uint64, local bool) {
+	// The epoch at the start of the set is the write's: a set that a flush
+	// overtakes is invalidated with the entries before it rather than surviving.
+	epoch := c.epoch.Load()
+	next := &slot[V]{value: value, expirySec: expirySec, epoch: epoch, ttlSec: c.hot.ttlSec(expirySec), local: local}
+
 	// Fast path: lock-free update for existing entries.
-	if ent, exists := c.entries.Load(key); exists {
-		c.updateEntry(ent, value, expirySec)
+	if ref, exists := c.entries.Load(key); exists && c.updateSlot(ref, next) {
 		return
 	}
 
-	// Slow path: need lock for new entry insertion.
+	// Slow path: need lock for new entry insertion. Hash first to keep it out of the lock.
+	if hash == 0 {
+		hash = c.keyHash(key)
+	}
 	c.mu.Lock()
 
-	// Double-check after acquiring lock.
-	if ent, exists := c.entries.Load(key); exists {
-		c.updateEntry(ent, value, expirySec)
+	// Double-check after acquiring lock. Entries in the map are never retired
+	// while the lock is held, so the update cannot fail.
+	if ref, exists := c.entries.Load(key); exists && c.updateSlot(ref, next) {
 		c.mu.Unlock()
 		return
 	}
+	if c.epoch.Load() != epoch {
+		c.mu.Unlock() // flushed or bumped while waiting for the lock
+		return
+	}
 
-	// Allocate-first: reuse recycled entry or allocate new one.
-	ent := c.freeEntry
-	if ent != nil {
-		c.freeEntry = nil
-		ent.key = key
-		ent.freqFlags.Store(0) // clears freq, peakFreq, inSmall, onDeathRow
-	} else {
-		ent = &entry[K, V]{key: key}
+	c.insert(key, value, expirySec, hash, local)
+	if c.large != nil && c.large.count.Load() > 0 {
+		c.large.del(key) // written by a setLarge that raced this set's size check
 	}
-	ent.storeValue(value)
-	ent.expirySec.Store(expirySec)
+	c.mu.Unlock()
+}
+
+// insert adds a new entry for key, evicting if the cache is full. local marks
+// the value as setLocal does. Must hold c.mu.
+func (c *s3fifo[K, V]) insert(key K, value V, expirySec uint32, hash uint64, local bool) {
+	ent := c.newEntry(key)
+	ent.slot.Store(&slot[V]{
+		value: value, expirySec: expirySec, epoch: c.epoch.Load(), gen: ent.gen.Load(),
+		ttlSec: c.hot.ttlSec(expirySec), local: local,
+	})
+	ref := entryRef[K, V]{e: ent, gen: ent.gen.Load()}
 
 	// Cache full hash for bloom filter (avoids re-hashing on eviction).
 	h := hash
@@ -613,22 +1030,26 @@ func (c *s3fifo[K, V]) setWithHash(key K, value V, expirySec uint32, hash uint64
 	}
 	ent.hash64 = h
 
-	full := c.totalEntries.Load() >= int64(c.capacity)
+	full := c.queued() >= c.capacity
 
 	// During warmup, skip eviction logic.
 	if !c.warmupComplete && !full {
 		ent.setInSmall(true)
 		c.small.pushBack(ent)
-		c.entries.Store(key, ent)
-		c.totalEntries.Add(1)
-		c.mu.Unlock()
+		c.entries.Store(key, ref)
+		c.totalEntries.Inc()
 		return
 	}
 	c.warmupComplete = true
 
 	// Only check ghost when full (saves bloom lookups during fill).
 	if full {
-		inGhost := c.ghostActive.Contains(h) || c.ghostAging.Contains(h)
+		var inGhost bool
+		if c.ghostExact != nil {
+			inGhost = c.ghostExact.contains(h)
+		} else {
+			inGhost = c.ghostActive.Contains(h) || c.ghostAging.Contains(h)
+		}
 		ent.setInSmall(!inGhost)
 
 		// Restore frequency from ghost for returning keys.
@@ -650,39 +1071,217 @@ func (c *s3fifo[K, V]) setWithHash(key K, value V, expirySec uint32, hash uint64
 		c.main.pushBack(ent)
 	}
 
-	c.entries.Store(key, ent)
-	c.totalEntries.Add(1)
-	c.mu.Unlock()
+	c.entries.Store(key, ref)
+	c.totalEntries.Inc()
 }
 
-func (c *s3fifo[K, V]) del(key K) {
+// newEntry returns a recycled or new entry for key. Must hold c.mu.
+func (c *s3fifo[K, V]) newEntry(key K) *entry[K, V] {
+	ent := c.freeEntry
+	if ent != nil {
+		c.freeEntry = nil
+	} else if pooled, ok := c.pool.Get().(*entry[K, V]); ok {
+		ent = pooled
+	} else {
+		return &entry[K, V]{key: key}
+	}
+	ent.key = key
+	ent.freqFlags.Store(0) // clears freq, peakFreq, pinned, inSmall, onDeathRow
+	return ent
+}
+
+// recycle makes a retired entry available to newEntry. Must hold c.mu.
+func (c *s3fifo[K, V]) recycle(ent *entry[K, V]) {
+	if c.freeEntry == nil {
+		c.freeEntry = ent
+		return
+	}
+	c.pool.Put(ent)
+}
+
+// getOrSet returns the live value for key if there is one, and otherwise stores value.
+// The check and the store are atomic with respect to other getOrSet calls; plain sets
+// racing with it may still overwrite the stored value.
+func (c *s3fifo[K, V]) getOrSet(key K, value V, expirySec uint32) (actual V, loaded bool) {
+	if v, ok := c.get(key); ok {
+		return v, true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ref, ok := c.entries.Load(key)
+	ent := ref.e
+	if ok && ent.onDeathRow() {
+		if v, live := c.resurrect(ent); live {
+			return v, true
+		}
+		// A stale value must not be claimed in place, or it would resurrect later.
+		c.unlink(ent)
+		ok = false
+	}
+
+	if c.large != nil {
+		if v, found := c.large.get(key); found {
+			return v, true
+		}
+		if size := c.large.size(value); size >= 0 {
+			if ok {
+				c.unlink(ent)
+			}
+			c.large.set(key, value, size, expirySec, false)
+			return value, false
+		}
+	}
+
+	if !ok {
+		c.insert(key, value, expirySec, c.keyHash(key), false)
+		return value, false
+	}
+
+	// Lock-free sets may still replace the slot, so claim it with a CAS.
+	next := &slot[V]{value: value, expirySec: expirySec, epoch: c.epoch.Load(), gen: ent.gen.Load(), ttlSec: c.hot.ttlSec(expirySec)}
+	for {
+		if sl, live := ent.live(c.now(), next.epoch); live {
+			return sl.value, true
+		}
+		if cur := ent.slot.Load(); cur != nil && ent.slot.CompareAndSwap(cur, next) {
+			return value, false
+		}
+	}
+}
+
+// swap stores value and returns the live value it replaced, if any.
+func (c *s3fifo[K, V]) swap(key K, value V, expirySec uint32) (old V, existed bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	ent, ok := c.entries.Load(key)
+	ref, ok := c.entries.Load(key)
+	ent := ref.e
+	if ok && ent.onDeathRow() {
+		if _, live := c.resurrect(ent); !live {
+			c.unlink(ent)
+			ok = false
+		}
+	}
+
+	if c.large != nil {
+		if c.large.count.Load() > 0 {
+			old, existed = c.large.take(key)
+		}
+		if size := c.large.size(value); size >= 0 {
+			if ok {
+				old, existed = c.take(ent)
+			}
+			c.large.set(key, value, size, expirySec, false)
+			return old, existed
+		}
+	}
+
 	if !ok {
+		c.insert(key, value, expirySec, c.keyHash(key), false)
+		return old, existed
+	}
+
+	// Entries in the map are not retired while c.mu is held, so the slot is non-nil,
+	// but lock-free sets may still replace it between the load and the CAS.
+	next := &slot[V]{value: value, expirySec: expirySec, epoch: c.epoch.Load(), gen: ent.gen.Load(), ttlSec: c.hot.ttlSec(expirySec)}
+	for {
+		cur := ent.slot.Load()
+		if !ent.slot.CompareAndSwap(cur, next) {
+			continue
+		}
+		if cur.live(c.now(), next.epoch) {
+			return cur.value, true
+		}
+		return old, existed
+	}
+}
+
+// getAndDelete removes key and returns its live value, if any.
+func (c *s3fifo[K, V]) getAndDelete(key K) (V, bool) {
+	var val V
+	var found bool
+	if c.large != nil && c.large.count.Load() > 0 {
+		val, found = c.large.take(key)
+	}
+	if _, ok := c.entries.Load(key); !ok {
+		return val, found
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if ref, ok := c.entries.Load(key); ok {
+		if v, live := c.take(ref.e); live {
+			return v, true
+		}
+	}
+	return val, found
+}
+
+// take unlinks ent and returns the value it held, if live. Must hold c.mu.
+func (c *s3fifo[K, V]) take(ent *entry[K, V]) (V, bool) {
+	sl, live := ent.live(c.now(), c.epoch.Load())
+	c.unlink(ent)
+	if !live {
+		var zero V
+		return zero, false
+	}
+	return sl.value, true
+}
+
+func (c *s3fifo[K, V]) del(key K) {
+	if c.large != nil && c.large.count.Load() > 0 {
+		c.large.del(key)
+	}
+	// Deleting an absent key needn't contend for the lock.
+	if _, ok := c.entries.Load(key); !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if ref, ok := c.entries.Load(key); ok {
+		c.unlink(ref.e)
+	}
+}
+
+// unlink removes ent from its queue or death row, retires, and recycles it. Must hold c.mu.
+func (c *s3fifo[K, V]) unlink(ent *entry[K, V]) {
+	// Death row entries are already out of the queues and the count.
+	if ent.onDeathRow() {
+		c.removeFromDeathRow(ent)
+		c.retire(ent)
+		c.recycle(ent)
 		return
 	}
 
 	if ent.inSmall() {
 		c.small.remove(ent)
 	} else {
-		c.main.remove(ent)
+		c.removeMain(ent)
 	}
 
-	c.entries.Delete(key)
-	c.totalEntries.Add(-1)
+	c.retire(ent)
+	c.recycle(ent)
+	c.totalEntries.Dec()
 }
 
 // addToGhost records an evicted key's hash for future admission decisions.
 // Bloom filter uses full 64-bit hash for proper double hashing (h2 = h >> 32).
 // Frequency ring uses lower 32 bits (sufficient for collision avoidance).
 func (c *s3fifo[K, V]) addToGhost(h64 uint64, peakFreq uint32) {
-	c.ghostActive.Add(h64)
 	if peakFreq >= 1 {
 		//nolint:gosec // G115: intentional truncation to 32-bit hash
 		c.ghostFreqRng.add(uint32(h64), peakFreq)
 	}
+	if c.ghostExact != nil {
+		c.ghostExact.add(h64)
+		return
+	}
+	c.ghostActive.Add(h64)
 	if c.ghostActive.entries >= c.ghostCap {
 		c.ghostAging.Reset()
 		c.ghostActive, c.ghostAging = c.ghostAging, c.ghostActive
@@ -701,10 +1300,34 @@ func (c *s3fifo[K, V]) evictOne() {
 			if c.evictFromSmall() {
 				return
 			}
+		} else if c.pinned.len > 0 {
+			// Only pinned entries are left: evict the oldest.
+			e := c.pinned.head
+			c.pinned.remove(e)
+			c.sendToDeathRow(e)
+			return
 		}
 	}
 }
 
+// pushMain appends e to main, or to the pinned list if it is pinned.
+func (c *s3fifo[K, V]) pushMain(e *entry[K, V]) {
+	if e.pinned() {
+		c.pinned.pushBack(e)
+		return
+	}
+	c.main.pushBack(e)
+}
+
+// removeMain removes e from main or, if it is pinned, the pinned list.
+func (c *s3fifo[K, V]) removeMain(e *entry[K, V]) {
+	if e.pinned() {
+		c.pinned.remove(e)
+		return
+	}
+	c.main.remove(e)
+}
+
 // evictFromSmall evicts cold entries (freq<2) or promotes warm ones to main.
 // Returns true if an entry was actually evicted.
 func (c *s3fifo[K, V]) evictFromSmall() bool {
@@ -720,13 +1343,16 @@ func (c *s3fifo[K, V]) evictFromSmall() bool {
 			return true
 		}
 
-		// Promote to main.
+		// Promote to main. Pinned entries keep their frequency.
+		c.step(e, actionPromote, 0)
 		c.small.remove(e)
-		e.setFreq(0)
+		if !e.pinned() {
+			e.setFreq(0)
+		}
 		e.setInSmall(false)
-		c.main.pushBack(e)
+		c.pushMain(e)
 
-		if c.main.len > mcap {
+		if c.main.len+c.pinned.len > mcap {
 			if c.evictFromMain() {
 				return true
 			}
@@ -742,6 +1368,9 @@ func (c *s3fifo[K, V]) evictFromSmall() bool {
 // get demoted to small queue with freq=1 instead of being evicted. This gives
 // them another chance to prove themselves before final eviction.
 // Improves meta by +4%, wikipedia by +1%, and most other traces.
+//
+// Pinned entries are not in main: they wait on their own list, and evictOne
+// evicts them only once small and main are empty, so capacity still holds.
 func (c *s3fifo[K, V]) evictFromMain() bool {
 	for c.main.len > 0 {
 		e := c.main.head
@@ -751,6 +1380,7 @@ func (c *s3fifo[K, V]) evictFromMain() bool {
 			c.main.remove(e)
 			// Demote once-hot items to small queue for another chance.
 			if e.peakFreq() >= 1 {
+				c.step(e, actionDemote, 0)
 				e.setFreq(1)
 				e.setInSmall(true)
 				c.small.pushBack(e)
@@ -761,6 +1391,7 @@ func (c *s3fifo[K, V]) evictFromMain() bool {
 		}
 
 		// Second chance.
+		c.step(e, actionSecondChance, 0)
 		c.main.remove(e)
 		e.setFreq(f - 1)
 		c.main.pushBack(e)
@@ -797,53 +1428,270 @@ func (c *s3fifo[K, V]) sendToDeathRow(e *entry[K, V]) {
 		threshold = 1
 	}
 	if e.peakFreq() < threshold {
-		c.entries.Delete(e.key)
+		c.step(e, actionEvict, threshold)
+		if c.onDrop != nil {
+			if v, ok := e.loadValue(); ok {
+				c.onDrop(e.key, e.hash64, v, e.expirySec())
+			}
+		}
 		c.addToGhost(e.hash64, e.peakFreq())
-		e.prev, e.next = nil, nil
-		c.freeEntry = e
-		c.totalEntries.Add(-1)
+		c.retire(e)
+		c.recycle(e)
+		c.totalEntries.Dec()
 		return
 	}
 
 	// If death row slot is occupied, truly evict that entry first.
-	if old := c.deathRow[c.deathRowPos]; old != nil {
-		c.entries.Delete(old.key)
+	// A stale slot's entry was deleted since, and may already be reused.
+	if s := c.deathRow[c.deathRowPos]; s.live() {
+		old := s.ent
+		c.step(old, actionEvict, 0)
+		if c.onEvict != nil || c.onDrop != nil {
+			if sl := old.slot.Load(); sl != nil {
+				if c.onEvict != nil && !sl.local {
+					c.onEvict(old.key, sl.value, sl.expirySec)
+				}
+				if c.onDrop != nil {
+					c.onDrop(old.key, old.hash64, sl.value, sl.expirySec)
+				}
+			}
+		}
 		c.addToGhost(old.hash64, old.peakFreq())
-		old.setOnDeathRow(false)
-		// Recycle entry for reuse (reduces allocations).
-		old.prev, old.next = nil, nil
-		c.freeEntry = old
+		c.retire(old)
+		c.recycle(old)
 	}
 
+	c.step(e, actionDeathRow, threshold)
 	e.setOnDeathRow(true)
-	c.deathRow[c.deathRowPos] = e
+	c.deathRow[c.deathRowPos] = deathRowSlot[K, V]{ent: e, gen: e.gen.Load()}
 	c.deathRowPos = (c.deathRowPos + 1) % len(c.deathRow)
-	c.totalEntries.Add(-1)
+	c.totalEntries.Dec()
+}
+
+// step reports an eviction decision about e to onStep, if set. Must hold c.mu.
+func (c *s3fifo[K, V]) step(e *entry[K, V], action evictAction, threshold uint32) {
+	if c.onStep == nil {
+		return
+	}
+	queue := queueMain
+	switch {
+	case e.onDeathRow():
+		queue = queueDeathRow
+	case e.inSmall():
+		queue = queueSmall
+	}
+	c.onStep(evictionStep[K]{
+		key: e.key, hash: e.hash64, queue: queue, freq: e.freq(), peak: e.peakFreq(),
+		threshold: threshold, action: action,
+	})
+}
+
+// queued returns the entries in the small, main, and pinned lists, the count len
+// reports before the large region. Must hold c.mu; unlike len, it reads no
+// striped counter.
+func (c *s3fifo[K, V]) queued() int {
+	return c.small.len + c.main.len + c.pinned.len
 }
 
 func (c *s3fifo[K, V]) len() int {
 	// Return live entries only (excludes items pending eviction on death row).
-	return int(c.totalEntries.Load())
+	n := int(c.totalEntries.Value())
+	if c.large != nil {
+		n += int(c.large.count.Load())
+	}
+	return n
+}
+
+// all returns an iterator over all non-expired key-value pairs, including large objects.
+func (c *s3fifo[K, V]) all() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		c.each(func(key K, v V, _ uint32) bool { return yield(key, v) })
+	}
+}
+
+// hottest returns up to n live keys, most frequently accessed first, ranked by
+// peakFreq and then by current freq. Large objects are not tracked and are
+// never included.
+func (c *s3fifo[K, V]) hottest(n int) []K {
+	if n <= 0 {
+		return nil
+	}
+	type hot struct {
+		key  K
+		rank uint32
+	}
+	now := c.now()
+	epoch := c.epoch.Load()
+	var hots []hot
+	c.entries.Range(func(key K, ref entryRef[K, V]) bool {
+		if !ref.load().live(now, epoch) || ref.e.onDeathRow() {
+			return true
+		}
+		f := ref.e.freqFlags.Load()
+		// peakFreq in the high bits, so it dominates the freq tiebreak.
+		hots = append(hots, hot{key: key, rank: (f>>peakFreqShift&peakFreqMask)<<4 | f&freqMask})
+		return true
+	})
+	slices.SortStableFunc(hots, func(a, b hot) int { return cmp.Compare(b.rank, a.rank) })
+	keys := make([]K, 0, min(n, len(hots)))
+	for _, h := range hots[:min(n, len(hots))] {
+		keys = append(keys, h.key)
+	}
+	return keys
+}
+
+// each calls fn for every live entry with its expiry, stopping when fn returns false.
+func (c *s3fifo[K, V]) each(fn func(key K, value V, expirySec uint32) bool) {
+	c.walk(true, fn)
+}
+
+// eachPersistable is each without values written by setLocal.
+func (c *s3fifo[K, V]) eachPersistable(fn func(key K, value V, expirySec uint32) bool) {
+	c.walk(false, fn)
+}
+
+// walk implements each, skipping values written by setLocal unless local is set.
+func (c *s3fifo[K, V]) walk(local bool, fn func(key K, value V, expirySec uint32) bool) {
+	now := c.now()
+	epoch := c.epoch.Load()
+	done := false
+	c.entries.Range(func(key K, ref entryRef[K, V]) bool {
+		// Skip expired, stale, and recycled entries.
+		sl := ref.load()
+		if !sl.live(now, epoch) || (sl.local && !local) {
+			return true
+		}
+
+		if !fn(key, sl.value, sl.expirySec) {
+			done = true
+			return false
+		}
+		return true
+	})
+	if done || c.large == nil {
+		return
+	}
+	for _, e := range c.large.snapshot() {
+		if e.local && !local {
+			continue
+		}
+		if !fn(e.key, e.value, e.expirySec) {
+			return
+		}
+	}
+}
+
+// deleteFunc removes every live entry for which fn returns true and returns their keys.
+// fn runs without the lock held; an entry written again after fn saw it is kept.
+func (c *s3fifo[K, V]) deleteFunc(fn func(key K, value V) bool) []K {
+	type match struct {
+		ent *entry[K, V]
+		sl  *slot[V]
+	}
+	var matches []match
+	now := c.now()
+	epoch := c.epoch.Load()
+	c.entries.Range(func(key K, ref entryRef[K, V]) bool {
+		if sl := ref.load(); sl.live(now, epoch) && fn(key, sl.value) {
+			matches = append(matches, match{ent: ref.e, sl: sl})
+		}
+		return true
+	})
+
+	var keys []K
+	if c.large != nil {
+		for _, e := range c.large.snapshot() {
+			if fn(e.key, e.value) {
+				c.large.del(e.key)
+				keys = append(keys, e.key)
+			}
+		}
+	}
+	if len(matches) == 0 {
+		return keys
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, m := range matches {
+		// A recycled entry has a new slot, so the slot check also covers reuse.
+		if m.ent.slot.Load() != m.sl {
+			continue
+		}
+		keys = append(keys, m.ent.key)
+		c.unlink(m.ent)
+	}
+	return keys
+}
+
+// bumpEpoch invalidates every entry in O(1). Stale entries stay queued and
+// are evicted normally, so len() keeps counting them until then.
+// Large objects are few, so they are flushed outright.
+func (c *s3fifo[K, V]) bumpEpoch() {
+	c.epoch.Add(1)
+	if c.large != nil {
+		c.large.flush()
+	}
+}
+
+// pin exempts key's entry from frequency decay, keeping it warm, for
+// SetImmutable. The pin lasts until the entry leaves the cache.
+func (c *s3fifo[K, V]) pin(key K) {
+	if _, ok := c.entries.Load(key); !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ref, ok := c.entries.Load(key); ok {
+		e := ref.e
+		cur := e.freqFlags.Load()
+		e.freqFlags.Store(cur&^freqMask | maxFreq | pinnedBit)
+		// A main entry moves to the pinned list; one in small follows when
+		// promoted, and one on death row when resurrected.
+		if cur&(pinnedBit|inSmallBit|onDeathRowBit) == 0 {
+			c.main.remove(e)
+			c.pinned.pushBack(e)
+		}
+	}
 }
 
 // getEntry returns an entry for testing purposes (not for production use).
 func (c *s3fifo[K, V]) getEntry(key K) (*entry[K, V], bool) {
-	return c.entries.Load(key)
+	ref, ok := c.entries.Load(key)
+	return ref.e, ok
 }
 
 func (c *s3fifo[K, V]) flush() int {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	n := c.entries.Size()
+	// Retire the epoch first, so every earlier slot reads as a miss: both those
+	// reached through refs loaded before the clear and those published by
+	// lock-free updates racing it. Only live entries count as removed.
+	epoch := c.epoch.Add(1) - 1
+	now := c.now()
+	n := 0
+	c.entries.Range(func(_ K, ref entryRef[K, V]) bool {
+		if !ref.e.onDeathRow() && ref.load().live(now, epoch) {
+			n++
+		}
+		return true
+	})
 	c.entries.Clear()
 	c.small.head, c.small.tail, c.small.len = nil, nil, 0
 	c.main.head, c.main.tail, c.main.len = nil, nil, 0
+	c.pinned.head, c.pinned.tail, c.pinned.len = nil, nil, 0
 	c.ghostActive.Reset()
 	c.ghostAging.Reset()
-	c.ghostFreqRng = ghostFreqRing{}
+	if c.ghostExact != nil {
+		c.ghostExact.reset()
+	}
+	c.ghostFreqRng.reset()
 	clear(c.deathRow)
 	c.deathRowPos = 0
-	c.totalEntries.Store(0)
+	c.totalEntries.Reset() // writers hold mu, as we do
+	if c.large != nil {
+		n += c.large.flush()
+	}
 	return n
 }
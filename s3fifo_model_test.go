@@ -0,0 +1,286 @@
+package fido
+
+import (
+	"math/rand/v2"
+	"sync"
+	"testing"
+)
+
+// checkInvariants verifies s3fifo's bookkeeping: the queues are well-linked
+// lists whose lengths add up to the entry count, no entry is in more than one
+// of small, main, and death row, every queued or death row entry is the one
+// the map holds for its key, and the map holds nothing else.
+func checkInvariants[K comparable, V any](t *testing.T, c *s3fifo[K, V]) {
+	t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	where := make(map[*entry[K, V]]string)
+	inMap := func(e *entry[K, V], place string) {
+		t.Helper()
+		if prev, dup := where[e]; dup {
+			t.Fatalf("key %v is in both %s and %s", e.key, prev, place)
+		}
+		where[e] = place
+		if ref, ok := c.entries.Load(e.key); !ok || ref.e != e || ref.gen != e.gen.Load() {
+			t.Fatalf("key %v in %s is not the entry the map holds", e.key, place)
+		}
+	}
+	walk := func(place string, l *entryList[K, V], small bool) {
+		t.Helper()
+		var prev *entry[K, V]
+		n := 0
+		for e := l.head; e != nil; e = e.next {
+			if n++; n > l.len {
+				t.Fatalf("%s holds more than its len %d entries", place, l.len)
+			}
+			if e.prev != prev {
+				t.Fatalf("key %v in %s has a wrong prev link", e.key, place)
+			}
+			if e.inSmall() != small || e.onDeathRow() {
+				t.Fatalf("key %v in %s has inSmall=%v onDeathRow=%v", e.key, place, e.inSmall(), e.onDeathRow())
+			}
+			inMap(e, place)
+			prev = e
+		}
+		if n != l.len || l.tail != prev {
+			t.Fatalf("%s has %d linked entries and tail %p; want len %d and the last entry", place, n, l.tail, l.len)
+		}
+	}
+	walk("small", &c.small, true)
+	walk("main", &c.main, false)
+
+	for _, s := range c.deathRow {
+		if !s.live() {
+			continue
+		}
+		if !s.ent.onDeathRow() {
+			t.Fatalf("key %v in a death row slot is not flagged onDeathRow", s.ent.key)
+		}
+		inMap(s.ent, "death row")
+	}
+
+	if n := int(c.totalEntries.Value()); n != c.small.len+c.main.len || n > c.capacity {
+		t.Fatalf("totalEntries = %d; want small %d + main %d, at most capacity %d", n, c.small.len, c.main.len, c.capacity)
+	}
+	if n := c.entries.Size(); n != len(where) {
+		t.Fatalf("map holds %d keys; want %d queued or on death row", n, len(where))
+	}
+}
+
+// peek returns key's value if it is live, on death row or not, without
+// touching its frequency or resurrecting it.
+func peek[K comparable, V any](c *s3fifo[K, V], key K) (V, bool) {
+	ref, ok := c.entries.Load(key)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	sl := ref.load()
+	if !sl.live(c.now(), c.epoch.Load()) {
+		var zero V
+		return zero, false
+	}
+	return sl.value, true
+}
+
+// modelEntry is the reference model's record of the last value set for a key.
+type modelEntry struct {
+	val  int
+	live bool // false when set with an expiry already past
+}
+
+// runModel applies the operations encoded in ops to an s3fifo and to a
+// reference map, checking after each that the cache's structure is intact,
+// that it never returns a value other than the last one written, and that it
+// only loses a live key it has reported evicting. Eviction order is S3-FIFO's
+// own, so the model predicts values rather than which keys survive.
+func runModel(t *testing.T, cfgByte byte, ops []byte) {
+	t.Helper()
+	cfg := &config{size: 8 + int(cfgByte%32), exactGhosts: cfgByte&0x80 != 0}
+	c := newS3FIFO[int, int](cfg)
+	keys := 4 * cfg.size
+
+	model := make(map[int]modelEntry)
+	present := make(map[int]bool) // set since last removed; must be readable while live
+	var dropped []int
+	c.onDrop = func(key int, _ uint64, _ int, _ uint32) { dropped = append(dropped, key) }
+
+	farFuture := c.now() + 3600
+	value := func(key, step int) int { return key*1_000_000 + step }
+
+	// expectRead checks a read of key that returned v, ok.
+	expectRead := func(step int, op string, key, v int, ok bool) {
+		t.Helper()
+		m, inModel := model[key]
+		live := inModel && m.live
+		if ok && (!live || v != m.val) {
+			t.Fatalf("step %d: %s(%d) = %d; model has %+v (in model %v)", step, op, key, v, m, inModel)
+		}
+		if !ok && live && present[key] {
+			t.Fatalf("step %d: %s(%d) missed a live key that was never evicted", step, op, key)
+		}
+	}
+	write := func(key, v int, live bool) {
+		model[key] = modelEntry{val: v, live: live}
+		present[key] = true
+	}
+	remove := func(key int) {
+		delete(model, key)
+		delete(present, key)
+	}
+
+	for step := 0; step+1 < len(ops); step += 2 {
+		key := int(ops[step+1]) % keys
+		v := value(key, step)
+		dropped = dropped[:0]
+
+		switch op := ops[step] % 20; {
+		case op < 6:
+			c.set(key, v, 0)
+			write(key, v, true)
+		case op == 6:
+			c.set(key, v, 1) // expired long ago
+			write(key, v, false)
+		case op == 7:
+			c.set(key, v, farFuture)
+			write(key, v, true)
+		case op < 12:
+			got, ok := c.get(key)
+			expectRead(step, "get", key, got, ok)
+		case op == 12:
+			c.del(key)
+			remove(key)
+		case op == 13:
+			got, loaded := c.getOrSet(key, v, 0)
+			expectRead(step, "getOrSet", key, got, loaded)
+			if !loaded {
+				write(key, v, true)
+			}
+		case op == 14:
+			old, existed := c.swap(key, v, 0)
+			expectRead(step, "swap", key, old, existed)
+			write(key, v, true)
+		case op == 15:
+			got, ok := c.getAndDelete(key)
+			expectRead(step, "getAndDelete", key, got, ok)
+			remove(key)
+		case op == 16:
+			for _, k := range c.deleteFunc(func(k, _ int) bool { return k%7 == key%7 }) {
+				if k%7 != key%7 {
+					t.Fatalf("step %d: deleteFunc removed %d, which the predicate rejects", step, k)
+				}
+			}
+			for k := range model {
+				if k%7 == key%7 {
+					remove(k)
+				}
+			}
+		case op == 17:
+			c.flush()
+			clear(model)
+			clear(present)
+		case op == 18:
+			c.bumpEpoch()
+			clear(model)
+			clear(present)
+		default:
+			c.getMulti([]int{key, (key + 1) % keys}, func(k, got int) {
+				expectRead(step, "getMulti", k, got, true)
+			})
+		}
+		for _, k := range dropped {
+			delete(present, k)
+		}
+
+		checkInvariants(t, c)
+		for k := range keys {
+			got, ok := peek(c, k)
+			expectRead(step, "peek", k, got, ok)
+		}
+	}
+}
+
+// FuzzS3FIFO runs operation sequences chosen by the fuzzer against runModel.
+func FuzzS3FIFO(f *testing.F) {
+	f.Add(byte(0), []byte{0, 1, 0, 2, 8, 1, 12, 1, 8, 1})
+	f.Add(byte(0x80), []byte{0, 1, 0, 2, 0, 3, 0, 4, 0, 5, 0, 6, 0, 7, 0, 8, 0, 9, 8, 1, 14, 1, 15, 2, 17, 0})
+	seed := make([]byte, 512)
+	for i := range seed {
+		seed[i] = byte(i * 7919 >> 3)
+	}
+	f.Add(byte(3), seed)
+
+	f.Fuzz(func(t *testing.T, cfgByte byte, ops []byte) {
+		runModel(t, cfgByte, ops)
+	})
+}
+
+// TestS3FIFO_Model runs runModel over long random operation sequences.
+func TestS3FIFO_Model(t *testing.T) {
+	seeds := 40
+	if testing.Short() {
+		seeds = 8
+	}
+	for seed := range uint64(seeds) {
+		rng := rand.New(rand.NewPCG(seed, 0x5eed))
+		ops := make([]byte, 4000)
+		for i := range ops {
+			ops[i] = byte(rng.Uint32())
+		}
+		runModel(t, byte(seed)|byte(seed%2)<<7, ops)
+		if t.Failed() {
+			t.Fatalf("seed %d failed", seed)
+		}
+	}
+}
+
+// TestS3FIFO_ConcurrentInvariants hammers one cache from several goroutines,
+// checking that reads only ever return values written for their own key, and
+// that the bookkeeping is intact once they finish.
+func TestS3FIFO_ConcurrentInvariants(t *testing.T) {
+	for _, exact := range []bool{false, true} {
+		c := newS3FIFO[int, int](&config{size: 64, exactGhosts: exact})
+		const keys = 256
+		iters := 20_000
+		if testing.Short() {
+			iters = 4000
+		}
+
+		var wg sync.WaitGroup
+		for g := range 8 {
+			wg.Go(func() {
+				rng := rand.New(rand.NewPCG(uint64(g), 1))
+				for i := range iters {
+					key := rng.IntN(keys)
+					v := key*1_000_000 + i
+					check := func(op string, got int, ok bool) {
+						if ok && got/1_000_000 != key {
+							t.Errorf("%s(%d) returned %d, written for key %d", op, key, got, got/1_000_000)
+						}
+					}
+					switch rng.IntN(10) {
+					case 0, 1, 2:
+						c.set(key, v, 0)
+					case 3, 4, 5:
+						got, ok := c.get(key)
+						check("get", got, ok)
+					case 6:
+						c.del(key)
+					case 7:
+						got, ok := c.getOrSet(key, v, 0)
+						check("getOrSet", got, ok)
+					case 8:
+						got, ok := c.swap(key, v, 0)
+						check("swap", got, ok)
+					default:
+						got, ok := c.getAndDelete(key)
+						check("getAndDelete", got, ok)
+					}
+				}
+			})
+		}
+		wg.Wait()
+		checkInvariants(t, c)
+	}
+}
@@ -2,6 +2,7 @@ package fido
 
 import (
 	"fmt"
+	"math/rand/v2"
 	"runtime"
 	"sync"
 	"sync/atomic"
@@ -344,6 +345,75 @@ func BenchmarkS3FIFO_SetEvict(b *testing.B) {
 	}
 }
 
+// BenchmarkS3FIFO_DeleteSet benchmarks delete/insert churn, which recycles entries.
+func BenchmarkS3FIFO_DeleteSet(b *testing.B) {
+	cache := newS3FIFO[int, int](&config{size: 10000})
+	for i := range 10000 {
+		cache.set(i, i, 0)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := range b.N {
+		k := i % 10000
+		cache.del(k)
+		cache.set(k, i, 0)
+	}
+}
+
+// BenchmarkS3FIFO_HitRate reports the hit rate on a Zipf workload for each ghost mode.
+func BenchmarkS3FIFO_HitRate(b *testing.B) {
+	for _, mode := range []struct {
+		name  string
+		exact bool
+	}{{"bloom", false}, {"exact", true}} {
+		b.Run(mode.name, func(b *testing.B) {
+			cache := newS3FIFO[uint64, uint64](&config{size: 10000, exactGhosts: mode.exact})
+			zipf := rand.NewZipf(rand.New(rand.NewPCG(1, 2)), 1.01, 1, 1_000_000)
+			var hits int
+			for range b.N {
+				k := zipf.Uint64()
+				if _, ok := cache.get(k); ok {
+					hits++
+					continue
+				}
+				cache.set(k, k, 0)
+			}
+			b.ReportMetric(100*float64(hits)/float64(b.N), "hit%")
+		})
+	}
+}
+
+// BenchmarkS3FIFO_SetEvictUint64 benchmarks Set with eviction using uint64 keys.
+func BenchmarkS3FIFO_SetEvictUint64(b *testing.B) {
+	cache := newS3FIFO[uint64, int](&config{size: 10000})
+	for i := range uint64(10000) {
+		cache.set(i, 0, 0)
+	}
+	b.ResetTimer()
+
+	for i := range b.N {
+		cache.set(uint64(10000+i), i, 0) //nolint:gosec // G115: b.N is non-negative
+	}
+}
+
+// BenchmarkS3FIFO_DeleteMiss benchmarks deleting absent keys alongside readers.
+func BenchmarkS3FIFO_DeleteMiss(b *testing.B) {
+	cache := newS3FIFO[int, int](&config{size: 10000})
+	for i := range 10000 {
+		cache.set(i, i, 0)
+	}
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			cache.del(10000 + i%10000)
+			i++
+		}
+	})
+}
+
 // BenchmarkS3FIFO_SetEvictString benchmarks Set with eviction using string keys.
 func BenchmarkS3FIFO_SetEvictString(b *testing.B) {
 	cache := newS3FIFO[string, int](&config{size: 10000})
@@ -534,6 +604,55 @@ func TestS3FIFO_FlushEmpty(t *testing.T) {
 	}
 }
 
+func TestS3FIFO_FlushCountsLive(t *testing.T) {
+	cache := newS3FIFO[string, int](&config{size: 100})
+	cache.set("live", 1, 0)
+	cache.set("expired", 2, 1) // expired in 1970
+	cache.set("stale", 3, 0)
+	cache.bumpEpoch()
+	cache.set("stale", 3, 0)
+	cache.set("old", 4, 0)
+	cache.bumpEpoch()
+	cache.set("live", 1, 0)
+
+	if removed := cache.flush(); removed != 1 {
+		t.Errorf("flush removed %d; want 1 live entry", removed)
+	}
+}
+
+func TestS3FIFO_FlushOvertakesSet(t *testing.T) {
+	cache := newS3FIFO[string, int](&config{size: 100})
+	cache.set("a", 1, 0)
+
+	// A lock-free update that loaded its entry before the flush lands after it.
+	ref, _ := cache.entries.Load("a") //nolint:errcheck // set above
+	epoch := cache.epoch.Load()
+	cache.flush()
+	cache.updateEntry(ref, 2, 0, epoch)
+	if v, ok := cache.get("a"); ok {
+		t.Errorf("get(a) = %d after flush; want a miss", v)
+	}
+
+	// An insert that waited on the lock while a flush held it is dropped.
+	cache.mu.Lock()
+	done := make(chan struct{})
+	go func() {
+		cache.set("b", 1, 0)
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond) // let set read the epoch and block
+	cache.epoch.Add(1)                // as flush does under the lock
+	cache.mu.Unlock()
+	<-done
+	if _, ok := cache.entries.Load("b"); ok {
+		t.Error("set overtaken by a flush was inserted")
+	}
+	cache.set("b", 3, 0)
+	if v, ok := cache.get("b"); !ok || v != 3 {
+		t.Errorf("get(b) = %d, %v after a later set; want 3, true", v, ok)
+	}
+}
+
 // stringerKey implements fmt.Stringer for testing the Stringer fast path.
 type stringerKey struct {
 	id int
@@ -1474,6 +1593,212 @@ func TestS3FIFO_DeathRowResurrectionTriggersEviction(t *testing.T) {
 	}
 }
 
+// fillDeathRow fills every death row slot with hot entries from the head of small.
+func fillDeathRow[K comparable, V any](t *testing.T, c *s3fifo[K, V]) {
+	t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for range len(c.deathRow) {
+		e := c.small.head
+		if e == nil {
+			t.Fatal("small queue empty")
+		}
+		c.small.remove(e)
+		e.setFreqPeak(0, maxPeakFreq)
+		c.sendToDeathRow(e)
+	}
+}
+
+// checkQueues verifies queue links and counts agree with the cache's view.
+func checkQueues[K comparable, V any](t *testing.T, c *s3fifo[K, V]) {
+	t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	walk := func(l *entryList[K, V]) int {
+		n := 0
+		var prev *entry[K, V]
+		for e := l.head; e != nil; e = e.next {
+			if e.prev != prev {
+				t.Fatalf("broken prev link at %v", e.key)
+			}
+			if e.onDeathRow() {
+				t.Fatalf("queued entry %v marked on death row", e.key)
+			}
+			prev = e
+			n++
+		}
+		if l.tail != prev {
+			t.Fatalf("tail mismatch")
+		}
+		return n
+	}
+	small, main := walk(&c.small), walk(&c.main)
+	if small != c.small.len || main != c.main.len {
+		t.Fatalf("queue lens = %d/%d; walked %d/%d", c.small.len, c.main.len, small, main)
+	}
+	if got := int(c.totalEntries.Value()); got != small+main {
+		t.Fatalf("totalEntries = %d; queues hold %d", got, small+main)
+	}
+}
+
+// deathRowKey returns the key of a live death row entry.
+func deathRowKey[K comparable, V any](t *testing.T, c *s3fifo[K, V]) K {
+	t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, s := range c.deathRow {
+		if s.live() {
+			return s.ent.key
+		}
+	}
+	t.Fatal("death row empty")
+	var zero K
+	return zero
+}
+
+func TestS3FIFO_DeleteOnDeathRow(t *testing.T) {
+	cache := newS3FIFO[int, int](&config{size: 100})
+	for i := range 100 {
+		cache.set(i, i, 0)
+	}
+	fillDeathRow(t, cache)
+	checkQueues(t, cache)
+
+	key := deathRowKey(t, cache)
+	before := cache.len()
+	cache.del(key)
+
+	checkQueues(t, cache)
+	if got := cache.len(); got != before {
+		t.Errorf("len after deleting death row entry = %d; want %d", got, before)
+	}
+	if _, ok := cache.get(key); ok {
+		t.Error("deleted death row entry should not be resurrected")
+	}
+	checkQueues(t, cache)
+}
+
+func TestS3FIFO_SetAfterDeleteOnDeathRow(t *testing.T) {
+	cache := newS3FIFO[int, int](&config{size: 100})
+	for i := range 100 {
+		cache.set(i, i, 0)
+	}
+	fillDeathRow(t, cache)
+
+	key := deathRowKey(t, cache)
+	cache.del(key)
+	cache.set(key, -1, 0)
+
+	// Rotating death row evicts the slot that held the deleted entry.
+	// That must not take the replacement with it.
+	fillDeathRow(t, cache)
+	checkQueues(t, cache)
+
+	if v, ok := cache.get(key); !ok || v != -1 {
+		t.Errorf("get(%d) = %d, %v; want -1, true", key, v, ok)
+	}
+}
+
+func TestS3FIFO_ResurrectStaleEpoch(t *testing.T) {
+	cache := newS3FIFO[int, int](&config{size: 100})
+	for i := range 100 {
+		cache.set(i, i, 0)
+	}
+	fillDeathRow(t, cache)
+	key := deathRowKey(t, cache)
+	before := cache.len()
+
+	cache.epoch.Add(1)
+	if _, ok := cache.get(key); ok {
+		t.Error("entry from an old epoch should not be resurrected")
+	}
+	if got := cache.len(); got != before {
+		t.Errorf("len = %d; want %d", got, before)
+	}
+	checkQueues(t, cache)
+}
+
+func TestS3FIFO_UpdateRetiredEntry(t *testing.T) {
+	cache := newS3FIFO[string, int](&config{size: 100})
+	cache.set("a", 1, 0)
+	ref, ok := cache.entries.Load("a")
+	if !ok {
+		t.Fatal("entry missing")
+	}
+	cache.del("a")
+
+	if ref.e.gen.Load() == ref.gen {
+		t.Error("delete should bump the entry generation")
+	}
+	// A writer that loaded the entry before the delete must not revive it.
+	if cache.updateEntry(ref, 2, 0, cache.epoch.Load()) {
+		t.Error("updateEntry on retired entry should fail")
+	}
+	if ref.load() != nil {
+		t.Error("retired entry should hold no value")
+	}
+
+	cache.set("a", 3, 0)
+	if v, ok := cache.get("a"); !ok || v != 3 {
+		t.Errorf("get(a) = %d, %v; want 3, true", v, ok)
+	}
+	if got := cache.len(); got != 1 {
+		t.Errorf("len = %d; want 1", got)
+	}
+}
+
+func TestS3FIFO_RecycledEntry(t *testing.T) {
+	cache := newS3FIFO[string, int](&config{size: 100})
+	cache.set("a", 1, 0)
+	stale, _ := cache.entries.Load("a")
+	cache.del("a")
+	cache.set("b", 2, 0)
+
+	ref, _ := cache.entries.Load("b")
+	if ref.e != stale.e {
+		t.Fatal("deleted entry should be reused")
+	}
+	// A lock-free reader still holding the old reference must not see b's value.
+	if sl := stale.load(); sl != nil {
+		t.Errorf("stale reference loaded value %d", sl.value)
+	}
+	if cache.updateEntry(stale, 9, 0, cache.epoch.Load()) {
+		t.Error("updateEntry through stale reference should fail")
+	}
+	if v, ok := cache.get("b"); !ok || v != 2 {
+		t.Errorf("get(b) = %d, %v; want 2, true", v, ok)
+	}
+	if _, ok := cache.get("a"); ok {
+		t.Error("a should be gone")
+	}
+}
+
+func TestS3FIFO_RecycleConcurrent(t *testing.T) {
+	cache := newS3FIFO[int, int](&config{size: 64})
+	const keys = 256
+	var wg sync.WaitGroup
+	for w := range 4 {
+		wg.Go(func() {
+			for i := range 20000 {
+				k := (i*7 + w) % keys
+				switch i % 3 {
+				case 0:
+					cache.set(k, k, 0)
+				case 1:
+					cache.del(k)
+				default:
+					if v, ok := cache.get(k); ok && v != k {
+						t.Errorf("get(%d) = %d", k, v)
+						return
+					}
+				}
+			}
+		})
+	}
+	wg.Wait()
+	checkQueues(t, cache)
+}
+
 // TestS3FIFO_DeleteFromMainQueue tests deleting an item that's in the main queue.
 func TestS3FIFO_DeleteFromMainQueue(t *testing.T) {
 	// Use larger cache to ensure items survive eviction
@@ -1768,6 +2093,137 @@ func TestS3FIFO_GhostFreqRing(t *testing.T) {
 	}
 }
 
+// TestS3FIFO_GhostFreqRing_Indexed tests rings large enough to keep a hash index.
+func TestS3FIFO_GhostFreqRing_Indexed(t *testing.T) {
+	ring := newGhostFreqRing(1000)
+	if ring.index == nil {
+		t.Fatal("ring of 1000 should be indexed")
+	}
+
+	ring.add(100, 5)
+	for i := range 998 {
+		ring.add(uint32(1000+i), uint32(i))
+	}
+	// A hash added twice must survive the older copy being overwritten.
+	ring.add(1000, 42)
+
+	if freq, ok := ring.lookup(100); !ok || freq != 5 {
+		t.Errorf("lookup(100) = %d, %v; want 5, true", freq, ok)
+	}
+	ring.add(2000, 1) // overwrites 100
+	if _, ok := ring.lookup(100); ok {
+		t.Error("lookup(100) should return false after wrap")
+	}
+	ring.add(2001, 1) // overwrites the first 1000
+	if freq, ok := ring.lookup(1000); !ok || freq != 42 {
+		t.Errorf("lookup(1000) = %d, %v; want 42, true", freq, ok)
+	}
+	if len(ring.index) > len(ring.hashes) {
+		t.Errorf("index holds %d hashes; want at most %d", len(ring.index), len(ring.hashes))
+	}
+
+	ring.reset()
+	if _, ok := ring.lookup(1000); ok || len(ring.index) != 0 || len(ring.hashes) != 1000 {
+		t.Errorf("after reset: found=%v, index=%d, size=%d", ok, len(ring.index), len(ring.hashes))
+	}
+}
+
+func TestS3FIFO_GhostOptions(t *testing.T) {
+	c := newS3FIFO[int, int](&config{size: 100})
+	if len(c.ghostFreqRng.hashes) != defaultGhostFreqs || c.ghostFPRate != ghostFPRate {
+		t.Errorf("defaults = %d, %v; want %d, %v", len(c.ghostFreqRng.hashes), c.ghostFPRate, defaultGhostFreqs, ghostFPRate)
+	}
+
+	// Large caches scale the ring with the ghost queue.
+	c = newS3FIFO[int, int](&config{size: 1 << 20})
+	if got, want := len(c.ghostFreqRng.hashes), (1<<20)*2225/1000/ghostFreqScale; got != want || got <= defaultGhostFreqs {
+		t.Errorf("auto ring size = %d; want %d", got, want)
+	}
+
+	c = newS3FIFO[int, int](&config{size: 100, ghostFreqs: 4096, ghostFPRate: 0.01})
+	if len(c.ghostFreqRng.hashes) != 4096 || c.ghostFPRate != 0.01 {
+		t.Errorf("configured = %d, %v; want 4096, 0.01", len(c.ghostFreqRng.hashes), c.ghostFPRate)
+	}
+	dflt := newBloomFilter(100, ghostFPRate)
+	if len(c.ghostActive.data) >= len(dflt.data) {
+		t.Errorf("bloom at 1%% FPR has %d words; want fewer than %d", len(c.ghostActive.data), len(dflt.data))
+	}
+
+	// Frequencies remembered past the default ring still restore on readmission.
+	for i := range 2000 {
+		c.set(i, i, 0)
+		c.get(i)
+		c.get(i)
+	}
+	for i := range 1000 {
+		c.set(i+10000, i, 0)
+	}
+	if _, ok := c.ghostFreqRng.lookup(uint32(c.hasher(0))); !ok { //nolint:gosec // G115: ring stores truncated hashes
+		t.Error("key 0 frequency should be remembered by a 4096-entry ring")
+	}
+}
+
+// TestS3FIFO_ExactGhostQueue tests the exact ghost queue used by ExactGhosts,
+// both scanned and indexed by a hash map.
+func TestS3FIFO_ExactGhostQueue(t *testing.T) {
+	indexed := newGhostQueue(3)
+	indexed.counts = make(map[uint64]uint32)
+	for _, q := range []*ghostQueue{newGhostQueue(3), indexed} {
+		q.add(1)
+		q.add(2)
+		q.add(1) // duplicate survives the first copy leaving
+		if !q.contains(1) || !q.contains(2) || q.contains(3) || q.contains(0) {
+			t.Fatalf("indexed=%v: contains wrong before wrap", q.counts != nil)
+		}
+		q.add(3) // evicts first 1
+		if !q.contains(1) {
+			t.Errorf("indexed=%v: 1 should remain: a later copy is still queued", q.counts != nil)
+		}
+		q.add(4) // evicts 2
+		if q.contains(2) {
+			t.Errorf("indexed=%v: 2 should be forgotten after wrap", q.counts != nil)
+		}
+		q.add(5) // evicts second 1
+		if q.contains(1) {
+			t.Errorf("indexed=%v: 1 should be forgotten once every copy has left", q.counts != nil)
+		}
+		if q.counts != nil && len(q.counts) != 3 {
+			t.Errorf("counts holds %d hashes; want 3", len(q.counts))
+		}
+		q.reset()
+		if q.contains(5) || len(q.counts) != 0 {
+			t.Errorf("indexed=%v: reset should forget everything", q.counts != nil)
+		}
+	}
+	if newGhostQueue(maxScanGhostFreqs).counts != nil || newGhostQueue(maxScanGhostFreqs+1).counts == nil {
+		t.Error("only queues over maxScanGhostFreqs should be indexed")
+	}
+}
+
+func TestS3FIFO_ExactGhosts(t *testing.T) {
+	c := newS3FIFO[int, int](&config{size: 100, exactGhosts: true})
+	if c.ghostExact == nil || len(c.ghostExact.hashes) != c.ghostCap {
+		t.Fatalf("ghost queue = %+v; want %d slots", c.ghostExact, c.ghostCap)
+	}
+
+	for i := range 101 {
+		c.set(i, i, 0)
+	}
+	// Key 0 was evicted from small into the ghost queue; readmission goes to main.
+	if !c.ghostExact.contains(c.hasher(0)) {
+		t.Fatal("key 0 should be in the ghost queue")
+	}
+	c.set(0, 0, 0)
+	if ent, ok := c.getEntry(0); !ok || ent.inSmall() {
+		t.Error("key 0 should be readmitted to main")
+	}
+
+	c.flush()
+	if c.ghostExact.contains(c.hasher(0)) {
+		t.Error("ghost queue should be empty after flush")
+	}
+}
+
 // TestS3FIFO_DeleteFromSmallQueue tests deleting an item that's in the small queue.
 func TestS3FIFO_DeleteFromSmallQueue(t *testing.T) {
 	cache := newS3FIFO[int, int](&config{size: 100})
@@ -2072,6 +2528,45 @@ func TestS3FIFO_HashInt64(t *testing.T) {
 	}
 }
 
+// TestS3FIFO_KeyHash tests that specialized key types hash without the
+// generic fallback and agree with the hasher used for eviction bookkeeping.
+func TestS3FIFO_KeyHash(t *testing.T) {
+	if c := newS3FIFO[int, int](&config{size: 10}); c.keyHash(-7) != hashInt64(-7) || c.hasher(-7) != hashInt64(-7) {
+		t.Error("int keys should hash with hashInt64")
+	}
+	if c := newS3FIFO[int64, int](&config{size: 10}); c.keyHash(42) != hashInt64(42) || c.hasher(42) != hashInt64(42) {
+		t.Error("int64 keys should hash with hashInt64")
+	}
+	if c := newS3FIFO[uint64, int](&config{size: 10}); c.keyHash(1<<63) != hashInt64(-1<<63) || c.hasher(1<<63) != hashInt64(-1<<63) {
+		t.Error("uint64 keys should hash their bits with hashInt64")
+	}
+	if c := newS3FIFO[string, int](&config{size: 10}); c.keyHash("k") != hashString("k") || c.hasher("k") != hashString("k") {
+		t.Error("string keys should hash with hashString")
+	}
+	// Other types defer to the hasher inside insert.
+	c := newS3FIFO[uint, int](&config{size: 10})
+	if c.keyHash(5) != 0 {
+		t.Error("uint keys should not take the fast path")
+	}
+	c.set(5, 5, 0)
+	if ent, ok := c.getEntry(5); !ok || ent.hash64 != c.hasher(5) {
+		t.Error("insert should fall back to the hasher")
+	}
+}
+
+// TestS3FIFO_DeleteMissing tests that deleting absent keys leaves the cache intact.
+func TestS3FIFO_DeleteMissing(t *testing.T) {
+	c := newS3FIFO[int, int](&config{size: 10})
+	c.set(1, 1, 0)
+	c.del(2)
+	if _, ok := c.getAndDelete(2); ok {
+		t.Error("getAndDelete(2) found a missing key")
+	}
+	if v, ok := c.get(1); !ok || v != 1 || c.len() != 1 {
+		t.Errorf("get(1) = %d, %v with len %d; want 1, true, 1", v, ok, c.len())
+	}
+}
+
 // TestS3FIFO_EvictFromMainEmpty tests evictFromMain when main is empty.
 func TestS3FIFO_EvictFromMainEmpty(t *testing.T) {
 	cache := newS3FIFO[int, int](&config{size: 100})
@@ -2109,7 +2604,7 @@ func TestS3FIFO_DefaultHasher_Uint(t *testing.T) {
 	}
 }
 
-// TestS3FIFO_DefaultHasher_Uint64 tests the default hasher with uint64 keys.
+// TestS3FIFO_DefaultHasher_Uint64 tests uint64 keys, which use the keyHash fast path.
 func TestS3FIFO_DefaultHasher_Uint64(t *testing.T) {
 	cache := newS3FIFO[uint64, int](&config{size: 100})
 
@@ -2445,13 +2940,13 @@ func TestS3FIFO_EvictionLoop_EmptyQueues(t *testing.T) {
 		e := cache.small.head
 		cache.small.remove(e)
 		cache.entries.Delete(e.key)
-		cache.totalEntries.Add(-1)
+		cache.totalEntries.Dec()
 	}
 	for cache.main.len > 0 {
 		e := cache.main.head
 		cache.main.remove(e)
 		cache.entries.Delete(e.key)
-		cache.totalEntries.Add(-1)
+		cache.totalEntries.Dec()
 	}
 	cache.mu.Unlock()
 
@@ -2464,9 +2959,6 @@ func TestS3FIFO_EvictionLoop_EmptyQueues(t *testing.T) {
 	}
 }
 
-// TestS3FIFO_SetWithHash_DoubleCheck is in s3fifo_seqlock_race_test.go
-// It tests concurrent set operations which trigger seqlock races.
-
 // TestS3FIFO_SetNotFull tests setting when cache is not full (else branch).
 func TestS3FIFO_SetNotFull(t *testing.T) {
 	cache := newS3FIFO[int, int](&config{size: 100})
@@ -2490,8 +2982,8 @@ func TestS3FIFO_SetNotFull(t *testing.T) {
 	}
 
 	// Verify cache is under capacity
-	if cache.totalEntries.Load() >= int64(cache.capacity) {
-		t.Fatalf("cache should be under capacity: %d >= %d", cache.totalEntries.Load(), cache.capacity)
+	if cache.totalEntries.Value() >= int64(cache.capacity) {
+		t.Fatalf("cache should be under capacity: %d >= %d", cache.totalEntries.Value(), cache.capacity)
 	}
 
 	// Now insert new entry - should hit the "else { ent.setInSmall(true) }" path
@@ -2760,49 +3252,180 @@ func TestS3FIFO_GhostEvictionReinsertConsistency(t *testing.T) {
 	t.Logf("Ghost recognition: %d/50 keys went to main queue", mainCount)
 }
 
-// TestEntry_Seqlock_Basic tests basic storeValue/loadValue functionality.
-func TestEntry_Seqlock_Basic(t *testing.T) {
+// TestEntry_Slot_Basic tests basic storeValue/loadValue functionality.
+func TestEntry_Slot_Basic(t *testing.T) {
 	e := &entry[string, int]{}
 
 	// Fresh entry should return false (never stored).
 	if _, ok := e.loadValue(); ok {
 		t.Error("loadValue on fresh entry should return false")
 	}
+	if exp := e.expirySec(); exp != 0 {
+		t.Errorf("expirySec() on fresh entry = %d; want 0", exp)
+	}
 
 	// Store and load.
-	e.storeValue(42)
+	e.storeValue(42, 7, 0, 0)
 	v, ok := e.loadValue()
 	if !ok || v != 42 {
 		t.Errorf("loadValue() = %d, %v; want 42, true", v, ok)
 	}
+	if exp := e.expirySec(); exp != 7 {
+		t.Errorf("expirySec() = %d; want 7", exp)
+	}
 
 	// Overwrite.
-	e.storeValue(100)
+	e.storeValue(100, 0, 0, 0)
 	v, ok = e.loadValue()
 	if !ok || v != 100 {
 		t.Errorf("loadValue() = %d, %v; want 100, true", v, ok)
 	}
 }
 
-// TestEntry_Seqlock_StringValue tests seqlock with string values.
-func TestEntry_Seqlock_StringValue(t *testing.T) {
+// TestEntry_Slot_StringValue tests slots with string values.
+func TestEntry_Slot_StringValue(t *testing.T) {
 	e := &entry[int, string]{}
 
-	e.storeValue("hello")
+	e.storeValue("hello", 0, 0, 0)
 	v, ok := e.loadValue()
 	if !ok || v != "hello" {
 		t.Errorf("loadValue() = %q, %v; want \"hello\", true", v, ok)
 	}
 
-	e.storeValue("world")
+	e.storeValue("world", 0, 0, 0)
 	v, ok = e.loadValue()
 	if !ok || v != "world" {
 		t.Errorf("loadValue() = %q, %v; want \"world\", true", v, ok)
 	}
 }
 
-// Concurrent seqlock tests are in s3fifo_seqlock_race_test.go
-// They are skipped under race detector because seqlocks are benign races.
+// TestEntry_Slot_Live tests expiry and epoch checks on the current slot.
+func TestEntry_Slot_Live(t *testing.T) {
+	e := &entry[int, int]{}
+	if _, ok := e.live(100, 0); ok {
+		t.Error("live() on fresh entry = true; want false")
+	}
+
+	e.storeValue(1, 100, 2, 0)
+	if s, ok := e.live(100, 2); !ok || s.value != 1 {
+		t.Errorf("live(100, 2) = %v, %v; want value 1, true", s, ok)
+	}
+	if _, ok := e.live(101, 2); ok {
+		t.Error("live() after expiry = true; want false")
+	}
+	if _, ok := e.live(100, 3); ok {
+		t.Error("live() with stale epoch = true; want false")
+	}
+
+	e.storeValue(2, 0, 3, 0)
+	if s, ok := e.live(1<<31, 3); !ok || s.value != 2 {
+		t.Errorf("live() without expiry = %v, %v; want value 2, true", s, ok)
+	}
+}
+
+// TestS3FIFO_SetWithHash_DoubleCheck tests the double-check path after lock.
+func TestS3FIFO_SetWithHash_DoubleCheck(t *testing.T) {
+	cache := newS3FIFO[int, int](&config{size: 100})
+
+	const key = 42
+	var wg sync.WaitGroup
+	var setCount atomic.Int32
+
+	// Multiple goroutines try to set the same key
+	for range 100 {
+		wg.Go(func() {
+			cache.set(key, 100, 0)
+			setCount.Add(1)
+		})
+	}
+
+	wg.Wait()
+
+	// Key should exist
+	if val, ok := cache.get(key); !ok || val != 100 {
+		t.Errorf("get(%d) = %v, %v; want 100, true", key, val, ok)
+	}
+}
+
+// TestEntry_Slot_Concurrent tests slots under concurrent read/write.
+func TestEntry_Slot_Concurrent(t *testing.T) {
+	e := &entry[int, int64]{}
+	const iterations = 100000
+
+	var wg sync.WaitGroup
+
+	// Writer goroutine: stores incrementing values.
+	wg.Go(func() {
+		for i := int64(1); i <= iterations; i++ {
+			e.storeValue(i, 0, 0, 0)
+		}
+	})
+
+	// Reader goroutines: verify values are valid.
+	for range 4 {
+		wg.Go(func() {
+			for range iterations {
+				v, ok := e.loadValue()
+				if ok {
+					// Value should be in valid range [1, iterations].
+					if v < 1 || v > iterations {
+						t.Errorf("loadValue() = %d; out of range [1, %d]", v, iterations)
+						return
+					}
+				}
+			}
+		})
+	}
+
+	wg.Wait()
+
+	// Final value should be iterations.
+	v, ok := e.loadValue()
+	if !ok || v != iterations {
+		t.Errorf("final loadValue() = %d, %v; want %d, true", v, ok, iterations)
+	}
+}
+
+// wideValue is larger than a machine word, so an in-place copy could tear.
+type wideValue struct {
+	a, b, c, d uint32
+}
+
+// TestEntry_Slot_Consistent verifies that concurrent readers see each value
+// whole, together with the expiry and epoch written alongside it.
+func TestEntry_Slot_Consistent(t *testing.T) {
+	e := &entry[int, wideValue]{}
+	const writers = 4
+	const perWriter = 10000
+
+	var wg sync.WaitGroup
+	for w := range writers {
+		wg.Go(func() {
+			for i := range perWriter {
+				n := uint32(w*perWriter + i + 1) //nolint:gosec // G115: small test values
+				e.storeValue(wideValue{n, n, n, n}, n, n, 0)
+			}
+		})
+	}
+
+	for range 4 {
+		wg.Go(func() {
+			for range perWriter {
+				s := e.slot.Load()
+				if s == nil {
+					continue
+				}
+				v := s.value
+				if v.b != v.a || v.c != v.a || v.d != v.a || s.expirySec != v.a || s.epoch != v.a {
+					t.Errorf("torn read: value %+v, expiry %d, epoch %d", v, s.expirySec, s.epoch)
+					return
+				}
+			}
+		})
+	}
+
+	wg.Wait()
+}
 
 // TestSmallRatio verifies the small queue ratio at tuning points.
 // Values determined via binary search on hitrate benchmarks.
@@ -2963,3 +3586,62 @@ func TestGhostSize(t *testing.T) {
 		}
 	}
 }
+
+func TestS3FIFO_GetOrSet_DeathRow(t *testing.T) {
+	cache := newS3FIFO[int, int](&config{size: 100})
+	for i := range 100 {
+		cache.set(i, i, 0)
+	}
+	fillDeathRow(t, cache)
+
+	key := deathRowKey(t, cache)
+	if v, loaded := cache.getOrSet(key, -1, 0); !loaded || v != key {
+		t.Errorf("getOrSet on live death row entry = %d, %v; want %d, true", v, loaded, key)
+	}
+	checkQueues(t, cache)
+
+	key = deathRowKey(t, cache)
+	cache.epoch.Add(1)
+	before := cache.len()
+	if v, loaded := cache.getOrSet(key, -1, 0); loaded || v != -1 {
+		t.Errorf("getOrSet on stale death row entry = %d, %v; want -1, false", v, loaded)
+	}
+	checkQueues(t, cache)
+	if got := cache.len(); got != before+1 {
+		t.Errorf("len = %d; want %d", got, before+1)
+	}
+	fillDeathRow(t, cache)
+	if v, ok := cache.get(key); !ok || v != -1 {
+		t.Errorf("get(%d) = %d, %v; want -1, true", key, v, ok)
+	}
+}
+
+func TestS3FIFO_SwapAndTake_DeathRow(t *testing.T) {
+	cache := newS3FIFO[int, int](&config{size: 100})
+	for i := range 100 {
+		cache.set(i, i, 0)
+	}
+	fillDeathRow(t, cache)
+
+	key := deathRowKey(t, cache)
+	if old, existed := cache.swap(key, -1, 0); !existed || old != key {
+		t.Errorf("swap on death row entry = %d, %v; want %d, true", old, existed, key)
+	}
+	checkQueues(t, cache)
+	if v, ok := cache.get(key); !ok || v != -1 {
+		t.Errorf("get(%d) = %d, %v; want -1, true", key, v, ok)
+	}
+
+	key = deathRowKey(t, cache)
+	before := cache.len()
+	if v, ok := cache.getAndDelete(key); !ok || v != key {
+		t.Errorf("getAndDelete on death row entry = %d, %v; want %d, true", v, ok, key)
+	}
+	checkQueues(t, cache)
+	if got := cache.len(); got != before {
+		t.Errorf("len = %d; want %d", got, before)
+	}
+	if _, ok := cache.get(key); ok {
+		t.Error("entry should be gone")
+	}
+}
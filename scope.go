@@ -0,0 +1,102 @@
+package fido
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Scope is a view of a Cache that records the keys written through it, so a
+// request can undo its cache writes if it fails. Reads and writes go straight
+// to the cache; unlike Child, other callers see them immediately.
+//
+// The record is dropped when the scope's context ends or Release is called.
+// After that the scope still passes operations through but stops recording.
+//
+//nolint:govet // fieldalignment: semantic grouping preferred
+type Scope[K comparable, V any] struct {
+	cache   *Cache[K, V]
+	mu      sync.Mutex
+	touched map[K]struct{} // nil once released
+	stop    func() bool
+}
+
+// Scope returns a view of c that records keys written through it until ctx ends.
+func (c *Cache[K, V]) Scope(ctx context.Context) *Scope[K, V] {
+	s := &Scope[K, V]{cache: c, touched: make(map[K]struct{})}
+	// Hold mu so a Release from an already-ended ctx waits for stop to be set.
+	s.mu.Lock()
+	s.stop = context.AfterFunc(ctx, s.Release)
+	s.mu.Unlock()
+	return s
+}
+
+func (s *Scope[K, V]) touch(key K) {
+	s.mu.Lock()
+	if s.touched != nil {
+		s.touched[key] = struct{}{}
+	}
+	s.mu.Unlock()
+}
+
+// Get returns the cached value for key. Reads are not recorded.
+func (s *Scope[K, V]) Get(key K) (V, bool) {
+	return s.cache.Get(key)
+}
+
+// Set stores a value using the cache's default TTL and records key.
+func (s *Scope[K, V]) Set(key K, value V) {
+	s.touch(key)
+	s.cache.Set(key, value)
+}
+
+// SetTTL stores a value with an explicit TTL and records key.
+// A zero or negative TTL means the entry never expires.
+func (s *Scope[K, V]) SetTTL(key K, value V, ttl time.Duration) {
+	s.touch(key)
+	s.cache.SetTTL(key, value, ttl)
+}
+
+// Delete removes key from the cache and records it.
+func (s *Scope[K, V]) Delete(key K) {
+	s.touch(key)
+	s.cache.Delete(key)
+}
+
+// Touched returns the keys written through the scope, in no particular order.
+func (s *Scope[K, V]) Touched() []K {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]K, 0, len(s.touched))
+	for k := range s.touched {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// InvalidateTouched deletes every key written through the scope from the cache,
+// including keys it set that another caller has since overwritten, and returns
+// how many keys it deleted. The record is cleared, so the scope can be reused.
+func (s *Scope[K, V]) InvalidateTouched() int {
+	s.mu.Lock()
+	touched := s.touched
+	if touched != nil {
+		s.touched = make(map[K]struct{})
+	}
+	s.mu.Unlock()
+
+	for k := range touched {
+		s.cache.Delete(k)
+	}
+	return len(touched)
+}
+
+// Release drops the record of touched keys and stops recording. It is called
+// automatically when the scope's context ends and is safe to call more than once.
+func (s *Scope[K, V]) Release() {
+	s.mu.Lock()
+	s.touched = nil
+	stop := s.stop
+	s.mu.Unlock()
+	stop()
+}
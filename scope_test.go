@@ -0,0 +1,91 @@
+package fido
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestScope_InvalidateTouched(t *testing.T) {
+	cache := New[string, int]()
+	cache.Set("other", 1)
+	cache.Set("gone", 2)
+
+	s := cache.Scope(context.Background())
+	defer s.Release()
+	s.Set("a", 1)
+	s.SetTTL("b", 2, time.Hour)
+	s.Delete("gone")
+	if v, ok := s.Get("other"); !ok || v != 1 {
+		t.Errorf("Get(other) = %d, %v; want 1, true", v, ok)
+	}
+	// Writes are visible to everyone immediately.
+	if v, ok := cache.Get("a"); !ok || v != 1 {
+		t.Errorf("cache.Get(a) = %d, %v; want 1, true", v, ok)
+	}
+
+	touched := s.Touched()
+	slices.Sort(touched)
+	if !slices.Equal(touched, []string{"a", "b", "gone"}) {
+		t.Errorf("Touched() = %v; want [a b gone]", touched)
+	}
+
+	if n := s.InvalidateTouched(); n != 3 {
+		t.Errorf("InvalidateTouched() = %d; want 3", n)
+	}
+	for _, k := range []string{"a", "b"} {
+		if _, ok := cache.Get(k); ok {
+			t.Errorf("cache.Get(%s) should miss after InvalidateTouched", k)
+		}
+	}
+	if _, ok := cache.Get("other"); !ok {
+		t.Error("InvalidateTouched removed a key the scope only read")
+	}
+
+	// The record starts over.
+	s.Set("c", 3)
+	if got := s.Touched(); !slices.Equal(got, []string{"c"}) {
+		t.Errorf("Touched() = %v after invalidate; want [c]", got)
+	}
+}
+
+func TestScope_ReleaseOnContextEnd(t *testing.T) {
+	cache := New[string, int]()
+	ctx, cancel := context.WithCancel(context.Background())
+	s := cache.Scope(ctx)
+	s.Set("a", 1)
+
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for len(s.Touched()) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("scope was not released when its context ended")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// A released scope passes writes through without recording them.
+	s.Set("b", 2)
+	if n := s.InvalidateTouched(); n != 0 {
+		t.Errorf("InvalidateTouched() = %d after release; want 0", n)
+	}
+	if _, ok := cache.Get("b"); !ok {
+		t.Error("write through a released scope should still reach the cache")
+	}
+	s.Release() // idempotent
+
+	// A scope over an already-ended context is released at once.
+	s = cache.Scope(ctx)
+	deadline = time.Now().Add(time.Second)
+	for {
+		s.Set("c", 3)
+		if s.InvalidateTouched() == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("scope over an ended context kept recording")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,115 @@
+package fido
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ErrClosed is returned by SetAsync and DeleteAsync once Shutdown or Close has
+// begun, so no new write reaches the store after it is closed.
+var ErrClosed = errors.New("cache is closed")
+
+// shutdownTimeout bounds CloseOnSignal's Shutdown. Cloud Run sends SIGTERM and
+// kills the instance 10 seconds later; the margin leaves time for the caller.
+const shutdownTimeout = 8 * time.Second
+
+// Shutdown refuses new SetAsync and DeleteAsync calls with ErrClosed, drains
+// those in flight, persists the memory tier if SnapshotOnShutdown is set, then
+// closes the cache. Draining and snapshotting stop when ctx is done; the cache
+// is closed regardless and ctx's error is returned if work was cut short.
+func (c *TieredCache[K, V]) Shutdown(ctx context.Context) error {
+	c.closeAsync()
+	drained := make(chan struct{})
+	go func() {
+		c.async.Wait()
+		close(drained)
+	}()
+
+	var err error
+	select {
+	case <-drained:
+		if c.snapshot {
+			err = c.persistMemory(ctx)
+		}
+		err = errors.Join(err, c.hotKeys.save(ctx))
+	case <-ctx.Done():
+		err = fmt.Errorf("drain async writes: %w", ctx.Err())
+	}
+	return errors.Join(err, c.Close())
+}
+
+// beginAsync read-locks asyncMu for an async write to start under, or returns
+// ErrClosed. The caller unlocks once the write's goroutine is started.
+func (c *TieredCache[K, V]) beginAsync() error {
+	c.asyncMu.RLock()
+	if c.closed {
+		c.asyncMu.RUnlock()
+		return ErrClosed
+	}
+	return nil
+}
+
+// closeAsync refuses async writes from now on. Every write admitted before is
+// already started when it returns, so c.async.Wait can no longer race an Add.
+func (c *TieredCache[K, V]) closeAsync() {
+	c.asyncMu.Lock()
+	c.closed = true
+	c.asyncMu.Unlock()
+}
+
+// persistMemory writes every live memory entry to the store, except values
+// stored with SetMemoryOnly.
+func (c *TieredCache[K, V]) persistMemory(ctx context.Context) error {
+	var errs []error
+	c.memory.eachPersistable(func(key K, value V, expirySec uint32) bool {
+		if ctx.Err() != nil {
+			return false
+		}
+		var expiry time.Time
+		if expirySec != 0 {
+			expiry = time.Unix(int64(expirySec), 0)
+		}
+		if err := c.Store.Set(ctx, key, value, expiry); err != nil {
+			errs = append(errs, fmt.Errorf("snapshot %v: %w", key, err))
+		}
+		return true
+	})
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, fmt.Errorf("snapshot memory: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// CloseOnSignal calls Shutdown, bounded to 8 seconds to fit Cloud Run's
+// 10-second termination window, when the process receives one of sigs
+// (default SIGTERM and os.Interrupt). The returned channel receives
+// Shutdown's result. Cancelling ctx stops listening without shutting down.
+//
+// Signal delivery is redirected to the cache, so the process no longer exits
+// on its own: callers should wait on the channel and then exit.
+func (c *TieredCache[K, V]) CloseOnSignal(ctx context.Context, sigs ...os.Signal) <-chan error {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGTERM, os.Interrupt}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+
+	result := make(chan error, 1)
+	go func() {
+		defer signal.Stop(ch)
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return
+		}
+		sctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+		defer cancel()
+		result <- c.Shutdown(sctx)
+	}()
+	return result
+}
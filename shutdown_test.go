@@ -0,0 +1,182 @@
+package fido
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTieredCache_Shutdown_DrainsAsync(t *testing.T) {
+	ctx := context.Background()
+	store := &gatedMockStore[string, int]{mockStore: newMockStore[string, int](), gate: make(chan struct{})}
+
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	if err := cache.SetAsync(ctx, "key1", 1); err != nil {
+		t.Fatalf("SetAsync: %v", err)
+	}
+
+	time.AfterFunc(20*time.Millisecond, func() { close(store.gate) })
+	if err := cache.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if val, _, found, err := store.mockStore.Get(ctx, "key1"); err != nil || !found || val != 1 {
+		t.Errorf("store.Get(key1) = %d, %v, %v; want async write drained", val, found, err)
+	}
+	if !store.closed {
+		t.Error("store should be closed after Shutdown")
+	}
+}
+
+func TestTieredCache_Shutdown_RefusesAsync(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[int, int]()
+	cache, err := NewTiered[int, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+
+	// Writers racing Shutdown either land before it returns or get ErrClosed.
+	var wg sync.WaitGroup
+	var accepted sync.Map
+	for w := range 4 {
+		wg.Go(func() {
+			for i := w * 1000; i < (w+1)*1000; i++ {
+				err := cache.SetAsync(ctx, i, i)
+				if errors.Is(err, ErrClosed) {
+					return
+				}
+				if err != nil {
+					t.Errorf("SetAsync(%d): %v", i, err)
+					return
+				}
+				accepted.Store(i, true)
+			}
+		})
+	}
+	time.Sleep(time.Millisecond)
+	if err := cache.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	wg.Wait()
+	accepted.Range(func(k, _ any) bool {
+		if _, _, found, _ := store.Get(ctx, k.(int)); !found { //nolint:errcheck,forcetypeassert // mock never fails; keys are ints
+			t.Errorf("accepted SetAsync(%v) never reached the store", k)
+		}
+		return true
+	})
+
+	if err := cache.SetAsync(ctx, 1, 1); !errors.Is(err, ErrClosed) {
+		t.Errorf("SetAsync after Shutdown = %v; want ErrClosed", err)
+	}
+	if err := cache.DeleteAsync(ctx, 1); !errors.Is(err, ErrClosed) {
+		t.Errorf("DeleteAsync after Shutdown = %v; want ErrClosed", err)
+	}
+}
+
+func TestTieredCache_Shutdown_Deadline(t *testing.T) {
+	store := &gatedMockStore[string, int]{mockStore: newMockStore[string, int](), gate: make(chan struct{})}
+	defer close(store.gate)
+
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	if err := cache.SetAsync(context.Background(), "key1", 1); err != nil {
+		t.Fatalf("SetAsync: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := cache.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown = %v; want DeadlineExceeded", err)
+	}
+	if !store.closed {
+		t.Error("store should be closed even when draining times out")
+	}
+}
+
+func TestTieredCache_Shutdown_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+
+	cache, err := NewTiered[string, int](store, SnapshotOnShutdown())
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	// Entries only memory holds, as Prefetch or a failed SetAsync leaves them.
+	cache.memory.set("mem", 1, timeToSec(time.Now().Add(time.Hour)))
+	cache.memory.set("forever", 2, 0)
+	if err := cache.SetMemoryOnly(ctx, "secret", 3, 0); err != nil {
+		t.Fatalf("SetMemoryOnly: %v", err)
+	}
+
+	if err := cache.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	val, expiry, found, err := store.Get(ctx, "mem")
+	if err != nil || !found || val != 1 {
+		t.Fatalf("store.Get(mem) = %d, %v, %v; want snapshot", val, found, err)
+	}
+	if expiry.IsZero() || expiry.After(time.Now().Add(time.Hour+time.Second)) {
+		t.Errorf("snapshot expiry = %v; want about an hour from now", expiry)
+	}
+	if _, expiry, found, _ := store.Get(ctx, "forever"); !found || !expiry.IsZero() { //nolint:errcheck // checked via found
+		t.Errorf("store.Get(forever) = %v, %v; want snapshot without expiry", found, expiry)
+	}
+	if _, _, found, _ := store.Get(ctx, "secret"); found { //nolint:errcheck // only checking presence
+		t.Error("snapshot persisted a SetMemoryOnly value")
+	}
+}
+
+func TestTieredCache_Shutdown_SnapshotSkipsLargeMemoryOnly(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, string]()
+
+	cache, err := NewTiered[string, string](store, SnapshotOnShutdown(), LargeObjects(8, 1<<20))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	if err := cache.SetMemoryOnly(ctx, "secret", "a large sensitive value", 0); err != nil {
+		t.Fatalf("SetMemoryOnly: %v", err)
+	}
+	cache.memory.set("shared", "a large shared value", 0)
+	if err := cache.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if _, _, found, _ := store.Get(ctx, "secret"); found { //nolint:errcheck // only checking presence
+		t.Error("snapshot persisted a large SetMemoryOnly value")
+	}
+	if _, _, found, _ := store.Get(ctx, "shared"); !found { //nolint:errcheck // only checking presence
+		t.Error("snapshot skipped a large value only memory held")
+	}
+}
+
+func TestTieredCache_CloseOnSignal_CtxCancel(t *testing.T) {
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := cache.CloseOnSignal(ctx)
+	cancel()
+
+	select {
+	case err := <-result:
+		t.Fatalf("CloseOnSignal shut down without a signal: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	store.mu.Lock()
+	closed := store.closed
+	store.mu.Unlock()
+	if closed {
+		t.Error("store should stay open when ctx is cancelled")
+	}
+}
@@ -0,0 +1,35 @@
+//go:build unix
+
+package fido
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestTieredCache_CloseOnSignal(t *testing.T) {
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+
+	result := cache.CloseOnSignal(context.Background(), syscall.SIGUSR1)
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatalf("Kill: %v", err)
+	}
+
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Errorf("Shutdown: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("CloseOnSignal did not shut down on signal")
+	}
+	if !store.closed {
+		t.Error("store should be closed after signal")
+	}
+}
@@ -0,0 +1,195 @@
+package fido
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	statsBucketSec = 10  // seconds per rolling-window bucket
+	statsBuckets   = 360 // one hour of buckets
+
+	// maxStatsStripes caps CPULocalStats, each stripe being about 9KB of buckets.
+	maxStatsStripes = 16
+)
+
+// Stats summarizes Get and Fetch lookups. A hit is a value served from any tier
+// without calling a loader. Lookup counts are zero unless the cache was created
+// with TrackStats.
+type Stats struct {
+	Hits   uint64 // since creation
+	Misses uint64
+
+	HitRate   float64 // since creation
+	HitRate1m float64 // over the last minute
+	HitRate5m float64 // over the last five minutes
+	HitRate1h float64 // over the last hour
+
+	// A TieredCache's SetAsync and DeleteAsync writes, counted without
+	// TrackStats. TotalStats doesn't sum them.
+	AsyncQueued   int    // admitted and not yet finished, as bounded by AsyncQueue
+	AsyncRejected uint64 // refused with ErrBackpressure
+	AsyncDropped  uint64 // dropped by BackpressureDropOldest
+}
+
+// hitStats counts hits and misses in total and in 10-second buckets of a
+// one-hour ring, so recent hit rates aren't hidden by lifetime averages.
+// A nil *hitStats records nothing. With CPULocalStats, it only routes each
+// record to one of its stripes, and reads sum them.
+type hitStats struct {
+	hits, misses atomic.Uint64
+	ring         [statsBuckets]statsBucket
+	now          func() time.Time
+
+	stripes []*hitStats // nil unless CPULocalStats
+	pick    sync.Pool   // of *int stripe indexes; its per-P cache keeps each P on one stripe
+	next    atomic.Uint32
+}
+
+// statsBucket holds counts for one 10-second slot. A bucket whose slot is stale
+// is reset by the first record in its new slot; counts racing with the reset
+// may be lost, which is acceptable for rates.
+type statsBucket struct {
+	slot   atomic.Int64
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+func newHitStats(cfg *config) *hitStats {
+	if !cfg.trackStats {
+		return nil
+	}
+	s := &hitStats{now: time.Now}
+	if n := statsStripes(cfg); n > 0 {
+		s.stripes = make([]*hitStats, n)
+		for i := range s.stripes {
+			s.stripes[i] = &hitStats{now: time.Now}
+		}
+		s.pick.New = func() any {
+			i := int(s.next.Add(1)) % len(s.stripes)
+			return &i
+		}
+	}
+	return s
+}
+
+// statsStripes returns how many stripes CPULocalStats gives TrackStats, or 0.
+func statsStripes(cfg *config) int {
+	if !cfg.trackStats || !cfg.cpuLocalStats {
+		return 0
+	}
+	return min(runtime.GOMAXPROCS(0), maxStatsStripes)
+}
+
+// stripe returns the stripe for the calling goroutine's P. sync.Pool hands a
+// P back the index it last put, so lookups on one core keep to one stripe; an
+// index dropped by GC is replaced round-robin.
+func (s *hitStats) stripe() *hitStats {
+	i := s.pick.Get().(*int) //nolint:errcheck,forcetypeassert // pick only holds *int
+	st := s.stripes[*i]
+	s.pick.Put(i)
+	return st
+}
+
+func (s *hitStats) record(hit bool) {
+	if s == nil {
+		return
+	}
+	if s.stripes != nil {
+		s = s.stripe()
+	}
+	slot := s.now().Unix() / statsBucketSec
+	b := &s.ring[slot%statsBuckets]
+	if old := b.slot.Load(); old != slot && b.slot.CompareAndSwap(old, slot) {
+		b.hits.Store(0)
+		b.misses.Store(0)
+	}
+	if hit {
+		s.hits.Add(1)
+		b.hits.Add(1)
+	} else {
+		s.misses.Add(1)
+		b.misses.Add(1)
+	}
+}
+
+// window sums the most recent n buckets, including the current partial one.
+func (s *hitStats) window(n int64) (hits, misses uint64) {
+	now := s.now().Unix() / statsBucketSec
+	for slot := now - n + 1; slot <= now; slot++ {
+		b := &s.ring[slot%statsBuckets]
+		if b.slot.Load() != slot {
+			continue
+		}
+		hits += b.hits.Load()
+		misses += b.misses.Load()
+	}
+	return hits, misses
+}
+
+func (s *hitStats) stats() Stats {
+	return sumStats(s)
+}
+
+// sumStats combines the counts of each of ss into one Stats, so rates are
+// over all their lookups. Nil entries are skipped.
+func sumStats(ss ...*hitStats) Stats {
+	var total, m1, m5, h1 lookups
+	for _, s := range ss {
+		if s == nil {
+			continue
+		}
+		parts := s.stripes
+		if parts == nil {
+			parts = []*hitStats{s}
+		}
+		for _, s := range parts {
+			total.add(s.hits.Load(), s.misses.Load())
+			m1.add(s.window(60 / statsBucketSec))
+			m5.add(s.window(300 / statsBucketSec))
+			h1.add(s.window(statsBuckets))
+		}
+	}
+	return Stats{
+		Hits:      total.hits,
+		Misses:    total.misses,
+		HitRate:   total.rate(),
+		HitRate1m: m1.rate(),
+		HitRate5m: m5.rate(),
+		HitRate1h: h1.rate(),
+	}
+}
+
+// lookups accumulates hit and miss counts.
+type lookups struct{ hits, misses uint64 }
+
+func (l *lookups) add(hits, misses uint64) {
+	l.hits += hits
+	l.misses += misses
+}
+
+func (l *lookups) rate() float64 { return hitRate(l.hits, l.misses) }
+
+func hitRate(hits, misses uint64) float64 {
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
+
+// Stats returns hit and miss counts and rates. Requires TrackStats.
+func (c *Cache[K, V]) Stats() Stats {
+	return c.stats.stats()
+}
+
+// Stats returns hit and miss counts and rates, which require TrackStats, and
+// the async write queue's depth and back-pressure counts.
+func (c *TieredCache[K, V]) Stats() Stats {
+	s := c.stats.stats()
+	s.AsyncQueued = int(c.queue.depth.Load())
+	s.AsyncRejected = c.queue.rejected.Load()
+	s.AsyncDropped = c.queue.dropped.Load()
+	return s
+}
@@ -0,0 +1,121 @@
+package fido
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCache_Stats(t *testing.T) {
+	cache := New[string, int](TrackStats())
+	cache.Set("a", 1)
+	cache.Get("a")
+	cache.Get("a")
+	cache.Get("missing")
+	if _, err := cache.Fetch("b", func() (int, error) { return 2, nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	s := cache.Stats()
+	if s.Hits != 2 || s.Misses != 2 || s.HitRate != 0.5 || s.HitRate1m != 0.5 || s.HitRate1h != 0.5 {
+		t.Errorf("Stats = %+v; want 2 hits, 2 misses, rate 0.5", s)
+	}
+
+	if got := New[string, int]().Stats(); got != (Stats{}) {
+		t.Errorf("Stats without TrackStats = %+v; want zero", got)
+	}
+}
+
+func TestCache_CPULocalStats(t *testing.T) {
+	cache := New[string, int](TrackStats(), CPULocalStats())
+	if got, want := cache.Config().StatsStripes, min(runtime.GOMAXPROCS(0), maxStatsStripes); got != want {
+		t.Errorf("Config().StatsStripes = %d; want %d", got, want)
+	}
+	cache.Set("a", 1)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 1000 {
+				cache.Get("a")
+				cache.Get("missing")
+			}
+		})
+	}
+	wg.Wait()
+
+	if s := cache.Stats(); s.Hits != 8000 || s.Misses != 8000 || s.HitRate1m != 0.5 {
+		t.Errorf("Stats = %+v; want 8000 hits, 8000 misses, rate 0.5", s)
+	}
+	if got := New[string, int](TrackStats()).Config().StatsStripes; got != 0 {
+		t.Errorf("StatsStripes without CPULocalStats = %d; want 0", got)
+	}
+}
+
+func TestTieredCache_Stats(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	_ = store.Set(ctx, "persisted", 1, time.Time{}) //nolint:errcheck // Test fixture
+
+	cache, err := NewTiered[string, int](store, TrackStats())
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	cache.Get(ctx, "persisted")         //nolint:errcheck // counting only
+	cache.GetWithInfo(ctx, "persisted") //nolint:errcheck // counting only
+	cache.Get(ctx, "missing")           //nolint:errcheck // counting only
+	if _, err := cache.Fetch(ctx, "loaded", func(context.Context) (int, error) { return 3, nil }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.Fetch(ctx, "loaded", func(context.Context) (int, error) { return 3, nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := cache.Stats(); s.Hits != 3 || s.Misses != 2 {
+		t.Errorf("Stats = %+v; want 3 hits, 2 misses", s)
+	}
+}
+
+func TestHitStats_Windows(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+	s := &hitStats{now: func() time.Time { return now }}
+
+	// An hour ago: all misses. Recently: all hits.
+	for range 10 {
+		s.record(false)
+	}
+	now = now.Add(50 * time.Minute)
+	for range 10 {
+		s.record(true)
+	}
+	now = now.Add(3 * time.Minute)
+	for range 10 {
+		s.record(true)
+	}
+
+	got := s.stats()
+	if got.HitRate1m != 1 || got.HitRate5m != 1 {
+		t.Errorf("recent rates = %v, %v; want 1", got.HitRate1m, got.HitRate5m)
+	}
+	if want := 20.0 / 30; got.HitRate1h != want || got.HitRate != want {
+		t.Errorf("HitRate1h, HitRate = %v, %v; want %v", got.HitRate1h, got.HitRate, want)
+	}
+
+	// Once the misses age out of the hour, they leave the window but not the lifetime rate.
+	now = now.Add(10 * time.Minute)
+	got = s.stats()
+	if got.HitRate1h != 1 || got.HitRate1m != 0 {
+		t.Errorf("after aging: HitRate1h = %v, HitRate1m = %v; want 1, 0", got.HitRate1h, got.HitRate1m)
+	}
+
+	// A bucket reused after wrapping around the ring starts from zero.
+	now = now.Add(time.Hour)
+	s.record(false)
+	if got := s.stats(); got.HitRate1m != 0 || got.HitRate1h != 0 {
+		t.Errorf("after wrap: %+v; want only the new miss in windows", got)
+	}
+}
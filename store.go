@@ -2,11 +2,18 @@ package fido
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"iter"
 	"time"
 )
 
 // Store is the persistence backend interface.
+//
+// Get reports an entry that exists but cannot be decoded, such as a truncated
+// file, with an error that has a Corrupt method returning true. TieredCache
+// uses it for read repair; stores in other modules define their own error type.
 type Store[K comparable, V any] interface {
 	ValidateKey(key K) error
 	Get(ctx context.Context, key K) (V, time.Time, bool, error)
@@ -18,6 +25,12 @@ type Store[K comparable, V any] interface {
 	Close() error
 }
 
+// isCorrupt reports whether err marks a stored entry as undecodable.
+func isCorrupt(err error) bool {
+	var c interface{ Corrupt() bool }
+	return errors.As(err, &c) && c.Corrupt()
+}
+
 // PrefixScanner is an optional interface for stores that support efficient prefix iteration.
 // Only meaningful for Store[string, V].
 type PrefixScanner[V any] interface {
@@ -29,3 +42,155 @@ type PrefixScanner[V any] interface {
 	// More expensive than Keys: loads and decodes values from storage.
 	Range(ctx context.Context, prefix string) iter.Seq2[string, V]
 }
+
+// EpochBumper is an optional interface for stores that can invalidate all entries
+// in O(1), typically by namespacing keys with an epoch number. Stores without it
+// are flushed by TieredCache.BumpEpoch instead.
+type EpochBumper interface {
+	// BumpEpoch makes all existing entries unreachable and returns the new epoch.
+	BumpEpoch(ctx context.Context) (uint64, error)
+}
+
+// Versioner is an optional interface for stores that can namespace entries by
+// application version, so a build never decodes entries written by another
+// build with incompatible value types. TieredCache uses it for the Version option.
+type Versioner interface {
+	// SetVersion namespaces later operations under version, making entries
+	// written under other versions unreachable. The empty version is the
+	// store's original namespace.
+	SetVersion(version string) error
+
+	// PurgeVersions deletes entries that are unreachable under the current
+	// version and epoch, and returns how many it removed.
+	PurgeVersions(ctx context.Context) (int, error)
+}
+
+// Locator is an optional interface for stores that can name where a key is
+// kept, such as a file path or Valkey key. TieredCache uses it for Locations.
+type Locator[K comparable] interface {
+	// Location returns where key's entry is or would be stored.
+	Location(key K) string
+}
+
+// KeyLimiter is an optional interface for stores that reject keys over a
+// length limit. TieredCache uses it to report the strictest limit across its
+// tiers in Config.MaxKeyLength, and for the HashLongKeys option.
+type KeyLimiter interface {
+	// MaxKeyLength returns the longest key, in bytes of its %v form, the store accepts.
+	MaxKeyLength() int
+}
+
+// OwnerTagger is an optional interface for shared stores that can tag
+// entries with an owner, such as the team that wrote them. TieredCache uses
+// it for WithOwner, FlushOwner, CleanupOwner, and OwnerLen.
+type OwnerTagger[K comparable, V any] interface {
+	// SetOwned is Set, tagging the entry with owner.
+	SetOwned(ctx context.Context, key K, value V, expiry time.Time, owner string) error
+
+	// OwnerKeys returns an iterator over the keys of entries tagged with
+	// owner, including expired entries not yet cleaned up. Only meaningful
+	// for string keys.
+	OwnerKeys(ctx context.Context, owner string) iter.Seq[string]
+}
+
+// FallbackDecoder is an optional interface for stores that decode values
+// themselves. TieredCache uses it for the DecodeFallback option.
+type FallbackDecoder[V any] interface {
+	// SetDecodeFallback sets a function that decodes a stored value the
+	// store's own codec rejects. raw is the value's encoding as the store
+	// wrote it, after decompression.
+	SetDecodeFallback(fn func(raw []byte) (V, error))
+}
+
+// DiskUsager is an optional interface for stores kept on a filesystem or
+// volume of limited size. TieredCache uses it for ThresholdCallback.
+type DiskUsager interface {
+	// DiskUsage returns the bytes used and the total bytes of the filesystem
+	// holding the store, including space used by other files.
+	DiskUsage(ctx context.Context) (used, total int64, err error)
+}
+
+// Leaser is an optional interface for shared stores that can grant a named,
+// time-limited lease. TieredCache uses it so that only one replica sharing a
+// store runs AutoCleanup; stores without it clean up on every replica.
+type Leaser interface {
+	// AcquireLease takes the named lease for owner, or extends it if owner
+	// already holds it, until ttl elapses. It reports whether owner holds the lease.
+	AcquireLease(ctx context.Context, name, owner string, ttl time.Duration) (bool, error)
+}
+
+// HotKeyRecorder is an optional interface for stores that can keep a list
+// of keys beside their entries. TieredCache uses it for PersistHotKeys.
+type HotKeyRecorder[K comparable] interface {
+	// SaveHotKeys replaces the saved list with keys, hottest first.
+	SaveHotKeys(ctx context.Context, keys []K) error
+
+	// LoadHotKeys returns the list SaveHotKeys last saved, or nil if there is none.
+	LoadHotKeys(ctx context.Context) ([]K, error)
+}
+
+// ValueValidator is an optional interface for stores with value constraints,
+// such as encodability or size limits. TieredCache calls ValidateValue before
+// writing, so SetAsync reports a rejected value to its caller instead of
+// logging the failure from a background goroutine.
+type ValueValidator[V any] interface {
+	// ValidateValue returns an error if value cannot be stored.
+	ValidateValue(value V) error
+}
+
+// Streamer is an optional interface for file and object stores that can hold
+// values too large to buffer in memory. TieredCache.SetReader and GetReader
+// require it. Streamed values are kept apart from values written by Set:
+// Get does not return them, and Delete removes both.
+type Streamer[K comparable] interface {
+	// SetReader stores everything read from r under key until expiry (zero means none).
+	SetReader(ctx context.Context, key K, r io.Reader, expiry time.Time) error
+
+	// GetReader opens the value streamed under key. The caller must close rc.
+	GetReader(ctx context.Context, key K) (rc io.ReadCloser, expiry time.Time, found bool, err error)
+}
+
+// Batcher is an optional interface for stores that can apply many writes in
+// one round trip, such as a Valkey pipeline. TieredCache.Tx commits through
+// it; stores without it get one Set or Delete per key.
+type Batcher[K comparable, V any] interface {
+	// SetBatch stores values[i] under keys[i] until expiries[i] (zero means
+	// none). If it fails, any of the writes may have been applied.
+	SetBatch(ctx context.Context, keys []K, values []V, expiries []time.Time) error
+
+	// DeleteBatch removes keys. If it fails, any of them may have been removed.
+	DeleteBatch(ctx context.Context, keys []K) error
+}
+
+// setBatch writes the entries to store in one SetBatch call if it implements
+// Batcher, and otherwise one Set at a time, stopping at the first failure.
+func setBatch[K comparable, V any](ctx context.Context, store Store[K, V], keys []K, values []V, expiries []time.Time) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if b, ok := store.(Batcher[K, V]); ok {
+		return b.SetBatch(ctx, keys, values, expiries)
+	}
+	for i, k := range keys {
+		if err := store.Set(ctx, k, values[i], expiries[i]); err != nil {
+			return fmt.Errorf("key %v: %w", k, err)
+		}
+	}
+	return nil
+}
+
+// deleteBatch removes keys from store as setBatch writes them.
+func deleteBatch[K comparable, V any](ctx context.Context, store Store[K, V], keys []K) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if b, ok := store.(Batcher[K, V]); ok {
+		return b.DeleteBatch(ctx, keys)
+	}
+	for _, k := range keys {
+		if err := store.Delete(ctx, k); err != nil {
+			return fmt.Errorf("key %v: %w", k, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package fido
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrStreamingUnsupported is returned by SetReader and GetReader when the store
+// does not implement Streamer.
+var ErrStreamingUnsupported = errors.New("store does not support streaming")
+
+// ErrNoPersist is returned by SetReader for a key that NoPersistPattern or
+// NoPersistFunc keeps out of persistence, as a streamed value has nowhere else
+// to live.
+var ErrNoPersist = errors.New("key is excluded from persistence")
+
+// streamer returns the store's Streamer, unwrapping fault injection.
+func (c *TieredCache[K, V]) streamer() (Streamer[K], error) {
+	if s, ok := baseStore(c.Store).(Streamer[K]); ok {
+		return s, nil
+	}
+	return nil, ErrStreamingUnsupported
+}
+
+// SetReader streams r to persistence under key with an explicit TTL, for
+// artifacts too large to hold in memory. A zero or negative TTL uses the
+// default TTL. Streamed values bypass the memory tier entirely and are kept
+// apart from values written by Set; Delete removes both. Keys excluded from
+// persistence fail with ErrNoPersist.
+func (c *TieredCache[K, V]) SetReader(ctx context.Context, key K, r io.Reader, ttl time.Duration) error {
+	s, err := c.streamer()
+	if err != nil {
+		return err
+	}
+	if err := c.Store.ValidateKey(key); err != nil {
+		return fmt.Errorf("invalid key: %w", err)
+	}
+	if excluded(c.Store, key) {
+		return ErrNoPersist
+	}
+	sem := c.limit.lane(ctx)
+	if err := sem.acquire(ctx); err != nil {
+		return fmt.Errorf("persistence stream failed: %w", err)
+	}
+	defer sem.release()
+	if err := s.SetReader(ctx, storedKey(c.Store, key), r, calculateExpiry(ttl, c.defaultTTL)); err != nil {
+		return fmt.Errorf("persistence stream failed: %w", err)
+	}
+	return nil
+}
+
+// GetReader opens a value stored by SetReader. The caller must close the reader.
+// Keys excluded from persistence always miss.
+//
+//nolint:gocritic // unnamedResult: matches Get
+func (c *TieredCache[K, V]) GetReader(ctx context.Context, key K) (io.ReadCloser, bool, error) {
+	s, err := c.streamer()
+	if err != nil {
+		return nil, false, err
+	}
+	if err := c.Store.ValidateKey(key); err != nil {
+		return nil, false, fmt.Errorf("invalid key: %w", err)
+	}
+	if excluded(c.Store, key) {
+		return nil, false, nil
+	}
+	sem := c.limit.lane(ctx)
+	if err := sem.acquire(ctx); err != nil {
+		return nil, false, fmt.Errorf("persistence stream load: %w", err)
+	}
+	rc, _, found, err := s.GetReader(ctx, storedKey(c.Store, key)) // reading rc is not limited
+	sem.release()
+	if err != nil {
+		return nil, false, fmt.Errorf("persistence stream load: %w", err)
+	}
+	return rc, found, nil
+}
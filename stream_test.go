@@ -0,0 +1,130 @@
+package fido
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// streamMockStore adds Streamer to mockStore, keeping streams apart from values.
+type streamMockStore struct {
+	*mockStore[string, int]
+	mu      sync.Mutex
+	streams map[string][]byte
+	expiry  map[string]time.Time
+}
+
+func newStreamMockStore() *streamMockStore {
+	return &streamMockStore{
+		mockStore: newMockStore[string, int](),
+		streams:   make(map[string][]byte),
+		expiry:    make(map[string]time.Time),
+	}
+}
+
+func (s *streamMockStore) SetReader(_ context.Context, key string, r io.Reader, expiry time.Time) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.streams[key], s.expiry[key] = b, expiry
+	return nil
+}
+
+//nolint:revive // function-result-limit: matches Streamer
+func (s *streamMockStore) GetReader(_ context.Context, key string) (io.ReadCloser, time.Time, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.streams[key]
+	if !ok {
+		return nil, time.Time{}, false, nil
+	}
+	return io.NopCloser(bytes.NewReader(b)), s.expiry[key], true, nil
+}
+
+func TestTieredCache_Stream(t *testing.T) {
+	ctx := context.Background()
+	store := newStreamMockStore()
+	cache, err := NewTiered[string, int](store, TTL(time.Hour))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	payload := strings.Repeat("artifact", 1024)
+	if err := cache.SetReader(ctx, "build", strings.NewReader(payload), 0); err != nil {
+		t.Fatalf("SetReader: %v", err)
+	}
+	if cache.Len() != 0 {
+		t.Errorf("Len = %d; streamed values should bypass memory", cache.Len())
+	}
+	if exp := store.expiry["build"]; exp.IsZero() || time.Until(exp) > time.Hour {
+		t.Errorf("expiry = %v; want the default TTL", exp)
+	}
+
+	rc, found, err := cache.GetReader(ctx, "build")
+	if err != nil || !found {
+		t.Fatalf("GetReader = %v, %v; want found", found, err)
+	}
+	got, err := io.ReadAll(rc)
+	_ = rc.Close() //nolint:errcheck // NopCloser
+	if err != nil || string(got) != payload {
+		t.Errorf("read %d bytes, %v; want %d bytes", len(got), err, len(payload))
+	}
+
+	if _, found, _ := cache.Get(ctx, "build"); found { //nolint:errcheck // mock
+		t.Error("Get should not return streamed values")
+	}
+	if rc, found, err := cache.GetReader(ctx, "missing"); err != nil || found || rc != nil {
+		t.Errorf("GetReader(missing) = %v, %v, %v; want nil, false, nil", rc, found, err)
+	}
+}
+
+func TestTieredCache_Stream_Unsupported(t *testing.T) {
+	ctx := context.Background()
+	cache, err := NewTiered[string, int](newMockStore[string, int]())
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	if err := cache.SetReader(ctx, "k", strings.NewReader("x"), 0); !errors.Is(err, ErrStreamingUnsupported) {
+		t.Errorf("SetReader = %v; want ErrStreamingUnsupported", err)
+	}
+	if _, _, err := cache.GetReader(ctx, "k"); !errors.Is(err, ErrStreamingUnsupported) {
+		t.Errorf("GetReader = %v; want ErrStreamingUnsupported", err)
+	}
+}
+
+func TestTieredCache_Stream_NoPersist(t *testing.T) {
+	ctx := context.Background()
+	store := newStreamMockStore()
+	cache, err := NewTiered[string, int](store, NoPersistPattern("nonce:*"))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	if err := cache.SetReader(ctx, "nonce:1", strings.NewReader("x"), 0); !errors.Is(err, ErrNoPersist) {
+		t.Errorf("SetReader(excluded) = %v; want ErrNoPersist", err)
+	}
+	if _, ok := store.streams["nonce:1"]; ok {
+		t.Error("an excluded key's stream reached the store")
+	}
+
+	store.streams["nonce:2"] = []byte("stale") // written before the key was excluded
+	if rc, found, err := cache.GetReader(ctx, "nonce:2"); err != nil || found || rc != nil {
+		t.Errorf("GetReader(excluded) = %v, %v, %v; want nil, false, nil", rc, found, err)
+	}
+
+	if err := cache.SetReader(ctx, "build", strings.NewReader("x"), 0); err != nil {
+		t.Errorf("SetReader(other key): %v", err)
+	}
+}
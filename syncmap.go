@@ -0,0 +1,83 @@
+package fido
+
+import "sync"
+
+// SyncMap is a bounded, S3-FIFO evicting replacement for sync.Map, for code
+// written against sync.Map's untyped API. Keys must be comparable, as for
+// sync.Map. Code that can name its types should use Cache directly.
+//
+// Unlike sync.Map, a SyncMap may evict a stored key at any time once it holds
+// Size entries, so Load after Store can miss. It lacks CompareAndSwap and
+// CompareAndDelete.
+//
+// The zero SyncMap is empty and ready for use with the default options.
+// A SyncMap must not be copied after first use.
+type SyncMap struct {
+	once  sync.Once
+	cache *Cache[any, any]
+}
+
+// NewSyncMap creates a SyncMap with the given options, as for New.
+func NewSyncMap(opts ...Option) *SyncMap {
+	return &SyncMap{cache: New[any, any](opts...)}
+}
+
+func (m *SyncMap) c() *Cache[any, any] {
+	m.once.Do(func() {
+		if m.cache == nil {
+			m.cache = New[any, any]()
+		}
+	})
+	return m.cache
+}
+
+// Load returns the value stored for key, or nil and false if there is none.
+func (m *SyncMap) Load(key any) (value any, ok bool) {
+	return m.c().Get(key)
+}
+
+// Store sets the value for key.
+func (m *SyncMap) Store(key, value any) {
+	m.c().Set(key, value)
+}
+
+// LoadOrStore returns the existing value for key if present. Otherwise it
+// stores and returns value. loaded reports whether the value was loaded.
+func (m *SyncMap) LoadOrStore(key, value any) (actual any, loaded bool) {
+	return m.c().GetOrSet(key, value)
+}
+
+// LoadAndDelete deletes the value for key, returning the previous value if any.
+func (m *SyncMap) LoadAndDelete(key any) (value any, loaded bool) {
+	return m.c().GetAndDelete(key)
+}
+
+// Delete deletes the value for key.
+func (m *SyncMap) Delete(key any) {
+	m.c().Delete(key)
+}
+
+// Swap stores value for key and returns the previous value if any.
+func (m *SyncMap) Swap(key, value any) (previous any, loaded bool) {
+	return m.c().Swap(key, value)
+}
+
+// Range calls f for each key and value present, stopping if f returns false.
+// As with sync.Map, it does not visit a consistent snapshot.
+func (m *SyncMap) Range(f func(key, value any) bool) {
+	for k, v := range m.c().Range() {
+		if !f(k, v) {
+			return
+		}
+	}
+}
+
+// Clear deletes all entries.
+func (m *SyncMap) Clear() {
+	m.c().Flush()
+}
+
+// Cache returns the Cache behind m, for Stats, Config, and typed access.
+func (m *SyncMap) Cache() *Cache[any, any] {
+	return m.c()
+}
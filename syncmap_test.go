@@ -0,0 +1,82 @@
+package fido
+
+import (
+	"sync"
+	"testing"
+)
+
+// syncMapAPI is the part of sync.Map's API that SyncMap provides.
+type syncMapAPI interface {
+	Load(key any) (any, bool)
+	Store(key, value any)
+	LoadOrStore(key, value any) (any, bool)
+	LoadAndDelete(key any) (any, bool)
+	Delete(key any)
+	Swap(key, value any) (any, bool)
+	Range(f func(key, value any) bool)
+	Clear()
+}
+
+var (
+	_ syncMapAPI = (*sync.Map)(nil)
+	_ syncMapAPI = (*SyncMap)(nil)
+)
+
+func TestSyncMap(t *testing.T) {
+	var m SyncMap // zero value is usable
+	m.Store("a", 1)
+	m.Store(1, "int key")
+	if v, ok := m.Load("a"); !ok || v != 1 {
+		t.Errorf(`Load("a") = %v, %v; want 1, true`, v, ok)
+	}
+	if v, ok := m.Load(1); !ok || v != "int key" {
+		t.Errorf("Load(1) = %v, %v; want int key, true", v, ok)
+	}
+	if _, ok := m.Load("1"); ok {
+		t.Error(`Load("1") should not find the int key 1`)
+	}
+
+	if v, loaded := m.LoadOrStore("a", 2); !loaded || v != 1 {
+		t.Errorf(`LoadOrStore("a") = %v, %v; want 1, true`, v, loaded)
+	}
+	if v, loaded := m.LoadOrStore("b", 2); loaded || v != 2 {
+		t.Errorf(`LoadOrStore("b") = %v, %v; want 2, false`, v, loaded)
+	}
+	if v, loaded := m.Swap("b", 3); !loaded || v != 2 {
+		t.Errorf(`Swap("b") = %v, %v; want 2, true`, v, loaded)
+	}
+	if v, loaded := m.LoadAndDelete("b"); !loaded || v != 3 {
+		t.Errorf(`LoadAndDelete("b") = %v, %v; want 3, true`, v, loaded)
+	}
+	m.Delete("a")
+	if _, ok := m.Load("a"); ok {
+		t.Error(`Load("a") found a deleted key`)
+	}
+
+	m.Store("c", 4)
+	visited := 0
+	m.Range(func(_, _ any) bool {
+		visited++
+		return false
+	})
+	if visited != 1 {
+		t.Errorf("Range visited %d entries after f returned false; want 1", visited)
+	}
+	m.Clear()
+	if n := m.Cache().Len(); n != 0 {
+		t.Errorf("Len() = %d after Clear; want 0", n)
+	}
+}
+
+func TestSyncMap_Bounded(t *testing.T) {
+	m := NewSyncMap(Size(100))
+	for i := range 1000 {
+		m.Store(i, i)
+	}
+	if n := m.Cache().Len(); n > 100 {
+		t.Errorf("Len() = %d; want at most Size(100)", n)
+	}
+	if got := m.Cache().Config().Size; got != 100 {
+		t.Errorf("Config().Size = %d; want 100", got)
+	}
+}
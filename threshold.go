@@ -0,0 +1,120 @@
+package fido
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Resources reported in Usage.
+const (
+	UsageMemory = "memory" // entries in memory against Size
+	UsageDisk   = "disk"   // bytes used on the store's filesystem, for DiskUsager stores
+	UsageAsync  = "async"  // async writes not yet persisted, against Size
+)
+
+// thresholdInterval is how often ThresholdCallback's resources are checked.
+const thresholdInterval = time.Second
+
+// Usage is a resource's occupancy, as reported to ThresholdCallback.
+type Usage struct {
+	Resource string  // UsageMemory, UsageDisk, or UsageAsync
+	Used     int64   // entries or bytes
+	Limit    int64   // capacity in the same unit
+	Percent  float64 // Used as a percentage of Limit
+}
+
+// usageProbe measures one resource.
+type usageProbe func(ctx context.Context) (Usage, error)
+
+// thresholdWatch polls resources, calling fn as each rises to pct. A
+// resource is reported again only after dropping back below pct.
+type thresholdWatch struct {
+	fn     func(Usage)
+	probes []usageProbe
+	above  map[string]bool // resources at or over pct, as of the last check
+	pct    float64
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startThresholds starts the ThresholdCallback watch, or returns nil if it is disabled.
+func startThresholds(cfg *config, probes ...usageProbe) *thresholdWatch {
+	if cfg.thresholdFn == nil {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &thresholdWatch{
+		fn:     cfg.thresholdFn,
+		probes: probes,
+		above:  make(map[string]bool),
+		pct:    cfg.thresholdPct,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(thresholdInterval)
+		defer ticker.Stop()
+		for {
+			w.check(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return w
+}
+
+// check measures each resource once, calling fn for those that crossed pct.
+func (w *thresholdWatch) check(ctx context.Context) {
+	for _, probe := range w.probes {
+		u, err := probe(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Debug("threshold check failed", "resource", u.Resource, "error", err)
+			}
+			continue
+		}
+		if u.Limit <= 0 {
+			continue
+		}
+		u.Percent = 100 * float64(u.Used) / float64(u.Limit)
+		over := u.Percent >= w.pct
+		if over && !w.above[u.Resource] {
+			w.fn(u)
+		}
+		w.above[u.Resource] = over
+	}
+}
+
+// stop ends the watch and waits for a callback in progress to return.
+func (w *thresholdWatch) stop() {
+	if w == nil {
+		return
+	}
+	w.cancel()
+	<-w.done
+}
+
+// usageProbes measures the resources ThresholdCallback watches.
+func (c *TieredCache[K, V]) usageProbes() []usageProbe {
+	limit := int64(c.memory.capacity)
+	probes := []usageProbe{
+		func(context.Context) (Usage, error) {
+			return Usage{Resource: UsageMemory, Used: int64(c.memory.len()), Limit: limit}, nil
+		},
+		func(context.Context) (Usage, error) {
+			return Usage{Resource: UsageAsync, Used: int64(c.pending.m.Size()), Limit: limit}, nil
+		},
+	}
+	if d, ok := baseStore(c.Store).(DiskUsager); ok {
+		probes = append(probes, func(ctx context.Context) (Usage, error) {
+			used, total, err := d.DiskUsage(ctx)
+			return Usage{Resource: UsageDisk, Used: used, Limit: total}, err
+		})
+	}
+	return probes
+}
@@ -0,0 +1,58 @@
+package fido
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestThresholdWatch_Check(t *testing.T) {
+	var got []Usage
+	used := int64(0)
+	w := &thresholdWatch{
+		fn:    func(u Usage) { got = append(got, u) },
+		above: make(map[string]bool),
+		pct:   80,
+		probes: []usageProbe{func(context.Context) (Usage, error) {
+			return Usage{Resource: UsageMemory, Used: used, Limit: 10}, nil
+		}},
+	}
+	ctx := context.Background()
+	for _, n := range []int64{5, 8, 9, 7, 10} {
+		used = n
+		w.check(ctx)
+	}
+	// Reported on reaching 8, not again at 9, and again after dropping to 7.
+	if len(got) != 2 || got[0].Used != 8 || got[0].Percent != 80 || got[1].Used != 10 {
+		t.Errorf("callbacks = %+v; want crossings at 8 and 10", got)
+	}
+}
+
+func TestTieredCache_ThresholdCallback(t *testing.T) {
+	ctx := context.Background()
+	crossed := make(chan Usage, 4)
+	cache, err := NewTiered[int, int](newMockStore[int, int](), Size(100),
+		ThresholdCallback(50, func(u Usage) { crossed <- u }))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+	if cache.Config().Threshold != 50 {
+		t.Errorf("Config().Threshold = %v; want 50", cache.Config().Threshold)
+	}
+
+	for i := range cache.memory.capacity {
+		if err := cache.Set(ctx, i, i); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	var u Usage
+	select {
+	case u = <-crossed:
+	case <-time.After(3 * thresholdInterval):
+		t.Fatal("no callback after filling memory")
+	}
+	if u.Resource != UsageMemory || u.Percent < 50 || u.Limit != int64(cache.memory.capacity) {
+		t.Errorf("Usage = %+v; want memory at or above 50%%", u)
+	}
+}
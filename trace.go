@@ -0,0 +1,184 @@
+package fido
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// evictAction is what the eviction algorithm did with an entry.
+type evictAction uint8
+
+const (
+	actionPromote      evictAction = iota // small to main
+	actionDemote                          // main to small, for an entry that was once hot
+	actionSecondChance                    // main head to main tail, frequency decremented
+	actionDeathRow                        // evicted, but held for resurrection
+	actionEvict                           // gone from memory, key remembered as a ghost
+	actionResurrect                       // death row back to main
+)
+
+var evictActionNames = [...]string{"promote", "demote", "second-chance", "death-row", "evict", "resurrect"}
+
+func (a evictAction) String() string {
+	if int(a) < len(evictActionNames) {
+		return evictActionNames[a]
+	}
+	return fmt.Sprintf("evictAction(%d)", a)
+}
+
+// Queues an entry can be in when an eviction decision is made about it.
+const (
+	queueSmall    = "small"
+	queueMain     = "main"
+	queueDeathRow = "death-row"
+)
+
+// evictionStep is one eviction decision about one entry, recorded before the
+// entry is changed.
+type evictionStep[K comparable] struct {
+	key       K
+	hash      uint64
+	queue     string // where the entry was
+	freq      uint32 // access frequency
+	peak      uint32 // peak access frequency, compared against threshold
+	threshold uint32 // death row admission threshold; 0 if not consulted
+	action    evictAction
+}
+
+// chainSteps returns an onStep hook calling each non-nil hook in turn, or nil.
+func chainSteps[K comparable](hooks ...func(evictionStep[K])) func(evictionStep[K]) {
+	var fns []func(evictionStep[K])
+	for _, h := range hooks {
+		if h != nil {
+			fns = append(fns, h)
+		}
+	}
+	switch len(fns) {
+	case 0:
+		return nil
+	case 1:
+		return fns[0]
+	}
+	return func(s evictionStep[K]) {
+		for _, f := range fns {
+			f(s)
+		}
+	}
+}
+
+// traceWriter returns an onStep hook that writes each step to w as one
+// numbered line. Write errors are ignored; a trace is best effort.
+func traceWriter[K comparable](w io.Writer) func(evictionStep[K]) {
+	var seq uint64
+	var buf []byte
+	return func(s evictionStep[K]) {
+		seq++
+		buf = fmt.Appendf(buf[:0], "%d %s key=%v hash=%016x queue=%s freq=%d peak=%d",
+			seq, s.action, s.key, s.hash, s.queue, s.freq, s.peak)
+		if s.threshold > 0 {
+			buf = fmt.Appendf(buf, " threshold=%d", s.threshold)
+		}
+		buf = append(buf, '\n')
+		_, _ = w.Write(buf) //nolint:errcheck // best effort
+	}
+}
+
+// EvictionTrace keeps the last n eviction decisions in memory, for Evictions
+// to answer why a key left the cache without adding logging to a running
+// service. Each decision costs a time.Now call under the cache lock, so a
+// trace of a few thousand suits production use. Default 0 (off).
+func EvictionTrace(n int) Option {
+	return func(c *config) { c.evictionTrace = n }
+}
+
+// Eviction is one eviction decision recorded by EvictionTrace. Keys are
+// reported only by hash; KeyHash computes it for a key.
+type Eviction struct {
+	Time      time.Time
+	Seq       uint64 // 1 for the cache's first decision; gaps mean the ring wrapped
+	KeyHash   uint64
+	Queue     string // where the entry was: small, main, or death-row
+	Action    string // promote, demote, second-chance, death-row, evict, or resurrect
+	Freq      uint32 // access frequency when decided
+	Peak      uint32 // peak access frequency, compared against Threshold
+	Threshold uint32 // death row admission threshold; 0 if not consulted
+}
+
+func (e Eviction) String() string {
+	s := fmt.Sprintf("%d %s %s hash=%016x queue=%s freq=%d peak=%d",
+		e.Seq, e.Time.Format(time.RFC3339Nano), e.Action, e.KeyHash, e.Queue, e.Freq, e.Peak)
+	if e.Threshold > 0 {
+		s += fmt.Sprintf(" threshold=%d", e.Threshold)
+	}
+	return s
+}
+
+// evictionRing holds the last eviction decisions, overwriting the oldest.
+// A nil *evictionRing records nothing.
+type evictionRing struct {
+	mu    sync.Mutex
+	steps []Eviction
+	seq   uint64 // decisions recorded
+}
+
+func newEvictionRing(n int) *evictionRing {
+	if n <= 0 {
+		return nil
+	}
+	return &evictionRing{steps: make([]Eviction, n)}
+}
+
+// recordSteps returns an onStep hook recording into r, or nil if r is nil.
+func recordSteps[K comparable](r *evictionRing) func(evictionStep[K]) {
+	if r == nil {
+		return nil
+	}
+	return func(s evictionStep[K]) {
+		r.mu.Lock()
+		r.seq++
+		r.steps[(r.seq-1)%uint64(len(r.steps))] = Eviction{
+			Time: time.Now(), Seq: r.seq, KeyHash: s.hash, Queue: s.queue,
+			Action: s.action.String(), Freq: s.freq, Peak: s.peak, Threshold: s.threshold,
+		}
+		r.mu.Unlock()
+	}
+}
+
+// snapshot returns the recorded decisions, oldest first.
+func (r *evictionRing) snapshot() []Eviction {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := min(r.seq, uint64(len(r.steps)))
+	out := make([]Eviction, 0, n)
+	for i := r.seq - n; i < r.seq; i++ {
+		out = append(out, r.steps[i%uint64(len(r.steps))])
+	}
+	return out
+}
+
+// Evictions returns the eviction decisions recorded by EvictionTrace, oldest
+// first, or nil if it is not set. Each prints as one line, for dumping from a
+// debug endpoint.
+func (c *Cache[K, V]) Evictions() []Eviction {
+	return c.memory.evictions.snapshot()
+}
+
+// Evictions returns the memory tier's eviction decisions, as Cache.Evictions.
+func (c *TieredCache[K, V]) Evictions() []Eviction {
+	return c.memory.evictions.snapshot()
+}
+
+// KeyHash returns the hash that Evictions and AccessLog report for key.
+func (c *Cache[K, V]) KeyHash(key K) uint64 {
+	return c.memory.hasher(key)
+}
+
+// KeyHash returns the hash that Evictions and AccessLog report for key.
+func (c *TieredCache[K, V]) KeyHash(key K) uint64 {
+	return c.memory.hasher(key)
+}
@@ -0,0 +1,115 @@
+package fido
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"time"
+)
+
+// replay runs a skewed workload against a Deterministic cache and returns its
+// trace and hit count.
+func replay(t *testing.T) (trace string, hits int) {
+	t.Helper()
+	var buf bytes.Buffer
+	c := New[int, int](Size(64), Deterministic(&buf))
+	rng := rand.New(rand.NewPCG(1, 2))
+	for range 20_000 {
+		key := int(rng.ExpFloat64() * 40)
+		if _, ok := c.Get(key); ok {
+			hits++
+			continue
+		}
+		c.Set(key, key)
+	}
+	return buf.String(), hits
+}
+
+func TestDeterministic_Replay(t *testing.T) {
+	trace, hits := replay(t)
+	trace2, hits2 := replay(t)
+	if hits != hits2 || trace != trace2 {
+		t.Fatalf("replays differ: %d hits and %d trace bytes, then %d and %d", hits, len(trace), hits2, len(trace2))
+	}
+
+	lines := strings.Split(strings.TrimSuffix(trace, "\n"), "\n")
+	if !strings.HasPrefix(lines[0], "1 ") {
+		t.Errorf("first line %q; want sequence number 1", lines[0])
+	}
+	for _, action := range evictActionNames {
+		if !strings.Contains(trace, " "+action+" key=") {
+			t.Errorf("trace has no %s step", action)
+		}
+	}
+	if !strings.Contains(trace, "threshold=") {
+		t.Error("trace never reports a death row threshold")
+	}
+}
+
+func TestDeterministic_Config(t *testing.T) {
+	src := &batchSource{batches: [][]KV[string, int]{kvs("a")}, calls: make(chan error, 1)}
+	c := New[string, int](Deterministic(nil), Prefetch[string, int](src, 0))
+	cfg := c.Config()
+	if !cfg.Deterministic || cfg.ClockResolution != 0 {
+		t.Errorf("Config() = Deterministic %v, ClockResolution %v; want true, 0", cfg.Deterministic, cfg.ClockResolution)
+	}
+	select {
+	case <-src.calls:
+		t.Error("Deterministic cache should not prefetch")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestCache_Evictions(t *testing.T) {
+	if New[string, int]().Evictions() != nil {
+		t.Error("Evictions() should be nil without EvictionTrace")
+	}
+
+	c := New[string, int](Size(16), EvictionTrace(8))
+	byHash := make(map[uint64]string)
+	for i := range 100 {
+		key := fmt.Sprintf("key%d", i)
+		byHash[c.KeyHash(key)] = key
+		c.Set(key, i)
+	}
+
+	evs := c.Evictions()
+	if len(evs) != 8 {
+		t.Fatalf("Evictions() returned %d decisions; want the last 8", len(evs))
+	}
+	for i, e := range evs {
+		if i > 0 && e.Seq != evs[i-1].Seq+1 {
+			t.Errorf("Seq %d follows %d; want consecutive", e.Seq, evs[i-1].Seq)
+		}
+		key, ok := byHash[e.KeyHash]
+		if !ok {
+			t.Fatalf("%v: hash matches no key", e)
+		}
+		if e.Action == "evict" {
+			if _, ok := c.Get(key); ok {
+				t.Errorf("%v: %s is still cached", e, key)
+			}
+		}
+		if !strings.Contains(e.String(), " "+e.Action+" hash=") {
+			t.Errorf("String() = %q", e)
+		}
+	}
+	if got := c.Config().EvictionTrace; got != 8 {
+		t.Errorf("Config().EvictionTrace = %d; want 8", got)
+	}
+
+	tc, err := NewTiered[string, int](newMockStore[string, int](), Size(16), EvictionTrace(4))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	for i := range 40 {
+		if err := tc.Set(t.Context(), fmt.Sprint(i), i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(tc.Evictions()); n != 4 {
+		t.Errorf("TieredCache.Evictions() returned %d decisions; want 4", n)
+	}
+}
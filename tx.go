@@ -0,0 +1,178 @@
+package fido
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ErrTxDone is returned by Txn methods called after the transaction has ended.
+var ErrTxDone = errors.New("transaction already committed or rolled back")
+
+// Txn buffers writes for TieredCache.Tx. Nothing is visible to other callers
+// until the transaction commits. A Txn must not be used after Tx returns or
+// from more than one goroutine.
+type Txn[K comparable, V any] struct {
+	cache *TieredCache[K, V]
+	ctx   context.Context //nolint:containedctx // scoped to the Tx call
+	ops   []txnOp[K, V]
+	index map[K]int // key -> position of its latest op in ops
+	done  bool
+}
+
+// txnOp is a buffered Set (delete false) or Delete for one key.
+type txnOp[K comparable, V any] struct {
+	key    K
+	value  V
+	expiry time.Time
+	delete bool
+}
+
+// Tx runs fn with a transaction that buffers Sets and Deletes. If fn returns
+// nil, the buffered changes are validated and applied to persistence, then to
+// memory; if fn returns an error or panics, they are discarded and the cache is
+// untouched. Only the last change per key is applied. A committed Delete also
+// deletes the key's dependents from SetWithDependencies, as Delete does, except
+// those the transaction itself writes.
+//
+// Commit is all-or-nothing for validation: an invalid key or value rejects the
+// whole transaction before anything is written. The writes then go to the
+// store in one SetBatch and one DeleteBatch call if it implements Batcher, and
+// otherwise one call per key, Sets first. Neither is atomic against the store:
+// if a write fails, the writes before it stay applied (with Batcher, any of
+// the batch's may be). Every buffered key's memory entry is then dropped so
+// reads fall through to the store, and Tx returns the error. Concurrent
+// readers may see some keys updated before others while a commit is in
+// progress.
+func (c *TieredCache[K, V]) Tx(ctx context.Context, fn func(tx *Txn[K, V]) error) error {
+	tx := &Txn[K, V]{cache: c, ctx: ctx, index: make(map[K]int)}
+	defer func() { tx.done = true }()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	tx.done = true
+	return tx.commit()
+}
+
+// Get returns key's value as the transaction would leave it: a buffered Set or
+// Delete wins, otherwise the cache is read.
+//
+//nolint:gocritic // unnamedResult: matches TieredCache.Get
+func (tx *Txn[K, V]) Get(key K) (V, bool, error) {
+	var zero V
+	if tx.done {
+		return zero, false, ErrTxDone
+	}
+	if i, ok := tx.index[key]; ok {
+		op := tx.ops[i]
+		if op.delete || (!op.expiry.IsZero() && time.Now().After(op.expiry)) {
+			return zero, false, nil
+		}
+		return tx.cache.clone.copy(op.value, true), true, nil
+	}
+	return tx.cache.Get(tx.ctx, key)
+}
+
+// Set buffers a write with the default TTL.
+func (tx *Txn[K, V]) Set(key K, value V) error {
+	return tx.SetTTL(key, value, 0)
+}
+
+// SetTTL buffers a write with an explicit TTL.
+// A zero or negative TTL uses the default TTL.
+func (tx *Txn[K, V]) SetTTL(key K, value V, ttl time.Duration) error {
+	return tx.add(txnOp[K, V]{key: key, value: value, expiry: tx.cache.expiry(value, ttl)})
+}
+
+// Delete buffers a delete.
+func (tx *Txn[K, V]) Delete(key K) error {
+	return tx.add(txnOp[K, V]{key: key, delete: true})
+}
+
+func (tx *Txn[K, V]) add(op txnOp[K, V]) error {
+	if tx.done {
+		return ErrTxDone
+	}
+	if i, ok := tx.index[op.key]; ok {
+		tx.ops[i] = op
+		return nil
+	}
+	tx.index[op.key] = len(tx.ops)
+	tx.ops = append(tx.ops, op)
+	return nil
+}
+
+// commit validates every buffered op, then applies them to persistence, batched
+// where the store allows, and memory.
+func (tx *Txn[K, V]) commit() error {
+	c := tx.cache
+	var errs []error
+	for _, op := range tx.ops {
+		if err := c.Store.ValidateKey(op.key); err != nil {
+			errs = append(errs, fmt.Errorf("invalid key %v: %w", op.key, err))
+			continue
+		}
+		if !op.delete {
+			if err := c.validateValue(op.value); err != nil {
+				errs = append(errs, fmt.Errorf("key %v: %w", op.key, err))
+			}
+			if err := c.checkMutable(op.key); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	var (
+		sets, dels []K
+		values     []V
+		expiries   []time.Time
+	)
+	for _, op := range tx.ops {
+		if op.delete {
+			dels = append(dels, op.key)
+			continue
+		}
+		sets = append(sets, op.key)
+		values = append(values, op.value)
+		expiries = append(expiries, op.expiry)
+	}
+	err := setBatch(tx.ctx, c.Store, sets, values, expiries)
+	if err == nil {
+		err = deleteBatch(tx.ctx, c.Store, dels)
+	}
+	if err != nil {
+		// Memory must not keep serving values the store may have replaced.
+		for _, op := range tx.ops {
+			c.memory.del(op.key)
+			c.forget(tx.ctx, op.key)
+		}
+		return fmt.Errorf("persistence commit: %w", err)
+	}
+
+	for _, op := range tx.ops {
+		if op.delete {
+			forgetFlight(c.flights, op.key)
+			c.memory.del(op.key)
+			c.thaw(op.key)
+		} else {
+			c.memory.set(op.key, op.value, c.memExpiry(op.expiry))
+		}
+		c.forget(tx.ctx, op.key)
+	}
+	// Deletes cascade as Delete does, except to keys this transaction wrote.
+	for _, k := range dels {
+		deps := slices.DeleteFunc(c.deps.cascade(k), func(d K) bool {
+			_, ok := tx.index[d]
+			return ok
+		})
+		if err := c.deleteDeps(tx.ctx, deps); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
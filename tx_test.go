@@ -0,0 +1,226 @@
+package fido
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// failKeyStore fails Set for one key.
+type failKeyStore struct {
+	*mockStore[string, int]
+	key string
+}
+
+func (s *failKeyStore) Set(ctx context.Context, key string, value int, expiry time.Time) error {
+	if key == s.key {
+		return errors.New("disk full")
+	}
+	return s.mockStore.Set(ctx, key, value, expiry)
+}
+
+func TestTieredCache_Tx(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+	_ = cache.Set(ctx, "gone", 1)        //nolint:errcheck // Test fixture
+
+	err = cache.Tx(ctx, func(tx *Txn[string, int]) error {
+		_ = tx.Set("a", 1)                           //nolint:errcheck // buffered
+		_ = tx.SetTTL("b", 2, time.Hour)             //nolint:errcheck // buffered
+		_ = tx.Set("a", 10)                          //nolint:errcheck // buffered; last write wins
+		_ = tx.Delete("gone")                        //nolint:errcheck // buffered
+		if v, ok, _ := tx.Get("a"); !ok || v != 10 { //nolint:errcheck // buffered read
+			t.Errorf("tx.Get(a) = %d, %v; want 10, true", v, ok)
+		}
+		if _, ok, _ := tx.Get("gone"); ok { //nolint:errcheck // buffered read
+			t.Error("tx.Get(gone) should miss after a buffered delete")
+		}
+		// Nothing is visible outside the transaction yet.
+		if _, ok, _ := cache.Get(ctx, "a"); ok { //nolint:errcheck // mock
+			t.Error("buffered write visible before commit")
+		}
+		if _, ok, _ := cache.Get(ctx, "gone"); !ok { //nolint:errcheck // mock
+			t.Error("buffered delete applied before commit")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Tx: %v", err)
+	}
+
+	if v, ok := cache.memory.get("a"); !ok || v != 10 {
+		t.Errorf("memory a = %d, %v; want 10, true", v, ok)
+	}
+	if v, _, found, _ := store.Get(ctx, "b"); !found || v != 2 { //nolint:errcheck // mock
+		t.Errorf("store b = %d, %v; want 2, true", v, found)
+	}
+	if _, ok, _ := cache.Get(ctx, "gone"); ok { //nolint:errcheck // mock
+		t.Error("gone should be deleted after commit")
+	}
+}
+
+func TestTieredCache_Tx_Rollback(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	var leaked *Txn[string, int]
+	errAbort := errors.New("abort")
+	err = cache.Tx(ctx, func(tx *Txn[string, int]) error {
+		leaked = tx
+		_ = tx.Set("a", 1) //nolint:errcheck // buffered
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Errorf("Tx error = %v; want errAbort", err)
+	}
+	if _, ok, _ := cache.Get(ctx, "a"); ok { //nolint:errcheck // mock
+		t.Error("rolled back write was applied")
+	}
+	if err := leaked.Set("b", 2); !errors.Is(err, ErrTxDone) {
+		t.Errorf("Set after Tx = %v; want ErrTxDone", err)
+	}
+	if _, _, err := leaked.Get("a"); !errors.Is(err, ErrTxDone) {
+		t.Errorf("Get after Tx = %v; want ErrTxDone", err)
+	}
+
+	// Invalid values reject the whole transaction before anything is written.
+	limited, err := NewTiered[string, string](newMockStore[string, string](), MaxValueBytes(4))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = limited.Close() }() //nolint:errcheck // Test cleanup
+	err = limited.Tx(ctx, func(tx *Txn[string, string]) error {
+		_ = tx.Set("ok", "ok")             //nolint:errcheck // buffered
+		_ = tx.Set("big", "far too large") //nolint:errcheck // buffered
+		return nil
+	})
+	if !errors.Is(err, ErrValueTooLarge) || !strings.Contains(err.Error(), "big") {
+		t.Errorf("Tx error = %v; want ErrValueTooLarge for big", err)
+	}
+	if _, ok, _ := limited.Get(ctx, "ok"); ok { //nolint:errcheck // mock
+		t.Error("valid key written despite invalid transaction")
+	}
+}
+
+func TestTieredCache_Tx_StoreFailure(t *testing.T) {
+	ctx := context.Background()
+	store := &failKeyStore{mockStore: newMockStore[string, int](), key: "b"}
+	cache, err := NewTiered[string, int](store)
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+	_ = cache.Set(ctx, "a", 1)           //nolint:errcheck // Test fixture
+
+	err = cache.Tx(ctx, func(tx *Txn[string, int]) error {
+		_ = tx.Set("a", 2) //nolint:errcheck // buffered
+		_ = tx.Set("b", 2) //nolint:errcheck // buffered
+		_ = tx.Set("c", 2) //nolint:errcheck // buffered
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("Tx error = %v; want disk full", err)
+	}
+	// a reached the store; memory must not keep serving the old value.
+	if _, ok := cache.memory.get("a"); ok {
+		t.Error("memory kept a stale value for a key the store replaced")
+	}
+	if v, ok, _ := cache.Get(ctx, "a"); !ok || v != 2 { //nolint:errcheck // mock
+		t.Errorf("Get(a) = %d, %v; want 2, true from the store", v, ok)
+	}
+	if _, ok, _ := cache.Get(ctx, "c"); ok { //nolint:errcheck // mock
+		t.Error("c was written after the failure")
+	}
+}
+
+// batchStore implements Batcher over mockStore, recording each call.
+type batchStore struct {
+	*mockStore[string, int]
+	setBatches, delBatches [][]string
+	sets                   int
+	fail                   error
+}
+
+func (s *batchStore) Set(ctx context.Context, key string, value int, expiry time.Time) error {
+	s.sets++
+	return s.mockStore.Set(ctx, key, value, expiry)
+}
+
+func (s *batchStore) SetBatch(ctx context.Context, keys []string, values []int, expiries []time.Time) error {
+	s.setBatches = append(s.setBatches, keys)
+	if s.fail != nil {
+		return s.fail
+	}
+	for i, k := range keys {
+		if err := s.mockStore.Set(ctx, k, values[i], expiries[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *batchStore) DeleteBatch(ctx context.Context, keys []string) error {
+	s.delBatches = append(s.delBatches, keys)
+	for _, k := range keys {
+		if err := s.mockStore.Delete(ctx, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestTieredCache_Tx_Batch(t *testing.T) {
+	ctx := context.Background()
+	store := &batchStore{mockStore: newMockStore[string, int]()}
+	cache, err := NewTiered[string, int](store, NoPersistPattern("local:*"))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+	_ = cache.Set(ctx, "c", 1)           //nolint:errcheck // Test fixture
+	store.sets = 0
+
+	err = cache.Tx(ctx, func(tx *Txn[string, int]) error {
+		_ = tx.Set("a", 1)       //nolint:errcheck // buffered
+		_ = tx.Set("local:x", 1) //nolint:errcheck // buffered
+		_ = tx.Set("b", 2)       //nolint:errcheck // buffered
+		_ = tx.Delete("c")       //nolint:errcheck // buffered
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Tx: %v", err)
+	}
+	if store.sets != 0 || len(store.setBatches) != 1 || strings.Join(store.setBatches[0], ",") != "a,b" {
+		t.Errorf("sets = %d, SetBatch calls = %v; want none, [[a b]] without the excluded key", store.sets, store.setBatches)
+	}
+	if len(store.delBatches) != 1 || strings.Join(store.delBatches[0], ",") != "c" {
+		t.Errorf("DeleteBatch calls = %v; want [[c]]", store.delBatches)
+	}
+	if v, ok, _ := cache.Get(ctx, "local:x"); !ok || v != 1 { //nolint:errcheck // mock
+		t.Errorf("Get(local:x) = %d, %v; want 1, true from memory", v, ok)
+	}
+
+	// A failed batch drops every buffered key from memory.
+	store.fail = errors.New("pipeline broken")
+	err = cache.Tx(ctx, func(tx *Txn[string, int]) error {
+		return tx.Set("a", 3)
+	})
+	if !errors.Is(err, store.fail) {
+		t.Fatalf("Tx error = %v; want the batch's", err)
+	}
+	if _, ok := cache.memory.get("a"); ok {
+		t.Error("memory kept a after a failed batch")
+	}
+}
@@ -0,0 +1,39 @@
+package fido
+
+import (
+	"context"
+	"log/slog"
+)
+
+// versionPurge runs Versioner.PurgeVersions once in the background, until
+// done or the cache is closed.
+type versionPurge struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func startVersionPurge(v Versioner) *versionPurge {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &versionPurge{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(p.done)
+		n, err := v.PurgeVersions(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Warn("purge old versions failed", "removed", n, "error", err)
+			return
+		}
+		if n > 0 {
+			slog.Info("purged entries from old versions", "removed", n)
+		}
+	}()
+	return p
+}
+
+// stop cancels a purge still in progress and waits for it to return.
+func (p *versionPurge) stop() {
+	if p == nil {
+		return
+	}
+	p.cancel()
+	<-p.done
+}
@@ -0,0 +1,95 @@
+package fido
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// versionStore is a mockStore that implements Versioner.
+type versionStore struct {
+	*mockStore[string, int]
+	version string
+	purged  chan struct{}
+	block   bool // PurgeVersions waits for its context to end
+}
+
+func (s *versionStore) SetVersion(v string) error {
+	if v == "bad" {
+		return errors.New("bad version")
+	}
+	s.version = v
+	return nil
+}
+
+func (s *versionStore) PurgeVersions(ctx context.Context) (int, error) {
+	defer close(s.purged)
+	if s.block {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+	return 3, nil
+}
+
+func TestTieredCache_Version(t *testing.T) {
+	store := &versionStore{mockStore: newMockStore[string, int](), purged: make(chan struct{})}
+	cache, err := NewTiered[string, int](store, Version("v2", true))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	if store.version != "v2" {
+		t.Errorf("store version = %q; want v2", store.version)
+	}
+	if cfg := cache.Config(); cfg.Version != "v2" || !cfg.PurgeVersions {
+		t.Errorf("Config Version, PurgeVersions = %q, %v; want v2, true", cfg.Version, cfg.PurgeVersions)
+	}
+	<-store.purged
+	if err := cache.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+
+	// Close stops a purge still in progress.
+	store = &versionStore{mockStore: newMockStore[string, int](), purged: make(chan struct{}), block: true}
+	cache, err = NewTiered[string, int](store, Version("v3", true))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	if err := cache.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	select {
+	case <-store.purged:
+	default:
+		t.Error("Close returned before the purge stopped")
+	}
+
+	// Without purge, only the namespace changes.
+	store = &versionStore{mockStore: newMockStore[string, int](), purged: make(chan struct{})}
+	cache, err = NewTiered[string, int](store, Version("v4", false))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	if err := cache.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	select {
+	case <-store.purged:
+		t.Error("PurgeVersions ran without purge")
+	default:
+	}
+}
+
+func TestTieredCache_Version_Invalid(t *testing.T) {
+	if _, err := NewTiered[string, int](newMockStore[string, int](), Version("v2", false)); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("NewTiered without Versioner = %v; want ErrInvalidConfig", err)
+	}
+	store := &versionStore{mockStore: newMockStore[string, int](), purged: make(chan struct{})}
+	if _, err := NewTiered[string, int](store, Version("bad", true)); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("NewTiered with a rejected version = %v; want ErrInvalidConfig", err)
+	}
+	select {
+	case <-store.purged:
+		t.Error("PurgeVersions ran for a rejected version")
+	default:
+	}
+}
@@ -0,0 +1,163 @@
+package fido
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v4"
+)
+
+// victimQueueSize bounds pending spills. Spills beyond this are dropped:
+// the victim tier is an optimization, never a reason to block eviction.
+const victimQueueSize = 1024
+
+// victimCache spills entries evicted from death row to a secondary store.
+// Death-row evictions are entries that were recently hot, so they're the
+// most likely misses to recur; a short-lived copy on local disk recovers
+// them far cheaper than a round trip to the primary persistence tier.
+//
+//nolint:govet // fieldalignment: semantic grouping preferred
+type victimCache[K comparable, V any] struct {
+	store    Store[K, V]
+	ttl      time.Duration
+	spilled  *xsync.Map[K, uint32] // keys written to store -> expiry seconds
+	resident func(K) bool          // reports whether key is back in memory
+	skip     func(K) bool          // NoPersistPattern and NoPersistFunc; nil when unset
+	queue    chan victimEntry[K, V]
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+type victimEntry[K comparable, V any] struct {
+	key    K
+	value  V
+	expiry time.Time
+}
+
+func newVictimCache[K comparable, V any](cfg *config, limit *persistLimit, resident func(K) bool) *victimCache[K, V] {
+	store, ok := cfg.victim.(Store[K, V])
+	if !ok || store == nil {
+		return nil
+	}
+	v := &victimCache[K, V]{
+		store:    hashKeys(limitStore(store, limit), cfg),
+		ttl:      cfg.victimTTL,
+		resident: resident,
+		skip:     newNoPersist[K](cfg),
+		spilled:  xsync.NewMap[K, uint32](),
+		queue:    make(chan victimEntry[K, V], victimQueueSize),
+		done:     make(chan struct{}),
+	}
+	v.wg.Go(v.run)
+	return v
+}
+
+// spill queues an evicted entry. Called under the s3fifo lock, so it must not block.
+func (v *victimCache[K, V]) spill(key K, value V, expirySec uint32) {
+	if v.skip != nil && v.skip(key) {
+		return
+	}
+	expiry := time.Now().Add(v.ttl)
+	if expirySec != 0 {
+		if e := time.Unix(int64(expirySec), 0); e.Before(expiry) {
+			expiry = e
+		}
+	}
+	select {
+	case v.queue <- victimEntry[K, V]{key: key, value: value, expiry: expiry}:
+	default:
+	}
+}
+
+func (v *victimCache[K, V]) run() {
+	for {
+		select {
+		case <-v.done:
+			return
+		case e := <-v.queue:
+			ctx, cancel := context.WithTimeout(BackgroundLane(context.Background()), asyncTimeout)
+			// Skip keys rewritten since eviction, whose spilled copy would be
+			// stale, and keys the victim store can't hold.
+			if !v.resident(e.key) && v.store.ValidateKey(e.key) == nil {
+				if err := v.store.Set(ctx, e.key, e.value, e.expiry); err != nil {
+					slog.Warn("victim spill failed", "key", e.key, "error", err)
+				} else {
+					v.spilled.Store(e.key, timeToSec(e.expiry))
+				}
+			}
+			cancel()
+			v.prune()
+		}
+	}
+}
+
+// prune drops expired keys from the spilled index once it grows past the queue size.
+func (v *victimCache[K, V]) prune() {
+	if v.spilled.Size() < victimQueueSize {
+		return
+	}
+	//nolint:gosec // G115: Unix seconds fit in uint32 until year 2106
+	now := uint32(time.Now().Unix())
+	v.spilled.Range(func(k K, exp uint32) bool {
+		if exp < now {
+			v.spilled.Delete(k)
+		}
+		return true
+	})
+}
+
+// load returns a spilled value, removing it from the victim store.
+// Only keys known to have been spilled are looked up, so ordinary misses cost nothing.
+func (v *victimCache[K, V]) load(ctx context.Context, key K) (V, time.Time, bool) {
+	var zero V
+	if _, ok := v.spilled.LoadAndDelete(key); !ok {
+		return zero, time.Time{}, false
+	}
+	val, expiry, found, err := v.store.Get(ctx, key)
+	if err != nil || !found {
+		return zero, time.Time{}, false
+	}
+	if err := v.store.Delete(ctx, key); err != nil {
+		slog.Warn("victim delete failed", "key", key, "error", err)
+	}
+	return val, expiry, true
+}
+
+// forget invalidates a spilled copy after key is written or deleted through the cache.
+func (v *victimCache[K, V]) forget(ctx context.Context, key K) {
+	if _, ok := v.spilled.LoadAndDelete(key); !ok {
+		return
+	}
+	if err := v.store.Delete(ctx, key); err != nil {
+		slog.Warn("victim delete failed", "key", key, "error", err)
+	}
+}
+
+// deleteFunc forgets every spilled entry for which fn returns true and returns their keys.
+func (v *victimCache[K, V]) deleteFunc(ctx context.Context, fn func(key K, value V) bool) []K {
+	var keys []K
+	v.spilled.Range(func(key K, _ uint32) bool {
+		val, _, found, err := v.store.Get(ctx, key)
+		if err == nil && found && fn(key, val) {
+			keys = append(keys, key)
+		}
+		return ctx.Err() == nil
+	})
+	for _, key := range keys {
+		v.forget(ctx, key)
+	}
+	return keys
+}
+
+func (v *victimCache[K, V]) flush(ctx context.Context) (int, error) {
+	v.spilled.Clear()
+	return v.store.Flush(ctx)
+}
+
+func (v *victimCache[K, V]) close() error {
+	close(v.done)
+	v.wg.Wait()
+	return v.store.Close()
+}
@@ -0,0 +1,155 @@
+package fido
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it returns true or the deadline passes.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTieredCache_Victim_ServesSpilledEntries(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	victim := newMockStore[string, int]()
+
+	cache, err := NewTiered[string, int](store, Victim[string, int](victim, time.Minute))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	cache.victim.spill("hot", 42, 0)
+	waitFor(t, func() bool { _, ok := cache.victim.spilled.Load("hot"); return ok })
+
+	// Main store is empty, so a hit must come from the victim store.
+	val, found, err := cache.Get(ctx, "hot")
+	if err != nil || !found || val != 42 {
+		t.Fatalf("Get(hot) = %d, %v, %v; want 42, true, nil", val, found, err)
+	}
+	if _, _, found, _ := victim.Get(ctx, "hot"); found { //nolint:errcheck // only checking presence
+		t.Error("restored entry should be removed from the victim store")
+	}
+	if _, ok := cache.memory.getEntry("hot"); !ok {
+		t.Error("restored entry should be back in memory")
+	}
+}
+
+func TestTieredCache_Victim_WriteInvalidatesSpill(t *testing.T) {
+	ctx := context.Background()
+	victim := newMockStore[string, int]()
+
+	cache, err := NewTiered[string, int](newMockStore[string, int](), Victim[string, int](victim, time.Minute))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	cache.victim.spill("k", 1, 0)
+	waitFor(t, func() bool { _, ok := cache.victim.spilled.Load("k"); return ok })
+
+	if err := cache.Set(ctx, "k", 2); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, _, found, _ := victim.Get(ctx, "k"); found { //nolint:errcheck // only checking presence
+		t.Error("write should invalidate the spilled copy")
+	}
+}
+
+func TestTieredCache_Victim_SpillsDeathRowEvictions(t *testing.T) {
+	victim := newMockStore[int, int]()
+
+	cache, err := NewTiered[int, int](newMockStore[int, int](), Size(100), Victim[int, int](victim, time.Minute))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	// Make everything hot so evictions pass through death row, then churn.
+	for i := range 2000 {
+		if err := cache.Set(context.Background(), i, i); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+		for range 3 {
+			cache.memory.get(i)
+		}
+	}
+
+	waitFor(t, func() bool { return cache.victim.spilled.Size() > 0 })
+	if n, _ := victim.Len(context.Background()); n == 0 { //nolint:errcheck // mock never fails
+		t.Error("death row evictions should be spilled to the victim store")
+	}
+}
+
+func TestTieredCache_Victim_SkipsMemoryOnly(t *testing.T) {
+	victim := newMockStore[int, int]()
+
+	cache, err := NewTiered[int, int](newMockStore[int, int](), Size(100), Victim[int, int](victim, time.Minute))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	// The churn of the test above, which spills plenty, but memory-only.
+	evicted, spilled := 0, 0
+	cache.memory.onDrop = func(int, uint64, int, uint32) { evicted++ }
+	spill := cache.memory.onEvict
+	cache.memory.onEvict = func(k, v int, exp uint32) {
+		spilled++
+		spill(k, v, exp)
+	}
+	for i := range 2000 {
+		if err := cache.SetMemoryOnly(context.Background(), i, i, 0); err != nil {
+			t.Fatalf("SetMemoryOnly: %v", err)
+		}
+		for range 3 {
+			cache.memory.get(i)
+		}
+	}
+	if evicted == 0 {
+		t.Fatal("nothing was evicted; the test doesn't exercise spilling")
+	}
+	if spilled != 0 {
+		t.Errorf("%d memory-only values were spilled; want none", spilled)
+	}
+	if n, _ := victim.Len(context.Background()); n != 0 { //nolint:errcheck // mock never fails
+		t.Errorf("victim store holds %d memory-only values; want none", n)
+	}
+}
+
+func TestTieredCache_Victim_DeleteFunc(t *testing.T) {
+	ctx := context.Background()
+	store := newMockStore[string, int]()
+	victim := newMockStore[string, int]()
+
+	cache, err := NewTiered[string, int](store, Victim[string, int](victim, time.Minute))
+	if err != nil {
+		t.Fatalf("NewTiered: %v", err)
+	}
+	defer func() { _ = cache.Close() }() //nolint:errcheck // Test cleanup
+
+	cache.victim.spill("a", 1, 0)
+	cache.victim.spill("b", 2, 0)
+	waitFor(t, func() bool { return cache.victim.spilled.Size() == 2 })
+
+	n, err := cache.DeleteFunc(ctx, func(_ string, v int) bool { return v == 1 })
+	if err != nil || n != 1 {
+		t.Fatalf("DeleteFunc = %d, %v; want 1, nil", n, err)
+	}
+	if _, found, _ := cache.Get(ctx, "a"); found { //nolint:errcheck // only checking presence
+		t.Error("matching spilled entry should be deleted")
+	}
+	if v, found, _ := cache.Get(ctx, "b"); !found || v != 2 { //nolint:errcheck // only checking presence
+		t.Errorf("Get(b) = %d, %v; want 2, true", v, found)
+	}
+}
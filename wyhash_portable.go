@@ -0,0 +1,10 @@
+//go:build !(386 || amd64 || arm64 || ppc64 || ppc64le || s390x) || purego || tinygo
+
+package fido
+
+// hashString hashes a string using wyhash, with byte loads only.
+// Selected on architectures where unaligned word loads fault or are slow
+// (e.g. arm, mips, riscv64, wasm), under TinyGo, and everywhere with -tags purego.
+func hashString(s string) uint64 {
+	return hashStringPortable(s)
+}
@@ -0,0 +1,40 @@
+package fido
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestHashString_Portable checks that the selected hashString matches the
+// byte-load implementation for every length and alignment up to 64 bytes.
+func TestHashString_Portable(t *testing.T) {
+	buf := strings.Repeat("0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ-_", 2)
+	for off := range 8 {
+		for n := range 65 {
+			s := buf[off : off+n]
+			if got, want := hashString(s), hashStringPortable(s); got != want {
+				t.Errorf("hashString(buf[%d:%d]) = %#x; portable = %#x", off, off+n, got, want)
+			}
+		}
+	}
+	if h := hashString(""); h != 0 {
+		t.Errorf("hashString(\"\") = %#x; want 0", h)
+	}
+}
+
+func BenchmarkHashString(b *testing.B) {
+	for _, n := range []int{3, 8, 16, 64} {
+		s := strings.Repeat("k", n)
+		b.Run("hashString/"+strconv.Itoa(n), func(b *testing.B) {
+			for range b.N {
+				hashString(s)
+			}
+		})
+		b.Run("portable/"+strconv.Itoa(n), func(b *testing.B) {
+			for range b.N {
+				hashStringPortable(s)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+//go:build (386 || amd64 || arm64 || ppc64 || ppc64le || s390x) && !purego && !tinygo
+
+package fido
+
+import "unsafe"
+
+// hashString hashes a string using wyhash.
+// Uses unsafe.Pointer for direct memory access - benchmarked 2.6x faster than maphash.String.
+// Replacing with maphash causes -12% string-get throughput, -16% getOrSet throughput.
+// Only built for architectures with fast unaligned loads; see hashStringPortable.
+func hashString(s string) uint64 {
+	n := len(s)
+	if n == 0 {
+		return 0
+	}
+
+	p := unsafe.Pointer(unsafe.StringData(s))
+	var a, b uint64
+
+	if n <= 8 {
+		if n >= 4 {
+			a = uint64(*(*uint32)(p))
+			b = uint64(*(*uint32)(unsafe.Add(p, n-4)))
+		} else {
+			a = uint64(*(*byte)(p))<<16 | uint64(*(*byte)(unsafe.Add(p, n>>1)))<<8 | uint64(*(*byte)(unsafe.Add(p, n-1)))
+			b = 0
+		}
+	} else {
+		a = *(*uint64)(p)
+		b = *(*uint64)(unsafe.Add(p, n-8))
+	}
+
+	return wymix(a, b, n)
+}